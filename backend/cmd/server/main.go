@@ -16,6 +16,88 @@
 //	    Maximum workflow execution time (default 1m)
 //	-max-node-executions int
 //	    Maximum node executions per workflow (default 10000)
+//	-store string
+//	    Workflow store backend: memory or file (default "memory")
+//	-data-dir string
+//	    Directory for the file workflow store (default "data/workflows")
+//	-execution-workers int
+//	    Concurrent asynchronous executions (default 4)
+//	-execution-queue int
+//	    Asynchronous executions that may wait for a worker (default 100)
+//	-execution-retention duration
+//	    How long finished asynchronous executions are kept (default 1h)
+//	-execution-event-buffer int
+//	    Events kept per execution for replay to late subscribers (default 1000)
+//	-history-store string
+//	    Execution history backend: memory or file (default "memory")
+//	-history-dir string
+//	    Directory for the file history store (default "data/executions")
+//	-history-retention duration
+//	    How long execution records are kept (default 168h)
+//	-history-max-records int
+//	    Maximum execution records kept, oldest pruned first (default 10000)
+//	-history-capture-outputs
+//	    Record node outputs in execution history (default true)
+//	-history-max-output-bytes int
+//	    Maximum bytes recorded per node output (default 4096)
+//	-schedule-store string
+//	    Workflow schedule backend: memory or file (default "memory")
+//	-schedule-dir string
+//	    Directory for the file schedule store (default "data/schedules")
+//	-schedule-misfire-threshold duration
+//	    How late a scheduled run may start before it counts as missed (default 1m)
+//	-schedule-max-catch-up int
+//	    Missed runs a catch_up schedule runs after downtime (default 10)
+//	-trigger-store string
+//	    Webhook trigger backend: memory or file (default "memory")
+//	-trigger-dir string
+//	    Directory for the file trigger store (default "data/triggers")
+//	-cors-origins string
+//	    Comma-separated origins allowed to call the API; "*" allows any (default "*")
+//	-api-keys-file string
+//	    JSON file of API keys and their roles; enables API key authentication
+//	-jwks string
+//	    JWKS file or URL used to verify bearer tokens; enables JWT authentication
+//	-jwks-refresh duration
+//	    How often a remote JWKS is fetched again (default 1h)
+//	-jwt-public-key string
+//	    PEM public key used to verify bearer tokens when no JWKS is set
+//	-jwt-issuer string
+//	    Required "iss" claim of bearer tokens
+//	-jwt-audience string
+//	    Required "aud" value of bearer tokens
+//	-jwt-roles-claim string
+//	    Claim holding the caller's roles (default "roles")
+//	-jwt-tenant-claim string
+//	    Claim holding the caller's tenant (default "tenant")
+//	-jwt-allow-no-exp
+//	    Accept bearer tokens without an "exp" claim
+//	-tenant-quotas-file string
+//	    JSON file of per-tenant execution quotas
+//	-event-capture-inputs
+//	    Include resolved node inputs in execution events and streams
+//	-event-max-bytes int
+//	    Maximum bytes of each node input and output in execution events; 0 keeps them whole
+//	-redact-keys string
+//	    Comma-separated patterns of object keys masked in execution data (default password, token, authorization, ...)
+//	-execution-profiling
+//	    Profile executions: per-node timings and the critical path in results and at /api/v1/executions/{id}/profile (default true)
+//	-max-debug-session-timeout duration
+//	    Longest a debugged execution may stay paused waiting for a command (default 10m0s)
+//	-otel-exporter string
+//	    Trace exporter: none, otlp-grpc or otlp-http (default "none")
+//	-otel-endpoint string
+//	    OTLP collector host:port or URL (default from OTEL_EXPORTER_OTLP_ENDPOINT)
+//	-otel-insecure
+//	    Export traces without TLS
+//	-otel-headers string
+//	    Comma-separated key=value headers sent with every trace export
+//	-otel-sample-ratio float
+//	    Fraction of new traces sampled, from 0 to 1 (default 1)
+//	-metrics-workflow-ids string
+//	    Comma-separated workflow IDs metrics are labelled with; others are "other", "*" allows any
+//	-metrics-http-clients string
+//	    Comma-separated HTTP client UIDs metrics are labelled with; others are "other", "*" allows any
 //
 // Example:
 //
@@ -25,21 +107,91 @@
 //	# Start server on custom port with strict limits
 //	server -addr :9090 -max-execution-time 30s -max-node-executions 1000
 //
+//	# Keep saved workflows across restarts
+//	server -store file -data-dir /var/lib/thaiyyal/workflows
+//
+//	# Keep execution history across restarts without node outputs
+//	server -history-store file -history-dir /var/lib/thaiyyal/executions -history-capture-outputs=false
+//
+//	# Keep workflow schedules across restarts
+//	server -store file -schedule-store file -schedule-dir /var/lib/thaiyyal/schedules
+//
+//	# Keep webhook triggers, and their signing secrets, across restarts
+//	server -store file -trigger-store file -trigger-dir /var/lib/thaiyyal/triggers
+//
+//	# Require API keys or tokens from the identity provider, and only accept
+//	# browser calls from the UI's origin
+//	server -api-keys-file /etc/thaiyyal/api-keys.json \
+//	    -jwks https://idp.example.com/.well-known/jwks.json -jwt-audience thaiyyal \
+//	    -cors-origins https://thaiyyal.example.com
+//
+//	# Export a span per workflow and node to a local OpenTelemetry collector
+//	server -otel-exporter otlp-grpc -otel-endpoint localhost:4317 -otel-insecure
+//
+//	# Break workflow and HTTP metrics down for a few critical workflows
+//	server -metrics-workflow-ids orders,billing -metrics-http-clients payments-api
+//
+// Without -api-keys-file, -jwks or -jwt-public-key every API endpoint is
+// open. Once any is set, API endpoints require credentials and a role
+// granting the endpoint's permission; health checks, metrics and the UI
+// stay public. Webhooks under /hooks/ never take API credentials; triggers
+// with a secret only accept requests signed with it.
+//
+// Each caller belongs to the tenant named by its API key or token, or to
+// the "default" tenant. Tenants only see their own workflows, schedules,
+// triggers, HTTP clients, executions and history, and -tenant-quotas-file can cap their
+// concurrent executions, executions per minute and workflow size.
+//
 // The server exposes the following endpoints:
 //
 //	POST   /api/v1/workflow/execute        - Execute a workflow
 //	POST   /api/v1/workflow/validate       - Validate a workflow
-//	POST   /api/v1/workflow/save           - Save a workflow
-//	GET    /api/v1/workflow/list           - List all saved workflows
+//	POST   /api/v1/workflow/plan           - Dry-run a workflow without side effects
+//	POST   /api/v1/workflow/save           - Save a workflow (or a new version when "id" is set)
+//	GET    /api/v1/workflow/list           - List saved workflows (?q=&sort=&order=&offset=&limit=)
 //	GET    /api/v1/workflow/load/{id}      - Load a workflow by ID
 //	DELETE /api/v1/workflow/delete/{id}    - Delete a workflow by ID
-//	POST   /api/v1/workflow/execute/{id}   - Execute a workflow by ID
+//	POST   /api/v1/workflow/execute/{id}   - Execute a workflow by ID (?version=N to pin a version, body {"inputs": {...}})
+//	GET    /api/v1/workflow/versions/{id}  - List versions of a workflow
+//	GET    /api/v1/workflow/versions/{id}/{version} - Get a specific version
+//	GET    /api/v1/workflow/diff/{id}      - Diff two versions (?from=N&to=M)
+//	POST   /api/v1/workflow/rollback/{id}  - Roll back to an earlier version
+//	GET    /api/v1/workflow/export/{id}    - Export a workflow bundle with its HTTP clients (?version=N)
+//	POST   /api/v1/workflow/import         - Import a workflow bundle
+//	POST   /api/v1/executions              - Start an asynchronous execution
+//	GET    /api/v1/executions              - List asynchronous executions
+//	GET    /api/v1/executions/{id}         - Get execution status, node progress and result
+//	DELETE /api/v1/executions/{id}         - Cancel an execution
+//	GET    /api/v1/executions/{id}/events  - Stream execution events (Server-Sent Events)
+//	GET    /api/v1/executions/{id}/profile - Get an execution's timing profile (?format=folded for flame graphs)
+//	GET    /api/v1/executions/{id}/debug   - Get a debugged execution's pause point, results, variables and context
+//	POST   /api/v1/executions/{id}/debug   - Step, continue, set a variable of or snapshot a paused execution
+//	GET    /api/v1/history                 - Search execution history (?workflow_id=&status=&from=&to=&offset=&limit=)
+//	GET    /api/v1/history/{id}            - Get an execution record with its node trace
+//	GET    /api/v1/schedules               - List workflow schedules (?workflow_id=)
+//	POST   /api/v1/schedules               - Schedule a saved workflow with a cron expression
+//	GET    /api/v1/schedules/{id}          - Get a schedule with its upcoming fire times (?next=N)
+//	PUT    /api/v1/schedules/{id}          - Replace a schedule's settings
+//	DELETE /api/v1/schedules/{id}          - Delete a schedule
+//	POST   /api/v1/schedules/{id}/pause    - Pause a schedule
+//	POST   /api/v1/schedules/{id}/resume   - Resume a paused schedule
+//	GET    /api/v1/triggers                - List webhook triggers (?workflow_id=)
+//	POST   /api/v1/triggers                - Expose a saved workflow at a webhook URL
+//	GET    /api/v1/triggers/{id}           - Get a webhook trigger
+//	PUT    /api/v1/triggers/{id}           - Replace a webhook trigger's settings
+//	DELETE /api/v1/triggers/{id}           - Delete a webhook trigger
+//	*      /hooks/{id}                     - Run a trigger's workflow with the request as input
+//	GET    /api/v1/tenant                  - Get the caller's tenant, quota and usage
 //	POST   /api/v1/httpclient/register     - Register an HTTP client
 //	GET    /api/v1/httpclient/list         - List registered HTTP clients
+//	GET    /api/v1/openapi.json            - OpenAPI 3 document of the API
 //	GET    /health                         - Health check
 //	GET    /health/live                    - Liveness probe
 //	GET    /health/ready                   - Readiness probe
 //	GET    /metrics                        - Prometheus metrics
+//
+// Execution endpoints honour W3C traceparent headers: the execution's
+// spans join the caller's trace, and HTTP nodes pass the trace on.
 package main
 
 import (
@@ -48,10 +200,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/history"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/server"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/telemetry"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/tenant"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -64,17 +222,120 @@ func main() {
 	maxNodeExecutions := flag.Int("max-node-executions", 10000, "Maximum node executions per workflow")
 	maxHTTPCalls := flag.Int("max-http-calls", 100, "Maximum HTTP calls per execution")
 	maxLoopIterations := flag.Int("max-loop-iterations", 10000, "Maximum loop iterations")
+	store := flag.String("store", server.StoreMemory, "Workflow store backend: memory or file")
+	dataDir := flag.String("data-dir", "data/workflows", "Directory for the file workflow store")
+	executionWorkers := flag.Int("execution-workers", 4, "Concurrent asynchronous executions")
+	executionQueue := flag.Int("execution-queue", 100, "Asynchronous executions that may wait for a worker")
+	executionRetention := flag.Duration("execution-retention", time.Hour, "How long finished asynchronous executions are kept")
+	executionEventBuffer := flag.Int("execution-event-buffer", 1000, "Events kept per execution for replay to late subscribers")
+	historyStore := flag.String("history-store", server.StoreMemory, "Execution history backend: memory or file")
+	historyDir := flag.String("history-dir", "data/executions", "Directory for the file history store")
+	historyRetention := flag.Duration("history-retention", 7*24*time.Hour, "How long execution records are kept")
+	historyMaxRecords := flag.Int("history-max-records", 10000, "Maximum execution records kept, oldest pruned first")
+	historyCaptureOutputs := flag.Bool("history-capture-outputs", true, "Record node outputs in execution history")
+	historyMaxOutputBytes := flag.Int("history-max-output-bytes", history.DefaultMaxOutputBytes, "Maximum bytes recorded per node output")
+	scheduleStore := flag.String("schedule-store", server.StoreMemory, "Workflow schedule backend: memory or file")
+	scheduleDir := flag.String("schedule-dir", "data/schedules", "Directory for the file schedule store")
+	scheduleMisfireThreshold := flag.Duration("schedule-misfire-threshold", time.Minute, "How late a scheduled run may start before it counts as missed")
+	scheduleMaxCatchUp := flag.Int("schedule-max-catch-up", 10, "Missed runs a catch_up schedule runs after downtime")
+	triggerStore := flag.String("trigger-store", server.StoreMemory, "Webhook trigger backend: memory or file")
+	triggerDir := flag.String("trigger-dir", "data/triggers", "Directory for the file trigger store")
+	corsOrigins := flag.String("cors-origins", "*", `Comma-separated origins allowed to call the API; "*" allows any`)
+	apiKeysFile := flag.String("api-keys-file", "", "JSON file of API keys and their roles; enables API key authentication")
+	jwks := flag.String("jwks", "", "JWKS file or URL used to verify bearer tokens; enables JWT authentication")
+	jwksRefresh := flag.Duration("jwks-refresh", auth.DefaultJWKSRefresh, "How often a remote JWKS is fetched again")
+	jwtPublicKey := flag.String("jwt-public-key", "", "PEM public key used to verify bearer tokens when no JWKS is set")
+	jwtIssuer := flag.String("jwt-issuer", "", `Required "iss" claim of bearer tokens`)
+	jwtAudience := flag.String("jwt-audience", "", `Required "aud" value of bearer tokens`)
+	jwtRolesClaim := flag.String("jwt-roles-claim", "roles", "Claim holding the caller's roles")
+	jwtTenantClaim := flag.String("jwt-tenant-claim", "tenant", "Claim holding the caller's tenant")
+	jwtAllowNoExp := flag.Bool("jwt-allow-no-exp", false, `Accept bearer tokens without an "exp" claim`)
+	tenantQuotasFile := flag.String("tenant-quotas-file", "", "JSON file of per-tenant execution quotas")
+	eventCaptureInputs := flag.Bool("event-capture-inputs", false, "Include resolved node inputs in execution events and streams")
+	eventMaxBytes := flag.Int("event-max-bytes", 0, "Maximum bytes of each node input and output in execution events; 0 keeps them whole")
+	redactKeys := flag.String("redact-keys", "", "Comma-separated patterns of object keys masked in execution data (default password, token, authorization, ...)")
+	executionProfiling := flag.Bool("execution-profiling", true, "Profile executions: per-node timings and the critical path in results and at /api/v1/executions/{id}/profile")
+	maxDebugSessionTimeout := flag.Duration("max-debug-session-timeout", engine.DefaultDebugSessionTimeout, "Longest a debugged execution may stay paused waiting for a command")
+	otelExporter := flag.String("otel-exporter", telemetry.TraceExporterNone, "Trace exporter: none, otlp-grpc or otlp-http")
+	otelEndpoint := flag.String("otel-endpoint", "", "OTLP collector host:port or URL (default from OTEL_EXPORTER_OTLP_ENDPOINT)")
+	otelInsecure := flag.Bool("otel-insecure", false, "Export traces without TLS")
+	otelHeaders := flag.String("otel-headers", "", "Comma-separated key=value headers sent with every trace export")
+	otelSampleRatio := flag.Float64("otel-sample-ratio", 1, "Fraction of new traces sampled, from 0 to 1")
+	metricsWorkflowIDs := flag.String("metrics-workflow-ids", "", `Comma-separated workflow IDs metrics are labelled with; others are "other", "*" allows any`)
+	metricsHTTPClients := flag.String("metrics-http-clients", "", `Comma-separated HTTP client UIDs metrics are labelled with; others are "other", "*" allows any`)
 
 	flag.Parse()
 
+	var tenantQuotas tenant.Quotas
+	if *tenantQuotasFile != "" {
+		quotas, err := tenant.LoadQuotas(*tenantQuotasFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load tenant quotas: %v\n", err)
+			os.Exit(1)
+		}
+		tenantQuotas = quotas
+	}
+
+	telemetryConfig := telemetry.DefaultConfig()
+	telemetryConfig.TraceExporter = *otelExporter
+	telemetryConfig.OTLPEndpoint = *otelEndpoint
+	telemetryConfig.OTLPInsecure = *otelInsecure
+	telemetryConfig.SampleRatio = *otelSampleRatio
+	telemetryConfig.MetricWorkflowIDs = splitList(*metricsWorkflowIDs)
+	telemetryConfig.MetricHTTPClients = splitList(*metricsHTTPClients)
+	headers, err := parseHeaders(*otelHeaders)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -otel-headers: %v\n", err)
+		os.Exit(1)
+	}
+	telemetryConfig.OTLPHeaders = headers
+
 	// Create server config
 	serverConfig := server.Config{
-		Address:            *addr,
-		ReadTimeout:        *readTimeout,
-		WriteTimeout:       *writeTimeout,
-		ShutdownTimeout:    10 * time.Second,
-		MaxRequestBodySize: 10 * 1024 * 1024, // 10MB
-		EnableCORS:         true,
+		Address:                  *addr,
+		ReadTimeout:              *readTimeout,
+		WriteTimeout:             *writeTimeout,
+		ShutdownTimeout:          10 * time.Second,
+		MaxRequestBodySize:       10 * 1024 * 1024, // 10MB
+		EnableCORS:               true,
+		CORSAllowedOrigins:       splitList(*corsOrigins),
+		WorkflowStore:            *store,
+		DataDir:                  *dataDir,
+		ExecutionWorkers:         *executionWorkers,
+		ExecutionQueueSize:       *executionQueue,
+		ExecutionRetention:       *executionRetention,
+		ExecutionEventBuffer:     *executionEventBuffer,
+		HistoryStore:             *historyStore,
+		HistoryDir:               *historyDir,
+		HistoryRetention:         *historyRetention,
+		HistoryMaxRecords:        *historyMaxRecords,
+		HistoryCaptureOutputs:    *historyCaptureOutputs,
+		HistoryMaxOutputBytes:    *historyMaxOutputBytes,
+		ScheduleStore:            *scheduleStore,
+		ScheduleDir:              *scheduleDir,
+		ScheduleMisfireThreshold: *scheduleMisfireThreshold,
+		ScheduleMaxCatchUp:       *scheduleMaxCatchUp,
+		TriggerStore:             *triggerStore,
+		TriggerDir:               *triggerDir,
+		Auth: auth.Config{
+			APIKeysFile:        *apiKeysFile,
+			JWKS:               *jwks,
+			JWKSRefresh:        *jwksRefresh,
+			JWTPublicKeyFile:   *jwtPublicKey,
+			Issuer:             *jwtIssuer,
+			Audience:           *jwtAudience,
+			RolesClaim:         *jwtRolesClaim,
+			TenantClaim:        *jwtTenantClaim,
+			Leeway:             30 * time.Second,
+			AllowMissingExpiry: *jwtAllowNoExp,
+		},
+		TenantQuotas:           tenantQuotas,
+		EventCaptureInputs:     *eventCaptureInputs,
+		EventMaxBytes:          *eventMaxBytes,
+		RedactKeys:             splitList(*redactKeys),
+		ExecutionProfiling:     *executionProfiling,
+		MaxDebugSessionTimeout: *maxDebugSessionTimeout,
+		Telemetry:              telemetryConfig,
 	}
 
 	// Create engine config
@@ -134,3 +395,27 @@ func main() {
 		fmt.Println("Server stopped")
 	}
 }
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseHeaders parses comma-separated key=value pairs
+func parseHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, item := range splitList(value) {
+		key, val, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%q is not key=value", item)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return headers, nil
+}
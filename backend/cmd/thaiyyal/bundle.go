@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/bundle"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/server"
+)
+
+// runExport writes a workflow bundle to a file or stdout
+func runExport(e *env, args []string) error {
+	flags := newFlagSet(e, "export", "<workflow-id>")
+	version := flags.Int("version", 0, "Workflow version to export (default latest)")
+	output := flags.String("o", "", "Write the bundle to this file instead of stdout")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected one workflow ID")
+	}
+
+	path := "/api/v1/workflow/export/" + url.PathEscape(flags.Arg(0))
+	if *version > 0 {
+		path += fmt.Sprintf("?version=%d", *version)
+	}
+	data, err := e.call(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = e.stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o600); err != nil {
+		return err
+	}
+
+	var b bundle.Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "Exported %q version %d with %d HTTP client(s) to %s\n",
+		b.Workflow.Name, b.Workflow.Version, len(b.HTTPClients), *output)
+	for _, s := range b.Secrets {
+		fmt.Fprintf(e.stderr, "  secret %s (%s %s)\n", s.Name, s.ClientUID, s.Field)
+	}
+	return nil
+}
+
+// secretFlags collects repeated -secret name=value flags
+type secretFlags map[string]string
+
+func (s secretFlags) String() string {
+	return fmt.Sprintf("%d secret(s)", len(s))
+}
+
+func (s secretFlags) Set(value string) error {
+	name, secret, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	s[name] = secret
+	return nil
+}
+
+// runImport sends a bundle file to the server and prints what was done
+func runImport(e *env, args []string) error {
+	flags := newFlagSet(e, "import", "<bundle.json>")
+	policy := flags.String("policy", string(bundle.PolicyFail), "Conflict policy: fail, overwrite, rename or skip")
+	secrets := secretFlags{}
+	flags.Var(secrets, "secret", "Secret value as name=value (repeatable)")
+	secretsFile := flags.String("secrets-file", "", "JSON file of secret values by name")
+	dryRun := flags.Bool("dry-run", false, "Show what would be imported without changing anything")
+	author := flags.String("author", os.Getenv("USER"), "Author recorded on the imported version")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected one bundle file")
+	}
+	if _, err := bundle.ParsePolicy(*policy); err != nil {
+		return usagef("%v", err)
+	}
+
+	req := server.ImportWorkflowRequest{
+		Policy:  *policy,
+		Secrets: map[string]string{},
+		DryRun:  *dryRun,
+		Author:  *author,
+	}
+	if *secretsFile != "" {
+		data, err := os.ReadFile(*secretsFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &req.Secrets); err != nil {
+			return fmt.Errorf("secrets file %s: %w", *secretsFile, err)
+		}
+	}
+	for name, value := range secrets {
+		req.Secrets[name] = value
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &req.Bundle); err != nil {
+		return fmt.Errorf("bundle %s: %w", flags.Arg(0), err)
+	}
+
+	data, err = e.call(http.MethodPost, "/api/v1/workflow/import", req)
+	if err != nil {
+		return err
+	}
+	var resp server.ImportWorkflowResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+
+	result := resp.Result
+	if result.DryRun {
+		fmt.Fprintln(e.stdout, "Dry run, nothing was changed:")
+	}
+	fmt.Fprintf(e.stdout, "workflow     %-9s %s", result.Workflow.Action, result.Workflow.Name)
+	if result.Workflow.ID != "" {
+		fmt.Fprintf(e.stdout, " (%s version %d)", result.Workflow.ID, result.Workflow.Version)
+	}
+	fmt.Fprintln(e.stdout)
+	for _, c := range result.HTTPClients {
+		fmt.Fprintf(e.stdout, "http client  %-9s %s", c.Action, c.UID)
+		if c.UID != c.SourceUID {
+			fmt.Fprintf(e.stdout, " (from %s)", c.SourceUID)
+		}
+		fmt.Fprintln(e.stdout)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+)
+
+// apiError is a non-2xx response from the server
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.status, e.message)
+}
+
+// call sends a request to the server API and returns the response body.
+// A non-2xx response is returned as an *apiError carrying the server's
+// error message.
+func (e *env) call(method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(e.server, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.apiKey != "" {
+		req.Header.Set(auth.APIKeyHeader, e.apiKey)
+	}
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &apiError{status: resp.StatusCode, message: errorMessage(data)}
+	}
+	return data, nil
+}
+
+// errorMessage extracts the error of a JSON error response, falling back
+// to the raw body
+func errorMessage(data []byte) string {
+	var resp struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Error == "" {
+		return strings.TrimSpace(string(data))
+	}
+	if resp.Details != "" {
+		return resp.Error + ": " + resp.Details
+	}
+	return resp.Error
+}
@@ -0,0 +1,607 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/config"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/graph"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/logging"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// configPresets are the engine configurations -config accepts by name
+var configPresets = map[string]func() types.Config{
+	"default":    types.DefaultConfig,
+	"dev":        types.DevelopmentConfig,
+	"prod":       func() types.Config { return *config.Production() },
+	"test":       func() types.Config { return *config.Testing() },
+	"zero-trust": types.ZeroTrustConfig,
+}
+
+// loadConfig returns the engine configuration named by a -config value:
+// a preset or a JSON file of Config fields applied over the defaults
+func loadConfig(name string) (types.Config, error) {
+	if preset, ok := configPresets[name]; ok {
+		return preset(), nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return types.Config{}, usagef("unknown config %q: expected default, dev, prod, test, zero-trust or a JSON file", name)
+		}
+		return types.Config{}, err
+	}
+	cfg := types.DefaultConfig()
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return types.Config{}, &invalidError{fmt.Errorf("config %s: %w", name, err)}
+	}
+	if err := cfg.Validate(); err != nil {
+		return types.Config{}, &invalidError{fmt.Errorf("config %s: %w", name, err)}
+	}
+	return cfg, nil
+}
+
+// readInput reads a file, or stdin when path is "-"
+func readInput(e *env, path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// inputFlags collects repeated -input name=value flags. Values that parse
+// as JSON are used decoded, anything else as a string.
+type inputFlags map[string]interface{}
+
+func (f inputFlags) String() string {
+	return fmt.Sprintf("%d input(s)", len(f))
+}
+
+func (f inputFlags) Set(value string) error {
+	name, raw, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		decoded = raw
+	}
+	f[name] = decoded
+	return nil
+}
+
+// dirSource runs sub_workflow nodes from <dir>/<id>.json files. Files have
+// no history: only the latest version can be asked for, and it is 1.
+type dirSource string
+
+func (d dirSource) Workflow(id string, version int) ([]byte, int, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return nil, 0, fmt.Errorf("invalid workflow ID %q", id)
+	}
+	if version > 1 {
+		return nil, 0, fmt.Errorf("workflow %s: files have no version %d", id, version)
+	}
+	data, err := os.ReadFile(filepath.Join(string(d), id+".json"))
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, 1, nil
+}
+
+// execFlags are the flags of the commands that execute workflows
+type execFlags struct {
+	format    *string
+	timeout   *time.Duration
+	workflows *string
+	snapshot  *string
+	profile   *string
+	logLevel  *string
+}
+
+// addExecFlags registers the execution flags on flags
+func addExecFlags(flags *flag.FlagSet) *execFlags {
+	return &execFlags{
+		format:    flags.String("format", "json", "Output format: json or table"),
+		timeout:   flags.Duration("timeout", 0, "Maximum execution time (default from the config)"),
+		workflows: flags.String("workflows", "", "Directory of <id>.json workflows that sub_workflow nodes run"),
+		snapshot:  flags.String("snapshot", "", "Write a snapshot of the execution state to this file"),
+		profile:   flags.String("profile", "", "Profile the execution and write it to this file as folded stacks for flame graph tools"),
+		logLevel:  flags.String("log-level", "error", "Engine log level on stderr: debug, info, warn or error"),
+	}
+}
+
+// check rejects invalid flag values before anything runs
+func (f *execFlags) check() error {
+	if *f.format != "json" && *f.format != "table" {
+		return usagef("unknown format %q: expected json or table", *f.format)
+	}
+	return nil
+}
+
+// applyTimeout sets the -timeout flag on cfg
+func (f *execFlags) applyTimeout(cfg *types.Config) {
+	if *f.timeout > 0 {
+		cfg.MaxExecutionTime = *f.timeout
+	}
+}
+
+// execute runs eng until it finishes, times out or the process is
+// interrupted, writes the snapshot and profile if asked to and prints the
+// result. A failed execution still prints its partial result.
+func (f *execFlags) execute(e *env, eng *engine.Engine, nodes []types.Node, edges []types.Edge) error {
+	eng.SetStructuredLogger(logging.New(logging.Config{Level: *f.logLevel, Output: e.stderr}))
+	if *f.workflows != "" {
+		eng.SetWorkflowSource(dirSource(*f.workflows))
+	}
+	if *f.profile != "" {
+		eng.SetProfiling(true)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, runErr := eng.ExecuteContext(ctx)
+
+	if *f.snapshot != "" {
+		if err := writeSnapshot(eng, *f.snapshot); err != nil {
+			return err
+		}
+	}
+	if *f.profile != "" && result != nil && result.Profile != nil {
+		if err := writeProfile(result, *f.profile); err != nil {
+			return err
+		}
+	}
+	if result != nil {
+		if err := printResult(e.stdout, *f.format, result, nodes, edges); err != nil {
+			return err
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("workflow failed: %w", runErr)
+	}
+	return nil
+}
+
+// writeSnapshot saves eng's execution state to path
+func writeSnapshot(eng *engine.Engine, path string) error {
+	snapshot, err := eng.SaveSnapshot()
+	if err != nil {
+		return err
+	}
+	data, err := engine.SerializeSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// writeProfile saves result's profile to path as folded stacks
+func writeProfile(result *types.Result, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := result.Profile.WriteFolded(file, result.WorkflowID); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runRun executes a workflow file and prints its result
+func runRun(e *env, args []string) error {
+	flags := newFlagSet(e, "run", "<workflow.json | ->")
+	inputs := inputFlags{}
+	flags.Var(inputs, "input", "Workflow input as name=value; JSON values are decoded (repeatable)")
+	inputsFile := flags.String("inputs-file", "", "JSON file of workflow inputs by name")
+	configName := flags.String("config", "default", "Engine config: default, dev, prod, test, zero-trust or a JSON file")
+	exec := addExecFlags(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected one workflow file")
+	}
+	if err := exec.check(); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configName)
+	if err != nil {
+		return err
+	}
+	exec.applyTimeout(&cfg)
+	payload, err := readBoundInput(e, flags.Arg(0), *inputsFile, inputs)
+	if err != nil {
+		return err
+	}
+	eng, wf, err := loadWorkflow(payload, cfg)
+	if err != nil {
+		return err
+	}
+	return exec.execute(e, eng, wf.Nodes, wf.Edges)
+}
+
+// readBoundInput reads the workflow at path with its inputs bound to the
+// values of the inputs file, overridden by the -input flags
+func readBoundInput(e *env, path, inputsFile string, inputs inputFlags) ([]byte, error) {
+	values := map[string]interface{}{}
+	if inputsFile != "" {
+		data, err := os.ReadFile(inputsFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, &invalidError{fmt.Errorf("inputs file %s: %w", inputsFile, err)}
+		}
+	}
+	for name, value := range inputs {
+		values[name] = value
+	}
+
+	payload, err := readInput(e, path)
+	if err != nil {
+		return nil, err
+	}
+	payload, err = params.Bind(payload, values)
+	if err != nil {
+		return nil, &invalidError{err}
+	}
+	return payload, nil
+}
+
+// runPlan prints what a workflow would do without running anything with
+// side effects: the nodes it would run, those it would skip and why, and
+// those whose path depends on runtime data
+func runPlan(e *env, args []string) error {
+	flags := newFlagSet(e, "plan", "<workflow.json | ->")
+	inputs := inputFlags{}
+	flags.Var(inputs, "input", "Workflow input as name=value; JSON values are decoded (repeatable)")
+	inputsFile := flags.String("inputs-file", "", "JSON file of workflow inputs by name")
+	configName := flags.String("config", "default", "Engine config: default, dev, prod, test, zero-trust or a JSON file")
+	format := flags.String("format", "text", "Output format: text or json")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected one workflow file")
+	}
+	if *format != "text" && *format != "json" {
+		return usagef("unknown format %q: expected text or json", *format)
+	}
+
+	cfg, err := loadConfig(*configName)
+	if err != nil {
+		return err
+	}
+	payload, err := readBoundInput(e, flags.Arg(0), *inputsFile, inputs)
+	if err != nil {
+		return err
+	}
+	eng, err := engine.NewWithConfig(payload, cfg)
+	if err != nil {
+		return &invalidError{err}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	plan, err := eng.Plan(ctx)
+	if errors.Is(err, engine.ErrExecutionTimeout) || errors.Is(err, engine.ErrExecutionCanceled) {
+		return err
+	}
+	if err != nil {
+		return &invalidError{err}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(e.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plan)
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tTYPE\tSTATUS\tDETAIL")
+	for _, node := range plan.Nodes {
+		var details []string
+		if node.Reason != "" {
+			details = append(details, node.Reason)
+		}
+		if node.Effect != nil {
+			details = append(details, "would "+summarize(node.Effect))
+		}
+		if node.Error != "" {
+			details = append(details, "fails: "+node.Error)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", node.NodeID, node.NodeType, node.Status, strings.Join(details, "; "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "\n%d to run, %d skipped, %d decided at runtime\n", len(plan.Run), len(plan.Skipped), len(plan.Runtime))
+	return nil
+}
+
+// loadWorkflow creates an engine for payload and checks the workflow. The
+// decoded payload is returned for printing.
+func loadWorkflow(payload []byte, cfg types.Config) (*engine.Engine, *types.Payload, error) {
+	eng, err := engine.NewWithConfig(payload, cfg)
+	if err != nil {
+		return nil, nil, &invalidError{err}
+	}
+	if err := eng.Validate(); err != nil {
+		return nil, nil, &invalidError{err}
+	}
+	var wf types.Payload
+	if err := json.Unmarshal(payload, &wf); err != nil {
+		return nil, nil, &invalidError{err}
+	}
+	return eng, &wf, nil
+}
+
+// runSnapshot dispatches the snapshot subcommands
+func runSnapshot(e *env, args []string) error {
+	if len(args) == 0 || args[0] != "resume" {
+		return usagef("expected: snapshot resume [flags] <snapshot.json>")
+	}
+	return runSnapshotResume(e, args[1:])
+}
+
+// runSnapshotResume executes the workflow of a snapshot written by run
+// -snapshot, from the state it recorded. Like engine.ExecuteFromSnapshot,
+// but the run can time out and be interrupted.
+func runSnapshotResume(e *env, args []string) error {
+	flags := newFlagSet(e, "snapshot resume", "<snapshot.json | ->")
+	exec := addExecFlags(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected one snapshot file")
+	}
+	if err := exec.check(); err != nil {
+		return err
+	}
+
+	data, err := readInput(e, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	snapshot, err := engine.DeserializeSnapshot(data)
+	if err != nil {
+		return &invalidError{err}
+	}
+	exec.applyTimeout(&snapshot.Config)
+	eng, err := engine.LoadSnapshot(snapshot, nil)
+	if err != nil {
+		return &invalidError{err}
+	}
+	return exec.execute(e, eng, snapshot.Nodes, snapshot.Edges)
+}
+
+// printResult writes result as indented JSON, or as a table of node
+// results in execution order followed by the final output and errors
+func printResult(w io.Writer, format string, result *types.Result, nodes []types.Node, edges []types.Edge) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tRESULT")
+	for _, id := range resultOrder(result, nodes, edges) {
+		fmt.Fprintf(tw, "%s\t%s\n", id, summarize(result.NodeResults[id]))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nFinal output: %s\n", summarize(result.FinalOutput))
+	fmt.Fprintf(w, "Execution ID: %s\n", result.ExecutionID)
+	for _, msg := range result.Errors {
+		fmt.Fprintf(w, "Error: %s\n", msg)
+	}
+	return nil
+}
+
+// resultOrder returns the IDs of the nodes with results in execution
+// order; results of nodes not in the graph follow, sorted
+func resultOrder(result *types.Result, nodes []types.Node, edges []types.Edge) []string {
+	order, _ := graph.New(nodes, edges).TopologicalSort()
+	ids := make([]string, 0, len(result.NodeResults))
+	seen := make(map[string]bool, len(order))
+	for _, id := range order {
+		seen[id] = true
+		if _, ok := result.NodeResults[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	var rest []string
+	for id := range result.NodeResults {
+		if !seen[id] {
+			rest = append(rest, id)
+		}
+	}
+	sort.Strings(rest)
+	return append(ids, rest...)
+}
+
+// maxSummary is the longest value printed in a table cell
+const maxSummary = 72
+
+// summarize renders a value as compact JSON cut to maxSummary runes
+func summarize(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	text := []rune(string(data))
+	if len(text) > maxSummary {
+		return string(text[:maxSummary-3]) + "..."
+	}
+	return string(text)
+}
+
+// runValidate checks workflow files without running them and reports
+// every problem found
+func runValidate(e *env, args []string) error {
+	flags := newFlagSet(e, "validate", "<workflow.json | ->...")
+	configName := flags.String("config", "default", "Engine config: default, dev, prod, test, zero-trust or a JSON file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("expected at least one workflow file")
+	}
+	cfg, err := loadConfig(*configName)
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, path := range flags.Args() {
+		payload, err := readInput(e, path)
+		if err == nil {
+			err = validatePayload(payload, cfg)
+		}
+		if err == nil {
+			fmt.Fprintf(e.stdout, "%s: valid\n", path)
+			continue
+		}
+		invalid++
+		fmt.Fprintf(e.stdout, "%s: invalid\n", path)
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(e.stdout, "  %s\n", line)
+		}
+	}
+	if invalid > 0 {
+		return &invalidError{fmt.Errorf("%d of %d workflow(s) invalid", invalid, flags.NArg())}
+	}
+	return nil
+}
+
+// validatePayload checks a workflow's input schema and graph
+func validatePayload(payload []byte, cfg types.Config) error {
+	if _, err := params.Schema(payload); err != nil {
+		return err
+	}
+	_, _, err := loadWorkflow(payload, cfg)
+	return err
+}
+
+// graphOutput is the JSON output of the graph command
+type graphOutput struct {
+	Order  []string   `json:"order"`
+	Levels [][]string `json:"levels"`
+}
+
+// runGraph prints a workflow's execution order and the levels of nodes
+// that do not depend on each other
+func runGraph(e *env, args []string) error {
+	flags := newFlagSet(e, "graph", "<workflow.json | ->")
+	format := flags.String("format", "text", "Output format: text or json")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected one workflow file")
+	}
+	if *format != "text" && *format != "json" {
+		return usagef("unknown format %q: expected text or json", *format)
+	}
+
+	payload, err := readInput(e, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var wf types.Payload
+	if err := json.Unmarshal(payload, &wf); err != nil {
+		return &invalidError{err}
+	}
+	g := graph.New(wf.Nodes, wf.Edges)
+	order, err := g.TopologicalSort()
+	if err != nil {
+		return &invalidError{err}
+	}
+	levels, err := g.ExecutionLevels()
+	if err != nil {
+		return &invalidError{err}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(e.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(graphOutput{Order: order, Levels: levels})
+	}
+
+	nodeTypes := make(map[string]types.NodeType, len(wf.Nodes))
+	for _, node := range wf.Nodes {
+		nodeTypes[node.ID] = node.Type
+	}
+	sources := make(map[string][]string)
+	for _, edge := range wf.Edges {
+		sources[edge.Target] = append(sources[edge.Target], edge.Source)
+	}
+
+	fmt.Fprintf(e.stdout, "Execution order: %s\n", strings.Join(order, " -> "))
+	for i, level := range levels {
+		fmt.Fprintf(e.stdout, "\nLevel %d:\n", i)
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		for _, id := range level {
+			fmt.Fprintf(tw, "  %s\t%s", id, nodeTypes[id])
+			if len(sources[id]) > 0 {
+				fmt.Fprintf(tw, "\t<- %s", strings.Join(sources[id], ", "))
+			}
+			fmt.Fprintln(tw)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runListNodes prints the node types the engine can run
+func runListNodes(e *env, args []string) error {
+	flags := newFlagSet(e, "list-nodes", "")
+	format := flags.String("format", "text", "Output format: text or json")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected arguments")
+	}
+	if *format != "text" && *format != "json" {
+		return usagef("unknown format %q: expected text or json", *format)
+	}
+
+	registered := engine.DefaultRegistry().ListRegisteredTypes()
+	names := make([]string, len(registered))
+	for i, nodeType := range registered {
+		names[i] = string(nodeType)
+	}
+	sort.Strings(names)
+
+	if *format == "json" {
+		return json.NewEncoder(e.stdout).Encode(names)
+	}
+	for _, name := range names {
+		fmt.Fprintln(e.stdout, name)
+	}
+	return nil
+}
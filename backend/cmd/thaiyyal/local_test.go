@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalCommands(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	adder := write("adder.json", `{
+		"inputs": [
+			{"name": "a", "type": "number", "required": true, "node": "1"},
+			{"name": "b", "type": "number", "default": 5, "node": "2"}
+		],
+		"nodes": [
+			{"id": "1", "data": {"value": 0}},
+			{"id": "2", "data": {"value": 0}},
+			{"id": "3", "data": {"op": "add"}}
+		],
+		"edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]
+	}`)
+	failing := write("failing.json", `{
+		"nodes": [
+			{"id": "1", "data": {"value": 1}},
+			{"id": "2", "data": {"value": 0}},
+			{"id": "3", "data": {"op": "divide"}}
+		],
+		"edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]
+	}`)
+	cyclic := write("cyclic.json", `{
+		"nodes": [
+			{"id": "1", "type": "expression", "data": {"expression": "input"}},
+			{"id": "2", "type": "expression", "data": {"expression": "input"}}
+		],
+		"edges": [{"source": "1", "target": "2"}, {"source": "2", "target": "1"}]
+	}`)
+	notify := write("notify.json", `{
+		"inputs": [{"name": "n", "type": "number", "required": true, "node": "1"}],
+		"nodes": [
+			{"id": "1", "data": {"value": 0}},
+			{"id": "check", "type": "condition", "data": {"condition": ">0"}},
+			{"id": "post", "type": "http", "data": {"url": "https://example.com/notify"}},
+			{"id": "skip", "type": "text_input", "data": {"text": "none"}}
+		],
+		"edges": [
+			{"source": "1", "target": "check"},
+			{"source": "check", "target": "post", "sourceHandle": "true"},
+			{"source": "check", "target": "skip", "sourceHandle": "false"}
+		]
+	}`)
+	outer := write("outer.json", `{
+		"nodes": [{"id": "sum", "type": "sub_workflow", "data": {"workflow_id": "adder", "inputs": {"a": 1}}}]
+	}`)
+	snapshot := filepath.Join(dir, "run.snap")
+	profile := filepath.Join(dir, "run.folded")
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		wantOut  []string
+		wantErr  string
+	}{
+		{
+			name:     "run prints the result as JSON",
+			args:     []string{"run", "-input", "a=10", adder},
+			wantCode: exitOK,
+			wantOut:  []string{`"final_output": 15`},
+		},
+		{
+			name:     "run with flags after the workflow file",
+			args:     []string{"run", adder, "--input", "a=10", "--input", "b=1"},
+			wantCode: exitOK,
+			wantOut:  []string{`"final_output": 11`},
+		},
+		{
+			name:     "run with a config after the workflow file",
+			args:     []string{"run", adder, "--input", "a=10", "--config", "staging"},
+			wantCode: exitUsage,
+			wantErr:  "unknown config",
+		},
+		{
+			name:     "run with two workflow files",
+			args:     []string{"run", adder, "-input", "a=1", failing},
+			wantCode: exitUsage,
+			wantErr:  "expected one workflow file",
+		},
+		{
+			name:     "run as a table with a snapshot",
+			args:     []string{"run", "-input", "a=1", "-input", "b=2", "-format", "table", "-snapshot", snapshot, adder},
+			wantCode: exitOK,
+			wantOut:  []string{"NODE", "3     3", "Final output: 3"},
+		},
+		{
+			name:     "run from stdin",
+			args:     []string{"run", "-input", "a=2", "-"},
+			stdin:    `{"inputs": [{"name": "a", "type": "number"}], "nodes": [{"id": "x", "type": "expression", "data": {"expression": "context.a * 3"}}, {"id": "in", "data": {"value": 0}}], "edges": [{"source": "in", "target": "x"}]}`,
+			wantCode: exitOK,
+			wantOut:  []string{`"final_output": 6`},
+		},
+		{
+			name:     "run sub-workflows from a directory",
+			args:     []string{"run", "-workflows", dir, outer},
+			wantCode: exitOK,
+			wantOut:  []string{`"final_output": 6`},
+		},
+		{
+			name:     "run with a profile",
+			args:     []string{"run", "-workflows", dir, "-profile", profile, outer},
+			wantCode: exitOK,
+			wantOut:  []string{`"critical_path": [`, `"node_id": "sum/3"`},
+		},
+		{
+			name:     "missing required input",
+			args:     []string{"run", adder},
+			wantCode: exitInvalid,
+			wantErr:  "a: required input is missing",
+		},
+		{
+			name:     "failed execution prints the partial result",
+			args:     []string{"run", failing},
+			wantCode: exitError,
+			wantOut:  []string{`"errors"`},
+			wantErr:  "workflow failed",
+		},
+		{
+			name:     "unknown config",
+			args:     []string{"run", "-config", "staging", adder},
+			wantCode: exitUsage,
+			wantErr:  "unknown config",
+		},
+		{
+			name:     "resume a snapshot",
+			args:     []string{"snapshot", "resume", "-format", "table", snapshot},
+			wantCode: exitOK,
+			wantOut:  []string{"Final output: 3"},
+		},
+		{
+			name:     "snapshot without resume",
+			args:     []string{"snapshot", snapshot},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "validate reports each file",
+			args:     []string{"validate", adder, cyclic},
+			wantCode: exitInvalid,
+			wantOut:  []string{adder + ": valid", cyclic + ": invalid", "cycle detected"},
+			wantErr:  "1 of 2 workflow(s) invalid",
+		},
+		{
+			name:     "graph",
+			args:     []string{"graph", adder},
+			wantCode: exitOK,
+			wantOut:  []string{"Execution order: 1 -> 2 -> 3", "Level 1:", "<- 1, 2"},
+		},
+		{
+			name:     "graph of a cycle",
+			args:     []string{"graph", "-format", "json", cyclic},
+			wantCode: exitInvalid,
+		},
+		{
+			name:     "plan",
+			args:     []string{"plan", "-input", "n=3", notify},
+			wantCode: exitOK,
+			wantOut:  []string{"post   http", `would {"method":"GET","url":"https://example.com/notify"}`, `condition "false" on the edge from check is not met`, "3 to run, 1 skipped, 0 decided at runtime"},
+		},
+		{
+			name:     "plan as JSON",
+			args:     []string{"plan", "-format", "json", "-input", "n=0", notify},
+			wantCode: exitOK,
+			wantOut:  []string{`"run": [`, `"skipped": [`, `"post"`},
+		},
+		{
+			name:     "plan of a cycle",
+			args:     []string{"plan", cyclic},
+			wantCode: exitInvalid,
+			wantErr:  "cycle detected",
+		},
+		{
+			name:     "list-nodes",
+			args:     []string{"list-nodes"},
+			wantCode: exitOK,
+			wantOut:  []string{"sub_workflow\n", "operation\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout does not contain %q:\n%s", want, stdout.String())
+				}
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr does not contain %q:\n%s", tt.wantErr, stderr.String())
+			}
+		})
+	}
+
+	folded, err := os.ReadFile(profile)
+	if err != nil {
+		t.Fatalf("Profile not written: %v", err)
+	}
+	if !strings.Contains(string(folded), "workflow;sum;sum/") {
+		t.Errorf("Profile has no stack for the sub-workflow's nodes:\n%s", folded)
+	}
+}
@@ -0,0 +1,241 @@
+// Command thaiyyal is the command line tool for the Thaiyyal workflow engine.
+//
+// Usage:
+//
+//	thaiyyal [global flags] <command> [flags] [args]
+//
+// Commands:
+//
+//	run              Run a workflow file and print its result
+//	validate         Check workflow files without running them
+//	graph            Show a workflow's execution order and levels
+//	list-nodes       List the node types the engine can run
+//	snapshot resume  Resume an execution from a snapshot file
+//	export           Export a saved workflow and its HTTP clients as a bundle
+//	import           Import a workflow bundle
+//
+// run, validate, graph, list-nodes and snapshot run locally, without a
+// server. Workflow and snapshot files can be "-" for stdin.
+//
+// Global flags, used by the commands that talk to a server:
+//
+//	-server string
+//	    Server URL (default $THAIYYAL_SERVER or "http://localhost:8080")
+//	-api-key string
+//	    API key sent to the server (default $THAIYYAL_API_KEY)
+//	-token string
+//	    Bearer token sent to the server (default $THAIYYAL_TOKEN)
+//
+// Examples:
+//
+//	# Run a workflow with inputs and print its node results as a table
+//	thaiyyal run -input quantity=3 -input 'tags=["a","b"]' -config prod -format table order.json
+//
+//	# Fail a cron job when the workflow fails, keeping its state to resume
+//	thaiyyal run -snapshot nightly.snap -log-level warn nightly.json > out.json
+//	thaiyyal snapshot resume nightly.snap
+//
+//	# See where a slow workflow spends its time as a flame graph
+//	thaiyyal run -profile sync.folded sync.json > out.json
+//	flamegraph.pl sync.folded > sync.svg
+//
+//	# Copy a workflow from staging to production
+//	thaiyyal -server https://staging.example.com export -o crm-sync.json 3e4e4585-2b18-4db1-9968-ad2d8649c64c
+//	thaiyyal -server https://prod.example.com import -policy rename -secret crm.token=$CRM_TOKEN crm-sync.json
+//
+// Exit codes:
+//
+//	0  success
+//	1  the command or the workflow execution failed
+//	2  invalid usage
+//	3  the workflow, its inputs, the config or a snapshot is invalid
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Exit codes
+const (
+	exitOK      = 0
+	exitError   = 1
+	exitUsage   = 2
+	exitInvalid = 3
+)
+
+// command is one thaiyyal subcommand
+type command struct {
+	summary string
+	run     func(env *env, args []string) error
+}
+
+// commands lists the subcommands by name
+var commands = map[string]command{
+	"run":        {"Run a workflow file and print its result", runRun},
+	"validate":   {"Check workflow files without running them", runValidate},
+	"graph":      {"Show a workflow's execution order and levels", runGraph},
+	"plan":       {"Show what a workflow would do without side effects", runPlan},
+	"list-nodes": {"List the node types the engine can run", runListNodes},
+	"snapshot":   {"Resume an execution from a snapshot file (snapshot resume)", runSnapshot},
+	"export":     {"Export a saved workflow and its HTTP clients as a bundle", runExport},
+	"import":     {"Import a workflow bundle", runImport},
+}
+
+// env is what commands share: the global flags and the standard streams
+type env struct {
+	server string
+	apiKey string
+	token  string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// usageError reports invalid arguments; it exits with exitUsage. An empty
+// message means the flag package already reported the problem.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+// invalidError reports a workflow, inputs, config or snapshot that cannot
+// be used; it exits with exitInvalid
+type invalidError struct {
+	err error
+}
+
+func (e *invalidError) Error() string { return e.err.Error() }
+
+func (e *invalidError) Unwrap() error { return e.err }
+
+// usagef returns a usageError with a formatted message
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("thaiyyal", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&e.server, "server", envOr("THAIYYAL_SERVER", "http://localhost:8080"), "Server URL")
+	flags.StringVar(&e.apiKey, "api-key", os.Getenv("THAIYYAL_API_KEY"), "API key sent to the server")
+	flags.StringVar(&e.token, "token", os.Getenv("THAIYYAL_TOKEN"), "Bearer token sent to the server")
+	flags.Usage = func() { printUsage(stderr, flags) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		printUsage(stderr, flags)
+		return exitUsage
+	}
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "thaiyyal: unknown command %q\n\n", name)
+		printUsage(stderr, flags)
+		return exitUsage
+	}
+
+	err := cmd.run(e, flags.Args()[1:])
+	var usageErr *usageError
+	var invalidErr *invalidError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		if usageErr.msg != "" {
+			fmt.Fprintf(stderr, "thaiyyal %s: %v\n", name, err)
+		}
+		return exitUsage
+	case errors.As(err, &invalidErr):
+		fmt.Fprintf(stderr, "thaiyyal %s: %v\n", name, err)
+		return exitInvalid
+	default:
+		fmt.Fprintf(stderr, "thaiyyal %s: %v\n", name, err)
+		return exitError
+	}
+}
+
+// printUsage writes the command overview
+func printUsage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: thaiyyal [global flags] <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	flags.PrintDefaults()
+}
+
+// newFlagSet returns the flag set of a subcommand taking the positional
+// args described by args
+func newFlagSet(e *env, name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: thaiyyal %s [flags] %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args, turning parse errors into usage errors. Flags
+// may follow the positional args, as in "run workflow.json -input a=1";
+// everything after "--" is positional.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return err
+			}
+			return &usageError{}
+		}
+		rest := flags.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	// Leave the positional args in flags.Args
+	return flags.Parse(append([]string{"--"}, positional...))
+}
+
+// envOr returns the environment variable key, or fallback when unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is one entry of the API key file. Exactly one of Key and
+// KeySHA256 must be set.
+type APIKey struct {
+	Name      string   `json:"name"`
+	Key       string   `json:"key,omitempty"`
+	KeySHA256 string   `json:"key_sha256,omitempty"`
+	Roles     []string `json:"roles"`
+	Tenant    string   `json:"tenant,omitempty"`
+}
+
+// apiKeyFile is the on-disk layout of the API key file
+type apiKeyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// APIKeys authenticates requests by the X-API-Key header. Keys are held
+// only as SHA-256 digests.
+type APIKeys struct {
+	principals map[[sha256.Size]byte]*Principal
+}
+
+// LoadAPIKeys reads an API key file
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+
+	var file apiKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKeyFile, path, err)
+	}
+	return NewAPIKeys(file.Keys)
+}
+
+// NewAPIKeys validates keys and builds an authenticator for them
+func NewAPIKeys(keys []APIKey) (*APIKeys, error) {
+	a := &APIKeys{principals: make(map[[sha256.Size]byte]*Principal, len(keys))}
+	names := make(map[string]bool, len(keys))
+
+	for i, key := range keys {
+		if key.Name == "" {
+			return nil, fmt.Errorf("%w: key %d has no name", ErrInvalidKeyFile, i)
+		}
+		if names[key.Name] {
+			return nil, fmt.Errorf("%w: duplicate key name %q", ErrInvalidKeyFile, key.Name)
+		}
+		names[key.Name] = true
+
+		digest, err := key.digest()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidKeyFile, key.Name, err)
+		}
+		if _, exists := a.principals[digest]; exists {
+			return nil, fmt.Errorf("%w: key %q duplicates another key", ErrInvalidKeyFile, key.Name)
+		}
+
+		roles, err := parseRoles(key.Roles)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidKeyFile, key.Name, err)
+		}
+		a.principals[digest] = &Principal{
+			Subject: key.Name,
+			Tenant:  key.Tenant,
+			Roles:   roles,
+			Method:  "api_key",
+		}
+	}
+	return a, nil
+}
+
+// digest returns the key's SHA-256 digest from whichever form was given
+func (k APIKey) digest() ([sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+	switch {
+	case k.Key != "" && k.KeySHA256 != "":
+		return digest, fmt.Errorf("set either key or key_sha256, not both")
+	case k.Key != "":
+		return sha256.Sum256([]byte(k.Key)), nil
+	case k.KeySHA256 != "":
+		raw, err := hex.DecodeString(k.KeySHA256)
+		if err != nil || len(raw) != sha256.Size {
+			return digest, fmt.Errorf("key_sha256 must be %d hex characters", 2*sha256.Size)
+		}
+		copy(digest[:], raw)
+		return digest, nil
+	default:
+		return digest, fmt.Errorf("key or key_sha256 is required")
+	}
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeys) Authenticate(r *http.Request) (*Principal, error) {
+	key := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// Looking up the digest rather than the key keeps the comparison
+	// independent of how much of the key matches
+	principal, ok := a.principals[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	copied := *principal
+	return &copied, nil
+}
+
+func parseRoles(names []string) ([]Role, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one role is required")
+	}
+	roles := make([]Role, 0, len(names))
+	for _, name := range names {
+		role, err := ParseRole(name)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
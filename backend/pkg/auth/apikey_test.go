@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAPIKeys(t *testing.T) {
+	digest := sha256.Sum256([]byte("ops-key"))
+	path := writeFile(t, "keys.json", `{"keys": [
+		{"name": "ci", "key": "ci-key", "roles": ["executor"], "tenant": "acme"},
+		{"name": "ops", "key_sha256": "`+hex.EncodeToString(digest[:])+`", "roles": ["viewer", "editor"]}
+	]}`)
+	keys, err := LoadAPIKeys(path)
+	if err != nil {
+		t.Fatalf("LoadAPIKeys() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		subject string
+		wantErr error
+	}{
+		{"plain key", "ci-key", "ci", nil},
+		{"hashed key", "ops-key", "ops", nil},
+		{"unknown key", "guess", "", ErrInvalidCredentials},
+		{"no key", "", "", ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.key != "" {
+				r.Header.Set(APIKeyHeader, tt.key)
+			}
+			principal, err := keys.Authenticate(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && principal.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", principal.Subject, tt.subject)
+			}
+		})
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(APIKeyHeader, "ci-key")
+	ci, _ := keys.Authenticate(r)
+	if ci.Tenant != "acme" || ci.Method != "api_key" || !ci.Can(PermissionExecute) || ci.Can(PermissionWrite) {
+		t.Errorf("ci principal = %+v, want an acme executor", ci)
+	}
+	r.Header.Set(APIKeyHeader, "ops-key")
+	ops, _ := keys.Authenticate(r)
+	if !ops.Can(PermissionWrite) || ops.Can(PermissionExecute) || ops.Can(PermissionAdmin) {
+		t.Errorf("ops principal = %+v, want viewer and editor permissions only", ops)
+	}
+}
+
+func TestAPIKeys_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []APIKey
+	}{
+		{"missing name", []APIKey{{Key: "k", Roles: []string{"admin"}}}},
+		{"missing key", []APIKey{{Name: "a", Roles: []string{"admin"}}}},
+		{"both key forms", []APIKey{{Name: "a", Key: "k", KeySHA256: "00", Roles: []string{"admin"}}}},
+		{"bad digest", []APIKey{{Name: "a", KeySHA256: "abc", Roles: []string{"admin"}}}},
+		{"no roles", []APIKey{{Name: "a", Key: "k"}}},
+		{"unknown role", []APIKey{{Name: "a", Key: "k", Roles: []string{"root"}}}},
+		{"duplicate name", []APIKey{{Name: "a", Key: "k1", Roles: []string{"admin"}}, {Name: "a", Key: "k2", Roles: []string{"admin"}}}},
+		{"duplicate key", []APIKey{{Name: "a", Key: "k", Roles: []string{"admin"}}, {Name: "b", Key: "k", Roles: []string{"admin"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAPIKeys(tt.keys); !errors.Is(err, ErrInvalidKeyFile) {
+				t.Errorf("NewAPIKeys() error = %v, want ErrInvalidKeyFile", err)
+			}
+		})
+	}
+
+	if _, err := LoadAPIKeys(writeFile(t, "bad.json", "{")); !errors.Is(err, ErrInvalidKeyFile) {
+		t.Errorf("LoadAPIKeys(corrupt) error = %v, want ErrInvalidKeyFile", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if authenticator, err := New(Config{}); err != nil || authenticator != nil {
+		t.Errorf("New(empty) = %v, %v; want nil, nil", authenticator, err)
+	}
+
+	if _, err := New(Config{JWKS: "a", JWTPublicKeyFile: "b"}); !errors.Is(err, ErrInvalidAuthConfig) {
+		t.Errorf("New(JWKS and public key) error = %v, want ErrInvalidAuthConfig", err)
+	}
+
+	path := writeFile(t, "keys.json", `{"keys": [{"name": "ci", "key": "ci-key", "roles": ["admin"]}]}`)
+	authenticator, err := New(Config{APIKeysFile: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer not-a-key")
+	if _, err := authenticator.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Authenticate(bearer without JWT) error = %v, want ErrNoCredentials", err)
+	}
+}
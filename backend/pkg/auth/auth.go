@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Role names a set of permissions
+type Role string
+
+// Roles understood by the server
+const (
+	RoleViewer   Role = "viewer"
+	RoleEditor   Role = "editor"
+	RoleExecutor Role = "executor"
+	RoleAdmin    Role = "admin"
+)
+
+// Permission is an action a route requires
+type Permission string
+
+// Permissions checked by the server
+const (
+	// PermissionRead covers listing and reading workflows, executions and
+	// history
+	PermissionRead Permission = "read"
+
+	// PermissionWrite covers saving, deleting and rolling back workflows
+	PermissionWrite Permission = "write"
+
+	// PermissionExecute covers running and cancelling workflows
+	PermissionExecute Permission = "execute"
+
+	// PermissionAdmin covers server-wide configuration such as HTTP client
+	// credentials
+	PermissionAdmin Permission = "admin"
+)
+
+// rolePermissions is the fixed role to permission mapping
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:   {PermissionRead},
+	RoleEditor:   {PermissionRead, PermissionWrite},
+	RoleExecutor: {PermissionRead, PermissionExecute},
+	RoleAdmin:    {PermissionRead, PermissionWrite, PermissionExecute, PermissionAdmin},
+}
+
+// ParseRole validates a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownRole, name)
+	}
+	return role, nil
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	// Subject identifies the caller: the API key name or the token subject
+	Subject string `json:"subject"`
+
+	// Tenant is the tenant the caller belongs to, if any
+	Tenant string `json:"tenant,omitempty"`
+
+	// Roles held by the caller
+	Roles []Role `json:"roles"`
+
+	// Method is the authenticator that accepted the caller ("api_key" or
+	// "jwt")
+	Method string `json:"method"`
+}
+
+// Can reports whether any of the principal's roles grants permission
+func (p *Principal) Can(permission Permission) bool {
+	if p == nil {
+		return false
+	}
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authenticator identifies the caller of a request. It returns
+// ErrNoCredentials when the request carries none of the credentials it
+// understands, and another error when credentials are present but invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries each authenticator in turn. The first one that finds its
+// credentials in the request decides the outcome.
+type Chain []Authenticator
+
+// Authenticate implements Authenticator
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// Config selects the authenticators built by New
+type Config struct {
+	// APIKeysFile is a JSON file of static API keys. Empty disables API key
+	// authentication.
+	APIKeysFile string
+
+	// JWKS is a JSON Web Key Set used to verify tokens, given as a file
+	// path or an http(s) URL. Remote sets are refreshed every
+	// JWKSRefresh and when a token names an unknown key.
+	JWKS string
+
+	// JWKSRefresh is how often a remote JWKS is fetched again (default 1h)
+	JWKSRefresh time.Duration
+
+	// JWTPublicKeyFile is a PEM public key or certificate used to verify
+	// tokens when no JWKS is configured
+	JWTPublicKeyFile string
+
+	// Issuer, when set, must match the token's "iss" claim
+	Issuer string
+
+	// Audience, when set, must be one of the token's "aud" values
+	Audience string
+
+	// RolesClaim names the claim holding the caller's roles (default
+	// "roles")
+	RolesClaim string
+
+	// TenantClaim names the claim holding the caller's tenant (default
+	// "tenant")
+	TenantClaim string
+
+	// Leeway allows for clock skew when checking exp and nbf
+	Leeway time.Duration
+
+	// AllowMissingExpiry accepts tokens without an "exp" claim. Tokens
+	// must carry one by default.
+	AllowMissingExpiry bool
+}
+
+// Enabled reports whether the config turns on any authenticator
+func (c Config) Enabled() bool {
+	return c.APIKeysFile != "" || c.JWKS != "" || c.JWTPublicKeyFile != ""
+}
+
+// New builds the authenticators enabled in config. It returns a nil
+// Authenticator when none are enabled.
+func New(config Config) (Authenticator, error) {
+	if config.JWKS != "" && config.JWTPublicKeyFile != "" {
+		return nil, fmt.Errorf("%w: set either a JWKS or a JWT public key, not both", ErrInvalidAuthConfig)
+	}
+
+	var chain Chain
+	if config.APIKeysFile != "" {
+		keys, err := LoadAPIKeys(config.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, keys)
+	}
+
+	var keySet KeySet
+	switch {
+	case config.JWKS != "":
+		jwks, err := NewJWKS(config.JWKS, config.JWKSRefresh)
+		if err != nil {
+			return nil, err
+		}
+		keySet = jwks
+	case config.JWTPublicKeyFile != "":
+		key, err := LoadPublicKey(config.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keySet = key
+	}
+	if keySet != nil {
+		chain = append(chain, NewJWT(keySet, JWTOptions{
+			Issuer:             config.Issuer,
+			Audience:           config.Audience,
+			RolesClaim:         config.RolesClaim,
+			TenantClaim:        config.TenantClaim,
+			Leeway:             config.Leeway,
+			AllowMissingExpiry: config.AllowMissingExpiry,
+		}))
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal stored by WithPrincipal
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,64 @@
+// Package auth authenticates API requests and maps callers to roles.
+//
+// # Overview
+//
+// An Authenticator turns an incoming request into a Principal. The
+// Principal holds the caller's subject, tenant and roles. Two
+// authenticators are provided:
+//
+//   - APIKeys checks the X-API-Key header against static keys loaded from a
+//     JSON file.
+//   - JWT verifies an "Authorization: Bearer" token. The token must be
+//     signed with RS*, PS* or ES* by a key from a JWKS (a file or a URL) or
+//     from a PEM public key.
+//
+// New builds the authenticators enabled in a Config and combines them into
+// a Chain:
+//
+//	authenticator, err := auth.New(auth.Config{
+//	    APIKeysFile: "/etc/thaiyyal/api-keys.json",
+//	    JWKS:        "https://idp.example.com/.well-known/jwks.json",
+//	    Issuer:      "https://idp.example.com/",
+//	    Audience:    "thaiyyal",
+//	})
+//
+//	principal, err := authenticator.Authenticate(r)
+//	if errors.Is(err, auth.ErrNoCredentials) {
+//	    // anonymous request
+//	}
+//	if !principal.Can(auth.PermissionExecute) {
+//	    // 403
+//	}
+//
+// # Roles
+//
+// Each role grants a fixed set of permissions. A principal may hold several
+// roles and gets the union of their permissions:
+//
+//	viewer    read
+//	editor    read, write
+//	executor  read, execute
+//	admin     read, write, execute, admin
+//
+// # API key file
+//
+// The file lists keys in plain text or as hex SHA-256 digests, so the file
+// need not hold the secrets themselves:
+//
+//	{
+//	  "keys": [
+//	    {"name": "ci", "key": "s3cret", "roles": ["executor"], "tenant": "acme"},
+//	    {"name": "ops", "key_sha256": "9f86d0...", "roles": ["admin"]}
+//	  ]
+//	}
+//
+// # JWT claims
+//
+// Tokens must not be expired or used before their nbf time. When the
+// configured Issuer and Audience are set, the token must match them. The
+// subject comes from "sub". Roles are read from Config.RolesClaim (default
+// "roles") and the tenant from Config.TenantClaim (default "tenant"). Both
+// claim names may be dotted paths into nested objects, such as
+// "realm_access.roles". A roles claim may be an array or a space-separated
+// string.
+package auth
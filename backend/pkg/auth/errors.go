@@ -0,0 +1,17 @@
+package auth
+
+import "errors"
+
+// Sentinel errors for authentication
+var (
+	ErrNoCredentials      = errors.New("no credentials provided")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenExpired       = errors.New("token expired")
+	ErrUnsupportedAlg     = errors.New("unsupported signing algorithm")
+	ErrUnknownKey         = errors.New("unknown signing key")
+	ErrUnknownRole        = errors.New("unknown role")
+	ErrInvalidKeyFile     = errors.New("invalid API key file")
+	ErrInvalidKeySet      = errors.New("invalid key set")
+	ErrInvalidAuthConfig  = errors.New("invalid auth configuration")
+)
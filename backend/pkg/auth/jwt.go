@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures claim checks for JWT
+type JWTOptions struct {
+	// Issuer, when set, must match the "iss" claim
+	Issuer string
+
+	// Audience, when set, must be one of the "aud" values
+	Audience string
+
+	// RolesClaim names the roles claim; dots select nested objects
+	// (default "roles")
+	RolesClaim string
+
+	// TenantClaim names the tenant claim; dots select nested objects
+	// (default "tenant")
+	TenantClaim string
+
+	// Leeway allows for clock skew when checking exp and nbf
+	Leeway time.Duration
+
+	// AllowMissingExpiry accepts tokens without an "exp" claim, which
+	// otherwise never expire and are rejected
+	AllowMissingExpiry bool
+}
+
+// JWT authenticates requests carrying "Authorization: Bearer <token>"
+type JWT struct {
+	keys    KeySet
+	options JWTOptions
+	now     func() time.Time
+}
+
+// NewJWT creates a JWT authenticator verifying signatures against keys
+func NewJWT(keys KeySet, options JWTOptions) *JWT {
+	if options.RolesClaim == "" {
+		options.RolesClaim = "roles"
+	}
+	if options.TenantClaim == "" {
+		options.TenantClaim = "tenant"
+	}
+	return &JWT{keys: keys, options: options, now: time.Now}
+}
+
+// Authenticate implements Authenticator
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := j.verify(r, strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+	return j.principal(claims)
+}
+
+// jwtHeader is the JOSE header of a signed token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verify checks the token signature and time claims and returns its claims
+func (j *JWT) verify(r *http.Request, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, header.Alg)
+	}
+
+	key, err := j.keys.Key(r.Context(), header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if err := alg.verify(key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := j.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims validates the registered time, issuer and audience claims
+func (j *JWT) checkClaims(claims map[string]interface{}) error {
+	now := j.now()
+	exp, ok := claims["exp"].(float64)
+	switch {
+	case !ok && !j.options.AllowMissingExpiry:
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	case ok && now.After(unixTime(exp).Add(j.options.Leeway)):
+		return ErrTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(j.options.Leeway).Before(unixTime(nbf)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	if j.options.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != j.options.Issuer {
+			return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+		}
+	}
+	if j.options.Audience != "" && !containsString(claims["aud"], j.options.Audience) {
+		return fmt.Errorf("%w: token not issued for audience %q", ErrInvalidToken, j.options.Audience)
+	}
+	return nil
+}
+
+// principal maps verified claims to a Principal. Unknown role names are
+// ignored so identity providers can issue roles for other services.
+func (j *JWT) principal(claims map[string]interface{}) (*Principal, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+
+	principal := &Principal{Subject: subject, Method: "jwt"}
+	if tenant, ok := claimPath(claims, j.options.TenantClaim).(string); ok {
+		principal.Tenant = tenant
+	}
+
+	var names []string
+	switch roles := claimPath(claims, j.options.RolesClaim).(type) {
+	case string:
+		names = strings.Fields(roles)
+	case []interface{}:
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		if role, err := ParseRole(name); err == nil {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+	return principal, nil
+}
+
+// claimPath looks up a dotted claim name in nested claim objects
+func claimPath(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// containsString reports whether value is s or an array holding s, the two
+// forms the "aud" claim may take
+func containsString(value interface{}, s string) bool {
+	switch v := value.(type) {
+	case string:
+		return v == s
+	case []interface{}:
+		for _, item := range v {
+			if item == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// algorithm verifies one JWS signing algorithm
+type algorithm struct {
+	hash crypto.Hash
+	kind string // "rsa", "pss" or "ecdsa"
+	size int    // ECDSA curve size in bytes
+}
+
+// algorithms lists the accepted "alg" values. HMAC and "none" are
+// deliberately absent: only public keys are configured.
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256, kind: "rsa"},
+	"RS384": {hash: crypto.SHA384, kind: "rsa"},
+	"RS512": {hash: crypto.SHA512, kind: "rsa"},
+	"PS256": {hash: crypto.SHA256, kind: "pss"},
+	"PS384": {hash: crypto.SHA384, kind: "pss"},
+	"PS512": {hash: crypto.SHA512, kind: "pss"},
+	"ES256": {hash: crypto.SHA256, kind: "ecdsa", size: 32},
+	"ES384": {hash: crypto.SHA384, kind: "ecdsa", size: 48},
+	"ES512": {hash: crypto.SHA512, kind: "ecdsa", size: 66},
+}
+
+var errBadSignature = errors.New("signature verification failed")
+
+func (a algorithm) verify(key crypto.PublicKey, signed, signature []byte) error {
+	h := a.hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch a.kind {
+	case "rsa", "pss":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key is not an RSA key", ErrUnsupportedAlg)
+		}
+		var err error
+		if a.kind == "rsa" {
+			err = rsa.VerifyPKCS1v15(pub, a.hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(pub, a.hash, digest, signature, nil)
+		}
+		if err != nil {
+			return errBadSignature
+		}
+		return nil
+	default:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || (pub.Curve.Params().BitSize+7)/8 != a.size {
+			return fmt.Errorf("%w: key does not match the algorithm's curve", ErrUnsupportedAlg)
+		}
+		if len(signature) != 2*a.size {
+			return errBadSignature
+		}
+		r := new(big.Int).SetBytes(signature[:a.size])
+		s := new(big.Int).SetBytes(signature[a.size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errBadSignature
+		}
+		return nil
+	}
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sign builds a compact JWS for claims. Algorithms other than RS256, PS256
+// and ES256 get a dummy signature.
+func sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	sum := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	var err error
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+	case "PS256":
+		signature, err = rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, sum[:], nil)
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, ecKey, sum[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	default:
+		signature = []byte("unsigned")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(signature)
+}
+
+func jwksJSON() string {
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		{"kty": "oct", "kid": "hmac-1", "k": "c2VjcmV0"},
+	}}
+	data, _ := json.Marshal(set)
+	return string(data)
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestJWT(t *testing.T) {
+	keys, err := NewJWKS(writeFile(t, "jwks.json", jwksJSON()), 0)
+	if err != nil {
+		t.Fatalf("NewJWKS() error = %v", err)
+	}
+	authenticator := NewJWT(keys, JWTOptions{
+		Issuer:      "https://idp.example.com/",
+		Audience:    "thaiyyal",
+		RolesClaim:  "realm_access.roles",
+		TenantClaim: "org",
+		Leeway:      time.Minute,
+	})
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":          "alice",
+			"iss":          "https://idp.example.com/",
+			"aud":          []string{"other", "thaiyyal"},
+			"exp":          now.Add(time.Hour).Unix(),
+			"org":          "acme",
+			"realm_access": map[string]interface{}{"roles": []string{"editor", "offline_access"}},
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"RS256", sign(t, "RS256", "rsa-1", claims(nil)), nil},
+		{"PS256", sign(t, "PS256", "rsa-1", claims(nil)), nil},
+		{"ES256", sign(t, "ES256", "ec-1", claims(nil)), nil},
+		{"expired within leeway", sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()})), nil},
+		{"expired", sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})), ErrTokenExpired},
+		{"no expiry", sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"exp": nil})), ErrInvalidToken},
+		{"not yet valid", sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})), ErrInvalidToken},
+		{"wrong issuer", sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"iss": "https://evil.example.com/"})), ErrInvalidToken},
+		{"wrong audience", sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"aud": "other"})), ErrInvalidToken},
+		{"no subject", sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"sub": ""})), ErrInvalidToken},
+		{"unknown key", sign(t, "RS256", "rsa-2", claims(nil)), ErrUnknownKey},
+		{"key of another type", sign(t, "RS256", "ec-1", claims(nil)), ErrUnsupportedAlg},
+		{"alg none", sign(t, "none", "rsa-1", claims(nil)), ErrUnsupportedAlg},
+		{"HMAC", sign(t, "HS256", "hmac-1", claims(nil)), ErrUnsupportedAlg},
+		{"malformed", "abc.def", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(bearer(tt.token))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if principal.Subject != "alice" || principal.Tenant != "acme" || principal.Method != "jwt" {
+				t.Errorf("Principal = %+v", principal)
+			}
+			if len(principal.Roles) != 1 || principal.Roles[0] != RoleEditor {
+				t.Errorf("Roles = %v, want [editor] with unknown roles dropped", principal.Roles)
+			}
+		})
+	}
+
+	// A valid signature over tampered claims is rejected
+	token := sign(t, "RS256", "rsa-1", claims(nil))
+	payload, _ := json.Marshal(claims(map[string]interface{}{"sub": "mallory"}))
+	parts := strings.Split(token, ".")
+	if _, err := authenticator.Authenticate(bearer(parts[0] + "." + b64(payload) + "." + parts[2])); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Authenticate(tampered) error = %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := authenticator.Authenticate(httptest.NewRequest("GET", "/", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Authenticate(no header) error = %v, want ErrNoCredentials", err)
+	}
+
+	// Tokens without exp are only accepted when explicitly allowed
+	noExpiry := claims(nil)
+	delete(noExpiry, "exp")
+	token = sign(t, "RS256", "rsa-1", noExpiry)
+	if _, err := authenticator.Authenticate(bearer(token)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Authenticate(no exp) error = %v, want ErrInvalidToken", err)
+	}
+	lenient := NewJWT(keys, JWTOptions{
+		Issuer:             "https://idp.example.com/",
+		Audience:           "thaiyyal",
+		TenantClaim:        "org",
+		AllowMissingExpiry: true,
+	})
+	if _, err := lenient.Authenticate(bearer(token)); err != nil {
+		t.Errorf("Authenticate(no exp) with AllowMissingExpiry error = %v", err)
+	}
+}
+
+func TestJWT_PublicKeyPEM(t *testing.T) {
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeFile(t, "key.pem", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+
+	authenticator, err := New(Config{JWTPublicKeyFile: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	token := sign(t, "ES256", "", map[string]interface{}{"sub": "svc", "roles": "viewer executor", "exp": time.Now().Add(time.Hour).Unix()})
+	principal, err := authenticator.Authenticate(bearer(token))
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !principal.Can(PermissionExecute) || principal.Can(PermissionWrite) {
+		t.Errorf("Principal = %+v, want viewer and executor from a space-separated claim", principal)
+	}
+
+	if _, err := LoadPublicKey(writeFile(t, "bad.pem", "not pem")); !errors.Is(err, ErrInvalidKeySet) {
+		t.Errorf("LoadPublicKey(bad) error = %v, want ErrInvalidKeySet", err)
+	}
+}
+
+func TestJWKS_RemoteRefresh(t *testing.T) {
+	var fetches atomic.Int32
+	var body atomic.Value
+	body.Store(`{"keys": []}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer srv.Close()
+
+	if _, err := NewJWKS(srv.URL, 0); !errors.Is(err, ErrInvalidKeySet) {
+		t.Fatalf("NewJWKS(empty set) error = %v, want ErrInvalidKeySet", err)
+	}
+
+	body.Store(jwksJSON())
+	keys, err := NewJWKS(srv.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWKS() error = %v", err)
+	}
+	clock := time.Now()
+	keys.now = func() time.Time { return clock }
+	keys.fetchedAt = clock
+	fetches.Store(0)
+
+	ctx := httptest.NewRequest("GET", "/", nil).Context()
+	if _, err := keys.Key(ctx, "rsa-1"); err != nil || fetches.Load() != 0 {
+		t.Errorf("Key(known) = %v after %d fetches, want a cached hit", err, fetches.Load())
+	}
+
+	// Unknown key IDs trigger at most one refetch per minute
+	for i := 0; i < 3; i++ {
+		keys.Key(ctx, "rotated")
+	}
+	if fetches.Load() != 0 {
+		t.Errorf("Expected no refetch within a minute of the last fetch, got %d", fetches.Load())
+	}
+	clock = clock.Add(2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		keys.Key(ctx, "rotated")
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected one refetch for an unknown key, got %d", fetches.Load())
+	}
+
+	// A failing refresh keeps the cached keys
+	body.Store("{")
+	clock = clock.Add(2 * time.Hour)
+	if _, err := keys.Key(ctx, "ec-1"); err != nil || fetches.Load() != 2 {
+		t.Errorf("Key() after failed refresh = %v with %d fetches, want cached key after a refetch", err, fetches.Load())
+	}
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeySet resolves the public key that signed a token
+type KeySet interface {
+	// Key returns the key with the given ID. kid is empty when the token
+	// header names no key.
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// PublicKey is a KeySet holding a single key loaded from PEM. It is used
+// whatever key ID a token names.
+type PublicKey struct {
+	key crypto.PublicKey
+}
+
+// LoadPublicKey reads a PEM encoded public key ("PUBLIC KEY" or "RSA PUBLIC
+// KEY") or certificate
+func LoadPublicKey(path string) (*PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeySet, err)
+	}
+	key, err := ParsePublicKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &PublicKey{key: key}, nil
+}
+
+// ParsePublicKeyPEM decodes the first PEM block of data as a public key
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidKeySet)
+	}
+
+	var key crypto.PublicKey
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported PEM block %q", ErrInvalidKeySet, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeySet, err)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidKeySet, key)
+	}
+}
+
+// Key implements KeySet
+func (p *PublicKey) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	return p.key, nil
+}
+
+// jwk is the subset of RFC 7517 fields needed for RSA and EC keys
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes a JSON Web Key Set into public keys by key ID. Keys
+// for encryption and of unsupported types are skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeySet, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsa()
+		case "EC":
+			key, err = k.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidKeySet, k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no usable signing keys", ErrInvalidKeySet)
+	}
+	return keys, nil
+}
+
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %v", err)
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %v", err)
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %v", err)
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+	}
+	return key, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("invalid base64url value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// DefaultJWKSRefresh is how often a remote JWKS is fetched again when
+// NewJWKS is given no interval
+const DefaultJWKSRefresh = time.Hour
+
+// jwksMinRefetch limits refetches triggered by unknown key IDs, so tokens
+// with made-up key IDs cannot hammer the key server
+const jwksMinRefetch = time.Minute
+
+// JWKS is a KeySet loaded from a file or fetched from a URL. A remote set
+// is fetched again by Key once it is older than the refresh interval, or
+// when a token names an unknown key.
+type JWKS struct {
+	source  string
+	remote  bool
+	refresh time.Duration
+	client  *http.Client
+	now     func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKS loads the key set at source, an http(s) URL or a file path. The
+// set is fetched once up front so a bad source fails at startup.
+func NewJWKS(source string, refresh time.Duration) (*JWKS, error) {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+	j := &JWKS{
+		source:  source,
+		remote:  strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"),
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+	}
+	if err := j.load(context.Background()); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Key implements KeySet
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key, ok := j.lookup(kid)
+	age := j.now().Sub(j.fetchedAt)
+	if j.remote && (age >= j.refresh || (!ok && age >= jwksMinRefetch)) {
+		// A failed refresh keeps serving the keys already loaded
+		if err := j.loadLocked(ctx); err == nil {
+			key, ok = j.lookup(kid)
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// lookup finds kid in the current keys. A token without a key ID matches
+// only when the set holds a single key. Callers must hold j.mu.
+func (j *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *JWKS) load(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.loadLocked(ctx)
+}
+
+// loadLocked reads the key set from its source. Callers must hold j.mu.
+func (j *JWKS) loadLocked(ctx context.Context) error {
+	// Record the attempt even if it fails, so refetches stay rate limited
+	j.fetchedAt = j.now()
+
+	data, err := j.read(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidKeySet, j.source, err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", j.source, err)
+	}
+	j.keys = keys
+	return nil
+}
+
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !j.remote {
+		return os.ReadFile(j.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
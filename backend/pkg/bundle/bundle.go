@@ -0,0 +1,240 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	workflow "github.com/yesoreyeram/thaiyyal/backend"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/httpclient"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+)
+
+// Bundle format identifiers. Version is bumped on incompatible changes.
+const (
+	Format  = "thaiyyal.bundle"
+	Version = 1
+)
+
+// defaultKeys masks the header and query parameter names in
+// observer.DefaultSensitiveKeys, for exports given no redactor
+var defaultKeys, _ = observer.NewRedactor()
+
+// Bundle is an exported workflow with the HTTP clients it references
+type Bundle struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Workflow   Workflow  `json:"workflow"`
+
+	// HTTPClients are httpclient.Config documents with secrets replaced
+	// by placeholders
+	HTTPClients []json.RawMessage `json:"http_clients,omitempty"`
+
+	// Secrets lists the placeholders that must be filled in at import
+	Secrets []Secret `json:"secrets,omitempty"`
+}
+
+// Workflow is the exported workflow version
+type Workflow struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Version     int             `json:"version"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Secret describes one placeholder in a bundled client config
+type Secret struct {
+	Name      string `json:"name"`
+	ClientUID string `json:"client_uid"`
+	Field     string `json:"field"`
+}
+
+// Placeholder returns the value that stands in for the named secret
+func Placeholder(name string) string {
+	return "${secret:" + name + "}"
+}
+
+// Export bundles version of the workflow id in store, or its latest version
+// when version is 0, with the configs of the clients it references.
+// Every referenced client must be registered in clients with a config.
+// Header and query parameter values are replaced by placeholders when keys
+// masks their names; nil uses observer.DefaultSensitiveKeys.
+func Export(store workflow.WorkflowStore, clients *httpclient.Registry, id string, version int, keys *observer.Redactor) (*Bundle, error) {
+	if keys == nil {
+		keys = defaultKeys
+	}
+
+	var wf Workflow
+	if version != 0 {
+		v, err := store.GetVersion(id, version)
+		if err != nil {
+			return nil, err
+		}
+		wf = Workflow{ID: id, Name: v.Name, Description: v.Description, Version: v.Version, Data: v.Data}
+	} else {
+		head, err := store.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		wf = Workflow{ID: id, Name: head.Name, Description: head.Description, Version: head.Version, Data: head.Data}
+	}
+
+	refs, err := HTTPClientRefs(wf.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: workflow data: %v", ErrInvalidBundle, err)
+	}
+
+	b := &Bundle{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: time.Now().UTC(),
+		Workflow:   wf,
+	}
+	for _, uid := range refs {
+		config, err := clients.Config(uid)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnknownHTTPClient, err)
+		}
+		doc, secrets, err := redact(config, keys)
+		if err != nil {
+			return nil, fmt.Errorf("client %q: %w", uid, err)
+		}
+		b.HTTPClients = append(b.HTTPClients, doc)
+		b.Secrets = append(b.Secrets, secrets...)
+	}
+	return b, nil
+}
+
+// HTTPClientRefs returns the distinct http_client_uid values used anywhere
+// in workflow data, sorted
+func HTTPClientRefs(data json.RawMessage) ([]string, error) {
+	doc, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	walkRefs(doc, func(uid string) string {
+		seen[uid] = true
+		return uid
+	})
+
+	refs := make([]string, 0, len(seen))
+	for uid := range seen {
+		refs = append(refs, uid)
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// rewriteRefs returns data with http_client_uid values renamed per renames
+func rewriteRefs(data json.RawMessage, renames map[string]string) (json.RawMessage, error) {
+	if len(renames) == 0 {
+		return data, nil
+	}
+	doc, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	walkRefs(doc, func(uid string) string {
+		if renamed, ok := renames[uid]; ok {
+			return renamed
+		}
+		return uid
+	})
+	return json.Marshal(doc)
+}
+
+// decode parses JSON keeping numbers exact, so rewritten workflows are
+// otherwise unchanged
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// walkRefs calls visit for every http_client_uid string in doc and stores
+// the value it returns
+func walkRefs(doc interface{}, visit func(uid string) string) {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if uid, ok := value.(string); ok && key == "http_client_uid" && uid != "" {
+				v[key] = visit(uid)
+				continue
+			}
+			walkRefs(value, visit)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkRefs(item, visit)
+		}
+	}
+}
+
+// redact encodes config with every secret, and every header and query
+// parameter whose name keys masks, replaced by a placeholder
+func redact(config httpclient.Config, keys *observer.Redactor) (json.RawMessage, []Secret, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	uid := config.UID
+	var secrets []Secret
+	used := make(map[string]bool)
+	replace := func(parent map[string]interface{}, key, name, field string) {
+		if used[name] {
+			name = fmt.Sprintf("%s.%d", name, len(secrets))
+		}
+		used[name] = true
+		parent[key] = Placeholder(name)
+		secrets = append(secrets, Secret{Name: name, ClientUID: uid, Field: field})
+	}
+
+	auth, _ := doc["auth"].(map[string]interface{})
+	if a := config.Auth.BasicAuth; a != nil && !a.Password.IsEmpty() {
+		replace(auth["basic_auth"].(map[string]interface{}), "password", uid+".password", "auth.basic_auth.password")
+	}
+	if a := config.Auth.Token; a != nil && !a.Token.IsEmpty() {
+		replace(auth["token"].(map[string]interface{}), "token", uid+".token", "auth.token.token")
+	}
+	if a := config.Auth.APIKey; a != nil && !a.Value.IsEmpty() {
+		replace(auth["api_key"].(map[string]interface{}), "value", uid+".api_key", "auth.api_key.value")
+	}
+
+	for _, list := range []struct {
+		field string
+		items []httpclient.KeyValue
+	}{
+		{"headers", config.Headers},
+		{"query_params", config.QueryParams},
+	} {
+		encoded, _ := doc[list.field].([]interface{})
+		for i, kv := range list.items {
+			if kv.Value == "" || !keys.SensitiveKey(kv.Key) {
+				continue
+			}
+			replace(encoded[i].(map[string]interface{}), "value",
+				uid+"."+list.field+"."+kv.Key, fmt.Sprintf("%s[%d].value", list.field, i))
+		}
+	}
+
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return redacted, secrets, nil
+}
@@ -0,0 +1,354 @@
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	workflow "github.com/yesoreyeram/thaiyyal/backend"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/httpclient"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+)
+
+const crmWorkflow = `{"nodes": [
+	{"id": "1", "type": "http", "data": {"url": "https://crm.example.com/contacts", "http_client_uid": "crm"}},
+	{"id": "2", "type": "http", "data": {"url": "https://crm.example.com/deals", "http_client_uid": "crm"}}
+], "edges": [{"source": "1", "target": "2"}]}`
+
+// newSource returns a store holding the CRM workflow and a registry with
+// the crm client it references
+func newSource(t *testing.T) (workflow.WorkflowStore, *httpclient.Registry, string) {
+	t.Helper()
+	store := workflow.NewWorkflowRegistry()
+	saved, err := store.Save("", "CRM sync", "Copies contacts", json.RawMessage(crmWorkflow), workflow.VersionInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients := httpclient.NewRegistry()
+	registerClient(t, clients, httpclient.Config{
+		UID: "crm",
+		Auth: httpclient.AuthConfig{
+			Type:  httpclient.AuthTypeBearer,
+			Token: &httpclient.TokenAuthConfig{Token: httpclient.NewSecureString("s3cret-token")},
+		},
+		Headers: []httpclient.KeyValue{
+			{Key: "Accept", Value: "application/json"},
+			{Key: "X-API-Key", Value: "s3cret-key"},
+		},
+	})
+	return store, clients, saved.ID
+}
+
+func registerClient(t *testing.T, clients *httpclient.Registry, config httpclient.Config) {
+	t.Helper()
+	client, err := httpclient.New(context.Background(), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clients.RegisterWithConfig(client, config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// roundTrip encodes and decodes b as it would travel between servers
+func roundTrip(t *testing.T, b *Bundle) *Bundle {
+	t.Helper()
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Bundle
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return &decoded
+}
+
+var crmSecrets = map[string]string{"crm.token": "new-token", "crm.headers.X-API-Key": "new-key"}
+
+func TestExport(t *testing.T) {
+	store, clients, id := newSource(t)
+
+	b, err := Export(store, clients, id, 0, nil)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if b.Format != Format || b.Version != Version || b.Workflow.ID != id || b.Workflow.Version != 1 {
+		t.Errorf("Unexpected bundle header: %+v", b)
+	}
+	if len(b.HTTPClients) != 1 {
+		t.Fatalf("Expected 1 HTTP client, got %d", len(b.HTTPClients))
+	}
+
+	encoded, _ := json.Marshal(b)
+	for _, secret := range []string{"s3cret-token", "s3cret-key", "REDACTED"} {
+		if strings.Contains(string(encoded), secret) {
+			t.Errorf("Bundle contains %q: %s", secret, encoded)
+		}
+	}
+	if !strings.Contains(string(b.HTTPClients[0]), "application/json") {
+		t.Errorf("Non-secret header was redacted: %s", b.HTTPClients[0])
+	}
+
+	var names []string
+	for _, s := range b.Secrets {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "crm.token,crm.headers.X-API-Key" {
+		t.Errorf("Secrets = %v", names)
+	}
+
+	if _, err := Export(store, httpclient.NewRegistry(), id, 0, nil); !errors.Is(err, ErrUnknownHTTPClient) {
+		t.Errorf("Expected ErrUnknownHTTPClient, got %v", err)
+	}
+	if _, err := Export(store, clients, id, 7, nil); !errors.Is(err, workflow.ErrVersionNotFound) {
+		t.Errorf("Expected ErrVersionNotFound, got %v", err)
+	}
+}
+
+func TestExport_SensitiveNames(t *testing.T) {
+	store, _, id := newSource(t)
+	clients := httpclient.NewRegistry()
+	registerClient(t, clients, httpclient.Config{
+		UID: "crm",
+		Headers: []httpclient.KeyValue{
+			{Key: "Accept", Value: "application/json"},
+			{Key: "Credential", Value: "v1"},
+			{Key: "Private_Key", Value: "v2"},
+			{Key: "X-Session-Cookie", Value: "v3"},
+			{Key: "X-Tenant", Value: "v4"},
+		},
+		QueryParams: []httpclient.KeyValue{
+			{Key: "key", Value: "v5"},
+			{Key: "sig", Value: "v6"},
+			{Key: "page", Value: "1"},
+		},
+	})
+
+	secretNames := func(keys *observer.Redactor) string {
+		t.Helper()
+		b, err := Export(store, clients, id, 0, keys)
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		var names []string
+		for _, s := range b.Secrets {
+			names = append(names, s.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	// Without a redactor the names hidden in execution events are hidden
+	want := "crm.headers.Credential,crm.headers.Private_Key,crm.headers.X-Session-Cookie,crm.query_params.key,crm.query_params.sig"
+	if got := secretNames(nil); got != want {
+		t.Errorf("Secrets = %s, want %s", got, want)
+	}
+
+	// A server's own redaction keys apply to exports too
+	keys, err := observer.NewRedactor("tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := secretNames(keys); got != "crm.headers.X-Tenant" {
+		t.Errorf("Secrets with custom keys = %s, want crm.headers.X-Tenant", got)
+	}
+}
+
+func TestImport(t *testing.T) {
+	srcStore, srcClients, id := newSource(t)
+	exported, err := Export(srcStore, srcClients, id, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := roundTrip(t, exported)
+
+	store := workflow.NewWorkflowRegistry()
+	clients := httpclient.NewRegistry()
+
+	if _, err := Import(context.Background(), b, store, clients, Options{}); !errors.Is(err, ErrMissingSecret) ||
+		!strings.Contains(err.Error(), "crm.headers.X-API-Key, crm.token") {
+		t.Fatalf("Expected ErrMissingSecret naming both secrets, got %v", err)
+	}
+
+	result, err := Import(context.Background(), b, store, clients, Options{Secrets: crmSecrets, Author: "ops"})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Workflow.Action != ActionCreate || result.HTTPClients[0].Action != ActionCreate {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	config, err := clients.Config("crm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Auth.Token.Token.Value() != "new-token" || config.Headers[1].Value != "new-key" {
+		t.Errorf("Secrets not filled in: %+v", config)
+	}
+	versions, _ := store.Versions(result.Workflow.ID)
+	if len(versions) != 1 || versions[0].Author != "ops" || versions[0].Name != "CRM sync" {
+		t.Errorf("Unexpected saved versions: %+v", versions)
+	}
+}
+
+func TestImportPolicies(t *testing.T) {
+	srcStore, srcClients, id := newSource(t)
+	exported, err := Export(srcStore, srcClients, id, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		policy         Policy
+		dryRun         bool
+		expectedErr    error
+		workflowAction Action
+		workflowName   string
+		clientUID      string
+		workflows      int
+		clients        int
+	}{
+		{"Fail", PolicyFail, false, ErrConflict, "", "", "", 1, 1},
+		{"Overwrite", PolicyOverwrite, false, nil, ActionOverwrite, "CRM sync", "crm", 1, 1},
+		{"Rename", PolicyRename, false, nil, ActionRename, "CRM sync (2)", "crm-2", 2, 2},
+		{"Skip", PolicySkip, false, nil, ActionSkip, "CRM sync", "crm", 1, 1},
+		{"Rename dry run", PolicyRename, true, nil, ActionRename, "CRM sync (2)", "crm-2", 1, 1},
+		{"Unknown policy", Policy("merge"), false, ErrInvalidPolicy, "", "", "", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The target already has the same workflow and client
+			store, clients, _ := newSource(t)
+
+			result, err := Import(context.Background(), roundTrip(t, exported), store, clients, Options{
+				Policy:  tt.policy,
+				Secrets: crmSecrets,
+				DryRun:  tt.dryRun,
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if store.Count() != tt.workflows || clients.Count() != tt.clients {
+				t.Errorf("Target has %d workflows and %d clients, want %d and %d",
+					store.Count(), clients.Count(), tt.workflows, tt.clients)
+			}
+			if err != nil {
+				return
+			}
+
+			if result.DryRun != tt.dryRun || result.Workflow.Action != tt.workflowAction ||
+				result.Workflow.Name != tt.workflowName || result.HTTPClients[0].UID != tt.clientUID {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+			if tt.dryRun {
+				return
+			}
+
+			head, err := store.Get(result.Workflow.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			refs, _ := HTTPClientRefs(head.Data)
+			if len(refs) != 1 || refs[0] != tt.clientUID {
+				t.Errorf("Workflow references %v, want [%s]", refs, tt.clientUID)
+			}
+			config, _ := clients.Config(tt.clientUID)
+			wantToken := "new-token"
+			if tt.policy == PolicySkip {
+				wantToken = "s3cret-token"
+			}
+			if config.Auth.Token.Token.Value() != wantToken {
+				t.Errorf("Client token = %q, want %q", config.Auth.Token.Token.Value(), wantToken)
+			}
+		})
+	}
+}
+
+// failingStore is a workflow store whose saves fail
+type failingStore struct {
+	workflow.WorkflowStore
+}
+
+func (failingStore) Save(id, name, description string, data json.RawMessage, info workflow.VersionInfo) (*workflow.WorkflowMeta, error) {
+	return nil, errors.New("disk full")
+}
+
+func TestImportRollback(t *testing.T) {
+	srcStore, srcClients, id := newSource(t)
+	exported, err := Export(srcStore, srcClients, id, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policy := range []Policy{PolicyOverwrite, PolicyRename} {
+		t.Run(string(policy), func(t *testing.T) {
+			store, clients, _ := newSource(t)
+			before, _ := clients.Get("crm")
+
+			_, err := Import(context.Background(), roundTrip(t, exported), failingStore{store}, clients, Options{
+				Policy:  policy,
+				Secrets: crmSecrets,
+			})
+			if err == nil {
+				t.Fatal("Expected the failed save to fail the import")
+			}
+
+			if clients.Count() != 1 {
+				t.Errorf("Target has clients %v, want only crm", clients.List())
+			}
+			after, _ := clients.Get("crm")
+			config, err := clients.Config("crm")
+			if err != nil || after != before || config.Auth.Token.Token.Value() != "s3cret-token" {
+				t.Errorf("Client crm was not restored: %+v, %v", config, err)
+			}
+		})
+	}
+}
+
+func TestImportValidation(t *testing.T) {
+	valid := func() *Bundle {
+		return &Bundle{
+			Format:   Format,
+			Version:  Version,
+			Workflow: Workflow{Name: "w", Data: json.RawMessage(crmWorkflow)},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(b *Bundle)
+		expectedErr error
+	}{
+		{"Wrong format", func(b *Bundle) { b.Format = "other" }, ErrInvalidBundle},
+		{"Future version", func(b *Bundle) { b.Version = Version + 1 }, ErrUnsupportedVersion},
+		{"Missing name", func(b *Bundle) { b.Workflow.Name = "" }, ErrInvalidBundle},
+		{"Bad data", func(b *Bundle) { b.Workflow.Data = json.RawMessage(`[1]`) }, ErrInvalidBundle},
+		{"Duplicate client", func(b *Bundle) {
+			b.HTTPClients = []json.RawMessage{json.RawMessage(`{"uid": "crm"}`), json.RawMessage(`{"uid": "crm"}`)}
+		}, ErrInvalidBundle},
+		{"Unresolved reference", func(b *Bundle) {}, ErrUnknownHTTPClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := valid()
+			tt.modify(b)
+			_, err := Import(context.Background(), b, workflow.NewWorkflowRegistry(), httpclient.NewRegistry(), Options{})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	// A reference to a client the target already has needs no bundled config
+	clients := httpclient.NewRegistry()
+	if err := clients.Register("crm", http.DefaultClient); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(context.Background(), valid(), workflow.NewWorkflowRegistry(), clients, Options{}); err != nil {
+		t.Errorf("Import with a registered client failed: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+// Package bundle moves saved workflows between servers.
+//
+// # Overview
+//
+// A Bundle is a versioned JSON document holding one workflow version and
+// the configs of the HTTP clients it references through http_client_uid.
+// Export builds a bundle from a workflow store and an HTTP client registry;
+// Import applies one to another pair:
+//
+//	b, err := bundle.Export(devStore, devClients, "wf-1", 0, nil) // 0 = latest version
+//	...
+//	result, err := bundle.Import(ctx, b, prodStore, prodClients, bundle.Options{
+//	    Policy:  bundle.PolicyRename,
+//	    Secrets: map[string]string{"crm.token": os.Getenv("CRM_TOKEN")},
+//	})
+//
+// # Secrets
+//
+// Exported client configs never carry credentials. Every secret, such as a
+// password, token, API key or a credential-like header or query parameter,
+// is replaced by a placeholder of the form ${secret:<name>} and listed in
+// Bundle.Secrets. Import requires a value for every placeholder.
+//
+// # Conflicts
+//
+// A bundled workflow conflicts with a target workflow that has the same ID
+// or, failing that, the same name. A bundled HTTP client conflicts with a
+// registered client that has the same UID. The Policy decides what happens:
+//
+//   - PolicyFail (default): nothing is imported and ErrConflict is returned
+//   - PolicyOverwrite: the workflow is saved as a new version of the
+//     conflicting one and the client replaces the registered one
+//   - PolicyRename: a new workflow named "<name> (2)" and a client with
+//     UID "<uid>-2" are created; the workflow's references are rewritten
+//   - PolicySkip: the existing workflow or client is kept
+//
+// Import validates the whole bundle and plans every action before it
+// changes anything. With Options.DryRun it returns the plan without
+// applying it.
+package bundle
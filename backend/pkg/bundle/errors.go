@@ -0,0 +1,13 @@
+package bundle
+
+import "errors"
+
+// Sentinel errors for bundles
+var (
+	ErrInvalidBundle      = errors.New("invalid bundle")
+	ErrUnsupportedVersion = errors.New("unsupported bundle version")
+	ErrMissingSecret      = errors.New("missing secret")
+	ErrUnknownHTTPClient  = errors.New("unknown HTTP client")
+	ErrConflict           = errors.New("import conflict")
+	ErrInvalidPolicy      = errors.New("invalid import policy")
+)
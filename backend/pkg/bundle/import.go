@@ -0,0 +1,406 @@
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	workflow "github.com/yesoreyeram/thaiyyal/backend"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/httpclient"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// Policy decides how Import handles a workflow or client that already
+// exists in the target
+type Policy string
+
+// Import policies
+const (
+	PolicyFail      Policy = "fail"
+	PolicyOverwrite Policy = "overwrite"
+	PolicyRename    Policy = "rename"
+	PolicySkip      Policy = "skip"
+)
+
+// ParsePolicy parses a policy name. An empty name is PolicyFail.
+func ParsePolicy(name string) (Policy, error) {
+	switch p := Policy(strings.ToLower(name)); p {
+	case "":
+		return PolicyFail, nil
+	case PolicyFail, PolicyOverwrite, PolicyRename, PolicySkip:
+		return p, nil
+	}
+	return "", fmt.Errorf("%w: %q (want fail, overwrite, rename or skip)", ErrInvalidPolicy, name)
+}
+
+// Options configures Import
+type Options struct {
+	// Policy applies to every conflict; the zero value is PolicyFail
+	Policy Policy
+
+	// Secrets holds the value of every placeholder in the bundle by name
+	Secrets map[string]string
+
+	// DryRun plans the import without changing the target
+	DryRun bool
+
+	// Author is recorded on the saved workflow version
+	Author string
+}
+
+// Action is what Import does with one workflow or client
+type Action string
+
+// Import actions
+const (
+	ActionCreate    Action = "create"
+	ActionOverwrite Action = "overwrite"
+	ActionRename    Action = "rename"
+	ActionSkip      Action = "skip"
+)
+
+// Result describes an import, or the plan of a dry run
+type Result struct {
+	DryRun      bool           `json:"dry_run"`
+	Workflow    WorkflowResult `json:"workflow"`
+	HTTPClients []ClientResult `json:"http_clients,omitempty"`
+}
+
+// WorkflowResult describes what happened to the bundled workflow. ID and
+// Version are empty when a dry run would create a new workflow.
+type WorkflowResult struct {
+	Action  Action `json:"action"`
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Version int    `json:"version,omitempty"`
+}
+
+// ClientResult describes what happened to one bundled HTTP client
+type ClientResult struct {
+	Action    Action `json:"action"`
+	UID       string `json:"uid"`
+	SourceUID string `json:"source_uid"`
+}
+
+// placeholderPattern matches a whole ${secret:<name>} value
+var placeholderPattern = regexp.MustCompile(`^\$\{secret:([^}]+)\}$`)
+
+// Validate checks the bundle's format and contents without secrets
+func Validate(b *Bundle) error {
+	if b == nil || b.Format != Format {
+		return fmt.Errorf("%w: format must be %q", ErrInvalidBundle, Format)
+	}
+	if b.Version != Version {
+		return fmt.Errorf("%w: %d (supported: %d)", ErrUnsupportedVersion, b.Version, Version)
+	}
+	if b.Workflow.Name == "" {
+		return fmt.Errorf("%w: workflow name is required", ErrInvalidBundle)
+	}
+	var payload types.Payload
+	if err := json.Unmarshal(b.Workflow.Data, &payload); err != nil {
+		return fmt.Errorf("%w: workflow data: %v", ErrInvalidBundle, err)
+	}
+	if _, err := params.Schema(b.Workflow.Data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	uids := make(map[string]bool)
+	for i, doc := range b.HTTPClients {
+		var config httpclient.Config
+		if err := json.Unmarshal(doc, &config); err != nil {
+			return fmt.Errorf("%w: http_clients[%d]: %v", ErrInvalidBundle, i, err)
+		}
+		if config.UID == "" {
+			return fmt.Errorf("%w: http_clients[%d]: uid is required", ErrInvalidBundle, i)
+		}
+		if uids[config.UID] {
+			return fmt.Errorf("%w: duplicate HTTP client %q", ErrInvalidBundle, config.UID)
+		}
+		uids[config.UID] = true
+	}
+	return nil
+}
+
+// Import applies b to store and clients. Every check runs before the
+// first change, the workflow is saved last, and HTTP clients registered
+// or replaced are restored when a later step fails, so a failed import
+// leaves the target untouched.
+func Import(ctx context.Context, b *Bundle, store workflow.WorkflowStore, clients *httpclient.Registry, opts Options) (*Result, error) {
+	if err := Validate(b); err != nil {
+		return nil, err
+	}
+	policy, err := ParsePolicy(string(opts.Policy))
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := resolveClients(b.HTTPClients, opts.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := HTTPClientRefs(b.Workflow.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: workflow data: %v", ErrInvalidBundle, err)
+	}
+	bundled := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		bundled[config.UID] = true
+	}
+	var unknown []string
+	for _, uid := range refs {
+		if !bundled[uid] && !clients.Has(uid) {
+			unknown = append(unknown, uid)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHTTPClient, strings.Join(unknown, ", "))
+	}
+
+	// Plan
+	result := &Result{DryRun: opts.DryRun}
+	var conflicts []string
+	renames := make(map[string]string)
+	for _, config := range configs {
+		planned := ClientResult{Action: ActionCreate, UID: config.UID, SourceUID: config.UID}
+		if clients.Has(config.UID) {
+			planned.Action = actionFor(policy)
+			switch policy {
+			case PolicyFail:
+				conflicts = append(conflicts, fmt.Sprintf("HTTP client %q exists", config.UID))
+			case PolicyRename:
+				planned.UID = freeUID(clients, config.UID)
+				renames[config.UID] = planned.UID
+			}
+		}
+		result.HTTPClients = append(result.HTTPClients, planned)
+	}
+
+	result.Workflow = WorkflowResult{Action: ActionCreate, Name: b.Workflow.Name}
+	if existing := findWorkflow(store, b.Workflow); existing != nil {
+		result.Workflow.Action = actionFor(policy)
+		switch policy {
+		case PolicyFail:
+			conflicts = append(conflicts, fmt.Sprintf("workflow %q exists as %s", existing.Name, existing.ID))
+		case PolicyOverwrite, PolicySkip:
+			result.Workflow.ID = existing.ID
+			result.Workflow.Name = existing.Name
+			result.Workflow.Version = existing.Version
+		case PolicyRename:
+			result.Workflow.Name = freeName(store, b.Workflow.Name)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrConflict, strings.Join(conflicts, "; "))
+	}
+
+	data, err := rewriteRefs(b.Workflow.Data, renames)
+	if err != nil {
+		return nil, fmt.Errorf("%w: workflow data: %v", ErrInvalidBundle, err)
+	}
+
+	built := make([]*http.Client, len(configs))
+	for i := range configs {
+		if result.HTTPClients[i].Action == ActionSkip {
+			continue
+		}
+		configs[i].UID = result.HTTPClients[i].UID
+		client, err := httpclient.New(ctx, &configs[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: HTTP client %q: %v", ErrInvalidBundle, result.HTTPClients[i].SourceUID, err)
+		}
+		built[i] = client
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// Apply. The workflow is saved last, and the clients are put back as
+	// they were when a later step fails.
+	var undo []func()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+	for i, planned := range result.HTTPClients {
+		switch planned.Action {
+		case ActionSkip:
+			continue
+		case ActionOverwrite:
+			restore, err := unregister(clients, planned.UID)
+			if err != nil {
+				rollback()
+				return nil, err
+			}
+			undo = append(undo, restore)
+		}
+		if err := clients.RegisterWithConfig(built[i], configs[i]); err != nil {
+			rollback()
+			return nil, fmt.Errorf("HTTP client %q: %w", planned.UID, err)
+		}
+		uid := planned.UID
+		undo = append(undo, func() { _ = clients.Unregister(uid) })
+	}
+
+	if result.Workflow.Action == ActionSkip {
+		return result, nil
+	}
+	info := workflow.VersionInfo{
+		Author:  opts.Author,
+		Message: fmt.Sprintf("Imported from bundle (source %s version %d)", b.Workflow.ID, b.Workflow.Version),
+	}
+	saved, err := store.Save(result.Workflow.ID, result.Workflow.Name, b.Workflow.Description, data, info)
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+	result.Workflow.ID = saved.ID
+	result.Workflow.Version = saved.Version
+	return result, nil
+}
+
+// unregister removes the client uid and returns a function registering it
+// again as it was
+func unregister(clients *httpclient.Registry, uid string) (func(), error) {
+	client, err := clients.Get(uid)
+	if err != nil {
+		return nil, err
+	}
+	config, configErr := clients.Config(uid)
+	if err := clients.Unregister(uid); err != nil {
+		return nil, err
+	}
+	return func() {
+		if configErr != nil {
+			_ = clients.Register(uid, client)
+			return
+		}
+		_ = clients.RegisterWithConfig(client, config)
+	}, nil
+}
+
+// resolveClients fills the placeholders of every client config and checks
+// the result. Missing secrets are reported together.
+func resolveClients(docs []json.RawMessage, secrets map[string]string) ([]httpclient.Config, error) {
+	configs := make([]httpclient.Config, 0, len(docs))
+	missing := make(map[string]bool)
+	for _, raw := range docs {
+		var doc interface{}
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		doc = fillSecrets(doc, secrets, missing)
+		filled, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		var config httpclient.Config
+		if err := json.Unmarshal(filled, &config); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		configs = append(configs, config)
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %s", ErrMissingSecret, strings.Join(names, ", "))
+	}
+
+	for i := range configs {
+		configs[i].ApplyDefaults()
+		if err := configs[i].Validate(); err != nil {
+			return nil, fmt.Errorf("%w: HTTP client %q: %v", ErrInvalidBundle, configs[i].UID, err)
+		}
+	}
+	return configs, nil
+}
+
+// fillSecrets replaces every placeholder string in doc with its secret,
+// recording names without a value in missing
+func fillSecrets(doc interface{}, secrets map[string]string, missing map[string]bool) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = fillSecrets(value, secrets, missing)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = fillSecrets(item, secrets, missing)
+		}
+	case string:
+		if m := placeholderPattern.FindStringSubmatch(v); m != nil {
+			value, ok := secrets[m[1]]
+			if !ok {
+				missing[m[1]] = true
+			}
+			return value
+		}
+	}
+	return doc
+}
+
+// findWorkflow returns the target workflow the bundled one conflicts with:
+// the one with the same ID, else the first with the same name
+func findWorkflow(store workflow.WorkflowStore, wf Workflow) *workflow.WorkflowMeta {
+	if wf.ID != "" {
+		if existing, err := store.Get(wf.ID); err == nil {
+			return existing
+		}
+	}
+	for _, summary := range store.List() {
+		if summary.Name == wf.Name {
+			if existing, err := store.Get(summary.ID); err == nil {
+				return existing
+			}
+		}
+	}
+	return nil
+}
+
+// freeName returns the first of "<name> (2)", "<name> (3)", ... not used
+// by a workflow in store
+func freeName(store workflow.WorkflowStore, name string) string {
+	used := make(map[string]bool)
+	for _, summary := range store.List() {
+		used[summary.Name] = true
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// freeUID returns the first of "<uid>-2", "<uid>-3", ... not registered
+func freeUID(clients *httpclient.Registry, uid string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", uid, n)
+		if !clients.Has(candidate) {
+			return candidate
+		}
+	}
+}
+
+// actionFor returns the action a conflict gets under policy
+func actionFor(policy Policy) Action {
+	switch policy {
+	case PolicyOverwrite:
+		return ActionOverwrite
+	case PolicyRename:
+		return ActionRename
+	case PolicySkip:
+		return ActionSkip
+	}
+	return ""
+}
@@ -2,6 +2,15 @@ package config
 
 import (
 	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
+)
+
+// Numeric modes for arithmetic in operation, reduce, group_by, accumulator
+// and expression nodes
+const (
+	NumericModeFloat   = "float"   // IEEE-754 float64 arithmetic (default)
+	NumericModeDecimal = "decimal" // Exact arbitrary-precision decimal arithmetic
 )
 
 // Config holds workflow engine configuration.
@@ -37,19 +46,40 @@ type Config struct {
 	MaxCacheSize    int           // Maximum number of cache entries (LRU eviction)
 
 	// Resource limits
-	MaxInputSize      int // Maximum size of input data (bytes)
-	MaxPayloadSize    int // Maximum size of workflow payload (bytes)
-	MaxNodes          int // Maximum number of nodes in workflow
-	MaxEdges          int // Maximum number of edges in workflow
-	MaxNodeExecutions int // Maximum total node executions (including loop iterations, 0 = unlimited)
-	MaxStringLength   int // Maximum length of string values (0 = unlimited)
-	MaxArrayLength    int // Maximum length of array values (0 = unlimited)
-	MaxVariables      int // Maximum number of variables in workflow state (0 = unlimited)
-	MaxContextDepth   int // Maximum depth of nested objects/arrays (0 = unlimited)
+	MaxInputSize        int // Maximum size of input data (bytes)
+	MaxPayloadSize      int // Maximum size of workflow payload (bytes)
+	MaxNodes            int // Maximum number of nodes in workflow
+	MaxEdges            int // Maximum number of edges in workflow
+	MaxNodeExecutions   int // Maximum total node executions (including loop iterations, 0 = unlimited)
+	MaxStringLength     int // Maximum length of string values (0 = unlimited)
+	MaxArrayLength      int // Maximum length of array values (0 = unlimited)
+	MaxVariables        int // Maximum number of variables in workflow state (0 = unlimited)
+	MaxContextDepth     int // Maximum depth of nested objects/arrays (0 = unlimited)
+	MaxSubWorkflowDepth int // Maximum nesting of sub_workflow nodes (0 = unlimited)
 
 	// Retry configuration
 	DefaultMaxAttempts int           // Default max retry attempts
 	DefaultBackoff     time.Duration // Default initial backoff delay
+
+	// Date/time configuration
+	DefaultTimezone string // IANA time zone for zone-less dates, e.g. "Europe/London" (empty = UTC)
+
+	// Expression evaluation budget (applied per node execution)
+	MaxExpressionSteps      int // Maximum expression evaluation steps per node (0 = unlimited)
+	MaxExpressionDepth      int // Maximum expression recursion depth (0 = unlimited)
+	MaxExpressionOutputSize int // Maximum elements produced by a single expression function (0 = unlimited)
+
+	// Expression semantics
+	StrictExpressions bool // Missing node, variable and field references are errors instead of null
+
+	// Numeric configuration
+	NumericMode     string // "float" (default) or "decimal" for exact money arithmetic
+	DecimalScale    int    // Fractional digits kept by decimal division and averages
+	DecimalRounding string // Rounding mode for decimal division, e.g. "half_even", "half_up"
+
+	// Determinism - for reproducible tests and replays
+	Clock      func() time.Time `json:"-"` // Source of now() in expressions (nil = wall clock); not serialized in snapshots
+	RandomSeed int64            // Seed for sample() and sample nodes (0 = non-deterministic)
 }
 
 // Default returns a Config with secure, production-ready default values.
@@ -81,19 +111,40 @@ func Default() *Config {
 		MaxCacheSize:    1000,
 
 		// Resource limits
-		MaxInputSize:      1024 * 1024,      // 1MB
-		MaxPayloadSize:    10 * 1024 * 1024, // 10MB
-		MaxNodes:          1000,
-		MaxEdges:          5000,
-		MaxNodeExecutions: 0, // unlimited
-		MaxStringLength:   0, // unlimited
-		MaxArrayLength:    0, // unlimited
-		MaxVariables:      0, // unlimited
-		MaxContextDepth:   0, // unlimited
+		MaxInputSize:        1024 * 1024,      // 1MB
+		MaxPayloadSize:      10 * 1024 * 1024, // 10MB
+		MaxNodes:            1000,
+		MaxEdges:            5000,
+		MaxNodeExecutions:   0, // unlimited
+		MaxStringLength:     0, // unlimited
+		MaxArrayLength:      0, // unlimited
+		MaxVariables:        0, // unlimited
+		MaxContextDepth:     0, // unlimited
+		MaxSubWorkflowDepth: 8,
 
 		// Retry configuration
 		DefaultMaxAttempts: 3,
 		DefaultBackoff:     1 * time.Second,
+
+		// Date/time configuration
+		DefaultTimezone: "", // UTC
+
+		// Expression evaluation budget
+		MaxExpressionSteps:      1000000,
+		MaxExpressionDepth:      256,
+		MaxExpressionOutputSize: 100000,
+
+		// Expression semantics
+		StrictExpressions: false, // missing references evaluate to null
+
+		// Numeric configuration
+		NumericMode:     NumericModeFloat,
+		DecimalScale:    16,
+		DecimalRounding: string(decimal.RoundHalfEven),
+
+		// Determinism
+		Clock:      nil, // wall clock
+		RandomSeed: 0,   // non-deterministic
 	}
 }
 
@@ -164,6 +215,26 @@ func (c *Config) Validate() error {
 	if c.DefaultBackoff < 0 {
 		return ErrInvalidBackoff
 	}
+	if c.MaxSubWorkflowDepth < 0 {
+		return ErrInvalidSubWorkflowDepth
+	}
+	if c.MaxExpressionSteps < 0 || c.MaxExpressionDepth < 0 || c.MaxExpressionOutputSize < 0 {
+		return ErrInvalidExpressionLimit
+	}
+	if c.DefaultTimezone != "" {
+		if _, err := time.LoadLocation(c.DefaultTimezone); err != nil {
+			return ErrInvalidTimezone
+		}
+	}
+	if c.NumericMode != "" && c.NumericMode != NumericModeFloat && c.NumericMode != NumericModeDecimal {
+		return ErrInvalidNumericMode
+	}
+	if c.DecimalScale < 0 {
+		return ErrInvalidDecimalScale
+	}
+	if _, err := decimal.ParseRoundingMode(c.DecimalRounding); err != nil {
+		return ErrInvalidRoundingMode
+	}
 	return nil
 }
 
@@ -68,6 +68,9 @@
 // MaxEdges: 5000
 // DefaultMaxAttempts: 3
 // DefaultBackoff: 1 second
+// NumericMode: "float" ("decimal" for exact money arithmetic)
+// DecimalScale: 16
+// DecimalRounding: half_even
 //
 // # Thread Safety
 //
@@ -21,17 +21,29 @@ var (
 	ErrInvalidMaxCacheSize = errors.New("invalid max cache size: must be non-negative")
 
 	// Resource limit errors
-	ErrInvalidInputSize    = errors.New("invalid max input size: must be non-negative")
-	ErrInvalidPayloadSize  = errors.New("invalid max payload size: must be non-negative")
-	ErrInvalidMaxNodes     = errors.New("invalid max nodes: must be non-negative")
-	ErrInvalidMaxEdges     = errors.New("invalid max edges: must be non-negative")
-	ErrInvalidStringLength = errors.New("invalid max string length: must be non-negative")
-	ErrInvalidArrayLength  = errors.New("invalid max array length: must be non-negative")
+	ErrInvalidInputSize        = errors.New("invalid max input size: must be non-negative")
+	ErrInvalidPayloadSize      = errors.New("invalid max payload size: must be non-negative")
+	ErrInvalidMaxNodes         = errors.New("invalid max nodes: must be non-negative")
+	ErrInvalidMaxEdges         = errors.New("invalid max edges: must be non-negative")
+	ErrInvalidStringLength     = errors.New("invalid max string length: must be non-negative")
+	ErrInvalidArrayLength      = errors.New("invalid max array length: must be non-negative")
+	ErrInvalidSubWorkflowDepth = errors.New("invalid max sub-workflow depth: must be non-negative")
+
+	// Expression budget errors
+	ErrInvalidExpressionLimit = errors.New("invalid expression limit: must be non-negative")
 
 	// Retry configuration errors
 	ErrInvalidMaxAttempts = errors.New("invalid max attempts: must be positive")
 	ErrInvalidBackoff     = errors.New("invalid backoff duration: must be non-negative")
 
+	// Date/time configuration errors
+	ErrInvalidTimezone = errors.New("invalid default timezone: must be an IANA time zone name")
+
+	// Numeric configuration errors
+	ErrInvalidNumericMode  = errors.New("invalid numeric mode: must be \"float\" or \"decimal\"")
+	ErrInvalidDecimalScale = errors.New("invalid decimal scale: must be non-negative")
+	ErrInvalidRoundingMode = errors.New("invalid decimal rounding mode")
+
 	// File loading errors
 	ErrConfigFileNotFound = errors.New("configuration file not found")
 	ErrInvalidConfigFile  = errors.New("invalid configuration file format")
@@ -0,0 +1,424 @@
+package decimal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExponent bounds the exponent accepted by Parse so inputs such as
+// "1e999999999" cannot force huge allocations
+const maxExponent = 10000
+
+// DefaultMaxDigits bounds the digits of a Pow result, in its coefficient
+// and in its scale, when no smaller limit is given
+const DefaultMaxDigits = 100000
+
+// Decimal is an immutable arbitrary-precision decimal number.
+// Its value is coef × 10^-scale. The zero value is 0.
+type Decimal struct {
+	coef  *big.Int // nil means zero
+	scale int32    // number of digits after the decimal point, always >= 0
+}
+
+// Zero is the decimal 0
+var Zero = Decimal{}
+
+// New returns unscaled × 10^-scale, e.g. New(1999, 2) is 19.99
+func New(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{coef: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+	return Decimal{coef: big.NewInt(unscaled), scale: scale}
+}
+
+// NewFromInt returns the decimal value of n
+func NewFromInt(n int64) Decimal {
+	return Decimal{coef: big.NewInt(n)}
+}
+
+// NewFromFloat returns the shortest decimal that round-trips to f, so
+// NewFromFloat(0.1) is exactly 0.1. NaN and infinities are rejected.
+func NewFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Zero, fmt.Errorf("%w: %v", ErrInvalidNumber, f)
+	}
+	return Parse(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// Parse parses a decimal literal such as "-12.50" or "1.5e3"
+func Parse(s string) (Decimal, error) {
+	text := strings.TrimSpace(s)
+	mantissa, exponent := text, 0
+	if idx := strings.IndexAny(text, "eE"); idx >= 0 {
+		exp, err := strconv.Atoi(text[idx+1:])
+		if err != nil || exp > maxExponent || exp < -maxExponent {
+			return Zero, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+		}
+		mantissa, exponent = text[:idx], exp
+	}
+
+	negative := false
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		negative = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+
+	intPart, fracPart := mantissa, ""
+	if idx := strings.IndexByte(mantissa, '.'); idx >= 0 {
+		intPart, fracPart = mantissa[:idx], mantissa[idx+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		coef.Neg(coef)
+	}
+	scale := len(fracPart) - exponent
+	if scale < 0 {
+		coef.Mul(coef, pow10(int32(-scale)))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// RequireFromString is like Parse but panics on error. Intended for tests
+// and constants.
+func RequireFromString(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// FromValue converts a workflow value to a decimal. It accepts Decimal,
+// json.Number, numeric strings and all Go integer and float types.
+func FromValue(v interface{}) (Decimal, bool) {
+	switch n := v.(type) {
+	case Decimal:
+		return n, true
+	case json.Number:
+		d, err := Parse(string(n))
+		return d, err == nil
+	case string:
+		d, err := Parse(n)
+		return d, err == nil
+	case float64:
+		d, err := NewFromFloat(n)
+		return d, err == nil
+	case float32:
+		d, err := NewFromFloat(float64(n))
+		return d, err == nil
+	case int:
+		return NewFromInt(int64(n)), true
+	case int8:
+		return NewFromInt(int64(n)), true
+	case int16:
+		return NewFromInt(int64(n)), true
+	case int32:
+		return NewFromInt(int64(n)), true
+	case int64:
+		return NewFromInt(n), true
+	case uint:
+		return Decimal{coef: new(big.Int).SetUint64(uint64(n))}, true
+	case uint8:
+		return NewFromInt(int64(n)), true
+	case uint16:
+		return NewFromInt(int64(n)), true
+	case uint32:
+		return NewFromInt(int64(n)), true
+	case uint64:
+		return Decimal{coef: new(big.Int).SetUint64(n)}, true
+	}
+	return Zero, false
+}
+
+// ============================================================================
+// Arithmetic
+// ============================================================================
+
+// Add returns d + e
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - e
+func (d Decimal) Sub(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul returns d × e
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), e.int()), scale: d.scale + e.scale}
+}
+
+// Div returns d ÷ e rounded to at most scale fractional digits using mode.
+// Trailing zeros are removed, so 10 ÷ 4 is 2.5 rather than 2.5000.
+func (d Decimal) Div(e Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	if e.IsZero() {
+		return Zero, ErrDivisionByZero
+	}
+	if scale < 0 {
+		scale = 0
+	}
+
+	// d/e = (dc × 10^-ds) / (ec × 10^-es); scale the numerator so the
+	// integer quotient carries the requested number of fractional digits
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(e.int())
+	shift := scale + e.scale - d.scale
+	if shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	q = roundQuotient(q, r, den, num.Sign()*den.Sign() < 0, mode)
+	return Decimal{coef: q, scale: scale}.Reduce(), nil
+}
+
+// Mod returns the remainder of d ÷ e truncated toward zero; the result has
+// the sign of d
+func (d Decimal) Mod(e Decimal) (Decimal, error) {
+	if e.IsZero() {
+		return Zero, ErrDivisionByZero
+	}
+	a, b, scale := align(d, e)
+	return Decimal{coef: new(big.Int).Rem(a, b), scale: scale}, nil
+}
+
+// Pow returns d raised to an integer power. Negative powers divide using
+// scale and mode. Results over DefaultMaxDigits digits are rejected.
+func (d Decimal) Pow(n int64, scale int32, mode RoundingMode) (Decimal, error) {
+	return d.pow(n, scale, mode, DefaultMaxDigits)
+}
+
+// pow is Pow with a limit on the digits of the result. The size is
+// estimated before computing, since big.Int.Exp cannot be interrupted.
+func (d Decimal) pow(n int64, scale int32, mode RoundingMode, maxDigits int64) (Decimal, error) {
+	if n < 0 {
+		p, err := d.pow(-n, scale, mode, maxDigits)
+		if err != nil {
+			return Zero, err
+		}
+		return NewFromInt(1).Div(p, scale, mode)
+	}
+	if n > maxExponent {
+		return Zero, fmt.Errorf("%w: exponent %d too large", ErrInvalidNumber, n)
+	}
+
+	// log10(2) < 0.30103, so bits × n × 0.30103 + 1 bounds the digits
+	digits := int64(float64(int64(d.int().BitLen())*n)*0.30103) + 1
+	resultScale := int64(d.scale) * n
+	if digits > maxDigits || resultScale > maxDigits || resultScale > math.MaxInt32 {
+		return Zero, fmt.Errorf("%w: power %d result exceeds %d digits", ErrInvalidNumber, n, maxDigits)
+	}
+	coef := new(big.Int).Exp(d.int(), big.NewInt(n), nil)
+	return Decimal{coef: coef, scale: int32(resultScale)}, nil
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Round rounds d to places fractional digits using mode
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return d
+	}
+	den := pow10(d.scale - places)
+	q, r := new(big.Int).QuoRem(d.int(), den, new(big.Int))
+	return Decimal{coef: roundQuotient(q, r, den, d.Sign() < 0, mode), scale: places}
+}
+
+// Reduce removes trailing fractional zeros: 2.500 becomes 2.5
+func (d Decimal) Reduce() Decimal {
+	if d.coef == nil || d.scale == 0 {
+		return d
+	}
+	coef := new(big.Int).Set(d.coef)
+	scale := d.scale
+	ten := big.NewInt(10)
+	q, r := new(big.Int), new(big.Int)
+	for scale > 0 {
+		q.QuoRem(coef, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		coef.Set(q)
+		scale--
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// ============================================================================
+// Comparison and Conversion
+// ============================================================================
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than e
+func (d Decimal) Cmp(e Decimal) int {
+	a, b, _ := align(d, e)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and e have the same value, regardless of scale
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// IsInteger reports whether d has no fractional part
+func (d Decimal) IsInteger() bool {
+	return d.Reduce().scale == 0
+}
+
+// Scale returns the number of fractional digits d carries
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Int64 returns the integer part of d and whether it fits in an int64
+func (d Decimal) Int64() (int64, bool) {
+	q := new(big.Int).Quo(d.int(), pow10(d.scale))
+	return q.Int64(), q.IsInt64()
+}
+
+// Float64 returns the nearest float64 to d
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Number returns d as a json.Number, which encoding/json writes verbatim
+func (d Decimal) Number() json.Number {
+	return json.Number(d.String())
+}
+
+// String returns d in plain decimal notation without an exponent
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON writes d as an exact JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or numeric string
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	parsed, err := Parse(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// int returns the coefficient, treating nil as zero
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// align returns both coefficients at a common scale
+func align(d, e Decimal) (*big.Int, *big.Int, int32) {
+	switch {
+	case d.scale == e.scale:
+		return d.int(), e.int(), d.scale
+	case d.scale < e.scale:
+		return new(big.Int).Mul(d.int(), pow10(e.scale-d.scale)), e.int(), e.scale
+	default:
+		return d.int(), new(big.Int).Mul(e.int(), pow10(d.scale-e.scale)), d.scale
+	}
+}
+
+// pow10 returns 10^n
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundQuotient adjusts a truncated quotient q with remainder r of a division
+// by den. negative is the sign of the exact quotient, which q alone cannot
+// carry when it truncated to zero.
+func roundQuotient(q, r, den *big.Int, negative bool, mode RoundingMode) *big.Int {
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// Compare the discarded fraction |r/den| against one half
+	twiceR := new(big.Int).Abs(r)
+	twiceR.Lsh(twiceR, 1)
+	half := twiceR.Cmp(new(big.Int).Abs(den))
+
+	var awayFromZero bool
+	switch mode {
+	case RoundDown:
+		awayFromZero = false
+	case RoundUp:
+		awayFromZero = true
+	case RoundCeiling:
+		awayFromZero = !negative
+	case RoundFloor:
+		awayFromZero = negative
+	case RoundHalfUp:
+		awayFromZero = half >= 0
+	case RoundHalfDown:
+		awayFromZero = half > 0
+	default: // RoundHalfEven
+		awayFromZero = half > 0 || (half == 0 && new(big.Int).Abs(q).Bit(0) == 1)
+	}
+
+	if !awayFromZero {
+		return q
+	}
+	if negative {
+		return q.Sub(q, big.NewInt(1))
+	}
+	return q.Add(q, big.NewInt(1))
+}
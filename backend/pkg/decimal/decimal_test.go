@@ -0,0 +1,173 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAndString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0", "0"},
+		{"12.50", "12.50"},
+		{"-0.05", "-0.05"},
+		{"+7", "7"},
+		{".5", "0.5"},
+		{"1.5e3", "1500"},
+		{"25e-3", "0.025"},
+		{"-1E2", "-100"},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %v", tt.in, err)
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "-", "1.2.3", "abc", "1e", "NaN", "1e99999"} {
+		if _, err := Parse(bad); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidNumber", bad, err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	d := RequireFromString
+
+	if got := d("0.1").Add(d("0.2")).String(); got != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+	if got := d("1.10").Sub(d("0.2")).String(); got != "0.90" {
+		t.Errorf("1.10 - 0.2 = %s, want 0.90", got)
+	}
+	if got := d("19.99").Mul(d("3")).String(); got != "59.97" {
+		t.Errorf("19.99 * 3 = %s, want 59.97", got)
+	}
+	if got, _ := d("10").Div(d("4"), 16, RoundHalfEven); got.String() != "2.5" {
+		t.Errorf("10 / 4 = %s, want 2.5", got)
+	}
+	if got, _ := d("7.5").Mod(d("2")); got.String() != "1.5" {
+		t.Errorf("7.5 %% 2 = %s, want 1.5", got)
+	}
+	if got, _ := d("1.1").Pow(2, 16, RoundHalfEven); got.String() != "1.21" {
+		t.Errorf("1.1 ^ 2 = %s, want 1.21", got)
+	}
+	if _, err := d("1").Div(Zero, 2, RoundHalfEven); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("expected ErrDivisionByZero, got %v", err)
+	}
+
+	f, err := NewFromFloat(0.1)
+	if err != nil || !f.Equal(d("0.1")) {
+		t.Errorf("NewFromFloat(0.1) = %v (err %v), want exactly 0.1", f, err)
+	}
+	if d("2.50").Cmp(d("2.5")) != 0 {
+		t.Error("2.50 and 2.5 should compare equal")
+	}
+}
+
+func TestPowLimits(t *testing.T) {
+	d := RequireFromString
+
+	// 7^10000 has 8,451 digits
+	huge, err := d("7").Pow(10000, 16, RoundHalfEven)
+	if err != nil {
+		t.Fatalf("7 ^ 10000 error = %v", err)
+	}
+	if got := len(huge.String()); got != 8451 {
+		t.Errorf("7 ^ 10000 has %d digits, want 8451", got)
+	}
+
+	tests := []struct {
+		name string
+		pow  func() (Decimal, error)
+	}{
+		{"result too long", func() (Decimal, error) { return huge.Pow(10000, 16, RoundHalfEven) }},
+		{"scale too long", func() (Decimal, error) { return d("0.00000000001").Pow(10000, 16, RoundHalfEven) }},
+		{"negative power too long", func() (Decimal, error) { return huge.Pow(-10000, 16, RoundHalfEven) }},
+		{"context limit", func() (Decimal, error) {
+			return Context{Scale: 16, Rounding: RoundHalfEven, MaxDigits: 1000}.Pow(d("7"), 10000)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.pow(); !errors.Is(err, ErrInvalidNumber) {
+				t.Errorf("error = %v, want ErrInvalidNumber", err)
+			}
+		})
+	}
+
+	// A scale whose product overflows int32 is rejected, not wrapped
+	wide := New(1, 300000)
+	if _, err := wide.Pow(10000, 16, RoundHalfEven); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("scale overflow error = %v, want ErrInvalidNumber", err)
+	}
+}
+
+func TestRoundingModes(t *testing.T) {
+	tests := []struct {
+		value string
+		mode  RoundingMode
+		want  string
+	}{
+		{"2.5", RoundHalfEven, "2"},
+		{"3.5", RoundHalfEven, "4"},
+		{"-2.5", RoundHalfEven, "-2"},
+		{"2.5", RoundHalfUp, "3"},
+		{"-2.5", RoundHalfUp, "-3"},
+		{"2.5", RoundHalfDown, "2"},
+		{"2.51", RoundHalfDown, "3"},
+		{"2.1", RoundUp, "3"},
+		{"-2.1", RoundUp, "-3"},
+		{"2.9", RoundDown, "2"},
+		{"-2.9", RoundDown, "-2"},
+		{"2.1", RoundCeiling, "3"},
+		{"-2.9", RoundCeiling, "-2"},
+		{"2.9", RoundFloor, "2"},
+		{"-2.1", RoundFloor, "-3"},
+		{"-0.4", RoundFloor, "-1"},
+	}
+	for _, tt := range tests {
+		if got := RequireFromString(tt.value).Round(0, tt.mode).String(); got != tt.want {
+			t.Errorf("Round(%s, %s) = %s, want %s", tt.value, tt.mode, got, tt.want)
+		}
+	}
+
+	ctx := Context{Scale: 2, Rounding: RoundHalfUp}
+	if got, _ := ctx.Div(NewFromInt(2), NewFromInt(3)); got.String() != "0.67" {
+		t.Errorf("2 / 3 at scale 2 = %s, want 0.67", got)
+	}
+	if got, _ := ctx.Div(NewFromInt(-1), NewFromInt(8)); got.String() != "-0.13" {
+		t.Errorf("-1 / 8 at scale 2 half_up = %s, want -0.13", got)
+	}
+
+	if _, err := ParseRoundingMode("sideways"); !errors.Is(err, ErrInvalidRoundingMode) {
+		t.Errorf("expected ErrInvalidRoundingMode, got %v", err)
+	}
+	if mode, _ := ParseRoundingMode(""); mode != RoundHalfEven {
+		t.Errorf("default rounding mode = %s, want half_even", mode)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	out, err := json.Marshal(map[string]interface{}{
+		"total":  RequireFromString("0.1").Add(RequireFromString("0.2")),
+		"number": RequireFromString("1234567890.123456789").Number(),
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `{"number":1234567890.123456789,"total":0.3}`; string(out) != want {
+		t.Errorf("Marshal = %s, want %s", out, want)
+	}
+
+	var d Decimal
+	if err := json.Unmarshal([]byte(`19.99`), &d); err != nil || d.String() != "19.99" {
+		t.Errorf("Unmarshal = %v (err %v), want 19.99", d, err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package decimal provides arbitrary-precision decimal arithmetic for the
+// workflow engine's exact numeric mode.
+//
+// # Overview
+//
+// Binary floating point cannot represent most decimal fractions, so
+// 0.1 + 0.2 evaluates to 0.30000000000000004. When config.Config.NumericMode
+// is "decimal", the engine performs operation, reduce, group_by, accumulator
+// and expression arithmetic with this package instead, and emits results as
+// json.Number values that serialize as exact JSON numbers.
+//
+// # Exactness
+//
+// Addition, subtraction and multiplication are always exact. Division keeps a
+// configurable number of fractional digits (Context.Scale) and rounds the
+// rest with a configurable RoundingMode. Float inputs are converted using
+// their shortest round-trip representation, so the float64 0.1 becomes
+// exactly 0.1.
+//
+// # Rounding Modes
+//
+//   - half_even: To nearest, ties to even (default, banker's rounding)
+//   - half_up:   To nearest, ties away from zero
+//   - half_down: To nearest, ties toward zero
+//   - up:        Away from zero
+//   - down:      Toward zero
+//   - ceiling:   Toward positive infinity
+//   - floor:     Toward negative infinity
+//
+// # Basic Usage
+//
+//	a := decimal.RequireFromString("0.1")
+//	b := decimal.RequireFromString("0.2")
+//	fmt.Println(a.Add(b)) // 0.3
+//
+//	ctx := decimal.Context{Scale: 2, Rounding: decimal.RoundHalfUp}
+//	q, _ := ctx.Div(decimal.NewFromInt(10), decimal.NewFromInt(3)) // 3.33
+//
+// # Thread Safety
+//
+// Decimal values are immutable and safe for concurrent use.
+package decimal
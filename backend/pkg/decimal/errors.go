@@ -0,0 +1,10 @@
+package decimal
+
+import "errors"
+
+// Sentinel errors for decimal arithmetic
+var (
+	ErrInvalidNumber       = errors.New("invalid decimal number")
+	ErrDivisionByZero      = errors.New("division by zero")
+	ErrInvalidRoundingMode = errors.New("invalid rounding mode")
+)
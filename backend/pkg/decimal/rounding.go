@@ -0,0 +1,56 @@
+package decimal
+
+import "fmt"
+
+// RoundingMode selects how inexact results are rounded
+type RoundingMode string
+
+// Supported rounding modes
+const (
+	RoundHalfEven RoundingMode = "half_even" // To nearest, ties to even (banker's rounding)
+	RoundHalfUp   RoundingMode = "half_up"   // To nearest, ties away from zero
+	RoundHalfDown RoundingMode = "half_down" // To nearest, ties toward zero
+	RoundUp       RoundingMode = "up"        // Away from zero
+	RoundDown     RoundingMode = "down"      // Toward zero (truncate)
+	RoundCeiling  RoundingMode = "ceiling"   // Toward positive infinity
+	RoundFloor    RoundingMode = "floor"     // Toward negative infinity
+)
+
+// ParseRoundingMode validates a rounding mode name. An empty name selects
+// RoundHalfEven.
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	switch mode := RoundingMode(name); mode {
+	case "":
+		return RoundHalfEven, nil
+	case RoundHalfEven, RoundHalfUp, RoundHalfDown, RoundUp, RoundDown, RoundCeiling, RoundFloor:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidRoundingMode, name)
+}
+
+// Context holds the settings for operations that cannot be exact
+type Context struct {
+	Scale     int32        // Fractional digits kept by division
+	Rounding  RoundingMode // Rounding applied to division and Round
+	MaxDigits int          // Maximum digits of a Pow result, at most DefaultMaxDigits (0 = DefaultMaxDigits)
+}
+
+// Div returns a ÷ b rounded to the context's scale
+func (c Context) Div(a, b Decimal) (Decimal, error) {
+	return a.Div(b, c.Scale, c.Rounding)
+}
+
+// Round rounds d to places fractional digits using the context's rounding mode
+func (c Context) Round(d Decimal, places int32) Decimal {
+	return d.Round(places, c.Rounding)
+}
+
+// Pow returns d raised to an integer power, rejecting results of more than
+// MaxDigits digits. Negative powers divide using the context's scale.
+func (c Context) Pow(d Decimal, n int64) (Decimal, error) {
+	maxDigits := int64(DefaultMaxDigits)
+	if c.MaxDigits > 0 && int64(c.MaxDigits) < maxDigits {
+		maxDigits = int64(c.MaxDigits)
+	}
+	return d.pow(n, c.Scale, c.Rounding, maxDigits)
+}
@@ -0,0 +1,319 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// DefaultDebugSessionTimeout is how long a paused execution waits for a
+// debugger command when DebugOptions.SessionTimeout is not set
+const DefaultDebugSessionTimeout = 10 * time.Minute
+
+// Pause reasons reported in DebugState
+const (
+	PauseBreakpoint = "breakpoint"
+	PauseStep       = "step"
+)
+
+// DebugOptions configure a debugged execution
+type DebugOptions struct {
+	// Breakpoints are the IDs of the nodes the execution pauses before
+	Breakpoints []string
+
+	// PauseOnStart pauses before the first node, as if stepping into
+	// the workflow
+	PauseOnStart bool
+
+	// SessionTimeout is how long a pause waits for a command before the
+	// execution fails with ErrDebugSessionExpired.
+	// Zero uses DefaultDebugSessionTimeout.
+	SessionTimeout time.Duration
+}
+
+// DebugState is what a debugged execution exposes. While paused, NodeID
+// is the node about to run and the results, variables and context are
+// those it will run with.
+type DebugState struct {
+	Paused           bool                   `json:"paused"`
+	NodeID           string                 `json:"node_id,omitempty"`
+	NodeType         types.NodeType         `json:"node_type,omitempty"`
+	Reason           string                 `json:"reason,omitempty"` // PauseBreakpoint or PauseStep
+	PausedAt         *time.Time             `json:"paused_at,omitempty"`
+	ExpiresAt        *time.Time             `json:"expires_at,omitempty"` // When the pause times out
+	Breakpoints      []string               `json:"breakpoints"`
+	NodeResults      map[string]interface{} `json:"node_results"`
+	Variables        map[string]interface{} `json:"variables"`
+	ContextVariables map[string]interface{} `json:"context_variables"`
+	ContextConstants map[string]interface{} `json:"context_constants"`
+}
+
+// Debugger steps through an execution started with debugging enabled.
+// The execution pauses before each breakpoint node and, after Step,
+// before the next node. While it is paused, variables can be changed and
+// the session saved with Snapshot. Its methods are safe to call from
+// other goroutines than the one executing the workflow.
+//
+// Only the top-level workflow is debugged; sub_workflow nodes run their
+// workflow without pausing.
+type Debugger struct {
+	engine  *Engine
+	timeout time.Duration
+
+	mu          sync.Mutex
+	breakpoints map[string]bool
+	stepping    bool
+	paused      *DebugState     // nil while running
+	resume      chan bool       // Receives whether to step, while paused
+	clock       *executionClock // MaxExecutionTime, stopped while paused
+}
+
+// SetDebug runs the workflow under a Debugger, which Debugger returns.
+// Time spent paused does not count towards MaxExecutionTime.
+// Returns the engine for method chaining.
+func (e *Engine) SetDebug(opts DebugOptions) *Engine {
+	timeout := opts.SessionTimeout
+	if timeout <= 0 {
+		timeout = DefaultDebugSessionTimeout
+	}
+	d := &Debugger{
+		engine:      e,
+		timeout:     timeout,
+		breakpoints: make(map[string]bool, len(opts.Breakpoints)),
+		stepping:    opts.PauseOnStart,
+	}
+	for _, nodeID := range opts.Breakpoints {
+		d.breakpoints[nodeID] = true
+	}
+	e.debugger = d
+	return e
+}
+
+// Debugger returns the engine's debugger, nil unless set with SetDebug
+func (e *Engine) Debugger() *Debugger {
+	return e.debugger
+}
+
+// State returns the execution's current debug state
+func (d *Debugger) State() DebugState {
+	d.mu.Lock()
+	state := DebugState{Breakpoints: d.breakpointList()}
+	if d.paused != nil {
+		state.Paused = true
+		state.NodeID = d.paused.NodeID
+		state.NodeType = d.paused.NodeType
+		state.Reason = d.paused.Reason
+		state.PausedAt = d.paused.PausedAt
+		state.ExpiresAt = d.paused.ExpiresAt
+	}
+	d.mu.Unlock()
+
+	e := d.engine
+	state.NodeResults = e.redactMap(e.GetAllNodeResults())
+	state.Variables = e.redactMap(e.GetVariables())
+	state.ContextVariables = e.redactMap(e.state.GetContextVariables())
+	state.ContextConstants = e.redactMap(e.state.GetContextConstants())
+	return state
+}
+
+// Paused reports whether the execution is paused. A nil Debugger is
+// never paused.
+func (d *Debugger) Paused() bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused != nil
+}
+
+// Step runs the node the execution is paused before and pauses again
+// before the next node.
+// Returns ErrDebugNotPaused if the execution is not paused.
+func (d *Debugger) Step() error {
+	return d.release(true)
+}
+
+// Continue resumes the execution until the next breakpoint.
+// Returns ErrDebugNotPaused if the execution is not paused.
+func (d *Debugger) Continue() error {
+	return d.release(false)
+}
+
+// SetVariable sets a workflow variable of the paused execution, subject
+// to the same limits as variable nodes.
+// Returns ErrDebugNotPaused if the execution is not paused.
+func (d *Debugger) SetVariable(name string, value interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == nil {
+		return ErrDebugNotPaused
+	}
+	if name == "" {
+		return fmt.Errorf("variable name is required")
+	}
+	return d.engine.SetVariable(name, value)
+}
+
+// Snapshot saves the paused execution, with its breakpoints, so the
+// session can be resumed later from LoadSnapshot.
+// Returns ErrDebugNotPaused if the execution is not paused.
+func (d *Debugger) Snapshot() (*Snapshot, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == nil {
+		return nil, ErrDebugNotPaused
+	}
+	snapshot := d.engine.saveSnapshot()
+	snapshot.Breakpoints = d.breakpointList()
+	return snapshot, nil
+}
+
+// release resumes the paused execution, stepping or not
+func (d *Debugger) release(step bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == nil {
+		return ErrDebugNotPaused
+	}
+	d.resume <- step
+	d.paused = nil
+	d.resume = nil
+	return nil
+}
+
+// breakpointList returns the breakpoints in ID order. d.mu must be held.
+func (d *Debugger) breakpointList() []string {
+	list := make([]string, 0, len(d.breakpoints))
+	for nodeID := range d.breakpoints {
+		list = append(list, nodeID)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// pause blocks before node when it is a breakpoint or the execution is
+// stepping, until a Step or Continue command, ctx is done or the session
+// times out
+func (d *Debugger) pause(ctx context.Context, node types.Node) error {
+	d.mu.Lock()
+	reason := ""
+	switch {
+	case d.breakpoints[node.ID]:
+		reason = PauseBreakpoint
+	case d.stepping:
+		reason = PauseStep
+	default:
+		d.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	expires := now.Add(d.timeout)
+	resume := make(chan bool, 1)
+	d.paused = &DebugState{
+		NodeID:    node.ID,
+		NodeType:  node.Type,
+		Reason:    reason,
+		PausedAt:  &now,
+		ExpiresAt: &expires,
+	}
+	d.resume = resume
+	d.clock.stop()
+	d.mu.Unlock()
+
+	d.engine.notifyNodePaused(ctx, node, reason)
+
+	timer := time.NewTimer(d.timeout)
+	defer timer.Stop()
+
+	var (
+		step bool
+		err  error
+	)
+	select {
+	case step = <-resume:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = fmt.Errorf("%w: no command within %v", ErrDebugSessionExpired, d.timeout)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.paused = nil
+		d.resume = nil
+		return err
+	}
+	d.stepping = step
+	d.clock.start()
+	return nil
+}
+
+// redactMap masks secrets in m like the node data of events
+func (e *Engine) redactMap(m map[string]interface{}) map[string]interface{} {
+	if e.capture == nil || e.capture.Redactor == nil {
+		return m
+	}
+	if masked, ok := e.capture.Redactor.Redact(m).(map[string]interface{}); ok {
+		return masked
+	}
+	return m
+}
+
+// executionContext returns the context nodes run under, cancelled after
+// MaxExecutionTime. When debugging, the time spent paused does not count.
+func (e *Engine) executionContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if e.debugger == nil || e.sub != nil {
+		return context.WithTimeout(parent, e.config.MaxExecutionTime)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	clock := &executionClock{remaining: e.config.MaxExecutionTime}
+	clock.timer = time.AfterFunc(clock.remaining, cancel)
+	clock.started = time.Now()
+
+	d := e.debugger
+	d.mu.Lock()
+	d.clock = clock
+	d.mu.Unlock()
+	return ctx, func() {
+		d.mu.Lock()
+		d.clock.stop()
+		d.mu.Unlock()
+		cancel()
+	}
+}
+
+// executionClock is the MaxExecutionTime timer of a debugged execution,
+// which stops while the execution is paused. It is guarded by Debugger.mu.
+type executionClock struct {
+	timer     *time.Timer
+	started   time.Time
+	remaining time.Duration
+	expired   bool
+}
+
+// stop pauses the clock
+func (c *executionClock) stop() {
+	if c == nil || c.expired {
+		return
+	}
+	if c.timer.Stop() {
+		c.remaining -= time.Since(c.started)
+	} else {
+		c.expired = true
+	}
+}
+
+// start resumes the clock with the time left
+func (c *executionClock) start() {
+	if c == nil || c.expired {
+		return
+	}
+	c.started = time.Now()
+	c.timer.Reset(c.remaining)
+}
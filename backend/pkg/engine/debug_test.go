@@ -0,0 +1,265 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// debugPayload sets x to 10, reads it back and adds it to 5
+var debugPayload = []byte(`{
+	"workflow_id": "debug",
+	"nodes": [
+		{"id": "1", "type": "number", "data": {"value": 10}},
+		{"id": "2", "type": "variable", "data": {"var_op": "set", "var_name": "x"}},
+		{"id": "3", "type": "variable", "data": {"var_op": "get", "var_name": "x"}},
+		{"id": "4", "type": "number", "data": {"value": 5}}
+	],
+	"edges": [
+		{"source": "1", "target": "2"},
+		{"source": "2", "target": "3"}
+	]
+}`)
+
+// debugRun is a debugged execution running in the background
+type debugRun struct {
+	engine *Engine
+	done   chan error
+	result *types.Result
+}
+
+func startDebugRun(t *testing.T, ctx context.Context, eng *Engine) *debugRun {
+	t.Helper()
+	run := &debugRun{engine: eng, done: make(chan error, 1)}
+	go func() {
+		result, err := eng.ExecuteContext(ctx)
+		run.result = result
+		run.done <- err
+	}()
+	return run
+}
+
+// waitPaused waits for the run to pause and returns its state
+func (r *debugRun) waitPaused(t *testing.T) DebugState {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if state := r.engine.Debugger().State(); state.Paused {
+			return state
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("execution did not pause")
+	return DebugState{}
+}
+
+// wait waits for the run to finish
+func (r *debugRun) wait(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-r.done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("execution did not finish")
+		return nil
+	}
+}
+
+func newDebugEngine(t *testing.T, opts DebugOptions) *Engine {
+	t.Helper()
+	eng, err := New(debugPayload)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	return eng.SetDebug(opts)
+}
+
+func TestDebugger_Breakpoint(t *testing.T) {
+	eng := newDebugEngine(t, DebugOptions{Breakpoints: []string{"3"}})
+	run := startDebugRun(t, context.Background(), eng)
+
+	state := run.waitPaused(t)
+	if state.NodeID != "3" || state.Reason != PauseBreakpoint {
+		t.Errorf("Paused at %s (%s), want 3 (%s)", state.NodeID, state.Reason, PauseBreakpoint)
+	}
+	if _, ok := state.NodeResults["2"]; !ok {
+		t.Errorf("Node results = %v, want results of nodes before the breakpoint", state.NodeResults)
+	}
+	if _, ok := state.NodeResults["3"]; ok {
+		t.Error("Breakpoint node ran before the execution continued")
+	}
+	if state.Variables["x"] != float64(10) {
+		t.Errorf("Variables = %v, want x = 10", state.Variables)
+	}
+	if !reflect.DeepEqual(state.Breakpoints, []string{"3"}) {
+		t.Errorf("Breakpoints = %v, want [3]", state.Breakpoints)
+	}
+
+	if err := eng.Debugger().SetVariable("x", float64(42)); err != nil {
+		t.Fatalf("SetVariable failed: %v", err)
+	}
+	if err := eng.Debugger().Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if err := run.wait(t); err != nil {
+		t.Fatalf("Execution failed: %v", err)
+	}
+
+	got := run.result.NodeResults["3"].(map[string]interface{})["value"]
+	if got != float64(42) {
+		t.Errorf("Node 3 read x = %v, want the modified 42", got)
+	}
+	if err := eng.Debugger().Continue(); !errors.Is(err, ErrDebugNotPaused) {
+		t.Errorf("Continue after the run = %v, want ErrDebugNotPaused", err)
+	}
+}
+
+func TestDebugger_Step(t *testing.T) {
+	eng := newDebugEngine(t, DebugOptions{PauseOnStart: true})
+	run := startDebugRun(t, context.Background(), eng)
+
+	var paused []string
+	for i := 0; i < 4; i++ {
+		state := run.waitPaused(t)
+		if state.Reason != PauseStep {
+			t.Errorf("Pause reason = %s, want %s", state.Reason, PauseStep)
+		}
+		paused = append(paused, state.NodeID)
+		if err := eng.Debugger().Step(); err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+	}
+	if err := run.wait(t); err != nil {
+		t.Fatalf("Execution failed: %v", err)
+	}
+	if want := []string{"1", "4", "2", "3"}; !reflect.DeepEqual(paused, want) {
+		t.Errorf("Paused before %v, want %v", paused, want)
+	}
+}
+
+func TestDebugger_SessionTimeout(t *testing.T) {
+	eng := newDebugEngine(t, DebugOptions{
+		Breakpoints:    []string{"2"},
+		SessionTimeout: 20 * time.Millisecond,
+	})
+	run := startDebugRun(t, context.Background(), eng)
+
+	err := run.wait(t)
+	if !errors.Is(err, ErrDebugSessionExpired) {
+		t.Errorf("Execution error = %v, want ErrDebugSessionExpired", err)
+	}
+	if eng.Debugger().State().Paused {
+		t.Error("Execution still paused after the session expired")
+	}
+}
+
+func TestDebugger_PauseDoesNotCountTowardsTimeout(t *testing.T) {
+	config := types.DefaultConfig()
+	config.MaxExecutionTime = 50 * time.Millisecond
+	eng, err := NewWithConfig(debugPayload, config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	eng.SetDebug(DebugOptions{Breakpoints: []string{"3"}})
+	run := startDebugRun(t, context.Background(), eng)
+
+	run.waitPaused(t)
+	time.Sleep(100 * time.Millisecond)
+	if err := eng.Debugger().Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if err := run.wait(t); err != nil {
+		t.Errorf("Execution failed: %v", err)
+	}
+}
+
+func TestDebugger_Cancel(t *testing.T) {
+	eng := newDebugEngine(t, DebugOptions{Breakpoints: []string{"2"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	run := startDebugRun(t, ctx, eng)
+
+	run.waitPaused(t)
+	cancel()
+	if err := run.wait(t); !errors.Is(err, ErrExecutionCanceled) {
+		t.Errorf("Execution error = %v, want ErrExecutionCanceled", err)
+	}
+}
+
+func TestDebugger_SnapshotAndResume(t *testing.T) {
+	eng := newDebugEngine(t, DebugOptions{Breakpoints: []string{"3"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	run := startDebugRun(t, ctx, eng)
+
+	run.waitPaused(t)
+	if err := eng.Debugger().SetVariable("x", float64(7)); err != nil {
+		t.Fatalf("SetVariable failed: %v", err)
+	}
+	snapshot, err := eng.Debugger().Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	cancel()
+	run.wait(t)
+
+	data, err := SerializeSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	restored, err := DeserializeSnapshot(data)
+	if err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Breakpoints, []string{"3"}) {
+		t.Errorf("Snapshot breakpoints = %v, want [3]", restored.Breakpoints)
+	}
+
+	resumed, err := LoadSnapshot(restored, nil)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	resumed.SetDebug(DebugOptions{Breakpoints: restored.Breakpoints})
+	run = startDebugRun(t, context.Background(), resumed)
+
+	state := run.waitPaused(t)
+	if state.NodeID != "3" {
+		t.Errorf("Resumed session paused at %s, want 3", state.NodeID)
+	}
+	if err := resumed.Debugger().Continue(); err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if err := run.wait(t); err != nil {
+		t.Fatalf("Resumed execution failed: %v", err)
+	}
+
+	got := run.result.NodeResults["3"].(map[string]interface{})["value"]
+	if got != float64(7) {
+		t.Errorf("Node 3 read x = %v, want 7 from the snapshot", got)
+	}
+	if count := resumed.GetNodeExecutionCount(); count != snapshot.NodeExecutionCount+1 {
+		t.Errorf("Node executions = %d, want %d: completed nodes ran again", count, snapshot.NodeExecutionCount+1)
+	}
+}
+
+func TestDebugger_NotPaused(t *testing.T) {
+	eng := newDebugEngine(t, DebugOptions{})
+	d := eng.Debugger()
+
+	if err := d.Step(); !errors.Is(err, ErrDebugNotPaused) {
+		t.Errorf("Step = %v, want ErrDebugNotPaused", err)
+	}
+	if err := d.SetVariable("x", 1); !errors.Is(err, ErrDebugNotPaused) {
+		t.Errorf("SetVariable = %v, want ErrDebugNotPaused", err)
+	}
+	if _, err := d.Snapshot(); !errors.Is(err, ErrDebugNotPaused) {
+		t.Errorf("Snapshot = %v, want ErrDebugNotPaused", err)
+	}
+
+	// Without breakpoints the workflow runs through
+	if _, err := eng.Execute(); err != nil {
+		t.Errorf("Execution failed: %v", err)
+	}
+}
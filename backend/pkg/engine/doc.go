@@ -63,6 +63,54 @@
 //	ctx := context.WithValue(context.Background(), types.ContextKeyExecutionID, "exec-123")
 //	result, err := eng.Execute(ctx, workflow)
 //
+// # Sub-workflows
+//
+// A sub_workflow node runs a saved workflow looked up through the
+// WorkflowSource set with SetWorkflowSource. The child engine shares the
+// parent's protection counters, context, tenant, HTTP clients and
+// observers, so one execution stays within one set of limits. Nesting is
+// bounded by Config.MaxSubWorkflowDepth, and a workflow already running
+// further up is rejected with ErrSubWorkflowCycle.
+//
+// # Tracing
+//
+// ExecuteContext traces the run as a workflow.execute span, under any span
+// in the caller's context, with a node.execute child span per node carrying
+// its type, input and output sizes and any error. Executors reach the
+// node's span through ExecutionContext.Context; the HTTP executor sends it
+// on as a W3C traceparent header. SetTracerProvider selects the provider;
+// the global OpenTelemetry provider is the default.
+//
+// # Profiling
+//
+// SetProfiling(true) adds a types.Profile to the result: each node's wall
+// time, the HTTP wait and loop iterations executors report through
+// ExecutionContext.RecordHTTPCall and RecordIterations, and the critical
+// path through the workflow, found with graph.Graph.CriticalPath.
+// Profile.WriteFolded exports it for flame graph tools.
+//
+// # Debugging
+//
+// SetDebug runs the workflow under a Debugger, which pauses before the
+// breakpoint nodes of DebugOptions. While paused, State exposes the node
+// results, variables and context the next node will see, SetVariable
+// changes a variable, and Step or Continue resume the run. Observers get
+// a node_paused event for each pause. A pause waits at most
+// DebugOptions.SessionTimeout for a command and does not count towards
+// MaxExecutionTime. Debugger.Snapshot saves a paused session; the engine
+// LoadSnapshot restores from it skips the nodes already completed.
+//
+// # Planning
+//
+// Plan is a dry run: it lists the nodes the workflow would run, the nodes
+// it would skip and why, and the nodes whose path depends on runtime
+// data. Nodes without side effects run for real, so conditional edges are
+// evaluated wherever their inputs are static; http, paginator,
+// sub_workflow, delay, cache and variable nodes are stubbed and report
+// what they would do, such as the request an http node would send. Nodes
+// of other types not known to be free of side effects, such as custom
+// executors, never run while planning and depend on runtime data.
+//
 // # Error Handling
 //
 // The engine provides detailed error information:
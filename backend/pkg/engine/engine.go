@@ -7,11 +7,14 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/executor"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/graph"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/logging"
@@ -41,6 +44,7 @@ type Engine struct {
 	resultsMu   sync.RWMutex
 	executionID string
 	workflowID  string
+	tenantID    string
 
 	// Runtime protection counters
 	nodeExecutionCount int
@@ -51,15 +55,42 @@ type Engine struct {
 	nodes []types.Node
 	edges []types.Edge
 
-	// Observer support
+	// Observer support: capture decides the node data events carry and
+	// masks secrets in them, and in logged and traced errors
 	observerMgr *observer.Manager
 	logger      observer.Logger
+	capture     *observer.Capture
 
 	// Structured logging
 	structuredLogger *logging.Logger
 
 	// HTTP client registry for named HTTP clients (uses standalone httpclient.Registry)
 	httpClientRegistry interface{}
+
+	// Sub-workflow support: where sub_workflow nodes find workflows and
+	// the link to the parent engine when this one runs a child workflow
+	workflowSource WorkflowSource
+	sub            *subWorkflow
+
+	// Tracing: the tracer for workflow and node spans, and the context of
+	// the node being executed, which carries its span
+	tracer    trace.Tracer
+	nodeCtx   context.Context
+	nodeCtxMu sync.RWMutex
+
+	// Profiling: node timings for the result's profile, nil unless
+	// enabled with SetProfiling
+	profiler *profiler
+
+	// Metrics for HTTP calls and exceeded limits, nil unless set with
+	// SetMetrics
+	metrics Metrics
+
+	// Debugging: the debugger pausing the execution, nil unless set with
+	// SetDebug, and the nodes completed before a snapshot the engine was
+	// restored from, which do not run again
+	debugger  *Debugger
+	completed map[string]bool
 }
 
 // ============================================================================
@@ -124,6 +155,10 @@ func NewWithRegistry(payloadJSON []byte, config types.Config, registry *executor
 		return nil, fmt.Errorf("failed to parse payload: %w", err)
 	}
 
+	if config.MaxNodes > 0 && len(payload.Nodes) > config.MaxNodes {
+		return nil, fmt.Errorf("%w: workflow has %d nodes, limit is %d", ErrMaxNodesExceeded, len(payload.Nodes), config.MaxNodes)
+	}
+
 	// Generate execution ID
 	executionID := generateExecutionID()
 
@@ -171,6 +206,7 @@ func NewWithRegistry(payloadJSON []byte, config types.Config, registry *executor
 //   - Advanced Control: Switch, Parallel, Join, Split, Delay, Cache
 //   - Error Handling: Retry, TryCatch, Timeout
 //   - Context: ContextVariable, ContextConstant
+//   - Workflow Composition: SubWorkflow
 func DefaultRegistry() *executor.Registry {
 	reg := executor.NewRegistry()
 
@@ -247,6 +283,9 @@ func DefaultRegistry() *executor.Registry {
 	// Visualization nodes
 	reg.MustRegister(&executor.RendererExecutor{})
 
+	// Workflow composition nodes
+	reg.MustRegister(&executor.SubWorkflowExecutor{})
+
 	return reg
 }
 
@@ -287,6 +326,32 @@ func (e *Engine) SetLogger(logger observer.Logger) *Engine {
 	return e
 }
 
+// SetEventCapture sets what node data events carry and how it is masked
+// and truncated; see observer.Capture. Secrets are also masked in the
+// errors the engine logs and records on spans. With no capture, events
+// carry results but no inputs, and nothing is masked.
+// Returns the engine for method chaining.
+func (e *Engine) SetEventCapture(capture *observer.Capture) *Engine {
+	e.capture = capture
+	return e
+}
+
+// SetStructuredLogger replaces the structured logger, which writes JSON
+// to stdout by default. The workflow, execution and tenant IDs are added
+// to its lines.
+// Returns the engine for method chaining.
+func (e *Engine) SetStructuredLogger(logger *logging.Logger) *Engine {
+	if logger == nil {
+		return e
+	}
+	logger = logger.WithWorkflowID(e.workflowID).WithExecutionID(e.executionID)
+	if e.tenantID != "" {
+		logger = logger.WithTenantID(e.tenantID)
+	}
+	e.structuredLogger = logger
+	return e
+}
+
 // SetHTTPClientRegistry sets the HTTP client registry for named HTTP clients.
 // The registry should be of type *httpclient.Registry from the standalone httpclient package.
 // Returns the engine for method chaining.
@@ -300,6 +365,24 @@ func (e *Engine) GetObserverCount() int {
 	return e.observerMgr.Count()
 }
 
+// ExecutionID returns the unique ID assigned to this execution
+func (e *Engine) ExecutionID() string {
+	return e.executionID
+}
+
+// SetTenantID tags the execution with the tenant it runs for. The ID is
+// added to log lines and observer events. Call it before Execute.
+func (e *Engine) SetTenantID(tenantID string) *Engine {
+	e.tenantID = tenantID
+	e.structuredLogger = e.structuredLogger.WithTenantID(tenantID)
+	return e
+}
+
+// TenantID returns the tenant set with SetTenantID, or "" if none was set
+func (e *Engine) TenantID() string {
+	return e.tenantID
+}
+
 // ============================================================================
 // Public API - Execute
 // ============================================================================
@@ -317,6 +400,34 @@ func (e *Engine) GetObserverCount() int {
 //   - *types.Result: Workflow execution results including execution ID, node outputs and final output
 //   - error: If execution fails, times out, or encounters an error
 func (e *Engine) Execute() (*types.Result, error) {
+	return e.ExecuteContext(context.Background())
+}
+
+// ExecuteContext runs the workflow like Execute, but stops early when parent
+// is cancelled. A cancelled run returns an error wrapping ErrExecutionCanceled;
+// MaxExecutionTime still applies on top of any deadline parent carries.
+//
+// The run is traced as a workflow span with a child span per node, under
+// any span parent carries.
+//
+// With profiling enabled, the result carries the run's profile, also when
+// the run fails.
+func (e *Engine) ExecuteContext(parent context.Context) (*types.Result, error) {
+	profiled := e.profiler != nil && e.sub == nil
+	if profiled {
+		e.profiler.start = time.Now()
+	}
+	ctx, span := e.startWorkflowSpan(parent)
+	result, err := e.execute(ctx)
+	endSpan(span, e.capture.RedactError(err))
+	if profiled && result != nil {
+		result.Profile = e.profile()
+	}
+	return result, err
+}
+
+// execute runs the workflow for ExecuteContext
+func (e *Engine) execute(parent context.Context) (*types.Result, error) {
 	workflowStartTime := time.Now()
 
 	// Log workflow execution start
@@ -345,7 +456,7 @@ func (e *Engine) Execute() (*types.Result, error) {
 		Debug("execution order determined")
 
 	// Step 3: Create context with timeout and execution metadata for workflow execution
-	ctx, cancel := context.WithTimeout(context.Background(), e.config.MaxExecutionTime)
+	ctx, cancel := e.executionContext(parent)
 	defer cancel()
 
 	// Add execution ID and workflow ID to context for logging and tracing
@@ -370,6 +481,11 @@ func (e *Engine) Execute() (*types.Result, error) {
 			default:
 			}
 
+			// Nodes completed before the snapshot this run resumes from
+			if e.completed[nodeID] {
+				continue
+			}
+
 			// Check if this node should be executed based on conditional edges
 			if !e.shouldExecuteNode(nodeID) {
 				e.structuredLogger.WithNodeID(nodeID).Debug("node skipped due to conditional edge")
@@ -377,11 +493,17 @@ func (e *Engine) Execute() (*types.Result, error) {
 			}
 
 			node := e.getNode(nodeID)
+			if e.debugger != nil {
+				if err := e.debugger.pause(ctx, node); err != nil {
+					done <- err
+					return
+				}
+			}
 			value, err := e.executeNode(ctx, node)
 			if err != nil {
 				errMsg := fmt.Sprintf("error executing node %s: %v", nodeID, err)
 				result.Errors = append(result.Errors, errMsg)
-				e.structuredLogger.WithNodeID(nodeID).WithError(err).Error("node execution failed")
+				e.structuredLogger.WithNodeID(nodeID).WithError(e.capture.RedactError(err)).Error("node execution failed")
 				done <- fmt.Errorf("%s", errMsg)
 				return
 			}
@@ -394,13 +516,24 @@ func (e *Engine) Execute() (*types.Result, error) {
 	select {
 	case err := <-done:
 		if err != nil {
-			e.structuredLogger.WithError(err).Error("workflow execution failed")
+			if parent.Err() != nil {
+				// Nodes stopped because the caller cancelled the run
+				err = fmt.Errorf("%w: %v", ErrExecutionCanceled, parent.Err())
+			}
+			e.structuredLogger.WithError(e.capture.RedactError(err)).Error("workflow execution failed")
 			// Notify observers: Workflow end with error
 			e.notifyWorkflowEnd(ctx, workflowStartTime, nil, err)
 			return result, err
 		}
 	case <-ctx.Done():
+		if parent.Err() != nil {
+			cancelErr := fmt.Errorf("%w: %v", ErrExecutionCanceled, parent.Err())
+			e.structuredLogger.WithError(cancelErr).Error("workflow execution canceled")
+			e.notifyWorkflowEnd(ctx, workflowStartTime, nil, cancelErr)
+			return result, cancelErr
+		}
 		timeoutErr := fmt.Errorf("workflow execution timeout: exceeded %v", e.config.MaxExecutionTime)
+		e.limitExceeded(ctx, LimitExecutionTime)
 		e.structuredLogger.WithField("timeout", e.config.MaxExecutionTime).Error("workflow execution timeout")
 		// Notify observers: Workflow end with timeout
 		e.notifyWorkflowEnd(ctx, workflowStartTime, nil, timeoutErr)
@@ -437,9 +570,18 @@ func (e *Engine) Execute() (*types.Result, error) {
 // Returns:
 //   - interface{}: Result of node execution (type depends on node)
 //   - error: If node execution fails
-func (e *Engine) executeNode(ctx context.Context, node types.Node) (interface{}, error) {
+func (e *Engine) executeNode(ctx context.Context, node types.Node) (result interface{}, err error) {
 	nodeStartTime := time.Now()
 
+	ctx, span := e.startNodeSpan(ctx, node)
+	defer func() { endNodeSpan(span, result, e.capture.RedactError(err)) }()
+	e.setContext(ctx)
+
+	if e.profiler != nil {
+		record := e.profiler.beginNode(e, node, nodeStartTime)
+		defer func() { e.profiler.endNode(e, record, err) }()
+	}
+
 	// Create node-specific logger
 	nodeLogger := e.structuredLogger.
 		WithNodeID(node.ID).
@@ -464,10 +606,13 @@ func (e *Engine) executeNode(ctx context.Context, node types.Node) (interface{},
 	// For now, interpolation should happen in individual executors if needed
 
 	// Dispatch to appropriate executor via registry
-	result, err := e.registry.Execute(e, node)
+	result, err = e.registry.Execute(e, node)
 
 	if err != nil {
-		nodeLogger.WithError(err).Error("node execution failed")
+		if errors.Is(err, executor.ErrResponseTooLarge) {
+			e.limitExceeded(ctx, LimitResponseSize)
+		}
+		nodeLogger.WithError(e.capture.RedactError(err)).Error("node execution failed")
 		// Notify observers: Node execution failure
 		e.notifyNodeFailure(ctx, node, nodeStartTime, result, err)
 		return nil, err
@@ -531,6 +676,7 @@ func (e *Engine) GetVariable(name string) (interface{}, error) {
 func (e *Engine) SetVariable(name string, value interface{}) error {
 	// Validate value against resource limits
 	if err := types.ValidateValue(value, e.config); err != nil {
+		e.limitExceeded(e.Context(), LimitValueSize)
 		return fmt.Errorf("variable validation failed: %w", err)
 	}
 
@@ -541,6 +687,7 @@ func (e *Engine) SetVariable(name string, value interface{}) error {
 			// Variable doesn't exist, check count limit
 			vars := e.state.GetAllVariables()
 			if len(vars) >= e.config.MaxVariables {
+				e.limitExceeded(e.Context(), LimitVariables)
 				return fmt.Errorf("maximum variables exceeded: %d (limit: %d)", len(vars), e.config.MaxVariables)
 			}
 		}
@@ -701,8 +848,12 @@ func (e *Engine) GetHTTPClientRegistry() interface{} {
 }
 
 // IncrementNodeExecution increments the node execution counter and checks limits.
-// Returns an error if the limit is exceeded.
+// Returns an error if the limit is exceeded. Sub-workflows count against
+// the top-level execution's limit.
 func (e *Engine) IncrementNodeExecution() error {
+	if e.sub != nil {
+		return e.rootEngine().IncrementNodeExecution()
+	}
 	e.countersMu.Lock()
 	defer e.countersMu.Unlock()
 
@@ -710,6 +861,7 @@ func (e *Engine) IncrementNodeExecution() error {
 
 	// Check if limit is configured and enforced (0 means unlimited)
 	if e.config.MaxNodeExecutions > 0 && e.nodeExecutionCount > e.config.MaxNodeExecutions {
+		e.limitExceeded(e.Context(), LimitNodeExecutions)
 		return fmt.Errorf("maximum node executions exceeded: %d (limit: %d)", e.nodeExecutionCount, e.config.MaxNodeExecutions)
 	}
 
@@ -717,8 +869,12 @@ func (e *Engine) IncrementNodeExecution() error {
 }
 
 // IncrementHTTPCall increments the HTTP call counter and checks limits.
-// Returns an error if the limit is exceeded.
+// Returns an error if the limit is exceeded. Sub-workflows count against
+// the top-level execution's limit.
 func (e *Engine) IncrementHTTPCall() error {
+	if e.sub != nil {
+		return e.rootEngine().IncrementHTTPCall()
+	}
 	e.countersMu.Lock()
 	defer e.countersMu.Unlock()
 
@@ -726,6 +882,7 @@ func (e *Engine) IncrementHTTPCall() error {
 
 	// Check if limit is configured and enforced (0 means unlimited)
 	if e.config.MaxHTTPCallsPerExec > 0 && e.httpCallCount > e.config.MaxHTTPCallsPerExec {
+		e.limitExceeded(e.Context(), LimitHTTPCalls)
 		return fmt.Errorf("maximum HTTP calls per execution exceeded: %d (limit: %d)", e.httpCallCount, e.config.MaxHTTPCallsPerExec)
 	}
 
@@ -734,6 +891,9 @@ func (e *Engine) IncrementHTTPCall() error {
 
 // GetNodeExecutionCount returns the current node execution count
 func (e *Engine) GetNodeExecutionCount() int {
+	if e.sub != nil {
+		return e.rootEngine().GetNodeExecutionCount()
+	}
 	e.countersMu.RLock()
 	defer e.countersMu.RUnlock()
 	return e.nodeExecutionCount
@@ -741,6 +901,9 @@ func (e *Engine) GetNodeExecutionCount() int {
 
 // GetHTTPCallCount returns the current HTTP call count
 func (e *Engine) GetHTTPCallCount() int {
+	if e.sub != nil {
+		return e.rootEngine().GetHTTPCallCount()
+	}
 	e.countersMu.RLock()
 	defer e.countersMu.RUnlock()
 	return e.httpCallCount
@@ -882,12 +1045,9 @@ func (e *Engine) shouldExecuteNode(nodeID string) bool {
 		hasExecutedSource = true
 
 		// Check if this edge has a condition (sourceHandle or legacy condition field)
-		edgeCondition := edge.SourceHandle
-		if edgeCondition == nil && edge.Condition != nil {
-			edgeCondition = edge.Condition // Backward compatibility
-		}
+		condition := edgeCondition(edge)
 
-		if edgeCondition == nil {
+		if condition == nil {
 			// Unconditional edge from an executed source - node should execute
 			return true
 		}
@@ -895,7 +1055,7 @@ func (e *Engine) shouldExecuteNode(nodeID string) bool {
 		hasConditionalEdge = true
 
 		// Check if the condition is satisfied based on source node result
-		if e.isConditionSatisfied(sourceResult, *edgeCondition) {
+		if e.isConditionSatisfied(sourceResult, *condition) {
 			conditionSatisfied = true
 			// Don't break here - we might find an unconditional edge
 		}
@@ -922,6 +1082,15 @@ func (e *Engine) getIncomingEdges(nodeID string) []types.Edge {
 	return incoming
 }
 
+// edgeCondition returns the condition of edge: its sourceHandle, or the
+// legacy condition field. Nil for unconditional edges.
+func edgeCondition(edge types.Edge) *string {
+	if edge.SourceHandle == nil && edge.Condition != nil {
+		return edge.Condition // Backward compatibility
+	}
+	return edge.SourceHandle
+}
+
 // isConditionSatisfied checks if an edge condition is satisfied by the source node's result
 // Supports:
 // - "true"/"false" for condition nodes
@@ -1041,10 +1210,11 @@ func (e *Engine) notifyWorkflowStart(ctx context.Context, startTime time.Time) {
 		Timestamp:   startTime,
 		ExecutionID: e.executionID,
 		WorkflowID:  e.workflowID,
+		TenantID:    e.tenantID,
 		StartTime:   startTime,
 	}
 
-	e.observerMgr.Notify(ctx, event)
+	e.notify(ctx, event)
 }
 
 // notifyWorkflowEnd notifies observers that workflow execution has ended
@@ -1064,13 +1234,15 @@ func (e *Engine) notifyWorkflowEnd(ctx context.Context, startTime time.Time, res
 		Timestamp:   time.Now(),
 		ExecutionID: e.executionID,
 		WorkflowID:  e.workflowID,
+		TenantID:    e.tenantID,
 		StartTime:   startTime,
 		ElapsedTime: time.Since(startTime),
 		Result:      result,
 		Error:       err,
+		Metadata:    map[string]interface{}{"nodes_executed": e.GetNodeExecutionCount()},
 	}
 
-	e.observerMgr.Notify(ctx, event)
+	e.notify(ctx, event)
 }
 
 // notifyNodeStart notifies observers that a node execution has started
@@ -1085,12 +1257,45 @@ func (e *Engine) notifyNodeStart(ctx context.Context, node types.Node, startTime
 		Timestamp:   startTime,
 		ExecutionID: e.executionID,
 		WorkflowID:  e.workflowID,
+		TenantID:    e.tenantID,
 		NodeID:      node.ID,
 		NodeType:    node.Type,
 		StartTime:   startTime,
 	}
 
-	e.observerMgr.Notify(ctx, event)
+	if e.capture.CapturesInputs() {
+		event.Inputs = e.GetNodeInputs(node.ID)
+	}
+
+	e.notify(ctx, event)
+}
+
+// notifyNodePaused notifies observers that a debugged execution paused
+// before node
+func (e *Engine) notifyNodePaused(ctx context.Context, node types.Node, reason string) {
+	if !e.observerMgr.HasObservers() {
+		return
+	}
+
+	now := time.Now()
+	event := observer.Event{
+		Type:        observer.EventNodePaused,
+		Status:      observer.StatusPaused,
+		Timestamp:   now,
+		ExecutionID: e.executionID,
+		WorkflowID:  e.workflowID,
+		TenantID:    e.tenantID,
+		NodeID:      node.ID,
+		NodeType:    node.Type,
+		StartTime:   now,
+		Metadata:    map[string]interface{}{"reason": reason},
+	}
+
+	if e.capture.CapturesInputs() {
+		event.Inputs = e.GetNodeInputs(node.ID)
+	}
+
+	e.notify(ctx, event)
 }
 
 // notifyNodeSuccess notifies observers that a node execution succeeded
@@ -1105,6 +1310,7 @@ func (e *Engine) notifyNodeSuccess(ctx context.Context, node types.Node, startTi
 		Timestamp:   time.Now(),
 		ExecutionID: e.executionID,
 		WorkflowID:  e.workflowID,
+		TenantID:    e.tenantID,
 		NodeID:      node.ID,
 		NodeType:    node.Type,
 		StartTime:   startTime,
@@ -1112,7 +1318,11 @@ func (e *Engine) notifyNodeSuccess(ctx context.Context, node types.Node, startTi
 		Result:      result,
 	}
 
-	e.observerMgr.Notify(ctx, event)
+	if e.capture.CapturesInputs() {
+		event.Inputs = e.GetNodeInputs(node.ID)
+	}
+
+	e.notify(ctx, event)
 }
 
 // notifyNodeFailure notifies observers that a node execution failed
@@ -1127,6 +1337,7 @@ func (e *Engine) notifyNodeFailure(ctx context.Context, node types.Node, startTi
 		Timestamp:   time.Now(),
 		ExecutionID: e.executionID,
 		WorkflowID:  e.workflowID,
+		TenantID:    e.tenantID,
 		NodeID:      node.ID,
 		NodeType:    node.Type,
 		StartTime:   startTime,
@@ -1135,5 +1346,9 @@ func (e *Engine) notifyNodeFailure(ctx context.Context, node types.Node, startTi
 		Error:       err,
 	}
 
-	e.observerMgr.Notify(ctx, event)
+	if e.capture.CapturesInputs() {
+		event.Inputs = e.GetNodeInputs(node.ID)
+	}
+
+	e.notify(ctx, event)
 }
@@ -29,6 +29,15 @@ var (
 	ErrStateNotFound = errors.New("state not found")
 	ErrInvalidState  = errors.New("invalid state")
 
+	// Sub-workflow errors
+	ErrSubWorkflowUnavailable   = errors.New("sub-workflow unavailable")
+	ErrSubWorkflowCycle         = errors.New("sub-workflow cycle detected")
+	ErrSubWorkflowDepthExceeded = errors.New("maximum sub-workflow depth exceeded")
+
+	// Debugger errors
+	ErrDebugNotPaused      = errors.New("execution is not paused")
+	ErrDebugSessionExpired = errors.New("debug session expired")
+
 	// Custom executor errors
 	ErrExecutorNotFound           = errors.New("executor not found for node type")
 	ErrExecutorRegistrationFailed = errors.New("failed to register executor")
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// Protection limits reported to Metrics.RecordLimitExceeded
+const (
+	LimitNodeExecutions   = "max_node_executions"
+	LimitHTTPCalls        = "max_http_calls"
+	LimitExecutionTime    = "max_execution_time"
+	LimitResponseSize     = "max_response_size"
+	LimitValueSize        = "max_value_size"
+	LimitVariables        = "max_variables"
+	LimitSubWorkflowDepth = "max_sub_workflow_depth"
+)
+
+// Metrics records measurements that observer events do not carry: each
+// HTTP call executors make and each protection limit that stops a node or
+// the workflow. telemetry.Provider.ForTenant returns one.
+type Metrics interface {
+	// RecordHTTPCall records one HTTP call made with the named client
+	// clientUID ("" for the default client). statusCode is 0 when no
+	// response arrived.
+	RecordHTTPCall(ctx context.Context, clientUID string, statusCode int, duration time.Duration)
+
+	// RecordLimitExceeded records that limit, one of the Limit constants,
+	// was exceeded
+	RecordLimitExceeded(ctx context.Context, limit string)
+}
+
+// SetMetrics sets where HTTP calls and exceeded limits are recorded.
+// Sub-workflows record into the same Metrics.
+// Returns the engine for method chaining.
+func (e *Engine) SetMetrics(metrics Metrics) *Engine {
+	e.metrics = metrics
+	return e
+}
+
+// RecordHTTPCall records an HTTP call made by the node being executed, in
+// its profile and in the engine's metrics
+func (e *Engine) RecordHTTPCall(clientUID string, statusCode int, d time.Duration) {
+	if e.metrics != nil {
+		e.metrics.RecordHTTPCall(e.Context(), clientUID, statusCode, d)
+	}
+	if p := e.profiler; p != nil {
+		p.recordHTTPCall(e, d)
+	}
+}
+
+// limitExceeded records that limit stopped a node or the workflow
+func (e *Engine) limitExceeded(ctx context.Context, limit string) {
+	if e.metrics != nil {
+		e.metrics.RecordLimitExceeded(ctx, limit)
+	}
+}
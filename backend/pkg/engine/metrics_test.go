@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// recordingMetrics records what the engine reports to Metrics
+type recordingMetrics struct {
+	mu     sync.Mutex
+	calls  []string
+	limits []string
+}
+
+func (m *recordingMetrics) RecordHTTPCall(ctx context.Context, clientUID string, statusCode int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, fmt.Sprintf("%s:%d", clientUID, statusCode))
+}
+
+func (m *recordingMetrics) RecordLimitExceeded(ctx context.Context, limit string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limits = append(m.limits, limit)
+}
+
+func TestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("ok"))
+			return
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 64)))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		nodes      string
+		configure  func(*types.Config)
+		wantCalls  []string
+		wantLimits []string
+	}{
+		{
+			name:      "HTTP calls",
+			nodes:     fmt.Sprintf(`{"id": "call", "type": "http", "data": {"url": "%s/missing"}}`, server.URL),
+			wantCalls: []string{":404"},
+		},
+		{
+			name: "HTTP call limit",
+			nodes: fmt.Sprintf(`{"id": "a", "type": "http", "data": {"url": "%[1]s/ok"}},
+				{"id": "b", "type": "http", "data": {"url": "%[1]s/ok"}}`, server.URL),
+			configure:  func(c *types.Config) { c.MaxHTTPCallsPerExec = 1 },
+			wantCalls:  []string{":200"},
+			wantLimits: []string{LimitHTTPCalls},
+		},
+		{
+			name:       "response size limit",
+			nodes:      fmt.Sprintf(`{"id": "call", "type": "http", "data": {"url": "%s/large"}}`, server.URL),
+			configure:  func(c *types.Config) { c.MaxResponseSize = 16 },
+			wantCalls:  []string{":200"},
+			wantLimits: []string{LimitResponseSize},
+		},
+		{
+			name:       "node execution limit",
+			nodes:      `{"id": "a", "data": {"value": 1}}, {"id": "b", "data": {"value": 2}}`,
+			configure:  func(c *types.Config) { c.MaxNodeExecutions = 1 },
+			wantLimits: []string{LimitNodeExecutions},
+		},
+		{
+			name:       "execution time limit",
+			nodes:      `{"id": "wait", "type": "delay", "data": {"duration": "200ms"}}`,
+			configure:  func(c *types.Config) { c.MaxExecutionTime = 20 * time.Millisecond },
+			wantLimits: []string{LimitExecutionTime},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := types.DefaultConfig()
+			config.AllowHTTP = true
+			config.AllowLocalhost = true
+			if tt.configure != nil {
+				tt.configure(&config)
+			}
+			eng, err := NewWithConfig([]byte(`{"nodes": [`+tt.nodes+`]}`), config)
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+			metrics := &recordingMetrics{}
+			eng.SetMetrics(metrics)
+			eng.Execute()
+
+			metrics.mu.Lock()
+			defer metrics.mu.Unlock()
+			if !reflect.DeepEqual(metrics.calls, tt.wantCalls) {
+				t.Errorf("HTTP calls = %v, want %v", metrics.calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(metrics.limits, tt.wantLimits) {
+				t.Errorf("Limits = %v, want %v", metrics.limits, tt.wantLimits)
+			}
+		})
+	}
+}
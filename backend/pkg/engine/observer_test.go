@@ -1,12 +1,17 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/logging"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
 // ============================================================================
@@ -396,6 +401,31 @@ func TestObserverWithWorkflowID(t *testing.T) {
 	}
 }
 
+func TestObserverWithTenantID(t *testing.T) {
+	payload := `{"nodes": [{"id": "1", "data": {"value": 42}}], "edges": []}`
+
+	engine, err := New([]byte(payload))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	engine.SetTenantID("acme")
+
+	obs := newTestObserver()
+	engine.RegisterObserver(obs)
+	obs.expectEvents(4)
+
+	if _, err := engine.Execute(); err != nil {
+		t.Fatalf("Execution failed: %v", err)
+	}
+	obs.wait()
+
+	for _, event := range obs.getEvents() {
+		if event.TenantID != "acme" {
+			t.Errorf("Expected tenant ID 'acme' on %s event, got '%s'", event.Type, event.TenantID)
+		}
+	}
+}
+
 func TestObserverPerformanceNoBlock(t *testing.T) {
 	payload := `{
 		"nodes": [
@@ -435,3 +465,61 @@ func TestObserverPerformanceNoBlock(t *testing.T) {
 		t.Logf("Execution took %v with 100 observers (acceptable but may indicate blocking)", elapsed)
 	}
 }
+
+func TestEventCapture(t *testing.T) {
+	redactor, err := observer.NewRedactor()
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	payload := `{"nodes": [
+		{"id": "text", "type": "text_input", "data": {"text": "{\"token\": \"hunter2\", \"n\": 1}"}},
+		{"id": "obj", "type": "parse", "data": {"input_type": "JSON"}},
+		{"id": "call", "type": "http", "data": {"url": "http://127.0.0.1:1/?key=hunter2"}}
+	], "edges": [{"source": "text", "target": "obj"}, {"source": "obj", "target": "call"}]}`
+
+	config := types.DefaultConfig()
+	config.AllowHTTP = true
+	config.AllowLocalhost = true
+	eng, err := NewWithConfig([]byte(payload), config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	var logs bytes.Buffer
+	recorder := &syncRecorder{}
+	eng.RegisterObserver(recorder).
+		SetStructuredLogger(logging.New(logging.Config{Level: "error", Output: &logs})).
+		SetEventCapture(&observer.Capture{
+			Inputs:   true,
+			Outputs:  true,
+			Redactor: redactor.WithSecrets("hunter2"),
+		})
+
+	if _, err := eng.Execute(); err == nil {
+		t.Fatal("Expected the HTTP node to fail")
+	}
+
+	var parsed, failed observer.Event
+	for _, event := range recorder.events {
+		switch {
+		case event.Type == observer.EventNodeSuccess && event.NodeID == "obj":
+			parsed = event
+		case event.Type == observer.EventNodeFailure:
+			failed = event
+		}
+	}
+
+	wantInputs := []interface{}{`{"token": "***REDACTED***", "n": 1}`}
+	if !reflect.DeepEqual(parsed.Inputs, wantInputs) {
+		t.Errorf("Inputs = %#v, want %#v", parsed.Inputs, wantInputs)
+	}
+	wantResult := map[string]interface{}{"token": observer.Redacted, "n": 1.0}
+	if !reflect.DeepEqual(parsed.Result, wantResult) {
+		t.Errorf("Result = %#v, want %#v", parsed.Result, wantResult)
+	}
+	if failed.Error == nil || strings.Contains(failed.Error.Error(), "hunter2") {
+		t.Errorf("Failure event error = %v, want the secret masked", failed.Error)
+	}
+	if !strings.Contains(logs.String(), "node execution failed") || strings.Contains(logs.String(), "hunter2") {
+		t.Errorf("Logs should record the failure without the secret:\n%s", logs.String())
+	}
+}
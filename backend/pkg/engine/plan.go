@@ -0,0 +1,434 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/state"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// Plan statuses of PlannedNode
+const (
+	PlanRun     = "run"     // The node runs
+	PlanSkip    = "skip"    // The node is skipped
+	PlanRuntime = "runtime" // Whether the node runs depends on runtime data
+)
+
+// Plan is what a workflow would do, as worked out by Engine.Plan without
+// running anything with side effects. Nodes lists every node in execution
+// order; Run, Skipped and Runtime list their IDs by status.
+type Plan struct {
+	WorkflowID string        `json:"workflow_id,omitempty"`
+	Nodes      []PlannedNode `json:"nodes"`
+	Run        []string      `json:"run"`
+	Skipped    []string      `json:"skipped"`
+	Runtime    []string      `json:"runtime"`
+}
+
+// PlannedNode is one node of a Plan. Output is the node's result when it
+// could be worked out from static inputs. Effect describes what a node
+// with side effects would do, such as the request an http node would
+// send. Error is set when the node fails with its static inputs, which
+// would fail the execution.
+type PlannedNode struct {
+	NodeID   string                 `json:"node_id"`
+	NodeType types.NodeType         `json:"node_type"`
+	Status   string                 `json:"status"`           // PlanRun, PlanSkip or PlanRuntime
+	Reason   string                 `json:"reason,omitempty"` // Why the node is skipped or depends on runtime data
+	Effect   map[string]interface{} `json:"effect,omitempty"`
+	Output   interface{}            `json:"output,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// planStub replaces the executor of a node type with side effects while
+// planning. effect describes what the node would do; run produces the
+// node's output without the side effect, or is nil when the output only
+// exists at runtime.
+type planStub struct {
+	effect func(plan *Engine, node types.Node) map[string]interface{}
+	run    func(plan *Engine, node types.Node) (interface{}, error)
+}
+
+// planStubs are the stubs for the node types with side effects. Cache and
+// variable nodes run against the plan's own state, which no execution
+// shares, so later reads in the plan see the writes.
+var planStubs = map[types.NodeType]planStub{
+	types.NodeTypeHTTP:        {effect: httpEffect},
+	types.NodeTypePaginator:   {effect: paginatorEffect},
+	types.NodeTypeSubWorkflow: {effect: subWorkflowEffect},
+	types.NodeTypeDelay:       {effect: delayEffect, run: runDelay},
+	types.NodeTypeCache:       {effect: cacheEffect, run: runExecutor},
+	types.NodeTypeVariable:    {effect: variableEffect, run: runExecutor},
+}
+
+// planSafe are the built-in node types without side effects, which run
+// for real while planning. Other node types, such as those of custom
+// executors, are stubbed and depend on runtime data. Retry, rate_limiter
+// and throttle nodes wait, and rate limiters share their state across
+// executions, so they are stubbed too.
+var planSafe = map[types.NodeType]bool{
+	types.NodeTypeNumber:          true,
+	types.NodeTypeTextInput:       true,
+	types.NodeTypeBooleanInput:    true,
+	types.NodeTypeDateInput:       true,
+	types.NodeTypeDateTimeInput:   true,
+	types.NodeTypeVisualization:   true,
+	types.NodeTypeOperation:       true,
+	types.NodeTypeTextOperation:   true,
+	types.NodeTypeExpression:      true,
+	types.NodeTypeCondition:       true,
+	types.NodeTypeForEach:         true,
+	types.NodeTypeWhileLoop:       true,
+	types.NodeTypeFilter:          true,
+	types.NodeTypeMap:             true,
+	types.NodeTypeReduce:          true,
+	types.NodeTypeSlice:           true,
+	types.NodeTypeSort:            true,
+	types.NodeTypeFind:            true,
+	types.NodeTypeFlatMap:         true,
+	types.NodeTypeGroupBy:         true,
+	types.NodeTypeUnique:          true,
+	types.NodeTypeChunk:           true,
+	types.NodeTypeReverse:         true,
+	types.NodeTypePartition:       true,
+	types.NodeTypeZip:             true,
+	types.NodeTypeSample:          true,
+	types.NodeTypeRange:           true,
+	types.NodeTypeCompact:         true,
+	types.NodeTypeTranspose:       true,
+	types.NodeTypeExtract:         true,
+	types.NodeTypeTransform:       true,
+	types.NodeTypeAccumulator:     true,
+	types.NodeTypeCounter:         true,
+	types.NodeTypeParse:           true,
+	types.NodeTypeFormat:          true,
+	types.NodeTypeSwitch:          true,
+	types.NodeTypeParallel:        true,
+	types.NodeTypeJoin:            true,
+	types.NodeTypeSplit:           true,
+	types.NodeTypeTryCatch:        true,
+	types.NodeTypeTimeout:         true,
+	types.NodeTypeSchemaValidator: true,
+	types.NodeTypeContextVariable: true,
+	types.NodeTypeContextConstant: true,
+	types.NodeTypeRenderer:        true,
+}
+
+// Plan works out which nodes the workflow would run without running
+// anything with side effects. It walks the nodes in execution order and
+// runs the nodes without side effects for real, so the conditions of
+// conditional edges are evaluated wherever the values reaching them are
+// static. Nodes with side effects (http, paginator, sub_workflow, delay,
+// cache and variable) are replaced by stubs that report what they would
+// do. Nodes of any other type not known to be free of side effects,
+// including custom executors, do not run and depend on runtime data.
+// Edges whose condition depends on the output of a stub leave their
+// targets depending on runtime data.
+//
+// The workflow is validated first; an invalid workflow returns the
+// validation error. The engine itself is left untouched and can still
+// be executed.
+func (e *Engine) Plan(ctx context.Context) (*Plan, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	order, err := e.graph.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	if e.config.MaxExecutionTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.MaxExecutionTime)
+		defer cancel()
+	}
+	p := e.planEngine()
+	p.setContext(ctx)
+
+	plan := &Plan{
+		WorkflowID: e.workflowID,
+		Nodes:      make([]PlannedNode, 0, len(order)),
+		Run:        []string{},
+		Skipped:    []string{},
+		Runtime:    []string{},
+	}
+	status := make(map[string]string, len(order))
+	known := make(map[string]bool, len(order)) // Nodes whose output is in p's results
+
+	for _, nodeID := range order {
+		if err := ctx.Err(); err != nil {
+			if err == context.DeadlineExceeded {
+				return nil, fmt.Errorf("%w: planning exceeded %v", ErrExecutionTimeout, e.config.MaxExecutionTime)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrExecutionCanceled, err)
+		}
+
+		node := e.getNode(nodeID)
+		planned := PlannedNode{NodeID: nodeID, NodeType: node.Type}
+		planned.Status, planned.Reason = e.planStatus(nodeID, status, known, p)
+
+		// Node types not known to be free of side effects never run
+		stub, stubbed := planStubs[node.Type]
+		if !stubbed && !planSafe[node.Type] {
+			stub, stubbed = planStub{effect: unknownEffect}, true
+			if planned.Status == PlanRun {
+				planned.Status = PlanRuntime
+				planned.Reason = fmt.Sprintf("%s nodes may have side effects and are not run while planning", node.Type)
+			}
+		}
+		status[nodeID] = planned.Status
+
+		if stubbed && planned.Status != PlanSkip {
+			planned.Effect = e.redactMap(stub.effect(p, node))
+		}
+
+		switch planned.Status {
+		case PlanRun:
+			plan.Run = append(plan.Run, nodeID)
+		case PlanSkip:
+			plan.Skipped = append(plan.Skipped, nodeID)
+		case PlanRuntime:
+			plan.Runtime = append(plan.Runtime, nodeID)
+		}
+
+		// Only nodes that run with static inputs have a static output
+		if planned.Status == PlanRun && e.staticInputs(nodeID, status, known) {
+			run := runExecutor
+			if stubbed {
+				run = stub.run
+			}
+			if run != nil {
+				output, err := p.planNode(node, run)
+				if err != nil {
+					planned.Error = e.capture.RedactError(err).Error()
+				} else {
+					p.SetNodeResult(nodeID, output)
+					known[nodeID] = true
+					planned.Output = e.redactValue(output)
+				}
+			}
+		}
+		plan.Nodes = append(plan.Nodes, planned)
+	}
+	return plan, nil
+}
+
+// planEngine returns the engine plans run nodes with: e's workflow and
+// settings with fresh state and results, and without observers
+func (e *Engine) planEngine() *Engine {
+	return &Engine{
+		graph:              e.graph,
+		state:              state.New(),
+		registry:           e.registry,
+		config:             e.config,
+		results:            make(map[string]interface{}),
+		executionID:        e.executionID,
+		workflowID:         e.workflowID,
+		tenantID:           e.tenantID,
+		nodes:              e.nodes,
+		edges:              e.edges,
+		observerMgr:        observer.NewManager(),
+		logger:             &observer.NoOpLogger{},
+		capture:            e.capture,
+		structuredLogger:   e.structuredLogger,
+		httpClientRegistry: e.httpClientRegistry,
+	}
+}
+
+// planNode runs node with run for a plan, counting it towards
+// MaxNodeExecutions
+func (e *Engine) planNode(node types.Node, run func(*Engine, types.Node) (interface{}, error)) (interface{}, error) {
+	if err := e.IncrementNodeExecution(); err != nil {
+		return nil, err
+	}
+	return run(e, node)
+}
+
+// planStatus works out whether nodeID runs like shouldExecuteNode, from
+// the statuses of the nodes before it and the outputs known in p. An edge
+// whose source depends on runtime data, or whose condition is on an
+// output only known at runtime, makes the node depend on runtime data
+// unless another edge makes it run.
+func (e *Engine) planStatus(nodeID string, status map[string]string, known map[string]bool, p *Engine) (string, string) {
+	incoming := e.getIncomingEdges(nodeID)
+	if len(incoming) == 0 {
+		return PlanRun, ""
+	}
+
+	runtimeReason, unmetReason := "", ""
+	for _, edge := range incoming {
+		if status[edge.Source] == PlanSkip {
+			continue
+		}
+		condition := edgeCondition(edge)
+		if condition != nil && !known[edge.Source] {
+			runtimeReason = fmt.Sprintf("condition %q on the edge from %s depends on runtime data", *condition, edge.Source)
+			continue
+		}
+		if condition != nil {
+			result, _ := p.GetNodeResult(edge.Source)
+			if !e.isConditionSatisfied(result, *condition) {
+				unmetReason = fmt.Sprintf("condition %q on the edge from %s is not met", *condition, edge.Source)
+				continue
+			}
+		}
+		if status[edge.Source] == PlanRun {
+			return PlanRun, ""
+		}
+		runtimeReason = fmt.Sprintf("node %s depends on runtime data", edge.Source)
+	}
+
+	switch {
+	case runtimeReason != "":
+		return PlanRuntime, runtimeReason
+	case unmetReason != "":
+		return PlanSkip, unmetReason
+	default:
+		return PlanSkip, "all nodes before it are skipped"
+	}
+}
+
+// staticInputs reports whether every node feeding nodeID that is not
+// skipped has a static output
+func (e *Engine) staticInputs(nodeID string, status map[string]string, known map[string]bool) bool {
+	for _, edge := range e.getIncomingEdges(nodeID) {
+		if status[edge.Source] != PlanSkip && !known[edge.Source] {
+			return false
+		}
+	}
+	return true
+}
+
+// redactValue masks secrets in v like the node data of events
+func (e *Engine) redactValue(v interface{}) interface{} {
+	if e.capture == nil || e.capture.Redactor == nil {
+		return v
+	}
+	return e.capture.Redactor.Redact(v)
+}
+
+// runExecutor runs node with its registered executor
+func runExecutor(plan *Engine, node types.Node) (interface{}, error) {
+	return plan.registry.Execute(plan, node)
+}
+
+// runDelay returns what a delay node returns, without waiting
+func runDelay(plan *Engine, node types.Node) (interface{}, error) {
+	data, err := types.AsDelayData(node.Data)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if inputs := plan.GetNodeInputs(node.ID); len(inputs) > 0 {
+		value = inputs[0]
+	}
+	return map[string]interface{}{
+		"value":    value,
+		"duration": *data.Duration,
+		"delayed":  true,
+	}, nil
+}
+
+// httpEffect describes the request an http node would send
+func httpEffect(plan *Engine, node types.Node) map[string]interface{} {
+	data, err := types.AsHTTPData(node.Data)
+	if err != nil || data.URL == nil {
+		return nil
+	}
+	effect := map[string]interface{}{
+		"method": "GET",
+		"url":    *data.URL,
+	}
+	if data.HTTPClientUID != nil && *data.HTTPClientUID != "" {
+		effect["http_client_uid"] = *data.HTTPClientUID
+	}
+	return effect
+}
+
+// paginatorEffect describes the pages a paginator node would fetch
+func paginatorEffect(plan *Engine, node types.Node) map[string]interface{} {
+	data, err := types.AsPaginatorData(node.Data)
+	if err != nil {
+		return nil
+	}
+	effect := map[string]interface{}{}
+	if data.PaginationStrategy != nil {
+		effect["pagination_strategy"] = *data.PaginationStrategy
+	}
+	if data.PageSize != nil {
+		effect["page_size"] = *data.PageSize
+	}
+	if data.MaxPages != nil {
+		effect["max_pages"] = *data.MaxPages
+	}
+	return effect
+}
+
+// subWorkflowEffect describes the workflow a sub_workflow node would run
+func subWorkflowEffect(plan *Engine, node types.Node) map[string]interface{} {
+	data, err := types.AsSubWorkflowData(node.Data)
+	if err != nil || data.WorkflowID == nil {
+		return nil
+	}
+	effect := map[string]interface{}{"workflow_id": *data.WorkflowID}
+	if data.Version != nil {
+		effect["version"] = *data.Version
+	}
+	return effect
+}
+
+// unknownEffect describes a node of a type that may have side effects
+// and has no stub of its own
+func unknownEffect(plan *Engine, node types.Node) map[string]interface{} {
+	return map[string]interface{}{"node_type": string(node.Type)}
+}
+
+// delayEffect describes how long a delay node would wait
+func delayEffect(plan *Engine, node types.Node) map[string]interface{} {
+	data, err := types.AsDelayData(node.Data)
+	if err != nil || data.Duration == nil {
+		return nil
+	}
+	return map[string]interface{}{"duration": *data.Duration}
+}
+
+// cacheEffect describes the cache entry a cache node would write or
+// delete; reads have no effect
+func cacheEffect(plan *Engine, node types.Node) map[string]interface{} {
+	data, err := types.AsCacheData(node.Data)
+	if err != nil || data.CacheOp == nil || data.CacheKey == nil || *data.CacheOp == "get" {
+		return nil
+	}
+	effect := map[string]interface{}{
+		"operation": *data.CacheOp,
+		"key":       *data.CacheKey,
+	}
+	if *data.CacheOp == "set" {
+		if data.TTL != nil {
+			effect["ttl"] = *data.TTL
+		}
+		if inputs := plan.GetNodeInputs(node.ID); len(inputs) > 0 {
+			effect["value"] = inputs[0]
+		}
+	}
+	return effect
+}
+
+// variableEffect describes the variable a variable node would set; reads
+// have no effect
+func variableEffect(plan *Engine, node types.Node) map[string]interface{} {
+	data, err := types.AsVariableData(node.Data)
+	if err != nil || data.VarOp == nil || data.VarName == nil || *data.VarOp != "set" {
+		return nil
+	}
+	effect := map[string]interface{}{
+		"operation": "set",
+		"var_name":  *data.VarName,
+	}
+	if inputs := plan.GetNodeInputs(node.ID); len(inputs) > 0 {
+		effect["value"] = inputs[0]
+	}
+	return effect
+}
@@ -0,0 +1,271 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/executor"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestPlan(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		payload     string
+		wantRun     []string
+		wantSkipped []string
+		wantRuntime []string
+		check       func(t *testing.T, nodes map[string]PlannedNode)
+	}{
+		{
+			name: "static condition",
+			payload: `{
+				"nodes": [
+					{"id": "age", "type": "number", "data": {"value": 25}},
+					{"id": "check", "type": "condition", "data": {"condition": ">=18"}},
+					{"id": "adult", "type": "text_input", "data": {"text": "adult"}},
+					{"id": "minor", "type": "text_input", "data": {"text": "minor"}},
+					{"id": "after_minor", "type": "text_input", "data": {"text": "after"}}
+				],
+				"edges": [
+					{"source": "age", "target": "check"},
+					{"source": "check", "target": "adult", "sourceHandle": "true"},
+					{"source": "check", "target": "minor", "sourceHandle": "false"},
+					{"source": "minor", "target": "after_minor"}
+				]
+			}`,
+			wantRun:     []string{"age", "check", "adult"},
+			wantSkipped: []string{"minor", "after_minor"},
+			wantRuntime: []string{},
+			check: func(t *testing.T, nodes map[string]PlannedNode) {
+				if want := `condition "false" on the edge from check is not met`; nodes["minor"].Reason != want {
+					t.Errorf("minor reason = %q, want %q", nodes["minor"].Reason, want)
+				}
+				if want := "all nodes before it are skipped"; nodes["after_minor"].Reason != want {
+					t.Errorf("after_minor reason = %q, want %q", nodes["after_minor"].Reason, want)
+				}
+				if nodes["adult"].Output == nil {
+					t.Error("adult has no output")
+				}
+			},
+		},
+		{
+			name: "condition on http response",
+			payload: `{
+				"nodes": [
+					{"id": "fetch", "type": "http", "data": {"url": "` + server.URL + `/orders", "http_client_uid": "prod"}},
+					{"id": "check", "type": "condition", "data": {"condition": ">0"}},
+					{"id": "post", "type": "http", "data": {"url": "` + server.URL + `/notify"}},
+					{"id": "wait", "type": "delay", "data": {"duration": "1h"}},
+					{"id": "done", "type": "text_input", "data": {"text": "done"}}
+				],
+				"edges": [
+					{"source": "fetch", "target": "check"},
+					{"source": "check", "target": "post", "sourceHandle": "true"},
+					{"source": "post", "target": "wait"},
+					{"source": "check", "target": "done"}
+				]
+			}`,
+			wantRun:     []string{"fetch", "check", "done"},
+			wantSkipped: []string{},
+			wantRuntime: []string{"post", "wait"},
+			check: func(t *testing.T, nodes map[string]PlannedNode) {
+				want := map[string]interface{}{"method": "GET", "url": server.URL + "/orders", "http_client_uid": "prod"}
+				if !reflect.DeepEqual(nodes["fetch"].Effect, want) {
+					t.Errorf("fetch effect = %v, want %v", nodes["fetch"].Effect, want)
+				}
+				if nodes["fetch"].Output != nil || nodes["check"].Output != nil {
+					t.Error("Nodes depending on the response have outputs")
+				}
+				if want := `condition "true" on the edge from check depends on runtime data`; nodes["post"].Reason != want {
+					t.Errorf("post reason = %q, want %q", nodes["post"].Reason, want)
+				}
+				if want := "node post depends on runtime data"; nodes["wait"].Reason != want {
+					t.Errorf("wait reason = %q, want %q", nodes["wait"].Reason, want)
+				}
+				if nodes["post"].Effect["url"] != server.URL+"/notify" {
+					t.Errorf("post effect = %v, want the request it would send", nodes["post"].Effect)
+				}
+			},
+		},
+		{
+			name: "stubbed state and delay",
+			payload: `{
+				"nodes": [
+					{"id": "n", "type": "number", "data": {"value": 7}},
+					{"id": "set", "type": "variable", "data": {"var_op": "set", "var_name": "x"}},
+					{"id": "get", "type": "variable", "data": {"var_op": "get", "var_name": "x"}},
+					{"id": "cache", "type": "cache", "data": {"cache_op": "set", "cache_key": "k", "ttl": "1m"}},
+					{"id": "wait", "type": "delay", "data": {"duration": "1h"}}
+				],
+				"edges": [
+					{"source": "n", "target": "set"},
+					{"source": "set", "target": "get"},
+					{"source": "n", "target": "cache"},
+					{"source": "n", "target": "wait"}
+				]
+			}`,
+			wantRun:     []string{"n", "set", "cache", "wait", "get"},
+			wantSkipped: []string{},
+			wantRuntime: []string{},
+			check: func(t *testing.T, nodes map[string]PlannedNode) {
+				want := map[string]interface{}{"operation": "set", "var_name": "x", "value": float64(7)}
+				if !reflect.DeepEqual(nodes["set"].Effect, want) {
+					t.Errorf("set effect = %v, want %v", nodes["set"].Effect, want)
+				}
+				if got := nodes["get"].Output.(map[string]interface{})["value"]; got != float64(7) {
+					t.Errorf("get read x = %v, want 7", got)
+				}
+				if nodes["get"].Effect != nil {
+					t.Errorf("get effect = %v, want none", nodes["get"].Effect)
+				}
+				want = map[string]interface{}{"operation": "set", "key": "k", "ttl": "1m", "value": float64(7)}
+				if !reflect.DeepEqual(nodes["cache"].Effect, want) {
+					t.Errorf("cache effect = %v, want %v", nodes["cache"].Effect, want)
+				}
+				if nodes["wait"].Output == nil {
+					t.Error("wait has no output")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng, err := New([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			plan, err := eng.Plan(ctx)
+			if err != nil {
+				t.Fatalf("Plan failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(plan.Run, tt.wantRun) {
+				t.Errorf("Run = %v, want %v", plan.Run, tt.wantRun)
+			}
+			if !reflect.DeepEqual(plan.Skipped, tt.wantSkipped) {
+				t.Errorf("Skipped = %v, want %v", plan.Skipped, tt.wantSkipped)
+			}
+			if !reflect.DeepEqual(plan.Runtime, tt.wantRuntime) {
+				t.Errorf("Runtime = %v, want %v", plan.Runtime, tt.wantRuntime)
+			}
+			nodes := make(map[string]PlannedNode, len(plan.Nodes))
+			for _, node := range plan.Nodes {
+				nodes[node.NodeID] = node
+			}
+			tt.check(t, nodes)
+
+			if count := eng.GetNodeExecutionCount(); count != 0 {
+				t.Errorf("Engine node executions = %d after planning, want 0", count)
+			}
+		})
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("Planning sent %d HTTP requests, want none", n)
+	}
+}
+
+// sendEmailExecutor is a custom executor with a side effect, counting
+// the emails it sends
+type sendEmailExecutor struct {
+	sent int32
+}
+
+func (e *sendEmailExecutor) Execute(ctx executor.ExecutionContext, node types.Node) (interface{}, error) {
+	atomic.AddInt32(&e.sent, 1)
+	return "sent", nil
+}
+
+func (e *sendEmailExecutor) NodeType() types.NodeType {
+	return types.NodeType("send_email")
+}
+
+func (e *sendEmailExecutor) Validate(node types.Node) error {
+	return nil
+}
+
+func TestPlan_CustomExecutor(t *testing.T) {
+	sender := &sendEmailExecutor{}
+	registry := DefaultRegistry()
+	registry.MustRegister(sender)
+	eng, err := NewWithRegistry([]byte(`{
+		"nodes": [
+			{"id": "email", "type": "send_email", "data": {}},
+			{"id": "after", "type": "text_input", "data": {"text": "done"}}
+		],
+		"edges": [{"source": "email", "target": "after"}]
+	}`), types.DefaultConfig(), registry)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	plan, err := eng.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&sender.sent); n != 0 {
+		t.Errorf("Planning called the custom executor %d times, want none", n)
+	}
+	if want := []string{"email", "after"}; !reflect.DeepEqual(plan.Runtime, want) {
+		t.Errorf("Runtime = %v, want %v", plan.Runtime, want)
+	}
+	email := plan.Nodes[0]
+	if email.Output != nil || email.Effect["node_type"] != "send_email" {
+		t.Errorf("Planned custom node = %+v, want a stub without output", email)
+	}
+}
+
+func TestPlan_Errors(t *testing.T) {
+	t.Run("invalid workflow", func(t *testing.T) {
+		eng, err := New([]byte(`{"nodes": [{"id": "1", "type": "number", "data": {"value": 1}}], "edges": [{"source": "1", "target": "2"}]}`))
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		if _, err := eng.Plan(context.Background()); !errors.Is(err, ErrInvalidEdge) {
+			t.Errorf("Plan error = %v, want ErrInvalidEdge", err)
+		}
+	})
+
+	t.Run("failing node", func(t *testing.T) {
+		eng, err := New([]byte(`{"nodes": [{"id": "1", "type": "variable", "data": {"var_op": "get", "var_name": "missing"}}], "edges": []}`))
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		plan, err := eng.Plan(context.Background())
+		if err != nil {
+			t.Fatalf("Plan failed: %v", err)
+		}
+		if node := plan.Nodes[0]; node.Status != PlanRun || node.Error == "" {
+			t.Errorf("Planned node = %+v, want a node that runs and fails", node)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		eng, err := New(debugPayload)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := eng.Plan(ctx); !errors.Is(err, ErrExecutionCanceled) {
+			t.Errorf("Plan error = %v, want ErrExecutionCanceled", err)
+		}
+	})
+}
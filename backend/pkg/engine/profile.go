@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// profiler records node timings for an execution's profile. It is shared
+// by the engines of the execution's sub-workflows, whose nodes it records
+// under the sub_workflow node running them.
+type profiler struct {
+	mu      sync.Mutex
+	start   time.Time
+	nodes   []types.NodeProfile
+	starts  []time.Time
+	running []bool
+	current map[*Engine]int // Record of the node each engine is running
+}
+
+// SetProfiling turns the execution profile on or off. A profiled run's
+// result carries a types.Profile with each node's wall time, HTTP wait
+// and loop iterations, and the workflow's critical path.
+// Returns the engine for method chaining.
+func (e *Engine) SetProfiling(enabled bool) *Engine {
+	if enabled {
+		e.profiler = &profiler{current: map[*Engine]int{}}
+	} else {
+		e.profiler = nil
+	}
+	return e
+}
+
+// RecordIterations sets the iteration count of the loop node being
+// executed
+func (e *Engine) RecordIterations(n int) {
+	if p := e.profiler; p != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if i, ok := p.current[e]; ok {
+			p.nodes[i].Iterations = n
+		}
+	}
+}
+
+// recordHTTPCall adds an HTTP call that waited d to the node e is running
+func (p *profiler) recordHTTPCall(e *Engine, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i, ok := p.current[e]; ok {
+		p.nodes[i].HTTPCalls++
+		p.nodes[i].HTTPWaitMS += milliseconds(d)
+	}
+}
+
+// beginNode starts the record of a node executed by e
+func (p *profiler) beginNode(e *Engine, node types.Node, start time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	record := types.NodeProfile{
+		NodeID:   e.eventNodeID(node.ID),
+		NodeType: node.Type,
+		StartMS:  milliseconds(start.Sub(p.start)),
+	}
+	if e.sub != nil {
+		record.Parent = e.sub.nodeID
+	}
+	p.nodes = append(p.nodes, record)
+	p.starts = append(p.starts, start)
+	p.running = append(p.running, true)
+	p.current[e] = len(p.nodes) - 1
+	return len(p.nodes) - 1
+}
+
+// endNode completes record i, started by e
+func (p *profiler) endNode(e *Engine, i int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nodes[i].DurationMS = milliseconds(time.Since(p.starts[i]))
+	p.nodes[i].Failed = err != nil
+	p.running[i] = false
+	delete(p.current, e)
+}
+
+// profile builds the profile of e's execution so far. Nodes still running,
+// as when the execution timed out, are timed up to now.
+func (e *Engine) profile() *types.Profile {
+	p := e.profiler
+	p.mu.Lock()
+	now := time.Now()
+	prof := &types.Profile{
+		TotalMS: milliseconds(now.Sub(p.start)),
+		Nodes:   append([]types.NodeProfile{}, p.nodes...),
+	}
+	for i := range prof.Nodes {
+		if p.running[i] {
+			prof.Nodes[i].DurationMS = milliseconds(now.Sub(p.starts[i]))
+			prof.Incomplete = true
+		}
+	}
+	p.mu.Unlock()
+
+	durations := make(map[string]float64, len(prof.Nodes))
+	for _, node := range prof.Nodes {
+		prof.HTTPWaitMS += node.HTTPWaitMS
+		if node.Parent == "" {
+			durations[node.NodeID] = node.DurationMS
+		}
+	}
+	path, total, err := e.graph.CriticalPath(func(nodeID string) float64 {
+		return durations[nodeID]
+	})
+	if err == nil {
+		prof.CriticalPath = path
+		prof.CriticalPathMS = total
+	}
+	if prof.CriticalPath == nil {
+		prof.CriticalPath = []string{}
+	}
+	return prof
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
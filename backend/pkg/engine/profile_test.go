@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte(`[1, 2, 3]`))
+	}))
+	defer server.Close()
+
+	source := mapSource{
+		"double": `{
+			"inputs": [{"name": "n", "type": "number"}],
+			"nodes": [
+				{"id": "in", "data": {"value": 0}},
+				{"id": "twice", "type": "expression", "data": {"expression": "context.n * 2"}}
+			],
+			"edges": [{"source": "in", "target": "twice"}]
+		}`,
+	}
+	payload := fmt.Sprintf(`{"workflow_id": "wf", "nodes": [
+		{"id": "call", "type": "http", "data": {"url": %q}},
+		{"id": "parse", "type": "parse", "data": {"input_type": "JSON"}},
+		{"id": "each", "type": "for_each", "data": {}},
+		{"id": "wait", "type": "delay", "data": {"duration": "5ms"}},
+		{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "double", "inputs": {"n": 2}}}
+	], "edges": [
+		{"source": "call", "target": "parse"},
+		{"source": "parse", "target": "each"}
+	]}`, server.URL)
+
+	config := types.DefaultConfig()
+	config.AllowHTTP = true
+	config.AllowLocalhost = true
+	eng, err := NewWithConfig([]byte(payload), config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	eng.SetWorkflowSource(source).SetProfiling(true)
+
+	result, err := eng.Execute()
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	profile := result.Profile
+	if profile == nil {
+		t.Fatal("Result has no profile")
+	}
+
+	nodes := map[string]types.NodeProfile{}
+	for _, node := range profile.Nodes {
+		nodes[node.NodeID] = node
+	}
+	if len(nodes) != 8 {
+		t.Errorf("Profiled %d nodes, want 8: %+v", len(nodes), profile.Nodes)
+	}
+	if call := nodes["call"]; call.HTTPCalls != 1 || call.HTTPWaitMS < 30 || call.DurationMS < call.HTTPWaitMS {
+		t.Errorf("call = %+v, want 1 HTTP call of at least 30ms", call)
+	}
+	if each := nodes["each"]; each.Iterations != 3 || each.NodeType != types.NodeTypeForEach {
+		t.Errorf("each = %+v, want 3 iterations", each)
+	}
+	if twice := nodes["sub/twice"]; twice.Parent != "sub" {
+		t.Errorf("sub/twice parent = %q, want sub", twice.Parent)
+	}
+	if profile.HTTPWaitMS != nodes["call"].HTTPWaitMS {
+		t.Errorf("HTTPWaitMS = %v, want %v", profile.HTTPWaitMS, nodes["call"].HTTPWaitMS)
+	}
+	if got := strings.Join(profile.CriticalPath, ","); got != "call,parse,each" {
+		t.Errorf("CriticalPath = %v, want [call parse each]", profile.CriticalPath)
+	}
+	if want := nodes["call"].DurationMS + nodes["parse"].DurationMS + nodes["each"].DurationMS; profile.CriticalPathMS != want {
+		t.Errorf("CriticalPathMS = %v, want %v", profile.CriticalPathMS, want)
+	}
+	if profile.TotalMS < profile.CriticalPathMS || profile.Incomplete {
+		t.Errorf("TotalMS = %v, Incomplete = %v", profile.TotalMS, profile.Incomplete)
+	}
+
+	var folded bytes.Buffer
+	if err := profile.WriteFolded(&folded, "wf"); err != nil {
+		t.Fatalf("WriteFolded failed: %v", err)
+	}
+	for _, stack := range []string{"wf;call;http ", "wf;wait ", "wf;sub;sub/twice "} {
+		if !strings.Contains(folded.String(), "\n"+stack) {
+			t.Errorf("Folded profile has no %q stack:\n%s", stack, folded.String())
+		}
+	}
+}
+
+func TestProfile_Disabled(t *testing.T) {
+	eng, err := New([]byte(`{"nodes": [{"id": "a", "data": {"value": 1}}]}`))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	result, err := eng.Execute()
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Profile != nil {
+		t.Errorf("Profile = %+v, want none", result.Profile)
+	}
+}
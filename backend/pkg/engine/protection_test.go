@@ -1,7 +1,9 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -379,6 +381,56 @@ func TestWorkflowTimeoutStillWorks(t *testing.T) {
 	}
 }
 
+// TestExecuteContextCancellation verifies that cancelling the caller's
+// context stops execution and is reported as ErrExecutionCanceled
+func TestExecuteContextCancellation(t *testing.T) {
+	payload := `{
+		"nodes": [
+			{"id": "1", "type": "number", "data": {"value": 1}},
+			{"id": "2", "type": "delay", "data": {"duration": "10s"}}
+		],
+		"edges": [
+			{"id": "e1", "source": "1", "target": "2"}
+		]
+	}`
+
+	engine, err := NewWithConfig([]byte(payload), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = engine.ExecuteContext(ctx)
+	if !errors.Is(err, ErrExecutionCanceled) {
+		t.Errorf("Expected ErrExecutionCanceled, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Cancellation took %v", elapsed)
+	}
+}
+
+// TestMaxNodes verifies that workflows over config.MaxNodes are rejected
+// when the engine is created
+func TestMaxNodes(t *testing.T) {
+	config := types.DefaultConfig()
+	config.MaxNodes = 3
+
+	if _, err := NewWithConfig([]byte(createLinearWorkflow(3)), config); err != nil {
+		t.Errorf("Workflow at the limit: unexpected error %v", err)
+	}
+	if _, err := NewWithConfig([]byte(createLinearWorkflow(4)), config); !errors.Is(err, ErrMaxNodesExceeded) {
+		t.Errorf("Expected ErrMaxNodesExceeded, got: %v", err)
+	}
+
+	config.MaxNodes = 0 // 0 means unlimited
+	if _, err := NewWithConfig([]byte(createLinearWorkflow(10)), config); err != nil {
+		t.Errorf("Unlimited nodes: unexpected error %v", err)
+	}
+}
+
 // Helper functions to create test workflows
 
 func createLinearWorkflow(nodeCount int) string {
@@ -19,10 +19,11 @@ import (
 // It contains all information needed to restore and resume execution from a specific point.
 type Snapshot struct {
 	// Metadata
-	Version      string    `json:"version"`       // Snapshot format version
-	SnapshotTime time.Time `json:"snapshot_time"` // When snapshot was created
-	WorkflowID   string    `json:"workflow_id"`   // Workflow definition ID
-	ExecutionID  string    `json:"execution_id"`  // Unique execution ID
+	Version      string    `json:"version"`             // Snapshot format version
+	SnapshotTime time.Time `json:"snapshot_time"`       // When snapshot was created
+	WorkflowID   string    `json:"workflow_id"`         // Workflow definition ID
+	ExecutionID  string    `json:"execution_id"`        // Unique execution ID
+	TenantID     string    `json:"tenant_id,omitempty"` // Tenant the execution runs for
 
 	// Workflow Definition
 	Nodes []types.Node `json:"nodes"` // Node definitions
@@ -47,6 +48,10 @@ type Snapshot struct {
 
 	// Configuration
 	Config types.Config `json:"config"` // Engine configuration
+
+	// Debugging: the breakpoints of a debugged execution, to debug the
+	// resumed execution with
+	Breakpoints []string `json:"breakpoints,omitempty"`
 }
 
 // snapshotVersion is the current snapshot format version
@@ -62,11 +67,23 @@ const snapshotVersion = "1.0.0"
 //   - Execution progress (completed nodes, current level)
 //   - Runtime protection counters
 //   - Engine configuration
+//   - Breakpoints, when debugging
 //
 // Returns:
 //   - *Snapshot: Complete execution state snapshot
 //   - error: If snapshot creation fails
 func (e *Engine) SaveSnapshot() (*Snapshot, error) {
+	snapshot := e.saveSnapshot()
+	if d := e.debugger; d != nil {
+		d.mu.Lock()
+		snapshot.Breakpoints = d.breakpointList()
+		d.mu.Unlock()
+	}
+	return snapshot, nil
+}
+
+// saveSnapshot captures the execution state for SaveSnapshot
+func (e *Engine) saveSnapshot() *Snapshot {
 	e.resultsMu.RLock()
 	e.countersMu.RLock()
 	defer e.resultsMu.RUnlock()
@@ -97,6 +114,7 @@ func (e *Engine) SaveSnapshot() (*Snapshot, error) {
 		SnapshotTime:       time.Now(),
 		WorkflowID:         e.workflowID,
 		ExecutionID:        e.executionID,
+		TenantID:           e.tenantID,
 		Nodes:              e.nodes,
 		Edges:              e.edges,
 		Results:            results,
@@ -112,19 +130,23 @@ func (e *Engine) SaveSnapshot() (*Snapshot, error) {
 		HTTPCallCount:      e.httpCallCount,
 		Config:             e.config,
 	}
-
-	return snapshot, nil
+	return snapshot
 }
 
 // LoadSnapshot restores a workflow execution from a snapshot.
 // This creates a new Engine instance with state restored from the snapshot.
 //
 // The restored engine will:
-//   - Have the same execution ID as the original
+//   - Have the same execution ID as the original, or a new one when the
+//     snapshot's ExecutionID is cleared
 //   - Have all node results from before the snapshot
 //   - Have all state manager data restored
 //   - Have runtime counters restored
-//   - Be ready to resume execution from where it left off
+//   - Be ready to resume execution from where it left off: nodes completed
+//     before the snapshot do not run again
+//
+// The restored engine is not debugged; call SetDebug with the snapshot's
+// Breakpoints to resume a debug session.
 //
 // Parameters:
 //   - snapshot: Previously saved snapshot
@@ -143,15 +165,28 @@ func LoadSnapshot(snapshot *Snapshot, registry *executor.Registry) (*Engine, err
 		return nil, fmt.Errorf("unsupported snapshot version: %s (expected %s)", snapshot.Version, snapshotVersion)
 	}
 
+	if snapshot.Config.MaxNodes > 0 && len(snapshot.Nodes) > snapshot.Config.MaxNodes {
+		return nil, fmt.Errorf("%w: workflow has %d nodes, limit is %d", ErrMaxNodesExceeded, len(snapshot.Nodes), snapshot.Config.MaxNodes)
+	}
+
 	// Use default registry if none provided
 	if registry == nil {
 		registry = DefaultRegistry()
 	}
 
+	// Keep the original execution ID unless the caller cleared it
+	executionID := snapshot.ExecutionID
+	if executionID == "" {
+		executionID = generateExecutionID()
+	}
+
 	// Create structured logger with workflow and execution context
 	structuredLogger := logging.New(logging.DefaultConfig()).
 		WithWorkflowID(snapshot.WorkflowID).
-		WithExecutionID(snapshot.ExecutionID)
+		WithExecutionID(executionID)
+	if snapshot.TenantID != "" {
+		structuredLogger = structuredLogger.WithTenantID(snapshot.TenantID)
+	}
 
 	// Create new engine with restored state
 	engine := &Engine{
@@ -159,8 +194,9 @@ func LoadSnapshot(snapshot *Snapshot, registry *executor.Registry) (*Engine, err
 		registry:           registry,
 		config:             snapshot.Config,
 		results:            make(map[string]interface{}),
-		executionID:        snapshot.ExecutionID, // Keep original execution ID
+		executionID:        executionID,
 		workflowID:         snapshot.WorkflowID,
+		tenantID:           snapshot.TenantID,
 		nodes:              snapshot.Nodes,
 		edges:              snapshot.Edges,
 		nodeExecutionCount: snapshot.NodeExecutionCount,
@@ -174,6 +210,10 @@ func LoadSnapshot(snapshot *Snapshot, registry *executor.Registry) (*Engine, err
 	for nodeID, result := range snapshot.Results {
 		engine.results[nodeID] = result
 	}
+	engine.completed = make(map[string]bool, len(snapshot.CompletedNodes))
+	for _, nodeID := range snapshot.CompletedNodes {
+		engine.completed[nodeID] = true
+	}
 
 	// Restore state manager data
 	for name, value := range snapshot.Variables {
@@ -257,8 +297,8 @@ func DeserializeSnapshot(data []byte) (*Snapshot, error) {
 // ExecuteFromSnapshot is a convenience method that loads a snapshot and executes the workflow.
 // This combines LoadSnapshot and Execute in a single call.
 //
-// Note: The current implementation will re-execute all nodes in the workflow.
-// Future versions may support incremental execution to skip already-completed nodes.
+// Nodes completed before the snapshot keep their results and do not run
+// again; execution continues with the remaining nodes.
 //
 // Parameters:
 //   - snapshot: Previously saved snapshot
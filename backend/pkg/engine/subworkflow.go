@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+)
+
+// WorkflowSource looks up the saved workflows that sub_workflow nodes run.
+// The root workflow package adapts a WorkflowStore to it.
+type WorkflowSource interface {
+	// Workflow returns the payload of a saved workflow's version, or of its
+	// latest version when version is 0, and the version number returned
+	Workflow(id string, version int) ([]byte, int, error)
+}
+
+// subWorkflow links the engine running a sub_workflow node's child to the
+// engine running the node
+type subWorkflow struct {
+	parent     *Engine
+	nodeID     string // Event ID of the sub_workflow node, e.g. "outer/inner"
+	workflowID string
+	version    int
+}
+
+// SetWorkflowSource sets where sub_workflow nodes find the workflows they
+// run. Without one, sub_workflow nodes fail.
+// Returns the engine for method chaining.
+func (e *Engine) SetWorkflowSource(source WorkflowSource) *Engine {
+	e.workflowSource = source
+	return e
+}
+
+// SetWorkflowID sets the ID of the saved workflow being run, overriding the
+// payload's workflow_id. Sub-workflow cycle detection starts from it.
+// Returns the engine for method chaining.
+func (e *Engine) SetWorkflowID(workflowID string) *Engine {
+	e.workflowID = workflowID
+	e.structuredLogger = e.structuredLogger.WithWorkflowID(workflowID)
+	return e
+}
+
+// ExecuteSubWorkflow runs a saved workflow for the sub_workflow node nodeID
+// and returns its final output. The child gets its own node results and
+// state, but shares the parent's protection counters, deadline, tenant,
+// HTTP clients and observers. Its node events carry the parent's execution
+// ID and node IDs prefixed with the sub_workflow node's ID.
+func (e *Engine) ExecuteSubWorkflow(nodeID, workflowID string, version int, inputs map[string]interface{}) (interface{}, error) {
+	if e.workflowSource == nil {
+		return nil, fmt.Errorf("%w: no workflow source configured", ErrSubWorkflowUnavailable)
+	}
+
+	stack := e.workflowStack()
+	for i, id := range stack {
+		if id == workflowID {
+			cycle := append(stack[i:len(stack):len(stack)], workflowID)
+			return nil, fmt.Errorf("%w: %s", ErrSubWorkflowCycle, strings.Join(cycle, " -> "))
+		}
+	}
+	if limit := e.config.MaxSubWorkflowDepth; limit > 0 && e.depth() >= limit {
+		e.limitExceeded(e.Context(), LimitSubWorkflowDepth)
+		return nil, fmt.Errorf("%w: limit is %d", ErrSubWorkflowDepthExceeded, limit)
+	}
+
+	payload, resolved, err := e.workflowSource.Workflow(workflowID, version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: workflow %s: %v", ErrSubWorkflowUnavailable, workflowID, err)
+	}
+	bound, err := params.Bind(payload, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("sub-workflow %s: %w", workflowID, err)
+	}
+
+	child, err := NewWithRegistry(bound, e.config, e.registry)
+	if err != nil {
+		return nil, fmt.Errorf("sub-workflow %s: %w", workflowID, err)
+	}
+	child.sub = &subWorkflow{
+		parent:     e,
+		nodeID:     e.eventNodeID(nodeID),
+		workflowID: workflowID,
+		version:    resolved,
+	}
+	child.workflowID = workflowID
+	child.executionID = e.executionID
+	child.tenantID = e.tenantID
+	child.httpClientRegistry = e.httpClientRegistry
+	child.workflowSource = e.workflowSource
+	child.observerMgr = e.observerMgr
+	child.logger = e.logger
+	child.tracer = e.tracer
+	child.capture = e.capture
+	child.profiler = e.profiler
+	child.metrics = e.metrics
+	child.structuredLogger = e.structuredLogger.
+		WithField("sub_workflow_id", workflowID).
+		WithField("sub_workflow_node", child.sub.nodeID)
+
+	result, err := child.ExecuteContext(e.Context())
+	if err != nil {
+		return nil, fmt.Errorf("sub-workflow %s: %w", workflowID, err)
+	}
+	return result.FinalOutput, nil
+}
+
+// workflowStack returns the IDs of the workflows running this engine's
+// workflow, outermost first, ending with its own. The outermost ID is
+// missing when it is unknown.
+func (e *Engine) workflowStack() []string {
+	var stack []string
+	for cur := e; cur != nil; cur = cur.parentEngine() {
+		if cur.workflowID != "" {
+			stack = append([]string{cur.workflowID}, stack...)
+		}
+	}
+	return stack
+}
+
+// depth returns how many sub_workflow nodes this engine runs under
+func (e *Engine) depth() int {
+	n := 0
+	for cur := e.parentEngine(); cur != nil; cur = cur.parentEngine() {
+		n++
+	}
+	return n
+}
+
+// parentEngine returns the engine running this one's sub_workflow node, or
+// nil for a top-level engine
+func (e *Engine) parentEngine() *Engine {
+	if e.sub == nil {
+		return nil
+	}
+	return e.sub.parent
+}
+
+// rootEngine returns the top-level engine, which owns the protection
+// counters
+func (e *Engine) rootEngine() *Engine {
+	root := e
+	for parent := root.parentEngine(); parent != nil; parent = root.parentEngine() {
+		root = parent
+	}
+	return root
+}
+
+// eventNodeID returns the node ID used in observer events: nested nodes
+// are prefixed with the IDs of the sub_workflow nodes running them
+func (e *Engine) eventNodeID(nodeID string) string {
+	if e.sub == nil {
+		return nodeID
+	}
+	return e.sub.nodeID + "/" + nodeID
+}
+
+// notify sends an event to the observers. A child engine's events are
+// relabelled as events of the top-level workflow's nodes; its own
+// workflow start and end are covered by the sub_workflow node's events
+// and are not sent.
+func (e *Engine) notify(ctx context.Context, event observer.Event) {
+	if e.sub != nil {
+		if event.NodeID == "" {
+			return
+		}
+		root := e.rootEngine()
+		event.WorkflowID = root.workflowID
+		event.NodeID = e.eventNodeID(event.NodeID)
+		event.Metadata = map[string]interface{}{
+			"sub_workflow_id":      e.sub.workflowID,
+			"sub_workflow_version": e.sub.version,
+			"sub_workflow_node":    e.sub.nodeID,
+		}
+	}
+	e.observerMgr.Notify(ctx, e.capture.Apply(event))
+}
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// mapSource is a WorkflowSource over in-memory payloads; every workflow is
+// at version 1
+type mapSource map[string]string
+
+func (s mapSource) Workflow(id string, version int) ([]byte, int, error) {
+	payload, ok := s[id]
+	if !ok || version > 1 {
+		return nil, 0, fmt.Errorf("workflow %s version %d not found", id, version)
+	}
+	return []byte(payload), 1, nil
+}
+
+// syncRecorder records events inline, in emission order
+type syncRecorder struct {
+	mu     sync.Mutex
+	events []observer.Event
+}
+
+func (r *syncRecorder) OnEvent(_ context.Context, event observer.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *syncRecorder) Synchronous() {}
+
+func TestSubWorkflow(t *testing.T) {
+	source := mapSource{
+		"double": `{
+			"inputs": [{"name": "n", "type": "number"}],
+			"nodes": [
+				{"id": "in", "data": {"value": 0}},
+				{"id": "twice", "type": "expression", "data": {"expression": "context.n * 2"}}
+			],
+			"edges": [{"source": "in", "target": "twice"}]
+		}`,
+		"quad": `{
+			"inputs": [{"name": "n", "type": "number"}],
+			"nodes": [
+				{"id": "in", "data": {"value": 0}},
+				{"id": "n", "type": "expression", "data": {"expression": "context.n"}},
+				{"id": "call", "type": "sub_workflow", "data": {"workflow_id": "double", "input_name": "n"}},
+				{"id": "again", "type": "sub_workflow", "data": {"workflow_id": "double", "input_name": "n"}}
+			],
+			"edges": [
+				{"source": "in", "target": "n"},
+				{"source": "n", "target": "call"},
+				{"source": "call", "target": "again"}
+			]
+		}`,
+		"ping": `{"nodes": [{"id": "call", "type": "sub_workflow", "data": {"workflow_id": "pong"}}]}`,
+		"pong": `{"nodes": [{"id": "call", "type": "sub_workflow", "data": {"workflow_id": "ping"}}]}`,
+	}
+
+	tests := []struct {
+		name     string
+		payload  string
+		config   func(*types.Config)
+		noSource bool
+		want     interface{}
+		wantErr  error
+		errText  string
+	}{
+		{
+			name: "input_name binds the upstream value",
+			payload: `{"nodes": [
+				{"id": "n", "data": {"value": 21}},
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "double", "input_name": "n"}}
+			], "edges": [{"source": "n", "target": "sub"}]}`,
+			want: 42.0,
+		},
+		{
+			name: "object inputs override static inputs",
+			payload: `{"nodes": [
+				{"id": "text", "type": "text_input", "data": {"text": "{\"n\": 5}"}},
+				{"id": "obj", "type": "parse", "data": {"input_type": "JSON"}},
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "double", "inputs": {"n": 1}}}
+			], "edges": [{"source": "text", "target": "obj"}, {"source": "obj", "target": "sub"}]}`,
+			want: 10.0,
+		},
+		{
+			name: "nested sub-workflows",
+			payload: `{"nodes": [
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "quad", "inputs": {"n": 3}}}
+			]}`,
+			want: 12.0,
+		},
+		{
+			name: "invalid child inputs",
+			payload: `{"nodes": [
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "double", "inputs": {"n": "x"}}}
+			]}`,
+			errText: "sub-workflow double",
+		},
+		{
+			name: "cycle",
+			payload: `{"nodes": [
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "ping"}}
+			]}`,
+			wantErr: ErrSubWorkflowCycle,
+			errText: "ping -> pong -> ping",
+		},
+		{
+			name: "depth limit",
+			payload: `{"nodes": [
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "quad", "inputs": {"n": 1}}}
+			]}`,
+			config:  func(c *types.Config) { c.MaxSubWorkflowDepth = 1 },
+			wantErr: ErrSubWorkflowDepthExceeded,
+		},
+		{
+			name: "shared node execution limit",
+			payload: `{"nodes": [
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "quad", "inputs": {"n": 1}}}
+			]}`,
+			// 1 here, 5 in quad and 3 in each double, counting their
+			// __inputs nodes: 12
+			config:  func(c *types.Config) { c.MaxNodeExecutions = 11 },
+			errText: "maximum node executions exceeded",
+		},
+		{
+			name: "unknown workflow",
+			payload: `{"nodes": [
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "missing"}}
+			]}`,
+			wantErr: ErrSubWorkflowUnavailable,
+		},
+		{
+			name: "no workflow source",
+			payload: `{"nodes": [
+				{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "double"}}
+			]}`,
+			noSource: true,
+			wantErr:  ErrSubWorkflowUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := types.DefaultConfig()
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			eng, err := NewWithConfig([]byte(tt.payload), config)
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+			if !tt.noSource {
+				eng.SetWorkflowSource(source)
+			}
+
+			result, err := eng.Execute()
+			if tt.wantErr != nil || tt.errText != "" {
+				if err == nil {
+					t.Fatalf("Expected an error, got output %v", result.FinalOutput)
+				}
+				// Node errors reach the caller as text
+				if tt.wantErr != nil && !strings.Contains(err.Error(), tt.wantErr.Error()) {
+					t.Errorf("Error = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("Error = %v, want it to mention %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result.FinalOutput != tt.want {
+				t.Errorf("FinalOutput = %#v, want %#v", result.FinalOutput, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubWorkflowEvents(t *testing.T) {
+	source := mapSource{
+		"double": `{
+			"inputs": [{"name": "n", "type": "number"}],
+			"nodes": [
+				{"id": "in", "data": {"value": 0}},
+				{"id": "twice", "type": "expression", "data": {"expression": "context.n * 2"}}
+			],
+			"edges": [{"source": "in", "target": "twice"}]
+		}`,
+	}
+	eng, err := New([]byte(`{"nodes": [
+		{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "double", "inputs": {"n": 2}}}
+	]}`))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	recorder := &syncRecorder{}
+	eng.SetWorkflowSource(source).SetWorkflowID("outer").RegisterObserver(recorder)
+
+	if _, err := eng.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var starts []string
+	for _, event := range recorder.events {
+		if event.ExecutionID != eng.ExecutionID() || event.WorkflowID != "outer" {
+			t.Errorf("Event %s %s has execution %q and workflow %q, want the parent's",
+				event.Type, event.NodeID, event.ExecutionID, event.WorkflowID)
+		}
+		switch event.Type {
+		case observer.EventWorkflowStart:
+			starts = append(starts, "workflow")
+		case observer.EventNodeStart:
+			starts = append(starts, event.NodeID)
+			if strings.HasPrefix(event.NodeID, "sub/") && event.Metadata["sub_workflow_id"] != "double" {
+				t.Errorf("Event for %s has metadata %v", event.NodeID, event.Metadata)
+			}
+		}
+	}
+	want := "workflow sub sub/__inputs sub/in sub/twice"
+	if got := strings.Join(starts, " "); got != want {
+		t.Errorf("Starts = %q, want %q", got, want)
+	}
+}
+
+func TestSubWorkflowSharesDeadline(t *testing.T) {
+	source := mapSource{
+		"slow": `{"nodes": [{"id": "wait", "type": "delay", "data": {"duration": "5s"}}]}`,
+	}
+	eng, err := New([]byte(`{"nodes": [
+		{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "slow"}}
+	]}`))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	eng.SetWorkflowSource(source)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := eng.ExecuteContext(ctx); err == nil {
+		t.Fatal("Expected the parent's deadline to stop the child")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Child ran for %v after the parent's deadline", elapsed)
+	}
+}
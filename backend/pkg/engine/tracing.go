@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// tracerName is the instrumentation scope of the engine's spans
+const tracerName = "github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+
+// SetTracerProvider sets the provider of the engine's workflow and node
+// spans. Without one, the global OpenTelemetry provider is used.
+func (e *Engine) SetTracerProvider(provider trace.TracerProvider) *Engine {
+	e.tracer = provider.Tracer(tracerName)
+	return e
+}
+
+// Context returns the context of the node being executed. It carries the
+// node's span and is cancelled with the execution, so executors pass it to
+// outgoing calls.
+func (e *Engine) Context() context.Context {
+	e.nodeCtxMu.RLock()
+	defer e.nodeCtxMu.RUnlock()
+	if e.nodeCtx == nil {
+		return context.Background()
+	}
+	return e.nodeCtx
+}
+
+// setContext records the context of the node being executed
+func (e *Engine) setContext(ctx context.Context) {
+	e.nodeCtxMu.Lock()
+	defer e.nodeCtxMu.Unlock()
+	e.nodeCtx = ctx
+}
+
+// getTracer returns the tracer for the engine's spans
+func (e *Engine) getTracer() trace.Tracer {
+	if e.tracer == nil {
+		return otel.Tracer(tracerName)
+	}
+	return e.tracer
+}
+
+// startWorkflowSpan starts the span covering one execution of the workflow
+func (e *Engine) startWorkflowSpan(ctx context.Context) (context.Context, trace.Span) {
+	return e.getTracer().Start(ctx, "workflow.execute", trace.WithAttributes(
+		attribute.String("workflow.id", e.workflowID),
+		attribute.String("execution.id", e.executionID),
+		attribute.String("tenant.id", e.tenantID),
+	))
+}
+
+// startNodeSpan starts the span covering one execution of node. The sizes
+// of its inputs are recorded only when the span is sampled, since they
+// are measured by encoding the inputs.
+func (e *Engine) startNodeSpan(ctx context.Context, node types.Node) (context.Context, trace.Span) {
+	ctx, span := e.getTracer().Start(ctx, "node.execute", trace.WithAttributes(
+		attribute.String("node.id", e.eventNodeID(node.ID)),
+		attribute.String("node.type", string(node.Type)),
+		attribute.String("execution.id", e.executionID),
+	))
+	if span.IsRecording() {
+		inputs := e.GetNodeInputs(node.ID)
+		span.SetAttributes(
+			attribute.Int("node.input.count", len(inputs)),
+			attribute.Int("node.input.bytes", encodedSize(inputs)),
+		)
+	}
+	return ctx, span
+}
+
+// endNodeSpan ends a node's span, recording err as its status, or the size
+// of result as the node's output size
+func endNodeSpan(span trace.Span, result interface{}, err error) {
+	if err == nil && span.IsRecording() {
+		span.SetAttributes(attribute.Int("node.output.bytes", encodedSize(result)))
+	}
+	endSpan(span, err)
+}
+
+// endSpan ends span, recording err as its status when it is not nil
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// encodedSize returns the length of v's JSON encoding, or -1 when v cannot
+// be encoded
+func encodedSize(v interface{}) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return -1
+	}
+	return len(data)
+}
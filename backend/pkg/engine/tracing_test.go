@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// spanAttr returns the value of span's attribute key
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	payload := fmt.Sprintf(`{"nodes": [
+		{"id": "a", "data": {"value": 2}},
+		{"id": "b", "data": {"value": 0}},
+		{"id": "call", "type": "http", "data": {"url": %q}},
+		{"id": "div", "data": {"op": "divide"}}
+	], "edges": [
+		{"source": "a", "target": "call"},
+		{"source": "a", "target": "div"},
+		{"source": "b", "target": "div"}
+	]}`, server.URL)
+
+	config := types.DefaultConfig()
+	config.AllowHTTP = true
+	config.AllowLocalhost = true
+	eng, err := NewWithConfig([]byte(payload), config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	eng.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	// The execution joins the caller's trace
+	callerCtx, caller := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "caller")
+	if _, err := eng.ExecuteContext(callerCtx); err == nil {
+		t.Fatal("Expected division by zero to fail the workflow")
+	}
+	caller.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	var workflow sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "workflow.execute" {
+			workflow = span
+			continue
+		}
+		spans[spanAttr(span, "node.id").AsString()] = span
+	}
+	if workflow == nil {
+		t.Fatal("No workflow span")
+	}
+	if workflow.Parent().SpanID() != caller.SpanContext().SpanID() {
+		t.Error("Workflow span is not a child of the caller's span")
+	}
+	if workflow.Status().Code != codes.Error {
+		t.Errorf("Workflow span status = %v, want Error", workflow.Status())
+	}
+	if got := spanAttr(workflow, "execution.id").AsString(); got != eng.ExecutionID() {
+		t.Errorf("execution.id = %q, want %q", got, eng.ExecutionID())
+	}
+
+	tests := []struct {
+		node       string
+		nodeType   string
+		inputs     int64
+		wantOutput bool
+		wantError  bool
+	}{
+		{node: "a", nodeType: "number", inputs: 0, wantOutput: true},
+		{node: "call", nodeType: "http", inputs: 1, wantOutput: true},
+		{node: "div", nodeType: "operation", inputs: 2, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.node, func(t *testing.T) {
+			span, ok := spans[tt.node]
+			if !ok {
+				t.Fatalf("No span for node %s", tt.node)
+			}
+			if span.Parent().SpanID() != workflow.SpanContext().SpanID() {
+				t.Error("Node span is not a child of the workflow span")
+			}
+			if got := spanAttr(span, "node.type").AsString(); got != tt.nodeType {
+				t.Errorf("node.type = %q, want %q", got, tt.nodeType)
+			}
+			if got := spanAttr(span, "node.input.count").AsInt64(); got != tt.inputs {
+				t.Errorf("node.input.count = %d, want %d", got, tt.inputs)
+			}
+			if got := spanAttr(span, "node.input.bytes").AsInt64(); got <= 0 {
+				t.Errorf("node.input.bytes = %d, want a size", got)
+			}
+			if got := spanAttr(span, "node.output.bytes").AsInt64(); (got > 0) != tt.wantOutput {
+				t.Errorf("node.output.bytes = %d, want output %v", got, tt.wantOutput)
+			}
+			if (span.Status().Code == codes.Error) != tt.wantError || (len(span.Events()) > 0) != tt.wantError {
+				t.Errorf("Status = %v with %d events, want error %v", span.Status(), len(span.Events()), tt.wantError)
+			}
+		})
+	}
+
+	// The HTTP node passes its own span on
+	want := fmt.Sprintf("00-%s-%s-01", workflow.SpanContext().TraceID(), spans["call"].SpanContext().SpanID())
+	if traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+}
+
+func TestTracing_SubWorkflow(t *testing.T) {
+	source := mapSource{
+		"double": `{
+			"inputs": [{"name": "n", "type": "number"}],
+			"nodes": [
+				{"id": "in", "data": {"value": 0}},
+				{"id": "twice", "type": "expression", "data": {"expression": "context.n * 2"}}
+			],
+			"edges": [{"source": "in", "target": "twice"}]
+		}`,
+	}
+	eng, err := New([]byte(`{"nodes": [
+		{"id": "sub", "type": "sub_workflow", "data": {"workflow_id": "double", "inputs": {"n": 2}}}
+	]}`))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	eng.SetWorkflowSource(source).
+		SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	if _, err := eng.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	parents := map[trace.SpanID]string{}
+	names := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		name := span.Name()
+		if id := spanAttr(span, "node.id").AsString(); id != "" {
+			name = id
+		} else if span.Parent().IsValid() {
+			name = "child workflow"
+		}
+		names[name] = span
+		parents[span.SpanContext().SpanID()] = name
+	}
+
+	for child, parent := range map[string]string{
+		"sub":            "workflow.execute",
+		"child workflow": "sub",
+		"sub/twice":      "child workflow",
+	} {
+		span, ok := names[child]
+		if !ok {
+			t.Errorf("No span for %s", child)
+			continue
+		}
+		if got := parents[span.Parent().SpanID()]; got != parent {
+			t.Errorf("Parent of %s = %q, want %q", child, got, parent)
+		}
+	}
+}
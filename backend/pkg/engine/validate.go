@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate checks the workflow without running it: it has nodes, node IDs
+// are present and unique, edges connect existing nodes, the graph has no
+// cycles and every node has a registered executor that accepts its
+// configuration. Every problem found is returned, joined into one error.
+func (e *Engine) Validate() error {
+	var errs []error
+	if len(e.nodes) == 0 {
+		errs = append(errs, ErrNoNodes)
+	}
+	if limit := e.config.MaxEdges; limit > 0 && len(e.edges) > limit {
+		errs = append(errs, fmt.Errorf("%w: workflow has %d edges, limit is %d", ErrMaxEdgesExceeded, len(e.edges), limit))
+	}
+
+	ids := make(map[string]bool, len(e.nodes))
+	for _, node := range e.nodes {
+		if node.ID == "" {
+			errs = append(errs, fmt.Errorf("%w: node of type %q", ErrMissingNodeID, node.Type))
+			continue
+		}
+		if ids[node.ID] {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrDuplicateNodeID, node.ID))
+			continue
+		}
+		ids[node.ID] = true
+		if err := e.registry.Validate(node); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", node.ID, err))
+		}
+	}
+
+	edgesValid := true
+	for _, edge := range e.edges {
+		if !ids[edge.Source] || !ids[edge.Target] {
+			errs = append(errs, fmt.Errorf("%w: %s -> %s", ErrInvalidEdge, edge.Source, edge.Target))
+			edgesValid = false
+		}
+	}
+	// Dangling edges would be reported again as a failed sort
+	if edgesValid {
+		if _, err := e.graph.TopologicalSort(); err != nil {
+			errs = append(errs, ErrCycleDetected)
+		}
+	}
+
+	return errors.Join(errs...)
+}
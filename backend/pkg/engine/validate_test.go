@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []error
+	}{
+		{
+			name: "valid",
+			payload: `{"nodes": [
+				{"id": "1", "data": {"value": 1}},
+				{"id": "2", "data": {"value": 2}},
+				{"id": "3", "data": {"op": "add"}}
+			], "edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]}`,
+		},
+		{
+			name:    "no nodes",
+			payload: `{"nodes": [], "edges": []}`,
+			want:    []error{ErrNoNodes},
+		},
+		{
+			name: "duplicate and missing IDs",
+			payload: `{"nodes": [
+				{"id": "1", "data": {"value": 1}},
+				{"id": "1", "data": {"value": 2}},
+				{"data": {"value": 3}}
+			]}`,
+			want: []error{ErrDuplicateNodeID, ErrMissingNodeID},
+		},
+		{
+			name: "dangling edge",
+			payload: `{"nodes": [{"id": "1", "data": {"value": 1}}],
+				"edges": [{"source": "1", "target": "2"}]}`,
+			want: []error{ErrInvalidEdge},
+		},
+		{
+			name: "cycle",
+			payload: `{"nodes": [
+				{"id": "1", "type": "expression", "data": {"expression": "input"}},
+				{"id": "2", "type": "expression", "data": {"expression": "input"}}
+			], "edges": [{"source": "1", "target": "2"}, {"source": "2", "target": "1"}]}`,
+			want: []error{ErrCycleDetected},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng, err := New([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+			err = eng.Validate()
+			if len(tt.want) == 0 && err != nil {
+				t.Fatalf("Validate() = %v, want nil", err)
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("Validate() = %v, want it to include %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_UnknownNodeType(t *testing.T) {
+	eng, err := New([]byte(`{"nodes": [{"id": "x", "type": "no_such_type", "data": {}}]}`))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if err := eng.Validate(); err == nil || !strings.Contains(err.Error(), "node x:") {
+		t.Errorf("Validate() = %v, want an error for node x", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package events fans workflow execution events out to live subscribers.
+//
+// # Overview
+//
+// A Stream is an observer.SyncObserver registered on one engine. Every
+// event the engine emits is numbered, appended to a bounded replay buffer
+// and offered to each subscriber:
+//
+//	stream := events.NewStream(events.DefaultBufferSize)
+//	eng.RegisterObserver(stream)
+//
+//	sub := stream.Subscribe(0, 64)
+//	defer sub.Close()
+//	for _, msg := range sub.Replay {
+//	    send(msg)
+//	}
+//	for msg := range sub.C {
+//	    send(msg)
+//	}
+//
+// # Replay
+//
+// Subscribe returns the buffered events after a given ID, then delivers
+// live events on a channel, with no gap or duplicate between the two.
+// A subscriber that arrives after the run has finished receives the
+// buffered events and a closed channel. When older events have already
+// been dropped from the buffer, Subscription.Missed says how many.
+//
+// # Back-pressure
+//
+// Publishing never blocks the engine. Each subscriber has a bounded
+// channel; a subscriber that falls so far behind that its channel is full
+// is disconnected and marked as lagged. It can then resubscribe from the
+// last ID it received to catch up from the replay buffer.
+package events
@@ -0,0 +1,222 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// Default sizes for replay buffers and subscriber channels
+const (
+	DefaultBufferSize     = 1000
+	DefaultSubscriberSize = 64
+)
+
+// Message is one numbered execution event in a form that can be encoded
+// as JSON. IDs start at 1 and increase by one per event.
+type Message struct {
+	ID          uint64                   `json:"id"`
+	Type        observer.EventType       `json:"type"`
+	Status      observer.ExecutionStatus `json:"status"`
+	Timestamp   time.Time                `json:"timestamp"`
+	ExecutionID string                   `json:"execution_id"`
+	WorkflowID  string                   `json:"workflow_id,omitempty"`
+	NodeID      string                   `json:"node_id,omitempty"`
+	NodeType    types.NodeType           `json:"node_type,omitempty"`
+	ElapsedMS   float64                  `json:"elapsed_ms,omitempty"`
+	Inputs      []interface{}            `json:"inputs,omitempty"`
+	Result      interface{}              `json:"result,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+}
+
+// Stream buffers the events of one execution and fans them out to
+// subscribers
+type Stream struct {
+	mu          sync.Mutex
+	capacity    int
+	buffer      []Message
+	lastID      uint64
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewStream creates a stream that keeps the last capacity events for
+// replay. A capacity below 1 uses DefaultBufferSize.
+func NewStream(capacity int) *Stream {
+	if capacity < 1 {
+		capacity = DefaultBufferSize
+	}
+	return &Stream{
+		capacity:    capacity,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Synchronous implements observer.SyncObserver so events are numbered in
+// the order the engine emits them
+func (s *Stream) Synchronous() {}
+
+// OnEvent implements observer.Observer
+func (s *Stream) OnEvent(ctx context.Context, event observer.Event) {
+	msg := Message{
+		Type:        event.Type,
+		Status:      event.Status,
+		Timestamp:   event.Timestamp,
+		ExecutionID: event.ExecutionID,
+		WorkflowID:  event.WorkflowID,
+		NodeID:      event.NodeID,
+		NodeType:    event.NodeType,
+		ElapsedMS:   float64(event.ElapsedTime) / float64(time.Millisecond),
+		Inputs:      event.Inputs,
+		Result:      event.Result,
+	}
+	if event.Error != nil {
+		msg.Error = event.Error.Error()
+	}
+	s.publish(msg)
+}
+
+// publish numbers msg, buffers it and offers it to every subscriber
+// without blocking
+func (s *Stream) publish(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.lastID++
+	msg.ID = s.lastID
+
+	s.buffer = append(s.buffer, msg)
+	if len(s.buffer) >= 2*s.capacity {
+		// Compact occasionally rather than shifting on every event
+		s.buffer = append([]Message(nil), s.buffer[len(s.buffer)-s.capacity:]...)
+	}
+
+	for sub := range s.subscribers {
+		select {
+		case sub.ch <- msg:
+		default:
+			sub.lagged = true
+			s.drop(sub)
+		}
+	}
+}
+
+// Close ends the stream. Subscribers' channels are closed once they have
+// received every event; later events are ignored. The buffer stays
+// available for replay.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for sub := range s.subscribers {
+		s.drop(sub)
+	}
+}
+
+// Closed reports whether the stream has ended
+func (s *Stream) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// LastID returns the ID of the most recent event, or 0 if there is none
+func (s *Stream) LastID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastID
+}
+
+// Subscribe returns the buffered events with an ID greater than after and
+// a channel of size buffered slots for the events that follow. Pass 0 to
+// replay from the start.
+func (s *Stream) Subscribe(after uint64, size int) *Subscription {
+	if size < 1 {
+		size = DefaultSubscriberSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan Message, size)
+	sub := &Subscription{C: ch, ch: ch, stream: s}
+
+	retained := s.retained()
+	if len(retained) > 0 && after+1 < retained[0].ID {
+		sub.Missed = retained[0].ID - after - 1
+	}
+	for _, msg := range retained {
+		if msg.ID > after {
+			sub.Replay = append(sub.Replay, msg)
+		}
+	}
+
+	if s.closed {
+		close(ch)
+	} else {
+		s.subscribers[sub] = struct{}{}
+	}
+	return sub
+}
+
+// retained returns the events still available for replay. Callers must
+// hold s.mu.
+func (s *Stream) retained() []Message {
+	if len(s.buffer) > s.capacity {
+		return s.buffer[len(s.buffer)-s.capacity:]
+	}
+	return s.buffer
+}
+
+// drop removes a subscriber and closes its channel. Callers must hold s.mu.
+func (s *Stream) drop(sub *Subscription) {
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+	delete(s.subscribers, sub)
+	close(sub.ch)
+}
+
+// Subscription is one subscriber's view of a stream
+type Subscription struct {
+	// Replay holds the buffered events that were published before the
+	// subscription, oldest first
+	Replay []Message
+
+	// Missed is the number of requested events no longer in the buffer
+	Missed uint64
+
+	// C delivers live events. It is closed when the stream ends, when the
+	// subscriber lags too far behind, or when Close is called.
+	C <-chan Message
+
+	ch     chan Message
+	stream *Stream
+	lagged bool
+}
+
+// Lagged reports whether the subscription was dropped because its channel
+// was full
+func (sub *Subscription) Lagged() bool {
+	sub.stream.mu.Lock()
+	defer sub.stream.mu.Unlock()
+	return sub.lagged
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (sub *Subscription) Close() {
+	sub.stream.mu.Lock()
+	defer sub.stream.mu.Unlock()
+	sub.stream.drop(sub)
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+)
+
+func emit(s *Stream, nodeIDs ...string) {
+	for _, id := range nodeIDs {
+		s.OnEvent(context.Background(), observer.Event{Type: observer.EventNodeSuccess, NodeID: id})
+	}
+}
+
+func ids(msgs []Message) []uint64 {
+	out := make([]uint64, len(msgs))
+	for i, msg := range msgs {
+		out[i] = msg.ID
+	}
+	return out
+}
+
+func TestStream_ReplayThenLive(t *testing.T) {
+	s := NewStream(10)
+	emit(s, "a", "b", "c")
+
+	sub := s.Subscribe(1, 4)
+	defer sub.Close()
+	if got := ids(sub.Replay); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("Replay IDs = %v, want [2 3]", got)
+	}
+	if sub.Missed != 0 {
+		t.Errorf("Missed = %d, want 0", sub.Missed)
+	}
+
+	emit(s, "d")
+	if msg := <-sub.C; msg.ID != 4 || msg.NodeID != "d" {
+		t.Errorf("Live message = %+v, want ID 4 for node d", msg)
+	}
+
+	s.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected channel to be closed when the stream ends")
+	}
+	if sub.Lagged() {
+		t.Error("Subscription closed by the stream should not be lagged")
+	}
+
+	// Events after Close are ignored; late subscribers still get the replay
+	emit(s, "e")
+	late := s.Subscribe(0, 4)
+	if got := ids(late.Replay); len(got) != 4 {
+		t.Errorf("Late replay IDs = %v, want 4 events", got)
+	}
+	if _, ok := <-late.C; ok {
+		t.Error("Expected a closed channel for a finished stream")
+	}
+}
+
+func TestStream_BufferBound(t *testing.T) {
+	s := NewStream(3)
+	emit(s, "a", "b", "c", "d", "e", "f", "g")
+
+	sub := s.Subscribe(0, 1)
+	defer sub.Close()
+	if got := ids(sub.Replay); len(got) != 3 || got[0] != 5 || got[2] != 7 {
+		t.Errorf("Replay IDs = %v, want [5 6 7]", got)
+	}
+	if sub.Missed != 4 {
+		t.Errorf("Missed = %d, want 4", sub.Missed)
+	}
+	if s.LastID() != 7 {
+		t.Errorf("LastID = %d, want 7", s.LastID())
+	}
+}
+
+func TestStream_SlowSubscriberIsDropped(t *testing.T) {
+	s := NewStream(10)
+	slow := s.Subscribe(0, 2)
+	fast := s.Subscribe(0, 10)
+	defer fast.Close()
+
+	// Publishing must not block even though nobody reads slow.C
+	emit(s, "a", "b", "c")
+
+	if !slow.Lagged() {
+		t.Fatal("Expected slow subscriber to be marked lagged")
+	}
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("Slow subscriber received %d events before being dropped, want 2", received)
+	}
+
+	// Resubscribing from the last received ID catches up from the buffer
+	resumed := s.Subscribe(2, 2)
+	defer resumed.Close()
+	if got := ids(resumed.Replay); len(got) != 1 || got[0] != 3 {
+		t.Errorf("Resumed replay IDs = %v, want [3]", got)
+	}
+
+	if fast.Lagged() || len(fast.C) != 3 {
+		t.Errorf("Fast subscriber lagged=%v buffered=%d, want false/3", fast.Lagged(), len(fast.C))
+	}
+}
+
+func TestStream_MessageFields(t *testing.T) {
+	s := NewStream(10)
+	s.OnEvent(context.Background(), observer.Event{
+		Type:        observer.EventNodeFailure,
+		Status:      observer.StatusFailure,
+		ExecutionID: "exec-1",
+		NodeID:      "n1",
+		Error:       errors.New("boom"),
+	})
+
+	sub := s.Subscribe(0, 1)
+	defer sub.Close()
+	msg := sub.Replay[0]
+	if msg.Error != "boom" || msg.ExecutionID != "exec-1" || msg.Status != observer.StatusFailure {
+		t.Errorf("Message = %+v", msg)
+	}
+
+	var _ observer.SyncObserver = s
+}
@@ -3,6 +3,7 @@ package executor
 import (
 	"fmt"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -40,7 +41,12 @@ func (e *AccumulatorExecutor) Execute(ctx ExecutionContext, node types.Node) (in
 	}
 
 	// Accumulate the input value
-	newAccum, err := accumulateValue(accumOp, currentAccum, inputs[0])
+	var newAccum interface{}
+	if decimalMode(ctx.GetConfig()) && isNumericAccumulator(accumOp) {
+		newAccum, err = accumulateDecimal(accumOp, currentAccum, inputs[0])
+	} else {
+		newAccum, err = accumulateValue(accumOp, currentAccum, inputs[0])
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -164,3 +170,30 @@ func accumulateCount(accum interface{}) (interface{}, error) {
 	}
 	return accumVal + 1, nil
 }
+
+// isNumericAccumulator reports whether an operation accumulates numbers
+func isNumericAccumulator(accumOp string) bool {
+	return accumOp == "sum" || accumOp == "product" || accumOp == "count"
+}
+
+// accumulateDecimal applies a numeric operation with exact decimals. The
+// accumulator may hold a float64 initial value or a json.Number from a
+// previous step; the result is always a json.Number.
+func accumulateDecimal(accumOp string, accum interface{}, input interface{}) (interface{}, error) {
+	accumVal, ok := toDecimal(accum)
+	if !ok {
+		return nil, fmt.Errorf("accumulator value is not a number")
+	}
+	if accumOp == "count" {
+		return accumVal.Add(decimal.NewFromInt(1)).Number(), nil
+	}
+
+	num, ok := toDecimal(input)
+	if !ok {
+		return nil, fmt.Errorf("%s accumulator requires numeric input, got %T", accumOp, input)
+	}
+	if accumOp == "product" {
+		return accumVal.Mul(num).Number(), nil
+	}
+	return accumVal.Add(num).Number(), nil
+}
@@ -31,14 +31,13 @@ func (e *ConditionExecutor) Execute(ctx ExecutionContext, node types.Node) (inte
 	input := inputs[0]
 
 	// Build expression context with access to node results and variables
-	exprCtx := &expression.Context{
-		NodeResults: ctx.GetAllNodeResults(),
-		Variables:   ctx.GetVariables(),
-		ContextVars: ctx.GetContextVariables(),
-	}
+	exprCtx := newExpressionContext(ctx, node.ID)
 
 	// Evaluate condition using expression engine
 	conditionMet, err := expression.Evaluate(*data.Condition, input, exprCtx)
+	if expression.IsLimitError(err) || expression.IsMissingReference(err) {
+		return nil, fmt.Errorf("condition evaluation failed: %w", err)
+	}
 	if err != nil {
 		// Fallback to simple evaluation for backward compatibility
 		conditionMet = evaluateCondition(*data.Condition, input)
@@ -1,8 +1,10 @@
 package executor
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/expression"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -302,6 +304,41 @@ func TestConditionExecutor_WithNodeReferences(t *testing.T) {
 	}
 }
 
+// TestConditionExecutor_StrictExpressions tests missing references with and without strict mode
+func TestConditionExecutor_StrictExpressions(t *testing.T) {
+	condition := `node.http1.user.address.city == "X"`
+	node := types.Node{
+		ID:   "test-node",
+		Type: types.NodeTypeCondition,
+		Data: types.ConditionData{Condition: &condition},
+	}
+	nodeResults := map[string]interface{}{
+		"http1": map[string]interface{}{"user": map[string]interface{}{"name": "Ada"}},
+	}
+
+	// Non-strict: the missing field is null and the condition is simply false
+	ctx := &MockExecutionContext{
+		inputs:      map[string][]interface{}{"test-node": {float64(1)}},
+		nodeResults: nodeResults,
+	}
+	result, err := (&ConditionExecutor{}).Execute(ctx, node)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.(map[string]interface{})["condition_met"].(bool) {
+		t.Error("Expected condition on a missing field to be false")
+	}
+
+	// Strict: the missing field fails the node
+	cfg := types.DefaultConfig()
+	cfg.StrictExpressions = true
+	ctx.config = &cfg
+	_, err = (&ConditionExecutor{}).Execute(ctx, node)
+	if !errors.Is(err, expression.ErrFieldNotFound) {
+		t.Errorf("Expected ErrFieldNotFound in strict mode, got %v", err)
+	}
+}
+
 // TestConditionExecutor_Validation tests node validation
 func TestConditionExecutor_Validation(t *testing.T) {
 	tests := []struct {
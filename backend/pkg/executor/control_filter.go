@@ -67,11 +67,7 @@ func (e *FilterExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 	}
 
 	// Build expression context with access to node results and variables
-	exprCtx := &expression.Context{
-		NodeResults: ctx.GetAllNodeResults(),
-		Variables:   ctx.GetVariables(),
-		ContextVars: ctx.GetContextVariables(),
-	}
+	exprCtx := newExpressionContext(ctx, node.ID)
 
 	// Filter array elements
 	filtered := make([]interface{}, 0, len(inputArray))
@@ -81,16 +77,7 @@ func (e *FilterExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 	for i, item := range inputArray {
 		// Create a temporary context with the current item
 		// Make the item available as 'item' variable for the expression
-		itemCtx := &expression.Context{
-			NodeResults: exprCtx.NodeResults,
-			Variables:   make(map[string]interface{}),
-			ContextVars: exprCtx.ContextVars,
-		}
-
-		// Copy existing variables
-		for k, v := range exprCtx.Variables {
-			itemCtx.Variables[k] = v
-		}
+		itemCtx := exprCtx.Clone()
 
 		// Add the current item as 'item' variable
 		itemCtx.Variables["item"] = item
@@ -100,6 +87,9 @@ func (e *FilterExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 
 		// Evaluate the condition for this item
 		conditionMet, err := expression.Evaluate(*data.Condition, item, itemCtx)
+		if expression.IsLimitError(err) || expression.IsMissingReference(err) {
+			return nil, fmt.Errorf("filter aborted at index %d: %w", i, err)
+		}
 		if err != nil {
 			// Log evaluation error but continue processing
 			slog.Debug("filter expression evaluation error",
@@ -1,6 +1,8 @@
 package executor
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -13,6 +15,8 @@ type MockExecutionContext struct {
 	variables   map[string]interface{}
 	nodeResults map[string]interface{}
 	contextVars map[string]interface{}
+	config      *types.Config
+	subWorkflow func(nodeID, workflowID string, version int, inputs map[string]interface{}) (interface{}, error)
 }
 
 func (m *MockExecutionContext) GetNodeInputs(nodeID string) []interface{} {
@@ -127,6 +131,9 @@ func (m *MockExecutionContext) GetContextVariables() map[string]interface{} {
 }
 
 func (m *MockExecutionContext) GetConfig() types.Config {
+	if m.config != nil {
+		return *m.config
+	}
 	return types.DefaultConfig()
 }
 
@@ -150,6 +157,21 @@ func (m *MockExecutionContext) GetHTTPCallCount() int {
 	return 0
 }
 
+func (m *MockExecutionContext) RecordHTTPCall(clientUID string, statusCode int, d time.Duration) {}
+
+func (m *MockExecutionContext) RecordIterations(n int) {}
+
+func (m *MockExecutionContext) Context() context.Context {
+	return context.Background()
+}
+
+func (m *MockExecutionContext) ExecuteSubWorkflow(nodeID, workflowID string, version int, inputs map[string]interface{}) (interface{}, error) {
+	if m.subWorkflow == nil {
+		return nil, errors.New("sub-workflows are not supported")
+	}
+	return m.subWorkflow(nodeID, workflowID, version, inputs)
+}
+
 // TestFilterExecutor_Basic tests basic array filtering functionality
 func TestFilterExecutor_Basic(t *testing.T) {
 	tests := []struct {
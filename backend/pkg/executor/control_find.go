@@ -54,23 +54,19 @@ func (e *FindExecutor) Execute(ctx ExecutionContext, node types.Node) (interface
 	}
 
 	// Search for first match
+	baseCtx := newExpressionContext(ctx, node.ID)
 	for i, item := range arr {
 		// Create context with item and index variables
-		itemCtx := &expression.Context{
-			Variables:   make(map[string]interface{}),
-			ContextVars: ctx.GetContextVariables(),
-			NodeResults: ctx.GetAllNodeResults(),
-		}
-		// Copy existing variables
-		for k, v := range ctx.GetVariables() {
-			itemCtx.Variables[k] = v
-		}
+		itemCtx := baseCtx.Clone()
 		itemCtx.Variables["item"] = item
 		itemCtx.Variables["index"] = i
 		itemCtx.Variables["items"] = arr
 
 		// Evaluate condition
 		result, err := expression.Evaluate(condition, item, itemCtx)
+		if expression.IsLimitError(err) || expression.IsMissingReference(err) {
+			return nil, fmt.Errorf("find aborted at index %d: %w", i, err)
+		}
 		if err != nil {
 			// Continue on error
 			continue
@@ -89,6 +89,8 @@ func (e *ForEachExecutor) Execute(ctx ExecutionContext, node types.Node) (interf
 		successful++
 	}
 
+	ctx.RecordIterations(successful + failed)
+
 	slog.Debug("for_each completed",
 		slog.String("node_id", node.ID),
 		slog.Int("successful", successful),
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -77,6 +78,7 @@ func (e *GroupByExecutor) Execute(ctx ExecutionContext, node types.Node) (interf
 
 	// Perform aggregation
 	aggregated := make(map[string]interface{})
+	cfg := ctx.GetConfig()
 
 	switch aggregate {
 	case "count":
@@ -85,6 +87,10 @@ func (e *GroupByExecutor) Execute(ctx ExecutionContext, node types.Node) (interf
 		}
 
 	case "sum":
+		if decimalMode(cfg) {
+			aggregateDecimal(groups, valueField, aggregated, nil)
+			break
+		}
 		for key, items := range groups {
 			sum := 0.0
 			for _, item := range items {
@@ -102,6 +108,11 @@ func (e *GroupByExecutor) Execute(ctx ExecutionContext, node types.Node) (interf
 		}
 
 	case "avg":
+		if decimalMode(cfg) {
+			dc := decimalContext(cfg)
+			aggregateDecimal(groups, valueField, aggregated, &dc)
+			break
+		}
 		for key, items := range groups {
 			sum := 0.0
 			count := 0
@@ -217,6 +228,28 @@ func (e *GroupByExecutor) Execute(ctx ExecutionContext, node types.Node) (interf
 	return result, nil
 }
 
+// aggregateDecimal sums each group's value field with exact decimals. With a
+// decimal context it stores the average instead of the sum. Results are
+// json.Number values.
+func aggregateDecimal(groups map[string][]interface{}, valueField string, aggregated map[string]interface{}, avg *decimal.Context) {
+	for key, items := range groups {
+		sum := decimal.Zero
+		count := 0
+		for _, item := range items {
+			if obj, ok := item.(map[string]interface{}); ok {
+				if num, ok := toDecimal(obj[valueField]); ok {
+					sum = sum.Add(num)
+					count++
+				}
+			}
+		}
+		if avg != nil && count > 0 {
+			sum, _ = avg.Div(sum, decimal.NewFromInt(int64(count)))
+		}
+		aggregated[key] = sum.Number()
+	}
+}
+
 // NodeType returns the node type this executor handles
 func (e *GroupByExecutor) NodeType() types.NodeType {
 	return types.NodeTypeGroupBy
@@ -76,6 +76,10 @@ func (e *MapExecutor) Execute(ctx ExecutionContext, node types.Node) (interface{
 	successful := 0
 	failed := 0
 
+	// One expression context per node execution, so the evaluation
+	// budget bounds the whole map rather than each item
+	baseCtx := newExpressionContext(ctx, node.ID)
+
 	for i, item := range inputArray {
 		var result interface{}
 		var err error
@@ -85,9 +89,12 @@ func (e *MapExecutor) Execute(ctx ExecutionContext, node types.Node) (interface{
 			result, err = e.extractField(item, *data.Field)
 		} else if hasExpression {
 			// Expression transformation mode
-			result, err = e.evaluateExpression(ctx, node, *data.Expression, item, i, inputArray)
+			result, err = e.evaluateExpression(baseCtx, *data.Expression, item, i, inputArray)
 		}
 
+		if expression.IsLimitError(err) || expression.IsMissingReference(err) {
+			return nil, fmt.Errorf("map aborted at index %d: %w", i, err)
+		}
 		if err != nil {
 			slog.Debug("map transformation error (continuing)",
 				slog.String("node_id", node.ID),
@@ -104,6 +111,8 @@ func (e *MapExecutor) Execute(ctx ExecutionContext, node types.Node) (interface{
 		successful++
 	}
 
+	ctx.RecordIterations(successful + failed)
+
 	slog.Debug("map node completed",
 		slog.String("node_id", node.ID),
 		slog.Int("successful", successful),
@@ -136,24 +145,14 @@ func (e *MapExecutor) extractField(item interface{}, field string) (interface{},
 
 // evaluateExpression evaluates a transformation expression for an item
 func (e *MapExecutor) evaluateExpression(
-	ctx ExecutionContext,
-	node types.Node,
+	baseCtx *expression.Context,
 	expressionStr string,
 	item interface{},
 	index int,
 	items []interface{},
 ) (interface{}, error) {
 	// Create expression context with item, index, and items
-	exprCtx := &expression.Context{
-		NodeResults: ctx.GetAllNodeResults(),
-		Variables:   make(map[string]interface{}),
-		ContextVars: ctx.GetContextVariables(),
-	}
-
-	// Copy existing variables
-	for k, v := range ctx.GetVariables() {
-		exprCtx.Variables[k] = v
-	}
+	exprCtx := baseCtx.Clone()
 
 	// Add iteration variables
 	exprCtx.Variables["item"] = item
@@ -54,6 +54,9 @@ func (e *RangeExecutor) Execute(ctx ExecutionContext, node types.Node) (interfac
 	// Generate range
 	var rangeArr []interface{}
 	maxItems := 10000 // Safety limit
+	if limit := ctx.GetConfig().MaxExpressionOutputSize; limit > 0 && limit < maxItems {
+		maxItems = limit
+	}
 
 	if step > 0 {
 		for i := start; i <= end && len(rangeArr) < maxItems; i += step {
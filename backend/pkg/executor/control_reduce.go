@@ -70,8 +70,15 @@ func (e *ReduceExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 	successful := 0
 	failed := 0
 
+	// One expression context per node execution, so the evaluation
+	// budget bounds the whole reduce rather than each item
+	baseCtx := newExpressionContext(ctx, node.ID)
+
 	for i, item := range inputArray {
-		result, err := e.evaluateExpression(ctx, node, *data.Expression, item, i, inputArray, accumulator)
+		result, err := e.evaluateExpression(baseCtx, *data.Expression, item, i, inputArray, accumulator)
+		if expression.IsLimitError(err) || expression.IsMissingReference(err) {
+			return nil, fmt.Errorf("reduce aborted at index %d: %w", i, err)
+		}
 		if err != nil {
 			slog.Debug("reduce expression evaluation error (continuing)",
 				slog.String("node_id", node.ID),
@@ -87,6 +94,8 @@ func (e *ReduceExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 		successful++
 	}
 
+	ctx.RecordIterations(successful + failed)
+
 	slog.Debug("reduce node completed",
 		slog.String("node_id", node.ID),
 		slog.Int("successful", successful),
@@ -107,8 +116,7 @@ func (e *ReduceExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 
 // evaluateExpression evaluates the reduce expression for an item and accumulator
 func (e *ReduceExecutor) evaluateExpression(
-	ctx ExecutionContext,
-	node types.Node,
+	baseCtx *expression.Context,
 	expressionStr string,
 	item interface{},
 	index int,
@@ -116,16 +124,7 @@ func (e *ReduceExecutor) evaluateExpression(
 	accumulator interface{},
 ) (interface{}, error) {
 	// Create expression context with accumulator, item, index, and items
-	exprCtx := &expression.Context{
-		NodeResults: ctx.GetAllNodeResults(),
-		Variables:   make(map[string]interface{}),
-		ContextVars: ctx.GetContextVariables(),
-	}
-
-	// Copy existing variables
-	for k, v := range ctx.GetVariables() {
-		exprCtx.Variables[k] = v
-	}
+	exprCtx := baseCtx.Clone()
 
 	// Add iteration and accumulator variables
 	exprCtx.Variables["accumulator"] = accumulator
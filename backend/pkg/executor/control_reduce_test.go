@@ -3,6 +3,7 @@ package executor
 import (
 	"testing"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/expression"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -266,3 +267,37 @@ func TestReduceExecutor_EmptyArray(t *testing.T) {
 		t.Errorf("Expected final_value to be 100 (initial_value), got %v", finalValue)
 	}
 }
+
+func TestReduceExecutor_AbortsOnExpressionBudget(t *testing.T) {
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = float64(i)
+	}
+
+	cfg := types.DefaultConfig()
+	cfg.MaxExpressionSteps = 50
+
+	executor := &ReduceExecutor{}
+	ctx := &MockExecutionContext{
+		inputs: map[string][]interface{}{"reduce1": {items}},
+		config: &cfg,
+	}
+
+	expr := "accumulator + item"
+	node := types.Node{
+		ID:   "reduce1",
+		Type: types.NodeTypeReduce,
+		Data: types.ReduceData{
+			InitialValue: float64(0),
+			Expression:   &expr,
+		},
+	}
+
+	_, err := executor.Execute(ctx, node)
+	if err == nil {
+		t.Fatal("expected reduce to abort when the expression budget is exhausted")
+	}
+	if !expression.IsLimitError(err) {
+		t.Errorf("expected a limit error, got %v", err)
+	}
+}
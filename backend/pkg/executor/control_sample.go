@@ -3,8 +3,6 @@ package executor
 import (
 	"fmt"
 	"log/slog"
-	"math/rand"
-	"time"
 
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
@@ -89,8 +87,8 @@ func (e *SampleExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 				indices[i] = i
 			}
 
-			// Initialize random seed
-			rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+			// Seeded from Config.RandomSeed when set, for reproducible runs
+			rng := newRand(ctx.GetConfig(), node.ID)
 
 			// Partial shuffle - only shuffle first 'count' elements
 			for i := 0; i < count; i++ {
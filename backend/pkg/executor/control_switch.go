@@ -27,11 +27,7 @@ func (e *SwitchExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 	inputValue := inputs[0]
 
 	// Build expression context with access to node results and variables
-	exprCtx := &expression.Context{
-		NodeResults: ctx.GetAllNodeResults(),
-		Variables:   ctx.GetVariables(),
-		ContextVars: ctx.GetContextVariables(),
-	}
+	exprCtx := newExpressionContext(ctx, node.ID)
 
 	// Check each case in order (last case is default)
 	for i, switchCase := range data.Cases {
@@ -52,6 +48,9 @@ func (e *SwitchExecutor) Execute(ctx ExecutionContext, node types.Node) (interfa
 
 		// Evaluate the expression
 		matched, err := expression.Evaluate(switchCase.When, inputValue, exprCtx)
+		if expression.IsLimitError(err) || expression.IsMissingReference(err) {
+			return nil, fmt.Errorf("switch case %d: %w", i, err)
+		}
 		if err != nil {
 			// If expression evaluation fails, skip this case and continue
 			// This allows graceful degradation for malformed expressions
@@ -43,6 +43,8 @@ func (e *WhileLoopExecutor) Execute(ctx ExecutionContext, node types.Node) (inte
 		// For now, we just count iterations without modifying the value
 	}
 
+	ctx.RecordIterations(iterationCount)
+
 	if iterationCount >= maxIter {
 		return nil, fmt.Errorf("while_loop exceeded max iterations: %d", maxIter)
 	}
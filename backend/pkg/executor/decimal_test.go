@@ -0,0 +1,164 @@
+package executor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/config"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func decimalConfig(scale int, rounding string) *types.Config {
+	cfg := types.DefaultConfig()
+	cfg.NumericMode = config.NumericModeDecimal
+	cfg.DecimalScale = scale
+	cfg.DecimalRounding = rounding
+	return &cfg
+}
+
+func TestOperationExecutor_DecimalMode(t *testing.T) {
+	tests := []struct {
+		op    string
+		left  interface{}
+		right interface{}
+		want  json.Number
+	}{
+		{"add", 0.1, 0.2, "0.3"},
+		{"subtract", json.Number("1.10"), 0.2, "0.90"},
+		{"multiply", 19.99, 3.0, "59.97"},
+		{"divide", 2.0, 3.0, "0.67"},
+		{"divide", 10.0, 4.0, "2.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op, func(t *testing.T) {
+			op := tt.op
+			ctx := &MockExecutionContext{
+				inputs: map[string][]interface{}{"op1": {tt.left, tt.right}},
+				config: decimalConfig(2, "half_up"),
+			}
+			node := types.Node{ID: "op1", Type: types.NodeTypeOperation, Data: types.OperationData{Op: &op}}
+
+			result, err := (&OperationExecutor{}).Execute(ctx, node)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("%v %s %v = %#v, want %#v", tt.left, tt.op, tt.right, result, tt.want)
+			}
+		})
+	}
+
+	op := "add"
+	ctx := &MockExecutionContext{
+		inputs: map[string][]interface{}{"op1": {"1", 2.0}},
+		config: decimalConfig(2, "half_up"),
+	}
+	node := types.Node{ID: "op1", Type: types.NodeTypeOperation, Data: types.OperationData{Op: &op}}
+	if _, err := (&OperationExecutor{}).Execute(ctx, node); err == nil {
+		t.Error("expected string inputs to be rejected in decimal mode")
+	}
+}
+
+func TestGroupByExecutor_DecimalMode(t *testing.T) {
+	items := []interface{}{
+		map[string]interface{}{"category": "A", "amount": 0.1},
+		map[string]interface{}{"category": "A", "amount": 0.2},
+		map[string]interface{}{"category": "B", "amount": json.Number("10")},
+		map[string]interface{}{"category": "B", "amount": 0.01},
+		map[string]interface{}{"category": "B", "amount": 0.01},
+	}
+
+	tests := []struct {
+		aggregate string
+		key       string
+		want      map[string]interface{}
+	}{
+		{"sum", "sums", map[string]interface{}{"A": json.Number("0.3"), "B": json.Number("10.02")}},
+		{"avg", "averages", map[string]interface{}{"A": json.Number("0.15"), "B": json.Number("3.34")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.aggregate, func(t *testing.T) {
+			field, valueField, aggregate := "category", "amount", tt.aggregate
+			ctx := &MockExecutionContext{
+				inputs: map[string][]interface{}{"group1": {items}},
+				config: decimalConfig(2, "half_even"),
+			}
+			node := types.Node{
+				ID:   "group1",
+				Type: types.NodeTypeGroupBy,
+				Data: types.GroupByData{Field: &field, Aggregate: &aggregate, ValueField: &valueField},
+			}
+
+			result, err := (&GroupByExecutor{}).Execute(ctx, node)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := result.(map[string]interface{})[tt.key].(map[string]interface{})
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("group %s = %#v, want %#v", k, got[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAccumulatorExecutor_DecimalMode(t *testing.T) {
+	tests := []struct {
+		op      string
+		initial interface{}
+		input   interface{}
+		want    json.Number
+	}{
+		{"sum", 0.1, 0.2, "0.3"},
+		{"product", 1.1, 1.1, "1.21"},
+		{"count", json.Number("2"), "ignored", "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op, func(t *testing.T) {
+			op := tt.op
+			ctx := &MockExecutionContext{
+				inputs: map[string][]interface{}{"acc1": {tt.input}},
+				config: decimalConfig(16, "half_even"),
+			}
+			node := types.Node{
+				ID:   "acc1",
+				Type: types.NodeTypeAccumulator,
+				Data: types.AccumulatorData{AccumOp: &op, InitialValue: tt.initial},
+			}
+
+			result, err := (&AccumulatorExecutor{}).Execute(ctx, node)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.(map[string]interface{})["value"]; got != tt.want {
+				t.Errorf("%s accumulator = %#v, want %#v", tt.op, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReduceExecutor_DecimalMode(t *testing.T) {
+	items := []interface{}{0.1, 0.2, 0.3}
+	expr := "accumulator + item"
+	ctx := &MockExecutionContext{
+		inputs: map[string][]interface{}{"reduce1": {items}},
+		config: decimalConfig(16, "half_even"),
+	}
+	node := types.Node{
+		ID:   "reduce1",
+		Type: types.NodeTypeReduce,
+		Data: types.ReduceData{InitialValue: float64(0), Expression: &expr},
+	}
+
+	result, err := (&ReduceExecutor{}).Execute(ctx, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := result.(map[string]interface{})["result"]; got != json.Number("0.6") {
+		t.Errorf("reduce result = %#v, want json.Number(\"0.6\")", got)
+	}
+}
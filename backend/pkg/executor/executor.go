@@ -3,6 +3,7 @@
 package executor
 
 import (
+	"context"
 	"time"
 
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
@@ -55,6 +56,22 @@ type ExecutionContext interface {
 	IncrementHTTPCall() error
 	GetNodeExecutionCount() int
 	GetHTTPCallCount() int
+
+	// Profiling and metrics - executors report each HTTP call, with the
+	// named client used ("" for the default client), the response status
+	// (0 when no response arrived) and the time spent waiting for it, and
+	// how many iterations loop nodes ran
+	RecordHTTPCall(clientUID string, statusCode int, d time.Duration)
+	RecordIterations(n int)
+
+	// Context of the node being executed - carries its trace span and is
+	// cancelled with the execution; pass it to outgoing calls
+	Context() context.Context
+
+	// Workflow composition - runs version (0 = latest) of a saved workflow
+	// for the sub_workflow node nodeID, with inputs bound to its declared
+	// inputs, and returns its final output
+	ExecuteSubWorkflow(nodeID, workflowID string, version int, inputs map[string]interface{}) (interface{}, error)
 }
 
 // NodeExecutor defines the interface for node execution strategies.
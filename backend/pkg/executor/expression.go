@@ -66,22 +66,16 @@ func (e *ExpressionExecutor) Execute(ctx ExecutionContext, node types.Node) (int
 	)
 
 	// Create expression context with input
-	exprCtx := &expression.Context{
-		NodeResults: ctx.GetAllNodeResults(),
-		Variables:   make(map[string]interface{}),
-		ContextVars: ctx.GetContextVariables(),
-	}
-
-	// Copy existing variables
-	for k, v := range ctx.GetVariables() {
-		exprCtx.Variables[k] = v
-	}
+	exprCtx := newExpressionContext(ctx, node.ID)
 
 	// Add 'input' to the expression context
 	exprCtx.Variables["input"] = input
 
 	// Try to evaluate as a value expression first (arithmetic, field access, etc.)
 	result, err := expression.EvaluateExpression(expr, input, exprCtx)
+	if expression.IsLimitError(err) || expression.IsMissingReference(err) {
+		return nil, fmt.Errorf("expression evaluation failed: %w", err)
+	}
 	if err != nil {
 		// If value expression fails, try as a boolean expression (comparisons)
 		// This handles expressions like "input > 2", "input == 5", etc.
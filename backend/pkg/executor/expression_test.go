@@ -86,6 +86,24 @@ func TestExpressionExecutor_Execute(t *testing.T) {
 			want:    true,
 			wantErr: false,
 		},
+		{
+			name:       "optional chaining comparison",
+			expression: "input.user?.age > 18",
+			input: map[string]interface{}{
+				"user": map[string]interface{}{"age": 25.0},
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:       "null coalescing in arithmetic",
+			expression: "(input.user.age ?? 0) + 1",
+			input: map[string]interface{}{
+				"user": map[string]interface{}{},
+			},
+			want:    1.0,
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
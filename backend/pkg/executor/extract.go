@@ -2,7 +2,9 @@ package executor
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/query"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -10,7 +12,8 @@ import (
 type ExtractExecutor struct{}
 
 // Execute runs the Extract node
-// Extracts specific fields from object inputs.
+// Extracts specific fields from object inputs, or runs JSONPath / JMESPath
+// queries against any input in query mode.
 func (e *ExtractExecutor) Execute(ctx ExecutionContext, node types.Node) (interface{}, error) {
 	data, err := types.AsExtractData(node.Data)
 	if err != nil {
@@ -22,6 +25,12 @@ func (e *ExtractExecutor) Execute(ctx ExecutionContext, node types.Node) (interf
 	}
 
 	input := inputs[0]
+
+	// Query mode works on any JSON-like input, not just objects
+	if data.Query != nil || len(data.Queries) > 0 {
+		return e.executeQueries(ctx, data, input)
+	}
+
 	inputMap, ok := input.(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("extract node requires object input, got %T", input)
@@ -55,6 +64,41 @@ func (e *ExtractExecutor) Execute(ctx ExecutionContext, node types.Node) (interf
 	return nil, fmt.Errorf("extract node requires 'field' or 'fields' configuration")
 }
 
+// executeQueries evaluates the configured queries with the engine's depth and
+// array-length limits applied
+func (e *ExtractExecutor) executeQueries(ctx ExecutionContext, data *types.ExtractData, input interface{}) (interface{}, error) {
+	limits := queryLimits(ctx.GetConfig())
+
+	// Single query
+	if data.Query != nil {
+		value, err := query.Evaluate(*data.Query, input, limits)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"query": *data.Query,
+			"value": value,
+		}, nil
+	}
+
+	// Named queries, evaluated in name order so errors are deterministic
+	names := make([]string, 0, len(data.Queries))
+	for name := range data.Queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		value, err := query.Evaluate(data.Queries[name], input, limits)
+		if err != nil {
+			return nil, fmt.Errorf("query %q: %w", name, err)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
 // NodeType returns the node type this executor handles
 func (e *ExtractExecutor) NodeType() types.NodeType {
 	return types.NodeTypeExtract
@@ -66,8 +110,20 @@ func (e *ExtractExecutor) Validate(node types.Node) error {
 	if err != nil {
 		return err
 	}
-	if data.Field == nil && len(data.Fields) == 0 {
-		return fmt.Errorf("extract node requires 'field' or 'fields' configuration")
+	if data.Field == nil && len(data.Fields) == 0 && data.Query == nil && len(data.Queries) == 0 {
+		return fmt.Errorf("extract node requires 'field', 'fields', 'query' or 'queries' configuration")
+	}
+
+	// Compile queries up front so syntax errors surface at validation time
+	if data.Query != nil {
+		if _, err := query.Compile(*data.Query); err != nil {
+			return err
+		}
+	}
+	for name, source := range data.Queries {
+		if _, err := query.Compile(source); err != nil {
+			return fmt.Errorf("query %q: %w", name, err)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/query"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestExtractExecutor_QueryMode(t *testing.T) {
+	response := map[string]interface{}{
+		"orders": []interface{}{
+			map[string]interface{}{"id": "o1", "total": float64(50), "tags": []interface{}{"new"}},
+			map[string]interface{}{"id": "o2", "total": float64(150), "tags": []interface{}{"vip", "bulk"}},
+		},
+	}
+
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name string
+		data types.ExtractData
+		want interface{}
+	}{
+		{
+			name: "single JSONPath query",
+			data: types.ExtractData{Query: strPtr("$.orders[?(@.total > 100)].id")},
+			want: map[string]interface{}{
+				"query": "$.orders[?(@.total > 100)].id",
+				"value": []interface{}{"o2"},
+			},
+		},
+		{
+			name: "named queries",
+			data: types.ExtractData{Queries: map[string]string{
+				"first_tags": "orders[*].tags[0]",
+				"last_total": "orders[-1].total",
+			}},
+			want: map[string]interface{}{
+				"first_tags": []interface{}{"new", "vip"},
+				"last_total": float64(150),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &ExtractExecutor{}
+			ctx := &MockExecutionContext{
+				inputs: map[string][]interface{}{"extract1": {response}},
+			}
+			node := types.Node{ID: "extract1", Type: types.NodeTypeExtract, Data: tt.data}
+
+			if err := executor.Validate(node); err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			got, err := executor.Execute(ctx, node)
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Execute() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractExecutor_QueryLimits(t *testing.T) {
+	items := make([]interface{}, 20)
+	for i := range items {
+		items[i] = float64(i)
+	}
+
+	cfg := types.DefaultConfig()
+	cfg.MaxArrayLength = 10
+
+	q := "$[*]"
+	executor := &ExtractExecutor{}
+	ctx := &MockExecutionContext{
+		inputs: map[string][]interface{}{"extract1": {items}},
+		config: &cfg,
+	}
+	node := types.Node{ID: "extract1", Type: types.NodeTypeExtract, Data: types.ExtractData{Query: &q}}
+
+	if _, err := executor.Execute(ctx, node); !errors.Is(err, query.ErrTooManyResults) {
+		t.Errorf("expected MaxArrayLength to bound query results, got %v", err)
+	}
+}
+
+func TestExtractExecutor_ValidateQuery(t *testing.T) {
+	bad := "$.orders[?(@.total > )]"
+	node := types.Node{ID: "extract1", Type: types.NodeTypeExtract, Data: types.ExtractData{Query: &bad}}
+
+	err := (&ExtractExecutor{}).Validate(node)
+	if !errors.Is(err, query.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
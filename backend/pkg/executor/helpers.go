@@ -2,11 +2,18 @@ package executor
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/config"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/expression"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/query"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -122,6 +129,18 @@ func convertTypedValue(value interface{}, valueType string) (interface{}, error)
 		}
 		return time.Unix(ms/1000, (ms%1000)*1000000), nil
 
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("cannot convert type %T to object", value)
+		}
+		return value, nil
+
+	case "array":
+		if _, ok := value.([]interface{}); !ok {
+			return nil, fmt.Errorf("cannot convert type %T to array", value)
+		}
+		return value, nil
+
 	case "null":
 		return nil, nil
 
@@ -130,6 +149,138 @@ func convertTypedValue(value interface{}, valueType string) (interface{}, error)
 	}
 }
 
+// ============================================================================
+// Expression Context Helpers
+// ============================================================================
+
+// newExpressionContext builds an expression evaluation context from the
+// current workflow state. The variables map is a copy, so callers may bind
+// per-item values (item, index, accumulator) without mutating workflow state.
+//
+// The context carries a fresh evaluation budget derived from the config.
+// Executors that evaluate an expression per array element should build one
+// context per node execution and Clone it per item, so the budget bounds the
+// node as a whole.
+func newExpressionContext(ctx ExecutionContext, nodeID string) *expression.Context {
+	cfg := ctx.GetConfig()
+	exprCtx := &expression.Context{
+		NodeResults: ctx.GetAllNodeResults(),
+		Variables:   make(map[string]interface{}),
+		ContextVars: ctx.GetContextVariables(),
+		Clock:       cfg.Clock,
+		Rand:        newRand(cfg, nodeID),
+		Budget: expression.NewBudget(expression.Limits{
+			MaxSteps:      cfg.MaxExpressionSteps,
+			MaxOutputSize: cfg.MaxExpressionOutputSize,
+			MaxDepth:      cfg.MaxExpressionDepth,
+		}),
+		QueryLimits: queryLimits(cfg),
+		Strict:      cfg.StrictExpressions,
+	}
+	if decimalMode(cfg) {
+		dc := decimalContext(cfg)
+		exprCtx.Decimal = &dc
+	}
+	for k, v := range ctx.GetVariables() {
+		exprCtx.Variables[k] = v
+	}
+
+	// Zone-less dates in expressions follow the engine's default time zone.
+	// Config.Validate rejects unknown zones, so a load failure falls back to UTC.
+	if cfg.DefaultTimezone != "" {
+		if loc, err := expression.LoadLocation(cfg.DefaultTimezone); err == nil {
+			exprCtx.Location = loc
+		}
+	}
+	return exprCtx
+}
+
+// queryLimits maps the engine's data-shape limits onto path query limits, so
+// queries walk no deeper and select no more than workflow values may hold
+func queryLimits(cfg types.Config) query.Limits {
+	return query.Limits{
+		MaxDepth:   cfg.MaxContextDepth,
+		MaxResults: cfg.MaxArrayLength,
+	}
+}
+
+// newRand returns a random source for a node. With Config.RandomSeed set the
+// source is seeded from the seed and the node ID, so each node draws a
+// reproducible but distinct sequence. Otherwise it is seeded from the clock.
+func newRand(cfg types.Config, nodeID string) *rand.Rand {
+	if cfg.RandomSeed == 0 {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	h := fnv.New64a()
+	h.Write([]byte(nodeID))
+	return rand.New(rand.NewSource(cfg.RandomSeed ^ int64(h.Sum64())))
+}
+
+// ============================================================================
+// Decimal Helpers
+// ============================================================================
+
+// decimalMode reports whether the engine computes with exact decimals
+func decimalMode(cfg types.Config) bool {
+	return cfg.NumericMode == config.NumericModeDecimal
+}
+
+// decimalContext returns the division scale and rounding mode from config.
+// Config.Validate rejects unknown rounding modes, so a parse failure falls
+// back to half_even. Powers may not produce more digits than a string
+// value may hold.
+func decimalContext(cfg types.Config) decimal.Context {
+	mode, err := decimal.ParseRoundingMode(cfg.DecimalRounding)
+	if err != nil {
+		mode = decimal.RoundHalfEven
+	}
+	return decimal.Context{Scale: int32(cfg.DecimalScale), Rounding: mode, MaxDigits: cfg.MaxStringLength}
+}
+
+// toDecimal converts a numeric workflow value (float, integer, json.Number)
+// to a decimal. Unlike decimal.FromValue it does not parse strings, so nodes
+// accept the same inputs in both numeric modes.
+func toDecimal(v interface{}) (decimal.Decimal, bool) {
+	if _, isStr := v.(string); isStr {
+		return decimal.Zero, false
+	}
+	return decimal.FromValue(v)
+}
+
+// ============================================================================
+// Date/Time Helpers
+// ============================================================================
+
+// resolveTimezone returns the time zone a node should use: the node's own
+// timezone if set, otherwise the engine's DefaultTimezone. The boolean is
+// false when neither is configured.
+func resolveTimezone(nodeTZ *string, cfg types.Config) (*time.Location, bool, error) {
+	name := cfg.DefaultTimezone
+	if nodeTZ != nil && *nodeTZ != "" {
+		name = *nodeTZ
+	}
+	if name == "" {
+		return nil, false, nil
+	}
+	loc, err := expression.LoadLocation(name)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return loc, true, nil
+}
+
+// interpretInZone parses value using the first matching layout and returns it
+// as an RFC3339 string in loc. Values without an explicit offset are taken to
+// be wall-clock times in loc; values with an offset are converted to loc.
+func interpretInZone(value string, loc *time.Location, layouts []string) (string, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.In(loc).Format(time.RFC3339), nil
+		}
+	}
+	return "", fmt.Errorf("unable to parse %q as a date/time", value)
+}
+
 // ============================================================================
 // Condition Evaluation Helpers
 // ============================================================================
@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/security"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
@@ -74,11 +76,24 @@ func (e *HTTPExecutor) Execute(ctx ExecutionContext, node types.Node) (interface
 		}
 	}
 
-	// Make HTTP GET request
-	resp, err := client.Get(*data.URL)
+	// Make HTTP GET request, carrying the node's trace context in W3C
+	// traceparent headers
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodGet, *data.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP request: %w", err)
+	}
+	propagation.TraceContext{}.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	clientUID := ""
+	if data.HTTPClientUID != nil {
+		clientUID = *data.HTTPClientUID
+	}
+	requestStart := time.Now()
+	resp, err := client.Do(req)
 	if err != nil {
+		ctx.RecordHTTPCall(clientUID, 0, time.Since(requestStart))
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
+	ctx.RecordHTTPCall(clientUID, resp.StatusCode, time.Since(requestStart))
 	defer resp.Body.Close()
 
 	// Check for error status codes (only 2xx considered success)
@@ -98,7 +113,7 @@ func (e *HTTPExecutor) Execute(ctx ExecutionContext, node types.Node) (interface
 		// Try to read one more byte to see if there's more data
 		oneByte := make([]byte, 1)
 		if n, _ := resp.Body.Read(oneByte); n > 0 {
-			return nil, fmt.Errorf("response too large (exceeds %d bytes limit)", config.MaxResponseSize)
+			return nil, fmt.Errorf("%w: exceeds %d bytes limit", ErrResponseTooLarge, config.MaxResponseSize)
 		}
 	}
 
@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -359,3 +360,15 @@ func (m *mockExecutionContext) GetNodeExecutionCount() int {
 func (m *mockExecutionContext) GetHTTPCallCount() int {
 	return 0
 }
+
+func (m *mockExecutionContext) RecordHTTPCall(clientUID string, statusCode int, d time.Duration) {}
+
+func (m *mockExecutionContext) RecordIterations(n int) {}
+
+func (m *mockExecutionContext) Context() context.Context {
+	return context.Background()
+}
+
+func (m *mockExecutionContext) ExecuteSubWorkflow(nodeID, workflowID string, version int, inputs map[string]interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("sub-workflows are not supported")
+}
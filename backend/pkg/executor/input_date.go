@@ -9,16 +9,26 @@ import (
 // DateInputExecutor executes DateInput nodes
 type DateInputExecutor struct{}
 
-// Execute returns the date value from a date input node
+// Execute returns the date value from a date input node.
+// When a timezone is configured (on the node or via Config.DefaultTimezone),
+// the date is returned as an RFC3339 timestamp for midnight in that zone.
 func (e *DateInputExecutor) Execute(ctx ExecutionContext, node types.Node) (interface{}, error) {
 	data, err := types.AsDateInputData(node.Data)
 	if err != nil {
 		return nil, err
 	}
-	if data.DateValue != nil {
+	if data.DateValue == nil {
+		return nil, fmt.Errorf("date input node missing date value")
+	}
+
+	loc, ok, err := resolveTimezone(data.Timezone, ctx.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
 		return *data.DateValue, nil
 	}
-	return nil, fmt.Errorf("date input node missing date value")
+	return interpretInZone(*data.DateValue, loc, []string{"2006-01-02"})
 }
 
 // NodeType returns the node type this executor handles
@@ -35,5 +45,8 @@ func (e *DateInputExecutor) Validate(node types.Node) error {
 	if data.DateValue == nil {
 		return fmt.Errorf("date input node missing date value")
 	}
+	if _, _, err := resolveTimezone(data.Timezone, types.Config{}); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestDateInputExecutors_Timezone(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/London"); err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+
+	ctx := &MockExecutionContext{}
+
+	tests := []struct {
+		name string
+		exec NodeExecutor
+		data types.NodeDataInterface
+		want interface{}
+	}{
+		{
+			name: "date without timezone is passed through",
+			exec: &DateInputExecutor{},
+			data: types.DateInputData{DateValue: stringPtr("2024-07-01")},
+			want: "2024-07-01",
+		},
+		{
+			name: "date interpreted as midnight in zone",
+			exec: &DateInputExecutor{},
+			data: types.DateInputData{DateValue: stringPtr("2024-07-01"), Timezone: stringPtr("Europe/London")},
+			want: "2024-07-01T00:00:00+01:00",
+		},
+		{
+			name: "zone-less datetime interpreted in zone",
+			exec: &DateTimeInputExecutor{},
+			data: types.DateTimeInputData{DateTimeValue: stringPtr("2024-01-15T09:30"), Timezone: stringPtr("America/New_York")},
+			want: "2024-01-15T09:30:00-05:00",
+		},
+		{
+			name: "datetime with offset converted to zone",
+			exec: &DateTimeInputExecutor{},
+			data: types.DateTimeInputData{DateTimeValue: stringPtr("2024-07-01T12:00:00Z"), Timezone: stringPtr("Europe/London")},
+			want: "2024-07-01T13:00:00+01:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.exec.Execute(ctx, types.Node{ID: "d1", Type: tt.exec.NodeType(), Data: tt.data})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	invalid := types.Node{ID: "d2", Type: types.NodeTypeDateInput, Data: types.DateInputData{
+		DateValue: stringPtr("2024-07-01"),
+		Timezone:  stringPtr("Mars/Olympus_Mons"),
+	}}
+	if err := (&DateInputExecutor{}).Validate(invalid); err == nil {
+		t.Error("Validate() should reject an unknown timezone")
+	}
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
@@ -9,16 +10,37 @@ import (
 // DateTimeInputExecutor executes DateTimeInput nodes
 type DateTimeInputExecutor struct{}
 
-// Execute returns the datetime value from a datetime input node
+// dateTimeInputLayouts are the formats accepted when a timezone is configured.
+// Layouts without an offset are interpreted as wall-clock time in that zone.
+var dateTimeInputLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// Execute returns the datetime value from a datetime input node.
+// When a timezone is configured (on the node or via Config.DefaultTimezone),
+// the value is returned as an RFC3339 timestamp in that zone.
 func (e *DateTimeInputExecutor) Execute(ctx ExecutionContext, node types.Node) (interface{}, error) {
 	data, err := types.AsDateTimeInputData(node.Data)
 	if err != nil {
 		return nil, err
 	}
-	if data.DateTimeValue != nil {
+	if data.DateTimeValue == nil {
+		return nil, fmt.Errorf("datetime input node missing datetime value")
+	}
+
+	loc, ok, err := resolveTimezone(data.Timezone, ctx.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
 		return *data.DateTimeValue, nil
 	}
-	return nil, fmt.Errorf("datetime input node missing datetime value")
+	return interpretInZone(*data.DateTimeValue, loc, dateTimeInputLayouts)
 }
 
 // NodeType returns the node type this executor handles
@@ -35,5 +57,8 @@ func (e *DateTimeInputExecutor) Validate(node types.Node) error {
 	if data.DateTimeValue == nil {
 		return fmt.Errorf("datetime input node missing datetime value")
 	}
+	if _, _, err := resolveTimezone(data.Timezone, types.Config{}); err != nil {
+		return err
+	}
 	return nil
 }
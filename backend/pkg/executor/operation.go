@@ -3,6 +3,7 @@ package executor
 import (
 	"fmt"
 
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -25,6 +26,10 @@ func (e *OperationExecutor) Execute(ctx ExecutionContext, node types.Node) (inte
 		return nil, fmt.Errorf("operation needs 2 inputs, got %d", len(inputs))
 	}
 
+	if cfg := ctx.GetConfig(); decimalMode(cfg) {
+		return executeDecimalOperation(*data.Op, inputs[0], inputs[1], decimalContext(cfg))
+	}
+
 	// Convert to numbers
 	left, ok1 := inputs[0].(float64)
 	right, ok2 := inputs[1].(float64)
@@ -50,6 +55,36 @@ func (e *OperationExecutor) Execute(ctx ExecutionContext, node types.Node) (inte
 	}
 }
 
+// executeDecimalOperation performs the operation with exact decimals and
+// returns the result as a json.Number
+func executeDecimalOperation(op string, a, b interface{}, dc decimal.Context) (interface{}, error) {
+	left, ok1 := toDecimal(a)
+	right, ok2 := toDecimal(b)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("operation inputs must be numbers")
+	}
+
+	switch op {
+	case "add":
+		return left.Add(right).Number(), nil
+	case "subtract":
+		return left.Sub(right).Number(), nil
+	case "multiply":
+		return left.Mul(right).Number(), nil
+	case "divide":
+		if right.IsZero() {
+			return nil, fmt.Errorf("division by zero")
+		}
+		result, err := dc.Div(left, right)
+		if err != nil {
+			return nil, err
+		}
+		return result.Number(), nil
+	default:
+		return nil, fmt.Errorf("unknown operation: %s", op)
+	}
+}
+
 // NodeType returns the node type this executor handles
 func (e *OperationExecutor) NodeType() types.NodeType {
 	return types.NodeTypeOperation
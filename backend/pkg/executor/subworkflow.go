@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// SubWorkflowExecutor executes SubWorkflow nodes
+type SubWorkflowExecutor struct{}
+
+// Execute runs the SubWorkflow node
+// Runs another saved workflow and returns its final output. The child's
+// inputs are the node's static inputs, overridden by its upstream values:
+// with input_name the first input is bound to that child input, otherwise
+// the fields of every object input are.
+func (e *SubWorkflowExecutor) Execute(ctx ExecutionContext, node types.Node) (interface{}, error) {
+	data, err := types.AsSubWorkflowData(node.Data)
+	if err != nil {
+		return nil, err
+	}
+	if data.WorkflowID == nil || *data.WorkflowID == "" {
+		return nil, fmt.Errorf("sub_workflow node requires workflow_id")
+	}
+
+	inputs := make(map[string]interface{}, len(data.Inputs))
+	for name, value := range data.Inputs {
+		inputs[name] = value
+	}
+
+	upstream := ctx.GetNodeInputs(node.ID)
+	switch {
+	case data.InputName != nil && *data.InputName != "":
+		if len(upstream) > 0 {
+			inputs[*data.InputName] = upstream[0]
+		}
+	default:
+		for i, value := range upstream {
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: input %d is %T; sub_workflow nodes without input_name take objects", ErrInputTypeMismatch, i, value)
+			}
+			for name, v := range fields {
+				inputs[name] = v
+			}
+		}
+	}
+
+	version := 0
+	if data.Version != nil {
+		version = *data.Version
+	}
+	return ctx.ExecuteSubWorkflow(node.ID, *data.WorkflowID, version, inputs)
+}
+
+// NodeType returns the node type this executor handles
+func (e *SubWorkflowExecutor) NodeType() types.NodeType {
+	return types.NodeTypeSubWorkflow
+}
+
+// Validate checks if node configuration is valid
+func (e *SubWorkflowExecutor) Validate(node types.Node) error {
+	data, err := types.AsSubWorkflowData(node.Data)
+	if err != nil {
+		return err
+	}
+	return data.Validate()
+}
@@ -0,0 +1,157 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// ============================================================================
+// Evaluation Budget
+// ============================================================================
+
+// Limits bounds the resources expression evaluation may consume.
+// A zero value for any field means unlimited.
+type Limits struct {
+	MaxSteps      int // Maximum evaluation steps charged to one budget
+	MaxOutputSize int // Maximum number of elements a single function may produce
+	MaxDepth      int // Maximum nesting depth of evaluation calls
+}
+
+// Budget tracks resource usage against Limits.
+//
+// A Budget is typically created once per node execution and shared (through
+// Context.Clone) by every expression that node evaluates, so a reduce over
+// 10,000 items is bounded as a whole rather than per item.
+//
+// Once a limit is exceeded the error is sticky: every later evaluation using
+// the budget fails with the same error, even if an intermediate caller
+// swallowed it. A Budget is not safe for concurrent use.
+type Budget struct {
+	limits Limits
+	steps  int
+	depth  int
+	err    error
+}
+
+// NewBudget creates a budget enforcing the given limits
+func NewBudget(limits Limits) *Budget {
+	return &Budget{limits: limits}
+}
+
+// Steps returns the number of evaluation steps consumed so far
+func (b *Budget) Steps() int {
+	return b.steps
+}
+
+// Err returns the limit error recorded by the budget, if any
+func (b *Budget) Err() error {
+	return b.err
+}
+
+// fail records a limit violation and returns it
+func (b *Budget) fail(expr string, message string, cause error) error {
+	if b.err == nil {
+		b.err = newExpressionErrorWithCause(expr, message, cause)
+	}
+	return b.err
+}
+
+// IsLimitError reports whether err was caused by an exhausted evaluation budget.
+// Executors that tolerate per-item failures should stop on these errors.
+func IsLimitError(err error) bool {
+	return errors.Is(err, ErrExpressionTooComplex) || errors.Is(err, ErrRecursionDepthExceeded)
+}
+
+// enter charges one step and one level of depth for evaluating expr.
+// Every successful enter must be paired with leave.
+func (c *Context) enter(expr string) error {
+	b := c.Budget
+	if b == nil {
+		return nil
+	}
+	if b.err != nil {
+		return b.err
+	}
+	b.steps++
+	if b.limits.MaxSteps > 0 && b.steps > b.limits.MaxSteps {
+		return b.fail(expr, fmt.Sprintf("evaluation step limit exceeded (limit: %d)", b.limits.MaxSteps), ErrExpressionTooComplex)
+	}
+	if b.limits.MaxDepth > 0 && b.depth >= b.limits.MaxDepth {
+		return b.fail(expr, fmt.Sprintf("evaluation depth limit exceeded (limit: %d)", b.limits.MaxDepth), ErrRecursionDepthExceeded)
+	}
+	b.depth++
+	return nil
+}
+
+// leave releases the depth acquired by enter
+func (c *Context) leave() {
+	if c.Budget != nil && c.Budget.depth > 0 {
+		c.Budget.depth--
+	}
+}
+
+// charge consumes n steps for work proportional to input size, such as
+// sorting or flattening an array inside a single function call.
+func (c *Context) charge(fn string, n int) error {
+	b := c.Budget
+	if b == nil {
+		return nil
+	}
+	if b.err != nil {
+		return b.err
+	}
+	b.steps += n
+	if b.limits.MaxSteps > 0 && b.steps > b.limits.MaxSteps {
+		return b.fail(fn+"()", fmt.Sprintf("evaluation step limit exceeded (limit: %d)", b.limits.MaxSteps), ErrExpressionTooComplex)
+	}
+	return nil
+}
+
+// checkOutput verifies that a function result of n elements fits the output limit
+func (c *Context) checkOutput(fn string, n int) error {
+	b := c.Budget
+	if b == nil || b.limits.MaxOutputSize <= 0 || n <= b.limits.MaxOutputSize {
+		return nil
+	}
+	return b.fail(fn+"()", fmt.Sprintf("output size %d exceeds limit %d", n, b.limits.MaxOutputSize), ErrExpressionTooComplex)
+}
+
+// checkDepth verifies that a recursive walk of the given depth fits the depth limit
+func (c *Context) checkDepth(fn string, depth int) error {
+	b := c.Budget
+	if b == nil || b.limits.MaxDepth <= 0 || b.depth+depth <= b.limits.MaxDepth {
+		return nil
+	}
+	return b.fail(fn+"()", fmt.Sprintf("evaluation depth limit exceeded (limit: %d)", b.limits.MaxDepth), ErrRecursionDepthExceeded)
+}
+
+// budgetErr returns the sticky budget error, if any
+func (c *Context) budgetErr() error {
+	if c.Budget == nil {
+		return nil
+	}
+	return c.Budget.err
+}
+
+// ============================================================================
+// Clock and Randomness
+// ============================================================================
+
+// now returns the current time from the context clock, or the wall clock if unset
+func (c *Context) now() time.Time {
+	if c != nil && c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
+
+// intn returns a random int in [0, n) from the context RNG,
+// falling back to the shared math/rand source if unset
+func (c *Context) intn(n int) int {
+	if c != nil && c.Rand != nil {
+		return c.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
@@ -0,0 +1,121 @@
+package expression
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newBudgetContext(limits Limits) *Context {
+	return &Context{
+		NodeResults: make(map[string]interface{}),
+		Variables:   make(map[string]interface{}),
+		ContextVars: make(map[string]interface{}),
+		Budget:      NewBudget(limits),
+	}
+}
+
+func TestBudget_Limits(t *testing.T) {
+	var nested interface{} = []interface{}{1.0}
+	for i := 0; i < 20; i++ {
+		nested = []interface{}{nested}
+	}
+	variables := map[string]interface{}{
+		"nums":   []interface{}{5.0, 4.0, 3.0, 2.0, 1.0, 0.0, 9.0, 8.0, 7.0, 6.0},
+		"pairs":  []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0, 4.0}},
+		"nested": nested,
+	}
+
+	tests := []struct {
+		name       string
+		limits     Limits
+		expression string
+		wantErr    error
+	}{
+		{"within step limit", Limits{MaxSteps: 100}, "1 + 2", nil},
+		{"step limit exceeded", Limits{MaxSteps: 5}, "map(variables.nums, item * 2)", ErrExpressionTooComplex},
+		{"sort charges per comparison", Limits{MaxSteps: 20}, "sort(variables.nums)", ErrExpressionTooComplex},
+		{"flatten output limit", Limits{MaxOutputSize: 3}, "flatten(variables.pairs)", ErrExpressionTooComplex},
+		{"flatten depth limit", Limits{MaxDepth: 10}, "flatten(variables.nested)", ErrRecursionDepthExceeded},
+		{"map output limit", Limits{MaxOutputSize: 2}, "map(variables.nums, item * 2)", ErrExpressionTooComplex},
+		{"unlimited", Limits{}, "flatten(variables.nested)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newBudgetContext(tt.limits)
+			ctx.Variables = variables
+			_, err := EvaluateExpression(tt.expression, nil, ctx)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if !IsLimitError(err) {
+				t.Errorf("IsLimitError(%v) = false, want true", err)
+			}
+		})
+	}
+}
+
+func TestBudget_SharedAndSticky(t *testing.T) {
+	base := newBudgetContext(Limits{MaxSteps: 50})
+
+	// Each clone shares the budget, so repeated evaluations eventually exhaust it
+	var err error
+	for i := 0; i < 100 && err == nil; i++ {
+		_, err = EvaluateExpression("1 + 2", nil, base.Clone())
+	}
+	if !errors.Is(err, ErrExpressionTooComplex) {
+		t.Fatalf("expected shared budget to be exhausted, got %v", err)
+	}
+
+	// Once exhausted, even trivial expressions fail
+	if _, err := Evaluate("true", nil, base.Clone()); !IsLimitError(err) {
+		t.Errorf("expected sticky limit error, got %v", err)
+	}
+	if base.Budget.Err() == nil {
+		t.Error("expected Budget.Err() to report the limit error")
+	}
+}
+
+func TestContext_InjectedClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 10, 8, 30, 0, 0, time.UTC)
+	ctx := newBudgetContext(Limits{})
+	ctx.Clock = func() time.Time { return fixed }
+
+	got, err := EvaluateExpression(`formatDate(now(), "RFC3339")`, nil, ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "2024-03-10T08:30:00Z" {
+		t.Errorf("expected injected clock time, got %v", got)
+	}
+}
+
+func TestContext_SeededSample(t *testing.T) {
+	sample := func(seed int64) interface{} {
+		ctx := newBudgetContext(Limits{})
+		ctx.Rand = rand.New(rand.NewSource(seed))
+		ctx.Variables["nums"] = []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0}
+		got, err := EvaluateExpression("sample(variables.nums, 4)", nil, ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return got
+	}
+
+	first, second := sample(42), sample(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected identical samples for the same seed, got %v and %v", first, second)
+	}
+	if n := len(first.([]interface{})); n != 4 {
+		t.Errorf("expected 4 sampled items, got %d", n)
+	}
+}
@@ -0,0 +1,434 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Time Zone Helpers
+// ============================================================================
+
+// contextLocation returns the default time zone for an evaluation context.
+// Zone-less dates are interpreted in this location; UTC is used when unset.
+func contextLocation(ctx *Context) *time.Location {
+	if ctx != nil && ctx.Location != nil {
+		return ctx.Location
+	}
+	return time.UTC
+}
+
+// locations caches loaded time zones by name. time.LoadLocation reads the
+// zone database on every call; only successful loads are cached, so it
+// holds at most one entry per zone in the database.
+var locations sync.Map
+
+// LoadLocation resolves an IANA time zone name (e.g. "Europe/London").
+// "UTC", "Z" and "Local" are also accepted. Loaded zones are cached, so
+// it is cheap to call per evaluation.
+func LoadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch name {
+	case "", "UTC", "utc", "Z":
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone: %s", name)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// locationArg converts a function argument into a time zone
+func locationArg(fn string, arg interface{}) (*time.Location, error) {
+	name, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() time zone must be a string, got %T", fn, arg)
+	}
+	return LoadLocation(name)
+}
+
+// parseDateTimeIn parses various date/time formats into time.Time.
+// Strings without an explicit offset are interpreted in loc.
+func parseDateTimeIn(value interface{}, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		// Formats carrying their own offset
+		for _, format := range []string{time.RFC3339, time.RFC3339Nano, time.RFC822, time.RFC1123} {
+			if t, err := time.Parse(format, v); err == nil {
+				return t, nil
+			}
+		}
+		// Zone-less formats are interpreted in the requested location
+		for _, format := range []string{"2006-01-02", "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+			if t, err := time.ParseInLocation(format, v, loc); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unable to parse date/time: %s", v)
+	case float64:
+		// Assume Unix timestamp in seconds
+		return time.Unix(int64(v), 0).In(loc), nil
+	case int64:
+		return time.Unix(v, 0).In(loc), nil
+	case int:
+		return time.Unix(int64(v), 0).In(loc), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported date/time type: %T", value)
+	}
+}
+
+// ============================================================================
+// Formatting
+// ============================================================================
+
+// namedLayouts maps well-known layout names to Go reference layouts
+var namedLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC822":      time.RFC822,
+	"ISO8601":     time.RFC3339,
+	"date":        "2006-01-02",
+	"datetime":    "2006-01-02 15:04:05",
+	"time":        "15:04:05",
+}
+
+// layoutTokens translates moment-style tokens into Go reference layout.
+// Longer tokens must come first so "YYYY" is not consumed as "YY".
+var layoutTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"},
+	{"YY", "06"},
+	{"MMMM", "January"},
+	{"MMM", "Jan"},
+	{"MM", "01"},
+	{"DD", "02"},
+	{"dddd", "Monday"},
+	{"ddd", "Mon"},
+	{"HH", "15"},
+	{"hh", "03"},
+	{"mm", "04"},
+	{"ss", "05"},
+	{"SSS", ".000"},
+	{"A", "PM"},
+	{"ZZ", "-0700"},
+	{"Z", "-07:00"},
+}
+
+// resolveLayout converts a user-supplied layout into a Go time layout.
+// Accepts named layouts ("RFC3339", "date"), Go reference layouts
+// ("2006-01-02") and moment-style tokens ("YYYY-MM-DD HH:mm:ss").
+func resolveLayout(layout string) string {
+	if named, ok := namedLayouts[layout]; ok {
+		return named
+	}
+	if strings.Contains(layout, "2006") || strings.Contains(layout, "15:04") {
+		return layout
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(layout); {
+		matched := false
+		for _, tk := range layoutTokens {
+			if strings.HasPrefix(layout[i:], tk.token) {
+				out.WriteString(tk.layout)
+				i += len(tk.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out.WriteByte(layout[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+// ============================================================================
+// Calendar Units
+// ============================================================================
+
+// startOfUnit truncates t to the beginning of the given calendar unit in t's zone.
+// Weeks start on Monday (ISO 8601).
+func startOfUnit(t time.Time, unit string) (time.Time, error) {
+	loc := t.Location()
+	switch strings.ToLower(unit) {
+	case "second":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+	case "minute":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc), nil
+	case "hour":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc), nil
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc), nil
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc), nil
+	case "quarter":
+		month := time.Month((int(t.Month())-1)/3*3 + 1)
+		return time.Date(t.Year(), month, 1, 0, 0, 0, 0, loc), nil
+	case "year":
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, loc), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown time unit: %s (expected second, minute, hour, day, week, month, quarter or year)", unit)
+	}
+}
+
+// endOfUnit returns the last instant (nanosecond precision) of the calendar unit containing t
+func endOfUnit(t time.Time, unit string) (time.Time, error) {
+	start, err := startOfUnit(t, unit)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var next time.Time
+	switch strings.ToLower(unit) {
+	case "second":
+		next = start.Add(time.Second)
+	case "minute":
+		next = start.Add(time.Minute)
+	case "hour":
+		next = start.Add(time.Hour)
+	case "day":
+		next = start.AddDate(0, 0, 1)
+	case "week":
+		next = start.AddDate(0, 0, 7)
+	case "month":
+		next = start.AddDate(0, 1, 0)
+	case "quarter":
+		next = start.AddDate(0, 3, 0)
+	case "year":
+		next = start.AddDate(1, 0, 0)
+	}
+	return next.Add(-time.Nanosecond), nil
+}
+
+// ============================================================================
+// ISO-8601 Durations
+// ============================================================================
+
+// isoDuration is a parsed ISO-8601 duration such as "P1Y2M3DT4H5M6S".
+// Calendar parts (years, months, weeks, days) are kept separate from the
+// clock part so they can be applied with calendar-aware arithmetic.
+type isoDuration struct {
+	negative bool
+	years    int
+	months   int
+	days     int
+	clock    time.Duration
+}
+
+// parseISODuration parses an ISO-8601 duration ("P1DT2H", "PT30M", "-P1W")
+func parseISODuration(s string) (isoDuration, error) {
+	var d isoDuration
+	str := strings.TrimSpace(s)
+	if strings.HasPrefix(str, "-") {
+		d.negative = true
+		str = str[1:]
+	}
+	if !strings.HasPrefix(str, "P") || len(str) < 2 {
+		return d, fmt.Errorf("invalid ISO-8601 duration: %s", s)
+	}
+	str = str[1:]
+
+	inTime := false
+	num := ""
+	seen := false
+	for i := 0; i < len(str); i++ {
+		ch := str[i]
+		switch {
+		case ch == 'T':
+			if inTime || num != "" {
+				return d, fmt.Errorf("invalid ISO-8601 duration: %s", s)
+			}
+			inTime = true
+		case (ch >= '0' && ch <= '9') || ch == '.':
+			num += string(ch)
+		default:
+			if num == "" {
+				return d, fmt.Errorf("invalid ISO-8601 duration: %s", s)
+			}
+			value, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return d, fmt.Errorf("invalid ISO-8601 duration: %s", s)
+			}
+			num = ""
+			seen = true
+			if !inTime {
+				if value != float64(int(value)) {
+					return d, fmt.Errorf("fractional calendar units are not supported: %s", s)
+				}
+				switch ch {
+				case 'Y':
+					d.years += int(value)
+				case 'M':
+					d.months += int(value)
+				case 'W':
+					d.days += int(value) * 7
+				case 'D':
+					d.days += int(value)
+				default:
+					return d, fmt.Errorf("invalid ISO-8601 duration designator '%c': %s", ch, s)
+				}
+				continue
+			}
+			switch ch {
+			case 'H':
+				d.clock += time.Duration(value * float64(time.Hour))
+			case 'M':
+				d.clock += time.Duration(value * float64(time.Minute))
+			case 'S':
+				d.clock += time.Duration(value * float64(time.Second))
+			default:
+				return d, fmt.Errorf("invalid ISO-8601 duration designator '%c': %s", ch, s)
+			}
+		}
+	}
+	if num != "" || !seen {
+		return d, fmt.Errorf("invalid ISO-8601 duration: %s", s)
+	}
+	return d, nil
+}
+
+// addTo applies the duration to t. Calendar parts are applied in t's zone so
+// that "P1D" keeps the wall-clock time across DST transitions, and "P1M"
+// clamps to the end of shorter months (Jan 31 + P1M = Feb 29 in a leap year).
+func (d isoDuration) addTo(t time.Time, sign int) time.Time {
+	if d.negative {
+		sign = -sign
+	}
+	t = addMonthsClamped(t, sign*(d.years*12+d.months))
+	return t.AddDate(0, 0, sign*d.days).Add(time.Duration(sign) * d.clock)
+}
+
+// addMonthsClamped adds n calendar months to t, clamping the day of month
+// to the last day of the target month instead of overflowing into the next.
+func addMonthsClamped(t time.Time, n int) time.Time {
+	if n == 0 {
+		return t
+	}
+	year, month, day := t.Date()
+	target := time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	lastDay := target.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(target.Year(), target.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// seconds returns the nominal length of the duration in seconds,
+// treating a day as 24h, a month as 30 days and a year as 365 days.
+func (d isoDuration) seconds() float64 {
+	total := d.clock.Seconds() +
+		float64(d.days)*86400 +
+		float64(d.months)*30*86400 +
+		float64(d.years)*365*86400
+	if d.negative {
+		return -total
+	}
+	return total
+}
+
+// durationArg converts a dateAdd/dateSub amount into a function that shifts a time.
+// Numbers are seconds; strings are ISO-8601 durations.
+func durationArg(fn string, arg interface{}) (func(time.Time, int) time.Time, error) {
+	if str, ok := arg.(string); ok && strings.Contains(strings.ToUpper(str), "P") {
+		d, err := parseISODuration(strings.ToUpper(str))
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", fn, err)
+		}
+		return d.addTo, nil
+	}
+	seconds, ok := toFloat64(arg)
+	if !ok {
+		return nil, fmt.Errorf("%s() amount must be seconds or an ISO-8601 duration, got %T", fn, arg)
+	}
+	return func(t time.Time, sign int) time.Time {
+		return t.Add(time.Duration(float64(sign) * seconds * float64(time.Second)))
+	}, nil
+}
+
+// ============================================================================
+// Business Days
+// ============================================================================
+
+// isWeekend reports whether t falls on a Saturday or Sunday
+func isWeekend(t time.Time) bool {
+	wd := t.Weekday()
+	return wd == time.Saturday || wd == time.Sunday
+}
+
+// maxBusinessDays bounds the day count addBusinessDays accepts, about
+// 3,800 years, so results stay within the range of time.Time
+const maxBusinessDays = 1_000_000
+
+// addBusinessDays moves t by n weekdays (Monday-Friday), skipping weekends.
+// A start date on a weekend is first rolled to the adjacent business day
+// in the direction of travel. Whole weeks of five business days are added
+// as seven calendar days, so the cost does not grow with n.
+func addBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step = -1
+		n = -n
+	}
+	if n == 0 {
+		return t
+	}
+
+	// From a weekday, every five business days is exactly one week
+	if isWeekend(t) {
+		for t = t.AddDate(0, 0, step); isWeekend(t); t = t.AddDate(0, 0, step) {
+		}
+		n--
+	}
+	t = t.AddDate(0, 0, step*7*(n/5))
+	for n %= 5; n > 0; {
+		t = t.AddDate(0, 0, step)
+		if !isWeekend(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// businessDaysBetween counts weekdays in the half-open interval [from, to).
+// The result is negative when to is before from.
+func businessDaysBetween(from, to time.Time) int {
+	sign := 1
+	if to.Before(from) {
+		from, to = to, from
+		sign = -1
+	}
+	to = to.In(from.Location())
+
+	// Count on UTC midnights so DST changes do not skew the day count
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	start := time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)
+	days := int(time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC).Sub(start).Hours() / 24)
+
+	count := days / 7 * 5
+	d := start.AddDate(0, 0, days/7*7)
+	for i := 0; i < days%7; i++ {
+		if !isWeekend(d) {
+			count++
+		}
+		d = d.AddDate(0, 0, 1)
+	}
+	return sign * count
+}
@@ -0,0 +1,309 @@
+package expression
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDateTimeFunctions_TimeZones(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+
+	ctx := &Context{
+		NodeResults: make(map[string]interface{}),
+		Variables: map[string]interface{}{
+			"summer": "2024-07-01T12:00:00Z",
+			"winter": "2024-01-15T12:00:00Z",
+		},
+		ContextVars: make(map[string]interface{}),
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+	}{
+		{"formatDate with go layout", `formatDate(variables.winter, "2006-01-02 15:04")`, "2024-01-15 12:00"},
+		{"formatDate with tokens", `formatDate(variables.winter, "YYYY/MM/DD HH:mm:ss")`, "2024/01/15 12:00:00"},
+		{"formatDate named layout", `formatDate(variables.winter, "date")`, "2024-01-15"},
+		{"formatDate in zone (BST)", `formatDate(variables.summer, "HH:mm Z", "Europe/London")`, "13:00 +01:00"},
+		{"formatDate in zone (GMT)", `formatDate(variables.winter, "HH:mm Z", "Europe/London")`, "12:00 +00:00"},
+		{"inZone nested in formatDate", `formatDate(inZone(variables.summer, "Asia/Tokyo"), "HH:mm")`, "21:00"},
+		{"parseDate with layout and zone", `formatDate(parseDate("15/01/2024 09:30", "DD/MM/YYYY HH:mm", "America/New_York"), "RFC3339")`, "2024-01-15T09:30:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateExpression(tt.expression, nil, ctx)
+			if err != nil {
+				t.Fatalf("EvaluateExpression() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The context location applies to zone-less inputs
+	ctx.Location = london
+	got, err := EvaluateExpression(`formatDate(parseDate("2024-07-01 08:00:00"), "RFC3339")`, nil, ctx)
+	if err != nil {
+		t.Fatalf("EvaluateExpression() error = %v", err)
+	}
+	if got != "2024-07-01T08:00:00+01:00" {
+		t.Errorf("parseDate with context location = %v, want 2024-07-01T08:00:00+01:00", got)
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	for _, name := range []string{"", "UTC", "Z", " utc "} {
+		if loc, err := LoadLocation(name); err != nil || loc != time.UTC {
+			t.Errorf("LoadLocation(%q) = %v, %v; want UTC", name, loc, err)
+		}
+	}
+	if _, err := LoadLocation("Mars/Olympus"); err == nil {
+		t.Error("LoadLocation() with unknown zone should fail")
+	}
+
+	first, err := LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+	if again, _ := LoadLocation(" Europe/London"); again != first {
+		t.Error("LoadLocation() loaded a cached zone again")
+	}
+}
+
+func TestDateTimeFunctions_StartEndOf(t *testing.T) {
+	ref := time.Date(2024, 5, 15, 13, 45, 30, 500, time.UTC) // Wednesday
+
+	tests := []struct {
+		fn   string
+		unit string
+		want time.Time
+	}{
+		{"startOf", "minute", time.Date(2024, 5, 15, 13, 45, 0, 0, time.UTC)},
+		{"startOf", "hour", time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC)},
+		{"startOf", "day", time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{"startOf", "week", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{"startOf", "month", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"startOf", "quarter", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"startOf", "year", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"endOf", "day", time.Date(2024, 5, 15, 23, 59, 59, 999999999, time.UTC)},
+		{"endOf", "week", time.Date(2024, 5, 19, 23, 59, 59, 999999999, time.UTC)},
+		{"endOf", "month", time.Date(2024, 5, 31, 23, 59, 59, 999999999, time.UTC)},
+		{"endOf", "year", time.Date(2024, 12, 31, 23, 59, 59, 999999999, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fn+"_"+tt.unit, func(t *testing.T) {
+			got, err := callDateTimeFunction(tt.fn, []interface{}{ref, tt.unit}, nil)
+			if err != nil {
+				t.Fatalf("%s() error = %v", tt.fn, err)
+			}
+			if !got.(time.Time).Equal(tt.want) {
+				t.Errorf("%s(%s) = %v, want %v", tt.fn, tt.unit, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := callDateTimeFunction("startOf", []interface{}{ref, "fortnight"}, nil); err == nil {
+		t.Error("startOf() with unknown unit should fail")
+	}
+}
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		seconds float64
+		wantErr bool
+	}{
+		{"PT30M", 1800, false},
+		{"P1DT2H", 93600, false},
+		{"P1W", 604800, false},
+		{"PT1.5S", 1.5, false},
+		{"-PT1H", -3600, false},
+		{"P", 0, true},
+		{"1D", 0, true},
+		{"P1X", 0, true},
+		{"P1.5D", 0, true},
+		{"PT", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := parseISODuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseISODuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && d.seconds() != tt.seconds {
+				t.Errorf("parseISODuration(%q).seconds() = %v, want %v", tt.input, d.seconds(), tt.seconds)
+			}
+		})
+	}
+}
+
+func TestDateAdd_ISODuration(t *testing.T) {
+	ctx := &Context{
+		NodeResults: make(map[string]interface{}),
+		Variables:   map[string]interface{}{"d": "2024-01-31T10:00:00Z"},
+		ContextVars: make(map[string]interface{}),
+	}
+
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{`formatDate(dateAdd(variables.d, "P1M"), "RFC3339")`, "2024-02-29T10:00:00Z"},
+		{`formatDate(dateAdd(variables.d, "P1DT2H"), "RFC3339")`, "2024-02-01T12:00:00Z"},
+		{`formatDate(dateAdd(variables.d, 3600), "RFC3339")`, "2024-01-31T11:00:00Z"},
+		{`formatDate(dateSub(variables.d, "P1Y"), "RFC3339")`, "2023-01-31T10:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := EvaluateExpression(tt.expression, nil, ctx)
+			if err != nil {
+				t.Fatalf("EvaluateExpression() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	got, err := EvaluateExpression(`parseDuration("P1DT2H")`, nil, ctx)
+	if err != nil || got != 93600.0 {
+		t.Errorf("parseDuration() = %v, %v; want 93600", got, err)
+	}
+}
+
+func TestBusinessDayFunctions(t *testing.T) {
+	friday := time.Date(2024, 5, 17, 9, 0, 0, 0, time.UTC)
+
+	got, err := callDateTimeFunction("addBusinessDays", []interface{}{friday, 1.0}, nil)
+	if err != nil {
+		t.Fatalf("addBusinessDays() error = %v", err)
+	}
+	if want := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC); !got.(time.Time).Equal(want) {
+		t.Errorf("addBusinessDays(friday, 1) = %v, want %v", got, want)
+	}
+
+	got, err = callDateTimeFunction("addBusinessDays", []interface{}{friday, -5.0}, nil)
+	if err != nil {
+		t.Fatalf("addBusinessDays() error = %v", err)
+	}
+	if want := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC); !got.(time.Time).Equal(want) {
+		t.Errorf("addBusinessDays(friday, -5) = %v, want %v", got, want)
+	}
+
+	got, err = callDateTimeFunction("businessDaysBetween", []interface{}{"2024-05-13", "2024-05-27"}, nil)
+	if err != nil || got != 10.0 {
+		t.Errorf("businessDaysBetween() = %v, %v; want 10", got, err)
+	}
+
+	got, err = callDateTimeFunction("isBusinessDay", []interface{}{"2024-05-18"}, nil)
+	if err != nil || got != false {
+		t.Errorf("isBusinessDay(saturday) = %v, %v; want false", got, err)
+	}
+}
+
+func TestBusinessDayArithmetic(t *testing.T) {
+	// Day-by-day reference implementations
+	walk := func(t time.Time, n int) time.Time {
+		step := 1
+		if n < 0 {
+			step, n = -1, -n
+		}
+		for n > 0 {
+			t = t.AddDate(0, 0, step)
+			if !isWeekend(t) {
+				n--
+			}
+		}
+		return t
+	}
+	count := func(from, to time.Time) int {
+		n := 0
+		for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+			if !isWeekend(d) {
+				n++
+			}
+		}
+		return n
+	}
+
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+	for _, loc := range []*time.Location{time.UTC, london} {
+		// Two weeks of start days, across the October DST change
+		for day := 20; day < 34; day++ {
+			start := time.Date(2024, 10, day, 0, 0, 0, 0, loc)
+			for n := -23; n <= 23; n++ {
+				if got, want := addBusinessDays(start, n), walk(start, n); !got.Equal(want) {
+					t.Errorf("addBusinessDays(%s, %d) = %v, want %v", start.Format("Mon 2006-01-02"), n, got, want)
+				}
+				end := start.AddDate(0, 0, n)
+				want := count(start, end)
+				if n < 0 {
+					want = -count(end, start)
+				}
+				if got := businessDaysBetween(start, end); got != want {
+					t.Errorf("businessDaysBetween(%s, %s) = %d, want %d", start.Format("Mon 2006-01-02"), end.Format("Mon 2006-01-02"), got, want)
+				}
+			}
+		}
+	}
+
+	// Large counts are computed, not walked, and the count is capped
+	monday := time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)
+	got, err := callDateTimeFunction("addBusinessDays", []interface{}{monday, float64(maxBusinessDays)}, nil)
+	if err != nil {
+		t.Fatalf("addBusinessDays() error = %v", err)
+	}
+	if want := monday.AddDate(0, 0, maxBusinessDays/5*7); !got.(time.Time).Equal(want) {
+		t.Errorf("addBusinessDays(monday, %d) = %v, want %v", maxBusinessDays, got, want)
+	}
+	for _, n := range []float64{1e12, -1e12, math.Inf(1), math.NaN()} {
+		if _, err := callDateTimeFunction("addBusinessDays", []interface{}{monday, n}, nil); err == nil {
+			t.Errorf("addBusinessDays(monday, %v) succeeded, want an error", n)
+		}
+	}
+}
+
+func TestIsBeforeIsAfter(t *testing.T) {
+	ctx := &Context{
+		NodeResults: map[string]interface{}{
+			"order": map[string]interface{}{"created": "2024-01-15T10:00:00Z"},
+		},
+		Variables:   make(map[string]interface{}),
+		ContextVars: make(map[string]interface{}),
+	}
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{`isBefore(node.order.created, "2024-02-01")`, true},
+		{`isAfter(node.order.created, "2024-02-01")`, false},
+		{`isAfter(node.order.created, dateSub("2024-01-15T10:00:00Z", "PT1M"))`, true},
+		{`isBefore(node.order.created, "2024-02-01") && isAfter(node.order.created, "2024-01-01")`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := Evaluate(tt.expression, nil, ctx)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
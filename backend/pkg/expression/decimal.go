@@ -0,0 +1,574 @@
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
+)
+
+// ============================================================================
+// Exact Decimal Arithmetic
+// ============================================================================
+
+// defaultDecimalContext is used by EvaluateDecimal when the context has no
+// decimal settings of its own
+var defaultDecimalContext = decimal.Context{Scale: 16, Rounding: decimal.RoundHalfEven}
+
+// EvaluateDecimal evaluates an arithmetic expression with exact decimals.
+// It accepts the same syntax as EvaluateArithmetic; division and the
+// round() function follow ctx.Decimal's scale and rounding mode.
+func EvaluateDecimal(expression string, ctx *Context) (decimal.Decimal, error) {
+	if ctx == nil {
+		ctx = &Context{
+			NodeResults: make(map[string]interface{}),
+			Variables:   make(map[string]interface{}),
+			ContextVars: make(map[string]interface{}),
+		}
+	}
+
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return decimal.Zero, newExpressionErrorWithPos(expression, 0, "empty expression")
+	}
+
+	if err := ctx.enter(expression); err != nil {
+		return decimal.Zero, withExpression(expression, -1, err)
+	}
+	defer ctx.leave()
+
+	parser := &decimalParser{
+		arithmeticParser: &arithmeticParser{expression: expression, ctx: ctx},
+		dc:               ctx.decimalContext(),
+	}
+
+	result, err := parser.parseExpression()
+	if budgetErr := ctx.budgetErr(); budgetErr != nil {
+		return decimal.Zero, withExpression(expression, -1, budgetErr)
+	}
+	if err != nil {
+		return decimal.Zero, withExpression(expression, parser.pos, err)
+	}
+
+	parser.skipWhitespace()
+	if parser.pos < len(parser.expression) {
+		return decimal.Zero, newExpressionErrorWithPos(expression, parser.pos, fmt.Sprintf("unexpected characters at position %d: %s", parser.pos, parser.expression[parser.pos:]))
+	}
+	return result, nil
+}
+
+// evaluateArithmeticValue evaluates arithmetic in the context's numeric mode:
+// float64 by default, or an exact json.Number when ctx.Decimal is set
+func evaluateArithmeticValue(expression string, ctx *Context) (interface{}, error) {
+	if ctx == nil || ctx.Decimal == nil {
+		return EvaluateArithmetic(expression, ctx)
+	}
+	d, err := EvaluateDecimal(expression, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.Number(), nil
+}
+
+// decimalContext returns the context's decimal settings or the defaults
+func (c *Context) decimalContext() decimal.Context {
+	if c.Decimal != nil {
+		return *c.Decimal
+	}
+	return defaultDecimalContext
+}
+
+// compareExact compares two values as decimals when either is already an
+// exact number (json.Number or decimal.Decimal), so exact values are never
+// compared through float64
+func compareExact(left, right interface{}) (int, bool) {
+	if !isExactNumber(left) && !isExactNumber(right) {
+		return 0, false
+	}
+	l, ok := decimal.FromValue(left)
+	if !ok {
+		return 0, false
+	}
+	r, ok := decimal.FromValue(right)
+	if !ok {
+		return 0, false
+	}
+	return l.Cmp(r), true
+}
+
+func isExactNumber(v interface{}) bool {
+	switch v.(type) {
+	case json.Number, decimal.Decimal:
+		return true
+	}
+	return false
+}
+
+// decimalParser mirrors arithmeticParser with exact decimal values. Operand
+// resolution (paths, variables, value function calls) is shared.
+type decimalParser struct {
+	*arithmeticParser
+	dc decimal.Context
+}
+
+// parseExpression parses addition and subtraction (lowest precedence)
+func (p *decimalParser) parseExpression() (decimal.Decimal, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.expression) {
+			break
+		}
+
+		op := p.peek()
+		if op != '+' && op != '-' {
+			break
+		}
+
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		if op == '+' {
+			left = left.Add(right)
+		} else {
+			left = left.Sub(right)
+		}
+	}
+
+	return left, nil
+}
+
+// parseTerm parses multiplication, division, and modulo (higher precedence)
+func (p *decimalParser) parseTerm() (decimal.Decimal, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.expression) {
+			break
+		}
+
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			break
+		}
+
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		switch op {
+		case '*':
+			left = left.Mul(right)
+		case '/':
+			if right.IsZero() {
+				return decimal.Zero, fmt.Errorf("division by zero")
+			}
+			if left, err = p.dc.Div(left, right); err != nil {
+				return decimal.Zero, err
+			}
+		case '%':
+			if right.IsZero() {
+				return decimal.Zero, fmt.Errorf("modulo by zero")
+			}
+			if left, err = left.Mod(right); err != nil {
+				return decimal.Zero, err
+			}
+		}
+	}
+
+	return left, nil
+}
+
+// parseFactor parses unary operators, numbers, variables, function calls, and parentheses
+func (p *decimalParser) parseFactor() (decimal.Decimal, error) {
+	p.skipWhitespace()
+
+	if p.pos >= len(p.expression) {
+		return decimal.Zero, fmt.Errorf("unexpected end of expression")
+	}
+
+	// Handle unary operators
+	if p.peek() == '+' {
+		p.pos++
+		return p.parseFactor()
+	}
+	if p.peek() == '-' {
+		p.pos++
+		val, err := p.parseFactor()
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return val.Neg(), nil
+	}
+
+	// Handle parentheses
+	if p.peek() == '(' {
+		if val, source, ok, err := p.coalesceGroup(); err != nil {
+			return decimal.Zero, err
+		} else if ok {
+			num, ok := decimal.FromValue(val)
+			if !ok {
+				return decimal.Zero, fmt.Errorf("value '%v' from %s cannot be converted to number", val, source)
+			}
+			return num, nil
+		}
+		p.pos++
+		val, err := p.parseExpression()
+		if err != nil {
+			return decimal.Zero, err
+		}
+		p.skipWhitespace()
+		if p.pos >= len(p.expression) || p.peek() != ')' {
+			return decimal.Zero, fmt.Errorf("unmatched parentheses at position %d", p.pos)
+		}
+		p.pos++
+		return val, nil
+	}
+
+	// Handle numbers
+	if p.isDigit(p.peek()) {
+		return p.parseNumber()
+	}
+
+	// Handle identifiers (variables, node references, function calls)
+	if p.isLetter(p.peek()) {
+		return p.parseIdentifier()
+	}
+
+	return decimal.Zero, fmt.Errorf("unexpected character '%c' at position %d", p.peek(), p.pos)
+}
+
+// parseNumber parses a numeric literal exactly
+func (p *decimalParser) parseNumber() (decimal.Decimal, error) {
+	start := p.pos
+	hasDecimal := false
+
+	for p.pos < len(p.expression) {
+		ch := p.expression[p.pos]
+		if ch == '.' {
+			if hasDecimal {
+				break
+			}
+			hasDecimal = true
+			p.pos++
+		} else if p.isDigit(ch) {
+			p.pos++
+		} else {
+			break
+		}
+	}
+
+	numStr := p.expression[start:p.pos]
+	val, err := decimal.Parse(numStr)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid number '%s' at position %d", numStr, start)
+	}
+	return val, nil
+}
+
+// parseIdentifier parses an identifier (variable, node reference, or function call)
+func (p *decimalParser) parseIdentifier() (decimal.Decimal, error) {
+	start := p.pos
+	ident := p.readIdentifier()
+
+	p.skipWhitespace()
+	if p.pos < len(p.expression) && p.peek() == '(' && isArithmeticFunction(ident) {
+		return p.parseFunction(ident)
+	}
+
+	val, source, err := p.resolveOperand(start, ident)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	num, ok := decimal.FromValue(val)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("value '%v' from %s cannot be converted to number", val, source)
+	}
+	return num, nil
+}
+
+// parseFunction parses a function call
+func (p *decimalParser) parseFunction(name string) (decimal.Decimal, error) {
+	p.pos++ // skip '('
+
+	var args []decimal.Decimal
+
+	p.skipWhitespace()
+	if p.peek() == ')' {
+		p.pos++
+		return p.callFunction(name, args)
+	}
+
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return decimal.Zero, err
+		}
+		args = append(args, arg)
+
+		p.skipWhitespace()
+		if p.pos >= len(p.expression) {
+			return decimal.Zero, fmt.Errorf("unmatched parentheses in function call")
+		}
+
+		if p.peek() == ')' {
+			p.pos++
+			break
+		}
+
+		if p.peek() == ',' {
+			p.pos++
+			p.skipWhitespace()
+			continue
+		}
+
+		return decimal.Zero, fmt.Errorf("expected ',' or ')' at position %d", p.pos)
+	}
+
+	return p.callFunction(name, args)
+}
+
+// callFunction executes a math function with decimal arguments
+func (p *decimalParser) callFunction(name string, args []decimal.Decimal) (decimal.Decimal, error) {
+	switch name {
+	case "pow":
+		if len(args) != 2 {
+			return decimal.Zero, fmt.Errorf("pow() requires exactly 2 arguments, got %d", len(args))
+		}
+		// Integer exponents are exact; fractional ones fall back to float64
+		if n, ok := args[1].Int64(); ok && args[1].IsInteger() {
+			return p.dc.Pow(args[0], n)
+		}
+		return p.fromFloat(math.Pow(args[0].Float64(), args[1].Float64()))
+
+	case "sqrt":
+		if len(args) != 1 {
+			return decimal.Zero, fmt.Errorf("sqrt() requires exactly 1 argument, got %d", len(args))
+		}
+		if args[0].Sign() < 0 {
+			return decimal.Zero, fmt.Errorf("sqrt() of negative number")
+		}
+		return p.fromFloat(math.Sqrt(args[0].Float64()))
+
+	case "abs":
+		if len(args) != 1 {
+			return decimal.Zero, fmt.Errorf("abs() requires exactly 1 argument, got %d", len(args))
+		}
+		return args[0].Abs(), nil
+
+	case "floor":
+		if len(args) != 1 {
+			return decimal.Zero, fmt.Errorf("floor() requires exactly 1 argument, got %d", len(args))
+		}
+		return args[0].Round(0, decimal.RoundFloor), nil
+
+	case "ceil":
+		if len(args) != 1 {
+			return decimal.Zero, fmt.Errorf("ceil() requires exactly 1 argument, got %d", len(args))
+		}
+		return args[0].Round(0, decimal.RoundCeiling), nil
+
+	case "round":
+		// round(x) or round(x, places), using the configured rounding mode
+		if len(args) != 1 && len(args) != 2 {
+			return decimal.Zero, fmt.Errorf("round() requires 1 or 2 arguments, got %d", len(args))
+		}
+		places := int64(0)
+		if len(args) == 2 {
+			n, ok := args[1].Int64()
+			if !ok || !args[1].IsInteger() || n < 0 {
+				return decimal.Zero, fmt.Errorf("round() places must be a non-negative integer, got %s", args[1])
+			}
+			places = n
+		}
+		return p.dc.Round(args[0], int32(places)), nil
+
+	case "min", "max":
+		if len(args) < 2 {
+			return decimal.Zero, fmt.Errorf("%s() requires at least 2 arguments, got %d", name, len(args))
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			if (name == "min" && arg.Cmp(result) < 0) || (name == "max" && arg.Cmp(result) > 0) {
+				result = arg
+			}
+		}
+		return result, nil
+
+	default:
+		return decimal.Zero, fmt.Errorf("unknown function '%s'", name)
+	}
+}
+
+// fromFloat converts an inexact float result, rounded to the context's scale
+func (p *decimalParser) fromFloat(f float64) (decimal.Decimal, error) {
+	d, err := decimal.NewFromFloat(f)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return p.dc.Round(d, p.dc.Scale), nil
+}
+
+// ============================================================================
+// Decimal Value Functions
+// ============================================================================
+
+// isDecimalValueFunction reports whether a value function has an exact
+// decimal implementation
+func isDecimalValueFunction(name string) bool {
+	switch name {
+	case "sum", "avg", "min", "max", "round", "floor", "ceil", "abs":
+		return true
+	}
+	return false
+}
+
+// evaluateDecimalFunctionCall implements the numeric value functions with
+// exact decimals. Results are json.Number values.
+func evaluateDecimalFunctionCall(funcName string, argStrs []string, input interface{}, ctx *Context) (interface{}, error) {
+	dc := ctx.decimalContext()
+
+	// round(x, places) is the only two-argument form of the rounding functions
+	if funcName == "round" && len(argStrs) == 2 {
+		vals, _, err := decimalArgs(funcName, argStrs, input, ctx)
+		if err != nil {
+			return nil, err
+		}
+		places, ok := vals[1].Int64()
+		if !ok || !vals[1].IsInteger() || places < 0 {
+			return nil, fmt.Errorf("round() places must be a non-negative integer, got %s", vals[1])
+		}
+		return dc.Round(vals[0], int32(places)).Number(), nil
+	}
+
+	switch funcName {
+	case "round", "floor", "ceil", "abs":
+		if len(argStrs) != 1 {
+			return nil, fmt.Errorf("%s() requires exactly 1 argument, got %d", funcName, len(argStrs))
+		}
+		val, err := EvaluateExpression(argStrs[0], input, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s() argument evaluation failed: %w", funcName, err)
+		}
+
+		apply := func(v interface{}) (interface{}, error) {
+			d, ok := decimal.FromValue(v)
+			if !ok {
+				return nil, fmt.Errorf("%s() requires numeric value, got %T", funcName, v)
+			}
+			switch funcName {
+			case "round":
+				d = dc.Round(d, 0)
+			case "floor":
+				d = d.Round(0, decimal.RoundFloor)
+			case "ceil":
+				d = d.Round(0, decimal.RoundCeiling)
+			case "abs":
+				d = d.Abs()
+			}
+			return d.Number(), nil
+		}
+
+		// If it's an array, apply function to each element
+		if arr, ok := val.([]interface{}); ok {
+			result := make([]interface{}, len(arr))
+			for i, it := range arr {
+				if result[i], err = apply(it); err != nil {
+					return nil, err
+				}
+			}
+			return result, nil
+		}
+		return apply(val)
+	}
+
+	vals, fromArray, err := decimalArgs(funcName, argStrs, input, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch funcName {
+	case "sum":
+		total := decimal.Zero
+		for _, v := range vals {
+			total = total.Add(v)
+		}
+		return total.Number(), nil
+
+	case "avg":
+		if len(vals) == 0 {
+			return nil, fmt.Errorf("avg() on empty array")
+		}
+		total := decimal.Zero
+		for _, v := range vals {
+			total = total.Add(v)
+		}
+		avg, err := dc.Div(total, decimal.NewFromInt(int64(len(vals))))
+		if err != nil {
+			return nil, err
+		}
+		return avg.Number(), nil
+
+	default: // min, max
+		if len(argStrs) == 1 && !fromArray {
+			return nil, fmt.Errorf("%s() requires at least 2 arguments or an array, got 1 non-array value", funcName)
+		}
+		if len(vals) == 0 {
+			return nil, fmt.Errorf("%s() on empty array", funcName)
+		}
+		result := vals[0]
+		for _, v := range vals[1:] {
+			if (funcName == "min" && v.Cmp(result) < 0) || (funcName == "max" && v.Cmp(result) > 0) {
+				result = v
+			}
+		}
+		return result.Number(), nil
+	}
+}
+
+// decimalArgs evaluates function arguments as decimals. A single array
+// argument is expanded to its elements, which fromArray reports.
+func decimalArgs(funcName string, argStrs []string, input interface{}, ctx *Context) (vals []decimal.Decimal, fromArray bool, err error) {
+	if len(argStrs) == 0 {
+		return nil, false, fmt.Errorf("%s() requires at least 1 argument", funcName)
+	}
+
+	var raw []interface{}
+	for _, a := range argStrs {
+		v, err := EvaluateExpression(a, input, ctx)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s() argument evaluation failed: %w", funcName, err)
+		}
+		if arr, ok := v.([]interface{}); ok && len(argStrs) == 1 {
+			raw = arr
+			fromArray = true
+		} else {
+			raw = append(raw, v)
+		}
+	}
+
+	vals = make([]decimal.Decimal, len(raw))
+	for i, v := range raw {
+		d, ok := decimal.FromValue(v)
+		if !ok {
+			return nil, false, fmt.Errorf("%s() encountered non-numeric element: %T", funcName, v)
+		}
+		vals[i] = d
+	}
+	return vals, fromArray, nil
+}
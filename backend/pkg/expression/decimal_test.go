@@ -0,0 +1,94 @@
+package expression
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
+)
+
+func newDecimalContext(scale int32, rounding decimal.RoundingMode) *Context {
+	return &Context{
+		NodeResults: make(map[string]interface{}),
+		Variables: map[string]interface{}{
+			"price":  json.Number("19.99"),
+			"qty":    3.0,
+			"prices": []interface{}{0.1, 0.2, json.Number("0.3")},
+		},
+		ContextVars: make(map[string]interface{}),
+		Decimal:     &decimal.Context{Scale: scale, Rounding: rounding},
+	}
+}
+
+func TestEvaluateExpression_DecimalMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       json.Number
+	}{
+		{"float drift avoided", "0.1 + 0.2", "0.3"},
+		{"exact multiplication", "variables.price * variables.qty", "59.97"},
+		{"division at scale", "10 / 3", "3.3333"},
+		{"modulo", "7.5 % 2", "1.5"},
+		{"integer pow", "pow(1.1, 2)", "1.21"},
+		{"round with places", "round(2.345, 2)", "2.34"},
+		{"sum of mixed numbers", "sum(variables.prices)", "0.6"},
+		{"avg at scale", "avg(variables.prices)", "0.2"},
+		{"max of arguments", "max(0.1, 0.25, 0.2)", "0.25"},
+		{"coalesce operand", "(variables.missing ?? variables.price) * 2", "39.98"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newDecimalContext(4, decimal.RoundHalfEven)
+			got, err := EvaluateExpression(tt.expression, nil, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateExpression(%q) = %#v, want %#v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateExpression_DecimalPowLimit(t *testing.T) {
+	ctx := newDecimalContext(4, decimal.RoundHalfEven)
+	if _, err := EvaluateExpression("pow(pow(pow(7, 10000), 10000), 10000)", nil, ctx); err == nil {
+		t.Error("Expected nested powers to exceed the digit limit")
+	}
+
+	ctx.Decimal.MaxDigits = 100
+	if _, err := EvaluateExpression("pow(7, 200)", nil, ctx); err == nil {
+		t.Error("Expected pow(7, 200) to exceed a 100 digit limit")
+	}
+	if got, err := EvaluateExpression("pow(7, 100)", nil, ctx); err != nil {
+		t.Errorf("pow(7, 100) = %v, %v; want 85 digits", got, err)
+	}
+}
+
+func TestEvaluateExpression_FloatModeUnchanged(t *testing.T) {
+	got, err := EvaluateExpression("0.1 + 0.2", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := got.(float64); !ok {
+		t.Errorf("expected float64 without decimal settings, got %T", got)
+	}
+}
+
+func TestEvaluate_ExactComparison(t *testing.T) {
+	ctx := newDecimalContext(16, decimal.RoundHalfEven)
+	ok, err := Evaluate("0.1 + 0.2 == 0.3", nil, ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected 0.1 + 0.2 == 0.3 in decimal mode")
+	}
+
+	ok, err = Evaluate("variables.price > 19.98", nil, ctx)
+	if err != nil || !ok {
+		t.Errorf("expected json.Number comparison to succeed, got %v (err %v)", ok, err)
+	}
+}
@@ -40,6 +40,8 @@
 //	x && y              // Logical AND
 //	x || y              // Logical OR
 //	!x                  // Logical NOT
+//	a?.b                // Optional chaining: null if a is null or has no b
+//	a ?? b              // Null coalescing: b if a is null or missing
 //
 // String operations:
 //
@@ -94,9 +96,27 @@
 //
 // Date/Time functions:
 //
-//	now()               // Current timestamp
-//	date(timestamp)     // Format date
-//	parseDate(string)   // Parse date string
+//	now()                       // Current timestamp (optionally now(tz))
+//	parseDate(s, layout, tz)    // Parse date string (layout and tz optional)
+//	formatDate(t, layout, tz)   // Format date ("RFC3339", "2006-01-02" or "YYYY-MM-DD")
+//	inZone(t, "Europe/London")  // Convert to a time zone
+//	startOf(t, "month")         // Start of second/minute/hour/day/week/month/quarter/year
+//	endOf(t, "week")            // Last instant of the unit (weeks start on Monday)
+//	dateAdd(t, "P1DT2H")        // Add seconds or an ISO-8601 duration
+//	dateSub(t, "P1M")           // Subtract seconds or an ISO-8601 duration
+//	parseDuration("PT90M")      // ISO-8601 duration in seconds
+//	addBusinessDays(t, n)       // Add weekdays, skipping weekends
+//	businessDaysBetween(a, b)   // Weekdays in [a, b)
+//	isBefore(a, b), isAfter(a, b)
+//
+// Zone-less dates are interpreted in Context.Location (UTC when unset).
+//
+// Query functions:
+//
+//	query(value, "$.orders[?(@.total > 100)].id")  // JSONPath query
+//	query(node.http1, "items[*].tags[0]")         // JMESPath query
+//
+// Queries use package query and are bounded by Context.QueryLimits.
 //
 // # Usage Examples
 //
@@ -161,6 +181,17 @@
 //   - Reference errors: Undefined field or variable
 //   - Function errors: Invalid function arguments
 //
+// Every entry point (Evaluate, EvaluateExpression, EvaluateArithmetic,
+// EvaluateDecimal) returns failures as an *ExpressionError holding the full
+// expression and, where known, the position of the offending token. Use
+// errors.Is with the sentinel errors to classify them.
+//
+// A reference to a node, variable, field or index that does not exist
+// evaluates to null, so node.http1.user.address.city == "X" is false when
+// address is missing. With Context.Strict set it is an error matching
+// ErrFieldNotFound, ErrUndefinedVariable or ErrIndexOutOfBounds (see
+// IsMissingReference). ?. and ?? accept missing values even in strict mode.
+//
 // # Performance
 //
 //   - Expression parsing is optimized for common patterns
@@ -175,6 +206,30 @@
 //   - Memory limits: Protection against resource exhaustion
 //   - No recursion: Prevents stack overflow
 //
+// # Evaluation Budget and Determinism
+//
+// A Context may carry a Budget limiting evaluation steps, recursion depth and
+// the number of elements a single function (map, flatten, zip, ...) may
+// produce. Clones of a context share its budget, so one budget can bound every
+// expression a node evaluates. Exceeding a limit fails with an error matching
+// ErrExpressionTooComplex or ErrRecursionDepthExceeded (see IsLimitError).
+//
+//	ctx.Budget = expression.NewBudget(expression.Limits{MaxSteps: 10000})
+//
+// Context.Clock and Context.Rand replace the wall clock used by now() and the
+// random source used by sample(), making evaluation reproducible.
+//
+// # Decimal Mode
+//
+// Setting Context.Decimal switches arithmetic, sum(), avg(), min(), max() and
+// the rounding functions to exact decimals (see package decimal). Results are
+// json.Number values, so 0.1 + 0.2 yields exactly 0.3. Division, avg() and
+// round() use the context's scale and rounding mode; pow() with a fractional
+// exponent and sqrt() fall back to float64 rounded to that scale. Comparisons
+// involving a json.Number are always exact.
+//
+//	ctx.Decimal = &decimal.Context{Scale: 2, Rounding: decimal.RoundHalfUp}
+//
 // # Extension Points
 //
 // Custom functions can be registered:
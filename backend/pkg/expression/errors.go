@@ -3,6 +3,8 @@ package expression
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 )
 
 // Sentinel errors for expression evaluation
@@ -43,6 +45,8 @@ type ExpressionError struct {
 	Message    string // The error message
 	Context    string // Additional context about what was being done
 	Cause      error  // The underlying error (if any)
+
+	ref string // Offending reference, used to locate Position within an enclosing expression
 }
 
 // Error implements the error interface
@@ -133,3 +137,96 @@ func newExpressionErrorWithCause(expr string, message string, cause error) *Expr
 		Cause:      cause,
 	}
 }
+
+// newReferenceError creates an ExpressionError for a reference that could not
+// be resolved. Its position is filled in once the error reaches an entry point.
+func newReferenceError(ref string, cause error, format string, args ...interface{}) *ExpressionError {
+	return &ExpressionError{
+		Position: -1,
+		Message:  fmt.Sprintf(format, args...),
+		Cause:    cause,
+		ref:      ref,
+	}
+}
+
+// newMissingError creates the error for a node, variable or field that does
+// not exist. A name that could not have been written as a reference, such as
+// "age > 18" when a comparison was resolved as a path, is reported as a
+// syntax error instead, so that it never evaluates to null.
+func newMissingError(ref, name string, cause error, format string, args ...interface{}) *ExpressionError {
+	if !isReferenceName(name) {
+		return newReferenceError(ref, ErrSyntaxError, "invalid reference: %s", ref)
+	}
+	return newReferenceError(ref, cause, format, args...)
+}
+
+// isReferenceName reports whether name is usable as a path segment
+func isReferenceName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '$' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsMissingReference reports whether err means a referenced node, variable,
+// field or index does not exist. Such errors are only returned in strict
+// mode; otherwise missing references evaluate to null. Executors that
+// tolerate per-item failures should stop on these errors.
+func IsMissingReference(err error) bool {
+	return errors.Is(err, ErrFieldNotFound) ||
+		errors.Is(err, ErrUndefinedVariable) ||
+		errors.Is(err, ErrIndexOutOfBounds)
+}
+
+// withExpression returns err as an *ExpressionError for expr, so that every
+// entry point reports failures the same way. pos is the position reported
+// for plain errors (-1 if unknown). An ExpressionError raised while
+// evaluating a sub-expression is re-anchored to expr, and any text wrapped
+// around it is kept as its Context.
+func withExpression(expr string, pos int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var inner *ExpressionError
+	if !errors.As(err, &inner) {
+		return &ExpressionError{Expression: expr, Position: pos, Message: err.Error(), Cause: err}
+	}
+	if inner.Expression == expr && err == error(inner) {
+		return err
+	}
+
+	out := *inner
+	out.Expression = expr
+	out.Position = inner.locate(expr)
+	if err != error(inner) && out.Context == "" {
+		out.Context = strings.TrimRight(strings.TrimSuffix(err.Error(), inner.Error()), ": ")
+	}
+	return &out
+}
+
+// locate finds the error's position within an enclosing expression
+func (e *ExpressionError) locate(expr string) int {
+	if e.Expression != "" {
+		base := strings.Index(expr, e.Expression)
+		if base < 0 {
+			return -1
+		}
+		if e.Position >= 0 {
+			return base + e.Position
+		}
+		if i := strings.Index(e.Expression, e.ref); e.ref != "" && i >= 0 {
+			return base + i
+		}
+		return base
+	}
+	if e.ref != "" {
+		return strings.Index(expr, e.ref)
+	}
+	return -1
+}
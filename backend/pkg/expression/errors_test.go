@@ -155,12 +155,14 @@ func TestErrorMessageQuality(t *testing.T) {
 			name:       "undefined field",
 			expression: "item.nonexistent",
 			input:      map[string]interface{}{"name": "test"},
+			ctx:        &Context{Variables: make(map[string]interface{}), Strict: true},
 			wantErr:    true,
 		},
 		{
 			name:       "array index out of bounds",
 			expression: "item.items[10]",
 			input:      map[string]interface{}{"items": []interface{}{1, 2, 3}},
+			ctx:        &Context{Variables: make(map[string]interface{}), Strict: true},
 			wantErr:    true,
 		},
 		{
@@ -173,7 +175,7 @@ func TestErrorMessageQuality(t *testing.T) {
 			name:       "undefined variable",
 			expression: "variables.missing",
 			input:      nil,
-			ctx:        &Context{Variables: make(map[string]interface{})},
+			ctx:        &Context{Variables: make(map[string]interface{}), Strict: true},
 			wantErr:    true,
 		},
 	}
@@ -3,12 +3,17 @@
 package expression
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"math/rand"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/decimal"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/query"
 )
 
 // Context provides access to workflow state during expression evaluation
@@ -16,6 +21,25 @@ type Context struct {
 	NodeResults map[string]interface{} // Results from executed nodes
 	Variables   map[string]interface{} // Workflow variables
 	ContextVars map[string]interface{} // Context variables/constants
+	Location    *time.Location         // Time zone for zone-less date/time values (nil = UTC)
+	Clock       func() time.Time       // Source of now() (nil = wall clock)
+	Rand        *rand.Rand             // Source of randomness for sample() (nil = shared math/rand)
+	Budget      *Budget                // Resource limits shared across evaluations (nil = unlimited)
+	QueryLimits query.Limits           // Depth and result limits for query() (zero = unlimited)
+	Decimal     *decimal.Context       // Exact decimal arithmetic settings (nil = float64 arithmetic)
+	Strict      bool                   // Missing references are errors instead of null
+}
+
+// Clone returns a shallow copy of the context with its own copy of the
+// variables map, so callers can bind per-item values (item, input, index)
+// without leaking them into the original context.
+func (c *Context) Clone() *Context {
+	newCtx := *c
+	newCtx.Variables = make(map[string]interface{}, len(c.Variables)+2)
+	for k, v := range c.Variables {
+		newCtx.Variables[k] = v
+	}
+	return &newCtx
 }
 
 // Evaluate evaluates an expression and returns a boolean result
@@ -35,6 +59,21 @@ func Evaluate(expression string, input interface{}, ctx *Context) (bool, error)
 		}
 	}
 
+	if err := ctx.enter(expression); err != nil {
+		return false, withExpression(expression, -1, err)
+	}
+	defer ctx.leave()
+
+	result, err := evaluateBool(expression, input, ctx)
+	// A budget error may have been swallowed by a fallback path; surface it
+	if budgetErr := ctx.budgetErr(); budgetErr != nil {
+		return false, withExpression(expression, -1, budgetErr)
+	}
+	return result, withExpression(expression, -1, err)
+}
+
+// evaluateBool implements Evaluate once the context and budget are set up
+func evaluateBool(expression string, input interface{}, ctx *Context) (bool, error) {
 	// If input is provided, ensure it's available as both 'item' and 'input'
 	// This allows expressions like "item.items.length > 0" and "input % 2 == 0" to work
 	if input != nil {
@@ -42,15 +81,7 @@ func Evaluate(expression string, input interface{}, ctx *Context) (bool, error)
 		_, hasInput := ctx.Variables["input"]
 		if !hasItem || !hasInput {
 			// Create a copy of the context with item and input added
-			newCtx := &Context{
-				NodeResults: ctx.NodeResults,
-				Variables:   make(map[string]interface{}),
-				ContextVars: ctx.ContextVars,
-			}
-			// Copy existing variables
-			for k, v := range ctx.Variables {
-				newCtx.Variables[k] = v
-			}
+			newCtx := ctx.Clone()
 			// Add item and input
 			if !hasItem {
 				newCtx.Variables["item"] = input
@@ -66,26 +97,9 @@ func Evaluate(expression string, input interface{}, ctx *Context) (bool, error)
 	expression = strings.TrimSpace(expression)
 
 	// Handle parentheses - strip outer parentheses if expression is fully wrapped
-	if strings.HasPrefix(expression, "(") && strings.HasSuffix(expression, ")") {
-		// Check if these are matching outer parentheses
-		depth := 0
-		allWrapped := true
-		for i, ch := range expression {
-			if ch == '(' {
-				depth++
-			} else if ch == ')' {
-				depth--
-				// If we hit zero before the end, these aren't outer wrapping parentheses
-				if depth == 0 && i < len(expression)-1 {
-					allWrapped = false
-					break
-				}
-			}
-		}
-		if allWrapped && depth == 0 {
-			// Strip outer parentheses and re-evaluate
-			return Evaluate(expression[1:len(expression)-1], input, ctx)
-		}
+	if isWrapped(expression) {
+		// Strip outer parentheses and re-evaluate
+		return Evaluate(expression[1:len(expression)-1], input, ctx)
 	}
 
 	// Handle boolean constants
@@ -97,7 +111,9 @@ func Evaluate(expression string, input interface{}, ctx *Context) (bool, error)
 	}
 
 	// Check for boolean operators (&&, ||)
-	if result, ok := evaluateBooleanExpression(expression, input, ctx); ok {
+	if result, ok, err := evaluateBooleanExpression(expression, input, ctx); err != nil {
+		return false, err
+	} else if ok {
 		return result, nil
 	}
 
@@ -125,7 +141,9 @@ func Evaluate(expression string, input interface{}, ctx *Context) (bool, error)
 	}
 
 	// Check for comparison operators with references
-	if result, ok := evaluateComparison(expression, input, ctx); ok {
+	if result, ok, err := evaluateComparison(expression, input, ctx); err != nil {
+		return false, err
+	} else if ok {
 		return result, nil
 	}
 
@@ -135,6 +153,8 @@ func Evaluate(expression string, input interface{}, ctx *Context) (bool, error)
 		if boolVal, ok := val.(bool); ok {
 			return boolVal, nil
 		}
+	} else if ctx.Strict && IsMissingReference(err) {
+		return false, err
 	}
 
 	// Fallback to simple numeric comparison (backward compatible)
@@ -158,6 +178,20 @@ func EvaluateExpression(expression string, input interface{}, ctx *Context) (int
 		}
 	}
 
+	if err := ctx.enter(expression); err != nil {
+		return nil, withExpression(expression, -1, err)
+	}
+	defer ctx.leave()
+
+	result, err := evaluateValue(expression, input, ctx)
+	if budgetErr := ctx.budgetErr(); budgetErr != nil {
+		return nil, withExpression(expression, -1, budgetErr)
+	}
+	return result, withExpression(expression, -1, err)
+}
+
+// evaluateValue implements EvaluateExpression once the context and budget are set up
+func evaluateValue(expression string, input interface{}, ctx *Context) (interface{}, error) {
 	// If input is provided, ensure it's available as both 'item' and 'input'
 	// in the variables map. This aligns with the docs that allow using either
 	// identifier to reference the current input value.
@@ -166,14 +200,7 @@ func EvaluateExpression(expression string, input interface{}, ctx *Context) (int
 		_, hasInput := ctx.Variables["input"]
 		if !hasItem || !hasInput {
 			// Create a shallow copy of the context and variables map
-			newCtx := &Context{
-				NodeResults: ctx.NodeResults,
-				Variables:   make(map[string]interface{}),
-				ContextVars: ctx.ContextVars,
-			}
-			for k, v := range ctx.Variables {
-				newCtx.Variables[k] = v
-			}
+			newCtx := ctx.Clone()
 			if !hasItem {
 				newCtx.Variables["item"] = input
 			}
@@ -186,9 +213,15 @@ func EvaluateExpression(expression string, input interface{}, ctx *Context) (int
 
 	expression = strings.TrimSpace(expression)
 
+	// Strip outer parentheses if the expression is fully wrapped, e.g. (a ?? b)
+	if isWrapped(expression) {
+		return EvaluateExpression(expression[1:len(expression)-1], input, ctx)
+	}
+
 	// Handle ternary operator: condition ? value1 : value2
-	if idx := strings.Index(expression, "?"); idx > 0 {
-		colonIdx := strings.Index(expression[idx:], ":")
+	// Quoted text is skipped so query strings such as "$[?(@.a > 1)]" are left alone.
+	if idx := indexTernary(expression); idx > 0 {
+		colonIdx := indexUnquoted(expression[idx:], ':')
 		if colonIdx > 0 {
 			colonIdx += idx
 			condition := strings.TrimSpace(expression[:idx])
@@ -209,6 +242,11 @@ func EvaluateExpression(expression string, input interface{}, ctx *Context) (int
 		}
 	}
 
+	// Handle null coalescing: value ?? fallback
+	if idx := findOperator(expression, "??"); idx != -1 {
+		return coalesce(expression[:idx], expression[idx+2:], input, ctx)
+	}
+
 	// Handle value-returning function calls like map(), avg()
 	if idx := strings.Index(expression, "("); idx > 0 && strings.HasSuffix(expression, ")") {
 		funcName := strings.TrimSpace(expression[:idx])
@@ -217,26 +255,91 @@ func EvaluateExpression(expression string, input interface{}, ctx *Context) (int
 		}
 	}
 
+	// Handle date/time and null-handling functions, e.g. formatDate(now(), "date")
+	if idx := strings.Index(expression, "("); idx > 0 && isSingleCall(expression, idx) {
+		funcName := strings.TrimSpace(expression[:idx])
+		if isFunctionCall(funcName) {
+			return evaluateDateTimeFunctionCall(expression, input, ctx)
+		}
+	}
+
+	// Comparisons and logical operators evaluate to a boolean; their
+	// operands may use ?? and ?.
+	if hasComparison(expression) {
+		return Evaluate(expression, input, ctx)
+	}
+
 	// Try arithmetic evaluation first (handles +, -, *, /, %, math functions)
+	var arithErr error
 	if containsArithmeticOp(expression) {
-		result, err := EvaluateArithmetic(expression, ctx)
+		result, err := evaluateArithmeticValue(expression, ctx)
 		if err == nil {
 			return result, nil
 		}
 		// If arithmetic fails, continue to other evaluation methods
+		arithErr = err
 	}
 
 	// Try to resolve as a value reference (variable, node, context, field access)
-	if val, err := resolveValue(expression, input, ctx); err == nil {
+	val, err := resolveReference(expression, input, ctx)
+	if err == nil {
 		return val, nil
 	}
+	if IsMissingReference(err) && referencePath.MatchString(expression) {
+		if ctx.Strict {
+			return nil, err
+		}
+		return nil, nil
+	}
+	// In strict mode, arithmetic over a missing reference reports that reference
+	if arithErr != nil && IsMissingReference(arithErr) {
+		return nil, arithErr
+	}
 
 	// Try as literal value
 	if val, ok := parseLiteral(expression); ok {
 		return val, nil
 	}
 
-	return nil, fmt.Errorf("could not evaluate expression: %s", expression)
+	cause := ErrEvaluationFailed
+	if arithErr != nil {
+		cause = arithErr
+	}
+	return nil, &ExpressionError{
+		Expression: expression,
+		Position:   0,
+		Message:    fmt.Sprintf("could not evaluate expression: %s", expression),
+		Cause:      cause,
+	}
+}
+
+// hasComparison reports whether expr has a comparison or logical operator
+// outside parentheses and quoted text
+func hasComparison(expr string) bool {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "&&", "||"} {
+		if findOperator(expr, op) != -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// referencePath matches expressions that are a single reference path, such
+// as node.id?.field or item.tags[0], which resolve to nil when missing
+var referencePath = regexp.MustCompile(`^[A-Za-z_][\w-]*(\??\.[\w$-]+|(\?\.)?\[\d+\])*$`)
+
+// coalesce implements left ?? right: the left value unless it is null or a
+// missing reference, otherwise the right value. Missing references on the
+// left are not errors even in strict mode.
+func coalesce(left, right string, input interface{}, ctx *Context) (interface{}, error) {
+	val, err := EvaluateExpression(strings.TrimSpace(left), input, ctx)
+	if err != nil && !IsMissingReference(err) {
+		return nil, err
+	}
+	if err == nil && val != nil {
+		return val, nil
+	}
+	return EvaluateExpression(strings.TrimSpace(right), input, ctx)
 }
 
 // containsArithmeticOp checks if expression contains arithmetic operators
@@ -305,8 +408,10 @@ func ExtractDependencies(expression string) []string {
 	return dependencies
 }
 
-// evaluateBooleanExpression handles && and || operators
-func evaluateBooleanExpression(expr string, input interface{}, ctx *Context) (bool, bool) {
+// evaluateBooleanExpression handles && and || operators. Operand errors are
+// returned in strict mode; otherwise the expression is left to the other
+// evaluation paths.
+func evaluateBooleanExpression(expr string, input interface{}, ctx *Context) (bool, bool, error) {
 	// Check for || (OR) - lower precedence
 	if idx := findOperator(expr, "||"); idx != -1 {
 		left := strings.TrimSpace(expr[:idx])
@@ -314,15 +419,15 @@ func evaluateBooleanExpression(expr string, input interface{}, ctx *Context) (bo
 
 		leftResult, err := Evaluate(left, input, ctx)
 		if err != nil {
-			return false, false
+			return false, false, strictError(ctx, err)
 		}
 
 		rightResult, err := Evaluate(right, input, ctx)
 		if err != nil {
-			return false, false
+			return false, false, strictError(ctx, err)
 		}
 
-		return leftResult || rightResult, true
+		return leftResult || rightResult, true, nil
 	}
 
 	// Check for && (AND) - higher precedence
@@ -332,37 +437,55 @@ func evaluateBooleanExpression(expr string, input interface{}, ctx *Context) (bo
 
 		leftResult, err := Evaluate(left, input, ctx)
 		if err != nil {
-			return false, false
+			return false, false, strictError(ctx, err)
 		}
 
 		rightResult, err := Evaluate(right, input, ctx)
 		if err != nil {
-			return false, false
+			return false, false, strictError(ctx, err)
 		}
 
-		return leftResult && rightResult, true
+		return leftResult && rightResult, true, nil
 	}
 
-	return false, false
+	return false, false, nil
+}
+
+// strictError returns err in strict mode and nil otherwise, for evaluation
+// paths that historically fell back to other interpretations on failure
+func strictError(ctx *Context, err error) error {
+	if ctx.Strict {
+		return err
+	}
+	return nil
 }
 
-// findOperator finds the position of an operator, respecting parentheses
+// findOperator finds the position of an operator, respecting parentheses and quoted text
 func findOperator(expr string, op string) int {
 	depth := 0
+	var quoteCh byte
 	for i := 0; i <= len(expr)-len(op); i++ {
-		if expr[i] == '(' {
+		switch c := expr[i]; {
+		case quoteCh != 0:
+			if c == quoteCh {
+				quoteCh = 0
+			}
+		case c == '"' || c == '\'':
+			quoteCh = c
+		case c == '(':
 			depth++
-		} else if expr[i] == ')' {
+		case c == ')':
 			depth--
-		} else if depth == 0 && strings.HasPrefix(expr[i:], op) {
+		case depth == 0 && strings.HasPrefix(expr[i:], op):
 			return i
 		}
 	}
 	return -1
 }
 
-// evaluateComparison handles comparison operations
-func evaluateComparison(expr string, input interface{}, ctx *Context) (bool, bool) {
+// evaluateComparison handles comparison operations. In strict mode a missing
+// reference in either operand is returned as an error.
+func evaluateComparison(expr string, input interface{}, ctx *Context) (bool, bool, error) {
 	operators := []string{"==", "!=", "<=", ">=", "<", ">"}
 
 	for _, op := range operators {
@@ -396,28 +519,65 @@ func evaluateComparison(expr string, input interface{}, ctx *Context) (bool, boo
 			// Resolve left operand
 			leftVal, err = resolveValue(left, input, ctx)
 			if err != nil {
-				return false, false
+				return false, false, missingError(err)
 			}
 		}
 
 		// Resolve right operand
 		rightVal, err := resolveValue(right, input, ctx)
 		if err != nil {
-			return false, false
+			return false, false, missingError(err)
 		}
 
 		// Perform comparison
 		result := compareValues(leftVal, rightVal, op)
-		return result, true
+		return result, true, nil
 	}
 
-	return false, false
+	return false, false, nil
+}
+
+// missingError returns err if it is a missing reference, which resolveValue
+// only reports in strict mode, and nil otherwise
+func missingError(err error) error {
+	if IsMissingReference(err) {
+		return err
+	}
+	return nil
 }
 
-// resolveValue resolves a value from a reference or literal
+// resolveValue resolves a value from a reference or literal. Outside strict
+// mode a missing reference resolves to nil.
 func resolveValue(ref string, input interface{}, ctx *Context) (interface{}, error) {
+	val, err := resolveReference(ref, input, ctx)
+	if err != nil && !ctx.Strict && IsMissingReference(err) {
+		return nil, nil
+	}
+	return val, err
+}
+
+// resolveReference resolves a value from a reference or literal, reporting
+// missing references as errors
+func resolveReference(ref string, input interface{}, ctx *Context) (val interface{}, err error) {
 	ref = strings.TrimSpace(ref)
 
+	// Errors raised while resolving point at the whole reference
+	defer func() {
+		if e, ok := err.(*ExpressionError); ok && e.Expression == "" {
+			e.ref = ref
+		}
+	}()
+
+	// Check for null coalescing: value ?? fallback
+	if idx := findOperator(ref, "??"); idx != -1 {
+		return coalesce(ref[:idx], ref[idx+2:], input, ctx)
+	}
+
+	// Check for a parenthesized operand, e.g. (a ?? b) == "x"
+	if isWrapped(ref) {
+		return EvaluateExpression(ref[1:len(ref)-1], input, ctx)
+	}
+
 	// Check for string literals
 	if (strings.HasPrefix(ref, "\"") && strings.HasSuffix(ref, "\"")) ||
 		(strings.HasPrefix(ref, "'") && strings.HasSuffix(ref, "'")) {
@@ -429,17 +589,25 @@ func resolveValue(ref string, input interface{}, ctx *Context) (interface{}, err
 		return num, nil
 	}
 
-	// Check for boolean literals
+	// Check for boolean and null literals
 	if ref == "true" {
 		return true, nil
 	}
 	if ref == "false" {
 		return false, nil
 	}
+	if ref == "null" {
+		return nil, nil
+	}
+
+	// Check for value-returning function calls, e.g. query(input, "$.total") > 100
+	if idx := strings.Index(ref, "("); idx > 0 && isSingleCall(ref, idx) && isValueFunction(strings.TrimSpace(ref[:idx])) {
+		return evaluateValueFunctionCall(ref, input, ctx)
+	}
 
 	// Check if it contains arithmetic operators or function calls - try arithmetic evaluation
 	if containsArithmetic(ref) {
-		if val, err := EvaluateArithmetic(ref, ctx); err == nil {
+		if val, err := evaluateArithmeticValue(ref, ctx); err == nil {
 			return val, nil
 		}
 		// If arithmetic evaluation fails, continue with reference resolution
@@ -456,67 +624,71 @@ func resolveValue(ref string, input interface{}, ctx *Context) (interface{}, err
 		// First, remove "variables." prefix
 		refWithoutPrefix := ref[10:] // Remove "variables."
 
-		// Find the variable name (up to first . or [)
+		// Find the variable name (up to first ., ?. or [)
 		varEndIdx := len(refWithoutPrefix)
-		if dotIdx := strings.Index(refWithoutPrefix, "."); dotIdx != -1 {
-			varEndIdx = dotIdx
-		}
-		if bracketIdx := strings.Index(refWithoutPrefix, "["); bracketIdx != -1 && bracketIdx < varEndIdx {
-			varEndIdx = bracketIdx
+		if idx := strings.IndexAny(refWithoutPrefix, ".?["); idx != -1 {
+			varEndIdx = idx
 		}
 
 		varName := refWithoutPrefix[:varEndIdx]
+		fieldPath := refWithoutPrefix[varEndIdx:]
 		val, ok := ctx.Variables[varName]
 		if !ok {
-			return nil, fmt.Errorf("variable not found: %s", varName)
+			if strings.HasPrefix(fieldPath, "?") {
+				return nil, nil
+			}
+			return nil, newMissingError(ref, varName, ErrUndefinedVariable, "variable not found: %s", varName)
 		}
 
 		// If just variables.name, return the whole value
-		if varEndIdx == len(refWithoutPrefix) {
+		if fieldPath == "" {
 			return val, nil
 		}
 
 		// Navigate to nested field/index using resolveFieldPath
-		fieldPath := refWithoutPrefix[varEndIdx:]
 		// Remove leading . if present
 		fieldPath = strings.TrimPrefix(fieldPath, ".")
 		return resolveFieldPath(fieldPath, val)
 	}
 
-	// Check for context reference: context.name
+	// Check for context reference: context.name or context.name.field
 	if strings.HasPrefix(ref, "context.") {
 		ctxName := ref[8:] // Remove "context." prefix
 		if val, ok := ctx.ContextVars[ctxName]; ok {
 			return val, nil
 		}
-		return nil, fmt.Errorf("context variable not found: %s", ctxName)
-	}
-
-	// Check for input reference
-	if ref == "input" {
-		return input, nil
-	}
-
-	// Check for item reference: item.field or just item
-	// This is the preferred syntax for filter expressions (e.g., "item.age >= 18")
-	if strings.HasPrefix(ref, "item.") || ref == "item" {
-		if ref == "item" {
-			return input, nil
+		baseName := ctxName
+		if idx := strings.IndexAny(ctxName, ".?["); idx > 0 {
+			baseName = ctxName[:idx]
+			fieldPath := ctxName[idx:]
+			if val, ok := ctx.ContextVars[baseName]; ok {
+				return resolveFieldPath(strings.TrimPrefix(fieldPath, "."), val)
+			}
+			if strings.HasPrefix(fieldPath, "?") {
+				return nil, nil
+			}
 		}
-		// Navigate to nested field starting from input
-		fieldPath := ref[5:] // Remove "item." prefix
-		return resolveFieldPath(fieldPath, input)
+		return nil, newMissingError(ref, baseName, ErrUndefinedVariable, "context variable not found: %s", ctxName)
 	}
 
-	// Check for input reference: input.field or just input
-	// Many condition expressions use 'input' as the value placeholder (e.g., "input > 10" or "input.age > 18")
-	if strings.HasPrefix(ref, "input.") || ref == "input" {
-		if ref == "input" {
-			return input, nil
+	// Check for item and input references: item.field, input?.field or just item/input.
+	// "item" is the preferred syntax for filter expressions (e.g., "item.age >= 18");
+	// many condition expressions use 'input' as the value placeholder (e.g., "input > 10").
+	for _, root := range []string{"item", "input"} {
+		if ref != root && !strings.HasPrefix(ref, root+".") && !strings.HasPrefix(ref, root+"?.") {
+			continue
+		}
+		// Inside arithmetic the current value is only bound as a variable
+		value := input
+		if value == nil {
+			value = ctx.Variables[root]
+		}
+		if ref == root {
+			return value, nil
 		}
 		// Navigate to nested field starting from input
-		fieldPath := ref[6:] // Remove "input." prefix
-		return resolveFieldPath(fieldPath, input)
+		fieldPath := strings.TrimPrefix(ref[len(root):], ".")
+		return resolveFieldPath(fieldPath, value)
 	}
 
 	// Check for direct field access on input object (e.g., "age", "name", "profile.verified")
@@ -528,19 +700,30 @@ func resolveValue(ref string, input interface{}, ctx *Context) (interface{}, err
 		}
 	}
 
-	return nil, fmt.Errorf("unknown reference: %s", ref)
+	return nil, newReferenceError(ref, ErrEvaluationFailed, "unknown reference: %s", ref)
 }
 
 // resolveFieldPath resolves a field path (e.g., "age" or "profile.verified") from an object
 // Also supports special properties like .length for arrays and strings
 // and array indexing like users[0] or tags[1].name
 // and method calls like .toUpperCase(), .toLowerCase(), .includes(), .startsWith(), .endsWith()
+// A segment reached through ?. (optional chaining) yields nil when its
+// receiver is nil or lacks the field, instead of an error.
 func resolveFieldPath(path string, obj interface{}) (interface{}, error) {
 	// Split by dots, but respect parentheses and brackets
 	parts := splitFieldPath(path)
 	current := obj
 
 	for _, field := range parts {
+		// Handle optional chaining: receiver?.field
+		optional := strings.HasPrefix(field, "?")
+		if optional {
+			field = field[1:]
+			if current == nil {
+				return nil, nil
+			}
+		}
+
 		// Handle method calls: fieldName()
 		if strings.HasSuffix(field, ")") && strings.Contains(field, "(") {
 			methodIdx := strings.Index(field, "(")
@@ -579,31 +762,37 @@ func resolveFieldPath(path string, obj interface{}) (interface{}, error) {
 
 			// First navigate to the field if there's a field name
 			if fieldName != "" {
-				if m, ok := current.(map[string]interface{}); ok {
-					if val, exists := m[fieldName]; exists {
-						current = val
-					} else {
-						return nil, fmt.Errorf("field not found: %s", fieldName)
-					}
-				} else {
-					return nil, fmt.Errorf("cannot access field %s on non-object", fieldName)
+				val, err := fieldValue(current, fieldName, optional)
+				if err != nil {
+					return nil, err
+				}
+				if val == nil && optional {
+					current = nil
+					continue
 				}
+				current = val
 			}
 
 			// Parse index
 			index, err := strconv.Atoi(indexStr)
 			if err != nil {
-				return nil, fmt.Errorf("invalid array index: %s", indexStr)
+				return nil, newReferenceError(field, ErrSyntaxError, "invalid array index: %s", indexStr)
 			}
 
 			// Access array element
 			if arr, ok := current.([]interface{}); ok {
 				if index < 0 || index >= len(arr) {
-					return nil, fmt.Errorf("array index %d out of bounds (length: %d)", index, len(arr))
+					if optional {
+						current = nil
+						continue
+					}
+					return nil, newReferenceError(field, ErrIndexOutOfBounds, "array index %d out of bounds (length: %d)", index, len(arr))
 				}
 				current = arr[index]
+			} else if current == nil {
+				return nil, newReferenceError(field, ErrFieldNotFound, "cannot use array indexing on null")
 			} else {
-				return nil, fmt.Errorf("cannot use array indexing on non-array type: %T", current)
+				return nil, newReferenceError(field, ErrInvalidFieldAccess, "cannot use array indexing on non-array type: %T", current)
 			}
 			continue
 		}
@@ -620,20 +809,33 @@ func resolveFieldPath(path string, obj interface{}) (interface{}, error) {
 		}
 
 		// Regular field access
-		if m, ok := current.(map[string]interface{}); ok {
-			if val, exists := m[field]; exists {
-				current = val
-			} else {
-				return nil, fmt.Errorf("field not found: %s", field)
-			}
-		} else {
-			return nil, fmt.Errorf("cannot access field %s on non-object", field)
+		val, err := fieldValue(current, field, optional)
+		if err != nil {
+			return nil, err
 		}
+		current = val
 	}
 
 	return current, nil
 }
 
+// fieldValue returns the named field of an object. A missing field is an
+// error unless optional is set, in which case it yields nil.
+func fieldValue(current interface{}, field string, optional bool) (interface{}, error) {
+	m, ok := current.(map[string]interface{})
+	if !ok {
+		if current == nil {
+			return nil, newMissingError(field, field, ErrFieldNotFound, "cannot access field %s on null", field)
+		}
+		return nil, newReferenceError(field, ErrInvalidFieldAccess, "cannot access field %s on non-object", field)
+	}
+	val, exists := m[field]
+	if !exists && !optional {
+		return nil, newMissingError(field, field, ErrFieldNotFound, "field not found: %s", field)
+	}
+	return val, nil
+}
+
 // splitFieldPath splits a field path by dots, respecting parentheses and brackets
 func splitFieldPath(path string) []string {
 	var parts []string
@@ -657,6 +859,18 @@ func splitFieldPath(path string) []string {
 		case ']':
 			bracketDepth--
 			current.WriteByte(ch)
+		case '?':
+			if parenDepth == 0 && bracketDepth == 0 && i+1 < len(path) && path[i+1] == '.' {
+				// Optional chaining separator: the next part is marked with a leading '?'
+				if current.Len() > 0 {
+					parts = append(parts, current.String())
+					current.Reset()
+				}
+				current.WriteByte('?')
+				i++
+			} else {
+				current.WriteByte(ch)
+			}
 		case '.':
 			if parenDepth == 0 && bracketDepth == 0 {
 				// This is a field separator
@@ -845,26 +1059,33 @@ func containsArithmetic(expr string) bool {
 // resolveNodeReference resolves node.id.field references
 // Supports special properties like .length and array indexing
 func resolveNodeReference(ref string, ctx *Context) (interface{}, error) {
-	// Parse: node.id.field or just node.id
-	parts := strings.Split(ref, ".")
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid node reference: %s", ref)
+	// Parse: node.id.field, node.id?.field or just node.id
+	rest := strings.TrimPrefix(ref, "node.")
+	idEnd := len(rest)
+	if idx := strings.IndexAny(rest, ".?"); idx != -1 {
+		idEnd = idx
+	}
+	if idEnd == 0 {
+		return nil, newReferenceError(ref, ErrSyntaxError, "invalid node reference: %s", ref)
 	}
 
-	nodeID := parts[1]
+	nodeID := rest[:idEnd]
+	fieldPath := rest[idEnd:]
 	result, ok := ctx.NodeResults[nodeID]
 	if !ok {
-		return nil, fmt.Errorf("node result not found: %s", nodeID)
+		if strings.HasPrefix(fieldPath, "?") {
+			return nil, nil
+		}
+		return nil, newMissingError(ref, nodeID, ErrUndefinedVariable, "node result not found: %s", nodeID)
 	}
 
 	// If just node.id, return the whole result
-	if len(parts) == 2 {
+	if fieldPath == "" {
 		return result, nil
 	}
 
 	// Navigate to nested field using resolveFieldPath (supports .length and array indexing)
-	fieldPath := strings.Join(parts[2:], ".")
-	return resolveFieldPath(fieldPath, result)
+	return resolveFieldPath(strings.TrimPrefix(fieldPath, "."), result)
 }
 
 // compareValues compares two values using the specified operator
@@ -897,6 +1118,11 @@ func compareEquality(left, right interface{}) bool {
 		return leftTime.Equal(rightTime)
 	}
 
+	// Exact numbers compare as decimals
+	if cmp, ok := compareExact(left, right); ok {
+		return cmp == 0
+	}
+
 	// Try numeric comparison
 	leftNum, leftIsNum := toFloat64(left)
 	rightNum, rightIsNum := toFloat64(right)
@@ -940,6 +1166,21 @@ func compareNumeric(left, right interface{}, op string) bool {
 		return false
 	}
 
+	// Exact numbers compare as decimals
+	if cmp, ok := compareExact(left, right); ok {
+		switch op {
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		}
+		return false
+	}
+
 	leftNum, leftOk := toFloat64(left)
 	rightNum, rightOk := toFloat64(right)
 
@@ -974,6 +1215,12 @@ func toFloat64(val interface{}) (float64, bool) {
 		return float64(v), true
 	case int32:
 		return float64(v), true
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, true
+		}
+	case decimal.Decimal:
+		return v.Float64(), true
 	case string:
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			return f, true
@@ -1044,7 +1291,9 @@ func splitArguments(s string) []string {
 // isFunctionCall checks if a name is a known function
 func isFunctionCall(name string) bool {
 	dateFuncs := []string{"now", "parseDate", "toEpoch", "toEpochMillis", "fromEpoch", "fromEpochMillis",
-		"dateDiff", "dateAdd", "year", "month", "day", "hour", "minute", "isNull", "coalesce"}
+		"dateDiff", "dateAdd", "dateSub", "year", "month", "day", "hour", "minute", "isNull", "coalesce",
+		"formatDate", "inZone", "startOf", "endOf", "parseDuration",
+		"addBusinessDays", "businessDaysBetween", "isBusinessDay", "isBefore", "isAfter"}
 	for _, fn := range dateFuncs {
 		if name == fn {
 			return true
@@ -1062,21 +1311,8 @@ func evaluateFunctionCall(expr string, input interface{}, ctx *Context) (bool, e
 	}
 
 	funcName := strings.TrimSpace(expr[:idx])
-	argsStr := expr[idx+1 : len(expr)-1] // Remove "funcName(" and ")"
-
-	// Parse arguments
-	argStrs := splitArguments(argsStr)
-	var args []interface{}
-	for _, argStr := range argStrs {
-		val, err := resolveValue(argStr, input, ctx)
-		if err != nil {
-			return false, fmt.Errorf("error resolving argument '%s': %w", argStr, err)
-		}
-		args = append(args, val)
-	}
 
-	// Call the function
-	result, err := callDateTimeFunction(funcName, args, ctx)
+	result, err := evaluateDateTimeFunctionCall(expr, input, ctx)
 	if err != nil {
 		return false, err
 	}
@@ -1090,6 +1326,122 @@ func evaluateFunctionCall(expr string, input interface{}, ctx *Context) (bool, e
 	return false, fmt.Errorf("function %s() did not return a boolean value", funcName)
 }
 
+// evaluateDateTimeFunctionCall evaluates the arguments of a date/time or null-handling
+// function call and invokes it, returning the function's value. Arguments may
+// themselves be nested function calls, e.g. formatDate(startOf(now(), "day"), "date").
+func evaluateDateTimeFunctionCall(expr string, input interface{}, ctx *Context) (interface{}, error) {
+	idx := strings.Index(expr, "(")
+	if idx == -1 || !strings.HasSuffix(expr, ")") {
+		return nil, fmt.Errorf("invalid function call: %s", expr)
+	}
+
+	funcName := strings.TrimSpace(expr[:idx])
+	argsStr := expr[idx+1 : len(expr)-1] // Remove "funcName(" and ")"
+
+	var args []interface{}
+	for _, argStr := range splitArgumentsRespectingParens(argsStr) {
+		val, err := EvaluateExpression(argStr, input, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving argument '%s': %w", argStr, err)
+		}
+		args = append(args, val)
+	}
+
+	return callDateTimeFunction(funcName, args, ctx)
+}
+
+// indexUnquoted returns the index of the first ch outside quoted text, or -1
+func indexUnquoted(expr string, ch byte) int {
+	var quoteCh byte
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case quoteCh != 0:
+			if c == quoteCh {
+				quoteCh = 0
+			}
+		case c == '"' || c == '\'':
+			quoteCh = c
+		case c == ch:
+			return i
+		}
+	}
+	return -1
+}
+
+// isWrapped reports whether expr is entirely enclosed in one pair of
+// parentheses, e.g. "(a + b)" but not "(a) + (b)"
+func isWrapped(expr string) bool {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return false
+	}
+	depth := 0
+	for i, ch := range expr {
+		if ch == '(' {
+			depth++
+		} else if ch == ')' {
+			depth--
+			// If we hit zero before the end, these aren't outer wrapping parentheses
+			if depth == 0 && i < len(expr)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// indexTernary returns the index of the ternary '?' in expr, skipping quoted
+// text and the ?. and ?? operators, or -1 if there is none
+func indexTernary(expr string) int {
+	var quoteCh byte
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case quoteCh != 0:
+			if c == quoteCh {
+				quoteCh = 0
+			}
+		case c == '"' || c == '\'':
+			quoteCh = c
+		case c == '?':
+			if i+1 < len(expr) && (expr[i+1] == '.' || expr[i+1] == '?') {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// isSingleCall reports whether expr is exactly one function call whose
+// opening parenthesis is at openIdx, e.g. "year(x)" but not "year(x) + year(y)".
+func isSingleCall(expr string, openIdx int) bool {
+	depth := 0
+	inQuotes := false
+	var quoteCh byte
+	for i := openIdx; i < len(expr); i++ {
+		ch := expr[i]
+		if inQuotes {
+			if ch == quoteCh {
+				inQuotes = false
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'':
+			inQuotes = true
+			quoteCh = ch
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i == len(expr)-1
+			}
+		}
+	}
+	return false
+}
+
 // evaluateSimpleCondition evaluates simple numeric conditions (backward compatible)
 func evaluateSimpleCondition(condition string, value interface{}) bool {
 	// Handle boolean constants
@@ -1188,9 +1540,14 @@ func EvaluateArithmetic(expression string, ctx *Context) (float64, error) {
 
 	expression = strings.TrimSpace(expression)
 	if expression == "" {
-		return 0, fmt.Errorf("empty expression")
+		return 0, newExpressionErrorWithPos(expression, 0, "empty expression")
 	}
 
+	if err := ctx.enter(expression); err != nil {
+		return 0, withExpression(expression, -1, err)
+	}
+	defer ctx.leave()
+
 	// Parse and evaluate the expression
 	parser := &arithmeticParser{
 		expression: expression,
@@ -1199,14 +1556,17 @@ func EvaluateArithmetic(expression string, ctx *Context) (float64, error) {
 	}
 
 	result, err := parser.parseExpression()
+	if budgetErr := ctx.budgetErr(); budgetErr != nil {
+		return 0, withExpression(expression, -1, budgetErr)
+	}
 	if err != nil {
-		return 0, err
+		return 0, withExpression(expression, parser.pos, err)
 	}
 
 	// Make sure we consumed the entire expression
 	parser.skipWhitespace()
 	if parser.pos < len(parser.expression) {
-		return 0, fmt.Errorf("unexpected characters at position %d: %s", parser.pos, parser.expression[parser.pos:])
+		return 0, newExpressionErrorWithPos(expression, parser.pos, fmt.Sprintf("unexpected characters at position %d: %s", parser.pos, parser.expression[parser.pos:]))
 	}
 
 	return result, nil
@@ -1320,6 +1680,15 @@ func (p *arithmeticParser) parseFactor() (float64, error) {
 
 	// Handle parentheses
 	if p.peek() == '(' {
+		if val, source, ok, err := p.coalesceGroup(); err != nil {
+			return 0, err
+		} else if ok {
+			num, ok := toFloat64(val)
+			if !ok {
+				return 0, fmt.Errorf("value '%v' from %s cannot be converted to number", val, source)
+			}
+			return num, nil
+		}
 		p.pos++
 		val, err := p.parseExpression()
 		if err != nil {
@@ -1378,93 +1747,111 @@ func (p *arithmeticParser) parseNumber() (float64, error) {
 // parseIdentifier parses an identifier (variable, node reference, or function call)
 func (p *arithmeticParser) parseIdentifier() (float64, error) {
 	start := p.pos
+	ident := p.readIdentifier()
+
+	// Known arithmetic functions are parsed with arithmetic rules
+	p.skipWhitespace()
+	if p.pos < len(p.expression) && p.peek() == '(' && isArithmeticFunction(ident) {
+		return p.parseFunction(ident)
+	}
 
-	// Read identifier
+	val, source, err := p.resolveOperand(start, ident)
+	if err != nil {
+		return 0, err
+	}
+	num, ok := toFloat64(val)
+	if !ok {
+		return 0, fmt.Errorf("value '%v' from %s cannot be converted to number", val, source)
+	}
+	return num, nil
+}
+
+// readIdentifier reads an identifier at the current position
+func (p *arithmeticParser) readIdentifier() string {
+	start := p.pos
 	for p.pos < len(p.expression) && (p.isLetter(p.expression[p.pos]) || p.isDigit(p.expression[p.pos]) || p.expression[p.pos] == '_') {
 		p.pos++
 	}
+	return p.expression[start:p.pos]
+}
 
-	ident := p.expression[start:p.pos]
+// isArithmeticFunction reports whether name is a math function handled by the arithmetic parser
+func isArithmeticFunction(name string) bool {
+	switch name {
+	case "pow", "sqrt", "abs", "floor", "ceil", "round", "min", "max":
+		return true
+	}
+	return false
+}
 
-	// Check if it's a function call
-	p.skipWhitespace()
+// resolveOperand resolves the value of a non-arithmetic operand that starts
+// with ident: a value function call (avg, sum, map, ...), a dotted path or
+// array index (variables.x, node.id.field, item[0]), or a bare identifier.
+// It returns the raw value and a description of its source for error messages.
+func (p *arithmeticParser) resolveOperand(start int, ident string) (interface{}, string, error) {
+	// Value function call: extract the full call with balanced parentheses
 	if p.pos < len(p.expression) && p.peek() == '(' {
-		// If it's a known arithmetic function, parse via arithmetic rules
-		switch ident {
-		case "pow", "sqrt", "abs", "floor", "ceil", "round", "min", "max":
-			return p.parseFunction(ident)
-		default:
-			// Otherwise, treat it as a value function (e.g., avg, sum, map, sort, etc.).
-			// Extract the full function call substring with balanced parentheses
-			funcStart := start
-			i := p.pos // current '('
-			depth := 0
-			inQuotes := false
-			var quoteCh byte
-			for i < len(p.expression) {
-				ch := p.expression[i]
-				if inQuotes {
-					if ch == quoteCh {
-						inQuotes = false
-					}
-				} else {
-					if ch == '\'' || ch == '"' {
-						inQuotes = true
-						quoteCh = ch
-					} else if ch == '(' {
-						depth++
-					} else if ch == ')' {
-						depth--
-						if depth == 0 {
-							// include this ')'
-							i++
-							break
-						}
+		funcStart := start
+		i := p.pos // current '('
+		depth := 0
+		inQuotes := false
+		var quoteCh byte
+		for i < len(p.expression) {
+			ch := p.expression[i]
+			if inQuotes {
+				if ch == quoteCh {
+					inQuotes = false
+				}
+			} else {
+				if ch == '\'' || ch == '"' {
+					inQuotes = true
+					quoteCh = ch
+				} else if ch == '(' {
+					depth++
+				} else if ch == ')' {
+					depth--
+					if depth == 0 {
+						// include this ')'
+						i++
+						break
 					}
 				}
-				i++
-			}
-			if depth != 0 {
-				return 0, fmt.Errorf("unmatched parentheses in function call starting at position %d", funcStart)
 			}
+			i++
+		}
+		if depth != 0 {
+			return nil, "", fmt.Errorf("unmatched parentheses in function call starting at position %d", funcStart)
+		}
 
-			callStr := p.expression[funcStart:i]
-			// Advance parser position to just after the function call
-			p.pos = i
+		callStr := p.expression[funcStart:i]
+		// Advance parser position to just after the function call
+		p.pos = i
 
-			// Evaluate the value function call using the general evaluator
-			// Pass through the current input value from context if available
-			var currentInput interface{}
-			if v, ok := p.ctx.Variables["input"]; ok {
-				currentInput = v
-			} else if v, ok := p.ctx.Variables["item"]; ok {
-				currentInput = v
-			}
-			val, err := EvaluateExpression(callStr, currentInput, p.ctx)
-			if err != nil {
-				return 0, fmt.Errorf("failed to evaluate function '%s' in arithmetic expression: %w", ident, err)
-			}
-			num, ok := toFloat64(val)
-			if !ok {
-				return 0, fmt.Errorf("function '%s' did not return a numeric value", ident)
-			}
-			return num, nil
+		// Evaluate the value function call using the general evaluator
+		val, err := EvaluateExpression(callStr, p.currentInput(), p.ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to evaluate function '%s' in arithmetic expression: %w", ident, err)
 		}
+		return val, fmt.Sprintf("function '%s'", ident), nil
 	}
 
-	// Check if it's a dotted path or array index (variables.x, node.id.field, item[0])
-	if p.pos < len(p.expression) && (p.peek() == '.' || p.peek() == '[') {
+	// Check if it's a dotted path or array index (variables.x, node.id?.field, item[0])
+	if p.pos < len(p.expression) && (p.peek() == '.' || p.peek() == '[' || p.peekOptionalChain()) {
 		// Read the full path including array indexing
 		path := ident
 		for p.pos < len(p.expression) {
-			if p.peek() == '.' {
+			if p.peek() == '.' || p.peekOptionalChain() {
+				if p.peek() == '?' {
+					p.pos++ // skip '?'
+					path += "?"
+				}
 				p.pos++ // skip '.'
 				start := p.pos
 				for p.pos < len(p.expression) && (p.isLetter(p.expression[p.pos]) || p.isDigit(p.expression[p.pos]) || p.expression[p.pos] == '_' || p.expression[p.pos] == '-') {
 					p.pos++
 				}
 				if p.pos == start {
-					return 0, fmt.Errorf("expected identifier after '.' at position %d", p.pos)
+					return nil, "", fmt.Errorf("expected identifier after '.' at position %d", p.pos)
 				}
 				path += "." + p.expression[start:p.pos]
 			} else if p.peek() == '[' {
@@ -1475,10 +1862,10 @@ func (p *arithmeticParser) parseIdentifier() (float64, error) {
 					p.pos++
 				}
 				if p.pos == start {
-					return 0, fmt.Errorf("expected number in array index at position %d", p.pos)
+					return nil, "", fmt.Errorf("expected number in array index at position %d", p.pos)
 				}
 				if p.pos >= len(p.expression) || p.peek() != ']' {
-					return 0, fmt.Errorf("expected ']' at position %d", p.pos)
+					return nil, "", fmt.Errorf("expected ']' at position %d", p.pos)
 				}
 				path += "[" + p.expression[start:p.pos] + "]"
 				p.pos++ // skip ']'
@@ -1496,33 +1883,64 @@ func (p *arithmeticParser) parseIdentifier() (float64, error) {
 				val, err = resolveValue(varPath, nil, p.ctx)
 			}
 			if err != nil {
-				return 0, err
+				return nil, "", err
 			}
 		}
-
-		// Convert to float64
-		num, ok := toFloat64(val)
-		if !ok {
-			return 0, fmt.Errorf("value '%v' at '%s' cannot be converted to number", val, path)
-		}
-
-		return num, nil
+		return val, fmt.Sprintf("'%s'", path), nil
 	}
 
 	// Handle bare identifiers (like "item", "accumulator")
 	// Try to resolve as variables.ident
 	val, err := resolveValue("variables."+ident, nil, p.ctx)
 	if err != nil {
-		return 0, fmt.Errorf("unknown identifier '%s' at position %d (tried as variables.%s but got: %v)", ident, start, ident, err)
+		return nil, "", fmt.Errorf("unknown identifier '%s' at position %d (tried as variables.%s but got: %v)", ident, start, ident, err)
 	}
+	return val, fmt.Sprintf("'variables.%s'", ident), nil
+}
 
-	// Convert to float64
-	num, ok := toFloat64(val)
-	if !ok {
-		return 0, fmt.Errorf("value '%v' at 'variables.%s' cannot be converted to number", val, ident)
+// currentInput returns the input value of the expression, for operands
+// evaluated with the general evaluator
+func (p *arithmeticParser) currentInput() interface{} {
+	if v, ok := p.ctx.Variables["input"]; ok {
+		return v
 	}
+	return p.ctx.Variables["item"]
+}
 
-	return num, nil
+// coalesceGroup evaluates a parenthesized null coalescing operand such as
+// (node.id?.field ?? 0) at the current '(' with the general evaluator.
+// ok is false, and the position unchanged, for other parenthesized groups.
+func (p *arithmeticParser) coalesceGroup() (val interface{}, source string, ok bool, err error) {
+	depth := 0
+	var quoteCh byte
+	for i := p.pos; i < len(p.expression); i++ {
+		switch c := p.expression[i]; {
+		case quoteCh != 0:
+			if c == quoteCh {
+				quoteCh = 0
+			}
+		case c == '"' || c == '\'':
+			quoteCh = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			inner := p.expression[p.pos+1 : i]
+			if findOperator(inner, "??") == -1 {
+				return nil, "", false, nil
+			}
+			val, err := EvaluateExpression(inner, p.currentInput(), p.ctx)
+			if err != nil {
+				return nil, "", false, err
+			}
+			p.pos = i + 1
+			return val, fmt.Sprintf("'(%s)'", inner), true, nil
+		}
+	}
+	return nil, "", false, nil
 }
 
 // parseFunction parses a function call
@@ -1653,6 +2071,11 @@ func (p *arithmeticParser) peek() byte {
 	return p.expression[p.pos]
 }
 
+// peekOptionalChain reports whether the parser is at a ?. operator
+func (p *arithmeticParser) peekOptionalChain() bool {
+	return p.pos+1 < len(p.expression) && p.expression[p.pos] == '?' && p.expression[p.pos+1] == '.'
+}
+
 // isDigit checks if a character is a digit
 func (p *arithmeticParser) isDigit(ch byte) bool {
 	return ch >= '0' && ch <= '9'
@@ -1679,6 +2102,9 @@ func isValueFunction(name string) bool {
 	// Array manipulation functions
 	case "sort", "slice", "sample", "unique", "zip", "reverse", "flatten":
 		return true
+	// Path queries over JSON-like data
+	case "query":
+		return true
 	default:
 		return false
 	}
@@ -1743,6 +2169,10 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 	argsStr := expr[idx+1 : len(expr)-1]
 	argStrs := splitArgumentsRespectingParens(argsStr)
 
+	if ctx != nil && ctx.Decimal != nil && isDecimalValueFunction(funcName) {
+		return evaluateDecimalFunctionCall(funcName, argStrs, input, ctx)
+	}
+
 	switch funcName {
 	case "map":
 		// map(arrayExpr, itemExpr)
@@ -1769,6 +2199,10 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 			return nil, fmt.Errorf("map() first argument must be an array, got %T", arrVal)
 		}
 
+		if err := ctx.checkOutput("map", len(arr)); err != nil {
+			return nil, err
+		}
+
 		// For each element, evaluate the second argument with the element bound as item
 		result := make([]interface{}, 0, len(arr))
 		for _, el := range arr {
@@ -2046,6 +2480,11 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 			return nil, fmt.Errorf("sort() requires an array, got %T", val)
 		}
 
+		// The bubble sort below is quadratic; charge for the comparisons up front
+		if err := ctx.charge("sort", len(arr)*len(arr)/2); err != nil {
+			return nil, err
+		}
+
 		// Create a copy to avoid modifying original
 		sorted := make([]interface{}, len(arr))
 		copy(sorted, arr)
@@ -2097,6 +2536,10 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 			return nil, fmt.Errorf("unique() requires an array, got %T", val)
 		}
 
+		if err := ctx.charge("unique", len(arr)); err != nil {
+			return nil, err
+		}
+
 		seen := make(map[interface{}]bool)
 		unique := make([]interface{}, 0)
 		for _, item := range arr {
@@ -2125,17 +2568,31 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 		}
 
 		flattened := make([]interface{}, 0)
-		var flattenRecursive func([]interface{})
-		flattenRecursive = func(items []interface{}) {
+		var flattenRecursive func([]interface{}, int) error
+		flattenRecursive = func(items []interface{}, depth int) error {
+			if err := ctx.checkDepth("flatten", depth); err != nil {
+				return err
+			}
+			if err := ctx.charge("flatten", len(items)); err != nil {
+				return err
+			}
 			for _, item := range items {
 				if subArr, ok := item.([]interface{}); ok {
-					flattenRecursive(subArr)
+					if err := flattenRecursive(subArr, depth+1); err != nil {
+						return err
+					}
 				} else {
 					flattened = append(flattened, item)
+					if err := ctx.checkOutput("flatten", len(flattened)); err != nil {
+						return err
+					}
 				}
 			}
+			return nil
+		}
+		if err := flattenRecursive(arr, 1); err != nil {
+			return nil, err
 		}
-		flattenRecursive(arr)
 		return flattened, nil
 
 	case "slice":
@@ -2237,28 +2694,19 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 		if n == 0 {
 			return []interface{}{}, nil
 		}
-		if n >= len(arr) {
-			// Return shuffled copy of entire array
-			result := make([]interface{}, len(arr))
-			copy(result, arr)
-			// Simple shuffle
-			for i := len(result) - 1; i > 0; i-- {
-				j := int(float64(i+1) * 0.5) // Simplified "random" - in production use math/rand
-				result[i], result[j] = result[j], result[i]
-			}
-			return result, nil
+		if n > len(arr) {
+			n = len(arr)
 		}
 
-		// Sample n elements (simplified - in production use proper random sampling)
-		result := make([]interface{}, n)
-		step := len(arr) / n
-		if step < 1 {
-			step = 1
-		}
-		for i := 0; i < n && i*step < len(arr); i++ {
-			result[i] = arr[i*step]
+		// Partial Fisher-Yates shuffle over a copy; with a seeded Context.Rand
+		// the sample is reproducible across runs
+		shuffled := make([]interface{}, len(arr))
+		copy(shuffled, arr)
+		for i := 0; i < n; i++ {
+			j := i + ctx.intn(len(shuffled)-i)
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 		}
-		return result, nil
+		return shuffled[:n], nil
 
 	case "zip":
 		// zip(array1, array2, ...) - combine arrays into array of arrays
@@ -2283,6 +2731,10 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 			}
 		}
 
+		if err := ctx.checkOutput("zip", maxLen); err != nil {
+			return nil, err
+		}
+
 		result := make([]interface{}, maxLen)
 		for i := 0; i < maxLen; i++ {
 			tuple := make([]interface{}, len(arrays))
@@ -2297,6 +2749,40 @@ func evaluateValueFunctionCall(expr string, input interface{}, ctx *Context) (in
 		}
 		return result, nil
 
+	case "query":
+		// query(value, "path") - JSONPath / JMESPath query, e.g. query(input, "$.orders[?(@.total > 100)].id")
+		if len(argStrs) != 2 {
+			return nil, fmt.Errorf("query() requires exactly 2 arguments (value, path), got %d", len(argStrs))
+		}
+
+		data, err := EvaluateExpression(argStrs[0], input, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("query() value argument evaluation failed: %w", err)
+		}
+
+		pathVal, err := EvaluateExpression(argStrs[1], input, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("query() path argument evaluation failed: %w", err)
+		}
+		source, ok := pathVal.(string)
+		if !ok {
+			return nil, fmt.Errorf("query() path must be a string, got %T", pathVal)
+		}
+
+		result, err := query.Evaluate(source, data, ctx.QueryLimits)
+		if err != nil {
+			return nil, err
+		}
+		if arr, ok := result.([]interface{}); ok {
+			if err := ctx.charge("query", len(arr)); err != nil {
+				return nil, err
+			}
+			if err := ctx.checkOutput("query", len(arr)); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+
 	default:
 		return nil, fmt.Errorf("unknown value function: %s", funcName)
 	}
@@ -2345,40 +2831,10 @@ func compareForSort(a, b interface{}) int {
 
 // Date/time helper functions for the expression evaluator
 
-// parseDateTime parses various date/time formats into time.Time
+// parseDateTime parses various date/time formats into time.Time.
+// Zone-less values are interpreted as UTC; see parseDateTimeIn.
 func parseDateTime(value interface{}) (time.Time, error) {
-	switch v := value.(type) {
-	case time.Time:
-		return v, nil
-	case string:
-		// Try common formats
-		formats := []string{
-			time.RFC3339,
-			time.RFC3339Nano,
-			time.RFC822,
-			time.RFC1123,
-			"2006-01-02",
-			"2006-01-02 15:04:05",
-			"2006-01-02T15:04:05",
-		}
-		for _, format := range formats {
-			if t, err := time.Parse(format, v); err == nil {
-				return t, nil
-			}
-		}
-		return time.Time{}, fmt.Errorf("unable to parse date/time: %s", v)
-	case float64:
-		// Assume Unix timestamp in seconds
-		return time.Unix(int64(v), 0), nil
-	case int64:
-		// Unix timestamp in seconds
-		return time.Unix(v, 0), nil
-	case int:
-		// Unix timestamp in seconds
-		return time.Unix(int64(v), 0), nil
-	default:
-		return time.Time{}, fmt.Errorf("unsupported date/time type: %T", value)
-	}
+	return parseDateTimeIn(value, time.UTC)
 }
 
 // isNull checks if a value is null/nil
@@ -2387,28 +2843,212 @@ func isNull(value interface{}) bool {
 }
 
 // callDateTimeFunction handles date/time specific functions
-func callDateTimeFunction(name string, args []interface{}, _ *Context) (interface{}, error) {
+func callDateTimeFunction(name string, args []interface{}, ctx *Context) (interface{}, error) {
+	loc := contextLocation(ctx)
+
 	switch name {
 	case "now":
-		// Current timestamp
-		if len(args) != 0 {
-			return nil, fmt.Errorf("now() takes no arguments, got %d", len(args))
+		// Current timestamp, optionally in a given time zone: now() or now(tz)
+		if len(args) > 1 {
+			return nil, fmt.Errorf("now() takes at most 1 argument, got %d", len(args))
 		}
-		return time.Now(), nil
+		if len(args) == 1 {
+			tz, err := locationArg("now", args[0])
+			if err != nil {
+				return nil, err
+			}
+			return ctx.now().In(tz), nil
+		}
+		return ctx.now().In(loc), nil
 
 	case "parseDate":
-		// Parse date string
+		// Parse date string: parseDate(value), parseDate(value, layout) or parseDate(value, layout, tz)
+		if len(args) < 1 || len(args) > 3 {
+			return nil, fmt.Errorf("parseDate() requires 1 to 3 arguments, got %d", len(args))
+		}
+		parseLoc := loc
+		if len(args) == 3 {
+			tz, err := locationArg("parseDate", args[2])
+			if err != nil {
+				return nil, err
+			}
+			parseLoc = tz
+		}
+		if len(args) >= 2 && args[1] != nil && args[1] != "" {
+			layout, ok := args[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("parseDate() layout must be a string, got %T", args[1])
+			}
+			str, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("parseDate() with a layout requires a string value, got %T", args[0])
+			}
+			t, err := time.ParseInLocation(resolveLayout(layout), str, parseLoc)
+			if err != nil {
+				return nil, fmt.Errorf("parseDate(): %w", err)
+			}
+			return t, nil
+		}
+		return parseDateTimeIn(args[0], parseLoc)
+
+	case "formatDate":
+		// Format a date: formatDate(t, layout) or formatDate(t, layout, tz)
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("formatDate() requires 2 or 3 arguments, got %d", len(args))
+		}
+		t, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("formatDate() first argument: %w", err)
+		}
+		layout, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("formatDate() layout must be a string, got %T", args[1])
+		}
+		if len(args) == 3 {
+			tz, err := locationArg("formatDate", args[2])
+			if err != nil {
+				return nil, err
+			}
+			t = t.In(tz)
+		}
+		return t.Format(resolveLayout(layout)), nil
+
+	case "inZone":
+		// Convert a date to another time zone: inZone(t, "Europe/London")
+		if len(args) != 2 {
+			return nil, fmt.Errorf("inZone() requires exactly 2 arguments, got %d", len(args))
+		}
+		t, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("inZone() first argument: %w", err)
+		}
+		tz, err := locationArg("inZone", args[1])
+		if err != nil {
+			return nil, err
+		}
+		return t.In(tz), nil
+
+	case "startOf", "endOf":
+		// Calendar boundaries: startOf(t, unit) or startOf(t, unit, tz)
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("%s() requires 2 or 3 arguments, got %d", name, len(args))
+		}
+		t, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s() first argument: %w", name, err)
+		}
+		unit, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s() unit must be a string, got %T", name, args[1])
+		}
+		if len(args) == 3 {
+			tz, err := locationArg(name, args[2])
+			if err != nil {
+				return nil, err
+			}
+			t = t.In(tz)
+		}
+		if name == "startOf" {
+			return startOfUnit(t, unit)
+		}
+		return endOfUnit(t, unit)
+
+	case "parseDuration":
+		// Parse an ISO-8601 duration into seconds: parseDuration("P1DT2H") -> 93600
 		if len(args) != 1 {
-			return nil, fmt.Errorf("parseDate() requires exactly 1 argument, got %d", len(args))
+			return nil, fmt.Errorf("parseDuration() requires exactly 1 argument, got %d", len(args))
+		}
+		str, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("parseDuration() requires a string, got %T", args[0])
 		}
-		return parseDateTime(args[0])
+		d, err := parseISODuration(strings.ToUpper(str))
+		if err != nil {
+			return nil, err
+		}
+		return d.seconds(), nil
+
+	case "dateSub":
+		// Subtract seconds or an ISO-8601 duration from a date
+		if len(args) != 2 {
+			return nil, fmt.Errorf("dateSub() requires exactly 2 arguments, got %d", len(args))
+		}
+		t, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("dateSub() first argument: %w", err)
+		}
+		shift, err := durationArg("dateSub", args[1])
+		if err != nil {
+			return nil, err
+		}
+		return shift(t, -1), nil
+
+	case "addBusinessDays":
+		// Add weekdays, skipping Saturdays and Sundays
+		if len(args) != 2 {
+			return nil, fmt.Errorf("addBusinessDays() requires exactly 2 arguments, got %d", len(args))
+		}
+		t, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("addBusinessDays() first argument: %w", err)
+		}
+		n, ok := toFloat64(args[1])
+		if !ok {
+			return nil, fmt.Errorf("addBusinessDays() second argument must be numeric")
+		}
+		if !(math.Abs(n) <= maxBusinessDays) {
+			return nil, fmt.Errorf("addBusinessDays() day count must be between -%d and %d", maxBusinessDays, maxBusinessDays)
+		}
+		return addBusinessDays(t, int(n)), nil
+
+	case "businessDaysBetween":
+		// Count weekdays in [from, to)
+		if len(args) != 2 {
+			return nil, fmt.Errorf("businessDaysBetween() requires exactly 2 arguments, got %d", len(args))
+		}
+		from, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("businessDaysBetween() first argument: %w", err)
+		}
+		to, err := parseDateTimeIn(args[1], loc)
+		if err != nil {
+			return nil, fmt.Errorf("businessDaysBetween() second argument: %w", err)
+		}
+		return float64(businessDaysBetween(from, to)), nil
+
+	case "isBusinessDay":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("isBusinessDay() requires exactly 1 argument, got %d", len(args))
+		}
+		t, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, err
+		}
+		return !isWeekend(t), nil
+
+	case "isBefore", "isAfter":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s() requires exactly 2 arguments, got %d", name, len(args))
+		}
+		t1, err := parseDateTimeIn(args[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s() first argument: %w", name, err)
+		}
+		t2, err := parseDateTimeIn(args[1], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s() second argument: %w", name, err)
+		}
+		if name == "isBefore" {
+			return t1.Before(t2), nil
+		}
+		return t1.After(t2), nil
 
 	case "toEpoch":
 		// Convert to Unix timestamp (seconds)
 		if len(args) != 1 {
 			return nil, fmt.Errorf("toEpoch() requires exactly 1 argument, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, err
 		}
@@ -2419,7 +3059,7 @@ func callDateTimeFunction(name string, args []interface{}, _ *Context) (interfac
 		if len(args) != 1 {
 			return nil, fmt.Errorf("toEpochMillis() requires exactly 1 argument, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, err
 		}
@@ -2452,37 +3092,37 @@ func callDateTimeFunction(name string, args []interface{}, _ *Context) (interfac
 		if len(args) != 2 {
 			return nil, fmt.Errorf("dateDiff() requires exactly 2 arguments, got %d", len(args))
 		}
-		t1, err := parseDateTime(args[0])
+		t1, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, fmt.Errorf("dateDiff() first argument: %w", err)
 		}
-		t2, err := parseDateTime(args[1])
+		t2, err := parseDateTimeIn(args[1], loc)
 		if err != nil {
 			return nil, fmt.Errorf("dateDiff() second argument: %w", err)
 		}
 		return float64(t1.Sub(t2).Seconds()), nil
 
 	case "dateAdd":
-		// Add seconds to a date
+		// Add seconds or an ISO-8601 duration ("P1M", "PT90M") to a date
 		if len(args) != 2 {
 			return nil, fmt.Errorf("dateAdd() requires exactly 2 arguments, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, fmt.Errorf("dateAdd() first argument: %w", err)
 		}
-		seconds, ok := toFloat64(args[1])
-		if !ok {
-			return nil, fmt.Errorf("dateAdd() second argument must be numeric")
+		shift, err := durationArg("dateAdd", args[1])
+		if err != nil {
+			return nil, err
 		}
-		return t.Add(time.Duration(seconds) * time.Second), nil
+		return shift(t, 1), nil
 
 	case "year":
 		// Get year from date
 		if len(args) != 1 {
 			return nil, fmt.Errorf("year() requires exactly 1 argument, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, err
 		}
@@ -2493,7 +3133,7 @@ func callDateTimeFunction(name string, args []interface{}, _ *Context) (interfac
 		if len(args) != 1 {
 			return nil, fmt.Errorf("month() requires exactly 1 argument, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, err
 		}
@@ -2504,7 +3144,7 @@ func callDateTimeFunction(name string, args []interface{}, _ *Context) (interfac
 		if len(args) != 1 {
 			return nil, fmt.Errorf("day() requires exactly 1 argument, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, err
 		}
@@ -2515,7 +3155,7 @@ func callDateTimeFunction(name string, args []interface{}, _ *Context) (interfac
 		if len(args) != 1 {
 			return nil, fmt.Errorf("hour() requires exactly 1 argument, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, err
 		}
@@ -2526,7 +3166,7 @@ func callDateTimeFunction(name string, args []interface{}, _ *Context) (interfac
 		if len(args) != 1 {
 			return nil, fmt.Errorf("minute() requires exactly 1 argument, got %d", len(args))
 		}
-		t, err := parseDateTime(args[0])
+		t, err := parseDateTimeIn(args[0], loc)
 		if err != nil {
 			return nil, err
 		}
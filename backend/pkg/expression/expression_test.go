@@ -1,9 +1,13 @@
 package expression
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"testing"
 	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/query"
 )
 
 func TestEvaluate_SimpleComparisons(t *testing.T) {
@@ -640,3 +644,52 @@ func TestCoalesceFunction(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryFunction(t *testing.T) {
+	response := map[string]interface{}{
+		"orders": []interface{}{
+			map[string]interface{}{"id": "o1", "total": 50.0},
+			map[string]interface{}{"id": "o2", "total": 150.0},
+			map[string]interface{}{"id": "o3", "total": 250.0},
+		},
+	}
+	ctx := &Context{
+		NodeResults: map[string]interface{}{"http1": response},
+		Variables:   make(map[string]interface{}),
+		ContextVars: make(map[string]interface{}),
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+	}{
+		{"JSONPath filter on input", `query(input, "$.orders[?(@.total > 100)].id")`, []interface{}{"o2", "o3"}},
+		{"JMESPath projection on node result", `query(node.http1, "orders[*].id")`, []interface{}{"o1", "o2", "o3"}},
+		{"definite path", `query(input, "orders[-1].total")`, 250.0},
+		{"used in map", `map(query(input, "orders[?total < ` + "`200`" + `]"), item.id)`, []interface{}{"o1", "o2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateExpression(tt.expression, response, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("EvaluateExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	ok, err := Evaluate(`query(input, "orders[0].total") < 100`, response, ctx)
+	if err != nil || !ok {
+		t.Errorf("expected query() to work in conditions, got %v (err: %v)", ok, err)
+	}
+
+	limited := ctx.Clone()
+	limited.QueryLimits = query.Limits{MaxResults: 2}
+	if _, err := EvaluateExpression(`query(input, "orders[*]")`, response, limited); !errors.Is(err, query.ErrTooManyResults) {
+		t.Errorf("expected query result limit error, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package expression
+
+import (
+	"errors"
+	"testing"
+)
+
+func newNullSafeContext(strict bool) *Context {
+	return &Context{
+		NodeResults: map[string]interface{}{
+			"http1": map[string]interface{}{
+				"user": map[string]interface{}{
+					"name":    "Ada",
+					"address": nil,
+					"tags":    []interface{}{"admin"},
+				},
+			},
+		},
+		Variables: map[string]interface{}{
+			"limit": 10.0,
+		},
+		ContextVars: map[string]interface{}{
+			"region": map[string]interface{}{"code": "eu"},
+		},
+		Strict: strict,
+	}
+}
+
+func TestEvaluateExpression_OptionalChainingAndCoalescing(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+	}{
+		{"optional chain through null", "node.http1.user.address?.city", nil},
+		{"optional chain on missing field", "node.http1.user?.email", nil},
+		{"optional chain on missing node", "node.http9?.user.name", nil},
+		{"optional chain on missing variable", "variables.missing?.value", nil},
+		{"optional chain to existing value", "node.http1?.user?.name", "Ada"},
+		{"optional index out of range", "node.http1.user?.tags?.[3]", nil},
+		{"coalesce null", "node.http1.user.address?.city ?? \"unknown\"", "unknown"},
+		{"coalesce missing field", "node.http1.user.email ?? \"none\"", "none"},
+		{"coalesce keeps value", "node.http1.user.name ?? \"anonymous\"", "Ada"},
+		{"coalesce chain", "variables.a ?? variables.b ?? variables.limit", 10.0},
+		{"coalesce with arithmetic fallback", "variables.max ?? variables.limit * 2", 20.0},
+		{"coalesce inside quotes is literal", "\"a ?? b\"", "a ?? b"},
+		{"nested context field", "context.region.code", "eu"},
+		{"ternary still works", "variables.limit > 5 ? \"high\" : \"low\"", "high"},
+		{"coalesce in arithmetic", "(node.http1.user.age ?? 30) + 1", 31.0},
+		{"coalesce missing variable in arithmetic", "(variables.nope ?? 1) * 2", 2.0},
+		{"coalesce present value in arithmetic", "(variables.limit ?? 1) * 2", 20.0},
+		{"optional chain in comparison", "node.http1.user?.name == \"Ada\"", true},
+		{"optional chain to missing field in comparison", "node.http1.user?.age > 18", false},
+		{"coalesce in comparison", "(node.http1.user.age ?? 30) > 18", true},
+	}
+
+	for _, strict := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := EvaluateExpression(tt.expression, nil, newNullSafeContext(strict))
+				if err != nil {
+					t.Fatalf("strict=%v: unexpected error: %v", strict, err)
+				}
+				if got != tt.want {
+					t.Errorf("strict=%v: EvaluateExpression(%q) = %#v, want %#v", strict, tt.expression, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestEvaluate_NullSafeConditions(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{`node.http1.user.address?.city == "X"`, false},
+		{`node.http1.user.address?.city == null`, true},
+		{`(node.http1.user.address?.city ?? "X") == "X"`, true},
+		{`node.http1.user.name == "Ada" && node.http1.user?.email == null`, true},
+		{`variables.max ?? variables.limit >= 10`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := Evaluate(tt.expression, nil, newNullSafeContext(true))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictMode_MissingReferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    error
+	}{
+		{"missing field", "node.http1.user.address.city", ErrFieldNotFound},
+		{"missing node", "node.http9.user", ErrUndefinedVariable},
+		{"missing variable", "variables.missing", ErrUndefinedVariable},
+		{"missing context", "context.missing", ErrUndefinedVariable},
+		{"index out of bounds", "node.http1.user.tags[3]", ErrIndexOutOfBounds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Non-strict: both entry points treat the reference as null
+			if got, err := EvaluateExpression(tt.expression, nil, newNullSafeContext(false)); err != nil || got != nil {
+				t.Errorf("non-strict EvaluateExpression = %#v, %v; want nil, nil", got, err)
+			}
+			if got, err := Evaluate(tt.expression+" == null", nil, newNullSafeContext(false)); err != nil || !got {
+				t.Errorf("non-strict Evaluate(== null) = %v, %v; want true, nil", got, err)
+			}
+
+			// Strict: both entry points fail with the same typed error
+			_, valueErr := EvaluateExpression(tt.expression, nil, newNullSafeContext(true))
+			_, boolErr := Evaluate(tt.expression+" == \"X\"", nil, newNullSafeContext(true))
+			for _, err := range []error{valueErr, boolErr} {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("strict error = %v, want %v", err, tt.wantErr)
+				}
+				var exprErr *ExpressionError
+				if !errors.As(err, &exprErr) {
+					t.Fatalf("strict error %T is not an *ExpressionError", err)
+				}
+				if exprErr.Position != 0 {
+					t.Errorf("error position = %d, want 0", exprErr.Position)
+				}
+			}
+		})
+	}
+}
+
+func TestExpressionError_Positions(t *testing.T) {
+	ctx := newNullSafeContext(true)
+
+	tests := []struct {
+		name       string
+		evaluate   func() error
+		expression string
+		position   int
+	}{
+		{
+			name: "reference inside condition",
+			evaluate: func() error {
+				_, err := Evaluate(`variables.limit > 5 && node.http1.user.email == "x"`, nil, ctx)
+				return err
+			},
+			expression: `variables.limit > 5 && node.http1.user.email == "x"`,
+			position:   23,
+		},
+		{
+			name: "arithmetic syntax",
+			evaluate: func() error {
+				_, err := EvaluateArithmetic("1 + 2 )", ctx)
+				return err
+			},
+			expression: "1 + 2 )",
+			position:   6,
+		},
+		{
+			name: "ternary branch",
+			evaluate: func() error {
+				_, err := EvaluateExpression(`variables.limit > 5 ? variables.nope : 0`, nil, ctx)
+				return err
+			},
+			expression: `variables.limit > 5 ? variables.nope : 0`,
+			position:   22,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.evaluate()
+			var exprErr *ExpressionError
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("error %v (%T) is not an *ExpressionError", err, err)
+			}
+			if exprErr.Expression != tt.expression {
+				t.Errorf("Expression = %q, want %q", exprErr.Expression, tt.expression)
+			}
+			if exprErr.Position != tt.position {
+				t.Errorf("Position = %d, want %d", exprErr.Position, tt.position)
+			}
+		})
+	}
+}
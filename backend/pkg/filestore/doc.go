@@ -0,0 +1,22 @@
+// Package filestore persists JSON documents keyed by ID, for packages such
+// as schedule and trigger that keep their own copy of every document in
+// memory and only need to load, save and delete.
+//
+// # Stores
+//
+// Store keeps one JSON file per document in a directory:
+//
+//	store, _ := filestore.New(dir, filestore.Options[Trigger]{
+//	    Kind:    "trigger",
+//	    ID:      func(t *Trigger) string { return t.ID },
+//	    DirMode: 0o700,
+//	})
+//
+// Files are replaced atomically through a temporary dot-file and a rename;
+// dot-files left behind by interrupted writes are removed on Load. A file
+// must hold the document its name says, so renamed or copied files are
+// reported rather than loaded under the wrong ID.
+//
+// Memory offers the same operations without a directory. It keeps
+// documents encoded, so callers never share values with the store.
+package filestore
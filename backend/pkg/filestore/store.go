@@ -0,0 +1,200 @@
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileExt is the extension of document files
+const fileExt = ".json"
+
+// DefaultDirMode is the permission of a store directory created by New
+// when Options.DirMode is zero
+const DefaultDirMode os.FileMode = 0o755
+
+// Options configures a store of documents of type T
+type Options[T any] struct {
+	// Kind names the documents in errors, e.g. "schedule"
+	Kind string
+
+	// ID returns the ID of a document, which names its file
+	ID func(*T) string
+
+	// DirMode is the permission of the directory when New creates it
+	// (default DefaultDirMode). Use 0o700 for documents holding secrets.
+	DirMode os.FileMode
+}
+
+// Store keeps one JSON file per document in a directory. It is safe for
+// concurrent use.
+type Store[T any] struct {
+	dir     string
+	options Options[T]
+	mu      sync.Mutex
+}
+
+// New opens the store in dir, creating the directory if needed
+func New[T any](dir string, options Options[T]) (*Store[T], error) {
+	if dir == "" {
+		return nil, fmt.Errorf("%s directory is required", options.Kind)
+	}
+	if options.DirMode == 0 {
+		options.DirMode = DefaultDirMode
+	}
+	if err := os.MkdirAll(dir, options.DirMode); err != nil {
+		return nil, fmt.Errorf("failed to create %s directory: %w", options.Kind, err)
+	}
+	return &Store[T]{dir: dir, options: options}, nil
+}
+
+// Dir returns the directory the store writes to
+func (s *Store[T]) Dir() string {
+	return s.dir
+}
+
+// Load returns every stored document. Temporary files left behind by
+// interrupted writes are removed.
+func (s *Store[T]) Load() ([]*T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s directory: %w", s.options.Kind, err)
+	}
+
+	var docs []*T
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, ".") {
+			_ = os.Remove(filepath.Join(s.dir, name))
+			continue
+		}
+		if filepath.Ext(name) != fileExt {
+			continue
+		}
+
+		raw, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s %s: %w", s.options.Kind, name, err)
+		}
+		doc := new(T)
+		if err := json.Unmarshal(raw, doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s: %w", s.options.Kind, name, err)
+		}
+		if id := s.options.ID(doc); id != strings.TrimSuffix(name, fileExt) {
+			return nil, fmt.Errorf("%s %s contains ID %q", s.options.Kind, name, id)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// Save creates or replaces a document's file atomically
+func (s *Store[T]) Save(doc *T) error {
+	id := s.options.ID(doc)
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %s: %w", s.options.Kind, id, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.dir, "."+id+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary %s file: %w", s.options.Kind, err)
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(raw)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, s.path(id))
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write %s %s: %w", s.options.Kind, id, err)
+	}
+	return nil
+}
+
+// Delete removes a document; deleting a missing one is not an error
+func (s *Store[T]) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s %s: %w", s.options.Kind, id, err)
+	}
+	return nil
+}
+
+// path returns the file path for a document ID
+func (s *Store[T]) path(id string) string {
+	return filepath.Join(s.dir, id+fileExt)
+}
+
+// Memory keeps documents until the process exits. It is safe for
+// concurrent use.
+type Memory[T any] struct {
+	options Options[T]
+	mu      sync.Mutex
+	docs    map[string][]byte
+}
+
+// NewMemory creates an empty memory store. Options.DirMode is not used.
+func NewMemory[T any](options Options[T]) *Memory[T] {
+	return &Memory[T]{options: options, docs: make(map[string][]byte)}
+}
+
+// Load returns every stored document
+func (m *Memory[T]) Load() ([]*T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	docs := make([]*T, 0, len(m.docs))
+	for id, raw := range m.docs {
+		doc := new(T)
+		if err := json.Unmarshal(raw, doc); err != nil {
+			return nil, fmt.Errorf("failed to decode %s %s: %w", m.options.Kind, id, err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// Save creates or replaces a document
+func (m *Memory[T]) Save(doc *T) error {
+	id := m.options.ID(doc)
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %s: %w", m.options.Kind, id, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs[id] = raw
+	return nil
+}
+
+// Delete removes a document; deleting a missing one is not an error
+func (m *Memory[T]) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.docs, id)
+	return nil
+}
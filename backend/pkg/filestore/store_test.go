@@ -0,0 +1,100 @@
+package filestore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type doc struct {
+	ID    string `json:"id"`
+	Value int    `json:"value"`
+}
+
+var docOptions = Options[doc]{
+	Kind: "doc",
+	ID:   func(d *doc) string { return d.ID },
+}
+
+// store is the interface Store and Memory share
+type store interface {
+	Load() ([]*doc, error)
+	Save(d *doc) error
+	Delete(id string) error
+}
+
+func TestStores(t *testing.T) {
+	fileStore, err := New(t.TempDir(), docOptions)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for name, s := range map[string]store{"file": fileStore, "memory": NewMemory(docOptions)} {
+		t.Run(name, func(t *testing.T) {
+			for _, d := range []*doc{{ID: "a", Value: 1}, {ID: "b", Value: 2}, {ID: "a", Value: 3}} {
+				if err := s.Save(d); err != nil {
+					t.Fatalf("Save(%s) error = %v", d.ID, err)
+				}
+			}
+			if err := s.Delete("b"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := s.Delete("missing"); err != nil {
+				t.Errorf("Delete(missing) error = %v, want none", err)
+			}
+
+			docs, err := s.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			sort.Slice(docs, func(i, k int) bool { return docs[i].ID < docs[k].ID })
+			if want := []*doc{{ID: "a", Value: 3}}; !reflect.DeepEqual(docs, want) {
+				t.Errorf("Load() = %+v, want %+v", docs, want)
+			}
+		})
+	}
+}
+
+func TestStore_Files(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	s, err := New(dir, Options[doc]{Kind: "doc", ID: docOptions.ID, DirMode: 0o700})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("Directory mode = %v (%v), want 0700", info.Mode().Perm(), err)
+	}
+	if err := s.Save(&doc{ID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leftover temporary files are removed and other files ignored
+	tmp := filepath.Join(dir, ".a-123.tmp")
+	if err := os.WriteFile(tmp, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hi"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if docs, err := s.Load(); err != nil || len(docs) != 1 {
+		t.Fatalf("Load() = %v, %v; want one doc", docs, err)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("Temporary file was not removed: %v", err)
+	}
+
+	// A file holding another ID is reported
+	if err := os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"id": "c"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load(); err == nil || !strings.Contains(err.Error(), `doc b.json contains ID "c"`) {
+		t.Errorf("Load() error = %v, want the ID mismatch", err)
+	}
+
+	if _, err := New("", docOptions); err == nil {
+		t.Error("New() accepted an empty directory")
+	}
+}
@@ -66,7 +66,7 @@
 // # Parallel Execution Analysis
 //
 //	// Find nodes that can execute in parallel
-//	levels := graph.ComputeExecutionLevels(nodes, edges)
+//	levels, err := graph.New(nodes, edges).ExecutionLevels()
 //	for level, nodeIDs := range levels {
 //	    // All nodes in same level can execute concurrently
 //	    executeParallel(nodeIDs)
@@ -118,6 +118,87 @@ func (g *Graph) TopologicalSort() ([]string, error) {
 	return order, nil
 }
 
+// ExecutionLevels groups the nodes by their distance from the workflow's
+// inputs: level 0 holds the nodes without input edges, and every other
+// node sits one level below its deepest source. Nodes on the same level do
+// not depend on each other. Within a level nodes keep their topological
+// order.
+//
+// Returns an error if the workflow contains cycles.
+func (g *Graph) ExecutionLevels() ([][]string, error) {
+	order, err := g.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string][]string, len(g.nodes))
+	for i := range g.edges {
+		sources[g.edges[i].Target] = append(sources[g.edges[i].Target], g.edges[i].Source)
+	}
+
+	level := make(map[string]int, len(order))
+	var levels [][]string
+	for _, nodeID := range order {
+		l := 0
+		for _, source := range sources[nodeID] {
+			if level[source]+1 > l {
+				l = level[source] + 1
+			}
+		}
+		level[nodeID] = l
+		if l == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[l] = append(levels[l], nodeID)
+	}
+	return levels, nil
+}
+
+// CriticalPath returns the heaviest chain of dependent nodes in the
+// workflow, starting at a node without input edges, and its total weight.
+// weight gives the cost of each node, such as its execution time; nodes
+// that did not run, like skipped ones, should weigh 0. Ties go to the path
+// found first in topological order.
+//
+// Returns an error if the workflow contains cycles.
+func (g *Graph) CriticalPath(weight func(nodeID string) float64) ([]string, float64, error) {
+	order, err := g.TopologicalSort()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sources := make(map[string][]string, len(g.nodes))
+	for i := range g.edges {
+		sources[g.edges[i].Target] = append(sources[g.edges[i].Target], g.edges[i].Source)
+	}
+
+	total := make(map[string]float64, len(order))
+	prev := make(map[string]string, len(order))
+	var end string
+	for _, nodeID := range order {
+		best, from := 0.0, ""
+		for _, source := range sources[nodeID] {
+			if from == "" || total[source] > best {
+				best, from = total[source], source
+			}
+		}
+		total[nodeID] = best + weight(nodeID)
+		prev[nodeID] = from
+		if end == "" || total[nodeID] > total[end] {
+			end = nodeID
+		}
+	}
+	if end == "" {
+		return nil, 0, nil
+	}
+
+	var path []string
+	for cur := end; cur != ""; cur = prev[cur] {
+		path = append([]string{cur}, path...)
+	}
+	return path, total[end], nil
+}
+
 // insertionSort sorts a slice of strings in place using insertion sort.
 // This is faster than the standard library sort for small slices (n < ~20).
 func insertionSort(arr []string) {
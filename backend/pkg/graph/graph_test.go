@@ -436,3 +436,144 @@ func isValidTopologicalOrder(order []string, edges []types.Edge) bool {
 
 	return true
 }
+
+// TestExecutionLevels tests grouping nodes by dependency depth
+func TestExecutionLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []types.Node
+		edges   []types.Edge
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:  "empty graph",
+			nodes: []types.Node{},
+			want:  nil,
+		},
+		{
+			name: "diamond with a shortcut",
+			nodes: []types.Node{
+				{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"},
+			},
+			edges: []types.Edge{
+				{Source: "a", Target: "b"},
+				{Source: "b", Target: "c"},
+				{Source: "a", Target: "c"},
+				{Source: "c", Target: "d"},
+				{Source: "a", Target: "d"},
+			},
+			want: [][]string{{"a"}, {"b"}, {"c"}, {"d"}},
+		},
+		{
+			name: "independent branches",
+			nodes: []types.Node{
+				{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"},
+			},
+			edges: []types.Edge{
+				{Source: "1", Target: "3"},
+				{Source: "2", Target: "4"},
+				{Source: "3", Target: "5"},
+				{Source: "4", Target: "5"},
+			},
+			want: [][]string{{"1", "2"}, {"3", "4"}, {"5"}},
+		},
+		{
+			name:  "cycle",
+			nodes: []types.Node{{ID: "1"}, {ID: "2"}},
+			edges: []types.Edge{
+				{Source: "1", Target: "2"},
+				{Source: "2", Target: "1"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.nodes, tt.edges).ExecutionLevels()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecutionLevels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExecutionLevels() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !equalSlices(got[i], tt.want[i]) {
+					t.Errorf("level %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestCriticalPath tests finding the heaviest chain of dependent nodes
+func TestCriticalPath(t *testing.T) {
+	diamond := []types.Edge{
+		{Source: "a", Target: "b"},
+		{Source: "a", Target: "c"},
+		{Source: "b", Target: "d"},
+		{Source: "c", Target: "d"},
+	}
+	tests := []struct {
+		name      string
+		nodes     []types.Node
+		edges     []types.Edge
+		weights   map[string]float64
+		want      []string
+		wantTotal float64
+		wantErr   bool
+	}{
+		{
+			name:  "empty graph",
+			nodes: []types.Node{},
+			want:  nil,
+		},
+		{
+			name:      "slow branch of a diamond",
+			nodes:     []types.Node{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}},
+			edges:     diamond,
+			weights:   map[string]float64{"a": 1, "b": 2, "c": 5, "d": 1},
+			want:      []string{"a", "c", "d"},
+			wantTotal: 7,
+		},
+		{
+			name:      "independent node outweighs a chain",
+			nodes:     []types.Node{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "slow"}},
+			edges:     diamond,
+			weights:   map[string]float64{"a": 1, "b": 1, "c": 1, "d": 1, "slow": 10},
+			want:      []string{"slow"},
+			wantTotal: 10,
+		},
+		{
+			name:      "unweighted nodes",
+			nodes:     []types.Node{{ID: "a"}, {ID: "b"}},
+			edges:     []types.Edge{{Source: "a", Target: "b"}},
+			want:      []string{"a"},
+			wantTotal: 0,
+		},
+		{
+			name:  "cycle",
+			nodes: []types.Node{{ID: "1"}, {ID: "2"}},
+			edges: []types.Edge{
+				{Source: "1", Target: "2"},
+				{Source: "2", Target: "1"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := New(tt.nodes, tt.edges).CriticalPath(func(nodeID string) float64 {
+				return tt.weights[nodeID]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CriticalPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !equalSlices(got, tt.want) || total != tt.wantTotal {
+				t.Errorf("CriticalPath() = %v, %v, want %v, %v", got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// Package history records finished workflow executions.
+//
+// # Overview
+//
+// A Recorder is an observer.SyncObserver registered on one engine. It
+// builds a Record from the engine's events. The Record holds the execution
+// ID, the workflow ID and version, the final status, timings, errors and a
+// trace of every node that ran. When the workflow_end event arrives, the
+// Recorder saves the Record to a Store:
+//
+//	store := history.NewMemoryStore(history.Retention{MaxAge: 7 * 24 * time.Hour})
+//
+//	eng, _ := engine.NewWithConfig(payload, config)
+//	eng.RegisterObserver(history.NewRecorder(store, history.Options{
+//	    WorkflowID:     "wf-1",
+//	    CaptureOutputs: true,
+//	}))
+//	eng.Execute()
+//
+//	page, _ := store.List(history.Filter{WorkflowID: "wf-1", Status: history.StatusFailed})
+//
+// # Node outputs
+//
+// With Options.CaptureOutputs, each successful node's output is stored as
+// JSON. An output longer than Options.MaxOutputBytes is cut to that many
+// bytes, stored as a JSON string and marked OutputTruncated. OutputSize
+// always holds the full encoded size.
+//
+// # Stores
+//
+// MemoryStore keeps records until the process exits. FileStore writes one
+// JSON file per execution and keeps an in-memory index of summaries, so
+// listing never reads record files.
+//
+// Both stores apply a Retention policy when a record is saved and when Prune
+// is called. The policy can limit the age of records, their number, or both.
+// The file store also applies it when opened.
+package history
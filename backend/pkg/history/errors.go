@@ -0,0 +1,10 @@
+package history
+
+import "errors"
+
+// Sentinel errors for execution history
+var (
+	ErrRecordNotFound = errors.New("execution record not found")
+	ErrInvalidFilter  = errors.New("invalid history filter")
+	ErrInvalidRecord  = errors.New("invalid execution record")
+)
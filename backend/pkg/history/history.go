@@ -0,0 +1,219 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// Status is the outcome of an execution or one of its nodes
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Summary describes one finished execution without its node trace
+type Summary struct {
+	ExecutionID     string    `json:"execution_id"`
+	WorkflowID      string    `json:"workflow_id,omitempty"`
+	WorkflowVersion int       `json:"workflow_version,omitempty"`
+	TenantID        string    `json:"tenant_id,omitempty"`
+	Status          Status    `json:"status"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationMS      float64   `json:"duration_ms"`
+	Error           string    `json:"error,omitempty"`
+	NodeCount       int       `json:"node_count"`
+}
+
+// Record is a finished execution with its node trace
+type Record struct {
+	Summary
+	Nodes []NodeRecord `json:"nodes"`
+}
+
+// NodeRecord is one node's entry in an execution trace, in the order nodes
+// started
+type NodeRecord struct {
+	NodeID          string          `json:"node_id"`
+	NodeType        types.NodeType  `json:"node_type,omitempty"`
+	Status          Status          `json:"status"`
+	StartedAt       time.Time       `json:"started_at"`
+	DurationMS      float64         `json:"duration_ms"`
+	Error           string          `json:"error,omitempty"`
+	Output          json.RawMessage `json:"output,omitempty"`
+	OutputSize      int             `json:"output_size,omitempty"`
+	OutputTruncated bool            `json:"output_truncated,omitempty"`
+}
+
+// Store persists execution records
+type Store interface {
+	// Save stores a record, replacing any record with the same execution
+	// ID, then applies the retention policy
+	Save(rec *Record) error
+
+	// Get returns the record for an execution ID
+	Get(executionID string) (*Record, error)
+
+	// List returns the summaries matching filter, newest first
+	List(filter Filter) (Page, error)
+
+	// Prune applies the retention policy as of now and returns the number
+	// of records removed
+	Prune(now time.Time) (int, error)
+}
+
+// Retention limits how many records a store keeps. Zero fields mean no
+// limit.
+type Retention struct {
+	// MaxAge removes records that finished longer ago than this
+	MaxAge time.Duration
+
+	// MaxRecords keeps only this many of the most recent records
+	MaxRecords int
+}
+
+// Filter selects executions to list. Zero fields match everything. From
+// and To bound the start time, inclusive; Limit 0 returns all matches.
+type Filter struct {
+	TenantID   string
+	WorkflowID string
+	Status     Status
+	From       time.Time
+	To         time.Time
+	Offset     int
+	Limit      int
+}
+
+// Page is one page of listed executions. Total counts every match.
+type Page struct {
+	Executions []Summary `json:"executions"`
+	Total      int       `json:"total"`
+	Offset     int       `json:"offset"`
+	Limit      int       `json:"limit"`
+}
+
+// HasMore reports whether there are matches after this page
+func (p Page) HasMore() bool {
+	return p.Offset+len(p.Executions) < p.Total
+}
+
+// validate checks the filter's fields
+func (f Filter) validate() error {
+	switch f.Status {
+	case "", StatusSucceeded, StatusFailed, StatusCancelled:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, f.Status)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.From.After(f.To) {
+		return fmt.Errorf("%w: from must not be after to", ErrInvalidFilter)
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// matches reports whether a summary passes the filter
+func (f Filter) matches(s *Summary) bool {
+	if f.TenantID != "" && s.TenantID != f.TenantID {
+		return false
+	}
+	if f.WorkflowID != "" && s.WorkflowID != f.WorkflowID {
+		return false
+	}
+	if f.Status != "" && s.Status != f.Status {
+		return false
+	}
+	if !f.From.IsZero() && s.StartedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && s.StartedAt.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// index holds the summaries of stored records. It is shared by the store
+// implementations, which guard it with their own lock.
+type index map[string]Summary
+
+// query filters, sorts and pages the indexed summaries
+func (idx index) query(filter Filter) (Page, error) {
+	if err := filter.validate(); err != nil {
+		return Page{}, err
+	}
+
+	matched := make([]Summary, 0, len(idx))
+	for _, s := range idx {
+		if filter.matches(&s) {
+			matched = append(matched, s)
+		}
+	}
+	sortNewestFirst(matched)
+
+	page := Page{Total: len(matched), Offset: filter.Offset, Limit: filter.Limit}
+	start := filter.Offset
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := len(matched)
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+	page.Executions = matched[start:end]
+	return page, nil
+}
+
+// expired returns the IDs the retention policy removes as of now
+func (idx index) expired(policy Retention, now time.Time) []string {
+	all := make([]Summary, 0, len(idx))
+	for _, s := range idx {
+		all = append(all, s)
+	}
+	sortNewestFirst(all)
+
+	var ids []string
+	for i, s := range all {
+		tooOld := policy.MaxAge > 0 && now.Sub(s.FinishedAt) > policy.MaxAge
+		tooMany := policy.MaxRecords > 0 && i >= policy.MaxRecords
+		if tooOld || tooMany {
+			ids = append(ids, s.ExecutionID)
+		}
+	}
+	return ids
+}
+
+// sortNewestFirst orders summaries by start time, newest first, with the
+// execution ID as a tiebreak so pages are stable
+func sortNewestFirst(summaries []Summary) {
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if !a.StartedAt.Equal(b.StartedAt) {
+			return a.StartedAt.After(b.StartedAt)
+		}
+		return strings.Compare(a.ExecutionID, b.ExecutionID) < 0
+	})
+}
+
+// validateRecord checks a record before it is stored
+func validateRecord(rec *Record) error {
+	if rec == nil || rec.ExecutionID == "" {
+		return fmt.Errorf("%w: execution ID is required", ErrInvalidRecord)
+	}
+	if strings.ContainsAny(rec.ExecutionID, `/\.`) {
+		return fmt.Errorf("%w: execution ID %q contains path characters", ErrInvalidRecord, rec.ExecutionID)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+)
+
+// DefaultMaxOutputBytes caps captured node outputs when Options leaves
+// MaxOutputBytes unset
+const DefaultMaxOutputBytes = 4096
+
+// Options configures a Recorder
+type Options struct {
+	// WorkflowID and WorkflowVersion identify the saved workflow that ran.
+	// Both are empty for ad-hoc executions.
+	WorkflowID      string
+	WorkflowVersion int
+
+	// CaptureOutputs stores each successful node's output in the trace
+	CaptureOutputs bool
+
+	// MaxOutputBytes caps each captured output
+	MaxOutputBytes int
+
+	// OnError is called if the record cannot be saved. Observers cannot
+	// return errors, so without it save failures are dropped.
+	OnError func(err error)
+}
+
+// Recorder builds an execution record from one engine's events and saves
+// it when the workflow ends
+type Recorder struct {
+	store   Store
+	options Options
+
+	mu    sync.Mutex
+	rec   Record
+	nodes map[string]int
+	done  bool
+}
+
+// NewRecorder creates a recorder for a single execution
+func NewRecorder(store Store, options Options) *Recorder {
+	if options.MaxOutputBytes <= 0 {
+		options.MaxOutputBytes = DefaultMaxOutputBytes
+	}
+	return &Recorder{
+		store:   store,
+		options: options,
+		rec: Record{
+			Summary: Summary{
+				WorkflowID:      options.WorkflowID,
+				WorkflowVersion: options.WorkflowVersion,
+			},
+			Nodes: []NodeRecord{},
+		},
+		nodes: make(map[string]int),
+	}
+}
+
+// Synchronous implements observer.SyncObserver so node events are traced
+// in the order they happened
+func (r *Recorder) Synchronous() {}
+
+// OnEvent implements observer.Observer
+func (r *Recorder) OnEvent(ctx context.Context, event observer.Event) {
+	r.mu.Lock()
+	if r.done {
+		// Nodes still running after a timeout or cancellation
+		r.mu.Unlock()
+		return
+	}
+
+	switch event.Type {
+	case observer.EventWorkflowStart:
+		r.rec.ExecutionID = event.ExecutionID
+		r.rec.StartedAt = event.StartTime
+		r.rec.TenantID = event.TenantID
+		if r.rec.WorkflowID == "" {
+			r.rec.WorkflowID = event.WorkflowID
+		}
+	case observer.EventNodeStart:
+		r.node(event)
+	case observer.EventNodeSuccess:
+		node := r.node(event)
+		node.Status = StatusSucceeded
+		node.DurationMS = milliseconds(event.ElapsedTime)
+		if r.options.CaptureOutputs {
+			r.captureOutput(node, event.Result)
+		}
+	case observer.EventNodeFailure:
+		node := r.node(event)
+		node.Status = StatusFailed
+		node.DurationMS = milliseconds(event.ElapsedTime)
+		if event.Error != nil {
+			node.Error = event.Error.Error()
+		}
+	case observer.EventWorkflowEnd:
+		r.finish(event)
+		rec := r.rec
+		r.mu.Unlock()
+
+		if err := r.store.Save(&rec); err != nil && r.options.OnError != nil {
+			r.options.OnError(err)
+		}
+		return
+	}
+	r.mu.Unlock()
+}
+
+// node returns the trace entry for the event's node, adding it on first
+// sight. Callers must hold r.mu.
+func (r *Recorder) node(event observer.Event) *NodeRecord {
+	if i, ok := r.nodes[event.NodeID]; ok {
+		return &r.rec.Nodes[i]
+	}
+	r.nodes[event.NodeID] = len(r.rec.Nodes)
+	r.rec.Nodes = append(r.rec.Nodes, NodeRecord{
+		NodeID:    event.NodeID,
+		NodeType:  event.NodeType,
+		Status:    StatusRunning,
+		StartedAt: event.StartTime,
+	})
+	return &r.rec.Nodes[len(r.rec.Nodes)-1]
+}
+
+// finish fills in the outcome from the workflow_end event. Callers must
+// hold r.mu.
+func (r *Recorder) finish(event observer.Event) {
+	r.done = true
+	if r.rec.ExecutionID == "" {
+		r.rec.ExecutionID = event.ExecutionID
+	}
+	if r.rec.StartedAt.IsZero() {
+		r.rec.StartedAt = event.StartTime
+	}
+	r.rec.FinishedAt = event.Timestamp
+	r.rec.DurationMS = milliseconds(event.ElapsedTime)
+	r.rec.NodeCount = len(r.rec.Nodes)
+
+	switch {
+	case event.Error == nil:
+		r.rec.Status = StatusSucceeded
+	case errors.Is(event.Error, engine.ErrExecutionCanceled):
+		r.rec.Status = StatusCancelled
+	default:
+		r.rec.Status = StatusFailed
+	}
+	if event.Error != nil {
+		r.rec.Error = event.Error.Error()
+	}
+
+	// Nodes interrupted by a timeout or cancellation never reported back
+	for i := range r.rec.Nodes {
+		if r.rec.Nodes[i].Status == StatusRunning {
+			r.rec.Nodes[i].Status = StatusCancelled
+		}
+	}
+}
+
+// captureOutput stores value as the node's output, truncated to the size cap
+func (r *Recorder) captureOutput(node *NodeRecord, value interface{}) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		raw, _ = json.Marshal("<unencodable output: " + err.Error() + ">")
+	}
+	node.OutputSize = len(raw)
+
+	if len(raw) <= r.options.MaxOutputBytes {
+		node.Output = raw
+		return
+	}
+
+	// Cut on a rune boundary and keep the prefix as a JSON string
+	cut := r.options.MaxOutputBytes
+	for cut > 0 && !utf8.RuneStart(raw[cut]) {
+		cut--
+	}
+	node.Output, _ = json.Marshal(string(raw[:cut]))
+	node.OutputTruncated = true
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,99 @@
+package history
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func run(t *testing.T, store Store, payload string, options Options) string {
+	t.Helper()
+	eng, err := engine.NewWithConfig([]byte(payload), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("engine.NewWithConfig() error = %v", err)
+	}
+	eng.RegisterObserver(NewRecorder(store, options))
+	_, _ = eng.Execute()
+	return eng.ExecutionID()
+}
+
+func TestRecorder_Success(t *testing.T) {
+	store := NewMemoryStore(Retention{})
+	payload := `{
+		"nodes": [
+			{"id": "1", "data": {"value": 10}},
+			{"id": "2", "data": {"text": "` + strings.Repeat("é", 40) + `"}},
+			{"id": "3", "data": {"value": 5}},
+			{"id": "4", "data": {"op": "add"}}
+		],
+		"edges": [{"source": "1", "target": "4"}, {"source": "3", "target": "4"}]
+	}`
+	id := run(t, store, payload, Options{WorkflowID: "wf-1", WorkflowVersion: 3, CaptureOutputs: true, MaxOutputBytes: 25})
+
+	// The record is saved before Execute returns
+	rec, err := store.Get(id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if rec.Status != StatusSucceeded || rec.WorkflowID != "wf-1" || rec.WorkflowVersion != 3 {
+		t.Errorf("Record = %+v", rec.Summary)
+	}
+	if rec.NodeCount != 4 || len(rec.Nodes) != 4 || rec.FinishedAt.Before(rec.StartedAt) {
+		t.Fatalf("Record = %+v, want 4 nodes and consistent timings", rec)
+	}
+
+	outputs := map[string]NodeRecord{}
+	for _, node := range rec.Nodes {
+		if node.Status != StatusSucceeded {
+			t.Errorf("Node %s status = %s, want succeeded", node.NodeID, node.Status)
+		}
+		outputs[node.NodeID] = node
+	}
+	if string(outputs["1"].Output) != "10" || outputs["1"].OutputTruncated {
+		t.Errorf("Node 1 output = %s (truncated %v), want 10", outputs["1"].Output, outputs["1"].OutputTruncated)
+	}
+
+	long := outputs["2"]
+	var prefix string
+	if !long.OutputTruncated || json.Unmarshal(long.Output, &prefix) != nil || len(prefix) > 25 || long.OutputSize <= 25 {
+		t.Errorf("Node 2 output = %s (size %d, truncated %v), want a valid truncated string", long.Output, long.OutputSize, long.OutputTruncated)
+	}
+}
+
+func TestRecorder_FailureWithoutOutputs(t *testing.T) {
+	store := NewMemoryStore(Retention{})
+	payload := `{
+		"nodes": [
+			{"id": "1", "data": {"value": 1}},
+			{"id": "2", "data": {"value": 0}},
+			{"id": "3", "data": {"op": "divide"}}
+		],
+		"edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]
+	}`
+	id := run(t, store, payload, Options{})
+
+	rec, err := store.Get(id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if rec.Status != StatusFailed || !strings.Contains(rec.Error, "division by zero") {
+		t.Errorf("Record = %+v, want failed with the node error", rec.Summary)
+	}
+	last := rec.Nodes[len(rec.Nodes)-1]
+	if last.NodeID != "3" || last.Status != StatusFailed || last.Error == "" {
+		t.Errorf("Failing node = %+v", last)
+	}
+	for _, node := range rec.Nodes {
+		if node.Output != nil {
+			t.Errorf("Node %s output captured without CaptureOutputs", node.NodeID)
+		}
+	}
+
+	page, _ := store.List(Filter{Status: StatusFailed})
+	if page.Total != 1 || page.Executions[0].ExecutionID != id {
+		t.Errorf("List(failed) = %+v", page)
+	}
+}
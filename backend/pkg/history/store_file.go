@@ -0,0 +1,198 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordFileExt is the extension of execution record files
+const recordFileExt = ".json"
+
+// FileStore keeps one JSON file per execution in a directory. Summaries are
+// indexed in memory when the store is opened.
+type FileStore struct {
+	dir       string
+	retention Retention
+
+	mu    sync.RWMutex
+	index index
+}
+
+// NewFileStore opens the store in dir, creating the directory if needed,
+// and applies the retention policy to the records already there
+func NewFileStore(dir string, retention Retention) (*FileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("history directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	s := &FileStore{
+		dir:       dir,
+		retention: retention,
+		index:     make(index),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if _, err := s.Prune(time.Now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Dir returns the directory the store writes to
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+// Save implements Store
+func (s *FileStore) Save(rec *Record) error {
+	if err := validateRecord(rec); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode execution record %s: %w", rec.ExecutionID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.write(rec.ExecutionID, raw); err != nil {
+		return err
+	}
+	s.index[rec.ExecutionID] = rec.Summary
+	return s.prune(time.Now())
+}
+
+// Get implements Store
+func (s *FileStore) Get(executionID string) (*Record, error) {
+	s.mu.RLock()
+	_, ok := s.index[executionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, executionID)
+	}
+
+	raw, err := os.ReadFile(s.path(executionID))
+	if errors.Is(err, os.ErrNotExist) {
+		// Pruned between the index lookup and the read
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, executionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read execution record %s: %w", executionID, err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode execution record %s: %w", executionID, err)
+	}
+	return &rec, nil
+}
+
+// List implements Store
+func (s *FileStore) List(filter Filter) (Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index.query(filter)
+}
+
+// Prune implements Store
+func (s *FileStore) Prune(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.index)
+	err := s.prune(now)
+	return before - len(s.index), err
+}
+
+// prune removes expired records from disk and the index. Callers must hold
+// s.mu.
+func (s *FileStore) prune(now time.Time) error {
+	for _, id := range s.index.expired(s.retention, now) {
+		if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove execution record %s: %w", id, err)
+		}
+		delete(s.index, id)
+	}
+	return nil
+}
+
+// load indexes every record file in the directory and removes temporary
+// files left behind by interrupted writes
+func (s *FileStore) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, ".") {
+			_ = os.Remove(filepath.Join(s.dir, name))
+			continue
+		}
+		if filepath.Ext(name) != recordFileExt {
+			continue
+		}
+
+		raw, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read execution record %s: %w", name, err)
+		}
+
+		// Only the summary is kept in memory; node traces stay on disk
+		var summary Summary
+		if err := json.Unmarshal(raw, &summary); err != nil {
+			return fmt.Errorf("failed to parse execution record %s: %w", name, err)
+		}
+		if summary.ExecutionID != strings.TrimSuffix(name, recordFileExt) {
+			return fmt.Errorf("execution record %s contains ID %q", name, summary.ExecutionID)
+		}
+		s.index[summary.ExecutionID] = summary
+	}
+
+	return nil
+}
+
+// write replaces the record file for id atomically
+func (s *FileStore) write(id string, raw []byte) error {
+	tmp, err := os.CreateTemp(s.dir, "."+id+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary record file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(raw)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, s.path(id))
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write execution record %s: %w", id, err)
+	}
+	return nil
+}
+
+// path returns the file path for an execution ID
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+recordFileExt)
+}
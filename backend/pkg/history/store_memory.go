@@ -0,0 +1,85 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps execution records in memory
+type MemoryStore struct {
+	mu        sync.RWMutex
+	retention Retention
+	index     index
+	records   map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore(retention Retention) *MemoryStore {
+	return &MemoryStore{
+		retention: retention,
+		index:     make(index),
+		records:   make(map[string][]byte),
+	}
+}
+
+// Save implements Store. Records are stored encoded so callers cannot
+// change them afterwards.
+func (s *MemoryStore) Save(rec *Record) error {
+	if err := validateRecord(rec); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode execution record %s: %w", rec.ExecutionID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index[rec.ExecutionID] = rec.Summary
+	s.records[rec.ExecutionID] = raw
+	s.prune(time.Now())
+	return nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(executionID string) (*Record, error) {
+	s.mu.RLock()
+	raw, ok := s.records[executionID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, executionID)
+	}
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode execution record %s: %w", executionID, err)
+	}
+	return &rec, nil
+}
+
+// List implements Store
+func (s *MemoryStore) List(filter Filter) (Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index.query(filter)
+}
+
+// Prune implements Store
+func (s *MemoryStore) Prune(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prune(now), nil
+}
+
+// prune removes expired records. Callers must hold s.mu.
+func (s *MemoryStore) prune(now time.Time) int {
+	ids := s.index.expired(s.retention, now)
+	for _, id := range ids {
+		delete(s.index, id)
+		delete(s.records, id)
+	}
+	return len(ids)
+}
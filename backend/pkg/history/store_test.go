@@ -0,0 +1,165 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// record builds a record that started minutes after base
+func record(id, workflowID string, status Status, minutes int) *Record {
+	started := base.Add(time.Duration(minutes) * time.Minute)
+	return &Record{
+		Summary: Summary{
+			ExecutionID: id,
+			WorkflowID:  workflowID,
+			Status:      status,
+			StartedAt:   started,
+			FinishedAt:  started.Add(time.Second),
+			NodeCount:   1,
+		},
+		Nodes: []NodeRecord{{NodeID: "n1", Status: StatusSucceeded}},
+	}
+}
+
+func stores(t *testing.T, retention Retention) map[string]Store {
+	fileStore, err := NewFileStore(t.TempDir(), retention)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	return map[string]Store{
+		"memory": NewMemoryStore(retention),
+		"file":   fileStore,
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	for name, store := range stores(t, Retention{}) {
+		t.Run(name, func(t *testing.T) {
+			acme := record("e3", "wf-b", StatusSucceeded, 3)
+			acme.TenantID = "acme"
+			for _, rec := range []*Record{
+				record("e1", "wf-a", StatusSucceeded, 1),
+				record("e2", "wf-a", StatusFailed, 2),
+				acme,
+				record("e4", "wf-a", StatusSucceeded, 4),
+			} {
+				if err := store.Save(rec); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			tests := []struct {
+				name    string
+				filter  Filter
+				wantIDs []string
+				total   int
+			}{
+				{"all newest first", Filter{}, []string{"e4", "e3", "e2", "e1"}, 4},
+				{"by workflow", Filter{WorkflowID: "wf-a"}, []string{"e4", "e2", "e1"}, 3},
+				{"by status", Filter{Status: StatusFailed}, []string{"e2"}, 1},
+				{"by tenant", Filter{TenantID: "acme"}, []string{"e3"}, 1},
+				{"time range", Filter{From: base.Add(2 * time.Minute), To: base.Add(3 * time.Minute)}, []string{"e3", "e2"}, 2},
+				{"paged", Filter{Offset: 1, Limit: 2}, []string{"e3", "e2"}, 4},
+			}
+			for _, tt := range tests {
+				page, err := store.List(tt.filter)
+				if err != nil {
+					t.Fatalf("%s: List() error = %v", tt.name, err)
+				}
+				if page.Total != tt.total || len(page.Executions) != len(tt.wantIDs) {
+					t.Fatalf("%s: got total %d and %d executions, want %d and %d", tt.name, page.Total, len(page.Executions), tt.total, len(tt.wantIDs))
+				}
+				for i, id := range tt.wantIDs {
+					if page.Executions[i].ExecutionID != id {
+						t.Errorf("%s: Executions[%d] = %s, want %s", tt.name, i, page.Executions[i].ExecutionID, id)
+					}
+				}
+			}
+
+			rec, err := store.Get("e2")
+			if err != nil || rec.WorkflowID != "wf-a" || len(rec.Nodes) != 1 {
+				t.Errorf("Get() = %+v, %v", rec, err)
+			}
+			if _, err := store.Get("missing"); !errors.Is(err, ErrRecordNotFound) {
+				t.Errorf("Get(missing) error = %v, want ErrRecordNotFound", err)
+			}
+			if _, err := store.List(Filter{Status: "paused"}); !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("List(bad status) error = %v, want ErrInvalidFilter", err)
+			}
+			if err := store.Save(&Record{}); !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("Save(no ID) error = %v, want ErrInvalidRecord", err)
+			}
+		})
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	for name, store := range stores(t, Retention{MaxRecords: 3, MaxAge: time.Hour}) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now().UTC()
+			for i := 0; i < 5; i++ {
+				rec := record(fmt.Sprintf("e%d", i), "wf", StatusSucceeded, i)
+				rec.StartedAt = now.Add(time.Duration(i) * time.Second)
+				rec.FinishedAt = rec.StartedAt
+				if err := store.Save(rec); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			page, _ := store.List(Filter{})
+			if page.Total != 3 || page.Executions[2].ExecutionID != "e2" {
+				t.Fatalf("After MaxRecords pruning got %+v, want e4..e2", page.Executions)
+			}
+
+			removed, err := store.Prune(now.Add(2 * time.Hour))
+			if err != nil || removed != 3 {
+				t.Errorf("Prune() = %d, %v; want 3 records removed by age", removed, err)
+			}
+			if page, _ := store.List(Filter{}); page.Total != 0 {
+				t.Errorf("Expected no records after age pruning, got %d", page.Total)
+			}
+		})
+	}
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, Retention{})
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.Save(record("e1", "wf", StatusSucceeded, 0)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Leftover temporary files from interrupted writes are cleaned up
+	leftover := filepath.Join(dir, ".e2-123.tmp")
+	if err := os.WriteFile(leftover, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(dir, Retention{})
+	if err != nil {
+		t.Fatalf("NewFileStore() reopen error = %v", err)
+	}
+	if rec, err := reopened.Get("e1"); err != nil || len(rec.Nodes) != 1 {
+		t.Errorf("Get() after reopen = %+v, %v", rec, err)
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Error("Expected temporary file to be removed on open")
+	}
+
+	// Corrupt records are reported rather than silently skipped
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(dir, Retention{}); err == nil {
+		t.Error("Expected error for corrupt record file")
+	}
+}
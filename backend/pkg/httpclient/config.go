@@ -108,6 +108,29 @@ type Config struct {
 	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"`
 }
 
+// Secrets returns the non-empty credential values of the configuration, so
+// they can be masked wherever they might otherwise be logged
+func (c *Config) Secrets() []string {
+	var secrets []SecureString
+	if c.Auth.BasicAuth != nil {
+		secrets = append(secrets, c.Auth.BasicAuth.Password)
+	}
+	if c.Auth.Token != nil {
+		secrets = append(secrets, c.Auth.Token.Token)
+	}
+	if c.Auth.APIKey != nil {
+		secrets = append(secrets, c.Auth.APIKey.Value)
+	}
+
+	var values []string
+	for _, secret := range secrets {
+		if !secret.IsEmpty() {
+			values = append(values, secret.Value())
+		}
+	}
+	return values
+}
+
 // Validate checks if the client configuration is valid
 func (c *Config) Validate() error {
 	if c.UID == "" {
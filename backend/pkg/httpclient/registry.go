@@ -9,6 +9,7 @@ import (
 // Registry manages named HTTP clients by their UIDs
 type Registry struct {
 	clients map[string]*http.Client
+	configs map[string]Config
 	mu      sync.RWMutex
 }
 
@@ -16,6 +17,7 @@ type Registry struct {
 func NewRegistry() *Registry {
 	return &Registry{
 		clients: make(map[string]*http.Client),
+		configs: make(map[string]Config),
 	}
 }
 
@@ -40,6 +42,49 @@ func (r *Registry) Register(uid string, client *http.Client) error {
 	return nil
 }
 
+// RegisterWithConfig adds a client under config.UID and keeps the config
+// it was built from, so it can be read back with Config
+func (r *Registry) RegisterWithConfig(client *http.Client, config Config) error {
+	if err := r.Register(config.UID, client); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configs[config.UID] = config
+	return nil
+}
+
+// Config returns the config a client was registered with. Clients added
+// with Register have no config.
+func (r *Registry) Config(uid string) (Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.clients[uid]; !exists {
+		return Config{}, fmt.Errorf("client with UID %q not found", uid)
+	}
+	config, exists := r.configs[uid]
+	if !exists {
+		return Config{}, fmt.Errorf("client with UID %q was registered without a config", uid)
+	}
+	return config, nil
+}
+
+// Secrets returns the credential values of every client registered with a
+// config
+func (r *Registry) Secrets() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var secrets []string
+	for _, config := range r.configs {
+		secrets = append(secrets, config.Secrets()...)
+	}
+	return secrets
+}
+
 // Get retrieves a client by UID
 func (r *Registry) Get(uid string) (*http.Client, error) {
 	r.mu.RLock()
@@ -88,6 +133,7 @@ func (r *Registry) Clear() {
 	defer r.mu.Unlock()
 
 	r.clients = make(map[string]*http.Client)
+	r.configs = make(map[string]Config)
 }
 
 // Unregister removes a client from the registry
@@ -100,5 +146,6 @@ func (r *Registry) Unregister(uid string) error {
 	}
 
 	delete(r.clients, uid)
+	delete(r.configs, uid)
 	return nil
 }
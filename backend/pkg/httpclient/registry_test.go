@@ -256,6 +256,46 @@ func TestRegistry_Unregister(t *testing.T) {
 	}
 }
 
+func TestRegistry_Config(t *testing.T) {
+	registry := NewRegistry()
+
+	config := &Config{
+		UID:     "crm",
+		BaseURL: "https://crm.example.com",
+		Auth:    AuthConfig{Type: AuthTypeBearer, Token: &TokenAuthConfig{Token: NewSecureString("s3cret")}},
+	}
+	client, err := New(context.Background(), config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := registry.RegisterWithConfig(client, *config); err != nil {
+		t.Fatalf("RegisterWithConfig() error = %v", err)
+	}
+	got, err := registry.Config("crm")
+	if err != nil {
+		t.Fatalf("Config() error = %v", err)
+	}
+	if got.BaseURL != config.BaseURL || got.Auth.Token.Token.Value() != "s3cret" {
+		t.Errorf("Config() = %+v, want the registered config", got)
+	}
+
+	// Clients registered without a config have none to return
+	registry.Register("plain", client)
+	if _, err := registry.Config("plain"); err == nil {
+		t.Error("Config() expected error for client without config, got nil")
+	}
+
+	if got := registry.Secrets(); len(got) != 1 || got[0] != "s3cret" {
+		t.Errorf("Secrets() = %v, want [s3cret]", got)
+	}
+
+	registry.Unregister("crm")
+	if _, err := registry.Config("crm"); err == nil {
+		t.Error("Config() expected error after unregister, got nil")
+	}
+}
+
 func TestRegistry_Concurrent(t *testing.T) {
 	registry := NewRegistry()
 
@@ -121,6 +121,13 @@ func (l *Logger) WithExecutionID(executionID string) *Logger {
 	}
 }
 
+// WithTenantID adds tenant_id to the logger context
+func (l *Logger) WithTenantID(tenantID string) *Logger {
+	return &Logger{
+		logger: l.logger.With(slog.String("tenant_id", tenantID)),
+	}
+}
+
 // WithNodeID adds node_id to the logger context
 func (l *Logger) WithNodeID(nodeID string) *Logger {
 	return &Logger{
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -410,3 +411,16 @@ func (m *mockExecutionContextWithInputs) GetNodeExecutionCount() int {
 func (m *mockExecutionContextWithInputs) GetHTTPCallCount() int {
 	return 0
 }
+
+func (m *mockExecutionContextWithInputs) RecordHTTPCall(clientUID string, statusCode int, d time.Duration) {
+}
+
+func (m *mockExecutionContextWithInputs) RecordIterations(n int) {}
+
+func (m *mockExecutionContextWithInputs) Context() context.Context {
+	return context.Background()
+}
+
+func (m *mockExecutionContextWithInputs) ExecuteSubWorkflow(nodeID, workflowID string, version int, inputs map[string]interface{}) (interface{}, error) {
+	return nil, nil
+}
@@ -0,0 +1,89 @@
+package observer
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// truncatedSuffix ends the JSON prefix kept for a truncated value
+const truncatedSuffix = "...[truncated]"
+
+// Capture controls the node data that events carry. The engine applies it
+// to each event before any observer sees it, so console, logging,
+// telemetry and history observers get the same masked and truncated data.
+// A nil Capture leaves events as the engine builds them: results without
+// inputs, unmasked.
+type Capture struct {
+	// Inputs adds each node's resolved inputs to its node events
+	Inputs bool
+
+	// Outputs keeps node and workflow results in events
+	Outputs bool
+
+	// MaxBytes truncates each input and output whose JSON encoding is
+	// longer, keeping the start of the encoding as a string. Zero keeps
+	// them whole.
+	MaxBytes int
+
+	// Redactor masks sensitive data in inputs, outputs and error
+	// messages; nil masks nothing
+	Redactor *Redactor
+}
+
+// CapturesInputs reports whether node events should carry node inputs
+func (c *Capture) CapturesInputs() bool {
+	return c != nil && c.Inputs
+}
+
+// RedactError masks secrets in err's message, for errors that reach logs
+// and spans outside of events
+func (c *Capture) RedactError(err error) error {
+	if c == nil {
+		return err
+	}
+	return c.Redactor.RedactError(err)
+}
+
+// Apply returns event with the capture settings applied
+func (c *Capture) Apply(event Event) Event {
+	if c == nil {
+		return event
+	}
+
+	if c.Outputs {
+		event.Result = c.value(event.Result)
+	} else {
+		event.Result = nil
+	}
+	if !c.Inputs {
+		event.Inputs = nil
+	} else if event.Inputs != nil {
+		inputs := make([]interface{}, len(event.Inputs))
+		for i, input := range event.Inputs {
+			inputs[i] = c.value(input)
+		}
+		event.Inputs = inputs
+	}
+	event.Error = c.Redactor.RedactError(event.Error)
+	return event
+}
+
+// value masks and truncates one captured value
+func (c *Capture) value(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	v = c.Redactor.Redact(v)
+	if c.MaxBytes <= 0 {
+		return v
+	}
+	raw, err := json.Marshal(v)
+	if err != nil || len(raw) <= c.MaxBytes {
+		return v
+	}
+	cut := c.MaxBytes
+	for cut > 0 && !utf8.RuneStart(raw[cut]) {
+		cut--
+	}
+	return string(raw[:cut]) + truncatedSuffix
+}
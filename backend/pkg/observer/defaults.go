@@ -61,6 +61,14 @@ func (o *ConsoleObserver) OnEvent(ctx context.Context, event Event) {
 		fields["elapsed_time"] = event.ElapsedTime.String()
 	}
 
+	// Inputs are only present when the engine captures them (see Capture)
+	if event.Inputs != nil {
+		fields["inputs"] = event.Inputs
+	}
+	if event.Result != nil {
+		fields["result"] = event.Result
+	}
+
 	msg := fmt.Sprintf("[%s] %s", event.Type, event.Status)
 
 	switch event.Type {
@@ -176,10 +184,16 @@ func (m *Manager) Register(observer Observer) {
 }
 
 // Notify sends an event to all registered observers asynchronously.
-// Each observer is called in a separate goroutine to prevent blocking.
+// Each observer is called in a separate goroutine to prevent blocking,
+// except SyncObservers, which are called inline in registration order.
 // If an observer panics, it will be recovered and not affect other observers or the main execution.
 func (m *Manager) Notify(ctx context.Context, event Event) {
 	for _, observer := range m.observers {
+		if sync, ok := observer.(SyncObserver); ok {
+			notifySync(ctx, sync, event)
+			continue
+		}
+
 		// Call observer in a goroutine for async execution
 		// Note: Explicit copy not strictly needed in Go 1.22+ but kept for compatibility
 		obs := observer
@@ -201,6 +215,14 @@ func (m *Manager) Notify(ctx context.Context, event Event) {
 	}
 }
 
+// notifySync calls a SyncObserver inline, recovering from any panic
+func notifySync(ctx context.Context, obs SyncObserver, event Event) {
+	defer func() {
+		_ = recover()
+	}()
+	obs.OnEvent(ctx, event)
+}
+
 // HasObservers returns true if any observers are registered
 func (m *Manager) HasObservers() bool {
 	return len(m.observers) > 0
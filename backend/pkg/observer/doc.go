@@ -165,10 +165,31 @@
 //	executionID := types.GetExecutionID(ctx)
 //	workflowID := types.GetWorkflowID(ctx)
 //
+// # Captured Data and Redaction
+//
+// Events carry node results, and node inputs on request. A Capture set on
+// the engine decides what they carry and sanitises it before any observer
+// sees the event, so console, logging, telemetry and history observers
+// all get the same data:
+//
+//	redactor, _ := observer.NewRedactor() // password, token, authorization, ...
+//	eng.SetEventCapture(&observer.Capture{
+//	    Inputs:   true,
+//	    Outputs:  true,
+//	    MaxBytes: 1024,
+//	    Redactor: redactor.WithSecrets(clients.Secrets()...),
+//	})
+//
+// The Redactor masks the values of matching object keys and any registered
+// secret, such as an HTTP client's SecureString credentials, wherever it
+// appears in a string or error message.
+//
 // # Performance Considerations
 //
 //   - Observers should not block
 //   - Use buffered channels for async processing
+//   - Observers run in their own goroutine, so events may arrive out of
+//     order; implement SyncObserver to receive them inline and in order
 //   - Minimize allocations in hot paths
 //   - Consider observer overhead for high-throughput
 //
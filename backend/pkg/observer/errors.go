@@ -13,4 +13,7 @@ var (
 	ErrObserverNotFound          = errors.New("observer not found")
 	ErrObserverAlreadyRegistered = errors.New("observer already registered")
 	ErrRegistrationFailed        = errors.New("observer registration failed")
+
+	// Redaction errors
+	ErrInvalidRedactionPattern = errors.New("invalid redaction pattern")
 )
@@ -22,6 +22,10 @@ const (
 	EventNodeEnd     EventType = "node_end"
 	EventNodeSuccess EventType = "node_success"
 	EventNodeFailure EventType = "node_failure"
+
+	// EventNodePaused is sent when a debugged execution pauses before a
+	// node; Metadata["reason"] says why
+	EventNodePaused EventType = "node_paused"
 )
 
 // ExecutionStatus represents the status of a node or workflow execution
@@ -32,6 +36,7 @@ const (
 	StatusSuccess   ExecutionStatus = "success"
 	StatusFailure   ExecutionStatus = "failure"
 	StatusCompleted ExecutionStatus = "completed"
+	StatusPaused    ExecutionStatus = "paused"
 )
 
 // Event represents an execution event with all relevant metadata
@@ -44,6 +49,7 @@ type Event struct {
 	// Execution context
 	ExecutionID string `json:"execution_id"`
 	WorkflowID  string `json:"workflow_id,omitempty"`
+	TenantID    string `json:"tenant_id,omitempty"`
 
 	// Node-specific data (empty for workflow-level events)
 	NodeID   string         `json:"node_id,omitempty"`
@@ -53,9 +59,11 @@ type Event struct {
 	StartTime   time.Time     `json:"start_time,omitempty"`
 	ElapsedTime time.Duration `json:"elapsed_time,omitempty"`
 
-	// Execution results
-	Result interface{} `json:"result,omitempty"`
-	Error  error       `json:"error,omitempty"`
+	// Execution results. Inputs holds a node's resolved inputs when the
+	// engine captures them (see Capture).
+	Inputs []interface{} `json:"inputs,omitempty"`
+	Result interface{}   `json:"result,omitempty"`
+	Error  error         `json:"error,omitempty"`
 
 	// Additional metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
@@ -69,6 +77,17 @@ type Observer interface {
 	OnEvent(ctx context.Context, event Event)
 }
 
+// SyncObserver is an Observer that the Manager calls inline, on the
+// goroutine that emits the event, instead of in a goroutine of its own.
+// Sync observers therefore see events in the order they were emitted.
+// OnEvent must be cheap and must never block; it delays the engine.
+type SyncObserver interface {
+	Observer
+
+	// Synchronous marks the observer for inline delivery
+	Synchronous()
+}
+
 // Logger defines the interface for custom logging.
 // This allows library consumers to integrate with their own logging systems.
 type Logger interface {
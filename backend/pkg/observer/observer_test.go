@@ -518,6 +518,44 @@ func (o *PanicObserver) OnEvent(ctx context.Context, event Event) {
 	panic("observer panic test")
 }
 
+// orderedObserver records events inline as a SyncObserver
+type orderedObserver struct {
+	types []EventType
+	panic bool
+}
+
+func (o *orderedObserver) OnEvent(ctx context.Context, event Event) {
+	o.types = append(o.types, event.Type)
+	if o.panic {
+		panic("sync observer panic test")
+	}
+}
+
+func (o *orderedObserver) Synchronous() {}
+
+func TestSyncObserverOrdering(t *testing.T) {
+	mgr := NewManager()
+	panicking := &orderedObserver{panic: true}
+	ordered := &orderedObserver{}
+	mgr.Register(panicking)
+	mgr.Register(ordered)
+
+	sequence := []EventType{EventWorkflowStart, EventNodeStart, EventNodeSuccess, EventNodeEnd, EventWorkflowEnd}
+	for _, eventType := range sequence {
+		mgr.Notify(context.Background(), Event{Type: eventType})
+	}
+
+	// No waiting: sync observers have seen every event when Notify returns
+	if len(ordered.types) != len(sequence) {
+		t.Fatalf("Expected %d events, got %d", len(sequence), len(ordered.types))
+	}
+	for i, eventType := range sequence {
+		if ordered.types[i] != eventType {
+			t.Errorf("Event %d: expected %s, got %s", i, eventType, ordered.types[i])
+		}
+	}
+}
+
 func TestMultipleObserversParallelExecution(t *testing.T) {
 	mgr := NewManager()
 
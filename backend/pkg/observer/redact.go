@@ -0,0 +1,181 @@
+package observer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces masked values, matching httpclient.SecureString
+const Redacted = "***REDACTED***"
+
+// DefaultSensitiveKeys are the key patterns NewRedactor uses when given
+// none. Patterns are regular expressions matched case-insensitively
+// anywhere in a key, so "token" also masks "access_token". Workflow
+// export hides HTTP client headers and query parameters by the same list.
+var DefaultSensitiveKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"credential",
+	"signature",
+	`api[_-]?key`,
+	`private[_-]?key`,
+	`^(?:key|sig)$`,
+}
+
+// Redactor masks sensitive data: the values of object keys matching its
+// key patterns, including objects held as JSON text, and registered secret
+// values wherever they appear in a string. A Redactor is immutable and
+// safe for concurrent use; a nil Redactor masks nothing.
+type Redactor struct {
+	keys    *regexp.Regexp
+	secrets []string
+}
+
+// NewRedactor creates a redactor masking keys that match keyPatterns, or
+// DefaultSensitiveKeys when none are given
+func NewRedactor(keyPatterns ...string) (*Redactor, error) {
+	if len(keyPatterns) == 0 {
+		keyPatterns = DefaultSensitiveKeys
+	}
+	for _, pattern := range keyPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidRedactionPattern, pattern, err)
+		}
+	}
+	keys := regexp.MustCompile("(?i)(?:" + strings.Join(keyPatterns, ")|(?:") + ")")
+	return &Redactor{keys: keys}, nil
+}
+
+// SensitiveKey reports whether the values of key are masked. A nil
+// Redactor masks nothing.
+func (r *Redactor) SensitiveKey(key string) bool {
+	return r != nil && r.keys.MatchString(key)
+}
+
+// MinSecretLength is the shortest secret value WithSecrets masks. Shorter
+// values, such as a one-letter password, would mask that substring in
+// every string.
+const MinSecretLength = 6
+
+// WithSecrets returns a copy of the redactor that also masks values, such
+// as the credentials of registered HTTP clients. Values shorter than
+// MinSecretLength are ignored.
+func (r *Redactor) WithSecrets(values ...string) *Redactor {
+	if r == nil {
+		return nil
+	}
+	secrets := make([]string, 0, len(r.secrets)+len(values))
+	secrets = append(secrets, r.secrets...)
+	for _, value := range values {
+		if len(value) >= MinSecretLength {
+			secrets = append(secrets, value)
+		}
+	}
+	return &Redactor{keys: r.keys, secrets: secrets}
+}
+
+// Redact returns a copy of v with sensitive data masked. Values that are
+// not JSON-shaped are converted through their JSON encoding first.
+func (r *Redactor) Redact(v interface{}) interface{} {
+	if r == nil {
+		return v
+	}
+	return r.redact(v)
+}
+
+// RedactString masks the registered secrets in s
+func (r *Redactor) RedactString(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// RedactError returns err with the registered secrets masked in its
+// message. The original error stays reachable through errors.Unwrap.
+func (r *Redactor) RedactError(err error) error {
+	if r == nil || err == nil {
+		return err
+	}
+	msg := r.RedactString(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+func (r *Redactor) redact(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, bool, float64, float32, int, int64, int32, uint, uint64, json.Number:
+		return val
+	case string:
+		return r.redactJSONString(r.RedactString(val))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			if r.keys.MatchString(key) {
+				out[key] = Redacted
+			} else {
+				out[key] = r.redact(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.redact(item)
+		}
+		return out
+	default:
+		// Typed maps, slices and structs are inspected as JSON
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return val
+		}
+		var generic interface{}
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return val
+		}
+		return r.redact(generic)
+	}
+}
+
+// redactJSONString masks sensitive keys in s when it holds a JSON object
+// or array, such as an HTTP body returned as text. s is re-encoded only if
+// something was masked.
+func (r *Redactor) redactJSONString(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return s
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return s
+	}
+	before, _ := json.Marshal(decoded)
+	after, err := json.Marshal(r.redact(decoded))
+	if err != nil || bytes.Equal(before, after) {
+		return s
+	}
+	return string(after)
+}
+
+// redactedError is an error whose message had secrets masked
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,201 @@
+package observer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor, err := NewRedactor()
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	// Values too short to be told apart from ordinary text are not masked,
+	// so "ada" below stays readable
+	redactor = redactor.WithSecrets("s3cr3t-value", "", "ad")
+
+	type login struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+
+	tests := []struct {
+		name  string
+		input interface{}
+		want  interface{}
+	}{
+		{
+			name:  "scalar",
+			input: 42.0,
+			want:  42.0,
+		},
+		{
+			name: "sensitive keys at any depth",
+			input: map[string]interface{}{
+				"user":          "ada",
+				"Authorization": "Bearer abc",
+				"nested":        map[string]interface{}{"access_token": "abc", "count": 2.0},
+				"list":          []interface{}{map[string]interface{}{"api-key": "abc"}},
+			},
+			want: map[string]interface{}{
+				"user":          "ada",
+				"Authorization": Redacted,
+				"nested":        map[string]interface{}{"access_token": Redacted, "count": 2.0},
+				"list":          []interface{}{map[string]interface{}{"api-key": Redacted}},
+			},
+		},
+		{
+			name:  "signatures and exact key names",
+			input: map[string]interface{}{"sig": "abc", "Key": "abc", "x-signature": "abc", "cache_key_count": 2.0},
+			want:  map[string]interface{}{"sig": Redacted, "Key": Redacted, "x-signature": Redacted, "cache_key_count": 2.0},
+		},
+		{
+			name:  "secret values inside strings",
+			input: []interface{}{"s3cr3t-value", "key=s3cr3t-value&x=1"},
+			want:  []interface{}{Redacted, "key=" + Redacted + "&x=1"},
+		},
+		{
+			name:  "JSON text",
+			input: `{"user": "ada", "password": "hunter2"}`,
+			want:  `{"password":"***REDACTED***","user":"ada"}`,
+		},
+		{
+			name:  "JSON text without sensitive keys is kept as is",
+			input: `{"user": "ada"}`,
+			want:  `{"user": "ada"}`,
+		},
+		{
+			name:  "typed values are inspected as JSON",
+			input: login{User: "ada", Password: "hunter2"},
+			want:  map[string]interface{}{"user": "ada", "password": Redacted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactor.Redact(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Redact() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("input is not modified", func(t *testing.T) {
+		input := map[string]interface{}{"token": "abc"}
+		redactor.Redact(input)
+		if input["token"] != "abc" {
+			t.Errorf("Redact() modified its input: %v", input)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cause := errors.New("GET https://api.example.com/?key=s3cr3t-value failed")
+		err := redactor.RedactError(cause)
+		if err.Error() != "GET https://api.example.com/?key="+Redacted+" failed" {
+			t.Errorf("RedactError() = %q", err)
+		}
+		if !errors.Is(err, cause) {
+			t.Error("RedactError() lost the original error")
+		}
+	})
+
+	t.Run("custom patterns", func(t *testing.T) {
+		custom, err := NewRedactor("^ssn$")
+		if err != nil {
+			t.Fatalf("NewRedactor() error = %v", err)
+		}
+		got := custom.Redact(map[string]interface{}{"SSN": "123", "password": "x"})
+		want := map[string]interface{}{"SSN": Redacted, "password": "x"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Redact() = %v, want %v", got, want)
+		}
+		if _, err := NewRedactor("("); !errors.Is(err, ErrInvalidRedactionPattern) {
+			t.Errorf("NewRedactor(\"(\") error = %v, want ErrInvalidRedactionPattern", err)
+		}
+	})
+
+	t.Run("sensitive key", func(t *testing.T) {
+		if !redactor.SensitiveKey("X-Session-Cookie") || redactor.SensitiveKey("Accept") {
+			t.Error("SensitiveKey() does not match the redacted keys")
+		}
+	})
+
+	t.Run("nil redactor", func(t *testing.T) {
+		var none *Redactor
+		if none.SensitiveKey("token") {
+			t.Error("nil SensitiveKey() = true, want false")
+		}
+		input := map[string]interface{}{"token": "abc"}
+		if got := none.Redact(input); !reflect.DeepEqual(got, input) {
+			t.Errorf("Redact() = %v, want input unchanged", got)
+		}
+	})
+}
+
+func TestCapture(t *testing.T) {
+	redactor, err := NewRedactor()
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	event := Event{
+		Type:   EventNodeFailure,
+		Inputs: []interface{}{map[string]interface{}{"password": "p"}, "0123456789abcdef"},
+		Result: map[string]interface{}{"token": "t"},
+		Error:  errors.New("rejected token-123"),
+	}
+
+	tests := []struct {
+		name       string
+		capture    *Capture
+		wantInputs []interface{}
+		wantResult interface{}
+		wantError  string
+	}{
+		{
+			name:       "nil capture leaves events unchanged",
+			capture:    nil,
+			wantInputs: event.Inputs,
+			wantResult: event.Result,
+			wantError:  "rejected token-123",
+		},
+		{
+			name:       "nothing captured",
+			capture:    &Capture{},
+			wantInputs: nil,
+			wantResult: nil,
+			wantError:  "rejected token-123",
+		},
+		{
+			name:    "masked and truncated",
+			capture: &Capture{Inputs: true, Outputs: true, MaxBytes: 8, Redactor: redactor.WithSecrets("token-123")},
+			wantInputs: []interface{}{
+				`{"passwo` + truncatedSuffix,
+				`"0123456` + truncatedSuffix,
+			},
+			wantResult: `{"token"` + truncatedSuffix,
+			wantError:  "rejected " + Redacted,
+		},
+		{
+			name:       "masked",
+			capture:    &Capture{Inputs: true, Outputs: true, Redactor: redactor},
+			wantInputs: []interface{}{map[string]interface{}{"password": Redacted}, "0123456789abcdef"},
+			wantResult: map[string]interface{}{"token": Redacted},
+			wantError:  "rejected token-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.capture.Apply(event)
+			if !reflect.DeepEqual(got.Inputs, tt.wantInputs) {
+				t.Errorf("Inputs = %#v, want %#v", got.Inputs, tt.wantInputs)
+			}
+			if !reflect.DeepEqual(got.Result, tt.wantResult) {
+				t.Errorf("Result = %#v, want %#v", got.Result, tt.wantResult)
+			}
+			if got.Error.Error() != tt.wantError {
+				t.Errorf("Error = %q, want %q", got.Error, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Package openapi builds OpenAPI 3 documents from Go types.
+//
+// # Overview
+//
+// The server describes its REST API with a table of operations whose
+// request and response bodies are the handlers' own Go structs. A
+// Generator turns those structs into JSON schemas by reflection, following
+// the same rules as encoding/json:
+//
+//   - the json tag names a property; "-" skips it
+//   - fields without omitempty are required
+//   - embedded structs without a tag have their fields promoted
+//   - named struct types become components referenced with $ref
+//
+// Because the schemas are derived from the types, a renamed or retyped
+// field changes the document with no extra step:
+//
+//	g := openapi.NewGenerator()
+//	g.SetField(SaveWorkflowRequest{}, "Data", g.Schema(types.Payload{}))
+//	schema := g.Schema(SaveWorkflowResponse{})
+//	doc.Components.Schemas = g.Schemas()
+//
+// Fields typed json.RawMessage or interface{} accept any value; SetField and
+// SetType give them, or types with custom JSON encodings, a precise schema.
+//
+// # Validation
+//
+// Document.Validate checks a JSON value against a schema of the document.
+// It is strict: properties the schema does not declare are errors. Contract
+// tests use it to catch handlers whose responses drift from the document.
+package openapi
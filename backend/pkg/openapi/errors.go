@@ -0,0 +1,9 @@
+package openapi
+
+import "errors"
+
+// Sentinel errors for OpenAPI documents
+var (
+	ErrUnknownSchema   = errors.New("unknown schema")
+	ErrSchemaViolation = errors.New("value does not match schema")
+)
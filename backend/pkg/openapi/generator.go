@@ -0,0 +1,215 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Generator derives schemas from Go types and collects the named ones as
+// components
+type Generator struct {
+	schemas  map[string]*Schema
+	names    map[reflect.Type]string
+	types    map[reflect.Type]*Schema
+	fields   map[fieldKey]*Schema
+	optional map[fieldKey]bool
+}
+
+// fieldKey identifies a struct field with a SetField schema
+type fieldKey struct {
+	owner reflect.Type
+	field string
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// NewGenerator creates a generator with no components
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas:  make(map[string]*Schema),
+		names:    make(map[reflect.Type]string),
+		types:    make(map[reflect.Type]*Schema),
+		fields:   make(map[fieldKey]*Schema),
+		optional: make(map[fieldKey]bool),
+	}
+}
+
+// SetType makes every value of v's type use schema
+func (g *Generator) SetType(v interface{}, schema *Schema) {
+	g.types[reflect.TypeOf(v)] = schema
+}
+
+// SetField makes the named Go field of struct v use schema
+func (g *Generator) SetField(v interface{}, field string, schema *Schema) {
+	g.fields[fieldKey{owner: reflect.TypeOf(v), field: field}] = schema
+}
+
+// SetOptional marks the named Go field of struct v as not required, for
+// fields without omitempty that decoding tolerates missing
+func (g *Generator) SetOptional(v interface{}, field string) {
+	g.optional[fieldKey{owner: reflect.TypeOf(v), field: field}] = true
+}
+
+// Define adds a hand-written component schema and returns a reference to it
+func (g *Generator) Define(name string, schema *Schema) *Schema {
+	g.schemas[name] = schema
+	return Ref(name)
+}
+
+// Schemas returns the component schemas collected so far
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Schema returns the schema of v's type. Named struct types are added as
+// components and returned as references.
+func (g *Generator) Schema(v interface{}) *Schema {
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Ptr {
+		return g.schemaOf(t.Elem())
+	}
+	if schema, ok := g.types[t]; ok {
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(jsonMarshalerType):
+		return &Schema{}
+	case t.Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return Ref(g.component(t))
+	}
+	// Interfaces, and kinds JSON cannot encode, accept anything
+	return &Schema{}
+}
+
+// component returns the component name of named struct type t, adding its
+// schema on first use. Names are the Go type name, qualified with the
+// package name when two packages use the same one.
+func (g *Generator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{} // placeholder for recursive types
+	g.schemas[name] = g.structSchema(t)
+	return name
+}
+
+// structSchema returns the object schema of struct type t
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the JSON properties of struct type t to schema, promoting
+// the fields of untagged embedded structs like encoding/json
+func (g *Generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		key := fieldKey{owner: t, field: field.Name}
+		property, ok := g.fields[key]
+		if !ok {
+			property = g.schemaOf(field.Type)
+		}
+		omitEmpty := strings.Contains(","+options+",", ",omitempty,")
+		if !omitEmpty && !g.optional[key] {
+			schema.Required = append(schema.Required, name)
+			switch field.Type.Kind() {
+			case reflect.Ptr, reflect.Slice, reflect.Map:
+				property = nullable(property)
+			}
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// nullable returns schema also accepting null. A reference cannot carry
+// siblings in OpenAPI 3.0, so it is wrapped in allOf.
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+	}
+	if schema.Type == "" {
+		return schema // already accepts anything
+	}
+	copied := *schema
+	copied.Nullable = true
+	return &copied
+}
@@ -0,0 +1,140 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type base struct {
+	Label *string `json:"label,omitempty"`
+}
+
+type item struct {
+	base
+	ID      string            `json:"id"`
+	Count   int               `json:"count"`
+	Score   float64           `json:"score,omitempty"`
+	Tags    []string          `json:"tags"`
+	Meta    map[string]int    `json:"meta,omitempty"`
+	Created time.Time         `json:"created"`
+	Next    *item             `json:"next,omitempty"`
+	Raw     json.RawMessage   `json:"raw,omitempty"`
+	Any     interface{}       `json:"any,omitempty"`
+	Secret  string            `json:"-"`
+	Extra   map[string]string `json:"extra"`
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator()
+	ref := g.Schema(item{})
+	if ref.Ref != "#/components/schemas/item" {
+		t.Fatalf("Expected a reference, got %+v", ref)
+	}
+
+	schema := g.Schemas()["item"]
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	if len(names) != 11 {
+		t.Errorf("Expected 11 properties including the promoted label, got %v", names)
+	}
+	if _, ok := schema.Properties["Secret"]; ok {
+		t.Error(`Field tagged "-" should be skipped`)
+	}
+	if got := strings.Join(schema.Required, ","); got != "id,count,tags,created,extra" {
+		t.Errorf("Required = %s", got)
+	}
+
+	tests := []struct {
+		property string
+		expected Schema
+	}{
+		{"id", Schema{Type: "string"}},
+		{"count", Schema{Type: "integer", Format: "int32"}},
+		{"score", Schema{Type: "number", Format: "double"}},
+		{"tags", Schema{Type: "array", Items: &Schema{Type: "string"}, Nullable: true}},
+		{"meta", Schema{Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int32"}}},
+		{"created", Schema{Type: "string", Format: "date-time"}},
+		{"next", Schema{Ref: "#/components/schemas/item"}},
+		{"raw", Schema{}},
+		{"any", Schema{}},
+		{"label", Schema{Type: "string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			if got := schema.Properties[tt.property]; !reflect.DeepEqual(*got, tt.expected) {
+				t.Errorf("Schema = %+v, want %+v", *got, tt.expected)
+			}
+		})
+	}
+
+	g2 := NewGenerator()
+	g2.SetField(item{}, "Raw", &Schema{Type: "object"})
+	g2.SetType(time.Time{}, &Schema{Type: "integer"})
+	g2.SetOptional(item{}, "Extra")
+	g2.Schema(item{})
+	overridden := g2.Schemas()["item"]
+	if overridden.Properties["raw"].Type != "object" || overridden.Properties["created"].Type != "integer" {
+		t.Errorf("Overrides not applied: raw %+v, created %+v", overridden.Properties["raw"], overridden.Properties["created"])
+	}
+	if got := strings.Join(overridden.Required, ","); got != "id,count,tags,created" {
+		t.Errorf("Required with optional extra = %s", got)
+	}
+}
+
+func TestSchemaExtensions(t *testing.T) {
+	data, err := json.Marshal(Schema{Type: "object", Extensions: map[string]interface{}{"x-kind": "node"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"type":"object","x-kind":"node"}` {
+		t.Errorf("Marshaled %s", data)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	g := NewGenerator()
+	schema := g.Schema(item{})
+	doc := &Document{Components: Components{Schemas: g.Schemas()}}
+
+	valid := `{"id": "a", "count": 1, "tags": null, "created": "2026-01-01T00:00:00Z", "extra": {"k": "v"},
+		"next": {"id": "b", "count": 2, "tags": ["x"], "created": "2026-01-01T00:00:00Z", "extra": null, "label": "B"}}`
+	if err := doc.Validate(schema, []byte(valid)); err != nil {
+		t.Errorf("Valid value rejected: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		message string
+	}{
+		{"Missing required", `{"id": "a"}`, `missing required property "count"`},
+		{"Undeclared property", `{"id": "a", "count": 1, "tags": [], "created": "", "extra": {}, "color": "red"}`, `undeclared property "color"`},
+		{"Wrong type", `{"id": 1, "count": 1, "tags": [], "created": "", "extra": {}}`, `$.id: expected a string`},
+		{"Fractional integer", `{"id": "a", "count": 1.5, "tags": [], "created": "", "extra": {}}`, `$.count: 1.5 is not an integer`},
+		{"Nested", `{"id": "a", "count": 1, "tags": [2], "created": "", "extra": {}}`, `$.tags[0]: expected a string`},
+		{"Additional property type", `{"id": "a", "count": 1, "tags": [], "created": "", "extra": {"k": 1}}`, `$.extra.k: expected a string`},
+		{"Null not allowed", `{"id": null, "count": 1, "tags": [], "created": "", "extra": {}}`, `$.id: null is not allowed`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.Validate(schema, []byte(tt.value))
+			if !errors.Is(err, ErrSchemaViolation) || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected violation %q, got %v", tt.message, err)
+			}
+		})
+	}
+
+	status := &Schema{Type: "string", Enum: []interface{}{"queued", "running"}}
+	if err := doc.Validate(status, []byte(`"done"`)); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Expected enum violation, got %v", err)
+	}
+	if err := doc.Validate(Ref("missing"), []byte(`{}`)); !errors.Is(err, ErrUnknownSchema) {
+		t.Errorf("Expected ErrUnknownSchema, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Validate checks the JSON document data against schema, resolving
+// references against the document's components. Properties that an object
+// schema does not declare are reported as errors.
+func (d *Document) Validate(schema *Schema, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaViolation, err)
+	}
+	return d.validate(schema, value, "$")
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, path string) error {
+	if schema.Ref != "" {
+		resolved, err := d.resolve(schema.Ref)
+		if err != nil {
+			return err
+		}
+		return d.validate(resolved, value, path)
+	}
+
+	if value == nil {
+		if schema.Nullable || (schema.Type == "" && len(schema.AllOf) == 0 && len(schema.AnyOf) == 0) {
+			return nil
+		}
+		return violation(path, "null is not allowed")
+	}
+
+	for _, sub := range schema.AllOf {
+		if err := d.validate(sub, value, path); err != nil {
+			return err
+		}
+	}
+	if len(schema.AnyOf) > 0 {
+		var errs []string
+		for _, sub := range schema.AnyOf {
+			err := d.validate(sub, value, path)
+			if err == nil {
+				errs = nil
+				break
+			}
+			errs = append(errs, err.Error())
+		}
+		if errs != nil {
+			return violation(path, "matches none of anyOf: "+strings.Join(errs, "; "))
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		return violation(path, fmt.Sprintf("%v is not one of %v", value, schema.Enum))
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "string":
+		if _, ok := value.(string); !ok {
+			return violation(path, "expected a string")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return violation(path, "expected a boolean")
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return violation(path, "expected a number")
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return violation(path, "expected an integer")
+		}
+		if _, err := n.Int64(); err != nil {
+			return violation(path, fmt.Sprintf("%s is not an integer", n))
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return violation(path, "expected an array")
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				if err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return violation(path, "expected an object")
+		}
+		return d.validateObject(schema, object, path)
+	default:
+		return violation(path, fmt.Sprintf("unsupported schema type %q", schema.Type))
+	}
+	return nil
+}
+
+// validateObject checks required, declared and additional properties
+func (d *Document) validateObject(schema *Schema, object map[string]interface{}, path string) error {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return violation(path, fmt.Sprintf("missing required property %q", name))
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propertyPath := path + "." + key
+		if property, ok := schema.Properties[key]; ok {
+			if err := d.validate(property, object[key], propertyPath); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case schema.AdditionalProperties != nil:
+			if err := d.validate(schema.AdditionalProperties, object[key], propertyPath); err != nil {
+				return err
+			}
+		case schema.Properties != nil:
+			return violation(path, fmt.Sprintf("undeclared property %q", key))
+		}
+	}
+	return nil
+}
+
+// resolve returns the component schema named by ref
+func (d *Document) resolve(ref string) (*Schema, error) {
+	name, ok := strings.CutPrefix(ref, refPrefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, ref)
+	}
+	schema, ok := d.Components.Schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, ref)
+	}
+	return schema, nil
+}
+
+// inEnum reports whether value is one of the enum values, comparing their
+// JSON encodings so named string types and numbers match
+func inEnum(enum []interface{}, value interface{}) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range enum {
+		if a, err := json.Marshal(allowed); err == nil && bytes.Equal(a, encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// violation returns an ErrSchemaViolation at path
+func violation(path, message string) error {
+	return fmt.Errorf("%w: %s: %s", ErrSchemaViolation, path, message)
+}
@@ -0,0 +1,46 @@
+// Package params binds caller-supplied inputs to saved workflows.
+//
+// # Overview
+//
+// A workflow payload may declare named inputs next to its nodes and edges:
+//
+//	{
+//	  "inputs": [
+//	    {"name": "amount", "type": "number", "required": true},
+//	    {"name": "currency", "type": "string", "default": "EUR"},
+//	    {"name": "threshold", "type": "number", "node": "n1"}
+//	  ],
+//	  "nodes": [...],
+//	  "edges": [...]
+//	}
+//
+// Bind validates a map of input values against that declaration, fills in
+// defaults, and returns a copy of the payload with the values applied. The
+// result is an ordinary payload that can be handed to engine.NewWithConfig,
+// so a saved workflow can be called like a function with different data on
+// every execution.
+//
+// # Binding
+//
+// An input with a "node" is written into that input node's value field
+// (number, text_input, boolean_input, date_input or datetime_input).
+// Any other input replaces every context_constant or context_variable value
+// with the same name. Inputs that match no context value are added as
+// constants of a synthesized context_constant node (InputsNodeID), so they
+// are always available as context.<name> in expressions and {{const.<name>}}
+// in templates.
+//
+// Optional inputs that are omitted and have no default leave the saved
+// payload untouched.
+//
+// Apply writes values the workflow does not declare, such as the HTTP
+// request that triggered it, the same way: into a named input node, or
+// into context values of the same name.
+//
+// # Validation
+//
+// Schema checks the declaration itself: unique identifier names, known types,
+// defaults that match their type and node bindings that point at compatible
+// input nodes. Bind reports every problem with the supplied values at once as
+// a *ValidationError, which matches ErrInvalidInputs with errors.Is.
+package params
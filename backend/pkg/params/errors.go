@@ -0,0 +1,47 @@
+package params
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for workflow inputs
+var (
+	ErrInvalidSchema = errors.New("invalid input schema")
+	ErrInvalidInputs = errors.New("invalid workflow inputs")
+)
+
+// FieldError describes a problem with one input value
+type FieldError struct {
+	Input   string `json:"input"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found in the supplied input values
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fmt.Sprintf("%s: %s", fe.Input, fe.Message)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidInputs, strings.Join(parts, "; "))
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidInputs)
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInputs
+}
+
+func (e *ValidationError) add(input, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Input: input, Message: fmt.Sprintf(format, args...)})
+}
+
+// schemaError wraps a problem with the input declaration
+func schemaError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSchema, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,460 @@
+package params
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// Input types accepted by types.InputDefinition.Type
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeAny     = "any"
+)
+
+// InputsNodeID is the ID of the context_constant node synthesized for
+// inputs that match no existing context value.
+const InputsNodeID = "__inputs"
+
+// inputNodeFields maps input node types to the data field holding their
+// value and the input type that field accepts.
+var inputNodeFields = map[types.NodeType]struct {
+	field     string
+	inputType string
+}{
+	types.NodeTypeNumber:        {"value", TypeNumber},
+	types.NodeTypeTextInput:     {"text", TypeString},
+	types.NodeTypeBooleanInput:  {"boolean_value", TypeBoolean},
+	types.NodeTypeDateInput:     {"date_value", TypeString},
+	types.NodeTypeDateTimeInput: {"datetime_value", TypeString},
+}
+
+// document is a payload decoded just far enough to read and patch it.
+// Everything else in the payload is carried through untouched.
+type document struct {
+	raw    map[string]interface{}
+	nodes  []map[string]interface{}
+	inputs []types.InputDefinition
+}
+
+// Schema returns the validated input declaration of a workflow payload.
+// A payload without inputs returns an empty schema.
+func Schema(payload []byte) ([]types.InputDefinition, error) {
+	doc, err := parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	return doc.inputs, nil
+}
+
+// Resolve checks values against the declared inputs and returns the value
+// for every input that has one, with defaults applied.
+func Resolve(defs []types.InputDefinition, values map[string]interface{}) (map[string]interface{}, error) {
+	verr := &ValidationError{}
+	resolved := make(map[string]interface{}, len(defs))
+	declared := make(map[string]bool, len(defs))
+
+	for _, def := range defs {
+		declared[def.Name] = true
+
+		value, ok := values[def.Name]
+		if !ok || value == nil {
+			if def.Default != nil {
+				resolved[def.Name] = def.Default
+			} else if def.Required {
+				verr.add(def.Name, "required input is missing")
+			}
+			continue
+		}
+
+		if msg := checkType(def.Type, value); msg != "" {
+			verr.add(def.Name, "%s", msg)
+			continue
+		}
+		resolved[def.Name] = value
+	}
+
+	for _, name := range sortedKeys(values) {
+		if !declared[name] {
+			verr.add(name, "input is not declared by the workflow")
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+	return resolved, nil
+}
+
+// Bind validates values against the inputs declared in payload and returns
+// a copy of the payload with the values applied.
+func Bind(payload []byte, values map[string]interface{}) ([]byte, error) {
+	doc, err := parse(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := Resolve(doc.inputs, values)
+	if err != nil {
+		return nil, err
+	}
+	return doc.apply(doc.inputs, resolved, payload)
+}
+
+// Apply writes values the workflow does not declare as inputs into a
+// payload, such as the HTTP request that started it. A value whose name
+// nodes maps to a node ID is written into that input node and must match
+// its type; the others are bound to context values like declared inputs
+// without a node. Nil values are skipped. The payload may already be bound.
+func Apply(payload []byte, values map[string]interface{}, nodes map[string]string) ([]byte, error) {
+	doc, err := decode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	verr := &ValidationError{}
+	defs := make([]types.InputDefinition, 0, len(values))
+	resolved := make(map[string]interface{}, len(values))
+	for _, name := range sortedKeys(values) {
+		def := types.InputDefinition{Name: name, Type: TypeAny, Node: nodes[name]}
+		value := values[name]
+		if def.Node != "" {
+			node := doc.node(def.Node)
+			if node == nil {
+				verr.add(name, "node %q not found", def.Node)
+				continue
+			}
+			target, ok := inputNodeFields[nodeType(node)]
+			if !ok {
+				verr.add(name, "node %q is not an input node", def.Node)
+				continue
+			}
+			def.Type = target.inputType
+			if value != nil {
+				if msg := checkType(def.Type, value); msg != "" {
+					verr.add(name, "%s node %q: %s", nodeType(node), def.Node, msg)
+					continue
+				}
+			}
+		}
+		if value == nil {
+			continue
+		}
+		defs = append(defs, def)
+		resolved[name] = value
+	}
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+	return doc.apply(defs, resolved, payload)
+}
+
+// apply writes the resolved values of defs into the document and encodes
+// it. Without values the original payload is returned.
+func (doc *document) apply(defs []types.InputDefinition, resolved map[string]interface{}, payload []byte) ([]byte, error) {
+	if len(resolved) == 0 {
+		return payload, nil
+	}
+
+	var synthesized []interface{}
+	for _, def := range defs {
+		value, ok := resolved[def.Name]
+		if !ok {
+			continue
+		}
+
+		if def.Node != "" {
+			node := doc.node(def.Node)
+			data := nodeData(node)
+			data[inputNodeFields[nodeType(node)].field] = value
+			continue
+		}
+
+		if !doc.bindContext(def.Name, value) {
+			synthesized = append(synthesized, map[string]interface{}{
+				"name":  def.Name,
+				"value": value,
+				"type":  contextType(def.Type, value),
+			})
+		}
+	}
+
+	if existing := doc.node(InputsNodeID); existing != nil && len(synthesized) > 0 {
+		data := nodeData(existing)
+		values, _ := data["context_values"].([]interface{})
+		data["context_values"] = append(values, synthesized...)
+	} else if len(synthesized) > 0 {
+		nodes, _ := doc.raw["nodes"].([]interface{})
+		doc.raw["nodes"] = append(nodes, map[string]interface{}{
+			"id":   InputsNodeID,
+			"type": string(types.NodeTypeContextConstant),
+			"data": map[string]interface{}{"context_values": synthesized},
+		})
+	}
+
+	out, err := json.Marshal(doc.raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bound payload: %w", err)
+	}
+	return out, nil
+}
+
+// parse decodes a payload and validates its input declaration
+func parse(payload []byte) (*document, error) {
+	doc, err := decode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	rawInputs, ok := doc.raw["inputs"]
+	if !ok || rawInputs == nil {
+		return doc, nil
+	}
+
+	encoded, err := json.Marshal(rawInputs)
+	if err != nil {
+		return nil, schemaError("%v", err)
+	}
+	if err := json.Unmarshal(encoded, &doc.inputs); err != nil {
+		return nil, schemaError("inputs must be an array of input definitions: %v", err)
+	}
+
+	if err := doc.validate(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decode decodes a payload and finds its nodes
+func decode(payload []byte) (*document, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	doc := &document{}
+	if err := dec.Decode(&doc.raw); err != nil {
+		return nil, fmt.Errorf("invalid workflow payload: %w", err)
+	}
+
+	if nodes, ok := doc.raw["nodes"].([]interface{}); ok {
+		for _, n := range nodes {
+			if node, ok := n.(map[string]interface{}); ok {
+				doc.nodes = append(doc.nodes, node)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// validate checks the input declaration against the payload's nodes
+func (doc *document) validate() error {
+	seen := make(map[string]bool, len(doc.inputs))
+
+	for i, def := range doc.inputs {
+		if !isIdentifier(def.Name) {
+			return schemaError("input %d: name %q must start with a letter or underscore and contain only letters, digits and underscores", i, def.Name)
+		}
+		if seen[def.Name] {
+			return schemaError("input %q is declared more than once", def.Name)
+		}
+		seen[def.Name] = true
+
+		switch def.Type {
+		case TypeString, TypeNumber, TypeBoolean, TypeObject, TypeArray, TypeAny:
+		default:
+			return schemaError("input %q: unknown type %q", def.Name, def.Type)
+		}
+
+		if def.Default != nil {
+			if msg := checkType(def.Type, def.Default); msg != "" {
+				return schemaError("input %q: default %s", def.Name, msg)
+			}
+		}
+
+		if def.Node == "" {
+			continue
+		}
+		node := doc.node(def.Node)
+		if node == nil {
+			return schemaError("input %q: node %q not found", def.Name, def.Node)
+		}
+		target, ok := inputNodeFields[nodeType(node)]
+		if !ok {
+			return schemaError("input %q: node %q is not an input node", def.Name, def.Node)
+		}
+		if target.inputType != def.Type {
+			return schemaError("input %q: type %s cannot be bound to %s node %q", def.Name, def.Type, nodeType(node), def.Node)
+		}
+	}
+
+	if doc.node(InputsNodeID) != nil {
+		return schemaError("node ID %q is reserved for workflow inputs", InputsNodeID)
+	}
+
+	return nil
+}
+
+// node returns the node with the given ID, or nil
+func (doc *document) node(id string) map[string]interface{} {
+	for _, node := range doc.nodes {
+		if nodeID, _ := node["id"].(string); nodeID == id {
+			return node
+		}
+	}
+	return nil
+}
+
+// bindContext sets every context value called name and reports whether any
+// was found. Both the context_values list and the legacy single-value form
+// are supported.
+func (doc *document) bindContext(name string, value interface{}) bool {
+	bound := false
+	for _, node := range doc.nodes {
+		switch nodeType(node) {
+		case types.NodeTypeContextConstant, types.NodeTypeContextVariable:
+		default:
+			continue
+		}
+
+		data := nodeData(node)
+		if values, ok := data["context_values"].([]interface{}); ok {
+			for _, v := range values {
+				if cv, ok := v.(map[string]interface{}); ok && cv["name"] == name {
+					cv["value"] = value
+					bound = true
+				}
+			}
+		}
+		if data["context_name"] == name {
+			data["context_value"] = value
+			bound = true
+		}
+	}
+	return bound
+}
+
+// nodeType returns a node's declared type, or infers it from its data the
+// same way the engine does for the node types params cares about.
+func nodeType(node map[string]interface{}) types.NodeType {
+	if t, ok := node["type"].(string); ok && t != "" {
+		return types.NodeType(t)
+	}
+
+	data := nodeData(node)
+	for _, candidate := range []types.NodeType{
+		types.NodeTypeNumber,
+		types.NodeTypeTextInput,
+		types.NodeTypeBooleanInput,
+		types.NodeTypeDateInput,
+		types.NodeTypeDateTimeInput,
+	} {
+		if _, ok := data[inputNodeFields[candidate].field]; ok {
+			return candidate
+		}
+	}
+	if _, ok := data["context_name"]; ok {
+		return types.NodeTypeContextVariable
+	}
+	if _, ok := data["context_values"]; ok {
+		return types.NodeTypeContextVariable
+	}
+	return ""
+}
+
+// nodeData returns a node's data object, creating it if needed
+func nodeData(node map[string]interface{}) map[string]interface{} {
+	data, ok := node["data"].(map[string]interface{})
+	if !ok {
+		data = make(map[string]interface{})
+		node["data"] = data
+	}
+	return data
+}
+
+// checkType returns a description of the mismatch, or "" if value fits
+func checkType(inputType string, value interface{}) string {
+	ok := true
+	switch inputType {
+	case TypeString:
+		_, ok = value.(string)
+	case TypeNumber:
+		switch v := value.(type) {
+		case float64:
+			ok = !math.IsNaN(v) && !math.IsInf(v, 0)
+		case json.Number:
+		default:
+			ok = false
+		}
+	case TypeBoolean:
+		_, ok = value.(bool)
+	case TypeObject:
+		_, ok = value.(map[string]interface{})
+	case TypeArray:
+		_, ok = value.([]interface{})
+	}
+	if ok {
+		return ""
+	}
+	return fmt.Sprintf("expected %s, got %s", inputType, jsonType(value))
+}
+
+// contextType picks the context value type for a synthesized constant
+func contextType(inputType string, value interface{}) string {
+	if inputType != TypeAny {
+		return inputType
+	}
+	return jsonType(value)
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return TypeString
+	case float64, json.Number:
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		return TypeArray
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// isIdentifier reports whether name can be used as context.<name>
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns map keys in a stable order for error reporting
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,209 @@
+package params_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+const pricingWorkflow = `{
+	"inputs": [
+		{"name": "quantity", "type": "number", "required": true, "node": "qty"},
+		{"name": "price", "type": "number", "default": 2.5},
+		{"name": "label", "type": "string"},
+		{"name": "tags", "type": "array"}
+	],
+	"nodes": [
+		{"id": "qty", "data": {"value": 1}},
+		{"id": "ctx", "type": "context_constant", "data": {"context_values": [{"name": "price", "value": 1, "type": "number"}]}},
+		{"id": "total", "type": "expression", "data": {"expression": "input * context.price"}}
+	],
+	"edges": [{"source": "qty", "target": "total"}]
+}`
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		want   float64
+	}{
+		{"default price", map[string]interface{}{"quantity": 4.0}, 10},
+		{"explicit price", map[string]interface{}{"quantity": 4.0, "price": 3.0}, 12},
+		{"null uses default", map[string]interface{}{"quantity": 2.0, "price": nil}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := params.Bind([]byte(pricingWorkflow), tt.values)
+			if err != nil {
+				t.Fatalf("Bind() error = %v", err)
+			}
+
+			eng, err := engine.New(payload)
+			if err != nil {
+				t.Fatalf("engine.New() error = %v", err)
+			}
+			result, err := eng.Execute()
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got := result.NodeResults["total"]; got != tt.want {
+				t.Errorf("total = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBind_SynthesizesContextConstants(t *testing.T) {
+	payload, err := params.Bind([]byte(pricingWorkflow), map[string]interface{}{
+		"quantity": 1.0,
+		"label":    "rush",
+		"tags":     []interface{}{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	var bound types.Payload
+	if err := json.Unmarshal(payload, &bound); err != nil {
+		t.Fatalf("bound payload does not decode: %v", err)
+	}
+	last := bound.Nodes[len(bound.Nodes)-1]
+	if last.ID != params.InputsNodeID || last.Type != types.NodeTypeContextConstant {
+		t.Fatalf("last node = %s (%s), want synthesized %s", last.ID, last.Type, params.InputsNodeID)
+	}
+
+	eng, err := engine.New(payload)
+	if err != nil {
+		t.Fatalf("engine.New() error = %v", err)
+	}
+	result, err := eng.Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	constants := result.NodeResults[params.InputsNodeID].(map[string]interface{})["constants"].(map[string]interface{})
+	if constants["label"] != "rush" || len(constants["tags"].([]interface{})) != 2 {
+		t.Errorf("synthesized constants = %v", constants)
+	}
+}
+
+func TestBind_ValidationErrors(t *testing.T) {
+	_, err := params.Bind([]byte(pricingWorkflow), map[string]interface{}{
+		"price": "cheap",
+		"extra": true,
+	})
+	if !errors.Is(err, params.ErrInvalidInputs) {
+		t.Fatalf("error = %v, want ErrInvalidInputs", err)
+	}
+
+	var verr *params.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error %T is not a *ValidationError", err)
+	}
+	want := map[string]string{
+		"quantity": "required input is missing",
+		"price":    "expected number, got string",
+		"extra":    "input is not declared by the workflow",
+	}
+	if len(verr.Errors) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(verr.Errors), len(want), verr.Errors)
+	}
+	for _, fe := range verr.Errors {
+		if want[fe.Input] != fe.Message {
+			t.Errorf("%s: message %q, want %q", fe.Input, fe.Message, want[fe.Input])
+		}
+	}
+}
+
+func TestBind_NoInputsDeclared(t *testing.T) {
+	payload := []byte(`{"nodes": [{"id": "1", "data": {"value": 1}}], "edges": []}`)
+
+	got, err := params.Bind(payload, nil)
+	if err != nil || string(got) != string(payload) {
+		t.Errorf("Bind() = %s, %v; want payload unchanged", got, err)
+	}
+
+	if _, err := params.Bind(payload, map[string]interface{}{"x": 1.0}); !errors.Is(err, params.ErrInvalidInputs) {
+		t.Errorf("undeclared input error = %v, want ErrInvalidInputs", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	payload := []byte(`{
+		"nodes": [
+			{"id": "msg", "data": {"text": "sample"}},
+			{"id": "ctx", "type": "context_constant", "data": {"context_values": [{"name": "request", "value": {}, "type": "object"}]}},
+			{"id": "method", "type": "expression", "data": {"expression": "context.request.method"}}
+		],
+		"edges": [{"source": "msg", "target": "method"}]
+	}`)
+
+	applied, err := params.Apply(payload, map[string]interface{}{
+		"request": map[string]interface{}{"method": "POST"},
+		"body":    "hello",
+		"skipped": nil,
+	}, map[string]string{"body": "msg"})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	eng, err := engine.New(applied)
+	if err != nil {
+		t.Fatalf("engine.New() error = %v", err)
+	}
+	result, err := eng.Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := result.NodeResults["msg"]; got != "hello" {
+		t.Errorf("msg = %v, want the applied text", got)
+	}
+	if got := result.NodeResults["method"]; got != "POST" {
+		t.Errorf("method = %v, want POST from the context constant", got)
+	}
+
+	_, err = params.Apply(payload, map[string]interface{}{"a": 1.0, "b": "x", "c": "x"}, map[string]string{"a": "msg", "b": "ctx", "c": "nope"})
+	var verr *params.ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 3 {
+		t.Fatalf("error = %v, want three field errors", err)
+	}
+	for i, want := range []string{"expected string, got number", "is not an input node", "not found"} {
+		if !strings.Contains(verr.Errors[i].Message, want) {
+			t.Errorf("%s: message %q, want it to contain %q", verr.Errors[i].Input, verr.Errors[i].Message, want)
+		}
+	}
+}
+
+func TestSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  string
+		wantErr string
+	}{
+		{"bad name", `[{"name": "a-b", "type": "string"}]`, "must start with a letter"},
+		{"duplicate", `[{"name": "a", "type": "string"}, {"name": "a", "type": "number"}]`, "declared more than once"},
+		{"unknown type", `[{"name": "a", "type": "date"}]`, "unknown type"},
+		{"bad default", `[{"name": "a", "type": "number", "default": "x"}]`, "default expected number"},
+		{"missing node", `[{"name": "a", "type": "number", "node": "nope"}]`, "not found"},
+		{"not an input node", `[{"name": "a", "type": "number", "node": "op"}]`, "is not an input node"},
+		{"incompatible node", `[{"name": "a", "type": "string", "node": "num"}]`, "cannot be bound"},
+		{"not an array", `{"name": "a"}`, "must be an array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := `{"inputs": ` + tt.inputs + `, "nodes": [{"id": "num", "data": {"value": 1}}, {"id": "op", "data": {"op": "add"}}], "edges": []}`
+			_, err := params.Schema([]byte(payload))
+			if !errors.Is(err, params.ErrInvalidSchema) {
+				t.Fatalf("error = %v, want ErrInvalidSchema", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package query provides a path query engine for JSON-like data.
+//
+// # Overview
+//
+// The query package selects values from decoded JSON (maps, slices and
+// scalars) using a path language that accepts both JSONPath and the common
+// subset of JMESPath. It is shared by the extract node's query mode and the
+// query() expression function, so both behave identically.
+//
+// # Syntax
+//
+//	$.orders[?(@.total > 100)].id     JSONPath filter
+//	items[*].tags[0]                  JMESPath projection
+//	$..name                           Recursive descent
+//	users[?active == `true`].email    JMESPath filter with JSON literal
+//	$['first name']                   Quoted member names
+//	items[0:10:2]                     Slices (start:end:step, negatives allowed)
+//	items[0,2,-1]                     Index and name unions
+//	matrix[]                          Flatten one level
+//
+// The leading "$" is optional. Inside filters, "@" refers to the current
+// element, "$" to the document root, and bare names are resolved relative to
+// the current element. Filters support ==, !=, <, <=, >, >=, &&, || and !.
+// Literals may be numbers, quoted strings, true/false/null or backtick JSON.
+//
+// # Results
+//
+// A query made only of single names and indices is definite: it evaluates to
+// the selected value, or nil when the path does not exist. Any wildcard,
+// filter, slice, union or recursive descent turns the query into a
+// projection, which always evaluates to a (possibly empty) []interface{}.
+// Missing members are skipped rather than reported as errors.
+//
+// # Limits
+//
+// Limits bounds recursive descent and nested filter depth (MaxDepth) and the
+// number of values any step may select (MaxResults), so queries against large
+// HTTP responses cannot grow without bound. The engine applies the workflow's
+// MaxContextDepth and MaxArrayLength through these fields.
+//
+// # Basic Usage
+//
+//	q, err := query.Compile("$.orders[?(@.total > 100)].id")
+//	if err != nil {
+//	    return err
+//	}
+//	ids, err := q.Evaluate(response, query.Limits{MaxDepth: 32, MaxResults: 10000})
+//
+// # Thread Safety
+//
+// A compiled Query is immutable and safe for concurrent use.
+package query
@@ -0,0 +1,33 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for query compilation and evaluation
+var (
+	// Compilation errors
+	ErrInvalidQuery = errors.New("invalid query")
+
+	// Evaluation limit errors
+	ErrDepthExceeded  = errors.New("query depth limit exceeded")
+	ErrTooManyResults = errors.New("query result limit exceeded")
+)
+
+// SyntaxError describes a malformed query and where parsing stopped
+type SyntaxError struct {
+	Query    string // The query being compiled
+	Position int    // Byte offset where the error was detected
+	Message  string // Description of the problem
+}
+
+// Error implements the error interface
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid query %q at position %d: %s", e.Query, e.Position, e.Message)
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidQuery)
+func (e *SyntaxError) Unwrap() error {
+	return ErrInvalidQuery
+}
@@ -0,0 +1,218 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ============================================================================
+// Filter Expressions
+// ============================================================================
+
+// expr is a filter expression evaluated against the current element
+type expr interface {
+	eval(ev *evaluator, current interface{}) (interface{}, error)
+}
+
+// literalExpr is a constant: 100, 'text', true, null, `{"a":1}`
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(_ *evaluator, _ interface{}) (interface{}, error) {
+	return e.value, nil
+}
+
+// pathExpr is a sub-query relative to the current element (@, bare names)
+// or to the document root ($)
+type pathExpr struct {
+	fromRoot bool
+	path     path
+}
+
+func (e pathExpr) eval(ev *evaluator, current interface{}) (interface{}, error) {
+	start := current
+	if e.fromRoot {
+		start = ev.root
+	}
+
+	ev.depth++
+	defer func() { ev.depth-- }()
+	if ev.limits.MaxDepth > 0 && ev.depth > ev.limits.MaxDepth {
+		return nil, fmt.Errorf("%w: filters nested deeper than %d levels", ErrDepthExceeded, ev.limits.MaxDepth)
+	}
+
+	nodes, err := ev.run(e.path, start)
+	if err != nil {
+		return nil, err
+	}
+	if e.path.definite() {
+		if len(nodes) == 0 {
+			return nil, nil
+		}
+		return nodes[0], nil
+	}
+	if nodes == nil {
+		nodes = []interface{}{}
+	}
+	return nodes, nil
+}
+
+// notExpr negates the truthiness of its operand: !expr
+type notExpr struct {
+	operand expr
+}
+
+func (e notExpr) eval(ev *evaluator, current interface{}) (interface{}, error) {
+	v, err := e.operand.eval(ev, current)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+// logicalExpr combines two expressions with && or ||, short-circuiting
+type logicalExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e logicalExpr) eval(ev *evaluator, current interface{}) (interface{}, error) {
+	l, err := e.left.eval(ev, current)
+	if err != nil {
+		return nil, err
+	}
+	if e.op == "&&" && !truthy(l) {
+		return false, nil
+	}
+	if e.op == "||" && truthy(l) {
+		return true, nil
+	}
+	r, err := e.right.eval(ev, current)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(r), nil
+}
+
+// compareExpr compares two operands: ==, !=, <, <=, >, >=
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e compareExpr) eval(ev *evaluator, current interface{}) (interface{}, error) {
+	l, err := e.left.eval(ev, current)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(ev, current)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	}
+
+	// Ordering is defined for two numbers or two strings; anything else is false
+	var cmp int
+	if ln, ok := toFloat(l); ok {
+		rn, ok := toFloat(r)
+		if !ok {
+			return false, nil
+		}
+		switch {
+		case ln < rn:
+			cmp = -1
+		case ln > rn:
+			cmp = 1
+		}
+	} else if ls, ok := l.(string); ok {
+		rs, ok := r.(string)
+		if !ok {
+			return false, nil
+		}
+		switch {
+		case ls < rs:
+			cmp = -1
+		case ls > rs:
+			cmp = 1
+		}
+	} else {
+		return false, nil
+	}
+
+	switch e.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, nil
+}
+
+// ============================================================================
+// Value Semantics
+// ============================================================================
+
+// truthy follows JMESPath: null, false and empty strings, arrays and objects
+// are false; everything else, including 0, is true
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	case []interface{}:
+		return len(val) > 0
+	case map[string]interface{}:
+		return len(val) > 0
+	}
+	return true
+}
+
+// equal compares values, treating all numeric types as equivalent
+func equal(a, b interface{}) bool {
+	if an, ok := toFloat(a); ok {
+		bn, ok := toFloat(b)
+		return ok && an == bn
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts numeric values to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
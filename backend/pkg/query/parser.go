@@ -0,0 +1,467 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parser is a recursive-descent parser over the query source
+type parser struct {
+	src string
+	pos int
+}
+
+// errorf returns a SyntaxError at the current position
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Query: p.src, Position: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// peek returns the current byte, or 0 at the end of input
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// consume advances past s if the input continues with it
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// expect consumes s or fails
+func (p *parser) expect(s string) error {
+	p.skipSpaces()
+	if !p.consume(s) {
+		if p.pos >= len(p.src) {
+			return p.errorf("expected %q, got end of query", s)
+		}
+		return p.errorf("expected %q, got %q", s, p.src[p.pos])
+	}
+	return nil
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// parseQuery parses a complete query: an optional $ or @ root followed by segments
+func (p *parser) parseQuery() (path, error) {
+	p.skipSpaces()
+	if p.pos == len(p.src) {
+		return nil, p.errorf("empty query")
+	}
+
+	var segs path
+	var err error
+	if c := p.peek(); c == '$' || c == '@' {
+		p.pos++
+		segs, err = p.parseSegments(false)
+	} else {
+		segs, err = p.parseSegments(true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if p.pos != len(p.src) {
+		return nil, p.errorf("unexpected character %q", p.src[p.pos])
+	}
+	return segs, nil
+}
+
+// parseSegments parses dot, bracket and descent segments. With bareStart a
+// leading member name without a dot is accepted (JMESPath style).
+func (p *parser) parseSegments(bareStart bool) (path, error) {
+	var segs path
+	if bareStart && isIdentStart(p.peek()) {
+		segs = append(segs, fieldSegment{names: []string{p.parseIdent()}})
+	}
+
+	for {
+		switch {
+		case p.consume(".."):
+			var inner segment
+			switch c := p.peek(); {
+			case c == '*':
+				p.pos++
+				inner = wildcardSegment{}
+			case c == '[':
+				seg, err := p.parseBracket()
+				if err != nil {
+					return nil, err
+				}
+				inner = seg
+			case isIdentStart(c):
+				inner = fieldSegment{names: []string{p.parseIdent()}}
+			default:
+				return nil, p.errorf("expected member name, '*' or '[' after '..'")
+			}
+			segs = append(segs, descendantSegment{inner: inner})
+
+		case p.consume("."):
+			switch c := p.peek(); {
+			case c == '*':
+				p.pos++
+				segs = append(segs, wildcardSegment{})
+			case isIdentStart(c):
+				segs = append(segs, fieldSegment{names: []string{p.parseIdent()}})
+			default:
+				return nil, p.errorf("expected member name or '*' after '.'")
+			}
+
+		case p.peek() == '[':
+			seg, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+
+		default:
+			return segs, nil
+		}
+	}
+}
+
+// parseBracket parses [...]: wildcard, flatten, filter, names, indices or slice
+func (p *parser) parseBracket() (segment, error) {
+	p.pos++ // '['
+	p.skipSpaces()
+
+	switch c := p.peek(); {
+	case c == ']':
+		p.pos++
+		return flattenSegment{}, nil
+
+	case c == '*':
+		p.pos++
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return wildcardSegment{}, nil
+
+	case c == '?':
+		p.pos++
+		predicate, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return filterSegment{predicate: predicate}, nil
+
+	case c == '\'' || c == '"':
+		var names []string
+		for {
+			p.skipSpaces()
+			name, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+			p.skipSpaces()
+			if !p.consume(",") {
+				break
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return fieldSegment{names: names}, nil
+	}
+
+	start, err := p.parseOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+
+	if p.consume(":") {
+		seg := sliceSegment{start: start, step: 1}
+		if seg.end, err = p.parseOptionalInt(); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.consume(":") {
+			step, err := p.parseOptionalInt()
+			if err != nil {
+				return nil, err
+			}
+			if step != nil {
+				if *step == 0 {
+					return nil, p.errorf("slice step cannot be 0")
+				}
+				seg.step = *step
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return seg, nil
+	}
+
+	if start == nil {
+		return nil, p.errorf("expected index, slice, name, '*' or filter in brackets")
+	}
+	indices := []int{*start}
+	for {
+		p.skipSpaces()
+		if !p.consume(",") {
+			break
+		}
+		idx, err := p.parseOptionalInt()
+		if err != nil {
+			return nil, err
+		}
+		if idx == nil {
+			return nil, p.errorf("expected index after ','")
+		}
+		indices = append(indices, *idx)
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return indexSegment{indices: indices}, nil
+}
+
+// parseOptionalInt parses a possibly negative integer, returning nil if absent
+func (p *parser) parseOptionalInt() (*int, error) {
+	p.skipSpaces()
+	begin := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	if p.pos == begin {
+		return nil, nil
+	}
+	text := p.src[begin:p.pos]
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		p.pos = begin
+		return nil, p.errorf("invalid integer %q", text)
+	}
+	return &n, nil
+}
+
+// parseIdent parses a member name
+func (p *parser) parseIdent() string {
+	begin := p.pos
+	for p.pos < len(p.src) && isIdentPart(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[begin:p.pos]
+}
+
+// parseString parses a single- or double-quoted string with backslash escapes
+func (p *parser) parseString() (string, error) {
+	quote := p.peek()
+	if quote != '\'' && quote != '"' {
+		return "", p.errorf("expected quoted string")
+	}
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch esc := p.src[p.pos]; esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(esc)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// ============================================================================
+// Filter Expression Parsing
+// ============================================================================
+
+// parseOr parses expr || expr
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "||", left: left, right: right}
+	}
+}
+
+// parseAnd parses expr && expr
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "&&", left: left, right: right}
+	}
+}
+
+// parseUnary parses !expr
+func (p *parser) parseUnary() (expr, error) {
+	p.skipSpaces()
+	if p.peek() == '!' && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+// comparisonOps lists operators longest first so "<=" wins over "<"
+var comparisonOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseComparison parses (expr) or operand [op operand]
+func (p *parser) parseComparison() (expr, error) {
+	p.skipSpaces()
+	if p.consume("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	for _, op := range comparisonOps {
+		if p.consume(op) {
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return compareExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+// parseOperand parses a path or literal inside a filter
+func (p *parser) parseOperand() (expr, error) {
+	p.skipSpaces()
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segs, err := p.parseSegments(false)
+		if err != nil {
+			return nil, err
+		}
+		return pathExpr{fromRoot: c == '$', path: segs}, nil
+
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{value: s}, nil
+
+	case c == '`':
+		end := strings.IndexByte(p.src[p.pos+1:], '`')
+		if end < 0 {
+			return nil, p.errorf("unterminated JSON literal")
+		}
+		raw := p.src[p.pos+1 : p.pos+1+end]
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, p.errorf("invalid JSON literal %q: %v", raw, err)
+		}
+		p.pos += end + 2
+		return literalExpr{value: value}, nil
+
+	case c == '-' || isDigit(c):
+		begin := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.src[begin:p.pos], 64)
+		if err != nil {
+			p.pos = begin
+			return nil, p.errorf("invalid number")
+		}
+		return literalExpr{value: n}, nil
+
+	case isIdentStart(c):
+		for keyword, value := range map[string]interface{}{"true": true, "false": false, "null": nil} {
+			end := p.pos + len(keyword)
+			if strings.HasPrefix(p.src[p.pos:], keyword) && (end == len(p.src) || !isIdentPart(p.src[end])) {
+				p.pos = end
+				return literalExpr{value: value}, nil
+			}
+		}
+		segs, err := p.parseSegments(true)
+		if err != nil {
+			return nil, err
+		}
+		return pathExpr{path: segs}, nil
+	}
+
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("expected operand, got end of query")
+	}
+	return nil, p.errorf("expected operand, got %q", p.src[p.pos])
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
@@ -0,0 +1,357 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Limits bounds query evaluation. A zero value for any field means unlimited.
+type Limits struct {
+	MaxDepth   int // Maximum nesting depth walked by recursive descent and nested filters
+	MaxResults int // Maximum number of values a single query step may select
+}
+
+// Query is a compiled path query
+type Query struct {
+	source   string
+	path     path
+	definite bool
+}
+
+// Compile parses a JSONPath or JMESPath query
+func Compile(source string) (*Query, error) {
+	p := &parser{src: source}
+	segments, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	return &Query{
+		source:   source,
+		path:     segments,
+		definite: segments.definite(),
+	}, nil
+}
+
+// MustCompile is like Compile but panics on error. Intended for tests and
+// package-level query variables.
+func MustCompile(source string) *Query {
+	q, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// Evaluate compiles and evaluates a query in one step
+func Evaluate(source string, data interface{}, limits Limits) (interface{}, error) {
+	q, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return q.Evaluate(data, limits)
+}
+
+// String returns the query source
+func (q *Query) String() string {
+	return q.source
+}
+
+// Definite reports whether the query selects at most one value.
+// Definite queries evaluate to that value; others evaluate to a slice.
+func (q *Query) Definite() bool {
+	return q.definite
+}
+
+// Evaluate runs the query against data
+func (q *Query) Evaluate(data interface{}, limits Limits) (interface{}, error) {
+	ev := &evaluator{root: data, limits: limits}
+	nodes, err := ev.run(q.path, data)
+	if err != nil {
+		return nil, fmt.Errorf("query %q: %w", q.source, err)
+	}
+	if q.definite {
+		if len(nodes) == 0 {
+			return nil, nil
+		}
+		return nodes[0], nil
+	}
+	if nodes == nil {
+		nodes = []interface{}{}
+	}
+	return nodes, nil
+}
+
+// ============================================================================
+// Path Segments
+// ============================================================================
+
+// path is a sequence of segments applied left to right
+type path []segment
+
+// definite reports whether every segment selects at most one value
+func (p path) definite() bool {
+	for _, seg := range p {
+		if !seg.definite() {
+			return false
+		}
+	}
+	return true
+}
+
+// segment maps a set of nodes to the set of values it selects from them
+type segment interface {
+	apply(ev *evaluator, nodes []interface{}) ([]interface{}, error)
+	definite() bool
+}
+
+// fieldSegment selects object members by name: .name, ['a','b']
+type fieldSegment struct {
+	names []string
+}
+
+func (s fieldSegment) definite() bool { return len(s.names) == 1 }
+
+func (s fieldSegment) apply(_ *evaluator, nodes []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, node := range nodes {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, name := range s.names {
+			if v, exists := obj[name]; exists {
+				out = append(out, v)
+			}
+		}
+	}
+	return out, nil
+}
+
+// indexSegment selects array elements by index: [0], [0,-1]
+type indexSegment struct {
+	indices []int
+}
+
+func (s indexSegment) definite() bool { return len(s.indices) == 1 }
+
+func (s indexSegment) apply(_ *evaluator, nodes []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, node := range nodes {
+		arr, ok := node.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, idx := range s.indices {
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if idx >= 0 && idx < len(arr) {
+				out = append(out, arr[idx])
+			}
+		}
+	}
+	return out, nil
+}
+
+// sliceSegment selects a range of array elements: [start:end:step]
+type sliceSegment struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSegment) definite() bool { return false }
+
+func (s sliceSegment) apply(_ *evaluator, nodes []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, node := range nodes {
+		arr, ok := node.([]interface{})
+		if !ok {
+			continue
+		}
+		n := len(arr)
+		normalize := func(i *int, def int) int {
+			if i == nil {
+				return def
+			}
+			v := *i
+			if v < 0 {
+				v += n
+			}
+			if s.step > 0 {
+				return clamp(v, 0, n)
+			}
+			return clamp(v, -1, n-1)
+		}
+		if s.step > 0 {
+			for i, end := normalize(s.start, 0), normalize(s.end, n); i < end; i += s.step {
+				out = append(out, arr[i])
+			}
+		} else {
+			for i, end := normalize(s.start, n-1), normalize(s.end, -1); i > end; i += s.step {
+				out = append(out, arr[i])
+			}
+		}
+	}
+	return out, nil
+}
+
+// wildcardSegment selects every array element or object value: [*], .*
+type wildcardSegment struct{}
+
+func (wildcardSegment) definite() bool { return false }
+
+func (wildcardSegment) apply(_ *evaluator, nodes []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, node := range nodes {
+		out = append(out, children(node)...)
+	}
+	return out, nil
+}
+
+// flattenSegment splices nested arrays one level: []
+type flattenSegment struct{}
+
+func (flattenSegment) definite() bool { return false }
+
+func (flattenSegment) apply(_ *evaluator, nodes []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, node := range nodes {
+		arr, ok := node.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range arr {
+			if inner, ok := item.([]interface{}); ok {
+				out = append(out, inner...)
+			} else {
+				out = append(out, item)
+			}
+		}
+	}
+	return out, nil
+}
+
+// descendantSegment applies its inner segment to a node and all of its
+// descendants: ..name, ..*, ..[0]
+type descendantSegment struct {
+	inner segment
+}
+
+func (descendantSegment) definite() bool { return false }
+
+func (s descendantSegment) apply(ev *evaluator, nodes []interface{}) ([]interface{}, error) {
+	var all []interface{}
+	var walk func(node interface{}, depth int) error
+	walk = func(node interface{}, depth int) error {
+		if ev.limits.MaxDepth > 0 && depth > ev.limits.MaxDepth {
+			return fmt.Errorf("%w: recursive descent deeper than %d levels", ErrDepthExceeded, ev.limits.MaxDepth)
+		}
+		all = append(all, node)
+		if err := ev.checkResults(len(all)); err != nil {
+			return err
+		}
+		for _, child := range children(node) {
+			if err := walk(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, node := range nodes {
+		if err := walk(node, 0); err != nil {
+			return nil, err
+		}
+	}
+	return s.inner.apply(ev, all)
+}
+
+// filterSegment selects children for which the predicate is truthy: [?(...)]
+type filterSegment struct {
+	predicate expr
+}
+
+func (filterSegment) definite() bool { return false }
+
+func (s filterSegment) apply(ev *evaluator, nodes []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, node := range nodes {
+		for _, child := range children(node) {
+			v, err := s.predicate.eval(ev, child)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(v) {
+				out = append(out, child)
+			}
+		}
+	}
+	return out, nil
+}
+
+// ============================================================================
+// Evaluation
+// ============================================================================
+
+// evaluator carries the document root and limits through one evaluation
+type evaluator struct {
+	root   interface{}
+	limits Limits
+	depth  int
+}
+
+// run applies a path to a starting node
+func (ev *evaluator) run(p path, start interface{}) ([]interface{}, error) {
+	nodes := []interface{}{start}
+	for _, seg := range p {
+		var err error
+		nodes, err = seg.apply(ev, nodes)
+		if err != nil {
+			return nil, err
+		}
+		if err := ev.checkResults(len(nodes)); err != nil {
+			return nil, err
+		}
+		if len(nodes) == 0 {
+			return nil, nil
+		}
+	}
+	return nodes, nil
+}
+
+// checkResults enforces the result limit
+func (ev *evaluator) checkResults(n int) error {
+	if ev.limits.MaxResults > 0 && n > ev.limits.MaxResults {
+		return fmt.Errorf("%w: selected more than %d values", ErrTooManyResults, ev.limits.MaxResults)
+	}
+	return nil
+}
+
+// children returns array elements or object values in key order
+func children(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]interface{}, len(keys))
+		for i, k := range keys {
+			out[i] = v[k]
+		}
+		return out
+	}
+	return nil
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,142 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testDocument = `{
+	"store": "north",
+	"first name": "Ada",
+	"orders": [
+		{"id": "o1", "total": 50, "status": "open", "tags": ["a", "b"]},
+		{"id": "o2", "total": 150, "status": "closed", "tags": ["c"]},
+		{"id": "o3", "total": 250, "status": "open", "tags": []}
+	],
+	"matrix": [[1, 2], [3], 4],
+	"meta": {"name": "inner", "nested": {"name": "deep"}},
+	"threshold": 100
+}`
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid test JSON: %v", err)
+	}
+	return v
+}
+
+func TestEvaluate(t *testing.T) {
+	doc := decode(t, testDocument)
+
+	tests := []struct {
+		name  string
+		query string
+		want  interface{}
+	}{
+		// Definite paths
+		{"root", "$", doc},
+		{"dotted member", "$.store", "north"},
+		{"bare member (JMESPath)", "store", "north"},
+		{"index", "$.orders[1].id", "o2"},
+		{"negative index", "orders[-1].id", "o3"},
+		{"quoted member", "$['first name']", "Ada"},
+		{"missing member", "$.missing.field", nil},
+		{"index out of range", "orders[10]", nil},
+
+		// Projections
+		{"JSONPath filter", "$.orders[?(@.total > 100)].id", []interface{}{"o2", "o3"}},
+		{"JMESPath filter with literal", "orders[?status == `\"open\"`].id", []interface{}{"o1", "o3"}},
+		{"filter on bare names", "orders[?total >= `150` && status == 'closed'].id", []interface{}{"o2"}},
+		{"filter with or and not", "orders[?!(total < 100) || id == 'o1'].id", []interface{}{"o1", "o2", "o3"}},
+		{"filter against root", "$.orders[?(@.total > $.threshold)].id", []interface{}{"o2", "o3"}},
+		{"filter existence", "orders[?tags].id", []interface{}{"o1", "o2"}},
+		{"wildcard projection", "items[*].tags[0]", []interface{}{}},
+		{"projection then index", "orders[*].tags[0]", []interface{}{"a", "c"}},
+		{"slice", "orders[0:2].id", []interface{}{"o1", "o2"}},
+		{"reverse slice", "orders[::-1].id", []interface{}{"o3", "o2", "o1"}},
+		{"index union", "orders[0,-1].id", []interface{}{"o1", "o3"}},
+		{"name union", "$.meta['name','missing']", []interface{}{"inner"}},
+		{"flatten", "matrix[]", []interface{}{1.0, 2.0, 3.0, 4.0}},
+		{"recursive descent", "$..name", []interface{}{"inner", "deep"}},
+		{"object wildcard", "$.meta.*", []interface{}{"inner", map[string]interface{}{"name": "deep"}}},
+		{"empty projection", "orders[?total > `1000`].id", []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.query, doc, Limits{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []string{
+		"",
+		"$.",
+		"$.orders[",
+		"$.orders[?(@.total > )]",
+		"orders[1:2:0]",
+		"$['unterminated]",
+		"$.a b",
+		"orders[?total == `{bad`]",
+	}
+
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			_, err := Compile(source)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("Compile(%q) error = %v, want ErrInvalidQuery", source, err)
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected *SyntaxError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Limits(t *testing.T) {
+	doc := decode(t, testDocument)
+
+	if _, err := Evaluate("$..name", doc, Limits{MaxDepth: 1}); !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("expected ErrDepthExceeded for deep descent, got %v", err)
+	}
+	if _, err := Evaluate("$..name", doc, Limits{MaxDepth: 10}); err != nil {
+		t.Errorf("unexpected error within depth limit: %v", err)
+	}
+	if _, err := Evaluate("orders[*]", doc, Limits{MaxResults: 2}); !errors.Is(err, ErrTooManyResults) {
+		t.Errorf("expected ErrTooManyResults, got %v", err)
+	}
+	if _, err := Evaluate("orders[?tags[?@ == 'a']].id", doc, Limits{MaxDepth: 1}); !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("expected ErrDepthExceeded for nested filters, got %v", err)
+	}
+}
+
+func TestQuery_Definite(t *testing.T) {
+	tests := map[string]bool{
+		"$.a.b[0]":      true,
+		"a.b":           true,
+		"$.a[*]":        false,
+		"$..a":          false,
+		"$.a[0:1]":      false,
+		"$.a[?(@.x)]":   false,
+		"$['a','b']":    false,
+		"$.a[0,1]":      false,
+		"matrix[].name": false,
+	}
+	for source, want := range tests {
+		if got := MustCompile(source).Definite(); got != want {
+			t.Errorf("Definite(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package runner executes workflows asynchronously on a bounded worker pool.
+//
+// # Overview
+//
+// Submit queues a prepared engine and returns immediately with the engine's
+// execution ID. A fixed number of workers take executions off the queue and
+// run them with a cancellable context, so callers can poll for progress with
+// Get and stop a run with Cancel instead of holding a connection open for
+// the whole execution.
+//
+//	r := runner.New(runner.DefaultConfig())
+//	defer r.Shutdown(ctx)
+//
+//	eng, _ := engine.NewWithConfig(payload, config)
+//	exec, err := r.Submit(eng, runner.SubmitOptions{WorkflowID: "wf-1"})
+//	...
+//	snapshot, _ := r.Get(exec.ID)
+//
+// # Lifecycle
+//
+// An execution moves from queued to running and ends as succeeded, failed or
+// cancelled. Cancelling a queued execution ends it without running it;
+// cancelling a running one cancels its context, so the engine returns at
+// once and no further nodes are started.
+//
+// Executions whose engine was set up with engine.SetDebug report paused
+// while the debugger holds them before a node, and Debugger returns the
+// debugger to inspect and resume them with.
+//
+// Per-node progress is collected from the engine's observer events while the
+// workflow runs. The same events are published on a per-execution
+// events.Stream (see Events) for live streaming and replay; the stream is
+// closed once the execution has reached its final status.
+//
+// # Retention
+//
+// Finished executions, including their results, are kept for
+// Config.Retention and then removed. Get returns ErrExecutionNotFound for
+// executions that never existed or have expired.
+package runner
@@ -0,0 +1,12 @@
+package runner
+
+import "errors"
+
+// Sentinel errors for asynchronous executions
+var (
+	ErrExecutionNotFound = errors.New("execution not found")
+	ErrExecutionFinished = errors.New("execution already finished")
+	ErrQueueFull         = errors.New("execution queue is full")
+	ErrRunnerClosed      = errors.New("runner is shut down")
+	ErrNotDebugged       = errors.New("execution is not being debugged")
+)
@@ -0,0 +1,557 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/events"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// Status is the lifecycle state of an execution or one of its nodes
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Finished reports whether the status is terminal
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// NodeProgress is the state of one node within an execution
+type NodeProgress struct {
+	NodeID     string         `json:"node_id"`
+	NodeType   types.NodeType `json:"node_type,omitempty"`
+	Status     Status         `json:"status"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Execution is a point-in-time snapshot of an asynchronous execution.
+// Nodes are listed in the order they started; Result is only set once the
+// workflow has succeeded.
+type Execution struct {
+	ID          string         `json:"id"`
+	WorkflowID  string         `json:"workflow_id,omitempty"`
+	TenantID    string         `json:"tenant_id,omitempty"`
+	Status      Status         `json:"status"`
+	SubmittedAt time.Time      `json:"submitted_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+	Nodes       []NodeProgress `json:"nodes"`
+	Result      *types.Result  `json:"result,omitempty"`
+	Error       string         `json:"error,omitempty"`
+
+	// Profile is the execution profile of a finished execution whose
+	// engine had profiling enabled, also when it failed. It is served on
+	// its own rather than with the execution.
+	Profile *types.Profile `json:"-"`
+}
+
+// Config holds runner configuration
+type Config struct {
+	// Workers is the number of executions that run concurrently
+	Workers int
+
+	// QueueSize is the number of executions that may wait for a worker
+	// before Submit returns ErrQueueFull
+	QueueSize int
+
+	// Retention is how long finished executions and their results are kept
+	Retention time.Duration
+
+	// EventBuffer is the number of events kept per execution for replay
+	// to late event stream subscribers
+	EventBuffer int
+}
+
+// DefaultConfig returns default runner configuration
+func DefaultConfig() Config {
+	return Config{
+		Workers:     4,
+		QueueSize:   100,
+		Retention:   time.Hour,
+		EventBuffer: events.DefaultBufferSize,
+	}
+}
+
+// Runner runs workflows on a bounded worker pool and tracks their progress
+type Runner struct {
+	config Config
+	queue  chan *job
+	stop   chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	closed bool
+
+	// now is replaced in tests to expire executions deterministically
+	now func() time.Time
+}
+
+// job is the mutable state behind an Execution. All fields except engine,
+// ctx and cancel are guarded by Runner.mu.
+type job struct {
+	exec   Execution
+	nodes  map[string]*NodeProgress
+	order  []string
+	events *events.Stream
+	engine *engine.Engine
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// onFinish is called once, when the job reaches a terminal status
+	onFinish func()
+}
+
+// New creates a runner and starts its workers. Zero config values fall back
+// to DefaultConfig.
+func New(config Config) *Runner {
+	defaults := DefaultConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+	if config.EventBuffer <= 0 {
+		config.EventBuffer = defaults.EventBuffer
+	}
+
+	r := &Runner{
+		config: config,
+		queue:  make(chan *job, config.QueueSize),
+		stop:   make(chan struct{}),
+		jobs:   make(map[string]*job),
+		now:    time.Now,
+	}
+
+	for i := 0; i < config.Workers; i++ {
+		r.wg.Add(1)
+		go r.worker()
+	}
+
+	r.wg.Add(1)
+	go r.sweeper()
+
+	return r
+}
+
+// SubmitOptions describes an execution passed to Submit
+type SubmitOptions struct {
+	// WorkflowID is the saved workflow being run, if any
+	WorkflowID string
+
+	// OnFinish, if set, is called once when the execution reaches a
+	// terminal status. It runs with the runner's lock held and must not
+	// call back into the runner. It is not called when Submit fails.
+	OnFinish func()
+
+	// Context, if set, supplies values such as the caller's trace context
+	// to the execution. Its cancellation is ignored; the execution outlives
+	// the request that submitted it.
+	Context context.Context
+}
+
+// Submit queues eng for execution and returns its initial snapshot. The
+// execution ID and tenant ID are the engine's.
+func (r *Runner) Submit(eng *engine.Engine, opts SubmitOptions) (Execution, error) {
+	parent := context.Background()
+	if opts.Context != nil {
+		parent = context.WithoutCancel(opts.Context)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	j := &job{
+		exec: Execution{
+			ID:         eng.ExecutionID(),
+			WorkflowID: opts.WorkflowID,
+			TenantID:   eng.TenantID(),
+			Status:     StatusQueued,
+		},
+		nodes:    make(map[string]*NodeProgress),
+		events:   events.NewStream(r.config.EventBuffer),
+		engine:   eng,
+		ctx:      ctx,
+		cancel:   cancel,
+		onFinish: opts.OnFinish,
+	}
+	eng.RegisterObserver(j.events)
+	eng.RegisterObserver(&progressObserver{runner: r, job: j})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		cancel()
+		return Execution{}, ErrRunnerClosed
+	}
+	if _, exists := r.jobs[j.exec.ID]; exists {
+		cancel()
+		return Execution{}, fmt.Errorf("duplicate execution ID %q", j.exec.ID)
+	}
+
+	j.exec.SubmittedAt = r.now()
+	select {
+	case r.queue <- j:
+	default:
+		cancel()
+		return Execution{}, ErrQueueFull
+	}
+	r.jobs[j.exec.ID] = j
+
+	return j.snapshot(true), nil
+}
+
+// Get returns a snapshot of the execution with the given ID
+func (r *Runner) Get(id string) (Execution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok || r.expired(j) {
+		return Execution{}, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	return j.snapshot(true), nil
+}
+
+// Events returns the event stream of the execution with the given ID. The
+// stream is closed once the execution has finished.
+func (r *Runner) Events(id string) (*events.Stream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok || r.expired(j) {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	return j.events, nil
+}
+
+// List returns snapshots of every retained execution, newest first.
+// Results are omitted; use Get to fetch one.
+func (r *Runner) List() []Execution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	executions := make([]Execution, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		if !r.expired(j) {
+			executions = append(executions, j.snapshot(false))
+		}
+	}
+	sort.SliceStable(executions, func(i, k int) bool {
+		if executions[i].SubmittedAt.Equal(executions[k].SubmittedAt) {
+			return executions[i].ID < executions[k].ID
+		}
+		return executions[i].SubmittedAt.After(executions[k].SubmittedAt)
+	})
+	return executions
+}
+
+// Cancel stops an execution. A queued execution is cancelled immediately;
+// a running one has its context cancelled and reports cancelled once the
+// engine stops. Cancelling a finished execution returns ErrExecutionFinished.
+func (r *Runner) Cancel(id string) (Execution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok || r.expired(j) {
+		return Execution{}, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	if j.exec.Status.Finished() {
+		return j.snapshot(true), fmt.Errorf("%w: %s is %s", ErrExecutionFinished, id, j.exec.Status)
+	}
+
+	if j.exec.Status == StatusQueued {
+		j.finish(StatusCancelled, r.now(), context.Canceled.Error())
+	}
+	j.cancel()
+
+	return j.snapshot(true), nil
+}
+
+// Debugger returns the debugger of an execution whose engine was set up
+// with engine.SetDebug. It returns ErrNotDebugged for other executions and
+// ErrExecutionFinished once the execution has finished.
+func (r *Runner) Debugger(id string) (*engine.Debugger, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok || r.expired(j) {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	if j.exec.Status.Finished() || j.engine == nil {
+		return nil, fmt.Errorf("%w: %s is %s", ErrExecutionFinished, id, j.exec.Status)
+	}
+	if j.engine.Debugger() == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotDebugged, id)
+	}
+	return j.engine.Debugger(), nil
+}
+
+// Shutdown stops accepting executions, cancels queued and running ones and
+// waits for the workers to exit or ctx to expire.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	now := r.now()
+	for _, j := range r.jobs {
+		if j.exec.Status == StatusQueued {
+			j.finish(StatusCancelled, now, ErrRunnerClosed.Error())
+		}
+		j.cancel()
+	}
+	close(r.stop)
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// worker runs queued executions until the runner shuts down
+func (r *Runner) worker() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case j := <-r.queue:
+			r.run(j)
+		}
+	}
+}
+
+// run executes one job and records its outcome
+func (r *Runner) run(j *job) {
+	defer j.cancel()
+
+	r.mu.Lock()
+	if j.exec.Status != StatusQueued {
+		// Cancelled while waiting for a worker
+		j.engine = nil
+		r.mu.Unlock()
+		return
+	}
+	started := r.now()
+	j.exec.StartedAt = &started
+	j.exec.Status = StatusRunning
+	r.mu.Unlock()
+
+	result, err := j.engine.ExecuteContext(j.ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if result != nil {
+		j.exec.Profile = result.Profile
+	}
+	switch {
+	case err == nil:
+		j.exec.Result = result
+		j.reconcile(result, now)
+		j.finish(StatusSucceeded, now, "")
+	case errors.Is(err, engine.ErrExecutionCanceled):
+		j.finish(StatusCancelled, now, err.Error())
+	default:
+		j.finish(StatusFailed, now, err.Error())
+	}
+	j.engine = nil
+}
+
+// sweeper periodically removes expired executions
+func (r *Runner) sweeper() {
+	defer r.wg.Done()
+
+	interval := r.config.Retention / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.sweep()
+		}
+	}
+}
+
+// sweep removes finished executions older than the retention period
+func (r *Runner) sweep() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, j := range r.jobs {
+		if r.expired(j) {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+// expired reports whether a finished job has outlived the retention period.
+// Callers must hold r.mu.
+func (r *Runner) expired(j *job) bool {
+	if j.exec.FinishedAt == nil {
+		return false
+	}
+	return r.now().Sub(*j.exec.FinishedAt) > r.config.Retention
+}
+
+// finish moves the job to a terminal status and ends its event stream
+func (j *job) finish(status Status, at time.Time, errMsg string) {
+	j.exec.Status = status
+	j.exec.FinishedAt = &at
+	j.exec.Error = errMsg
+	j.events.Close()
+	if j.onFinish != nil {
+		j.onFinish()
+		j.onFinish = nil
+	}
+}
+
+// reconcile marks nodes with results as succeeded. Observer events are
+// delivered asynchronously, so some may still be in flight when the engine
+// returns.
+func (j *job) reconcile(result *types.Result, at time.Time) {
+	if result == nil {
+		return
+	}
+
+	ids := make([]string, 0, len(result.NodeResults))
+	for id := range result.NodeResults {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		node := j.node(id)
+		if node.Status == StatusRunning {
+			node.Status = StatusSucceeded
+			node.FinishedAt = &at
+		}
+	}
+}
+
+// node returns the progress entry for a node, creating it if needed
+func (j *job) node(id string) *NodeProgress {
+	node, ok := j.nodes[id]
+	if !ok {
+		node = &NodeProgress{NodeID: id, Status: StatusRunning}
+		j.nodes[id] = node
+		j.order = append(j.order, id)
+	}
+	return node
+}
+
+// snapshot copies the job's state so it can be read without the lock
+func (j *job) snapshot(withResult bool) Execution {
+	exec := j.exec
+	if exec.Status == StatusRunning && j.engine != nil && j.engine.Debugger().Paused() {
+		exec.Status = StatusPaused
+	}
+	if !withResult {
+		exec.Result = nil
+		exec.Profile = nil
+	}
+	exec.Nodes = make([]NodeProgress, 0, len(j.order))
+	for _, id := range j.order {
+		exec.Nodes = append(exec.Nodes, *j.nodes[id])
+	}
+	return exec
+}
+
+// progressObserver records node events for one job
+type progressObserver struct {
+	runner *Runner
+	job    *job
+}
+
+// OnEvent implements observer.Observer
+func (o *progressObserver) OnEvent(ctx context.Context, event observer.Event) {
+	if event.NodeID == "" {
+		return
+	}
+
+	o.runner.mu.Lock()
+	defer o.runner.mu.Unlock()
+
+	// Events are delivered asynchronously and may arrive after the engine
+	// returned; they still describe the node's real outcome.
+	node := o.job.node(event.NodeID)
+	if event.NodeType != "" {
+		node.NodeType = event.NodeType
+	}
+	if event.Type == observer.EventNodePaused {
+		// The debugger paused before the node; ignore a pause arriving
+		// after the node started
+		if node.StartedAt == nil {
+			node.Status = StatusPaused
+		}
+		return
+	}
+	if node.Status == StatusPaused {
+		node.Status = StatusRunning
+	}
+	if node.StartedAt == nil && !event.StartTime.IsZero() {
+		started := event.StartTime
+		node.StartedAt = &started
+	}
+
+	switch event.Type {
+	case observer.EventNodeSuccess:
+		node.Status = StatusSucceeded
+	case observer.EventNodeFailure:
+		node.Status = StatusFailed
+		if event.Error != nil {
+			node.Error = event.Error.Error()
+		}
+	default:
+		// node_start may arrive after the node finished; keep the outcome
+		return
+	}
+	finished := event.StartTime.Add(event.ElapsedTime)
+	node.FinishedAt = &finished
+}
@@ -0,0 +1,319 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+const (
+	addWorkflow   = `{"nodes": [{"id": "1", "data": {"value": 10}}, {"id": "2", "data": {"value": 5}}, {"id": "3", "data": {"op": "add"}}], "edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]}`
+	divideByZero  = `{"nodes": [{"id": "1", "data": {"value": 1}}, {"id": "2", "data": {"value": 0}}, {"id": "3", "data": {"op": "divide"}}], "edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]}`
+	delayWorkflow = `{"nodes": [{"id": "1", "data": {"value": 1}}, {"id": "wait", "data": {"duration": "2s"}}, {"id": "after", "data": {"value": 2}}], "edges": [{"source": "1", "target": "wait"}, {"source": "wait", "target": "after"}]}`
+)
+
+func newEngine(t *testing.T, payload string) *engine.Engine {
+	t.Helper()
+	eng, err := engine.NewWithConfig([]byte(payload), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("engine.NewWithConfig() error = %v", err)
+	}
+	return eng
+}
+
+// waitFor polls until the execution reaches a status matching done
+func waitFor(t *testing.T, r *Runner, id string, done func(Status) bool) Execution {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		exec, err := r.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+		if done(exec.Status) {
+			return exec
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("execution %s stuck in %s", id, exec.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunner_Outcomes(t *testing.T) {
+	r := New(DefaultConfig())
+	defer r.Shutdown(context.Background())
+
+	tests := []struct {
+		name       string
+		payload    string
+		wantStatus Status
+		wantOutput interface{}
+	}{
+		{"succeeds", addWorkflow, StatusSucceeded, 15.0},
+		{"fails", divideByZero, StatusFailed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng := newEngine(t, tt.payload).SetProfiling(true)
+			submitted, err := r.Submit(eng, SubmitOptions{WorkflowID: "wf-1"})
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			if submitted.ID != eng.ExecutionID() || submitted.WorkflowID != "wf-1" {
+				t.Errorf("Submit() = %+v, want the engine's execution ID", submitted)
+			}
+
+			exec := waitFor(t, r, submitted.ID, Status.Finished)
+			if exec.Status != tt.wantStatus {
+				t.Fatalf("status = %s (%s), want %s", exec.Status, exec.Error, tt.wantStatus)
+			}
+			if exec.StartedAt == nil || exec.FinishedAt == nil {
+				t.Errorf("expected start and finish times, got %+v", exec)
+			}
+			if exec.Profile == nil || len(exec.Profile.Nodes) != 3 {
+				t.Errorf("profile = %+v, want 3 profiled nodes", exec.Profile)
+			}
+
+			if tt.wantStatus != StatusSucceeded {
+				if exec.Error == "" || exec.Result != nil {
+					t.Errorf("failed execution = %+v, want an error and no result", exec)
+				}
+				return
+			}
+			if exec.Result == nil || exec.Result.FinalOutput != tt.wantOutput {
+				t.Fatalf("result = %+v, want final output %v", exec.Result, tt.wantOutput)
+			}
+			if len(exec.Nodes) != 3 {
+				t.Fatalf("nodes = %+v, want 3", exec.Nodes)
+			}
+			for _, node := range exec.Nodes {
+				if node.Status != StatusSucceeded || node.FinishedAt == nil {
+					t.Errorf("node %s = %+v, want succeeded", node.NodeID, node)
+				}
+			}
+		})
+	}
+}
+
+func TestRunner_Events(t *testing.T) {
+	r := New(DefaultConfig())
+	defer r.Shutdown(context.Background())
+
+	submitted, err := r.Submit(newEngine(t, addWorkflow), SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	waitFor(t, r, submitted.ID, Status.Finished)
+
+	stream, err := r.Events(submitted.ID)
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if !stream.Closed() {
+		t.Error("Expected the event stream to be closed after the execution finished")
+	}
+
+	sub := stream.Subscribe(0, 1)
+	replay := sub.Replay
+	if len(replay) == 0 || replay[0].Type != observer.EventWorkflowStart || replay[len(replay)-1].Type != observer.EventWorkflowEnd {
+		t.Fatalf("Replay = %+v, want workflow_start first and workflow_end last", replay)
+	}
+	for i, msg := range replay {
+		if msg.ID != uint64(i+1) || msg.ExecutionID != submitted.ID {
+			t.Errorf("Replay[%d] = %+v, want sequential IDs for execution %s", i, msg, submitted.ID)
+		}
+	}
+
+	if _, err := r.Events("missing"); !errors.Is(err, ErrExecutionNotFound) {
+		t.Errorf("Events(missing) error = %v, want ErrExecutionNotFound", err)
+	}
+}
+
+func TestRunner_CancelRunning(t *testing.T) {
+	r := New(DefaultConfig())
+	defer r.Shutdown(context.Background())
+
+	submitted, err := r.Submit(newEngine(t, delayWorkflow), SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	waitFor(t, r, submitted.ID, func(s Status) bool { return s == StatusRunning })
+
+	start := time.Now()
+	if _, err := r.Cancel(submitted.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	exec := waitFor(t, r, submitted.ID, Status.Finished)
+	if exec.Status != StatusCancelled {
+		t.Fatalf("status = %s, want cancelled", exec.Status)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancellation took %v, want it to stop without waiting for the delay", elapsed)
+	}
+	for _, node := range exec.Nodes {
+		if node.NodeID == "after" {
+			t.Error("node after the cancelled delay should not have started")
+		}
+	}
+
+	if _, err := r.Cancel(submitted.ID); !errors.Is(err, ErrExecutionFinished) {
+		t.Errorf("second Cancel() error = %v, want ErrExecutionFinished", err)
+	}
+	if _, err := r.Cancel("missing"); !errors.Is(err, ErrExecutionNotFound) {
+		t.Errorf("Cancel(missing) error = %v, want ErrExecutionNotFound", err)
+	}
+}
+
+func TestRunner_Debug(t *testing.T) {
+	r := New(DefaultConfig())
+	defer r.Shutdown(context.Background())
+
+	eng := newEngine(t, addWorkflow).SetDebug(engine.DebugOptions{Breakpoints: []string{"3"}})
+	submitted, err := r.Submit(eng, SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	exec := waitFor(t, r, submitted.ID, func(s Status) bool { return s == StatusPaused })
+	waitForNode := func() NodeProgress {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			for _, node := range exec.Nodes {
+				if node.NodeID == "3" {
+					return node
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			exec, _ = r.Get(submitted.ID)
+		}
+		t.Fatal("paused node missing from progress")
+		return NodeProgress{}
+	}
+	if node := waitForNode(); node.Status != StatusPaused {
+		t.Errorf("node 3 status = %s, want paused", node.Status)
+	}
+
+	debugger, err := r.Debugger(submitted.ID)
+	if err != nil {
+		t.Fatalf("Debugger() error = %v", err)
+	}
+	if err := debugger.Continue(); err != nil {
+		t.Fatalf("Continue() error = %v", err)
+	}
+	exec = waitFor(t, r, submitted.ID, Status.Finished)
+	if exec.Status != StatusSucceeded {
+		t.Fatalf("status = %s, want succeeded", exec.Status)
+	}
+
+	if _, err := r.Debugger(submitted.ID); !errors.Is(err, ErrExecutionFinished) {
+		t.Errorf("Debugger(finished) error = %v, want ErrExecutionFinished", err)
+	}
+	plain, err := r.Submit(newEngine(t, delayWorkflow), SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	defer r.Cancel(plain.ID)
+	if _, err := r.Debugger(plain.ID); !errors.Is(err, ErrNotDebugged) {
+		t.Errorf("Debugger(not debugged) error = %v, want ErrNotDebugged", err)
+	}
+	if _, err := r.Debugger("missing"); !errors.Is(err, ErrExecutionNotFound) {
+		t.Errorf("Debugger(missing) error = %v, want ErrExecutionNotFound", err)
+	}
+}
+
+func TestRunner_QueueBounds(t *testing.T) {
+	r := New(Config{Workers: 1, QueueSize: 1, Retention: time.Minute})
+	defer r.Shutdown(context.Background())
+
+	running, err := r.Submit(newEngine(t, delayWorkflow), SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	waitFor(t, r, running.ID, func(s Status) bool { return s == StatusRunning })
+
+	var finished atomic.Int32
+	queued, err := r.Submit(newEngine(t, addWorkflow).SetTenantID("acme"), SubmitOptions{
+		OnFinish: func() { finished.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := r.Submit(newEngine(t, addWorkflow), SubmitOptions{}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit() on a full queue error = %v, want ErrQueueFull", err)
+	}
+
+	// A queued execution is cancelled without running
+	exec, err := r.Cancel(queued.ID)
+	if err != nil || exec.Status != StatusCancelled {
+		t.Fatalf("Cancel(queued) = %s, %v; want cancelled", exec.Status, err)
+	}
+	if _, err := r.Cancel(running.ID); err != nil {
+		t.Fatalf("Cancel(running) error = %v", err)
+	}
+	waitFor(t, r, running.ID, Status.Finished)
+
+	if exec, _ := r.Get(queued.ID); exec.StartedAt != nil || exec.TenantID != "acme" {
+		t.Errorf("cancelled queued execution = %+v, want tenant acme and never started", exec)
+	}
+	if got := finished.Load(); got != 1 {
+		t.Errorf("OnFinish called %d times, want 1", got)
+	}
+	if got := len(r.List()); got != 2 {
+		t.Errorf("List() returned %d executions, want 2", got)
+	}
+}
+
+func TestRunner_Retention(t *testing.T) {
+	r := New(Config{Workers: 1, Retention: time.Minute})
+	defer r.Shutdown(context.Background())
+
+	submitted, err := r.Submit(newEngine(t, addWorkflow), SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	exec := waitFor(t, r, submitted.ID, Status.Finished)
+
+	r.mu.Lock()
+	r.now = func() time.Time { return exec.FinishedAt.Add(2 * time.Minute) }
+	r.mu.Unlock()
+
+	if _, err := r.Get(submitted.ID); !errors.Is(err, ErrExecutionNotFound) {
+		t.Errorf("Get() after retention error = %v, want ErrExecutionNotFound", err)
+	}
+	r.sweep()
+	r.mu.Lock()
+	remaining := len(r.jobs)
+	r.mu.Unlock()
+	if remaining != 0 {
+		t.Errorf("sweep left %d executions, want 0", remaining)
+	}
+}
+
+func TestRunner_Shutdown(t *testing.T) {
+	r := New(DefaultConfig())
+
+	submitted, err := r.Submit(newEngine(t, delayWorkflow), SubmitOptions{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	waitFor(t, r, submitted.ID, func(s Status) bool { return s == StatusRunning })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if exec, _ := r.Get(submitted.ID); exec.Status != StatusCancelled {
+		t.Errorf("status after shutdown = %s, want cancelled", exec.Status)
+	}
+	if _, err := r.Submit(newEngine(t, addWorkflow), SubmitOptions{}); !errors.Is(err, ErrRunnerClosed) {
+		t.Errorf("Submit() after shutdown error = %v, want ErrRunnerClosed", err)
+	}
+}
@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(loc *time.Location, value string) time.Time {
+		t.Helper()
+		v, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected []string
+	}{
+		{"Every 15 minutes", "*/15 * * * *", at(time.UTC, "2026-03-01 10:07"), []string{"2026-03-01 10:15", "2026-03-01 10:30", "2026-03-01 10:45"}},
+		{"Strictly after", "30 9 * * *", at(time.UTC, "2026-03-01 09:30"), []string{"2026-03-02 09:30"}},
+		{"Weekdays by name", "0 9 * * mon-fri", at(time.UTC, "2026-03-06 10:00"), []string{"2026-03-09 09:00", "2026-03-10 09:00"}},
+		{"Sunday as 7", "0 0 * * 7", at(time.UTC, "2026-03-02 00:00"), []string{"2026-03-08 00:00"}},
+		{"Lists and ranges", "0 8-10/2,17 * * *", at(time.UTC, "2026-03-01 09:00"), []string{"2026-03-01 10:00", "2026-03-01 17:00", "2026-03-02 08:00"}},
+		{"Day of month or week", "0 0 13 * fri", at(time.UTC, "2026-03-01 00:00"), []string{"2026-03-06 00:00", "2026-03-13 00:00", "2026-03-20 00:00"}},
+		{"Month names", "0 0 1 jan,jul *", at(time.UTC, "2026-03-01 00:00"), []string{"2026-07-01 00:00", "2027-01-01 00:00"}},
+		{"Leap day", "0 0 29 2 *", at(time.UTC, "2026-03-01 00:00"), []string{"2028-02-29 00:00"}},
+		{"Macro", "@daily", at(time.UTC, "2026-03-01 12:00"), []string{"2026-03-02 00:00"}},
+		{"Time zone", "0 9 * * *", at(newYork, "2026-03-01 10:00"), []string{"2026-03-02 09:00"}},
+		{"Skipped by spring forward", "30 2 * * *", at(newYork, "2026-03-07 12:00"), []string{"2026-03-09 02:30"}},
+		{"Hourly across spring forward", "0 * * * *", at(newYork, "2026-03-08 00:30"), []string{"2026-03-08 01:00", "2026-03-08 03:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := tt.from
+			for _, want := range tt.expected {
+				next = cron.Next(next)
+				if got := next.Format("2006-01-02 15:04"); got != want {
+					t.Fatalf("Next = %s, want %s", got, want)
+				}
+				if next.Location() != tt.from.Location() {
+					t.Errorf("Next is in %s, want %s", next.Location(), tt.from.Location())
+				}
+			}
+		})
+	}
+
+	// Clocks set back repeat 01:00-01:59; a daily run fires once, an
+	// hourly one every hour
+	from := at(newYork, "2026-11-01 00:00")
+	daily, _ := ParseCron("30 1 * * *")
+	first := daily.Next(from)
+	if second := daily.Next(first); second.Sub(first) < 23*time.Hour {
+		t.Errorf("Daily run repeated at %s after %s", second, first)
+	}
+	hourly, _ := ParseCron("30 * * * *")
+	first = hourly.Next(from.Add(time.Hour))
+	if second := hourly.Next(first); second.Sub(first) != time.Hour || second.Hour() != 1 {
+		t.Errorf("Hourly run after %s is %s, want the repeated 01:30", first, second)
+	}
+
+	never, _ := ParseCron("0 0 30 2 *")
+	if next := never.Next(from); !next.IsZero() {
+		t.Errorf("Expected no fire time for February 30, got %s", next)
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every 5m",
+	} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("ParseCron(%q) = %v, want ErrInvalidCron", expr, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package schedule fires saved workflows on cron schedules.
+//
+// # Overview
+//
+// A Schedule attaches a five-field cron expression, evaluated in an IANA
+// time zone, to a saved workflow. The Scheduler keeps every schedule in
+// memory, writes changes through to a Store, and calls a RunFunc at each
+// fire time:
+//
+//	sched, _ := schedule.New(schedule.Config{
+//	    Store: store,
+//	    Run: func(ctx context.Context, s schedule.Schedule, at time.Time) (string, error) {
+//	        return runWorkflow(ctx, s.TenantID, s.WorkflowID, s.Version, s.Inputs)
+//	    },
+//	})
+//
+//	s, _ := sched.Create("default", schedule.Spec{
+//	    WorkflowID: "wf-1",
+//	    Cron:       "0 9 * * mon-fri",
+//	    TimeZone:   "Europe/London",
+//	})
+//	next, _ := sched.NextRuns(s.ID, 5)
+//
+// # Overlaps and misfires
+//
+// Runs of the same workflow in the same tenant never overlap, even when
+// they come from different schedules. A fire time is a misfire when the
+// scheduler reaches it more than Config.MisfireThreshold late, because the
+// server was down or the workflow was still running. With MisfireSkip,
+// misfires and fire times that would overlap are dropped and counted in
+// SkippedRuns. With MisfireCatchUp, they run one after another, oldest
+// first, up to Config.MaxCatchUp of them.
+//
+// Pausing a schedule stops it firing; resuming it continues from the next
+// fire time, so the time it was paused is not caught up.
+//
+// # Time
+//
+// The scheduler reads the time and sleeps through a Clock. Tests pass a
+// fake clock to fire schedules without waiting.
+package schedule
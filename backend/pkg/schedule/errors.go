@@ -0,0 +1,11 @@
+package schedule
+
+import "errors"
+
+// Sentinel errors for schedules
+var (
+	ErrInvalidCron      = errors.New("invalid cron expression")
+	ErrInvalidSchedule  = errors.New("invalid schedule")
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrSchedulerClosed  = errors.New("scheduler is shut down")
+)
@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Time zones resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// MisfirePolicy decides what happens to fire times the scheduler missed,
+// because the server was down or an earlier run of the workflow was still
+// going
+type MisfirePolicy string
+
+const (
+	// MisfireSkip drops missed fire times and waits for the next one
+	MisfireSkip MisfirePolicy = "skip"
+
+	// MisfireCatchUp runs every missed fire time, oldest first and one at
+	// a time, up to Config.MaxCatchUp of them
+	MisfireCatchUp MisfirePolicy = "catch_up"
+)
+
+// RunStatus is the state of one scheduled run
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+)
+
+// Spec is the part of a schedule its owner sets
+type Spec struct {
+	// WorkflowID is the saved workflow to run
+	WorkflowID string `json:"workflow_id"`
+
+	// Version pins a workflow version; 0 runs the latest
+	Version int `json:"version,omitempty"`
+
+	// Cron is a five-field cron expression, see ParseCron
+	Cron string `json:"cron"`
+
+	// TimeZone is the IANA time zone the expression is evaluated in
+	// (default UTC)
+	TimeZone string `json:"timezone,omitempty"`
+
+	// Inputs are bound to the workflow's declared inputs on every run
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+
+	// MisfirePolicy handles missed fire times (default skip)
+	MisfirePolicy MisfirePolicy `json:"misfire_policy,omitempty"`
+
+	// Paused schedules keep their settings but do not fire
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Schedule attaches a cron expression to a saved workflow
+type Schedule struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Spec
+
+	// NextRunAt is the next fire time, unset while paused
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+
+	// LastRun is the most recent run, if any
+	LastRun *Run `json:"last_run,omitempty"`
+
+	// SkippedRuns counts fire times dropped as misfires or overlaps
+	SkippedRuns int `json:"skipped_runs"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Run is one scheduled execution of a workflow
+type Run struct {
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ExecutionID string     `json:"execution_id,omitempty"`
+	Status      RunStatus  `json:"status"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Filter selects schedules in List. Empty fields match everything.
+type Filter struct {
+	TenantID   string
+	WorkflowID string
+}
+
+// normalize fills defaults and checks spec, returning its parsed cron
+// expression and time zone
+func (spec *Spec) normalize() (*Cron, *time.Location, error) {
+	spec.WorkflowID = strings.TrimSpace(spec.WorkflowID)
+	if spec.WorkflowID == "" {
+		return nil, nil, fmt.Errorf("%w: workflow_id is required", ErrInvalidSchedule)
+	}
+	if spec.Version < 0 {
+		return nil, nil, fmt.Errorf("%w: version must be positive", ErrInvalidSchedule)
+	}
+
+	cron, err := ParseCron(spec.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec.Cron = cron.String()
+
+	if spec.TimeZone == "" {
+		spec.TimeZone = "UTC"
+	}
+	loc, err := time.LoadLocation(spec.TimeZone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidSchedule, spec.TimeZone)
+	}
+
+	switch spec.MisfirePolicy {
+	case "":
+		spec.MisfirePolicy = MisfireSkip
+	case MisfireSkip, MisfireCatchUp:
+	default:
+		return nil, nil, fmt.Errorf("%w: misfire_policy must be %q or %q", ErrInvalidSchedule, MisfireSkip, MisfireCatchUp)
+	}
+	return cron, loc, nil
+}
+
+// matches reports whether the schedule passes the filter
+func (f Filter) matches(s *Schedule) bool {
+	if f.TenantID != "" && s.TenantID != f.TenantID {
+		return false
+	}
+	return f.WorkflowID == "" || s.WorkflowID == f.WorkflowID
+}
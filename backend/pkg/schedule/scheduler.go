@@ -0,0 +1,522 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock tells the time and waits for it. Tests replace SystemClock with a
+// fake to fire schedules without sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the wall clock
+var SystemClock Clock = systemClock{}
+
+// RunFunc runs one scheduled execution of s and blocks until it finishes.
+// It returns the ID of the execution, if one was started, and the reason
+// the run failed, if it did. ctx is cancelled when the scheduler shuts
+// down.
+type RunFunc func(ctx context.Context, s Schedule, scheduledAt time.Time) (executionID string, err error)
+
+// Config holds scheduler configuration
+type Config struct {
+	// Run executes scheduled workflows. Required.
+	Run RunFunc
+
+	// Store persists schedules (default a MemoryStore)
+	Store Store
+
+	// Clock is the scheduler's time source (default SystemClock)
+	Clock Clock
+
+	// MisfireThreshold is how late a fire time may be and still run under
+	// MisfireSkip (default one minute)
+	MisfireThreshold time.Duration
+
+	// MaxCatchUp is the most missed fire times one schedule runs under
+	// MisfireCatchUp; older ones are skipped (default 10)
+	MaxCatchUp int
+
+	// OnError, if set, is called when a schedule cannot be persisted
+	OnError func(error)
+}
+
+// maxWait bounds how long the scheduler sleeps, so it notices wall-clock
+// jumps and host suspends
+const maxWait = time.Minute
+
+// Scheduler fires saved workflows on cron schedules
+type Scheduler struct {
+	config Config
+
+	mu        sync.Mutex
+	schedules map[string]*entry
+	running   map[string]bool // overlap keys of running workflows
+	closed    bool
+
+	wake   chan struct{}
+	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// entry is a schedule with its parsed expression and time zone
+type entry struct {
+	schedule Schedule
+	cron     *Cron
+	loc      *time.Location
+}
+
+// New loads the stored schedules and starts the scheduler. Runs that were
+// in progress when the store was last written are marked failed. Fire
+// times missed while the scheduler was down are handled by each
+// schedule's misfire policy.
+func New(config Config) (*Scheduler, error) {
+	if config.Run == nil {
+		return nil, fmt.Errorf("schedule run function is required")
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock
+	}
+	if config.MisfireThreshold <= 0 {
+		config.MisfireThreshold = time.Minute
+	}
+	if config.MaxCatchUp <= 0 {
+		config.MaxCatchUp = 10
+	}
+
+	stored, err := config.Store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		config:    config,
+		schedules: make(map[string]*entry, len(stored)),
+		running:   make(map[string]bool),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	now := config.Clock.Now()
+	for _, sch := range stored {
+		cron, loc, err := sch.Spec.normalize()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("stored schedule %s: %w", sch.ID, err)
+		}
+		e := &entry{schedule: *sch, cron: cron, loc: loc}
+		if run := e.schedule.LastRun; run != nil && run.Status == RunRunning {
+			run.Status = RunFailed
+			run.Error = "interrupted by scheduler shutdown"
+			run.FinishedAt = &now
+		}
+		if !e.schedule.Paused && e.schedule.NextRunAt == nil {
+			e.schedule.NextRunAt = e.next(now)
+		}
+		s.schedules[sch.ID] = e
+	}
+
+	s.wg.Add(1)
+	go s.loop()
+	return s, nil
+}
+
+// Create adds a schedule for a tenant's workflow. The caller checks that
+// the workflow exists.
+func (s *Scheduler) Create(tenantID string, spec Spec) (Schedule, error) {
+	cron, loc, err := spec.normalize()
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return Schedule{}, ErrSchedulerClosed
+	}
+
+	now := s.config.Clock.Now()
+	e := &entry{
+		schedule: Schedule{
+			ID:        uuid.New().String(),
+			TenantID:  tenantID,
+			Spec:      spec,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		cron: cron,
+		loc:  loc,
+	}
+	if !spec.Paused {
+		e.schedule.NextRunAt = e.next(now)
+	}
+	if err := s.config.Store.Save(&e.schedule); err != nil {
+		return Schedule{}, err
+	}
+	s.schedules[e.schedule.ID] = e
+	s.notify()
+	return e.snapshot(), nil
+}
+
+// Get returns the schedule with the given ID
+func (s *Scheduler) Get(id string) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrScheduleNotFound, id)
+	}
+	return e.snapshot(), nil
+}
+
+// List returns the schedules matching filter, oldest first
+func (s *Scheduler) List(filter Filter) []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, e := range s.schedules {
+		if filter.matches(&e.schedule) {
+			schedules = append(schedules, e.snapshot())
+		}
+	}
+	sort.Slice(schedules, func(i, k int) bool {
+		if schedules[i].CreatedAt.Equal(schedules[k].CreatedAt) {
+			return schedules[i].ID < schedules[k].ID
+		}
+		return schedules[i].CreatedAt.Before(schedules[k].CreatedAt)
+	})
+	return schedules
+}
+
+// Update replaces a schedule's spec and computes its next fire time from
+// now. Its run history is kept.
+func (s *Scheduler) Update(id string, spec Spec) (Schedule, error) {
+	cron, loc, err := spec.normalize()
+	if err != nil {
+		return Schedule{}, err
+	}
+	return s.modify(id, func(e *entry, now time.Time) {
+		e.schedule.Spec = spec
+		e.cron, e.loc = cron, loc
+		e.schedule.NextRunAt = nil
+		if !spec.Paused {
+			e.schedule.NextRunAt = e.next(now)
+		}
+	})
+}
+
+// Pause stops a schedule from firing. A run in progress is not affected.
+func (s *Scheduler) Pause(id string) (Schedule, error) {
+	return s.modify(id, func(e *entry, now time.Time) {
+		e.schedule.Paused = true
+		e.schedule.NextRunAt = nil
+	})
+}
+
+// Resume lets a paused schedule fire again from its next fire time after
+// now. Fire times that passed while it was paused are not misfires.
+func (s *Scheduler) Resume(id string) (Schedule, error) {
+	return s.modify(id, func(e *entry, now time.Time) {
+		if e.schedule.Paused {
+			e.schedule.Paused = false
+			e.schedule.NextRunAt = e.next(now)
+		}
+	})
+}
+
+// Delete removes a schedule. A run in progress is not affected.
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSchedulerClosed
+	}
+
+	if _, ok := s.schedules[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrScheduleNotFound, id)
+	}
+	if err := s.config.Store.Delete(id); err != nil {
+		return err
+	}
+	delete(s.schedules, id)
+	return nil
+}
+
+// NextRuns returns up to n upcoming fire times of a schedule, starting
+// with NextRunAt. A paused schedule has none.
+func (s *Scheduler) NextRuns(id string, n int) ([]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.schedules[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrScheduleNotFound, id)
+	}
+	var times []time.Time
+	for next := e.schedule.NextRunAt; next != nil && len(times) < n; next = e.next(*next) {
+		times = append(times, *next)
+	}
+	return times, nil
+}
+
+// Shutdown stops firing schedules, cancels runs in progress and waits for
+// them to return or ctx to expire
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// modify applies change to a schedule under the lock and persists it
+func (s *Scheduler) modify(id string, change func(e *entry, now time.Time)) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return Schedule{}, ErrSchedulerClosed
+	}
+
+	e, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrScheduleNotFound, id)
+	}
+
+	previous := *e
+	now := s.config.Clock.Now()
+	change(e, now)
+	e.schedule.UpdatedAt = now
+	if err := s.config.Store.Save(&e.schedule); err != nil {
+		*e = previous
+		return Schedule{}, err
+	}
+	s.notify()
+	return e.snapshot(), nil
+}
+
+// loop fires due schedules until the scheduler shuts down
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	for {
+		wait := s.tick()
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-s.config.Clock.After(wait):
+		}
+	}
+}
+
+// tick fires every due schedule and returns how long to wait for the next
+// one
+func (s *Scheduler) tick() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return maxWait
+	}
+
+	now := s.config.Clock.Now()
+	due := make([]*entry, 0)
+	wait := maxWait
+	for _, e := range s.schedules {
+		next := e.schedule.NextRunAt
+		switch {
+		case e.schedule.Paused || next == nil:
+		case next.After(now):
+			if d := next.Sub(now); d < wait {
+				wait = d
+			}
+		default:
+			due = append(due, e)
+		}
+	}
+	// Oldest fire times first, so catch-up order is deterministic
+	sort.Slice(due, func(i, k int) bool {
+		a, b := due[i].schedule, due[k].schedule
+		if a.NextRunAt.Equal(*b.NextRunAt) {
+			return a.ID < b.ID
+		}
+		return a.NextRunAt.Before(*b.NextRunAt)
+	})
+
+	for _, e := range due {
+		sch := &e.schedule
+		switch {
+		case s.running[e.overlapKey()] && sch.MisfirePolicy == MisfireCatchUp:
+			// Fires once the running execution finishes
+			continue
+		case s.running[e.overlapKey()]:
+			s.skipThrough(e, now)
+		case sch.MisfirePolicy == MisfireSkip:
+			s.skipThrough(e, now.Add(-s.config.MisfireThreshold))
+		default:
+			s.limitCatchUp(e, now)
+		}
+		if next := sch.NextRunAt; next != nil && !next.After(now) && !s.running[e.overlapKey()] {
+			s.fire(e, now)
+		}
+		s.save(sch)
+		if next := sch.NextRunAt; next != nil && next.After(now) && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// skipThrough drops the fire times of e up to and including until.
+// Callers must hold s.mu.
+func (s *Scheduler) skipThrough(e *entry, until time.Time) {
+	for e.schedule.NextRunAt != nil && !e.schedule.NextRunAt.After(until) {
+		e.schedule.SkippedRuns++
+		e.schedule.NextRunAt = e.next(*e.schedule.NextRunAt)
+	}
+}
+
+// limitCatchUp drops all but the newest MaxCatchUp missed fire times of e.
+// Callers must hold s.mu.
+func (s *Scheduler) limitCatchUp(e *entry, now time.Time) {
+	var missed []time.Time
+	for next := e.schedule.NextRunAt; next != nil && !next.After(now); next = e.next(*next) {
+		missed = append(missed, *next)
+		if len(missed) > s.config.MaxCatchUp {
+			missed = missed[1:]
+			e.schedule.SkippedRuns++
+		}
+	}
+	e.schedule.NextRunAt = &missed[0]
+}
+
+// fire starts a run of e for its current fire time and advances it to the
+// following one. Callers must hold s.mu.
+func (s *Scheduler) fire(e *entry, now time.Time) {
+	sch := &e.schedule
+	scheduledAt := *sch.NextRunAt
+	sch.NextRunAt = e.next(scheduledAt)
+	sch.LastRun = &Run{ScheduledAt: scheduledAt, StartedAt: now, Status: RunRunning}
+
+	key := e.overlapKey()
+	s.running[key] = true
+	snapshot := e.snapshot()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		executionID, err := s.config.Run(s.ctx, snapshot, scheduledAt)
+		s.finish(snapshot.ID, key, scheduledAt, executionID, err)
+	}()
+}
+
+// finish records the outcome of a run and wakes the loop, which may have
+// catch-up runs waiting for it
+func (s *Scheduler) finish(id, key string, scheduledAt time.Time, executionID string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, key)
+	if e, ok := s.schedules[id]; ok && e.schedule.LastRun != nil && e.schedule.LastRun.ScheduledAt.Equal(scheduledAt) {
+		run := *e.schedule.LastRun
+		finished := s.config.Clock.Now()
+		run.FinishedAt = &finished
+		run.ExecutionID = executionID
+		run.Status = RunSucceeded
+		if err != nil {
+			run.Status = RunFailed
+			run.Error = err.Error()
+		}
+		e.schedule.LastRun = &run
+		s.save(&e.schedule)
+	}
+	s.notify()
+}
+
+// save persists a schedule changed by the scheduler itself, reporting
+// failures to OnError. Callers must hold s.mu.
+func (s *Scheduler) save(sch *Schedule) {
+	if err := s.config.Store.Save(sch); err != nil && s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// notify wakes the loop to recompute its wait
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the fire time after t in the schedule's time zone, or nil
+// when there is none
+func (e *entry) next(t time.Time) *time.Time {
+	next := e.cron.Next(t.In(e.loc))
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// overlapKey identifies the workflow whose runs must not overlap
+func (e *entry) overlapKey() string {
+	return e.schedule.TenantID + "/" + e.schedule.WorkflowID
+}
+
+// snapshot copies the schedule so it can be read without the lock
+func (e *entry) snapshot() Schedule {
+	sch := e.schedule
+	if sch.NextRunAt != nil {
+		next := *sch.NextRunAt
+		sch.NextRunAt = &next
+	}
+	if sch.LastRun != nil {
+		run := *sch.LastRun
+		sch.LastRun = &run
+	}
+	return sch
+}
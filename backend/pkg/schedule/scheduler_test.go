@@ -0,0 +1,333 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock moved by hand. Every Advance wakes every waiter:
+// the scheduler treats wakeups as hints and re-reads the time, so early
+// wakeups are harmless and no wakeup is lost to a race with Advance.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, ch)
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, ch := range c.waiters {
+		ch <- c.now
+	}
+	c.waiters = nil
+}
+
+// firing is one call of the test RunFunc
+type firing struct {
+	scheduleID  string
+	scheduledAt time.Time
+}
+
+// harness runs a scheduler whose runs report on fired. Runs of the
+// workflows in block wait for a value on release.
+type harness struct {
+	t       *testing.T
+	clock   *fakeClock
+	sched   *Scheduler
+	fired   chan firing
+	release chan error
+	block   map[string]bool
+}
+
+func newHarness(t *testing.T, store Store, start time.Time, block ...string) *harness {
+	t.Helper()
+	h := &harness{
+		t:       t,
+		clock:   &fakeClock{now: start},
+		fired:   make(chan firing, 100),
+		release: make(chan error, 100),
+		block:   make(map[string]bool),
+	}
+	for _, id := range block {
+		h.block[id] = true
+	}
+	sched, err := New(Config{
+		Store: store,
+		Clock: h.clock,
+		Run: func(ctx context.Context, s Schedule, at time.Time) (string, error) {
+			h.fired <- firing{scheduleID: s.ID, scheduledAt: at}
+			if !h.block[s.WorkflowID] {
+				return "exec-" + at.Format("1504"), nil
+			}
+			select {
+			case err := <-h.release:
+				return "exec-" + at.Format("1504"), err
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	h.sched = sched
+	t.Cleanup(func() { _ = sched.Shutdown(context.Background()) })
+	return h
+}
+
+// advance moves the clock and returns the runs fired within a short wait
+func (h *harness) advance(d time.Duration, want int) []firing {
+	h.t.Helper()
+	h.clock.Advance(d)
+	var fired []firing
+	deadline := time.After(2 * time.Second)
+	for len(fired) < want {
+		select {
+		case f := <-h.fired:
+			fired = append(fired, f)
+		case <-time.After(5 * time.Millisecond):
+			// Kick the loop in case it was between reading the time and
+			// waiting when the clock moved
+			h.clock.Advance(0)
+		case <-deadline:
+			h.t.Fatalf("Got %d runs, want %d", len(fired), want)
+		}
+	}
+	// Give unexpected extra runs a chance to show up
+	h.clock.Advance(0)
+	select {
+	case f := <-h.fired:
+		h.t.Fatalf("Unexpected run of %s at %s", f.scheduleID, f.scheduledAt)
+	case <-time.After(20 * time.Millisecond):
+	}
+	return fired
+}
+
+// waitFor polls the schedule until cond holds
+func (h *harness) waitFor(id string, cond func(Schedule) bool) Schedule {
+	h.t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s, err := h.sched.Get(id)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		if cond(s) {
+			return s
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("Schedule never reached the expected state: %+v", s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+var start = time.Date(2026, 3, 2, 8, 59, 30, 0, time.UTC) // a Monday
+
+func TestSchedulerFires(t *testing.T) {
+	h := newHarness(t, nil, start)
+
+	s, err := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "0 9 * * *", TimeZone: "Europe/London"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.MisfirePolicy != MisfireSkip || s.NextRunAt == nil || !s.NextRunAt.Equal(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected schedule %+v", s)
+	}
+	if s.NextRunAt.Location().String() != "Europe/London" {
+		t.Errorf("NextRunAt is in %s, want the schedule's time zone", s.NextRunAt.Location())
+	}
+
+	next, err := h.sched.NextRuns(s.ID, 3)
+	if err != nil || len(next) != 3 || next[2].Sub(next[0]) != 48*time.Hour {
+		t.Errorf("NextRuns = %v, %v", next, err)
+	}
+
+	h.advance(20*time.Second, 0)
+	fired := h.advance(10*time.Second, 1)
+	if !fired[0].scheduledAt.Equal(*s.NextRunAt) {
+		t.Errorf("Fired for %s, want %s", fired[0].scheduledAt, s.NextRunAt)
+	}
+	s = h.waitFor(s.ID, func(s Schedule) bool { return s.LastRun != nil && s.LastRun.Status == RunSucceeded })
+	if s.LastRun.ExecutionID != "exec-0900" || s.LastRun.FinishedAt == nil {
+		t.Errorf("Unexpected last run %+v", s.LastRun)
+	}
+	if !s.NextRunAt.Equal(time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextRunAt = %s, want the next day", s.NextRunAt)
+	}
+
+	if _, err := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "bad"}); !errors.Is(err, ErrInvalidCron) {
+		t.Errorf("Expected ErrInvalidCron, got %v", err)
+	}
+	if _, err := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "@daily", TimeZone: "Mars/Olympus"}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Expected ErrInvalidSchedule for time zone, got %v", err)
+	}
+	if _, err := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "@daily", MisfirePolicy: "later"}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Expected ErrInvalidSchedule for misfire policy, got %v", err)
+	}
+}
+
+func TestSchedulerPauseResume(t *testing.T) {
+	h := newHarness(t, nil, start)
+	s, _ := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "* * * * *"})
+
+	paused, err := h.sched.Pause(s.ID)
+	if err != nil || !paused.Paused || paused.NextRunAt != nil {
+		t.Fatalf("Pause = %+v, %v", paused, err)
+	}
+	if next, _ := h.sched.NextRuns(s.ID, 3); len(next) != 0 {
+		t.Errorf("Paused schedule has next runs %v", next)
+	}
+	h.advance(5*time.Minute, 0)
+
+	resumed, err := h.sched.Resume(s.ID)
+	if err != nil || resumed.Paused || !resumed.NextRunAt.Equal(time.Date(2026, 3, 2, 9, 5, 0, 0, time.UTC)) {
+		t.Fatalf("Resume = %+v, %v", resumed, err)
+	}
+	h.advance(time.Minute, 1)
+	if s := h.waitFor(s.ID, func(s Schedule) bool { return s.LastRun != nil }); s.SkippedRuns != 0 {
+		t.Errorf("Paused time counted as %d skipped runs", s.SkippedRuns)
+	}
+
+	updated, err := h.sched.Update(s.ID, Spec{WorkflowID: "wf-1", Cron: "0 12 * * *", Paused: true})
+	if err != nil || !updated.Paused || updated.LastRun == nil || updated.Cron != "0 12 * * *" {
+		t.Errorf("Update = %+v, %v", updated, err)
+	}
+
+	if err := h.sched.Delete(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.sched.Get(s.ID); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+	}
+	if _, err := h.sched.Pause(s.ID); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	for _, policy := range []MisfirePolicy{MisfireSkip, MisfireCatchUp} {
+		t.Run(string(policy), func(t *testing.T) {
+			h := newHarness(t, nil, start, "wf-1")
+			s, _ := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "* * * * *", MisfirePolicy: policy})
+			other, _ := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "1 9 * * *"})
+			unrelated, _ := h.sched.Create("default", Spec{WorkflowID: "wf-2", Cron: "1 9 * * *"})
+
+			// The 09:00 run of wf-1 is still going at 09:01, so only wf-2
+			// fires then
+			h.advance(30*time.Second, 1)
+			if fired := h.advance(time.Minute, 1); fired[0].scheduleID != unrelated.ID {
+				t.Fatalf("Schedule %s fired during an overlap", fired[0].scheduleID)
+			}
+			if got, _ := h.sched.Get(other.ID); got.SkippedRuns != 1 || got.LastRun != nil {
+				t.Errorf("Overlapping schedule: skipped %d, last run %+v", got.SkippedRuns, got.LastRun)
+			}
+
+			h.release <- nil
+			if policy == MisfireCatchUp {
+				// 09:01 runs as soon as 09:00 finishes
+				fired := h.advance(0, 1)
+				if !fired[0].scheduledAt.Equal(start.Add(90 * time.Second)) {
+					t.Errorf("Caught up %s, want 09:01", fired[0].scheduledAt)
+				}
+				h.release <- nil
+				h.waitFor(s.ID, func(s Schedule) bool { return s.LastRun.FinishedAt != nil && s.LastRun.ScheduledAt.Minute() == 1 })
+			} else {
+				h.advance(0, 0)
+				got := h.waitFor(s.ID, func(s Schedule) bool { return s.LastRun.FinishedAt != nil })
+				if got.SkippedRuns != 1 || got.LastRun.ScheduledAt.Minute() != 0 {
+					t.Errorf("Skip schedule: skipped %d, last run %+v", got.SkippedRuns, got.LastRun)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerMisfires(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHarness(t, store, start, "wf-1", "wf-2")
+	skip, _ := h.sched.Create("default", Spec{WorkflowID: "wf-1", Cron: "*/10 * * * *"})
+	catchUp, _ := h.sched.Create("default", Spec{WorkflowID: "wf-2", Cron: "*/10 * * * *", MisfirePolicy: MisfireCatchUp})
+	h.advance(time.Minute, 2)
+	if err := h.sched.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart at 11:00:30. The runs cut short by the shutdown failed. wf-1
+	// skips its 11 misfires and runs 11:00, which is on time; wf-2 runs
+	// the newest 10 fire times, one at a time.
+	store, err = NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = newHarness(t, store, start.Add(2*time.Hour+time.Minute), "wf-2")
+	for _, id := range []string{skip.ID, catchUp.ID} {
+		s, err := h.sched.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.LastRun == nil || s.LastRun.Status != RunFailed || s.LastRun.Error == "" {
+			t.Errorf("Interrupted run not marked failed: %+v", s.LastRun)
+		}
+	}
+
+	var caughtUp []time.Time
+	for _, f := range h.advance(0, 2) {
+		if f.scheduleID == catchUp.ID {
+			caughtUp = append(caughtUp, f.scheduledAt)
+		}
+	}
+	for len(caughtUp) < 10 {
+		h.release <- nil
+		fired := h.advance(0, 1)
+		caughtUp = append(caughtUp, fired[0].scheduledAt)
+	}
+	h.release <- nil
+	if first, last := caughtUp[0], caughtUp[9]; !first.Equal(time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)) ||
+		!last.Equal(time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("Caught up %s to %s, want 09:30 to 11:00", first, last)
+	}
+
+	s := h.waitFor(skip.ID, func(s Schedule) bool { return s.LastRun.Status == RunSucceeded })
+	if s.SkippedRuns != 11 || !s.LastRun.ScheduledAt.Equal(time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)) ||
+		!s.NextRunAt.Equal(time.Date(2026, 3, 2, 11, 10, 0, 0, time.UTC)) {
+		t.Errorf("Skip schedule: skipped %d, last run %s, next %s", s.SkippedRuns, s.LastRun.ScheduledAt, s.NextRunAt)
+	}
+	s = h.waitFor(catchUp.ID, func(s Schedule) bool { return s.LastRun.Status == RunSucceeded })
+	if s.SkippedRuns != 2 {
+		t.Errorf("Catch-up schedule skipped %d runs, want the 2 beyond MaxCatchUp", s.SkippedRuns)
+	}
+
+	// Schedules survive in the file store
+	if err := h.sched.Delete(skip.ID); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.Load()
+	if err != nil || len(stored) != 1 || stored[0].ID != catchUp.ID {
+		t.Errorf("Store holds %d schedules, %v", len(stored), err)
+	}
+}
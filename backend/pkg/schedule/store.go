@@ -0,0 +1,39 @@
+package schedule
+
+import "github.com/yesoreyeram/thaiyyal/backend/pkg/filestore"
+
+// Store persists schedules. The scheduler keeps its own copy of every
+// schedule and writes through to the store on each change, so stores need
+// no querying.
+type Store interface {
+	// Load returns every stored schedule
+	Load() ([]*Schedule, error)
+
+	// Save creates or replaces a schedule
+	Save(s *Schedule) error
+
+	// Delete removes a schedule; deleting a missing one is not an error
+	Delete(id string) error
+}
+
+// MemoryStore keeps schedules until the process exits
+type MemoryStore = filestore.Memory[Schedule]
+
+// FileStore keeps one JSON file per schedule in a directory
+type FileStore = filestore.Store[Schedule]
+
+// storeOptions describes schedules to filestore
+var storeOptions = filestore.Options[Schedule]{
+	Kind: "schedule",
+	ID:   func(s *Schedule) string { return s.ID },
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return filestore.NewMemory(storeOptions)
+}
+
+// NewFileStore opens the store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	return filestore.New(dir, storeOptions)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestAuthorization(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	err := os.WriteFile(keysFile, []byte(`{"keys": [
+		{"name": "viewer", "key": "viewer-key", "roles": ["viewer"]},
+		{"name": "editor", "key": "editor-key", "roles": ["editor"]},
+		{"name": "executor", "key": "executor-key", "roles": ["executor"]},
+		{"name": "admin", "key": "admin-key", "roles": ["admin"]}
+	]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.Auth = auth.Config{APIKeysFile: keysFile}
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.httpServer.Handler
+
+	tests := []struct {
+		name           string
+		key            string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Health is public", "", http.MethodGet, "/health/live", "", http.StatusOK},
+		{"Anonymous API call", "", http.MethodGet, "/api/v1/workflow/list", "", http.StatusUnauthorized},
+		{"Unknown key", "wrong", http.MethodGet, "/health/live", "", http.StatusUnauthorized},
+		{"Viewer lists workflows", "viewer-key", http.MethodGet, "/api/v1/workflow/list", "", http.StatusOK},
+		{"Viewer lists executions", "viewer-key", http.MethodGet, "/api/v1/executions", "", http.StatusOK},
+		{"Viewer cannot save", "viewer-key", http.MethodPost, "/api/v1/workflow/save", `{"name": "w", "data": ` + addWorkflow + `}`, http.StatusForbidden},
+		{"Viewer cannot execute", "viewer-key", http.MethodPost, "/api/v1/workflow/execute", addWorkflow, http.StatusForbidden},
+		{"Viewer cannot submit", "viewer-key", http.MethodPost, "/api/v1/executions", `{"workflow": ` + addWorkflow + `}`, http.StatusForbidden},
+		{"Editor saves", "editor-key", http.MethodPost, "/api/v1/workflow/save", `{"name": "w", "data": ` + addWorkflow + `}`, http.StatusCreated},
+		{"Editor cannot execute", "editor-key", http.MethodPost, "/api/v1/workflow/execute", addWorkflow, http.StatusForbidden},
+		{"Executor executes", "executor-key", http.MethodPost, "/api/v1/workflow/execute", addWorkflow, http.StatusOK},
+		{"Executor submits", "executor-key", http.MethodPost, "/api/v1/executions", `{"workflow": ` + addWorkflow + `}`, http.StatusAccepted},
+		{"Executor cannot save", "executor-key", http.MethodPost, "/api/v1/workflow/save", `{"name": "w", "data": ` + addWorkflow + `}`, http.StatusForbidden},
+		{"Executor cannot register HTTP clients", "executor-key", http.MethodPost, "/api/v1/httpclient/register", `{"config": {"uid": "c1"}}`, http.StatusForbidden},
+		{"Editor cannot import HTTP clients", "editor-key", http.MethodPost, "/api/v1/workflow/import", `{"bundle": {"http_clients": [{"uid": "c2"}]}}`, http.StatusForbidden},
+		{"Executor cannot snapshot a debug session", "executor-key", http.MethodPost, "/api/v1/executions/missing/debug", `{"command": "snapshot"}`, http.StatusForbidden},
+		{"Executor sends other debug commands", "executor-key", http.MethodPost, "/api/v1/executions/missing/debug", `{"command": "step"}`, http.StatusNotFound},
+		{"Admin snapshots a debug session", "admin-key", http.MethodPost, "/api/v1/executions/missing/debug", `{"command": "snapshot"}`, http.StatusNotFound},
+		{"Admin registers HTTP clients", "admin-key", http.MethodPost, "/api/v1/httpclient/register", `{"config": {"uid": "c1"}}`, http.StatusCreated},
+		{"Unlisted method", "admin-key", http.MethodPut, "/api/v1/executions", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected WWW-Authenticate header on 401")
+			}
+		})
+	}
+
+	config.Auth = auth.Config{APIKeysFile: filepath.Join(t.TempDir(), "missing.json")}
+	if _, err := New(config, types.DefaultConfig()); err == nil {
+		t.Error("Expected error for missing API key file")
+	}
+}
+
+func TestVersionAuthorFromPrincipal(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	err := os.WriteFile(keysFile, []byte(`{"keys": [{"name": "ana", "key": "ana-key", "roles": ["editor"]}]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Auth = auth.Config{APIKeysFile: keysFile}
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(auth.APIKeyHeader, "ana-key")
+		w := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// The author in the body is ignored in favour of the caller
+	w := do(http.MethodPost, "/api/v1/workflow/save", `{"name": "w", "author": "bo", "data": `+addWorkflow+`}`)
+	var saved SaveWorkflowResponse
+	_ = json.NewDecoder(w.Body).Decode(&saved)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Save returned %d: %+v", w.Code, saved)
+	}
+	w = do(http.MethodPost, "/api/v1/workflow/rollback/"+saved.ID, `{"version": 1, "author": "bo"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Rollback returned %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodGet, "/api/v1/workflow/versions/"+saved.ID, "")
+	var list ListVersionsResponse
+	_ = json.NewDecoder(w.Body).Decode(&list)
+	if w.Code != http.StatusOK || len(list.Versions) != 2 {
+		t.Fatalf("List versions returned %d %+v", w.Code, list)
+	}
+	for _, version := range list.Versions {
+		if version.Author != "ana" {
+			t.Errorf("Version %d author = %q, want the authenticated caller", version.Version, version.Author)
+		}
+	}
+}
+
+func TestCORSAllowList(t *testing.T) {
+	config := DefaultConfig()
+	config.CORSAllowedOrigins = []string{"https://app.example.com", "https://*.thaiyyal.dev"}
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		origin         string
+		expectedStatus int
+		allowOrigin    string
+	}{
+		{"Listed origin", http.MethodGet, "https://app.example.com", http.StatusOK, "https://app.example.com"},
+		{"Wildcard subdomain", http.MethodGet, "https://ui.thaiyyal.dev", http.StatusOK, "https://ui.thaiyyal.dev"},
+		{"Unlisted origin", http.MethodGet, "https://evil.example.com", http.StatusOK, ""},
+		{"Lookalike domain", http.MethodGet, "https://evilthaiyyal.dev", http.StatusOK, ""},
+		{"No origin", http.MethodGet, "", http.StatusOK, ""},
+		{"Preflight from listed origin", http.MethodOptions, "https://app.example.com", http.StatusOK, "https://app.example.com"},
+		{"Preflight from unlisted origin", http.MethodOptions, "https://evil.example.com", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/workflow/list", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	workflow "github.com/yesoreyeram/thaiyyal/backend"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/bundle"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/history"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/httpclient"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/openapi"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/runner"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/schedule"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/trigger"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// apiOperation describes one API operation for the OpenAPI document. The
+// request and response values are the Go types the handler decodes and
+// writes; their schemas are derived from them.
+type apiOperation struct {
+	method     string
+	path       string
+	id         string
+	summary    string
+	tag        string
+	permission auth.Permission // empty for public operations
+	query      []openapi.Parameter
+	request    interface{}
+	responses  map[int]interface{} // nil value: no JSON body
+	events     bool                // responds with a Server-Sent Events stream
+}
+
+// anyOf documents a status whose body is one of several types, such as
+// handlers answering malformed requests with ErrorResponse
+type anyOf []interface{}
+
+// Query parameters shared by several operations
+var (
+	versionParam  = queryParam("version", "integer", "Workflow version (default latest)")
+	offsetParam   = queryParam("offset", "integer", "Number of items to skip")
+	limitParam    = queryParam("limit", "integer", "Maximum number of items to return")
+	nextRunsParam = queryParam("next", "integer", "Number of upcoming fire times to list (default 5, max 100)")
+)
+
+// apiOperations lists every operation of the REST API. Keep it in sync
+// with registerRoutes; TestOpenAPIDrift fails when they differ.
+var apiOperations = []apiOperation{
+	// Workflows
+	{method: http.MethodPost, path: "/api/v1/workflow/execute", id: "executeWorkflow", summary: "Execute a workflow",
+		tag: "Workflows", permission: auth.PermissionExecute, request: types.Payload{},
+		responses: map[int]interface{}{200: ExecuteWorkflowResponse{}, 400: ErrorResponse{}, 429: ErrorResponse{}, 500: ErrorResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/workflow/validate", id: "validateWorkflow", summary: "Validate a workflow",
+		tag: "Workflows", permission: auth.PermissionRead, request: types.Payload{},
+		responses: map[int]interface{}{200: ValidateWorkflowResponse{}, 400: ErrorResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/workflow/plan", id: "planWorkflow", summary: "Dry-run a workflow: the nodes it would run, skip or decide at runtime",
+		tag: "Workflows", permission: auth.PermissionRead, request: types.Payload{},
+		responses: map[int]interface{}{200: PlanWorkflowResponse{}, 400: ErrorResponse{}, 500: ErrorResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/workflow/save", id: "saveWorkflow", summary: "Save a workflow, or a new version when id is set",
+		tag: "Workflows", permission: auth.PermissionWrite, request: SaveWorkflowRequest{},
+		responses: map[int]interface{}{200: SaveWorkflowResponse{}, 201: SaveWorkflowResponse{}, 400: anyOf{SaveWorkflowResponse{}, ErrorResponse{}}, 404: SaveWorkflowResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/workflow/list", id: "listWorkflows", summary: "List saved workflows",
+		tag: "Workflows", permission: auth.PermissionRead,
+		query: []openapi.Parameter{
+			queryParam("q", "string", "Case-insensitive search in names and descriptions"),
+			queryParam("sort", "string", "Sort field: name, created_at or updated_at"),
+			queryParam("order", "string", "Sort order: asc or desc"),
+			offsetParam, limitParam,
+		},
+		responses: map[int]interface{}{200: ListWorkflowsResponse{}, 400: ListWorkflowsResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/workflow/load/{id}", id: "loadWorkflow", summary: "Load a workflow",
+		tag: "Workflows", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: LoadWorkflowResponse{}, 400: LoadWorkflowResponse{}, 404: LoadWorkflowResponse{}}},
+	{method: http.MethodDelete, path: "/api/v1/workflow/delete/{id}", id: "deleteWorkflow", summary: "Delete a workflow and its history",
+		tag: "Workflows", permission: auth.PermissionWrite,
+		responses: map[int]interface{}{200: DeleteWorkflowResponse{}, 400: DeleteWorkflowResponse{}, 404: DeleteWorkflowResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/workflow/execute/{id}", id: "executeWorkflowByID", summary: "Execute a saved workflow",
+		tag: "Workflows", permission: auth.PermissionExecute, query: []openapi.Parameter{versionParam}, request: ExecuteWorkflowByIDRequest{},
+		responses: map[int]interface{}{200: ExecuteWorkflowByIDResponse{}, 400: ErrorResponse{}, 404: ErrorResponse{}, 429: ErrorResponse{}, 500: ErrorResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/workflow/export/{id}", id: "exportWorkflow", summary: "Export a workflow bundle with its HTTP clients",
+		tag: "Workflows", permission: auth.PermissionRead, query: []openapi.Parameter{versionParam},
+		responses: map[int]interface{}{200: bundle.Bundle{}, 400: ImportWorkflowResponse{}, 404: ImportWorkflowResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/workflow/import", id: "importWorkflow", summary: "Import a workflow bundle; bundles with HTTP clients need the admin permission",
+		tag: "Workflows", permission: auth.PermissionWrite, request: ImportWorkflowRequest{},
+		responses: map[int]interface{}{200: ImportWorkflowResponse{}, 201: ImportWorkflowResponse{}, 400: anyOf{ImportWorkflowResponse{}, ErrorResponse{}}, 409: ImportWorkflowResponse{}}},
+
+	// Versions
+	{method: http.MethodGet, path: "/api/v1/workflow/versions/{id}", id: "listWorkflowVersions", summary: "List the versions of a workflow",
+		tag: "Versions", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: ListVersionsResponse{}, 400: ListVersionsResponse{}, 404: ListVersionsResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/workflow/versions/{id}/{version}", id: "getWorkflowVersion", summary: "Get one version of a workflow",
+		tag: "Versions", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: GetVersionResponse{}, 400: GetVersionResponse{}, 404: GetVersionResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/workflow/diff/{id}", id: "diffWorkflow", summary: "Diff two versions of a workflow",
+		tag: "Versions", permission: auth.PermissionRead,
+		query: []openapi.Parameter{
+			queryParam("from", "integer", "Older version (default the version before to)"),
+			queryParam("to", "integer", "Newer version (default latest)"),
+		},
+		responses: map[int]interface{}{200: DiffWorkflowResponse{}, 400: DiffWorkflowResponse{}, 404: DiffWorkflowResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/workflow/rollback/{id}", id: "rollbackWorkflow", summary: "Save an earlier version as the new latest version",
+		tag: "Versions", permission: auth.PermissionWrite, request: RollbackWorkflowRequest{},
+		responses: map[int]interface{}{200: SaveWorkflowResponse{}, 400: anyOf{SaveWorkflowResponse{}, ErrorResponse{}}, 404: SaveWorkflowResponse{}}},
+
+	// Executions
+	{method: http.MethodPost, path: "/api/v1/executions", id: "submitExecution", summary: "Start an asynchronous execution",
+		tag: "Executions", permission: auth.PermissionExecute, request: SubmitExecutionRequest{},
+		responses: map[int]interface{}{202: SubmitExecutionResponse{}, 400: ErrorResponse{}, 404: ErrorResponse{}, 429: ErrorResponse{}, 503: ErrorResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/executions", id: "listExecutions", summary: "List asynchronous executions",
+		tag: "Executions", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: ListExecutionsResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/executions/{id}", id: "getExecution", summary: "Get an execution's status, node progress and result",
+		tag: "Executions", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: ExecutionResponse{}, 400: ExecutionResponse{}, 404: ExecutionResponse{}}},
+	{method: http.MethodDelete, path: "/api/v1/executions/{id}", id: "cancelExecution", summary: "Cancel an execution",
+		tag: "Executions", permission: auth.PermissionExecute,
+		responses: map[int]interface{}{200: ExecutionResponse{}, 400: ExecutionResponse{}, 404: ExecutionResponse{}, 409: ExecutionResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/executions/{id}/events", id: "streamExecutionEvents", summary: "Stream an execution's events as Server-Sent Events",
+		tag: "Executions", permission: auth.PermissionRead, events: true,
+		query:     []openapi.Parameter{queryParam("last_event_id", "integer", "Resume after this event ID (or send Last-Event-ID)")},
+		responses: map[int]interface{}{200: nil, 400: ExecutionResponse{}, 404: ExecutionResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/executions/{id}/profile", id: "getExecutionProfile", summary: "Get a finished execution's timing profile and critical path",
+		tag: "Executions", permission: auth.PermissionRead,
+		query:     []openapi.Parameter{queryParam("format", "string", "json (default), or folded for flame graph tools")},
+		responses: map[int]interface{}{200: ExecutionProfileResponse{}, 400: ExecutionProfileResponse{}, 404: ExecutionProfileResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/executions/{id}/debug", id: "getExecutionDebug", summary: "Get a debugged execution's pause point, node results, variables and context",
+		tag: "Executions", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: DebugResponse{}, 404: DebugResponse{}, 409: DebugResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/executions/{id}/debug", id: "debugExecution", summary: "Step, continue, set a variable of or snapshot (admin only) a paused execution",
+		tag: "Executions", permission: auth.PermissionExecute, request: DebugCommandRequest{},
+		responses: map[int]interface{}{200: DebugResponse{}, 400: DebugResponse{}, 404: DebugResponse{}, 409: DebugResponse{}}},
+
+	// History
+	{method: http.MethodGet, path: "/api/v1/history", id: "listHistory", summary: "Search execution history",
+		tag: "History", permission: auth.PermissionRead,
+		query: []openapi.Parameter{
+			queryParam("workflow_id", "string", "Only executions of this workflow"),
+			queryParam("status", "string", "Only executions with this status"),
+			queryParam("from", "string", "Only executions started at or after this RFC 3339 time"),
+			queryParam("to", "string", "Only executions started before this RFC 3339 time"),
+			offsetParam, limitParam,
+		},
+		responses: map[int]interface{}{200: ListHistoryResponse{}, 400: ListHistoryResponse{}, 500: ListHistoryResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/history/{id}", id: "getHistory", summary: "Get an execution record with its node trace",
+		tag: "History", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: GetHistoryResponse{}, 400: GetHistoryResponse{}, 404: GetHistoryResponse{}, 500: GetHistoryResponse{}}},
+
+	// Schedules
+	{method: http.MethodGet, path: "/api/v1/schedules", id: "listSchedules", summary: "List workflow schedules",
+		tag: "Schedules", permission: auth.PermissionRead,
+		query:     []openapi.Parameter{queryParam("workflow_id", "string", "Only schedules of this workflow")},
+		responses: map[int]interface{}{200: ListSchedulesResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/schedules", id: "createSchedule", summary: "Schedule a saved workflow with a cron expression",
+		tag: "Schedules", permission: auth.PermissionExecute, query: []openapi.Parameter{nextRunsParam}, request: schedule.Spec{},
+		responses: map[int]interface{}{201: ScheduleResponse{}, 400: anyOf{ScheduleResponse{}, ErrorResponse{}}, 404: ErrorResponse{}, 500: ScheduleResponse{}, 503: ScheduleResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/schedules/{id}", id: "getSchedule", summary: "Get a schedule with its upcoming fire times",
+		tag: "Schedules", permission: auth.PermissionRead, query: []openapi.Parameter{nextRunsParam},
+		responses: map[int]interface{}{200: ScheduleResponse{}, 400: ScheduleResponse{}, 404: ScheduleResponse{}}},
+	{method: http.MethodPut, path: "/api/v1/schedules/{id}", id: "updateSchedule", summary: "Replace a schedule's settings",
+		tag: "Schedules", permission: auth.PermissionExecute, query: []openapi.Parameter{nextRunsParam}, request: schedule.Spec{},
+		responses: map[int]interface{}{200: ScheduleResponse{}, 400: anyOf{ScheduleResponse{}, ErrorResponse{}}, 404: anyOf{ScheduleResponse{}, ErrorResponse{}}, 500: ScheduleResponse{}, 503: ScheduleResponse{}}},
+	{method: http.MethodDelete, path: "/api/v1/schedules/{id}", id: "deleteSchedule", summary: "Delete a schedule",
+		tag: "Schedules", permission: auth.PermissionExecute,
+		responses: map[int]interface{}{200: ScheduleResponse{}, 400: ScheduleResponse{}, 404: ScheduleResponse{}, 500: ScheduleResponse{}, 503: ScheduleResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/schedules/{id}/pause", id: "pauseSchedule", summary: "Stop a schedule from firing",
+		tag: "Schedules", permission: auth.PermissionExecute, query: []openapi.Parameter{nextRunsParam},
+		responses: map[int]interface{}{200: ScheduleResponse{}, 400: ScheduleResponse{}, 404: ScheduleResponse{}, 500: ScheduleResponse{}, 503: ScheduleResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/schedules/{id}/resume", id: "resumeSchedule", summary: "Let a paused schedule fire again from its next fire time",
+		tag: "Schedules", permission: auth.PermissionExecute, query: []openapi.Parameter{nextRunsParam},
+		responses: map[int]interface{}{200: ScheduleResponse{}, 400: ScheduleResponse{}, 404: ScheduleResponse{}, 500: ScheduleResponse{}, 503: ScheduleResponse{}}},
+
+	// Webhook triggers
+	{method: http.MethodGet, path: "/api/v1/triggers", id: "listTriggers", summary: "List webhook triggers",
+		tag: "Triggers", permission: auth.PermissionRead,
+		query:     []openapi.Parameter{queryParam("workflow_id", "string", "Only triggers of this workflow")},
+		responses: map[int]interface{}{200: ListTriggersResponse{}}},
+	{method: http.MethodPost, path: "/api/v1/triggers", id: "createTrigger", summary: "Expose a saved workflow at a webhook URL",
+		tag: "Triggers", permission: auth.PermissionAdmin, request: trigger.Spec{},
+		responses: map[int]interface{}{201: TriggerResponse{}, 400: anyOf{TriggerResponse{}, ErrorResponse{}}, 404: ErrorResponse{}, 500: TriggerResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/triggers/{id}", id: "getTrigger", summary: "Get a webhook trigger",
+		tag: "Triggers", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: TriggerResponse{}, 400: TriggerResponse{}, 404: TriggerResponse{}}},
+	{method: http.MethodPut, path: "/api/v1/triggers/{id}", id: "updateTrigger", summary: "Replace a webhook trigger's settings, keeping its URL",
+		tag: "Triggers", permission: auth.PermissionAdmin, request: trigger.Spec{},
+		responses: map[int]interface{}{200: TriggerResponse{}, 400: anyOf{TriggerResponse{}, ErrorResponse{}}, 404: anyOf{TriggerResponse{}, ErrorResponse{}}, 500: TriggerResponse{}}},
+	{method: http.MethodDelete, path: "/api/v1/triggers/{id}", id: "deleteTrigger", summary: "Delete a webhook trigger",
+		tag: "Triggers", permission: auth.PermissionAdmin,
+		responses: map[int]interface{}{200: TriggerResponse{}, 400: TriggerResponse{}, 404: TriggerResponse{}, 500: TriggerResponse{}}},
+
+	// Tenants
+	{method: http.MethodGet, path: "/api/v1/tenant", id: "getTenant", summary: "Get the caller's tenant, quota and usage",
+		tag: "Tenants", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: TenantResponse{}}},
+
+	// HTTP clients
+	{method: http.MethodPost, path: "/api/v1/httpclient/register", id: "registerHTTPClient", summary: "Register a named HTTP client",
+		tag: "HTTP Clients", permission: auth.PermissionAdmin, request: RegisterHTTPClientRequest{},
+		responses: map[int]interface{}{201: RegisterHTTPClientResponse{}, 400: anyOf{RegisterHTTPClientResponse{}, ErrorResponse{}}, 409: RegisterHTTPClientResponse{}}},
+	{method: http.MethodGet, path: "/api/v1/httpclient/list", id: "listHTTPClients", summary: "List registered HTTP clients",
+		tag: "HTTP Clients", permission: auth.PermissionRead,
+		responses: map[int]interface{}{200: ListHTTPClientsResponse{}}},
+
+	// Meta
+	{method: http.MethodGet, path: "/api/v1/openapi.json", id: "getOpenAPI", summary: "Get this OpenAPI document",
+		tag: "Meta", responses: map[int]interface{}{200: nil}},
+}
+
+// queryParam returns an optional query parameter
+func queryParam(name, typ, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: typ}}
+}
+
+// pathParamPattern matches the {name} parameters of an operation path
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// newOpenAPIGenerator returns a generator with the schemas of types whose
+// JSON encoding reflection cannot see
+func newOpenAPIGenerator() *openapi.Generator {
+	g := openapi.NewGenerator()
+	g.SetType(httpclient.SecureString{}, &openapi.Schema{Type: "string", Format: "password"})
+	g.SetType(runner.Status(""), enumSchema(runner.StatusQueued, runner.StatusRunning, runner.StatusPaused, runner.StatusSucceeded, runner.StatusFailed, runner.StatusCancelled))
+	g.SetType(history.Status(""), enumSchema(history.StatusRunning, history.StatusSucceeded, history.StatusFailed, history.StatusCancelled))
+	g.SetType(bundle.Action(""), enumSchema(bundle.ActionCreate, bundle.ActionOverwrite, bundle.ActionRename, bundle.ActionSkip))
+	g.SetType(schedule.MisfirePolicy(""), enumSchema(schedule.MisfireSkip, schedule.MisfireCatchUp))
+	g.SetType(schedule.RunStatus(""), enumSchema(schedule.RunRunning, schedule.RunSucceeded, schedule.RunFailed))
+	g.SetType(trigger.Mode(""), enumSchema(trigger.ModeSync, trigger.ModeAsync))
+	g.SetType(trigger.Delivery(""), enumSchema(trigger.DeliverContext, trigger.DeliverNode))
+
+	// Node data is decoded by node type
+	nodeTypes := types.NodeDataTypes()
+	names := make([]string, 0, len(nodeTypes))
+	for nodeType := range nodeTypes {
+		names = append(names, string(nodeType))
+	}
+	sort.Strings(names)
+	nodeData := &openapi.Schema{
+		Description: "Node data. Its schema is chosen by the node's type, see x-node-types; other types run custom executors.",
+		Extensions:  map[string]interface{}{},
+	}
+	mapping := make(map[string]string, len(names))
+	for _, name := range names {
+		ref := g.Schema(nodeTypes[types.NodeType(name)])
+		nodeData.AnyOf = append(nodeData.AnyOf, ref)
+		mapping[name] = ref.Ref
+	}
+	nodeData.AnyOf = append(nodeData.AnyOf, &openapi.Schema{Type: "object", Description: "Custom executor data"})
+	nodeData.Extensions["x-node-types"] = mapping
+	g.SetField(types.Node{}, "Data", g.Define("NodeData", nodeData))
+	g.SetOptional(types.Edge{}, "ID")
+
+	// Workflow payloads stored and passed around as raw JSON
+	payload := g.Schema(types.Payload{})
+	g.SetField(SaveWorkflowRequest{}, "Data", payload)
+	g.SetField(SubmitExecutionRequest{}, "Workflow", payload)
+	g.SetField(workflow.WorkflowMeta{}, "Data", payload)
+	g.SetField(workflow.WorkflowVersion{}, "Data", payload)
+	g.SetField(bundle.Workflow{}, "Data", payload)
+	g.SetField(bundle.Bundle{}, "HTTPClients", &openapi.Schema{
+		Type:        "array",
+		Description: "HTTP client configs with secrets replaced by ${secret:<name>} placeholders",
+		Items:       g.Schema(httpclient.Config{}),
+	})
+	return g
+}
+
+// enumSchema returns a string schema allowing only values
+func enumSchema[T ~string](values ...T) *openapi.Schema {
+	schema := &openapi.Schema{Type: "string"}
+	for _, v := range values {
+		schema.Enum = append(schema.Enum, string(v))
+	}
+	return schema
+}
+
+// buildOpenAPI returns the OpenAPI document of the API. With
+// authentication enabled, operations list the permission they need and
+// the credentials they accept.
+func buildOpenAPI(authEnabled bool) *openapi.Document {
+	g := newOpenAPIGenerator()
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Thaiyyal Workflow Engine API",
+			Description: "Execute, store and manage Thaiyyal workflows.",
+			Version:     serviceVersion,
+		},
+		Paths: make(map[string]openapi.PathItem),
+	}
+
+	if authEnabled {
+		doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+			"apiKey": {Type: "apiKey", In: "header", Name: auth.APIKeyHeader},
+			"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+		doc.Security = []openapi.SecurityRequirement{{"apiKey": {}}, {"bearer": {}}}
+	}
+
+	tags := make(map[string]bool)
+	for _, op := range apiOperations {
+		operation := &openapi.Operation{
+			OperationID: op.id,
+			Summary:     op.summary,
+			Tags:        []string{op.tag},
+			Responses:   make(map[string]*openapi.Response),
+		}
+		if !tags[op.tag] {
+			tags[op.tag] = true
+			doc.Tags = append(doc.Tags, openapi.Tag{Name: op.tag})
+		}
+
+		for _, m := range pathParamPattern.FindAllStringSubmatch(op.path, -1) {
+			typ := "string"
+			if m[1] == "version" {
+				typ = "integer"
+			}
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name: m[1], In: "path", Required: true, Schema: &openapi.Schema{Type: typ},
+			})
+		}
+		operation.Parameters = append(operation.Parameters, op.query...)
+
+		if op.request != nil {
+			operation.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(g.Schema(op.request))}
+		}
+
+		for status, body := range operationResponses(op) {
+			response := &openapi.Response{Description: http.StatusText(status)}
+			switch {
+			case body != nil:
+				response.Content = openapi.JSON(bodySchema(g, body))
+			case op.events:
+				response.Content = map[string]openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}}
+			case op.id == "getOpenAPI":
+				response.Content = openapi.JSON(&openapi.Schema{Type: "object"})
+			}
+			operation.Responses[strconv.Itoa(status)] = response
+		}
+
+		switch {
+		case op.permission == "":
+			if authEnabled {
+				operation.Security = []openapi.SecurityRequirement{{}}
+			}
+		case authEnabled:
+			operation.Extensions = map[string]interface{}{"x-permission": string(op.permission)}
+			unauthorized := &openapi.Response{Description: "Missing or invalid credentials", Content: openapi.JSON(g.Schema(ErrorResponse{}))}
+			forbidden := &openapi.Response{
+				Description: fmt.Sprintf("The caller lacks the %s permission", op.permission),
+				Content:     openapi.JSON(g.Schema(ErrorResponse{})),
+			}
+			operation.Responses["401"] = unauthorized
+			operation.Responses["403"] = forbidden
+		}
+
+		item, ok := doc.Paths[op.path]
+		if !ok {
+			item = make(openapi.PathItem)
+			doc.Paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	doc.Components.Schemas = g.Schemas()
+	return doc
+}
+
+// operationResponses returns the documented responses of op. Operations
+// behind require also fail with 500 when the caller's tenant cannot be
+// opened.
+func operationResponses(op apiOperation) map[int]interface{} {
+	if op.permission == "" {
+		return op.responses
+	}
+	responses := make(map[int]interface{}, len(op.responses)+1)
+	for status, body := range op.responses {
+		responses[status] = body
+	}
+	switch body := responses[http.StatusInternalServerError].(type) {
+	case nil:
+		responses[http.StatusInternalServerError] = ErrorResponse{}
+	case ErrorResponse, anyOf:
+	default:
+		responses[http.StatusInternalServerError] = anyOf{body, ErrorResponse{}}
+	}
+	return responses
+}
+
+// bodySchema returns the schema of a response body
+func bodySchema(g *openapi.Generator, body interface{}) *openapi.Schema {
+	bodies, ok := body.(anyOf)
+	if !ok {
+		return g.Schema(body)
+	}
+	schema := &openapi.Schema{}
+	for _, b := range bodies {
+		schema.AnyOf = append(schema.AnyOf, g.Schema(b))
+	}
+	return schema
+}
+
+// handleOpenAPI serves the OpenAPI document of the API
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeJSONResponse(w, http.StatusOK, s.openapi)
+}
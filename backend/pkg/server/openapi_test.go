@@ -0,0 +1,341 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/openapi"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// registeredAPIRoutes returns the /api/ patterns registerRoutes passes to
+// mux.HandleFunc, read from the source
+func registeredAPIRoutes(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "server.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patterns []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "HandleFunc" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		pattern, _ := strconv.Unquote(lit.Value)
+		if strings.HasPrefix(pattern, "/api/") {
+			patterns = append(patterns, pattern)
+		}
+		return true
+	})
+	return patterns
+}
+
+// operationFor returns the operation whose path template matches method
+// and the request path
+func operationFor(method, path string) *apiOperation {
+	for i := range apiOperations {
+		op := &apiOperations[i]
+		template := "^" + pathParamPattern.ReplaceAllString(op.path, `[^/]+`) + "$"
+		if op.method == method && regexp.MustCompile(template).MatchString(path) {
+			return op
+		}
+	}
+	return nil
+}
+
+func TestOpenAPIDrift(t *testing.T) {
+	// Every registered API route is documented and every documented path
+	// is served by a registered route
+	patterns := registeredAPIRoutes(t)
+	if len(patterns) == 0 {
+		t.Fatal("Found no API routes in server.go")
+	}
+	for _, pattern := range patterns {
+		documented := false
+		for _, op := range apiOperations {
+			if op.path == pattern || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(op.path, pattern)) {
+				documented = true
+			}
+		}
+		if !documented {
+			t.Errorf("Route %s has no operation in apiOperations", pattern)
+		}
+	}
+	for _, op := range apiOperations {
+		served := false
+		for _, pattern := range patterns {
+			if op.path == pattern || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(op.path, pattern)) {
+				served = true
+			}
+		}
+		if !served {
+			t.Errorf("Operation %s %s has no registered route", op.method, op.path)
+		}
+	}
+
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	handler := srv.httpServer.Handler
+
+	// Serve the document and check it round-trips
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode the OpenAPI document: %v", err)
+	}
+	if doc.OpenAPI != openapi.Version || len(doc.Paths) == 0 || doc.Components.Schemas["NodeData"] == nil {
+		t.Fatalf("Unexpected document: openapi %q, %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+	spec := srv.openapi
+
+	// Call every operation, checking request and response bodies against
+	// the schemas of the documented statuses
+	exercised := make(map[string]bool)
+	do := func(method, target, body string, expectedStatus int) []byte {
+		t.Helper()
+		path, _, _ := strings.Cut(target, "?")
+		op := operationFor(method, path)
+		if op == nil {
+			t.Fatalf("%s %s matches no documented operation", method, path)
+		}
+		operation := spec.Operation(method, op.path)
+		exercised[op.id] = true
+
+		if body != "" && expectedStatus < 300 {
+			if err := spec.Validate(operation.RequestBody.Content["application/json"].Schema, []byte(body)); err != nil {
+				t.Errorf("%s: request does not match the spec: %v", op.id, err)
+			}
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		if w.Code != expectedStatus {
+			t.Fatalf("%s %s returned %d, want %d: %s", method, target, w.Code, expectedStatus, w.Body.String())
+		}
+		response, ok := operation.Responses[strconv.Itoa(w.Code)]
+		if !ok {
+			t.Errorf("%s: status %d is not documented", op.id, w.Code)
+			return w.Body.Bytes()
+		}
+		contentType, _, _ := strings.Cut(w.Header().Get("Content-Type"), ";")
+		media, ok := response.Content[contentType]
+		if !ok {
+			t.Errorf("%s: content type %q of status %d is not documented", op.id, contentType, w.Code)
+			return w.Body.Bytes()
+		}
+		if contentType == "application/json" {
+			if err := spec.Validate(media.Schema, w.Body.Bytes()); err != nil {
+				t.Errorf("%s: status %d response does not match the spec: %v", op.id, w.Code, err)
+			}
+		}
+		return w.Body.Bytes()
+	}
+	decode := func(data []byte, v interface{}) {
+		t.Helper()
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	do(http.MethodGet, "/api/v1/openapi.json", "", http.StatusOK)
+
+	// Workflows
+	do(http.MethodPost, "/api/v1/workflow/execute", addWorkflow, http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/execute", `{"nodes": [`, http.StatusBadRequest)
+	do(http.MethodPost, "/api/v1/workflow/validate", addWorkflow, http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/validate", `{"nodes": [{"id": "1", "data": {"value": 1}}], "edges": [{"source": "1", "target": "2"}]}`, http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/plan", addWorkflow, http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/plan", `{"nodes": [{"id": "1", "data": {"value": 1}}], "edges": [{"source": "1", "target": "2"}]}`, http.StatusBadRequest)
+
+	var saved SaveWorkflowResponse
+	decode(do(http.MethodPost, "/api/v1/workflow/save", `{"name": "Add", "description": "Adds", "data": `+addWorkflow+`}`, http.StatusCreated), &saved)
+	id := saved.ID
+	do(http.MethodPost, "/api/v1/workflow/save", `{"id": "`+id+`", "name": "Add", "data": `+addWorkflow+`, "message": "Second"}`, http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/save", `{"data": `+addWorkflow+`}`, http.StatusBadRequest)
+	do(http.MethodGet, "/api/v1/workflow/list?q=add&sort=name", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/workflow/list?limit=x", "", http.StatusBadRequest)
+	do(http.MethodGet, "/api/v1/workflow/load/"+id, "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/workflow/load/missing", "", http.StatusNotFound)
+	do(http.MethodPost, "/api/v1/workflow/execute/"+id+"?version=1", `{"inputs": {}}`, http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/execute/missing", "", http.StatusNotFound)
+
+	// Versions
+	do(http.MethodGet, "/api/v1/workflow/versions/"+id, "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/workflow/versions/"+id+"/1", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/workflow/versions/"+id+"/9", "", http.StatusNotFound)
+	do(http.MethodGet, "/api/v1/workflow/diff/"+id, "", http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/rollback/"+id, `{"version": 1}`, http.StatusOK)
+
+	// Bundles
+	exported := do(http.MethodGet, "/api/v1/workflow/export/"+id, "", http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/import", `{"bundle": `+string(exported)+`, "policy": "rename", "dry_run": true}`, http.StatusOK)
+	do(http.MethodPost, "/api/v1/workflow/import", `{"bundle": `+string(exported)+`}`, http.StatusConflict)
+	do(http.MethodPost, "/api/v1/workflow/import", `{"bundle": `+string(exported)+`, "policy": "rename"}`, http.StatusCreated)
+
+	// HTTP clients and tenants
+	do(http.MethodPost, "/api/v1/httpclient/register", `{"config": {"uid": "crm", "auth": {"type": "bearer", "token": {"token": "s3cret"}}}}`, http.StatusCreated)
+	do(http.MethodPost, "/api/v1/httpclient/register", `{"config": {"uid": "crm"}}`, http.StatusConflict)
+	do(http.MethodGet, "/api/v1/httpclient/list", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/tenant", "", http.StatusOK)
+
+	// Executions
+	var submitted SubmitExecutionResponse
+	decode(do(http.MethodPost, "/api/v1/executions", `{"workflow": `+addWorkflow+`}`, http.StatusAccepted), &submitted)
+	do(http.MethodPost, "/api/v1/executions", `{}`, http.StatusBadRequest)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var resp ExecutionResponse
+		decode(do(http.MethodGet, "/api/v1/executions/"+submitted.ExecutionID, "", http.StatusOK), &resp)
+		if resp.Execution.Status.Finished() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Execution stuck in %s", resp.Execution.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	do(http.MethodGet, "/api/v1/executions", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/executions/missing", "", http.StatusNotFound)
+	do(http.MethodGet, "/api/v1/executions/"+submitted.ExecutionID+"/events", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/executions/"+submitted.ExecutionID+"/events?last_event_id=x", "", http.StatusBadRequest)
+	do(http.MethodGet, "/api/v1/executions/"+submitted.ExecutionID+"/profile", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/executions/"+submitted.ExecutionID+"/profile?format=x", "", http.StatusBadRequest)
+	do(http.MethodGet, "/api/v1/executions/missing/profile", "", http.StatusNotFound)
+	do(http.MethodDelete, "/api/v1/executions/"+submitted.ExecutionID, "", http.StatusConflict)
+
+	// Debugging
+	var debugged SubmitExecutionResponse
+	decode(do(http.MethodPost, "/api/v1/executions", `{"workflow": `+addWorkflow+`, "debug": {"pause_on_start": true}}`, http.StatusAccepted), &debugged)
+	debugPath := "/api/v1/executions/" + debugged.ExecutionID + "/debug"
+	for {
+		var resp DebugResponse
+		decode(do(http.MethodGet, debugPath, "", http.StatusOK), &resp)
+		if resp.Debug.Paused {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Debugged execution did not pause")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	do(http.MethodPost, debugPath, `{"command": "rewind"}`, http.StatusBadRequest)
+	do(http.MethodPost, debugPath, `{"command": "set_variable", "name": "x", "value": 1}`, http.StatusOK)
+	do(http.MethodPost, debugPath, `{"command": "snapshot"}`, http.StatusOK)
+	do(http.MethodPost, debugPath, `{"command": "continue"}`, http.StatusOK)
+	do(http.MethodGet, "/api/v1/executions/"+submitted.ExecutionID+"/debug", "", http.StatusConflict)
+	do(http.MethodGet, "/api/v1/executions/missing/debug", "", http.StatusNotFound)
+
+	// History
+	do(http.MethodGet, "/api/v1/history?workflow_id="+id+"&status=succeeded", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/history?from=yesterday", "", http.StatusBadRequest)
+	do(http.MethodGet, "/api/v1/history/"+submitted.ExecutionID, "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/history/missing", "", http.StatusNotFound)
+
+	// Schedules
+	var created ScheduleResponse
+	decode(do(http.MethodPost, "/api/v1/schedules", `{"workflow_id": "`+id+`", "cron": "0 9 * * mon-fri", "timezone": "Europe/Paris"}`, http.StatusCreated), &created)
+	scheduleID := created.Schedule.ID
+	do(http.MethodPost, "/api/v1/schedules", `{"workflow_id": "`+id+`", "cron": "0 25 * * *"}`, http.StatusBadRequest)
+	do(http.MethodPost, "/api/v1/schedules", `{"workflow_id": "missing", "cron": "@daily"}`, http.StatusNotFound)
+	do(http.MethodGet, "/api/v1/schedules?workflow_id="+id, "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/schedules/"+scheduleID+"?next=3", "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/schedules/"+scheduleID+"?next=x", "", http.StatusBadRequest)
+	do(http.MethodGet, "/api/v1/schedules/missing", "", http.StatusNotFound)
+	do(http.MethodPut, "/api/v1/schedules/"+scheduleID, `{"workflow_id": "`+id+`", "version": 1, "cron": "@hourly", "misfire_policy": "catch_up"}`, http.StatusOK)
+	do(http.MethodPost, "/api/v1/schedules/"+scheduleID+"/pause", "", http.StatusOK)
+	do(http.MethodPost, "/api/v1/schedules/"+scheduleID+"/resume", "", http.StatusOK)
+	do(http.MethodDelete, "/api/v1/schedules/"+scheduleID, "", http.StatusOK)
+	do(http.MethodDelete, "/api/v1/schedules/"+scheduleID, "", http.StatusNotFound)
+
+	// Webhook triggers
+	var hook TriggerResponse
+	decode(do(http.MethodPost, "/api/v1/triggers", `{"workflow_id": "`+id+`", "mode": "async", "secret": "s3cret"}`, http.StatusCreated), &hook)
+	triggerID := hook.Trigger.ID
+	do(http.MethodPost, "/api/v1/triggers", `{"workflow_id": "`+id+`", "mode": "later"}`, http.StatusBadRequest)
+	do(http.MethodPost, "/api/v1/triggers", `{"workflow_id": "missing"}`, http.StatusNotFound)
+	do(http.MethodGet, "/api/v1/triggers?workflow_id="+id, "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/triggers/"+triggerID, "", http.StatusOK)
+	do(http.MethodGet, "/api/v1/triggers/missing", "", http.StatusNotFound)
+	do(http.MethodPut, "/api/v1/triggers/"+triggerID, `{"workflow_id": "`+id+`", "methods": ["PUT"]}`, http.StatusOK)
+	do(http.MethodDelete, "/api/v1/triggers/"+triggerID, "", http.StatusOK)
+	do(http.MethodDelete, "/api/v1/triggers/"+triggerID, "", http.StatusNotFound)
+
+	do(http.MethodDelete, "/api/v1/workflow/delete/"+id, "", http.StatusOK)
+	do(http.MethodDelete, "/api/v1/workflow/delete/"+id, "", http.StatusNotFound)
+
+	for _, op := range apiOperations {
+		if !exercised[op.id] {
+			t.Errorf("Operation %s is not exercised by this test", op.id)
+		}
+	}
+}
+
+func TestOpenAPIPermissions(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	err := os.WriteFile(keysFile, []byte(`{"keys": [{"name": "viewer", "key": "viewer-key", "roles": ["viewer"]}]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Auth = auth.Config{APIKeysFile: keysFile}
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	handler := srv.httpServer.Handler
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("The OpenAPI document should be public, got %d", w.Code)
+	}
+	if srv.openapi.Components.SecuritySchemes["apiKey"] == nil {
+		t.Error("Expected an apiKey security scheme")
+	}
+
+	// A viewer is refused exactly the operations documented with a
+	// permission other than read
+	for _, op := range apiOperations {
+		path := pathParamPattern.ReplaceAllString(op.path, "1")
+		req := httptest.NewRequest(op.method, path, strings.NewReader("{}"))
+		req.Header.Set(auth.APIKeyHeader, "viewer-key")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		operation := srv.openapi.Operation(op.method, op.path)
+		permission, _ := operation.Extensions["x-permission"].(string)
+		if want := op.permission != "" && op.permission != auth.PermissionRead; (w.Code == http.StatusForbidden) != want {
+			t.Errorf("%s: viewer got %d, documented permission %q", op.id, w.Code, permission)
+		}
+		if op.permission != "" && permission != string(op.permission) {
+			t.Errorf("%s: x-permission = %q, want %q", op.id, permission, op.permission)
+		}
+	}
+}
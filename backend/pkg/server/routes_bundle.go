@@ -0,0 +1,147 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/bundle"
+)
+
+// ImportWorkflowRequest represents the request to import a workflow bundle.
+// Author is ignored when authentication is enabled.
+type ImportWorkflowRequest struct {
+	Bundle  *bundle.Bundle    `json:"bundle"`
+	Policy  string            `json:"policy,omitempty"`
+	Secrets map[string]string `json:"secrets,omitempty"`
+	DryRun  bool              `json:"dry_run,omitempty"`
+	Author  string            `json:"author,omitempty"`
+}
+
+// ImportWorkflowResponse represents the response from importing a bundle
+type ImportWorkflowResponse struct {
+	Success bool           `json:"success"`
+	Result  *bundle.Result `json:"result,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// handleExportWorkflow handles exporting a workflow with the HTTP clients it
+// references as a bundle. Secrets in client configs are replaced by
+// placeholders.
+//
+// Path format: /api/v1/workflow/export/{id}?version=N
+func (s *Server) handleExportWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/v1/workflow/export/"))
+	if id == "" {
+		s.writeJSONResponse(w, http.StatusBadRequest, ImportWorkflowResponse{
+			Success: false,
+			Error:   "Workflow ID is required",
+		})
+		return
+	}
+
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		var err error
+		if version, err = parseVersion(v); err != nil {
+			s.writeJSONResponse(w, http.StatusBadRequest, ImportWorkflowResponse{Success: false, Error: err.Error()})
+			return
+		}
+	}
+
+	scope := s.scope(r)
+	b, err := bundle.Export(scope.workflows, scope.httpClients, id, version, s.redactor)
+	if err != nil {
+		s.writeJSONResponse(w, workflowErrorStatus(err), ImportWorkflowResponse{
+			Success: false,
+			Error:   "Failed to export workflow: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="workflow-%s-v%d.json"`, id, b.Workflow.Version))
+	s.writeJSONResponse(w, http.StatusOK, b)
+}
+
+// handleImportWorkflow handles importing a workflow bundle. Bundles that
+// carry HTTP client configs also need the admin permission, like
+// registering clients directly.
+func (s *Server) handleImportWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Limit request body size
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest, err)
+		return
+	}
+
+	var req ImportWorkflowRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.writeErrorResponse(w, "Failed to parse request", http.StatusBadRequest, err)
+		return
+	}
+	if req.Bundle == nil {
+		s.writeJSONResponse(w, http.StatusBadRequest, ImportWorkflowResponse{
+			Success: false,
+			Error:   "bundle is required",
+		})
+		return
+	}
+	if len(req.Bundle.HTTPClients) > 0 && !s.authorize(w, r, auth.PermissionAdmin) {
+		return
+	}
+
+	policy, err := bundle.ParsePolicy(req.Policy)
+	if err != nil {
+		s.writeJSONResponse(w, http.StatusBadRequest, ImportWorkflowResponse{Success: false, Error: err.Error()})
+		return
+	}
+
+	scope := s.scope(r)
+	result, err := bundle.Import(r.Context(), req.Bundle, scope.workflows, scope.httpClients, bundle.Options{
+		Policy:  policy,
+		Secrets: req.Secrets,
+		DryRun:  req.DryRun,
+		Author:  s.author(r, req.Author),
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, bundle.ErrConflict) {
+			status = http.StatusConflict
+		}
+		s.writeJSONResponse(w, status, ImportWorkflowResponse{
+			Success: false,
+			Error:   "Failed to import workflow: " + err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusCreated
+	if result.DryRun {
+		status = http.StatusOK
+	} else {
+		s.logger.WithField("id", result.Workflow.ID).
+			WithField("action", string(result.Workflow.Action)).
+			WithField("http_clients", len(result.HTTPClients)).
+			Info("Workflow imported")
+	}
+	s.writeJSONResponse(w, status, ImportWorkflowResponse{
+		Success: true,
+		Result:  result,
+	})
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/bundle"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestWorkflowBundleEndpoints(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.httpServer.Handler
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/httpclient/register",
+		`{"config": {"uid": "crm", "auth": {"type": "bearer", "token": {"token": "s3cret"}}}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Register returned %d: %s", w.Code, w.Body.String())
+	}
+	data := `{"nodes": [{"id": "1", "type": "http", "data": {"url": "https://crm.example.com", "http_client_uid": "crm"}}], "edges": []}`
+	w = do(http.MethodPost, "/api/v1/workflow/save", `{"name": "CRM", "data": `+data+`}`)
+	var saved SaveWorkflowResponse
+	if err := json.NewDecoder(w.Body).Decode(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// Export
+	w = do(http.MethodGet, "/api/v1/workflow/export/"+saved.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Export returned %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("Missing Content-Disposition header")
+	}
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Errorf("Exported bundle contains the token: %s", w.Body.String())
+	}
+	exported := w.Body.String()
+
+	if w := do(http.MethodGet, "/api/v1/workflow/export/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Exporting a missing workflow returned %d, want 404", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/v1/workflow/export/"+saved.ID+"?version=x", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid version returned %d, want 400", w.Code)
+	}
+
+	// Import
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedAction bundle.Action
+	}{
+		{"Missing bundle", `{}`, http.StatusBadRequest, ""},
+		{"Missing secret", `{"bundle": ` + exported + `, "policy": "rename"}`, http.StatusBadRequest, ""},
+		{"Invalid policy", `{"bundle": ` + exported + `, "policy": "merge"}`, http.StatusBadRequest, ""},
+		{"Conflict", `{"bundle": ` + exported + `, "secrets": {"crm.token": "t"}}`, http.StatusConflict, ""},
+		{"Dry run", `{"bundle": ` + exported + `, "policy": "rename", "secrets": {"crm.token": "t"}, "dry_run": true}`, http.StatusOK, bundle.ActionRename},
+		{"Rename", `{"bundle": ` + exported + `, "policy": "rename", "secrets": {"crm.token": "t"}}`, http.StatusCreated, bundle.ActionRename},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(http.MethodPost, "/api/v1/workflow/import", tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			var resp ImportWorkflowResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if tt.expectedAction != "" && resp.Result.Workflow.Action != tt.expectedAction {
+				t.Errorf("Workflow action = %q, want %q", resp.Result.Workflow.Action, tt.expectedAction)
+			}
+		})
+	}
+
+	if n := srv.defaultScope().workflows.Count(); n != 2 {
+		t.Errorf("Expected 2 workflows after the import, got %d", n)
+	}
+	if !srv.defaultScope().httpClients.Has("crm-2") {
+		t.Error("Expected the renamed crm-2 client to be registered")
+	}
+}
@@ -0,0 +1,599 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/events"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/runner"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// SubmitExecutionRequest starts an asynchronous execution of a saved
+// workflow (WorkflowID, with optional Version and Inputs), an inline
+// workflow payload (Workflow) or the execution saved in a debug snapshot
+// (Snapshot), which resumes after the nodes it completed. Debug runs the
+// execution under the debugger.
+type SubmitExecutionRequest struct {
+	WorkflowID string                 `json:"workflow_id,omitempty"`
+	Version    int                    `json:"version,omitempty"`
+	Inputs     map[string]interface{} `json:"inputs,omitempty"`
+	Workflow   json.RawMessage        `json:"workflow,omitempty"`
+	Snapshot   json.RawMessage        `json:"snapshot,omitempty"`
+	Debug      *DebugOptions          `json:"debug,omitempty"`
+}
+
+// DebugOptions run an asynchronous execution under the debugger, which
+// pauses it before its breakpoint nodes. When resuming a snapshot without
+// breakpoints, the snapshot's breakpoints are used.
+type DebugOptions struct {
+	Breakpoints    []string `json:"breakpoints,omitempty"`
+	PauseOnStart   bool     `json:"pause_on_start,omitempty"`  // Pause before the first node
+	SessionTimeout string   `json:"session_timeout,omitempty"` // How long a pause waits for a command, such as "5m"
+}
+
+// DebugCommandRequest controls a paused execution. Command is one of
+// step, continue, set_variable (with Name and Value) or snapshot.
+type DebugCommandRequest struct {
+	Command string      `json:"command"`
+	Name    string      `json:"name,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+// DebugResponse represents the debug state of an execution, and the
+// snapshot requested with the snapshot command
+type DebugResponse struct {
+	Success     bool               `json:"success"`
+	ExecutionID string             `json:"execution_id,omitempty"`
+	Debug       *engine.DebugState `json:"debug,omitempty"`
+	Snapshot    *engine.Snapshot   `json:"snapshot,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// Debug commands accepted by DebugCommandRequest
+const (
+	debugStep        = "step"
+	debugContinue    = "continue"
+	debugSetVariable = "set_variable"
+	debugSnapshot    = "snapshot"
+)
+
+// SubmitExecutionResponse represents the response from submitting an execution
+type SubmitExecutionResponse struct {
+	Success         bool          `json:"success"`
+	ExecutionID     string        `json:"execution_id,omitempty"`
+	Status          runner.Status `json:"status,omitempty"`
+	WorkflowID      string        `json:"workflow_id,omitempty"`
+	WorkflowVersion int           `json:"workflow_version,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// ExecutionResponse represents the response from fetching or cancelling an
+// execution
+type ExecutionResponse struct {
+	Success   bool              `json:"success"`
+	Execution *runner.Execution `json:"execution,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// ExecutionProfileResponse represents the response from fetching an
+// execution's profile
+type ExecutionProfileResponse struct {
+	Success     bool           `json:"success"`
+	ExecutionID string         `json:"execution_id,omitempty"`
+	Profile     *types.Profile `json:"profile,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// ListExecutionsResponse represents the response from listing executions
+type ListExecutionsResponse struct {
+	Success    bool               `json:"success"`
+	Executions []runner.Execution `json:"executions"`
+	Count      int                `json:"count"`
+}
+
+// handleExecutions handles submitting (POST) and listing (GET) executions
+func (s *Server) handleExecutions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handleSubmitExecution(w, r)
+	case http.MethodGet:
+		id := s.scope(r).id
+		executions := []runner.Execution{}
+		for _, exec := range s.runner.List() {
+			if exec.TenantID == id {
+				executions = append(executions, exec)
+			}
+		}
+		s.writeJSONResponse(w, http.StatusOK, ListExecutionsResponse{
+			Success:    true,
+			Executions: executions,
+			Count:      len(executions),
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSubmitExecution queues a workflow and returns its execution ID
+// without waiting for it to run
+func (s *Server) handleSubmitExecution(w http.ResponseWriter, r *http.Request) {
+	// Limit request body size
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest, err)
+		return
+	}
+
+	var req SubmitExecutionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.writeErrorResponse(w, "Failed to parse request", http.StatusBadRequest, err)
+		return
+	}
+
+	req.WorkflowID = strings.TrimSpace(req.WorkflowID)
+	hasInline := len(req.Workflow) > 0 && string(req.Workflow) != "null"
+	hasSnapshot := len(req.Snapshot) > 0 && string(req.Snapshot) != "null"
+	sources := 0
+	for _, set := range []bool{req.WorkflowID != "", hasInline, hasSnapshot} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		s.writeErrorResponse(w, "Invalid execution request", http.StatusBadRequest,
+			fmt.Errorf("exactly one of workflow_id, workflow and snapshot is required"))
+		return
+	}
+	if req.Version < 0 {
+		s.writeErrorResponse(w, "Invalid workflow version", http.StatusBadRequest,
+			fmt.Errorf("version must be a positive integer, got %d", req.Version))
+		return
+	}
+
+	scope := s.scope(r)
+	payload := []byte(req.Workflow)
+	versionNumber := 0
+	if req.WorkflowID != "" {
+		bound, version, ok := s.bindSavedWorkflow(w, scope.workflows, req.WorkflowID, req.Version, req.Inputs)
+		if !ok {
+			return
+		}
+		payload, versionNumber = bound, version.Version
+	}
+
+	var (
+		eng      *engine.Engine
+		snapshot *engine.Snapshot
+	)
+	if hasSnapshot {
+		if snapshot, err = engine.DeserializeSnapshot(req.Snapshot); err != nil {
+			s.writeErrorResponse(w, "Failed to create engine", http.StatusBadRequest, err)
+			return
+		}
+		// The snapshot's workflow ID is recorded in the history, metrics
+		// and logs, so it must name a workflow of the caller's tenant
+		if snapshot.WorkflowID != "" {
+			if _, err := savedWorkflowVersion(scope.workflows, snapshot.WorkflowID, 0); err != nil {
+				s.writeErrorResponse(w, "Failed to load workflow", workflowErrorStatus(err), err)
+				return
+			}
+		}
+		eng, err = s.resumeEngine(scope, snapshot)
+	} else {
+		eng, err = s.newEngine(scope, payload, req.WorkflowID, versionNumber)
+	}
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to create engine", http.StatusBadRequest, err)
+		return
+	}
+	if req.Debug != nil {
+		opts, err := s.debugOptions(req.Debug, snapshot)
+		if err != nil {
+			s.writeErrorResponse(w, "Invalid debug options", http.StatusBadRequest, err)
+			return
+		}
+		eng.SetDebug(opts)
+	}
+	workflowID := req.WorkflowID
+	if snapshot != nil {
+		workflowID = snapshot.WorkflowID
+	}
+
+	// The quota slot is held until the execution finishes
+	release, ok := s.acquireQuota(w, scope)
+	if !ok {
+		return
+	}
+	exec, err := s.runner.Submit(eng, runner.SubmitOptions{
+		WorkflowID: workflowID,
+		OnFinish:   release,
+		Context:    traceContext(r.Context(), r),
+	})
+	if err != nil {
+		release()
+		s.writeErrorResponse(w, "Failed to submit execution", http.StatusServiceUnavailable, err)
+		return
+	}
+
+	s.logger.WithField("execution_id", exec.ID).WithField("workflow_id", workflowID).Info("Execution submitted")
+
+	w.Header().Set("Location", "/api/v1/executions/"+exec.ID)
+	s.writeJSONResponse(w, http.StatusAccepted, SubmitExecutionResponse{
+		Success:         true,
+		ExecutionID:     exec.ID,
+		Status:          exec.Status,
+		WorkflowID:      workflowID,
+		WorkflowVersion: versionNumber,
+	})
+}
+
+// debugOptions converts the debug options of a request. A session
+// timeout may not exceed the server's MaxDebugSessionTimeout, which is
+// also the default.
+func (s *Server) debugOptions(req *DebugOptions, snapshot *engine.Snapshot) (engine.DebugOptions, error) {
+	limit := s.config.MaxDebugSessionTimeout
+	if limit <= 0 {
+		limit = engine.DefaultDebugSessionTimeout
+	}
+	opts := engine.DebugOptions{
+		Breakpoints:    req.Breakpoints,
+		PauseOnStart:   req.PauseOnStart,
+		SessionTimeout: limit,
+	}
+	if len(opts.Breakpoints) == 0 && snapshot != nil {
+		opts.Breakpoints = snapshot.Breakpoints
+	}
+	if req.SessionTimeout != "" {
+		timeout, err := time.ParseDuration(req.SessionTimeout)
+		if err != nil || timeout <= 0 {
+			return opts, fmt.Errorf("session_timeout must be a positive duration such as 5m, got %q", req.SessionTimeout)
+		}
+		if timeout > limit {
+			return opts, fmt.Errorf("session_timeout %v exceeds the limit of %v", timeout, limit)
+		}
+		opts.SessionTimeout = timeout
+	}
+	return opts, nil
+}
+
+// handleExecution returns (GET) or cancels (DELETE) one execution, or
+// streams its events, returns its profile or controls its debugger.
+// Path format: /api/v1/executions/{id}, /api/v1/executions/{id}/events,
+// /api/v1/executions/{id}/profile or /api/v1/executions/{id}/debug
+func (s *Server) handleExecution(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/v1/executions/"))
+	if streamID, ok := strings.CutSuffix(id, "/events"); ok {
+		s.handleExecutionEvents(w, r, streamID)
+		return
+	}
+	if profileID, ok := strings.CutSuffix(id, "/profile"); ok {
+		s.handleExecutionProfile(w, r, profileID)
+		return
+	}
+	if debugID, ok := strings.CutSuffix(id, "/debug"); ok {
+		s.handleExecutionDebug(w, r, debugID)
+		return
+	}
+	if id == "" || strings.Contains(id, "/") {
+		s.writeJSONResponse(w, http.StatusBadRequest, ExecutionResponse{
+			Success: false,
+			Error:   "Execution ID is required",
+		})
+		return
+	}
+
+	var (
+		exec runner.Execution
+		err  error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		exec, err = s.getExecution(r, id)
+	case http.MethodDelete:
+		if _, err = s.getExecution(r, id); err == nil {
+			exec, err = s.runner.Cancel(id)
+		}
+		if err == nil {
+			s.logger.WithField("execution_id", id).Info("Execution cancelled")
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, runner.ErrExecutionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, runner.ErrExecutionFinished):
+			status = http.StatusConflict
+		}
+		s.writeJSONResponse(w, status, ExecutionResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, ExecutionResponse{
+		Success:   true,
+		Execution: &exec,
+	})
+}
+
+// getExecution returns the execution with the given ID if it belongs to
+// the caller's tenant. Other tenants' executions are reported as missing.
+func (s *Server) getExecution(r *http.Request, id string) (runner.Execution, error) {
+	exec, err := s.runner.Get(id)
+	if err != nil {
+		return exec, err
+	}
+	if exec.TenantID != s.scope(r).id {
+		return runner.Execution{}, fmt.Errorf("%w: %s", runner.ErrExecutionNotFound, id)
+	}
+	return exec, nil
+}
+
+// handleExecutionProfile returns a finished execution's profile: each
+// node's wall time, HTTP wait and loop iterations, and the critical path.
+// With ?format=folded it is written as folded stacks for flame graph tools.
+func (s *Server) handleExecutionProfile(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	exec, err := s.getExecution(r, id)
+	if err == nil && exec.Profile == nil {
+		err = fmt.Errorf("execution %s has no profile: it has not finished or profiling is disabled", id)
+	}
+	if err != nil {
+		s.writeJSONResponse(w, http.StatusNotFound, ExecutionProfileResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		s.writeJSONResponse(w, http.StatusOK, ExecutionProfileResponse{
+			Success:     true,
+			ExecutionID: exec.ID,
+			Profile:     exec.Profile,
+		})
+	case "folded":
+		root := exec.WorkflowID
+		if root == "" {
+			root = exec.ID
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err := exec.Profile.WriteFolded(w, root); err != nil {
+			s.logger.WithError(err).Error("failed to write execution profile")
+		}
+	default:
+		s.writeJSONResponse(w, http.StatusBadRequest, ExecutionProfileResponse{
+			Success: false,
+			Error:   fmt.Sprintf("unknown profile format %q: use json or folded", format),
+		})
+	}
+}
+
+// handleExecutionDebug returns the debug state of an execution started
+// with debug options (GET), or runs a debugger command on it (POST): step,
+// continue, set_variable or snapshot. Commands other than snapshot
+// respond with the state after the command. State is redacted but a
+// snapshot holds raw results and variables, so snapshot needs admin.
+func (s *Server) handleExecutionDebug(w http.ResponseWriter, r *http.Request, id string) {
+	var req DebugCommandRequest
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONResponse(w, http.StatusBadRequest, DebugResponse{
+				Success: false,
+				Error:   fmt.Sprintf("invalid debug command: %v", err),
+			})
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Command == debugSnapshot && !s.authorize(w, r, auth.PermissionAdmin) {
+		return
+	}
+
+	_, err := s.getExecution(r, id)
+	var debugger *engine.Debugger
+	if err == nil {
+		debugger, err = s.runner.Debugger(id)
+	}
+
+	var snapshot *engine.Snapshot
+	if err == nil {
+		switch req.Command {
+		case "":
+			if r.Method == http.MethodPost {
+				err = fmt.Errorf("command is required: step, continue, set_variable or snapshot")
+			}
+		case debugStep:
+			err = debugger.Step()
+		case debugContinue:
+			err = debugger.Continue()
+		case debugSetVariable:
+			err = debugger.SetVariable(req.Name, req.Value)
+		case debugSnapshot:
+			snapshot, err = debugger.Snapshot()
+		default:
+			err = fmt.Errorf("unknown debug command %q: use step, continue, set_variable or snapshot", req.Command)
+		}
+	}
+
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, runner.ErrExecutionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, runner.ErrExecutionFinished), errors.Is(err, runner.ErrNotDebugged),
+			errors.Is(err, engine.ErrDebugNotPaused):
+			status = http.StatusConflict
+		}
+		s.writeJSONResponse(w, status, DebugResponse{
+			Success:     false,
+			ExecutionID: id,
+			Error:       err.Error(),
+		})
+		return
+	}
+
+	if req.Command != "" {
+		s.logger.WithField("execution_id", id).WithField("command", req.Command).Info("Debug command")
+	}
+	if snapshot != nil {
+		s.writeJSONResponse(w, http.StatusOK, DebugResponse{
+			Success:     true,
+			ExecutionID: id,
+			Snapshot:    snapshot,
+		})
+		return
+	}
+	state := debugger.State()
+	s.writeJSONResponse(w, http.StatusOK, DebugResponse{
+		Success:     true,
+		ExecutionID: id,
+		Debug:       &state,
+	})
+}
+
+// sseKeepAlive is how often an idle event stream sends a comment so
+// proxies keep the connection open
+var sseKeepAlive = 15 * time.Second
+
+// handleExecutionEvents streams an execution's events as Server-Sent Events.
+// Buffered events are replayed first, starting after the Last-Event-ID header
+// or ?last_event_id= when given. The stream ends with an "end" event carrying
+// the execution's final status. A client that falls too far behind receives
+// a "lagged" event and is disconnected; it can reconnect with the last ID it
+// received to resume.
+func (s *Server) handleExecutionEvents(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := s.getExecution(r, id); err != nil {
+		s.writeJSONResponse(w, http.StatusNotFound, ExecutionResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	stream, err := s.runner.Events(id)
+	if err != nil {
+		s.writeJSONResponse(w, http.StatusNotFound, ExecutionResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var after uint64
+	if lastID != "" {
+		if after, err = strconv.ParseUint(lastID, 10, 64); err != nil {
+			s.writeJSONResponse(w, http.StatusBadRequest, ExecutionResponse{
+				Success: false,
+				Error:   fmt.Sprintf("invalid last event ID %q", lastID),
+			})
+			return
+		}
+	}
+
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	sub := stream.Subscribe(after, events.DefaultSubscriberSize)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, eventID uint64, data interface{}) bool {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			s.logger.WithError(err).WithField("execution_id", id).WithField("event_id", eventID).Error("failed to encode event")
+			return true
+		}
+		if eventID > 0 {
+			fmt.Fprintf(w, "id: %d\n", eventID)
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		return rc.Flush() == nil
+	}
+
+	if sub.Missed > 0 && !send("replay_truncated", 0, map[string]uint64{"missed": sub.Missed}) {
+		return
+	}
+	for _, msg := range sub.Replay {
+		if !send(string(msg.Type), msg.ID, msg) {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			if rc.Flush() != nil {
+				return
+			}
+		case msg, ok := <-sub.C:
+			if ok {
+				if !send(string(msg.Type), msg.ID, msg) {
+					return
+				}
+				continue
+			}
+			if sub.Lagged() {
+				send("lagged", 0, map[string]string{"error": "client fell behind; reconnect with Last-Event-ID to resume"})
+				return
+			}
+			exec, err := s.runner.Get(id)
+			if err != nil {
+				return
+			}
+			send("end", 0, map[string]interface{}{"status": exec.Status, "error": exec.Error})
+			return
+		}
+	}
+}
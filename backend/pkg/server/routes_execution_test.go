@@ -0,0 +1,395 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/runner"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+const delayWorkflow = `{"nodes": [{"id": "1", "data": {"value": 1}}, {"id": "wait", "data": {"duration": "2s"}}], "edges": [{"source": "1", "target": "wait"}]}`
+
+func TestExecutionsEndpoints(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	handler := srv.httpServer.Handler
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	submit := func(body string) SubmitExecutionResponse {
+		t.Helper()
+		w := do(http.MethodPost, "/api/v1/executions", body)
+		var resp SubmitExecutionResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusAccepted || resp.ExecutionID == "" {
+			t.Fatalf("Submit returned %d %+v, want 202 with an execution ID", w.Code, resp)
+		}
+		if got := w.Header().Get("Location"); got != "/api/v1/executions/"+resp.ExecutionID {
+			t.Errorf("Location = %q", got)
+		}
+		return resp
+	}
+	poll := func(id string, done func(runner.Status) bool) runner.Execution {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			w := do(http.MethodGet, "/api/v1/executions/"+id, "")
+			var resp ExecutionResponse
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			if w.Code != http.StatusOK || resp.Execution == nil {
+				t.Fatalf("Get execution returned %d %+v", w.Code, resp)
+			}
+			if done(resp.Execution.Status) {
+				return *resp.Execution
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Execution %s stuck in %s", id, resp.Execution.Status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// Saved workflow with inputs
+	id, err := srv.defaultScope().workflows.Register("Adder", "", json.RawMessage(`{
+		"inputs": [{"name": "a", "type": "number", "required": true, "node": "1"}],
+		"nodes": [{"id": "1", "data": {"value": 0}}, {"id": "2", "data": {"value": 5}}, {"id": "3", "data": {"op": "add"}}],
+		"edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	saved := submit(`{"workflow_id": "` + id + `", "inputs": {"a": 10}}`)
+	if saved.WorkflowVersion != 1 {
+		t.Errorf("workflow_version = %d, want 1", saved.WorkflowVersion)
+	}
+	exec := poll(saved.ExecutionID, runner.Status.Finished)
+	if exec.Status != runner.StatusSucceeded || exec.Result == nil || exec.Result.FinalOutput != 15.0 {
+		t.Fatalf("Execution = %+v, want succeeded with output 15", exec)
+	}
+	if len(exec.Nodes) != 3 {
+		t.Errorf("Nodes = %+v, want progress for 3 nodes", exec.Nodes)
+	}
+
+	// Profile, as JSON and as folded stacks
+	w := do(http.MethodGet, "/api/v1/executions/"+saved.ExecutionID+"/profile", "")
+	var profile ExecutionProfileResponse
+	_ = json.NewDecoder(w.Body).Decode(&profile)
+	if w.Code != http.StatusOK || profile.Profile == nil || len(profile.Profile.CriticalPath) == 0 {
+		t.Fatalf("Profile returned %d %+v, want a profile with a critical path", w.Code, profile)
+	}
+	if exec.Result.Profile == nil {
+		t.Error("Result has no profile")
+	}
+	w = do(http.MethodGet, "/api/v1/executions/"+saved.ExecutionID+"/profile?format=folded", "")
+	if w.Code != http.StatusOK || !strings.Contains("\n"+w.Body.String(), "\n"+id+";") {
+		t.Errorf("Folded profile returned %d: %q", w.Code, w.Body.String())
+	}
+
+	// Inline workflow, cancelled while running
+	running := submit(`{"workflow": ` + delayWorkflow + `}`)
+	poll(running.ExecutionID, func(s runner.Status) bool { return s == runner.StatusRunning })
+	if w := do(http.MethodDelete, "/api/v1/executions/"+running.ExecutionID, ""); w.Code != http.StatusOK {
+		t.Fatalf("Cancel returned %d: %s", w.Code, w.Body.String())
+	}
+	if exec := poll(running.ExecutionID, runner.Status.Finished); exec.Status != runner.StatusCancelled {
+		t.Errorf("Status after cancel = %s, want cancelled", exec.Status)
+	}
+	if w := do(http.MethodDelete, "/api/v1/executions/"+running.ExecutionID, ""); w.Code != http.StatusConflict {
+		t.Errorf("Cancelling a finished execution returned %d, want 409", w.Code)
+	}
+
+	// Listing
+	w = do(http.MethodGet, "/api/v1/executions", "")
+	var list ListExecutionsResponse
+	_ = json.NewDecoder(w.Body).Decode(&list)
+	if w.Code != http.StatusOK || list.Count != 2 {
+		t.Errorf("List returned %d %+v, want 2 executions", w.Code, list)
+	}
+
+	// Errors
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Unknown execution", http.MethodGet, "/api/v1/executions/missing", "", http.StatusNotFound},
+		{"Profile of unknown execution", http.MethodGet, "/api/v1/executions/missing/profile", "", http.StatusNotFound},
+		{"Unknown profile format", http.MethodGet, "/api/v1/executions/" + saved.ExecutionID + "/profile?format=pprof", "", http.StatusBadRequest},
+		{"Cancel unknown execution", http.MethodDelete, "/api/v1/executions/missing", "", http.StatusNotFound},
+		{"Neither workflow nor ID", http.MethodPost, "/api/v1/executions", `{}`, http.StatusBadRequest},
+		{"Both workflow and ID", http.MethodPost, "/api/v1/executions", `{"workflow_id": "` + id + `", "workflow": ` + delayWorkflow + `}`, http.StatusBadRequest},
+		{"Unknown workflow", http.MethodPost, "/api/v1/executions", `{"workflow_id": "missing"}`, http.StatusNotFound},
+		{"Invalid inputs", http.MethodPost, "/api/v1/executions", `{"workflow_id": "` + id + `"}`, http.StatusBadRequest},
+		{"Invalid workflow", http.MethodPost, "/api/v1/executions", `{"workflow": {"nodes": "x"}}`, http.StatusBadRequest},
+		{"Method not allowed", http.MethodPut, "/api/v1/executions", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestExecutionDebug(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	handler := srv.httpServer.Handler
+
+	do := func(method, path, body string, out interface{}) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		if out != nil {
+			if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+				t.Fatalf("%s %s: invalid response: %v", method, path, err)
+			}
+		}
+		return w.Code
+	}
+	submit := func(body string) string {
+		t.Helper()
+		var resp SubmitExecutionResponse
+		if code := do(http.MethodPost, "/api/v1/executions", body, &resp); code != http.StatusAccepted {
+			t.Fatalf("Submit returned %d %+v, want 202", code, resp)
+		}
+		return resp.ExecutionID
+	}
+	poll := func(id string, done func(runner.Status) bool) runner.Execution {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			var resp ExecutionResponse
+			do(http.MethodGet, "/api/v1/executions/"+id, "", &resp)
+			if resp.Execution != nil && done(resp.Execution.Status) {
+				return *resp.Execution
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Execution %s stuck: %+v", id, resp)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	paused := func(s runner.Status) bool { return s == runner.StatusPaused }
+	command := func(id, body string) DebugResponse {
+		t.Helper()
+		var resp DebugResponse
+		if code := do(http.MethodPost, "/api/v1/executions/"+id+"/debug", body, &resp); code != http.StatusOK {
+			t.Fatalf("Debug command %s returned %d %+v", body, code, resp)
+		}
+		return resp
+	}
+	readX := func(exec runner.Execution) interface{} {
+		t.Helper()
+		if exec.Status != runner.StatusSucceeded {
+			t.Fatalf("Execution = %+v, want succeeded", exec)
+		}
+		return exec.Result.NodeResults["3"].(map[string]interface{})["value"]
+	}
+
+	workflow := `{"nodes": [
+		{"id": "1", "type": "number", "data": {"value": 10}},
+		{"id": "2", "type": "variable", "data": {"var_op": "set", "var_name": "x"}},
+		{"id": "3", "type": "variable", "data": {"var_op": "get", "var_name": "x"}}
+	], "edges": [{"source": "1", "target": "2"}, {"source": "2", "target": "3"}]}`
+
+	// Pause at the breakpoint, change x and save the session
+	id := submit(`{"workflow": ` + workflow + `, "debug": {"breakpoints": ["3"], "session_timeout": "1m"}}`)
+	poll(id, paused)
+	var state DebugResponse
+	if code := do(http.MethodGet, "/api/v1/executions/"+id+"/debug", "", &state); code != http.StatusOK {
+		t.Fatalf("Debug state returned %d %+v", code, state)
+	}
+	if !state.Debug.Paused || state.Debug.NodeID != "3" || state.Debug.Variables["x"] != 10.0 {
+		t.Errorf("Debug state = %+v, want paused at 3 with x = 10", state.Debug)
+	}
+	if state = command(id, `{"command": "set_variable", "name": "x", "value": 42}`); state.Debug.Variables["x"] != 42.0 {
+		t.Errorf("Variables after set_variable = %v, want x = 42", state.Debug.Variables)
+	}
+	saved := command(id, `{"command": "snapshot"}`)
+	if saved.Snapshot == nil || len(saved.Snapshot.Breakpoints) != 1 {
+		t.Fatalf("Snapshot = %+v, want one with the breakpoint", saved.Snapshot)
+	}
+	command(id, `{"command": "continue"}`)
+	if x := readX(poll(id, runner.Status.Finished)); x != 42.0 {
+		t.Errorf("Node 3 read x = %v, want 42", x)
+	}
+
+	// Resume the saved session, which pauses at its breakpoint again
+	snapshot, err := json.Marshal(saved.Snapshot)
+	if err != nil {
+		t.Fatalf("Failed to encode snapshot: %v", err)
+	}
+	resumed := submit(`{"snapshot": ` + string(snapshot) + `, "debug": {}}`)
+	if resumed == id {
+		t.Error("Resumed execution reuses the original execution ID")
+	}
+	poll(resumed, paused)
+	command(resumed, `{"command": "step"}`)
+	if x := readX(poll(resumed, runner.Status.Finished)); x != 42.0 {
+		t.Errorf("Resumed node 3 read x = %v, want 42 from the snapshot", x)
+	}
+
+	// A snapshot cannot claim another tenant's or a missing workflow
+	foreign := *saved.Snapshot
+	foreign.WorkflowID = "missing"
+	foreignSnapshot, err := json.Marshal(&foreign)
+	if err != nil {
+		t.Fatalf("Failed to encode snapshot: %v", err)
+	}
+
+	plain := submit(`{"workflow": ` + delayWorkflow + `}`)
+	defer do(http.MethodDelete, "/api/v1/executions/"+plain, "", nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Unknown execution", http.MethodGet, "/api/v1/executions/missing/debug", "", http.StatusNotFound},
+		{"Finished execution", http.MethodPost, "/api/v1/executions/" + id + "/debug", `{"command": "continue"}`, http.StatusConflict},
+		{"Not debugged", http.MethodGet, "/api/v1/executions/" + plain + "/debug", "", http.StatusConflict},
+		{"Command to execution not debugged", http.MethodPost, "/api/v1/executions/" + plain + "/debug", `{"command": "step"}`, http.StatusConflict},
+		{"Invalid command body", http.MethodPost, "/api/v1/executions/" + id + "/debug", `{`, http.StatusBadRequest},
+		{"Method not allowed", http.MethodDelete, "/api/v1/executions/" + id + "/debug", "", http.StatusMethodNotAllowed},
+		{"Invalid session timeout", http.MethodPost, "/api/v1/executions", `{"workflow": ` + workflow + `, "debug": {"session_timeout": "soon"}}`, http.StatusBadRequest},
+		{"Session timeout over the limit", http.MethodPost, "/api/v1/executions", `{"workflow": ` + workflow + `, "debug": {"session_timeout": "24h"}}`, http.StatusBadRequest},
+		{"Snapshot and workflow", http.MethodPost, "/api/v1/executions", `{"workflow": ` + workflow + `, "snapshot": ` + string(snapshot) + `}`, http.StatusBadRequest},
+		{"Snapshot of unknown workflow", http.MethodPost, "/api/v1/executions", `{"snapshot": ` + string(foreignSnapshot) + `}`, http.StatusNotFound},
+		{"Invalid snapshot", http.MethodPost, "/api/v1/executions", `{"snapshot": {"version": "0"}}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := do(tt.method, tt.path, tt.body, nil); code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, code)
+			}
+		})
+	}
+}
+
+// sseEvent is one parsed Server-Sent Event
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSE reads events until the stream closes
+func readSSE(t *testing.T, resp *http.Response) []sseEvent {
+	t.Helper()
+	var (
+		out     []sseEvent
+		current sseEvent
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.event != "" {
+				out = append(out, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return out
+}
+
+func TestExecutionEventsStream(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	ts := httptest.NewServer(srv.httpServer.Handler)
+	defer ts.Close()
+
+	workflow := `{"nodes": [{"id": "1", "data": {"value": 1}}, {"id": "wait", "data": {"duration": "100ms"}}], "edges": [{"source": "1", "target": "wait"}]}`
+	resp, err := http.Post(ts.URL+"/api/v1/executions", "application/json", strings.NewReader(`{"workflow": `+workflow+`}`))
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	var submitted SubmitExecutionResponse
+	_ = json.NewDecoder(resp.Body).Decode(&submitted)
+	resp.Body.Close()
+
+	// Subscribe while the workflow is still running
+	eventsURL := ts.URL + "/api/v1/executions/" + submitted.ExecutionID + "/events"
+	resp, err = http.Get(eventsURL)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	live := readSSE(t, resp)
+	resp.Body.Close()
+
+	want := []string{"workflow_start", "node_start", "node_success", "node_start", "node_success", "workflow_end", "end"}
+	if len(live) != len(want) {
+		t.Fatalf("Got events %+v, want %v", live, want)
+	}
+	for i, name := range want {
+		if live[i].event != name {
+			t.Errorf("Event %d = %s, want %s", i, live[i].event, name)
+		}
+	}
+	if !strings.Contains(live[len(live)-1].data, `"status":"succeeded"`) {
+		t.Errorf("End event data = %s, want succeeded status", live[len(live)-1].data)
+	}
+
+	// A late subscriber resumes after the last event it saw
+	req, _ := http.NewRequest(http.MethodGet, eventsURL, nil)
+	req.Header.Set("Last-Event-ID", "4")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Resubscribe failed: %v", err)
+	}
+	resumed := readSSE(t, resp)
+	resp.Body.Close()
+	if len(resumed) != 3 || resumed[0].id != "5" || resumed[2].event != "end" {
+		t.Errorf("Resumed events = %+v, want events 5 and 6 then end", resumed)
+	}
+
+	for path, status := range map[string]int{
+		"/api/v1/executions/missing/events":                                       http.StatusNotFound,
+		"/api/v1/executions/" + submitted.ExecutionID + "/events?last_event_id=x": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("GET %s returned %d, want %d", path, resp.StatusCode, status)
+		}
+	}
+}
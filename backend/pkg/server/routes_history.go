@@ -0,0 +1,141 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/history"
+)
+
+// ListHistoryResponse represents the response from listing execution
+// history. Count is the number of executions in this page and Total the
+// number matching the filter across all pages.
+type ListHistoryResponse struct {
+	Success    bool              `json:"success"`
+	Executions []history.Summary `json:"executions"`
+	Count      int               `json:"count"`
+	Total      int               `json:"total"`
+	Offset     int               `json:"offset"`
+	Limit      int               `json:"limit"`
+	HasMore    bool              `json:"has_more"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// GetHistoryResponse represents the response from fetching one execution
+// record with its node trace
+type GetHistoryResponse struct {
+	Success   bool            `json:"success"`
+	Execution *history.Record `json:"execution,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// handleListHistory lists the caller's tenant's recorded executions, newest
+// first. Supported query parameters: workflow_id, status, from and to
+// (RFC 3339, bounding the start time), offset and limit.
+func (s *Server) handleListHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseHistoryFilter(r.URL.Query())
+	if err != nil {
+		s.writeJSONResponse(w, http.StatusBadRequest, ListHistoryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	filter.TenantID = s.scope(r).id
+	page, err := s.history.List(filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, history.ErrInvalidFilter) {
+			status = http.StatusBadRequest
+		}
+		s.writeJSONResponse(w, status, ListHistoryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, ListHistoryResponse{
+		Success:    true,
+		Executions: page.Executions,
+		Count:      len(page.Executions),
+		Total:      page.Total,
+		Offset:     page.Offset,
+		Limit:      page.Limit,
+		HasMore:    page.HasMore(),
+	})
+}
+
+// handleGetHistory returns one recorded execution with its node trace
+// Path format: /api/v1/history/{execution_id}
+func (s *Server) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/v1/history/"))
+	if id == "" {
+		s.writeJSONResponse(w, http.StatusBadRequest, GetHistoryResponse{
+			Success: false,
+			Error:   "Execution ID is required",
+		})
+		return
+	}
+
+	rec, err := s.history.Get(id)
+	if err == nil && rec.TenantID != s.scope(r).id {
+		// Other tenants' executions are reported as missing
+		err = fmt.Errorf("%w: %s", history.ErrRecordNotFound, id)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, history.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		s.writeJSONResponse(w, status, GetHistoryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, GetHistoryResponse{
+		Success:   true,
+		Execution: rec,
+	})
+}
+
+// parseHistoryFilter reads history listing parameters from a query string
+func parseHistoryFilter(query url.Values) (history.Filter, error) {
+	filter := history.Filter{
+		WorkflowID: query.Get("workflow_id"),
+		Status:     history.Status(query.Get("status")),
+	}
+
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+		}
+		*target = t
+	}
+
+	var err error
+	filter.Offset, filter.Limit, err = parsePaging(query)
+	return filter, err
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/history"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/httpclient"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestHistoryEndpoints(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.httpServer.Handler
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	id, err := srv.defaultScope().workflows.Register("Calc", "", json.RawMessage(addWorkflow))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	// One saved execution, one ad-hoc success and one ad-hoc failure
+	if w := do(http.MethodPost, "/api/v1/workflow/execute/"+id, ""); w.Code != http.StatusOK {
+		t.Fatalf("Execute by ID returned %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/v1/workflow/execute", addWorkflow); w.Code != http.StatusOK {
+		t.Fatalf("Execute returned %d: %s", w.Code, w.Body.String())
+	}
+	failing := `{"nodes": [{"id": "1", "data": {"value": 1}}, {"id": "2", "data": {"value": 0}}, {"id": "3", "data": {"op": "divide"}}], "edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]}`
+	do(http.MethodPost, "/api/v1/workflow/execute", failing)
+
+	list := func(query string) ListHistoryResponse {
+		t.Helper()
+		w := do(http.MethodGet, "/api/v1/history"+query, "")
+		var resp ListHistoryResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusOK {
+			t.Fatalf("List history%s returned %d: %+v", query, w.Code, resp)
+		}
+		return resp
+	}
+
+	if resp := list(""); resp.Total != 3 || resp.Limit != defaultListLimit {
+		t.Errorf("List all = %+v, want 3 executions", resp)
+	}
+	byWorkflow := list("?workflow_id=" + id)
+	if byWorkflow.Total != 1 || byWorkflow.Executions[0].WorkflowVersion != 1 {
+		t.Fatalf("List by workflow = %+v, want one execution of version 1", byWorkflow)
+	}
+	failed := list("?status=failed")
+	if failed.Total != 1 || !strings.Contains(failed.Executions[0].Error, "division by zero") {
+		t.Errorf("List failed = %+v, want the division by zero execution", failed)
+	}
+	if resp := list("?from=2000-01-01T00:00:00Z&to=2000-12-31T00:00:00Z"); resp.Total != 0 {
+		t.Errorf("List in the past = %+v, want none", resp)
+	}
+
+	// Full node trace with captured outputs
+	w := do(http.MethodGet, "/api/v1/history/"+byWorkflow.Executions[0].ExecutionID, "")
+	var got GetHistoryResponse
+	_ = json.NewDecoder(w.Body).Decode(&got)
+	if w.Code != http.StatusOK || got.Execution == nil || len(got.Execution.Nodes) != 3 {
+		t.Fatalf("Get history returned %d %+v", w.Code, got)
+	}
+	last := got.Execution.Nodes[2]
+	if last.NodeID != "3" || last.Status != history.StatusSucceeded || string(last.Output) != "15" {
+		t.Errorf("Node trace entry = %+v, want node 3 with output 15", last)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"Unknown execution", "/api/v1/history/missing", http.StatusNotFound},
+		{"Unknown status", "/api/v1/history?status=paused", http.StatusBadRequest},
+		{"Bad timestamp", "/api/v1/history?from=yesterday", http.StatusBadRequest},
+		{"Inverted range", "/api/v1/history?from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z", http.StatusBadRequest},
+		{"Bad limit", "/api/v1/history?limit=0", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(http.MethodGet, tt.path, ""); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNew_FileHistoryStore(t *testing.T) {
+	config := DefaultConfig()
+	config.HistoryStore = StoreFile
+	config.HistoryDir = t.TempDir()
+
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	w := httptest.NewRecorder()
+	srv.handleExecuteWorkflow(w, httptest.NewRequest(http.MethodPost, "/api/v1/workflow/execute", strings.NewReader(addWorkflow)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Execute returned %d: %s", w.Code, w.Body.String())
+	}
+
+	restarted, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to recreate server: %v", err)
+	}
+	if page, _ := restarted.history.List(history.Filter{}); page.Total != 1 {
+		t.Errorf("Expected the execution record to survive a restart, got %d records", page.Total)
+	}
+
+	config.HistoryStore = "sqlite"
+	if _, err := New(config, types.DefaultConfig()); err == nil {
+		t.Error("Expected error for unknown history store")
+	}
+}
+
+func TestHistoryRedaction(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	crm := httpclient.Config{
+		UID:  "crm",
+		Auth: httpclient.AuthConfig{Type: httpclient.AuthTypeBearer, Token: &httpclient.TokenAuthConfig{Token: httpclient.NewSecureString("crm-token-1")}},
+	}
+	if err := srv.defaultScope().httpClients.RegisterWithConfig(&http.Client{}, crm); err != nil {
+		t.Fatalf("Failed to register HTTP client: %v", err)
+	}
+
+	body := `{"nodes": [
+		{"id": "text", "type": "text_input", "data": {"text": "{\"password\": \"hunter2\", \"note\": \"uses crm-token-1\"}"}},
+		{"id": "obj", "type": "parse", "data": {"input_type": "JSON"}}
+	], "edges": [{"source": "text", "target": "obj"}]}`
+	w := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/workflow/execute", strings.NewReader(body)))
+	var executed ExecuteWorkflowResponse
+	if err := json.NewDecoder(w.Body).Decode(&executed); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Execute returned %d: %v", w.Code, err)
+	}
+
+	w = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/history/"+executed.Results.ExecutionID, nil))
+	var got GetHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || got.Execution == nil {
+		t.Fatalf("Get history returned %d: %v", w.Code, err)
+	}
+	for _, node := range got.Execution.Nodes {
+		output := string(node.Output)
+		if strings.Contains(output, "hunter2") || strings.Contains(output, "crm-token-1") {
+			t.Errorf("Node %s output leaks a secret: %s", node.NodeID, output)
+		}
+	}
+	if output := string(got.Execution.Nodes[1].Output); output != `{"note":"uses ***REDACTED***","password":"***REDACTED***"}` {
+		t.Errorf("Parsed output = %s, want masked values", output)
+	}
+}
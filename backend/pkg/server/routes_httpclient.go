@@ -75,7 +75,7 @@ func (s *Server) handleRegisterHTTPClient(w http.ResponseWriter, r *http.Request
 	}
 
 	// Register the client
-	if err := s.httpClientRegistry.Register(req.Config.UID, client); err != nil {
+	if err := s.scope(r).httpClients.RegisterWithConfig(client, *req.Config); err != nil {
 		s.writeJSONResponse(w, http.StatusConflict, RegisterHTTPClientResponse{
 			Success: false,
 			Error:   "Failed to register HTTP client: " + err.Error(),
@@ -101,7 +101,7 @@ func (s *Server) handleListHTTPClients(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get list of registered clients
-	clients := s.httpClientRegistry.List()
+	clients := s.scope(r).httpClients.List()
 
 	// Write successful response
 	s.writeJSONResponse(w, http.StatusOK, ListHTTPClientsResponse{
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/schedule"
+)
+
+// defaultNextRuns and maxNextRuns bound the ?next= query parameter of
+// schedule responses
+const (
+	defaultNextRuns = 5
+	maxNextRuns     = 100
+)
+
+// ScheduleResponse represents the response from creating, fetching or
+// changing a schedule. NextRuns lists its upcoming fire times.
+type ScheduleResponse struct {
+	Success  bool               `json:"success"`
+	Schedule *schedule.Schedule `json:"schedule,omitempty"`
+	NextRuns []time.Time        `json:"next_runs,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// ListSchedulesResponse represents the response from listing schedules
+type ListSchedulesResponse struct {
+	Success   bool                `json:"success"`
+	Schedules []schedule.Schedule `json:"schedules"`
+	Count     int                 `json:"count"`
+}
+
+// handleSchedules handles creating (POST) and listing (GET) the caller's
+// tenant's schedules. GET accepts ?workflow_id= to list one workflow's.
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		schedules := s.scheduler.List(schedule.Filter{
+			TenantID:   s.scope(r).id,
+			WorkflowID: strings.TrimSpace(r.URL.Query().Get("workflow_id")),
+		})
+		s.writeJSONResponse(w, http.StatusOK, ListSchedulesResponse{
+			Success:   true,
+			Schedules: schedules,
+			Count:     len(schedules),
+		})
+	case http.MethodPost:
+		next, ok := s.readNextRuns(w, r)
+		if !ok {
+			return
+		}
+		spec, ok := s.readScheduleSpec(w, r)
+		if !ok {
+			return
+		}
+		scope := s.scope(r)
+		sch, err := s.scheduler.Create(scope.id, spec)
+		if err != nil {
+			s.writeScheduleError(w, err)
+			return
+		}
+		s.logger.WithField("schedule_id", sch.ID).WithField("workflow_id", sch.WorkflowID).Info("Schedule created")
+
+		w.Header().Set("Location", "/api/v1/schedules/"+sch.ID)
+		s.writeScheduleResponse(w, http.StatusCreated, sch, next)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSchedule returns (GET), replaces (PUT) or deletes (DELETE) one
+// schedule, or pauses and resumes it (POST). ?next= chooses how many
+// upcoming fire times are listed.
+// Path format: /api/v1/schedules/{id}, /api/v1/schedules/{id}/pause or
+// /api/v1/schedules/{id}/resume
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/v1/schedules/"))
+	id, action, _ := strings.Cut(id, "/")
+	if id == "" {
+		s.writeJSONResponse(w, http.StatusBadRequest, ScheduleResponse{
+			Success: false,
+			Error:   "Schedule ID is required",
+		})
+		return
+	}
+
+	switch action {
+	case "":
+	case "pause", "resume":
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	// A bad ?next= is rejected before the schedule is changed
+	next, ok := s.readNextRuns(w, r)
+	if !ok {
+		return
+	}
+
+	// Other tenants' schedules are reported as missing
+	if _, err := s.getSchedule(r, id); err != nil {
+		s.writeScheduleError(w, err)
+		return
+	}
+
+	var (
+		sch schedule.Schedule
+		err error
+	)
+	switch {
+	case action == "pause":
+		sch, err = s.scheduler.Pause(id)
+	case action == "resume":
+		sch, err = s.scheduler.Resume(id)
+	case r.Method == http.MethodGet:
+		sch, err = s.scheduler.Get(id)
+	case r.Method == http.MethodPut:
+		spec, ok := s.readScheduleSpec(w, r)
+		if !ok {
+			return
+		}
+		sch, err = s.scheduler.Update(id, spec)
+	case r.Method == http.MethodDelete:
+		if err := s.scheduler.Delete(id); err != nil {
+			s.writeScheduleError(w, err)
+			return
+		}
+		s.logger.WithField("schedule_id", id).Info("Schedule deleted")
+		s.writeJSONResponse(w, http.StatusOK, ScheduleResponse{Success: true})
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		s.writeScheduleError(w, err)
+		return
+	}
+	if action != "" || r.Method != http.MethodGet {
+		s.logger.WithField("schedule_id", id).WithField("paused", sch.Paused).Info("Schedule updated")
+	}
+	s.writeScheduleResponse(w, http.StatusOK, sch, next)
+}
+
+// readScheduleSpec decodes a schedule spec from the request body and
+// checks that its workflow exists and accepts its inputs. On failure it
+// writes the error response and returns false.
+func (s *Server) readScheduleSpec(w http.ResponseWriter, r *http.Request) (schedule.Spec, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest, err)
+		return schedule.Spec{}, false
+	}
+
+	var spec schedule.Spec
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		s.writeErrorResponse(w, "Failed to parse request", http.StatusBadRequest, err)
+		return schedule.Spec{}, false
+	}
+
+	spec.WorkflowID = strings.TrimSpace(spec.WorkflowID)
+	if spec.WorkflowID == "" || spec.Version < 0 {
+		s.writeErrorResponse(w, "Invalid schedule", http.StatusBadRequest,
+			fmt.Errorf("%w: workflow_id is required and version must not be negative", schedule.ErrInvalidSchedule))
+		return schedule.Spec{}, false
+	}
+
+	// Fail now rather than on every run when the workflow cannot run
+	version, err := savedWorkflowVersion(s.scope(r).workflows, spec.WorkflowID, spec.Version)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to load workflow", workflowErrorStatus(err), err)
+		return schedule.Spec{}, false
+	}
+	if _, err := params.Bind(version.Data, spec.Inputs); err != nil {
+		s.writeInputErrors(w, err)
+		return schedule.Spec{}, false
+	}
+	return spec, true
+}
+
+// getSchedule returns the schedule with the given ID if it belongs to the
+// caller's tenant. Other tenants' schedules are reported as missing.
+func (s *Server) getSchedule(r *http.Request, id string) (schedule.Schedule, error) {
+	sch, err := s.scheduler.Get(id)
+	if err != nil {
+		return sch, err
+	}
+	if sch.TenantID != s.scope(r).id {
+		return schedule.Schedule{}, fmt.Errorf("%w: %s", schedule.ErrScheduleNotFound, id)
+	}
+	return sch, nil
+}
+
+// readNextRuns returns how many upcoming fire times the ?next= query
+// parameter asks for. On failure it writes the error response and returns
+// false.
+func (s *Server) readNextRuns(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("next")
+	if v == "" {
+		return defaultNextRuns, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > maxNextRuns {
+		s.writeJSONResponse(w, http.StatusBadRequest, ScheduleResponse{
+			Success: false,
+			Error:   fmt.Sprintf("next must be an integer between 0 and %d", maxNextRuns),
+		})
+		return 0, false
+	}
+	return n, true
+}
+
+// writeScheduleResponse writes sch with its next n fire times
+func (s *Server) writeScheduleResponse(w http.ResponseWriter, status int, sch schedule.Schedule, n int) {
+	// The schedule may have been deleted since; it is still reported
+	nextRuns, _ := s.scheduler.NextRuns(sch.ID, n)
+	s.writeJSONResponse(w, status, ScheduleResponse{
+		Success:  true,
+		Schedule: &sch,
+		NextRuns: nextRuns,
+	})
+}
+
+// writeScheduleError maps a scheduler error to an HTTP status
+func (s *Server) writeScheduleError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, schedule.ErrScheduleNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, schedule.ErrInvalidCron), errors.Is(err, schedule.ErrInvalidSchedule):
+		status = http.StatusBadRequest
+	case errors.Is(err, schedule.ErrSchedulerClosed):
+		status = http.StatusServiceUnavailable
+	}
+	s.writeJSONResponse(w, status, ScheduleResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+// runScheduled executes a schedule's workflow for the scheduler. It takes
+// the same path as handleExecuteWorkflowByID: the saved version is bound
+// to the schedule's inputs, run by an engine with the server's observers
+// and counted against the tenant's quota.
+func (s *Server) runScheduled(ctx context.Context, sch schedule.Schedule, scheduledAt time.Time) (string, error) {
+	logger := s.logger.WithField("schedule_id", sch.ID).WithField("workflow_id", sch.WorkflowID).WithField("scheduled_at", scheduledAt)
+
+	scope, err := s.tenants.Get(sch.TenantID)
+	if err != nil {
+		return "", fmt.Errorf("failed to open tenant: %w", err)
+	}
+	version, err := savedWorkflowVersion(scope.workflows, sch.WorkflowID, sch.Version)
+	if err != nil {
+		logger.WithError(err).Error("Scheduled workflow not found")
+		return "", err
+	}
+	payload, err := params.Bind(version.Data, sch.Inputs)
+	if err != nil {
+		logger.WithError(err).Error("Scheduled workflow inputs rejected")
+		return "", err
+	}
+
+	eng, err := s.newEngine(scope, payload, sch.WorkflowID, version.Version)
+	if err != nil {
+		logger.WithError(err).Error("Failed to create engine for scheduled run")
+		return "", err
+	}
+
+	release, err := s.quotas.Acquire(scope.id)
+	if err != nil {
+		logger.WithError(err).Warn("Scheduled run rejected by tenant quota")
+		return "", err
+	}
+	defer release()
+
+	_, err = eng.ExecuteContext(ctx)
+	logger = logger.WithField("execution_id", eng.ExecutionID()).WithField("version", version.Version)
+	if err != nil {
+		logger.WithError(err).Error("Scheduled workflow execution failed")
+		return eng.ExecutionID(), err
+	}
+	logger.Info("Scheduled workflow executed")
+	return eng.ExecutionID(), nil
+}
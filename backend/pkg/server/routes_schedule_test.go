@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/history"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/schedule"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// testClock is a schedule.Clock moved by hand. Advancing it wakes every
+// waiter so the scheduler re-checks its schedules.
+type testClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []chan time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) After(time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, ch)
+	return ch
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, ch := range c.waiters {
+		ch <- c.now
+	}
+	c.waiters = nil
+}
+
+func TestScheduleEndpoints(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 3, 2, 8, 59, 30, 0, time.UTC)}
+	config := DefaultConfig()
+	config.ScheduleClock = clock
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	defer srv.scheduler.Shutdown(context.Background())
+	handler := srv.httpServer.Handler
+
+	do := func(method, path, body string, v interface{}) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		if v != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+				t.Fatalf("%s %s: failed to decode %q: %v", method, path, w.Body.String(), err)
+			}
+		}
+		return w.Code
+	}
+
+	id, err := srv.defaultScope().workflows.Register("Adder", "", json.RawMessage(`{
+		"inputs": [{"name": "a", "type": "number", "required": true, "node": "1"}],
+		"nodes": [{"id": "1", "data": {"value": 0}}, {"id": "2", "data": {"value": 5}}, {"id": "3", "data": {"op": "add"}}],
+		"edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	// Inputs are checked when the schedule is created, not when it fires
+	var rejected ErrorResponse
+	if code := do(http.MethodPost, "/api/v1/schedules", `{"workflow_id": "`+id+`", "cron": "0 9 * * *"}`, &rejected); code != http.StatusBadRequest || len(rejected.InputErrors) != 1 {
+		t.Errorf("Schedule without required input returned %d %+v, want 400 with one input error", code, rejected)
+	}
+	var invalid ScheduleResponse
+	if code := do(http.MethodPost, "/api/v1/schedules", `{"workflow_id": "`+id+`", "cron": "0 9 * * *", "timezone": "Mars/Olympus", "inputs": {"a": 1}}`, &invalid); code != http.StatusBadRequest {
+		t.Errorf("Schedule with unknown time zone returned %d %+v, want 400", code, invalid)
+	}
+
+	// A bad ?next= is rejected before anything is saved
+	if code := do(http.MethodPost, "/api/v1/schedules?next=abc", `{"workflow_id": "`+id+`", "cron": "0 9 * * *", "inputs": {"a": 10}}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Create with next=abc returned %d, want 400", code)
+	}
+	if n := len(srv.scheduler.List(schedule.Filter{})); n != 0 {
+		t.Fatalf("Rejected create saved %d schedules, want none", n)
+	}
+
+	var created ScheduleResponse
+	if code := do(http.MethodPost, "/api/v1/schedules", `{"workflow_id": "`+id+`", "cron": "0 9 * * *", "timezone": "America/New_York", "inputs": {"a": 10}}`, &created); code != http.StatusCreated {
+		t.Fatalf("Create schedule returned %d %+v", code, created)
+	}
+	sch := created.Schedule
+	firstRun := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	if sch.NextRunAt == nil || !sch.NextRunAt.Equal(firstRun) || sch.MisfirePolicy != schedule.MisfireSkip {
+		t.Fatalf("Created schedule %+v, want next run at %s and the skip policy", sch, firstRun)
+	}
+	if len(created.NextRuns) != defaultNextRuns || !created.NextRuns[1].Equal(firstRun.Add(24*time.Hour)) {
+		t.Errorf("next_runs = %v", created.NextRuns)
+	}
+
+	// Another tenant's schedule is not visible
+	other, err := srv.scheduler.Create("acme", schedule.Spec{WorkflowID: id, Cron: "@daily"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list ListSchedulesResponse
+	if do(http.MethodGet, "/api/v1/schedules", "", &list); list.Count != 1 || list.Schedules[0].ID != sch.ID {
+		t.Errorf("List returned %+v, want only %s", list, sch.ID)
+	}
+	if code := do(http.MethodGet, "/api/v1/schedules/"+other.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("Get other tenant's schedule returned %d, want 404", code)
+	}
+
+	// Firing runs the saved workflow through the engine and records it
+	clock.Advance(5*time.Hour + 30*time.Second)
+	var fired ScheduleResponse
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		do(http.MethodGet, "/api/v1/schedules/"+sch.ID, "", &fired)
+		if run := fired.Schedule.LastRun; run != nil && run.Status != schedule.RunRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Schedule did not run: %+v", fired.Schedule)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	run := fired.Schedule.LastRun
+	if run.Status != schedule.RunSucceeded || !run.ScheduledAt.Equal(firstRun) || run.ExecutionID == "" {
+		t.Fatalf("Last run = %+v, want a successful run at %s", run, firstRun)
+	}
+	record, err := srv.history.Get(run.ExecutionID)
+	if err != nil {
+		t.Fatalf("Scheduled run is not in the history: %v", err)
+	}
+	if record.WorkflowID != id || record.Status != history.StatusSucceeded {
+		t.Errorf("History record %+v", record.Summary)
+	}
+
+	// Paused schedules have no upcoming runs
+	var paused ScheduleResponse
+	if do(http.MethodPost, "/api/v1/schedules/"+sch.ID+"/pause", "", &paused); !paused.Schedule.Paused || paused.Schedule.NextRunAt != nil || len(paused.NextRuns) != 0 {
+		t.Errorf("Paused schedule %+v with next runs %v", paused.Schedule, paused.NextRuns)
+	}
+	if code := do(http.MethodPost, "/api/v1/schedules/"+sch.ID+"/resume?next=1000", "", nil); code != http.StatusBadRequest {
+		t.Errorf("Resume with next=1000 returned %d, want 400", code)
+	}
+	if do(http.MethodGet, "/api/v1/schedules/"+sch.ID, "", &paused); !paused.Schedule.Paused {
+		t.Error("Rejected resume resumed the schedule")
+	}
+	var resumed ScheduleResponse
+	if do(http.MethodPost, "/api/v1/schedules/"+sch.ID+"/resume?next=2", "", &resumed); resumed.Schedule.NextRunAt == nil || len(resumed.NextRuns) != 2 {
+		t.Errorf("Resumed schedule %+v with next runs %v", resumed.Schedule, resumed.NextRuns)
+	}
+
+	// Deleting the workflow deletes its schedules
+	if code := do(http.MethodDelete, "/api/v1/workflow/delete/"+id, "", nil); code != http.StatusOK {
+		t.Fatalf("Delete workflow returned %d", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/schedules/"+sch.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("Schedule of deleted workflow returned %d, want 404", code)
+	}
+	if _, err := srv.scheduler.Get(other.ID); err != nil {
+		t.Errorf("Other tenant's schedule was deleted: %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/runner"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/trigger"
+)
+
+// hooksPath is the prefix of webhook URLs: /hooks/{trigger-id}
+const hooksPath = "/hooks/"
+
+// TriggerResponse represents the response from creating, fetching or
+// changing a webhook trigger. URL is the path that fires it. Secrets are
+// never returned.
+type TriggerResponse struct {
+	Success bool             `json:"success"`
+	Trigger *trigger.Trigger `json:"trigger,omitempty"`
+	URL     string           `json:"url,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// ListTriggersResponse represents the response from listing triggers
+type ListTriggersResponse struct {
+	Success  bool              `json:"success"`
+	Triggers []trigger.Trigger `json:"triggers"`
+	Count    int               `json:"count"`
+}
+
+// handleTriggers handles creating (POST) and listing (GET) the caller's
+// tenant's webhook triggers. GET accepts ?workflow_id= to list one
+// workflow's.
+func (s *Server) handleTriggers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		triggers := s.triggers.List(trigger.Filter{
+			TenantID:   s.scope(r).id,
+			WorkflowID: strings.TrimSpace(r.URL.Query().Get("workflow_id")),
+		})
+		for i := range triggers {
+			triggers[i] = triggers[i].Redacted()
+		}
+		s.writeJSONResponse(w, http.StatusOK, ListTriggersResponse{
+			Success:  true,
+			Triggers: triggers,
+			Count:    len(triggers),
+		})
+	case http.MethodPost:
+		spec, ok := s.readTriggerSpec(w, r)
+		if !ok {
+			return
+		}
+		t, err := s.triggers.Create(s.scope(r).id, spec)
+		if err != nil {
+			s.writeTriggerError(w, err)
+			return
+		}
+		s.logger.WithField("trigger_id", t.ID).WithField("workflow_id", t.WorkflowID).Info("Trigger created")
+
+		w.Header().Set("Location", "/api/v1/triggers/"+t.ID)
+		s.writeTriggerResponse(w, http.StatusCreated, t)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTrigger returns (GET), replaces (PUT) or deletes (DELETE) one
+// webhook trigger. Replacing a trigger keeps its URL.
+// Path format: /api/v1/triggers/{id}
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/v1/triggers/"))
+	if id == "" || strings.Contains(id, "/") {
+		s.writeJSONResponse(w, http.StatusBadRequest, TriggerResponse{
+			Success: false,
+			Error:   "Trigger ID is required",
+		})
+		return
+	}
+
+	// Other tenants' triggers are reported as missing
+	t, err := s.triggers.Get(id)
+	if err == nil && t.TenantID != s.scope(r).id {
+		err = fmt.Errorf("%w: %s", trigger.ErrTriggerNotFound, id)
+	}
+	if err != nil {
+		s.writeTriggerError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.writeTriggerResponse(w, http.StatusOK, t)
+	case http.MethodPut:
+		spec, ok := s.readTriggerSpec(w, r)
+		if !ok {
+			return
+		}
+		t, err = s.triggers.Update(id, spec)
+		if err != nil {
+			s.writeTriggerError(w, err)
+			return
+		}
+		s.logger.WithField("trigger_id", id).Info("Trigger updated")
+		s.writeTriggerResponse(w, http.StatusOK, t)
+	case http.MethodDelete:
+		if err := s.triggers.Delete(id); err != nil {
+			s.writeTriggerError(w, err)
+			return
+		}
+		s.logger.WithField("trigger_id", id).Info("Trigger deleted")
+		s.writeJSONResponse(w, http.StatusOK, TriggerResponse{Success: true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// readTriggerSpec decodes a trigger spec from the request body and checks
+// that its workflow exists and accepts its inputs and delivery. On failure
+// it writes the error response and returns false.
+func (s *Server) readTriggerSpec(w http.ResponseWriter, r *http.Request) (trigger.Spec, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest, err)
+		return trigger.Spec{}, false
+	}
+
+	var spec trigger.Spec
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		s.writeErrorResponse(w, "Failed to parse request", http.StatusBadRequest, err)
+		return trigger.Spec{}, false
+	}
+	if err := spec.Normalize(); err != nil {
+		s.writeTriggerError(w, err)
+		return trigger.Spec{}, false
+	}
+
+	// Fail now rather than on every request when the workflow cannot run;
+	// an empty request stands in for the ones to come
+	version, err := savedWorkflowVersion(s.scope(r).workflows, spec.WorkflowID, spec.Version)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to load workflow", workflowErrorStatus(err), err)
+		return trigger.Spec{}, false
+	}
+	if _, err := trigger.Deliver(version.Data, spec, trigger.Request{}); err != nil {
+		s.writeInputErrors(w, err)
+		return trigger.Spec{}, false
+	}
+	return spec, true
+}
+
+// writeTriggerResponse writes t without its secret
+func (s *Server) writeTriggerResponse(w http.ResponseWriter, status int, t trigger.Trigger) {
+	t = t.Redacted()
+	s.writeJSONResponse(w, status, TriggerResponse{
+		Success: true,
+		Trigger: &t,
+		URL:     hooksPath + t.ID,
+	})
+}
+
+// writeTriggerError maps a trigger registry error to an HTTP status
+func (s *Server) writeTriggerError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, trigger.ErrTriggerNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, trigger.ErrInvalidTrigger):
+		status = http.StatusBadRequest
+	}
+	s.writeJSONResponse(w, status, TriggerResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+// handleHook runs a trigger's workflow with the request as input. It is
+// public: triggers with a secret only accept requests signed with it.
+// Sync triggers answer with the workflow's output, async ones with 202
+// Accepted and the execution ID.
+// Path format: /hooks/{trigger-id}
+func (s *Server) handleHook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, hooksPath)
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	t, err := s.triggers.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	addLogField(r.Context(), "trigger_id", t.ID)
+	addLogField(r.Context(), "tenant_id", t.TenantID)
+
+	if !t.Allows(r.Method) {
+		w.Header().Set("Allow", strings.Join(t.Methods, ", "))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeHookError(w, t.ID, "Failed to read request body", http.StatusBadRequest, err)
+		return
+	}
+
+	// The signature covers the timestamp and raw body, so it is checked
+	// before parsing; stale timestamps are rejected to stop replays
+	if t.HasSecret {
+		if err := trigger.Verify(t.Secret, r.Header.Get(t.SignatureHeader), r.Header.Get(trigger.TimestampHeader), body, time.Now()); err != nil {
+			s.logger.WithField("trigger_id", t.ID).WithField("remote_addr", r.RemoteAddr).WithError(err).Warn("webhook signature rejected")
+			s.writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{
+				Success: false,
+				Error:   "Invalid signature",
+			})
+			return
+		}
+	}
+
+	req, err := trigger.NewRequest(r, body, t.SignatureHeader)
+	if err != nil {
+		s.writeHookError(w, t.ID, "Failed to parse request", http.StatusBadRequest, err)
+		return
+	}
+
+	scope, err := s.tenants.Get(t.TenantID)
+	if err != nil {
+		s.writeHookError(w, t.ID, "Failed to open tenant", http.StatusInternalServerError, err)
+		return
+	}
+	version, err := savedWorkflowVersion(scope.workflows, t.WorkflowID, t.Version)
+	if err != nil {
+		s.writeHookError(w, t.ID, "Failed to load workflow", workflowErrorStatus(err), err)
+		return
+	}
+	payload, err := trigger.Deliver(version.Data, t.Spec, req)
+	if err != nil {
+		// Input errors describe the caller's own request
+		if errors.As(err, new(*params.ValidationError)) {
+			s.writeInputErrors(w, err)
+		} else {
+			s.writeHookError(w, t.ID, "Invalid request", http.StatusBadRequest, err)
+		}
+		return
+	}
+	eng, err := s.newEngine(scope, payload, t.WorkflowID, version.Version)
+	if err != nil {
+		s.writeHookError(w, t.ID, "Failed to create engine", http.StatusBadRequest, err)
+		return
+	}
+
+	release, ok := s.acquireQuota(w, scope)
+	if !ok {
+		return
+	}
+
+	if t.Mode == trigger.ModeAsync {
+		exec, err := s.runner.Submit(eng, runner.SubmitOptions{
+			WorkflowID: t.WorkflowID,
+			OnFinish:   release,
+			Context:    traceContext(r.Context(), r),
+		})
+		if err != nil {
+			release()
+			s.writeHookError(w, t.ID, "Failed to submit execution", http.StatusServiceUnavailable, err)
+			return
+		}
+		s.logger.WithField("trigger_id", t.ID).WithField("execution_id", exec.ID).Info("Webhook execution submitted")
+
+		w.Header().Set("Location", "/api/v1/executions/"+exec.ID)
+		s.writeJSONResponse(w, http.StatusAccepted, SubmitExecutionResponse{
+			Success:         true,
+			ExecutionID:     exec.ID,
+			Status:          exec.Status,
+			WorkflowID:      t.WorkflowID,
+			WorkflowVersion: version.Version,
+		})
+		return
+	}
+
+	defer release()
+	result, err := eng.ExecuteContext(traceContext(r.Context(), r))
+	if err != nil {
+		s.writeHookError(w, t.ID, "Workflow execution failed", http.StatusInternalServerError, err)
+		return
+	}
+	s.logger.WithField("trigger_id", t.ID).WithField("execution_id", eng.ExecutionID()).Info("Webhook workflow executed")
+
+	output := result.FinalOutput
+	if t.Response.Node != "" {
+		output = result.NodeResults[t.Response.Node]
+	}
+	w.Header().Set("X-Execution-ID", eng.ExecutionID())
+	s.writeHookOutput(w, t.ID, t.Response, output)
+}
+
+// writeHookOutput writes a sync trigger's workflow output. JSON content
+// types get the output encoded; for others a string output is written as
+// is and anything else as JSON.
+func (s *Server) writeHookOutput(w http.ResponseWriter, triggerID string, response *trigger.Response, output interface{}) {
+	mediaType, _, _ := mime.ParseMediaType(response.ContentType)
+	text, isText := output.(string)
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+
+	var body []byte
+	if isText && !isJSON {
+		body = []byte(text)
+	} else {
+		encoded, err := json.Marshal(output)
+		if err != nil {
+			s.writeHookError(w, triggerID, "Failed to encode workflow output", http.StatusInternalServerError, err)
+			return
+		}
+		body = encoded
+	}
+
+	// The trigger owner picks the content type, so the output is served
+	// sandboxed rather than as a page of the API's origin
+	w.Header().Set("Content-Type", response.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.WriteHeader(response.Status)
+	if _, err := w.Write(body); err != nil {
+		s.logger.WithError(err).Error("Failed to write webhook response")
+	}
+}
+
+// writeHookError logs a webhook failure and answers with message alone.
+// Hooks are public, so error details, which may quote the workflow, stay
+// in the log.
+func (s *Server) writeHookError(w http.ResponseWriter, triggerID, message string, statusCode int, err error) {
+	s.logger.WithError(err).
+		WithField("trigger_id", triggerID).
+		WithField("status_code", statusCode).
+		Error(message)
+	s.writeJSONResponse(w, statusCode, ErrorResponse{
+		Success: false,
+		Error:   message,
+	})
+}
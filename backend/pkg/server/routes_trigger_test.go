@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/trigger"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestTriggerEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "keys.json")
+	err := os.WriteFile(keysFile, []byte(`{"keys": [
+		{"name": "acme", "key": "acme-key", "roles": ["admin"], "tenant": "acme"},
+		{"name": "acme-reader", "key": "reader-key", "roles": ["viewer"], "tenant": "acme"},
+		{"name": "globex", "key": "globex-key", "roles": ["admin"], "tenant": "globex"}
+	]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.Auth = auth.Config{APIKeysFile: keysFile}
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	handler := srv.httpServer.Handler
+
+	do := func(key, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	create := func(spec string) trigger.Trigger {
+		t.Helper()
+		w := do("acme-key", http.MethodPost, "/api/v1/triggers", spec, nil)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Create trigger returned %d: %s", w.Code, w.Body.String())
+		}
+		var resp TriggerResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.URL != "/hooks/"+resp.Trigger.ID || w.Header().Get("Location") != "/api/v1/triggers/"+resp.Trigger.ID {
+			t.Errorf("Create trigger URL = %q, Location = %q", resp.URL, w.Header().Get("Location"))
+		}
+		return *resp.Trigger
+	}
+
+	scope, err := srv.tenants.Get("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := scope.workflows.Register("Greeter", "", json.RawMessage(`{
+		"inputs": [{"name": "greeting", "type": "string", "default": "Hello"}],
+		"nodes": [
+			{"id": "body", "data": {"text": ""}},
+			{"id": "greeting", "type": "expression", "data": {"expression": "context.greeting"}},
+			{"id": "name", "type": "expression", "data": {"expression": "context.request.body.name"}}
+		],
+		"edges": [{"source": "body", "target": "greeting"}, {"source": "body", "target": "name"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	// A signed sync trigger answers with its response node's output
+	signed := create(`{"workflow_id": "` + id + `", "secret": "s3cret", "response": {"node": "name", "status": 201}}`)
+	if signed.Secret != "" || !signed.HasSecret {
+		t.Errorf("Created trigger = %+v, want the secret redacted", signed)
+	}
+	body := `{"name": "Ada"}`
+	now := time.Now().Unix()
+	header := http.Header{
+		"Content-Type":          {"application/json"},
+		"X-Signature-256":       {trigger.Sign("s3cret", now, []byte(body))},
+		"X-Signature-Timestamp": {strconv.FormatInt(now, 10)},
+		// Credentials meant for someone else do not get in the way
+		"Authorization": {"Bearer not-ours"},
+	}
+	w := do("", http.MethodPost, "/hooks/"+signed.ID, body, header)
+	if w.Code != http.StatusCreated || strings.TrimSpace(w.Body.String()) != `"Ada"` || w.Header().Get("X-Execution-ID") == "" {
+		t.Errorf("Signed hook returned %d %q, want 201 \"Ada\" with an execution ID", w.Code, w.Body.String())
+	}
+
+	// A delivery signed long ago is not replayed
+	stale := now - int64(trigger.SignatureTolerance/time.Second) - 60
+	header.Set("X-Signature-256", trigger.Sign("s3cret", stale, []byte(body)))
+	header.Set("X-Signature-Timestamp", strconv.FormatInt(stale, 10))
+	if w := do("", http.MethodPost, "/hooks/"+signed.ID, body, header); w.Code != http.StatusUnauthorized {
+		t.Errorf("Stale signed hook returned %d, want 401", w.Code)
+	}
+
+	header.Set("X-Signature-256", trigger.Sign("wrong", now, []byte(body)))
+	header.Set("X-Signature-Timestamp", strconv.FormatInt(now, 10))
+	if w := do("", http.MethodPost, "/hooks/"+signed.ID, body, header); w.Code != http.StatusUnauthorized {
+		t.Errorf("Badly signed hook returned %d, want 401", w.Code)
+	}
+	if w := do("", http.MethodGet, "/hooks/"+signed.ID, "", nil); w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("GET hook returned %d with Allow %q, want 405 POST", w.Code, w.Header().Get("Allow"))
+	}
+	if w := do("", http.MethodPost, "/hooks/missing", body, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Unknown hook returned %d, want 404", w.Code)
+	}
+
+	// Node delivery writes the raw body into an input node
+	text := create(`{"workflow_id": "` + id + `", "methods": ["put"], "delivery": "node", "node": "body",
+		"response": {"node": "body", "content_type": "text/plain"}}`)
+	w = do("", http.MethodPut, "/hooks/"+text.ID, "ping", http.Header{"Content-Type": {"text/plain"}})
+	if w.Code != http.StatusOK || w.Body.String() != "ping" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("Text hook returned %d %q (%s), want 200 ping", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || !strings.Contains(w.Header().Get("Content-Security-Policy"), "sandbox") {
+		t.Errorf("Hook output headers = %v, want nosniff and a sandbox policy", w.Header())
+	}
+	w = do("", http.MethodPut, "/hooks/"+text.ID, `{"a": 1}`, http.Header{"Content-Type": {"application/json"}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Object delivered to a text node returned %d, want 400", w.Code)
+	}
+
+	// Failures do not show anonymous callers the workflow's internals
+	failingID, err := scope.workflows.Register("Failing", "", json.RawMessage(`{
+		"nodes": [{"id": "1", "type": "expression", "data": {"expression": "lookupSecret(\"internal-key\")"}}],
+		"edges": []
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := create(`{"workflow_id": "` + failingID + `"}`)
+	w = do("", http.MethodPost, "/hooks/"+failing.ID, body, http.Header{"Content-Type": {"application/json"}})
+	var failed ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&failed); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusInternalServerError || failed.Details != "" || failed.Error != "Workflow execution failed" {
+		t.Errorf("Failing hook returned %d %+v, want 500 without details", w.Code, failed)
+	}
+	if w := do("acme-key", http.MethodDelete, "/api/v1/triggers/"+failing.ID, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("Delete trigger returned %d", w.Code)
+	}
+
+	// Async triggers queue the execution
+	async := create(`{"workflow_id": "` + id + `", "mode": "async", "inputs": {"greeting": "Hi"}}`)
+	w = do("", http.MethodPost, "/hooks/"+async.ID, body, http.Header{"Content-Type": {"application/json"}})
+	var submitted SubmitExecutionResponse
+	if err := json.NewDecoder(w.Body).Decode(&submitted); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusAccepted || submitted.ExecutionID == "" || w.Header().Get("Location") != "/api/v1/executions/"+submitted.ExecutionID {
+		t.Errorf("Async hook returned %d %+v", w.Code, submitted)
+	}
+
+	// Managing triggers is scoped to the tenant and needs admin
+	if w := do("acme-key", http.MethodPost, "/api/v1/triggers", `{"workflow_id": "`+id+`", "inputs": {"greeting": 1}}`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Trigger with bad inputs returned %d, want 400", w.Code)
+	}
+	if w := do("reader-key", http.MethodPost, "/api/v1/triggers", `{"workflow_id": "`+id+`"}`, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Viewer creating a trigger returned %d, want 403", w.Code)
+	}
+	if w := do("reader-key", http.MethodGet, "/api/v1/triggers/"+signed.ID, "", nil); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "s3cret") {
+		t.Errorf("Viewer reading a trigger returned %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("globex-key", http.MethodGet, "/api/v1/triggers/"+signed.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("globex reading acme's trigger returned %d, want 404", w.Code)
+	}
+	var list ListTriggersResponse
+	if err := json.NewDecoder(do("globex-key", http.MethodGet, "/api/v1/triggers", "", nil).Body).Decode(&list); err != nil || list.Count != 0 {
+		t.Errorf("globex lists %d triggers, want 0 (%v)", list.Count, err)
+	}
+
+	// Updating keeps the URL and drops the secret unless it is resent
+	w = do("acme-key", http.MethodPut, "/api/v1/triggers/"+signed.ID, `{"workflow_id": "`+id+`", "response": {"node": "greeting"}}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Update trigger returned %d: %s", w.Code, w.Body.String())
+	}
+	w = do("", http.MethodPost, "/hooks/"+signed.ID, body, http.Header{"Content-Type": {"application/json"}})
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `"Hello"` {
+		t.Errorf("Updated hook returned %d %q, want 200 \"Hello\"", w.Code, w.Body.String())
+	}
+
+	// Deleting the workflow deletes its triggers
+	if w := do("acme-key", http.MethodDelete, "/api/v1/workflow/delete/"+id, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("Delete workflow returned %d", w.Code)
+	}
+	if n := len(srv.triggers.List(trigger.Filter{})); n != 0 {
+		t.Errorf("%d triggers left after deleting their workflow", n)
+	}
+	if w := do("", http.MethodPost, "/hooks/"+signed.ID, body, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Hook of a deleted workflow returned %d, want 404", w.Code)
+	}
+}
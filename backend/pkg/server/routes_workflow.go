@@ -1,26 +1,42 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	workflow "github.com/yesoreyeram/thaiyyal/backend"
-	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/schedule"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/trigger"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
-// SaveWorkflowRequest represents the request to save a workflow
+// SaveWorkflowRequest represents the request to save a workflow. When ID
+// is set a new version of that workflow is saved instead of a new workflow.
+// Author is ignored when authentication is enabled; the version is
+// recorded against the caller instead.
 type SaveWorkflowRequest struct {
+	ID          string          `json:"id,omitempty"`
 	Name        string          `json:"name"`
 	Description string          `json:"description,omitempty"`
 	Data        json.RawMessage `json:"data"`
+	Author      string          `json:"author,omitempty"`
+	Message     string          `json:"message,omitempty"`
 }
 
 // SaveWorkflowResponse represents the response from saving a workflow
 type SaveWorkflowResponse struct {
 	Success bool   `json:"success"`
 	ID      string `json:"id,omitempty"`
+	Version int    `json:"version,omitempty"`
 	Message string `json:"message,omitempty"`
 	Error   string `json:"error,omitempty"`
 }
@@ -32,11 +48,24 @@ type LoadWorkflowResponse struct {
 	Error    string                 `json:"error,omitempty"`
 }
 
-// ListWorkflowsResponse represents the response from listing workflows
+// Page sizes for workflow listings
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// ListWorkflowsResponse represents the response from listing workflows.
+// Count is the number of workflows in this page and Total the number
+// matching the search across all pages.
 type ListWorkflowsResponse struct {
 	Success   bool                       `json:"success"`
 	Workflows []workflow.WorkflowSummary `json:"workflows"`
 	Count     int                        `json:"count"`
+	Total     int                        `json:"total"`
+	Offset    int                        `json:"offset"`
+	Limit     int                        `json:"limit"`
+	HasMore   bool                       `json:"has_more"`
+	Error     string                     `json:"error,omitempty"`
 }
 
 // DeleteWorkflowResponse represents the response from deleting a workflow
@@ -70,22 +99,39 @@ func (s *Server) handleSaveWorkflow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Save workflow
-	id, err := s.workflowRegistry.Register(req.Name, req.Description, req.Data)
+	// Reject invalid input declarations up front rather than at execution
+	if len(req.Data) > 0 {
+		if _, err := params.Schema(req.Data); errors.Is(err, params.ErrInvalidSchema) {
+			s.writeJSONResponse(w, http.StatusBadRequest, SaveWorkflowResponse{
+				Success: false,
+				Error:   "Failed to save workflow: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	// Save workflow, creating a new version when an ID is given
+	info := workflow.VersionInfo{Author: s.author(r, req.Author), Message: req.Message}
+	saved, err := s.scope(r).workflows.Save(req.ID, req.Name, req.Description, req.Data, info)
 	if err != nil {
-		s.writeJSONResponse(w, http.StatusBadRequest, SaveWorkflowResponse{
+		s.writeJSONResponse(w, workflowErrorStatus(err), SaveWorkflowResponse{
 			Success: false,
 			Error:   "Failed to save workflow: " + err.Error(),
 		})
 		return
 	}
 
-	s.logger.WithField("id", id).WithField("name", req.Name).Info("Workflow saved")
+	s.logger.WithField("id", saved.ID).WithField("name", req.Name).WithField("version", saved.Version).Info("Workflow saved")
 
 	// Write successful response
-	s.writeJSONResponse(w, http.StatusCreated, SaveWorkflowResponse{
+	status := http.StatusCreated
+	if req.ID != "" {
+		status = http.StatusOK
+	}
+	s.writeJSONResponse(w, status, SaveWorkflowResponse{
 		Success: true,
-		ID:      id,
+		ID:      saved.ID,
+		Version: saved.Version,
 		Message: "Workflow saved successfully",
 	})
 }
@@ -111,7 +157,7 @@ func (s *Server) handleLoadWorkflow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Load workflow
-	workflow, err := s.workflowRegistry.Get(id)
+	workflow, err := s.scope(r).workflows.Get(id)
 	if err != nil {
 		s.writeJSONResponse(w, http.StatusNotFound, LoadWorkflowResponse{
 			Success: false,
@@ -127,24 +173,90 @@ func (s *Server) handleLoadWorkflow(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleListWorkflows handles listing all workflows
+// handleListWorkflows handles listing workflows.
+//
+// Query parameters:
+//   - q: case-insensitive name search
+//   - sort: name, created_at or updated_at (default updated_at)
+//   - order: asc or desc (default desc for timestamps, asc for name)
+//   - offset: number of workflows to skip (default 0)
+//   - limit: page size (default 50, max 500)
 func (s *Server) handleListWorkflows(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Get list of workflows
-	workflows := s.workflowRegistry.List()
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		s.writeJSONResponse(w, http.StatusBadRequest, ListWorkflowsResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Get one page of workflows
+	page, err := s.scope(r).workflows.Query(opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, workflow.ErrInvalidListOptions) {
+			status = http.StatusBadRequest
+		}
+		s.writeJSONResponse(w, status, ListWorkflowsResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
 
 	// Write successful response
 	s.writeJSONResponse(w, http.StatusOK, ListWorkflowsResponse{
 		Success:   true,
-		Workflows: workflows,
-		Count:     len(workflows),
+		Workflows: page.Workflows,
+		Count:     len(page.Workflows),
+		Total:     page.Total,
+		Offset:    page.Offset,
+		Limit:     page.Limit,
+		HasMore:   page.HasMore(),
 	})
 }
 
+// parseListOptions reads listing parameters from a query string
+func parseListOptions(query url.Values) (workflow.ListOptions, error) {
+	opts := workflow.ListOptions{
+		Search: query.Get("q"),
+		SortBy: query.Get("sort"),
+		Order:  query.Get("order"),
+	}
+
+	var err error
+	opts.Offset, opts.Limit, err = parsePaging(query)
+	return opts, err
+}
+
+// parsePaging reads the offset and limit parameters shared by list
+// endpoints, applying the default page size
+func parsePaging(query url.Values) (offset, limit int, err error) {
+	limit = defaultListLimit
+
+	if v := query.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return 0, 0, fmt.Errorf("limit must be an integer between 1 and %d", maxListLimit)
+		}
+	}
+
+	return offset, limit, nil
+}
+
 // handleDeleteWorkflow handles deleting a workflow by ID
 func (s *Server) handleDeleteWorkflow(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
@@ -166,15 +278,32 @@ func (s *Server) handleDeleteWorkflow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete workflow
-	err := s.workflowRegistry.Unregister(id)
+	err := s.scope(r).workflows.Unregister(id)
 	if err != nil {
-		s.writeJSONResponse(w, http.StatusNotFound, DeleteWorkflowResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, workflow.ErrWorkflowNotFound) {
+			status = http.StatusNotFound
+		}
+		s.writeJSONResponse(w, status, DeleteWorkflowResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
 
+	// Schedules and triggers of a deleted workflow could only fail from
+	// now on
+	for _, sch := range s.scheduler.List(schedule.Filter{TenantID: s.scope(r).id, WorkflowID: id}) {
+		if err := s.scheduler.Delete(sch.ID); err != nil {
+			s.logger.WithField("schedule_id", sch.ID).WithError(err).Warn("Failed to delete schedule of deleted workflow")
+		}
+	}
+	for _, t := range s.triggers.List(trigger.Filter{TenantID: s.scope(r).id, WorkflowID: id}) {
+		if err := s.triggers.Delete(t.ID); err != nil {
+			s.logger.WithField("trigger_id", t.ID).WithError(err).Warn("Failed to delete trigger of deleted workflow")
+		}
+	}
+
 	s.logger.WithField("id", id).Info("Workflow deleted")
 
 	// Write successful response
@@ -184,7 +313,26 @@ func (s *Server) handleDeleteWorkflow(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleExecuteWorkflowByID handles executing a workflow by ID
+// ExecuteWorkflowByIDRequest is the optional body of an execute-by-ID
+// request. Inputs are validated against the workflow's declared inputs.
+type ExecuteWorkflowByIDRequest struct {
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+}
+
+// ExecuteWorkflowByIDResponse represents the response from executing a
+// saved workflow
+type ExecuteWorkflowByIDResponse struct {
+	Success         bool          `json:"success"`
+	WorkflowID      string        `json:"workflow_id"`
+	WorkflowName    string        `json:"workflow_name"`
+	WorkflowVersion int           `json:"workflow_version"`
+	Results         *types.Result `json:"results"`
+}
+
+// handleExecuteWorkflowByID handles executing a workflow by ID. The
+// optional ?version=N query parameter pins execution to that version;
+// otherwise the latest version runs. The optional request body supplies
+// values for the workflow's declared inputs.
 func (s *Server) handleExecuteWorkflowByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -201,33 +349,128 @@ func (s *Server) handleExecuteWorkflowByID(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Load workflow
-	workflow, err := s.workflowRegistry.Get(id)
+	// Limit request body size
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		s.writeErrorResponse(w, "Failed to load workflow", http.StatusNotFound, err)
+		s.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest, err)
+		return
+	}
+
+	var req ExecuteWorkflowByIDRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.writeErrorResponse(w, "Failed to parse request", http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	number := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		if number, err = parseVersion(v); err != nil {
+			s.writeErrorResponse(w, "Invalid workflow version", http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	scope := s.scope(r)
+	payload, version, ok := s.bindSavedWorkflow(w, scope.workflows, id, number, req.Inputs)
+	if !ok {
 		return
 	}
 
-	// Execute workflow using the loaded data
-	eng, err := engine.NewWithConfig(workflow.Data, s.engineConfig)
+	// Execute workflow using the bound payload
+	eng, err := s.newEngine(scope, payload, id, version.Version)
 	if err != nil {
 		s.writeErrorResponse(w, "Failed to create engine", http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := eng.Execute()
+	release, ok := s.acquireQuota(w, scope)
+	if !ok {
+		return
+	}
+	defer release()
+
+	result, err := eng.ExecuteContext(traceContext(context.Background(), r))
 	if err != nil {
 		s.writeErrorResponse(w, "Workflow execution failed", http.StatusInternalServerError, err)
 		return
 	}
 
-	s.logger.WithField("id", id).WithField("name", workflow.Name).Info("Workflow executed by ID")
+	s.logger.WithField("id", id).WithField("name", version.Name).WithField("version", version.Version).Info("Workflow executed by ID")
 
 	// Write successful response
-	s.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"success":       true,
-		"workflow_id":   id,
-		"workflow_name": workflow.Name,
-		"results":       result,
+	s.writeJSONResponse(w, http.StatusOK, ExecuteWorkflowByIDResponse{
+		Success:         true,
+		WorkflowID:      id,
+		WorkflowName:    version.Name,
+		WorkflowVersion: version.Version,
+		Results:         result,
 	})
 }
+
+// bindSavedWorkflow loads version number of a saved workflow, or the latest
+// version when number is 0, from store and binds inputs to it. On failure
+// it writes the error response and returns false.
+func (s *Server) bindSavedWorkflow(w http.ResponseWriter, store workflow.WorkflowStore, id string, number int, inputs map[string]interface{}) ([]byte, *workflow.WorkflowVersion, bool) {
+	version, err := savedWorkflowVersion(store, id, number)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to load workflow", http.StatusNotFound, err)
+		return nil, nil, false
+	}
+
+	// Validate inputs and bind them before the engine sees the payload
+	payload, err := params.Bind(version.Data, inputs)
+	if err != nil {
+		s.writeInputErrors(w, err)
+		return nil, nil, false
+	}
+
+	return payload, version, true
+}
+
+// savedWorkflowVersion loads version number of a saved workflow, or the
+// latest version when number is 0
+func savedWorkflowVersion(store workflow.WorkflowStore, id string, number int) (*workflow.WorkflowVersion, error) {
+	if number != 0 {
+		return store.GetVersion(id, number)
+	}
+	head, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &workflow.WorkflowVersion{Version: head.Version, Name: head.Name, Data: head.Data}, nil
+}
+
+// storeSource adapts a tenant's workflow store to the engine's
+// WorkflowSource, so sub_workflow nodes run the tenant's saved workflows
+type storeSource struct {
+	store workflow.WorkflowStore
+}
+
+// Workflow returns the payload and version number of a saved workflow
+func (s storeSource) Workflow(id string, version int) ([]byte, int, error) {
+	v, err := savedWorkflowVersion(s.store, id, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v.Data, v.Version, nil
+}
+
+// writeInputErrors writes the 400 response for inputs params.Bind
+// rejected, listing each invalid field when it can
+func (s *Server) writeInputErrors(w http.ResponseWriter, err error) {
+	var verr *params.ValidationError
+	if errors.As(err, &verr) {
+		s.writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{
+			Success:     false,
+			Error:       "Invalid workflow inputs",
+			Details:     err.Error(),
+			InputErrors: verr.Errors,
+		})
+		return
+	}
+	s.writeErrorResponse(w, "Invalid workflow inputs", http.StatusBadRequest, err)
+}
@@ -0,0 +1,383 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestListWorkflows_Pagination(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	data := json.RawMessage(`{"nodes": [], "edges": []}`)
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("Report %d", i)
+		if i%2 == 0 {
+			name = fmt.Sprintf("Import %d", i)
+		}
+		if _, err := srv.defaultScope().workflows.Register(name, "", data); err != nil {
+			t.Fatalf("Failed to register workflow: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		wantNames      []string
+		wantTotal      int
+		wantMore       bool
+	}{
+		{
+			name:           "First page sorted by name",
+			query:          "?sort=name&limit=2",
+			expectedStatus: http.StatusOK,
+			wantNames:      []string{"Import 2", "Import 4"},
+			wantTotal:      5,
+			wantMore:       true,
+		},
+		{
+			name:           "Last page sorted by name",
+			query:          "?sort=name&limit=2&offset=4",
+			expectedStatus: http.StatusOK,
+			wantNames:      []string{"Report 5"},
+			wantTotal:      5,
+		},
+		{
+			name:           "Name search",
+			query:          "?q=report&sort=name&order=desc",
+			expectedStatus: http.StatusOK,
+			wantNames:      []string{"Report 5", "Report 3", "Report 1"},
+			wantTotal:      3,
+		},
+		{
+			name:           "Invalid limit",
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid sort field",
+			query:          "?sort=size",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/workflow/list"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			srv.handleListWorkflows(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			var resp ListWorkflowsResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				if resp.Success || resp.Error == "" {
+					t.Errorf("Expected failure with an error message, got %+v", resp)
+				}
+				return
+			}
+
+			if resp.Total != tt.wantTotal || resp.HasMore != tt.wantMore || resp.Count != len(tt.wantNames) {
+				t.Errorf("Got total=%d has_more=%v count=%d, want %d/%v/%d",
+					resp.Total, resp.HasMore, resp.Count, tt.wantTotal, tt.wantMore, len(tt.wantNames))
+			}
+			for i, want := range tt.wantNames {
+				if i >= len(resp.Workflows) || resp.Workflows[i].Name != want {
+					t.Errorf("Workflows[%d] mismatch, want %q (got %+v)", i, want, resp.Workflows)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_FileWorkflowStore(t *testing.T) {
+	config := DefaultConfig()
+	config.WorkflowStore = StoreFile
+	config.DataDir = t.TempDir()
+
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	id, err := srv.defaultScope().workflows.Register("Saved", "", json.RawMessage(`{"nodes": [], "edges": []}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	restarted, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to recreate server: %v", err)
+	}
+	if !restarted.defaultScope().workflows.Has(id) {
+		t.Error("Expected saved workflow to survive a server restart")
+	}
+
+	config.WorkflowStore = "sqlite"
+	if _, err := New(config, types.DefaultConfig()); err == nil {
+		t.Error("Expected error for unknown workflow store")
+	}
+}
+
+func TestExecuteWorkflowByID_Inputs(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	data := json.RawMessage(`{
+		"inputs": [
+			{"name": "a", "type": "number", "required": true, "node": "1"},
+			{"name": "b", "type": "number", "default": 5, "node": "2"}
+		],
+		"nodes": [
+			{"id": "1", "data": {"value": 0}},
+			{"id": "2", "data": {"value": 0}},
+			{"id": "3", "data": {"op": "add"}}
+		],
+		"edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]
+	}`)
+	id, err := srv.defaultScope().workflows.Register("Adder", "", data)
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		wantOutput     float64
+		wantInputError string
+	}{
+		{"Defaults applied", `{"inputs": {"a": 10}}`, http.StatusOK, 15, ""},
+		{"All inputs", `{"inputs": {"a": 10, "b": 1}}`, http.StatusOK, 11, ""},
+		{"Missing required", ``, http.StatusBadRequest, 0, "a"},
+		{"Wrong type", `{"inputs": {"a": "ten"}}`, http.StatusBadRequest, 0, "a"},
+		{"Undeclared input", `{"inputs": {"a": 1, "c": 2}}`, http.StatusBadRequest, 0, "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/workflow/execute/"+id, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			srv.handleExecuteWorkflowByID(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Results struct {
+					FinalOutput float64 `json:"final_output"`
+				} `json:"results"`
+				InputErrors []struct {
+					Input string `json:"input"`
+				} `json:"input_errors"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if tt.wantInputError != "" {
+				if len(resp.InputErrors) != 1 || resp.InputErrors[0].Input != tt.wantInputError {
+					t.Errorf("input_errors = %+v, want one error for %q", resp.InputErrors, tt.wantInputError)
+				}
+				return
+			}
+			if resp.Results.FinalOutput != tt.wantOutput {
+				t.Errorf("final_output = %v, want %v", resp.Results.FinalOutput, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestExecuteWorkflowByID_SubWorkflow(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	store := srv.defaultScope().workflows
+
+	adder, err := store.Register("Adder", "", json.RawMessage(`{
+		"inputs": [
+			{"name": "a", "type": "number", "required": true, "node": "1"},
+			{"name": "b", "type": "number", "required": true, "node": "2"}
+		],
+		"nodes": [
+			{"id": "1", "data": {"value": 0}},
+			{"id": "2", "data": {"value": 0}},
+			{"id": "3", "data": {"op": "add"}}
+		],
+		"edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+	outer, err := store.Register("Outer", "", json.RawMessage(`{
+		"nodes": [{"id": "sum", "type": "sub_workflow", "data": {"workflow_id": "`+adder+`", "inputs": {"a": 2, "b": 3}}}]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/workflow/execute/"+outer, nil)
+	w := httptest.NewRecorder()
+	srv.handleExecuteWorkflowByID(w, req)
+
+	var resp struct {
+		Results struct {
+			FinalOutput float64 `json:"final_output"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if w.Code != http.StatusOK || resp.Results.FinalOutput != 5 {
+		t.Errorf("Expected 200 with final_output 5, got %d with %v", w.Code, resp.Results.FinalOutput)
+	}
+}
+
+func TestSaveWorkflow_InvalidInputSchema(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	body := `{"name": "Bad", "data": {"inputs": [{"name": "x", "type": "decimal"}], "nodes": [], "edges": []}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/workflow/save", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	srv.handleSaveWorkflow(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if srv.defaultScope().workflows.Count() != 0 {
+		t.Error("Workflow with an invalid input schema should not be saved")
+	}
+}
+
+func TestExecuteWorkflow_Traceparent(t *testing.T) {
+	var traceparent string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+
+	engineConfig := types.DefaultConfig()
+	engineConfig.AllowHTTP = true
+	engineConfig.AllowLocalhost = true
+	srv, err := New(DefaultConfig(), engineConfig)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	body := fmt.Sprintf(`{"nodes": [{"id": "call", "type": "http", "data": {"url": %q}}]}`, target.URL)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/workflow/execute", strings.NewReader(body))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	srv.handleExecuteWorkflow(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(traceparent, "00-"+traceID+"-") {
+		t.Errorf("HTTP node sent traceparent %q, want trace %s", traceparent, traceID)
+	}
+}
+
+func TestPlanWorkflow(t *testing.T) {
+	requests := 0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer target.Close()
+
+	engineConfig := types.DefaultConfig()
+	engineConfig.AllowHTTP = true
+	engineConfig.AllowLocalhost = true
+	srv, err := New(DefaultConfig(), engineConfig)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		wantRun        []string
+		wantRuntime    []string
+	}{
+		{
+			name: "Request depending on a response",
+			body: fmt.Sprintf(`{
+				"nodes": [
+					{"id": "fetch", "type": "http", "data": {"url": %q}},
+					{"id": "check", "type": "condition", "data": {"condition": ">0"}},
+					{"id": "post", "type": "http", "data": {"url": %q}}
+				],
+				"edges": [
+					{"source": "fetch", "target": "check"},
+					{"source": "check", "target": "post", "sourceHandle": "true"}
+				]
+			}`, target.URL+"/orders", target.URL+"/notify"),
+			expectedStatus: http.StatusOK,
+			wantRun:        []string{"fetch", "check"},
+			wantRuntime:    []string{"post"},
+		},
+		{
+			name:           "Invalid workflow",
+			body:           `{"nodes": [{"id": "1", "data": {"value": 1}}], "edges": [{"source": "1", "target": "2"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Malformed payload",
+			body:           `{"nodes": [`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/workflow/plan", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			srv.handlePlanWorkflow(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp PlanWorkflowResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if fmt.Sprint(resp.Plan.Run) != fmt.Sprint(tt.wantRun) || fmt.Sprint(resp.Plan.Runtime) != fmt.Sprint(tt.wantRuntime) {
+				t.Errorf("Plan runs %v and decides %v at runtime, want %v and %v", resp.Plan.Run, resp.Plan.Runtime, tt.wantRun, tt.wantRuntime)
+			}
+		})
+	}
+
+	if requests != 0 {
+		t.Errorf("Planning sent %d HTTP requests, want none", requests)
+	}
+}
@@ -0,0 +1,262 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	workflow "github.com/yesoreyeram/thaiyyal/backend"
+)
+
+// ListVersionsResponse represents the response from listing workflow versions
+type ListVersionsResponse struct {
+	Success  bool                       `json:"success"`
+	ID       string                     `json:"id,omitempty"`
+	Versions []workflow.WorkflowVersion `json:"versions,omitempty"`
+	Count    int                        `json:"count"`
+	Error    string                     `json:"error,omitempty"`
+}
+
+// GetVersionResponse represents the response from fetching one workflow version
+type GetVersionResponse struct {
+	Success bool                      `json:"success"`
+	ID      string                    `json:"id,omitempty"`
+	Version *workflow.WorkflowVersion `json:"version,omitempty"`
+	Error   string                    `json:"error,omitempty"`
+}
+
+// DiffWorkflowResponse represents the response from diffing two versions
+type DiffWorkflowResponse struct {
+	Success bool                   `json:"success"`
+	ID      string                 `json:"id,omitempty"`
+	Diff    *workflow.WorkflowDiff `json:"diff,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// RollbackWorkflowRequest represents the request to roll a workflow back.
+// Author is ignored when authentication is enabled.
+type RollbackWorkflowRequest struct {
+	Version int    `json:"version"`
+	Author  string `json:"author,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// handleWorkflowVersions handles listing versions and fetching one version
+//
+// Path format: /api/v1/workflow/versions/{id} or /api/v1/workflow/versions/{id}/{version}
+func (s *Server) handleWorkflowVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/workflow/versions/"), "/")
+	id, versionPart, hasVersion := strings.Cut(path, "/")
+	id = strings.TrimSpace(id)
+
+	if id == "" {
+		s.writeJSONResponse(w, http.StatusBadRequest, ListVersionsResponse{
+			Success: false,
+			Error:   "Workflow ID is required",
+		})
+		return
+	}
+
+	if !hasVersion {
+		versions, err := s.scope(r).workflows.Versions(id)
+		if err != nil {
+			s.writeJSONResponse(w, workflowErrorStatus(err), ListVersionsResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, ListVersionsResponse{
+			Success:  true,
+			ID:       id,
+			Versions: versions,
+			Count:    len(versions),
+		})
+		return
+	}
+
+	number, err := parseVersion(versionPart)
+	if err != nil {
+		s.writeJSONResponse(w, http.StatusBadRequest, GetVersionResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	version, err := s.scope(r).workflows.GetVersion(id, number)
+	if err != nil {
+		s.writeJSONResponse(w, workflowErrorStatus(err), GetVersionResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, GetVersionResponse{
+		Success: true,
+		ID:      id,
+		Version: version,
+	})
+}
+
+// handleDiffWorkflow handles diffing two versions of a workflow.
+//
+// Path format: /api/v1/workflow/diff/{id}?from=N&to=M
+//
+// "to" defaults to the latest version and "from" to the version before "to".
+func (s *Server) handleDiffWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/v1/workflow/diff/"))
+	if id == "" {
+		s.writeJSONResponse(w, http.StatusBadRequest, DiffWorkflowResponse{
+			Success: false,
+			Error:   "Workflow ID is required",
+		})
+		return
+	}
+
+	head, err := s.scope(r).workflows.Get(id)
+	if err != nil {
+		s.writeJSONResponse(w, workflowErrorStatus(err), DiffWorkflowResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	to := head.Version
+	if v := query.Get("to"); v != "" {
+		if to, err = parseVersion(v); err != nil {
+			s.writeJSONResponse(w, http.StatusBadRequest, DiffWorkflowResponse{Success: false, Error: err.Error()})
+			return
+		}
+	}
+	from := max(to-1, 1)
+	if v := query.Get("from"); v != "" {
+		if from, err = parseVersion(v); err != nil {
+			s.writeJSONResponse(w, http.StatusBadRequest, DiffWorkflowResponse{Success: false, Error: err.Error()})
+			return
+		}
+	}
+
+	diff, err := diffVersions(s.scope(r).workflows, id, from, to)
+	if err != nil {
+		s.writeJSONResponse(w, workflowErrorStatus(err), DiffWorkflowResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, DiffWorkflowResponse{
+		Success: true,
+		ID:      id,
+		Diff:    diff,
+	})
+}
+
+// diffVersions loads and compares two versions of a workflow in store
+func diffVersions(store workflow.WorkflowStore, id string, from, to int) (*workflow.WorkflowDiff, error) {
+	before, err := store.GetVersion(id, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := store.GetVersion(id, to)
+	if err != nil {
+		return nil, err
+	}
+	return workflow.DiffVersions(before, after)
+}
+
+// handleRollbackWorkflow handles rolling a workflow back to an earlier
+// version. The rollback is saved as a new version.
+//
+// Path format: /api/v1/workflow/rollback/{id}
+func (s *Server) handleRollbackWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/v1/workflow/rollback/"))
+	if id == "" {
+		s.writeJSONResponse(w, http.StatusBadRequest, SaveWorkflowResponse{
+			Success: false,
+			Error:   "Workflow ID is required",
+		})
+		return
+	}
+
+	// Limit request body size
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest, err)
+		return
+	}
+
+	var req RollbackWorkflowRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.writeErrorResponse(w, "Failed to parse request", http.StatusBadRequest, err)
+		return
+	}
+	if req.Version < 1 {
+		s.writeJSONResponse(w, http.StatusBadRequest, SaveWorkflowResponse{
+			Success: false,
+			Error:   "version must be a positive integer",
+		})
+		return
+	}
+
+	saved, err := s.scope(r).workflows.Rollback(id, req.Version, workflow.VersionInfo{Author: s.author(r, req.Author), Message: req.Message})
+	if err != nil {
+		s.writeJSONResponse(w, workflowErrorStatus(err), SaveWorkflowResponse{
+			Success: false,
+			Error:   "Failed to roll back workflow: " + err.Error(),
+		})
+		return
+	}
+
+	s.logger.WithField("id", id).WithField("from_version", req.Version).WithField("version", saved.Version).Info("Workflow rolled back")
+
+	s.writeJSONResponse(w, http.StatusOK, SaveWorkflowResponse{
+		Success: true,
+		ID:      id,
+		Version: saved.Version,
+		Message: fmt.Sprintf("Workflow rolled back to version %d", req.Version),
+	})
+}
+
+// parseVersion parses a version number from a path or query parameter
+func parseVersion(value string) (int, error) {
+	version, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("version must be a positive integer, got %q", value)
+	}
+	return version, nil
+}
+
+// workflowErrorStatus maps a workflow store error to an HTTP status
+func workflowErrorStatus(err error) int {
+	if errors.Is(err, workflow.ErrWorkflowNotFound) || errors.Is(err, workflow.ErrVersionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+const (
+	addWorkflow      = `{"nodes": [{"id": "1", "data": {"value": 10}}, {"id": "2", "data": {"value": 5}}, {"id": "3", "data": {"op": "add"}}], "edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]}`
+	multiplyWorkflow = `{"nodes": [{"id": "1", "data": {"value": 10}}, {"id": "2", "data": {"value": 5}}, {"id": "3", "data": {"op": "multiply"}}], "edges": [{"source": "1", "target": "3"}, {"source": "2", "target": "3"}]}`
+)
+
+func TestWorkflowVersioningEndpoints(t *testing.T) {
+	srv, err := New(DefaultConfig(), types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.httpServer.Handler
+
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatalf("Failed to encode request: %v", err)
+			}
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, &buf))
+		return w
+	}
+
+	// Save version 1, then version 2 under the same ID
+	w := do(http.MethodPost, "/api/v1/workflow/save", SaveWorkflowRequest{Name: "Calc", Data: json.RawMessage(addWorkflow)})
+	var saved SaveWorkflowResponse
+	_ = json.NewDecoder(w.Body).Decode(&saved)
+	if w.Code != http.StatusCreated || saved.Version != 1 {
+		t.Fatalf("Save returned %d %+v, want 201 and version 1", w.Code, saved)
+	}
+	id := saved.ID
+
+	w = do(http.MethodPost, "/api/v1/workflow/save", SaveWorkflowRequest{ID: id, Name: "Calc", Data: json.RawMessage(multiplyWorkflow), Author: "ana", Message: "multiply"})
+	_ = json.NewDecoder(w.Body).Decode(&saved)
+	if w.Code != http.StatusOK || saved.Version != 2 {
+		t.Fatalf("Save new version returned %d %+v, want 200 and version 2", w.Code, saved)
+	}
+
+	// List and fetch versions
+	w = do(http.MethodGet, "/api/v1/workflow/versions/"+id, nil)
+	var list ListVersionsResponse
+	_ = json.NewDecoder(w.Body).Decode(&list)
+	if w.Code != http.StatusOK || list.Count != 2 || list.Versions[0].Author != "ana" {
+		t.Errorf("List versions returned %d %+v", w.Code, list)
+	}
+
+	w = do(http.MethodGet, "/api/v1/workflow/versions/"+id+"/1", nil)
+	var got GetVersionResponse
+	_ = json.NewDecoder(w.Body).Decode(&got)
+	if w.Code != http.StatusOK || got.Version == nil || got.Version.Version != 1 {
+		t.Errorf("Get version returned %d %+v", w.Code, got)
+	}
+
+	if w = do(http.MethodGet, "/api/v1/workflow/versions/"+id+"/7", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Missing version returned %d, want 404", w.Code)
+	}
+
+	// Diff defaults to the latest version against the one before it
+	w = do(http.MethodGet, "/api/v1/workflow/diff/"+id, nil)
+	var diff DiffWorkflowResponse
+	_ = json.NewDecoder(w.Body).Decode(&diff)
+	if w.Code != http.StatusOK || diff.Diff == nil || diff.Diff.FromVersion != 1 || diff.Diff.ToVersion != 2 {
+		t.Fatalf("Diff returned %d %+v", w.Code, diff)
+	}
+	if len(diff.Diff.Nodes.Changed) != 1 || diff.Diff.Nodes.Changed[0].ID != "3" {
+		t.Errorf("Diff nodes = %+v, want node 3 changed", diff.Diff.Nodes)
+	}
+
+	// Executions can be pinned to a version
+	results := map[string]float64{"": 50, "?version=1": 15, "?version=2": 50}
+	for query, want := range results {
+		w = do(http.MethodPost, "/api/v1/workflow/execute/"+id+query, nil)
+		var resp struct {
+			Version int `json:"workflow_version"`
+			Results struct {
+				FinalOutput float64 `json:"final_output"`
+			} `json:"results"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusOK || resp.Results.FinalOutput != want {
+			t.Errorf("Execute%s returned %d output %v, want %v", query, w.Code, resp.Results.FinalOutput, want)
+		}
+	}
+	if w = do(http.MethodPost, "/api/v1/workflow/execute/"+id+"?version=x", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Execute with bad version returned %d, want 400", w.Code)
+	}
+
+	// Roll back to version 1; the rollback becomes version 3
+	w = do(http.MethodPost, "/api/v1/workflow/rollback/"+id, RollbackWorkflowRequest{Version: 1, Author: "bo"})
+	_ = json.NewDecoder(w.Body).Decode(&saved)
+	if w.Code != http.StatusOK || saved.Version != 3 {
+		t.Fatalf("Rollback returned %d %+v, want 200 and version 3", w.Code, saved)
+	}
+	head, err := srv.defaultScope().workflows.Get(id)
+	if err != nil || !bytes.Contains(head.Data, []byte(`"op":"add"`)) {
+		t.Errorf("Head after rollback = %+v (err %v), want version 1 content", head, err)
+	}
+
+	if w = do(http.MethodPost, "/api/v1/workflow/rollback/"+id, RollbackWorkflowRequest{Version: 0}); w.Code != http.StatusBadRequest {
+		t.Errorf("Rollback to version 0 returned %d, want 400", w.Code)
+	}
+	if w = do(http.MethodPost, "/api/v1/workflow/save", SaveWorkflowRequest{ID: "missing", Name: "x", Data: json.RawMessage(`{}`)}); w.Code != http.StatusNotFound {
+		t.Errorf("Save to missing ID returned %d, want 404", w.Code)
+	}
+}
@@ -3,9 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 
 	// Import pprof for profiling endpoints
 	// WARNING: In production, consider restricting access to /debug/pprof/* endpoints
@@ -15,13 +18,24 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 
 	workflow "github.com/yesoreyeram/thaiyyal/backend"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/events"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/health"
-	"github.com/yesoreyeram/thaiyyal/backend/pkg/httpclient"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/history"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/logging"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/openapi"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/runner"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/schedule"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/telemetry"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/tenant"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/trigger"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -44,30 +58,182 @@ type Config struct {
 
 	// EnableCORS enables CORS headers
 	EnableCORS bool
+
+	// CORSAllowedOrigins lists the origins browsers may call the API from.
+	// "*" allows any origin and "https://*.example.com" any subdomain.
+	CORSAllowedOrigins []string
+
+	// Auth selects how API callers are authenticated. With no
+	// authenticator configured every API route is open.
+	Auth auth.Config
+
+	// WorkflowStore selects where saved workflows live: StoreMemory
+	// (lost on restart) or StoreFile (JSON files under DataDir)
+	WorkflowStore string
+
+	// DataDir is the directory used by the file workflow store
+	DataDir string
+
+	// ExecutionWorkers is the number of asynchronous executions that run
+	// concurrently
+	ExecutionWorkers int
+
+	// ExecutionQueueSize is the number of asynchronous executions that may
+	// wait for a worker before new submissions are rejected
+	ExecutionQueueSize int
+
+	// ExecutionRetention is how long finished asynchronous executions and
+	// their results are kept
+	ExecutionRetention time.Duration
+
+	// ExecutionEventBuffer is the number of events kept per execution for
+	// replay to late event stream subscribers
+	ExecutionEventBuffer int
+
+	// HistoryStore selects where execution history lives: StoreMemory or
+	// StoreFile (one JSON file per execution under HistoryDir)
+	HistoryStore string
+
+	// HistoryDir is the directory used by the file history store
+	HistoryDir string
+
+	// HistoryRetention removes execution records older than this; zero
+	// keeps them indefinitely
+	HistoryRetention time.Duration
+
+	// HistoryMaxRecords keeps only this many of the most recent execution
+	// records; zero means no limit
+	HistoryMaxRecords int
+
+	// HistoryCaptureOutputs stores each node's output in the execution
+	// history, truncated to HistoryMaxOutputBytes
+	HistoryCaptureOutputs bool
+
+	// HistoryMaxOutputBytes caps each stored node output
+	HistoryMaxOutputBytes int
+
+	// ScheduleStore selects where workflow schedules live: StoreMemory or
+	// StoreFile (one JSON file per schedule under ScheduleDir)
+	ScheduleStore string
+
+	// ScheduleDir is the directory used by the file schedule store
+	ScheduleDir string
+
+	// ScheduleMisfireThreshold is how late a scheduled run may start
+	// before it counts as missed
+	ScheduleMisfireThreshold time.Duration
+
+	// ScheduleMaxCatchUp is the most missed runs a catch-up schedule makes
+	// up for
+	ScheduleMaxCatchUp int
+
+	// ScheduleClock is the scheduler's time source; nil uses the wall
+	// clock. Tests set a fake one.
+	ScheduleClock schedule.Clock
+
+	// TriggerStore selects where webhook triggers live: StoreMemory or
+	// StoreFile (one JSON file per trigger under TriggerDir)
+	TriggerStore string
+
+	// TriggerDir is the directory used by the file trigger store. Trigger
+	// files hold signing secrets and are readable by the owner only.
+	TriggerDir string
+
+	// TenantQuotas limits the executions and workflow size of each tenant,
+	// on top of the engine config. The zero value sets no quotas.
+	TenantQuotas tenant.Quotas
+
+	// EventCaptureInputs adds each node's resolved inputs to execution
+	// events and event streams
+	EventCaptureInputs bool
+
+	// EventMaxBytes truncates each node input and output that execution
+	// events carry; zero keeps them whole. Execution history applies
+	// HistoryMaxOutputBytes on top.
+	EventMaxBytes int
+
+	// RedactKeys are regular expressions for the object keys whose values
+	// are masked in execution events, history, logs and spans; empty uses
+	// observer.DefaultSensitiveKeys. The credentials of a tenant's HTTP
+	// clients are always masked in its executions.
+	RedactKeys []string
+
+	// ExecutionProfiling adds an execution profile, with per-node timings
+	// and the critical path, to execution results and serves it at
+	// /api/v1/executions/{id}/profile
+	ExecutionProfiling bool
+
+	// MaxDebugSessionTimeout caps how long a debugged execution may stay
+	// paused waiting for a command, holding its worker and quota slot.
+	// Zero uses engine.DefaultDebugSessionTimeout.
+	MaxDebugSessionTimeout time.Duration
+
+	// Telemetry configures metrics and trace export. Executions join the
+	// trace of an incoming traceparent header when tracing is enabled.
+	Telemetry telemetry.Config
 }
 
+// Workflow store backends accepted by Config.WorkflowStore
+const (
+	StoreMemory = "memory"
+	StoreFile   = "file"
+)
+
 // DefaultConfig returns default server configuration
 func DefaultConfig() Config {
 	return Config{
-		Address:            ":8080",
-		ReadTimeout:        30 * time.Second,
-		WriteTimeout:       30 * time.Second,
-		ShutdownTimeout:    10 * time.Second,
-		MaxRequestBodySize: 10 * 1024 * 1024, // 10MB
-		EnableCORS:         true,
+		Address:                  ":8080",
+		ReadTimeout:              30 * time.Second,
+		WriteTimeout:             30 * time.Second,
+		ShutdownTimeout:          10 * time.Second,
+		MaxRequestBodySize:       10 * 1024 * 1024, // 10MB
+		EnableCORS:               true,
+		CORSAllowedOrigins:       []string{"*"},
+		WorkflowStore:            StoreMemory,
+		DataDir:                  "data/workflows",
+		ExecutionWorkers:         4,
+		ExecutionQueueSize:       100,
+		ExecutionRetention:       time.Hour,
+		ExecutionEventBuffer:     events.DefaultBufferSize,
+		HistoryStore:             StoreMemory,
+		HistoryDir:               "data/executions",
+		HistoryRetention:         7 * 24 * time.Hour,
+		HistoryMaxRecords:        10000,
+		HistoryCaptureOutputs:    true,
+		HistoryMaxOutputBytes:    history.DefaultMaxOutputBytes,
+		ScheduleStore:            StoreMemory,
+		ScheduleDir:              "data/schedules",
+		ScheduleMisfireThreshold: time.Minute,
+		ScheduleMaxCatchUp:       10,
+		TriggerStore:             StoreMemory,
+		TriggerDir:               "data/triggers",
+		ExecutionProfiling:       true,
+		MaxDebugSessionTimeout:   engine.DefaultDebugSessionTimeout,
+		Telemetry:                telemetry.DefaultConfig(),
 	}
 }
 
+// serviceVersion is the version reported by health checks and the OpenAPI
+// document
+const serviceVersion = "0.1.0"
+
 // Server is the HTTP API server
 type Server struct {
-	config             Config
-	httpServer         *http.Server
-	healthChecker      *health.Checker
-	telemetryProvider  *telemetry.Provider
-	logger             *logging.Logger
-	engineConfig       types.Config
-	httpClientRegistry *httpclient.Registry
-	workflowRegistry   *workflow.WorkflowRegistry
+	config            Config
+	httpServer        *http.Server
+	healthChecker     *health.Checker
+	telemetryProvider *telemetry.Provider
+	logger            *logging.Logger
+	engineConfig      types.Config
+	tenants           *tenant.Registry[*tenantScope]
+	quotas            *tenant.Limiter
+	runner            *runner.Runner
+	history           history.Store
+	authenticator     auth.Authenticator
+	scheduler         *schedule.Scheduler
+	triggers          *trigger.Registry
+	openapi           *openapi.Document
+	redactor          *observer.Redactor
 }
 
 // New creates a new server instance
@@ -76,14 +242,13 @@ func New(config Config, engineConfig types.Config) (*Server, error) {
 	logger := logging.New(logging.DefaultConfig())
 
 	// Create telemetry provider
-	telemetryConfig := telemetry.DefaultConfig()
-	telemetryProvider, err := telemetry.NewProvider(context.Background(), telemetryConfig)
+	telemetryProvider, err := telemetry.NewProvider(context.Background(), config.Telemetry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create telemetry provider: %w", err)
 	}
 
 	// Create health checker
-	healthChecker := health.NewChecker("thaiyyal-workflow-engine", "0.1.0")
+	healthChecker := health.NewChecker("thaiyyal-workflow-engine", serviceVersion)
 
 	// Register basic health checks
 	healthChecker.RegisterCheck("engine", func(ctx context.Context) error {
@@ -91,21 +256,70 @@ func New(config Config, engineConfig types.Config) (*Server, error) {
 		return nil
 	}, 5*time.Second, true)
 
-	// Create HTTP client registry
-	httpClientRegistry := httpclient.NewRegistry()
+	// Create per-tenant workflow stores and HTTP client registries. The
+	// default tenant is opened up front so a bad store config fails here.
+	tenants := tenant.NewRegistry(func(id string) (*tenantScope, error) {
+		return newTenantScope(config, id)
+	})
+	if _, err := tenants.Get(tenant.Default); err != nil {
+		return nil, err
+	}
 
-	// Create workflow registry
-	workflowRegistry := workflow.NewWorkflowRegistry()
+	// Create execution history store
+	historyStore, err := newHistoryStore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the redactor for execution data
+	redactor, err := observer.NewRedactor(config.RedactKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create authenticator
+	authenticator, err := auth.New(config.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure authentication: %w", err)
+	}
+	if authenticator == nil {
+		logger.Warn("authentication disabled: all API routes are open")
+	}
 
 	server := &Server{
-		config:             config,
-		healthChecker:      healthChecker,
-		telemetryProvider:  telemetryProvider,
-		logger:             logger,
-		engineConfig:       engineConfig,
-		httpClientRegistry: httpClientRegistry,
-		workflowRegistry:   workflowRegistry,
+		config:            config,
+		healthChecker:     healthChecker,
+		telemetryProvider: telemetryProvider,
+		logger:            logger,
+		engineConfig:      engineConfig,
+		tenants:           tenants,
+		quotas:            tenant.NewLimiter(config.TenantQuotas),
+		history:           historyStore,
+		authenticator:     authenticator,
+		openapi:           buildOpenAPI(authenticator != nil),
+		redactor:          redactor,
+		runner: runner.New(runner.Config{
+			Workers:     config.ExecutionWorkers,
+			QueueSize:   config.ExecutionQueueSize,
+			Retention:   config.ExecutionRetention,
+			EventBuffer: config.ExecutionEventBuffer,
+		}),
+	}
+
+	// Create workflow scheduler; its runs go through the server's engine
+	// path, so it is created once the server exists
+	scheduler, err := newScheduler(config, server.runScheduled, logger)
+	if err != nil {
+		return nil, err
 	}
+	server.scheduler = scheduler
+
+	// Create webhook trigger registry
+	triggers, err := newTriggerRegistry(config)
+	if err != nil {
+		return nil, err
+	}
+	server.triggers = triggers
 
 	// Create HTTP server
 	mux := http.NewServeMux()
@@ -121,6 +335,171 @@ func New(config Config, engineConfig types.Config) (*Server, error) {
 	return server, nil
 }
 
+// newWorkflowStore opens the workflow store selected in the config
+func newWorkflowStore(config Config) (workflow.WorkflowStore, error) {
+	switch config.WorkflowStore {
+	case "", StoreMemory:
+		return workflow.NewWorkflowRegistry(), nil
+	case StoreFile:
+		store, err := workflow.NewFileWorkflowStore(config.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open workflow store: %w", err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown workflow store %q (expected %q or %q)", config.WorkflowStore, StoreMemory, StoreFile)
+	}
+}
+
+// newScheduler opens the schedule store selected in the config and starts
+// a scheduler running workflows with run
+func newScheduler(config Config, run schedule.RunFunc, logger *logging.Logger) (*schedule.Scheduler, error) {
+	var store schedule.Store
+	switch config.ScheduleStore {
+	case "", StoreMemory:
+		store = schedule.NewMemoryStore()
+	case StoreFile:
+		fileStore, err := schedule.NewFileStore(config.ScheduleDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open schedule store: %w", err)
+		}
+		store = fileStore
+	default:
+		return nil, fmt.Errorf("unknown schedule store %q (expected %q or %q)", config.ScheduleStore, StoreMemory, StoreFile)
+	}
+
+	scheduler, err := schedule.New(schedule.Config{
+		Run:              run,
+		Store:            store,
+		Clock:            config.ScheduleClock,
+		MisfireThreshold: config.ScheduleMisfireThreshold,
+		MaxCatchUp:       config.ScheduleMaxCatchUp,
+		OnError: func(err error) {
+			logger.WithError(err).Error("failed to save schedule")
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start scheduler: %w", err)
+	}
+	return scheduler, nil
+}
+
+// newTriggerRegistry opens the trigger store selected in the config
+func newTriggerRegistry(config Config) (*trigger.Registry, error) {
+	var store trigger.Store
+	switch config.TriggerStore {
+	case "", StoreMemory:
+		store = trigger.NewMemoryStore()
+	case StoreFile:
+		fileStore, err := trigger.NewFileStore(config.TriggerDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trigger store: %w", err)
+		}
+		store = fileStore
+	default:
+		return nil, fmt.Errorf("unknown trigger store %q (expected %q or %q)", config.TriggerStore, StoreMemory, StoreFile)
+	}
+
+	registry, err := trigger.NewRegistry(store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load triggers: %w", err)
+	}
+	return registry, nil
+}
+
+// newHistoryStore opens the execution history store selected in the config
+func newHistoryStore(config Config) (history.Store, error) {
+	retention := history.Retention{
+		MaxAge:     config.HistoryRetention,
+		MaxRecords: config.HistoryMaxRecords,
+	}
+	switch config.HistoryStore {
+	case "", StoreMemory:
+		return history.NewMemoryStore(retention), nil
+	case StoreFile:
+		store, err := history.NewFileStore(config.HistoryDir, retention)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open history store: %w", err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown history store %q (expected %q or %q)", config.HistoryStore, StoreMemory, StoreFile)
+	}
+}
+
+// newEngine creates an engine for payload that runs for the tenant of
+// scope, with the tenant's HTTP clients and quota-adjusted limits, and the
+// observers every execution gets: telemetry and execution history. Node
+// data in events is masked with the server's redaction keys and the
+// tenant's HTTP client credentials.
+// workflowID and version identify a saved workflow and are empty for
+// ad-hoc executions.
+func (s *Server) newEngine(scope *tenantScope, payload []byte, workflowID string, version int) (*engine.Engine, error) {
+	eng, err := engine.NewWithConfig(payload, s.tenantEngineConfig(scope))
+	if err != nil {
+		return nil, err
+	}
+	s.setupEngine(eng, scope, workflowID, version)
+	return eng, nil
+}
+
+// resumeEngine restores the execution saved in snapshot for the tenant of
+// scope, set up like newEngine. The resumed execution gets a new ID and
+// the tenant's current limits rather than those in the snapshot.
+func (s *Server) resumeEngine(scope *tenantScope, snapshot *engine.Snapshot) (*engine.Engine, error) {
+	snapshot.ExecutionID = ""
+	snapshot.TenantID = scope.id
+	snapshot.Config = s.tenantEngineConfig(scope)
+	eng, err := engine.LoadSnapshot(snapshot, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.setupEngine(eng, scope, snapshot.WorkflowID, 0)
+	return eng, nil
+}
+
+// setupEngine gives eng the tenant settings and observers of newEngine
+func (s *Server) setupEngine(eng *engine.Engine, scope *tenantScope, workflowID string, version int) {
+	eng.SetTenantID(scope.id)
+	eng.SetHTTPClientRegistry(scope.httpClients)
+	eng.SetTracerProvider(s.telemetryProvider.TracerProvider())
+	eng.SetMetrics(s.telemetryProvider.ForTenant(scope.id))
+	eng.SetEventCapture(&observer.Capture{
+		Inputs:   s.config.EventCaptureInputs,
+		Outputs:  true,
+		MaxBytes: s.config.EventMaxBytes,
+		Redactor: s.redactor.WithSecrets(scope.httpClients.Secrets()...),
+	})
+	eng.SetWorkflowSource(storeSource{store: scope.workflows})
+	eng.SetProfiling(s.config.ExecutionProfiling)
+	if workflowID != "" {
+		eng.SetWorkflowID(workflowID)
+	}
+
+	eng.RegisterObserver(telemetry.NewTelemetryObserver(s.telemetryProvider))
+	eng.RegisterObserver(history.NewRecorder(s.history, history.Options{
+		WorkflowID:      workflowID,
+		WorkflowVersion: version,
+		CaptureOutputs:  s.config.HistoryCaptureOutputs,
+		MaxOutputBytes:  s.config.HistoryMaxOutputBytes,
+		OnError: func(err error) {
+			s.logger.WithError(err).Error("failed to record execution history")
+		},
+	}))
+}
+
+// traceContext returns ctx carrying the trace context of r's traceparent
+// header, so an execution joins the caller's trace
+func traceContext(ctx context.Context, r *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+}
+
+// tenantEngineConfig returns the engine config tightened to the quota of
+// scope's tenant
+func (s *Server) tenantEngineConfig(scope *tenantScope) types.Config {
+	return s.quotas.Quota(scope.id).Apply(s.engineConfig)
+}
+
 // registerRoutes registers all HTTP routes
 func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// Health endpoints
@@ -132,19 +511,71 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.Handle("/metrics", promhttp.Handler())
 
 	// API endpoints
-	mux.HandleFunc("/api/v1/workflow/execute", s.handleExecuteWorkflow)
-	mux.HandleFunc("/api/v1/workflow/validate", s.handleValidateWorkflow)
+	mux.HandleFunc("/api/v1/workflow/execute", s.require(auth.PermissionExecute, s.handleExecuteWorkflow))
+	mux.HandleFunc("/api/v1/workflow/validate", s.require(auth.PermissionRead, s.handleValidateWorkflow))
+	mux.HandleFunc("/api/v1/workflow/plan", s.require(auth.PermissionRead, s.handlePlanWorkflow))
 
 	// Workflow storage endpoints
-	mux.HandleFunc("/api/v1/workflow/save", s.handleSaveWorkflow)
-	mux.HandleFunc("/api/v1/workflow/list", s.handleListWorkflows)
-	mux.HandleFunc("/api/v1/workflow/load/", s.handleLoadWorkflow)
-	mux.HandleFunc("/api/v1/workflow/delete/", s.handleDeleteWorkflow)
-	mux.HandleFunc("/api/v1/workflow/execute/", s.handleExecuteWorkflowByID)
+	mux.HandleFunc("/api/v1/workflow/save", s.require(auth.PermissionWrite, s.handleSaveWorkflow))
+	mux.HandleFunc("/api/v1/workflow/list", s.require(auth.PermissionRead, s.handleListWorkflows))
+	mux.HandleFunc("/api/v1/workflow/load/", s.require(auth.PermissionRead, s.handleLoadWorkflow))
+	mux.HandleFunc("/api/v1/workflow/delete/", s.require(auth.PermissionWrite, s.handleDeleteWorkflow))
+	mux.HandleFunc("/api/v1/workflow/execute/", s.require(auth.PermissionExecute, s.handleExecuteWorkflowByID))
+	mux.HandleFunc("/api/v1/workflow/versions/", s.require(auth.PermissionRead, s.handleWorkflowVersions))
+	mux.HandleFunc("/api/v1/workflow/diff/", s.require(auth.PermissionRead, s.handleDiffWorkflow))
+	mux.HandleFunc("/api/v1/workflow/rollback/", s.require(auth.PermissionWrite, s.handleRollbackWorkflow))
+	mux.HandleFunc("/api/v1/workflow/export/", s.require(auth.PermissionRead, s.handleExportWorkflow))
+	mux.HandleFunc("/api/v1/workflow/import", s.require(auth.PermissionWrite, s.handleImportWorkflow))
+
+	// Execution history endpoints
+	mux.HandleFunc("/api/v1/history", s.require(auth.PermissionRead, s.handleListHistory))
+	mux.HandleFunc("/api/v1/history/", s.require(auth.PermissionRead, s.handleGetHistory))
+
+	// Asynchronous execution endpoints
+	mux.HandleFunc("/api/v1/executions", s.requireMethods(map[string]auth.Permission{
+		http.MethodGet:  auth.PermissionRead,
+		http.MethodPost: auth.PermissionExecute,
+	}, s.handleExecutions))
+	mux.HandleFunc("/api/v1/executions/", s.requireMethods(map[string]auth.Permission{
+		http.MethodGet:    auth.PermissionRead,
+		http.MethodPost:   auth.PermissionExecute,
+		http.MethodDelete: auth.PermissionExecute,
+	}, s.handleExecution))
+
+	// Schedule endpoints
+	scheduleMethods := map[string]auth.Permission{
+		http.MethodGet:    auth.PermissionRead,
+		http.MethodPost:   auth.PermissionExecute,
+		http.MethodPut:    auth.PermissionExecute,
+		http.MethodDelete: auth.PermissionExecute,
+	}
+	mux.HandleFunc("/api/v1/schedules", s.requireMethods(scheduleMethods, s.handleSchedules))
+	mux.HandleFunc("/api/v1/schedules/", s.requireMethods(scheduleMethods, s.handleSchedule))
+
+	// Webhook trigger endpoints. Triggers expose workflows without API
+	// credentials, so managing them needs the admin permission.
+	triggerMethods := map[string]auth.Permission{
+		http.MethodGet:    auth.PermissionRead,
+		http.MethodPost:   auth.PermissionAdmin,
+		http.MethodPut:    auth.PermissionAdmin,
+		http.MethodDelete: auth.PermissionAdmin,
+	}
+	mux.HandleFunc("/api/v1/triggers", s.requireMethods(triggerMethods, s.handleTriggers))
+	mux.HandleFunc("/api/v1/triggers/", s.requireMethods(triggerMethods, s.handleTrigger))
+
+	// Webhooks; requests authenticate with trigger signatures instead of
+	// API credentials
+	mux.HandleFunc(hooksPath, s.handleHook)
+
+	// Tenant endpoint
+	mux.HandleFunc("/api/v1/tenant", s.require(auth.PermissionRead, s.handleGetTenant))
 
 	// HTTP Client management endpoints
-	mux.HandleFunc("/api/v1/httpclient/register", s.handleRegisterHTTPClient)
-	mux.HandleFunc("/api/v1/httpclient/list", s.handleListHTTPClients)
+	mux.HandleFunc("/api/v1/httpclient/register", s.require(auth.PermissionAdmin, s.handleRegisterHTTPClient))
+	mux.HandleFunc("/api/v1/httpclient/list", s.require(auth.PermissionRead, s.handleListHTTPClients))
+
+	// API description, public so clients can discover how to authenticate
+	mux.HandleFunc("/api/v1/openapi.json", s.handleOpenAPI)
 
 	// Static file serving (should be last to act as catch-all)
 	mux.HandleFunc("/", s.handleStaticFiles)
@@ -152,7 +583,11 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 
 // middlewareChain applies middleware to the handler
 func (s *Server) middlewareChain(handler http.Handler) http.Handler {
-	// Apply CORS if enabled
+	// Apply authentication; routes check permissions with require
+	handler = s.authMiddleware(handler)
+
+	// Apply CORS if enabled, outside authentication so preflight requests
+	// need no credentials
 	if s.config.EnableCORS {
 		handler = s.corsMiddleware(handler)
 	}
@@ -166,6 +601,35 @@ func (s *Server) middlewareChain(handler http.Handler) http.Handler {
 	return handler
 }
 
+// ExecuteWorkflowResponse represents the response from executing a workflow
+type ExecuteWorkflowResponse struct {
+	Success       bool          `json:"success"`
+	Results       *types.Result `json:"results"`
+	ExecutionTime string        `json:"execution_time"`
+}
+
+// ValidateWorkflowResponse represents the response from validating a
+// workflow. Invalid workflows are reported with a 200 status and Error.
+type ValidateWorkflowResponse struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// PlanWorkflowResponse represents the response from planning a workflow
+type PlanWorkflowResponse struct {
+	Success bool         `json:"success"`
+	Plan    *engine.Plan `json:"plan"`
+}
+
+// ErrorResponse is the body of error responses that are not specific to an
+// endpoint. InputErrors lists the problems with a saved workflow's inputs.
+type ErrorResponse struct {
+	Success     bool                `json:"success"`
+	Error       string              `json:"error"`
+	Details     string              `json:"details,omitempty"`
+	InputErrors []params.FieldError `json:"input_errors,omitempty"`
+}
+
 // handleExecuteWorkflow handles workflow execution requests
 func (s *Server) handleExecuteWorkflow(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -184,39 +648,34 @@ func (s *Server) handleExecuteWorkflow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Execute workflow
+	scope := s.scope(r)
 	startTime := time.Now()
-	eng, err := engine.NewWithConfig(body, s.engineConfig)
+	eng, err := s.newEngine(scope, body, "", 0)
 	if err != nil {
 		s.writeErrorResponse(w, "Failed to create engine", http.StatusBadRequest, err)
 		return
 	}
 
-	// Register telemetry observer
-	telemetryObserver := telemetry.NewTelemetryObserver(s.telemetryProvider)
-	eng.RegisterObserver(telemetryObserver)
+	release, ok := s.acquireQuota(w, scope)
+	if !ok {
+		return
+	}
+	defer release()
 
 	// Execute
-	result, err := eng.Execute()
+	result, err := eng.ExecuteContext(traceContext(context.Background(), r))
 	duration := time.Since(startTime)
 
-	// Record metrics
-	success := err == nil
-	nodesExecuted := 0
-	if result != nil {
-		nodesExecuted = len(result.NodeResults)
-	}
-	s.telemetryProvider.RecordWorkflowExecution(r.Context(), "", duration, success, nodesExecuted)
-
 	if err != nil {
 		s.writeErrorResponse(w, "Workflow execution failed", http.StatusInternalServerError, err)
 		return
 	}
 
 	// Write successful response
-	s.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"success":        true,
-		"results":        result,
-		"execution_time": duration.String(),
+	s.writeJSONResponse(w, http.StatusOK, ExecuteWorkflowResponse{
+		Success:       true,
+		Results:       result,
+		ExecutionTime: duration.String(),
 	})
 }
 
@@ -238,17 +697,57 @@ func (s *Server) handleValidateWorkflow(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Try to create engine (validates the workflow)
-	_, err = engine.NewWithConfig(body, s.engineConfig)
+	_, err = engine.NewWithConfig(body, s.tenantEngineConfig(s.scope(r)))
 	if err != nil {
-		s.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-			"valid": false,
-			"error": err.Error(),
+		s.writeJSONResponse(w, http.StatusOK, ValidateWorkflowResponse{
+			Valid: false,
+			Error: err.Error(),
 		})
 		return
 	}
 
-	s.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"valid": true,
+	s.writeJSONResponse(w, http.StatusOK, ValidateWorkflowResponse{
+		Valid: true,
+	})
+}
+
+// handlePlanWorkflow handles dry runs, which report the nodes a workflow
+// would run without sending requests or making other side effects
+func (s *Server) handlePlanWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Limit request body size
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBodySize)
+
+	// Read request body
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest, err)
+		return
+	}
+
+	eng, err := s.newEngine(s.scope(r), body, "", 0)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to create engine", http.StatusBadRequest, err)
+		return
+	}
+
+	plan, err := eng.Plan(r.Context())
+	if err != nil {
+		if errors.Is(err, engine.ErrExecutionTimeout) || errors.Is(err, engine.ErrExecutionCanceled) {
+			s.writeErrorResponse(w, "Workflow planning failed", http.StatusInternalServerError, err)
+			return
+		}
+		s.writeErrorResponse(w, "Invalid workflow", http.StatusBadRequest, err)
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, PlanWorkflowResponse{
+		Success: true,
+		Plan:    plan,
 	})
 }
 
@@ -266,10 +765,10 @@ func (s *Server) writeJSONResponse(w http.ResponseWriter, statusCode int, data i
 func (s *Server) writeErrorResponse(w http.ResponseWriter, message string, statusCode int, err error) {
 	s.logger.WithError(err).WithField("status_code", statusCode).Error(message)
 
-	s.writeJSONResponse(w, statusCode, map[string]interface{}{
-		"success": false,
-		"error":   message,
-		"details": err.Error(),
+	s.writeJSONResponse(w, statusCode, ErrorResponse{
+		Success: false,
+		Error:   message,
+		Details: err.Error(),
 	})
 }
 
@@ -293,6 +792,16 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		return fmt.Errorf("failed to shutdown http server: %w", err)
 	}
 
+	// Stop firing schedules and cancel scheduled runs
+	if err := s.scheduler.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown scheduler: %w", err)
+	}
+
+	// Cancel asynchronous executions and stop their workers
+	if err := s.runner.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown execution runner: %w", err)
+	}
+
 	// Shutdown telemetry
 	if err := s.telemetryProvider.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown telemetry: %w", err)
@@ -302,14 +811,28 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// corsMiddleware adds CORS headers
+// corsMiddleware adds CORS headers for origins in the allow-list
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := r.Header.Get("Origin")
+		allowed := origin == "" || s.corsAllowed(origin)
+
+		if origin != "" && allowed {
+			if slices.Contains(s.config.CORSAllowedOrigins, "*") {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+auth.APIKeyHeader+", Last-Event-ID")
+		}
 
 		if r.Method == http.MethodOptions {
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
 			w.WriteHeader(http.StatusOK)
 			return
 		}
@@ -318,6 +841,21 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// corsAllowed reports whether origin matches the CORS allow-list
+func (s *Server) corsAllowed(origin string) bool {
+	for _, pattern := range s.config.CORSAllowedOrigins {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		// "https://*.example.com" matches any subdomain of example.com
+		if prefix, domain, ok := strings.Cut(pattern, "*."); ok &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
 // loggingMiddleware logs HTTP requests
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -326,17 +864,18 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		// Create response writer wrapper to capture status code
 		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
-		next.ServeHTTP(rw, r)
+		// Inner middleware and handlers add fields such as the tenant
+		fields := map[string]interface{}{}
+		next.ServeHTTP(rw, r.WithContext(withRequestLog(r.Context(), fields)))
 
 		duration := time.Since(startTime)
 
-		s.logger.WithFields(map[string]interface{}{
-			"method":      r.Method,
-			"path":        r.URL.Path,
-			"status_code": rw.statusCode,
-			"duration_ms": duration.Milliseconds(),
-			"remote_addr": r.RemoteAddr,
-		}).Info("http request")
+		fields["method"] = r.Method
+		fields["path"] = r.URL.Path
+		fields["status_code"] = rw.statusCode
+		fields["duration_ms"] = duration.Milliseconds()
+		fields["remote_addr"] = r.RemoteAddr
+		s.logger.WithFields(fields).Info("http request")
 	})
 }
 
@@ -367,3 +906,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap exposes the underlying writer to http.ResponseController, which
+// streaming handlers use to flush
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
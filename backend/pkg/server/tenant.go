@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	workflow "github.com/yesoreyeram/thaiyyal/backend"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/httpclient"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/tenant"
+)
+
+// tenantScope holds the resources one tenant can see. Handlers reach it
+// with Server.scope and never touch another tenant's stores.
+type tenantScope struct {
+	id          string
+	workflows   workflow.WorkflowStore
+	httpClients *httpclient.Registry
+}
+
+// tenantScopeKey is the context key for the caller's tenantScope
+type tenantScopeKey struct{}
+
+// newTenantScope creates the stores of tenant id. With the file store the
+// default tenant keeps using DataDir itself, so workflows saved before
+// tenants existed stay visible; other tenants live under DataDir/tenants.
+func newTenantScope(config Config, id string) (*tenantScope, error) {
+	storeConfig := config
+	if id != tenant.Default {
+		storeConfig.DataDir = filepath.Join(config.DataDir, "tenants", id)
+	}
+	workflows, err := newWorkflowStore(storeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantScope{
+		id:          id,
+		workflows:   workflows,
+		httpClients: httpclient.NewRegistry(),
+	}, nil
+}
+
+// tenantID returns the tenant of the request's caller. Anonymous callers
+// and callers without a tenant belong to the default tenant.
+func tenantID(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.Tenant != "" {
+		return principal.Tenant
+	}
+	return tenant.Default
+}
+
+// withScope resolves the caller's tenant and stores its scope in the
+// request context. On failure it writes the error response and returns
+// false.
+func (s *Server) withScope(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	id := tenantID(r)
+	scope, err := s.tenants.Get(id)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to open tenant", http.StatusInternalServerError, err)
+		return nil, false
+	}
+	addLogField(r.Context(), "tenant_id", id)
+	return r.WithContext(context.WithValue(r.Context(), tenantScopeKey{}, scope)), true
+}
+
+// scope returns the caller's tenant scope stored by withScope, or the
+// default tenant's when the request did not pass through it
+func (s *Server) scope(r *http.Request) *tenantScope {
+	if scope, ok := r.Context().Value(tenantScopeKey{}).(*tenantScope); ok {
+		return scope
+	}
+	return s.defaultScope()
+}
+
+// defaultScope returns the default tenant's scope
+func (s *Server) defaultScope() *tenantScope {
+	scope, _ := s.tenants.Get(tenant.Default) // created in New, cannot fail
+	return scope
+}
+
+// acquireQuota counts one execution against the caller's tenant. When the
+// tenant is over quota it writes a 429 response and returns false.
+func (s *Server) acquireQuota(w http.ResponseWriter, scope *tenantScope) (release func(), ok bool) {
+	release, err := s.quotas.Acquire(scope.id)
+	if err == nil {
+		return release, true
+	}
+
+	var quotaErr *tenant.QuotaError
+	if errors.As(err, &quotaErr) && quotaErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(quotaErr.RetryAfter.Seconds()))))
+	}
+	s.logger.WithField("tenant_id", scope.id).WithError(err).Warn("execution rejected by tenant quota")
+	s.writeJSONResponse(w, http.StatusTooManyRequests, ErrorResponse{
+		Success: false,
+		Error:   "Tenant quota exceeded",
+		Details: err.Error(),
+	})
+	return nil, false
+}
+
+// TenantResponse describes the caller's tenant with its quota and current
+// usage
+type TenantResponse struct {
+	Success bool         `json:"success"`
+	Tenant  string       `json:"tenant"`
+	Quota   tenant.Quota `json:"quota"`
+	Usage   tenant.Usage `json:"usage"`
+}
+
+// handleGetTenant returns the caller's tenant, quota and usage
+func (s *Server) handleGetTenant(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := s.scope(r).id
+	s.writeJSONResponse(w, http.StatusOK, TenantResponse{
+		Success: true,
+		Tenant:  id,
+		Quota:   s.quotas.Quota(id),
+		Usage:   s.quotas.Usage(id),
+	})
+}
+
+// requestLogKey is the context key for fields added to a request's log line
+type requestLogKey struct{}
+
+// withRequestLog returns a context that collects fields for the request
+// log line written by loggingMiddleware
+func withRequestLog(ctx context.Context, fields map[string]interface{}) context.Context {
+	return context.WithValue(ctx, requestLogKey{}, fields)
+}
+
+// addLogField adds a field to the request log line, if ctx collects them.
+// Handlers run on the request's goroutine, so no locking is needed.
+func addLogField(ctx context.Context, key string, value interface{}) {
+	if fields, ok := ctx.Value(requestLogKey{}).(map[string]interface{}); ok {
+		fields[key] = value
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/auth"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/tenant"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "keys.json")
+	err := os.WriteFile(keysFile, []byte(`{"keys": [
+		{"name": "acme", "key": "acme-key", "roles": ["admin"], "tenant": "acme"},
+		{"name": "globex", "key": "globex-key", "roles": ["admin"], "tenant": "globex"},
+		{"name": "bad", "key": "bad-key", "roles": ["admin"], "tenant": "../etc"}
+	]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.Auth = auth.Config{APIKeysFile: keysFile}
+	config.WorkflowStore = StoreFile
+	config.DataDir = filepath.Join(dir, "workflows")
+	config.TenantQuotas = tenant.Quotas{
+		Tenants: map[string]tenant.Quota{"globex": {MaxNodes: 2, MaxExecutionsPerMinute: 1}},
+	}
+	srv, err := New(config, types.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.runner.Shutdown(context.Background())
+	handler := srv.httpServer.Handler
+
+	do := func(key, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(auth.APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v interface{}) {
+		t.Helper()
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+
+	// Workflows
+	w := do("acme-key", http.MethodPost, "/api/v1/workflow/save", `{"name": "Adder", "data": `+addWorkflow+`}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Save returned %d: %s", w.Code, w.Body.String())
+	}
+	var saved SaveWorkflowResponse
+	decode(w, &saved)
+	if _, err := os.Stat(filepath.Join(config.DataDir, "tenants", "acme", saved.ID+".json")); err != nil {
+		t.Errorf("Expected workflow under the tenant's directory: %v", err)
+	}
+
+	var list ListWorkflowsResponse
+	decode(do("globex-key", http.MethodGet, "/api/v1/workflow/list", ""), &list)
+	if list.Count != 0 {
+		t.Errorf("globex sees %d workflows, want 0", list.Count)
+	}
+	if w := do("globex-key", http.MethodGet, "/api/v1/workflow/load/"+saved.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("globex loading acme's workflow returned %d, want 404", w.Code)
+	}
+	if w := do("globex-key", http.MethodPost, "/api/v1/workflow/execute/"+saved.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("globex executing acme's workflow returned %d, want 404", w.Code)
+	}
+
+	// HTTP clients
+	if w := do("acme-key", http.MethodPost, "/api/v1/httpclient/register", `{"config": {"uid": "crm"}}`); w.Code != http.StatusCreated {
+		t.Fatalf("Register returned %d: %s", w.Code, w.Body.String())
+	}
+	var clients ListHTTPClientsResponse
+	decode(do("globex-key", http.MethodGet, "/api/v1/httpclient/list", ""), &clients)
+	if clients.Count != 0 {
+		t.Errorf("globex sees %d HTTP clients, want 0", clients.Count)
+	}
+	if w := do("globex-key", http.MethodPost, "/api/v1/httpclient/register", `{"config": {"uid": "crm"}}`); w.Code != http.StatusCreated {
+		t.Errorf("globex registering its own crm client returned %d, want 201", w.Code)
+	}
+
+	// Executions and history
+	w = do("acme-key", http.MethodPost, "/api/v1/executions", `{"workflow_id": "`+saved.ID+`"}`)
+	var submitted SubmitExecutionResponse
+	decode(w, &submitted)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Submit returned %d", w.Code)
+	}
+	if w := do("globex-key", http.MethodGet, "/api/v1/executions/"+submitted.ExecutionID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("globex reading acme's execution returned %d, want 404", w.Code)
+	}
+	if w := do("globex-key", http.MethodDelete, "/api/v1/executions/"+submitted.ExecutionID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("globex cancelling acme's execution returned %d, want 404", w.Code)
+	}
+	var executions ListExecutionsResponse
+	decode(do("globex-key", http.MethodGet, "/api/v1/executions", ""), &executions)
+	if executions.Count != 0 {
+		t.Errorf("globex sees %d executions, want 0", executions.Count)
+	}
+	decode(do("acme-key", http.MethodGet, "/api/v1/executions", ""), &executions)
+	if executions.Count != 1 || executions.Executions[0].TenantID != "acme" {
+		t.Errorf("acme executions = %+v, want its one execution", executions.Executions)
+	}
+
+	var executed struct {
+		Results types.Result `json:"results"`
+	}
+	decode(do("acme-key", http.MethodPost, "/api/v1/workflow/execute", addWorkflow), &executed)
+	historyPath := "/api/v1/history/" + executed.Results.ExecutionID
+	if w := do("acme-key", http.MethodGet, historyPath, ""); w.Code != http.StatusOK {
+		t.Errorf("acme reading its history returned %d, want 200", w.Code)
+	}
+	if w := do("globex-key", http.MethodGet, historyPath, ""); w.Code != http.StatusNotFound {
+		t.Errorf("globex reading acme's history returned %d, want 404", w.Code)
+	}
+	var history ListHistoryResponse
+	decode(do("globex-key", http.MethodGet, "/api/v1/history", ""), &history)
+	if history.Total != 0 {
+		t.Errorf("globex sees %d history records, want 0", history.Total)
+	}
+
+	// Quotas
+	if w := do("globex-key", http.MethodPost, "/api/v1/workflow/execute", addWorkflow); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "maximum number of nodes") {
+		t.Errorf("globex running 3 nodes over its 2 node quota returned %d: %s", w.Code, w.Body.String())
+	}
+	small := `{"nodes": [{"id": "1", "data": {"value": 1}}], "edges": []}`
+	if w := do("globex-key", http.MethodPost, "/api/v1/workflow/execute", small); w.Code != http.StatusOK {
+		t.Errorf("globex first execution returned %d: %s", w.Code, w.Body.String())
+	}
+	w = do("globex-key", http.MethodPost, "/api/v1/executions", `{"workflow": `+small+`}`)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("globex over its rate quota returned %d with Retry-After %q, want 429", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := do("acme-key", http.MethodPost, "/api/v1/workflow/execute", addWorkflow); w.Code != http.StatusOK {
+		t.Errorf("acme is not limited by globex's quota, got %d", w.Code)
+	}
+
+	var info TenantResponse
+	decode(do("globex-key", http.MethodGet, "/api/v1/tenant", ""), &info)
+	if info.Tenant != "globex" || info.Quota.MaxNodes != 2 || info.Usage.StartedLastMinute != 1 {
+		t.Errorf("globex tenant = %+v", info)
+	}
+
+	// A credential naming an unusable tenant is rejected
+	if w := do("bad-key", http.MethodGet, "/api/v1/workflow/list", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Invalid tenant returned %d, want 401", w.Code)
+	}
+}
@@ -4,4 +4,15 @@
 //   - Prometheus metrics for workflow and node execution statistics
 //   - Custom metrics exporters and collectors
 //   - Integration with industry-standard observability platforms
+//
+// Spans are exported over OTLP/gRPC or OTLP/HTTP when Config.TraceExporter
+// is set, sampling Config.SampleRatio of new traces. The engine creates
+// the workflow and node spans itself from Provider.TracerProvider;
+// TelemetryObserver records metrics only.
+//
+// Metrics are labelled by workflow ID, node type, HTTP client and status.
+// Workflow IDs and HTTP client UIDs only appear as labels when listed in
+// Config.MetricWorkflowIDs and Config.MetricHTTPClients; the rest share
+// the LabelOther label. ForTenant adapts the provider to engine.Metrics,
+// which records HTTP calls and exceeded protection limits.
 package telemetry
@@ -0,0 +1,9 @@
+package telemetry
+
+import "errors"
+
+// Sentinel errors for telemetry configuration
+var (
+	// ErrInvalidConfig is returned by NewProvider for invalid tracing settings
+	ErrInvalidConfig = errors.New("invalid telemetry config")
+)
@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"fmt"
+	"time"
+)
+
+// allowList holds the label values recorded as they are. Values outside
+// it are recorded as LabelOther.
+type allowList struct {
+	all    bool
+	values map[string]bool
+}
+
+// newAllowList creates an allow-list of values; a "*" entry allows any
+func newAllowList(values []string) allowList {
+	list := allowList{values: make(map[string]bool, len(values))}
+	for _, value := range values {
+		if value == "*" {
+			list.all = true
+		}
+		list.values[value] = true
+	}
+	return list
+}
+
+// label returns value if the list allows it, or LabelOther. The empty
+// value, as for workflows run without a saved ID, is always allowed.
+func (l allowList) label(value string) string {
+	if value == "" || l.all || l.values[value] {
+		return value
+	}
+	return LabelOther
+}
+
+// statusLabel returns the status label of an execution's outcome
+func statusLabel(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
+
+// statusClass returns the class of an HTTP status code, such as "2xx", or
+// "error" when no response arrived
+func statusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -3,17 +3,23 @@ package telemetry
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
@@ -31,16 +37,43 @@ const (
 	metricNodeDuration       = "node.execution.duration"
 	metricNodeSuccess        = "node.executions.success.total"
 	metricNodeFailure        = "node.executions.failure.total"
+	metricWorkflowNodes      = "workflow.execution.nodes"
+	metricWorkflowActive     = "workflow.executions.active"
+	metricLimitsExceeded     = "workflow.limits.exceeded.total"
 	metricHTTPCalls          = "http.calls.total"
 	metricHTTPDuration       = "http.call.duration"
 )
 
+// Label values recorded in place of missing or unlisted ones
+const (
+	// LabelOther replaces workflow IDs and HTTP client UIDs that are not
+	// in the metrics allow-lists
+	LabelOther = "other"
+
+	// LabelDefaultClient labels HTTP calls made without a named client
+	LabelDefaultClient = "default"
+)
+
+// Trace exporters
+const (
+	// TraceExporterNone keeps spans in the process; they reach only the
+	// globally registered tracer provider
+	TraceExporterNone = "none"
+
+	// TraceExporterOTLPGRPC exports spans over OTLP/gRPC
+	TraceExporterOTLPGRPC = "otlp-grpc"
+
+	// TraceExporterOTLPHTTP exports spans over OTLP/HTTP
+	TraceExporterOTLPHTTP = "otlp-http"
+)
+
 // Provider manages OpenTelemetry setup and provides access to tracers and meters.
 type Provider struct {
-	meterProvider  *sdkmetric.MeterProvider
-	tracerProvider trace.TracerProvider
-	meter          metric.Meter
-	tracer         trace.Tracer
+	meterProvider     *sdkmetric.MeterProvider
+	tracerProvider    trace.TracerProvider
+	sdkTracerProvider *sdktrace.TracerProvider
+	meter             metric.Meter
+	tracer            trace.Tracer
 
 	// Metrics instruments
 	workflowExecutions metric.Int64Counter
@@ -51,9 +84,16 @@ type Provider struct {
 	nodeDuration       metric.Float64Histogram
 	nodeSuccess        metric.Int64Counter
 	nodeFailure        metric.Int64Counter
+	workflowNodes      metric.Int64Histogram
+	workflowActive     metric.Int64UpDownCounter
+	limitsExceeded     metric.Int64Counter
 	httpCalls          metric.Int64Counter
 	httpDuration       metric.Float64Histogram
 
+	// Label allow-lists bounding the number of series
+	workflowLabels allowList
+	clientLabels   allowList
+
 	mu sync.RWMutex
 }
 
@@ -73,6 +113,49 @@ type Config struct {
 
 	// EnableMetrics enables metrics collection
 	EnableMetrics bool
+
+	// TraceExporter is where spans are exported: TraceExporterNone (or
+	// empty), TraceExporterOTLPGRPC or TraceExporterOTLPHTTP
+	TraceExporter string
+
+	// OTLPEndpoint is the collector address, as host:port or a URL. Empty
+	// uses the OTEL_EXPORTER_OTLP_* environment variables or the
+	// exporter's default (localhost:4317 for gRPC, localhost:4318 for HTTP).
+	OTLPEndpoint string
+
+	// OTLPInsecure disables TLS to the collector
+	OTLPInsecure bool
+
+	// OTLPHeaders are sent with every export, e.g. for authentication
+	OTLPHeaders map[string]string
+
+	// SampleRatio is the fraction of new traces that are sampled, from 0
+	// to 1. Executions joining a caller's trace follow its sampling
+	// decision. Used only with an exporter.
+	SampleRatio float64
+
+	// MetricWorkflowIDs lists the workflow IDs metrics are labelled with.
+	// Other workflows are labelled LabelOther, so the number of series
+	// stays bounded however many workflows are saved. A "*" entry allows
+	// every ID.
+	MetricWorkflowIDs []string
+
+	// MetricHTTPClients lists the named HTTP client UIDs HTTP call metrics
+	// are labelled with, like MetricWorkflowIDs
+	MetricHTTPClients []string
+}
+
+// Validate checks the tracing settings
+func (c Config) Validate() error {
+	switch c.TraceExporter {
+	case "", TraceExporterNone, TraceExporterOTLPGRPC, TraceExporterOTLPHTTP:
+	default:
+		return fmt.Errorf("%w: unknown trace exporter %q", ErrInvalidConfig, c.TraceExporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("%w: sample ratio %v is outside [0, 1]", ErrInvalidConfig, c.SampleRatio)
+	}
+	return nil
 }
 
 // DefaultConfig returns default telemetry configuration
@@ -83,14 +166,26 @@ func DefaultConfig() Config {
 		Environment:    "development",
 		EnableTracing:  true,
 		EnableMetrics:  true,
+		TraceExporter:  TraceExporterNone,
+		SampleRatio:    1,
 	}
 }
 
 // NewProvider creates a new telemetry provider with Prometheus metrics exporter.
 // It initializes OpenTelemetry with the given configuration and returns a provider
 // that can be used to create tracers and record metrics.
+//
+// With tracing enabled, the W3C trace context and baggage propagators are
+// registered globally, and with a trace exporter so is the provider's
+// tracer provider.
 func NewProvider(ctx context.Context, config Config) (*Provider, error) {
-	provider := &Provider{}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	provider := &Provider{
+		workflowLabels: newAllowList(config.MetricWorkflowIDs),
+		clientLabels:   newAllowList(config.MetricHTTPClients),
+	}
 
 	// Create resource with service information
 	res, err := resource.New(ctx,
@@ -113,7 +208,9 @@ func NewProvider(ctx context.Context, config Config) (*Provider, error) {
 
 	// Initialize tracing if enabled
 	if config.EnableTracing {
-		provider.initTracing()
+		if err := provider.initTracing(ctx, res, config); err != nil {
+			return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+		}
 	}
 
 	return provider, nil
@@ -127,32 +224,99 @@ func (p *Provider) initMetrics(res *resource.Resource) error {
 		return fmt.Errorf("failed to create prometheus exporter: %w", err)
 	}
 
-	// Create meter provider with the exporter
-	p.meterProvider = sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(exporter),
-	)
+	if err := p.initMeter(res, exporter); err != nil {
+		return err
+	}
 
 	// Set as global meter provider
 	otel.SetMeterProvider(p.meterProvider)
+	return nil
+}
 
-	// Create meter
+// initMeter creates the meter provider reading into reader, and the
+// metric instruments
+func (p *Provider) initMeter(res *resource.Resource, reader sdkmetric.Reader) error {
+	p.meterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(reader),
+	)
 	p.meter = p.meterProvider.Meter(serviceName)
 
-	// Create metric instruments
 	if err := p.createMetricInstruments(); err != nil {
 		return fmt.Errorf("failed to create metric instruments: %w", err)
 	}
-
 	return nil
 }
 
-// initTracing initializes the tracing provider
-func (p *Provider) initTracing() {
-	// For now, use the global tracer provider
-	// In production, this should be configured with appropriate exporters (OTLP, Jaeger, etc.)
-	p.tracerProvider = otel.GetTracerProvider()
+// initTracing initializes the tracing provider. Without an exporter, the
+// global tracer provider is used.
+func (p *Provider) initTracing(ctx context.Context, res *resource.Resource, config Config) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newTraceExporter(ctx, config)
+	if err != nil {
+		return err
+	}
+	if exporter == nil {
+		p.tracerProvider = otel.GetTracerProvider()
+		p.tracer = p.tracerProvider.Tracer(serviceName)
+		return nil
+	}
+
+	p.sdkTracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(p.sdkTracerProvider)
+
+	p.tracerProvider = p.sdkTracerProvider
 	p.tracer = p.tracerProvider.Tracer(serviceName)
+	return nil
+}
+
+// newTraceExporter creates the configured span exporter, or returns nil
+// when spans are not exported
+func newTraceExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, error) {
+	isURL := strings.Contains(config.OTLPEndpoint, "://")
+
+	switch config.TraceExporter {
+	case TraceExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		switch {
+		case isURL:
+			opts = append(opts, otlptracegrpc.WithEndpointURL(config.OTLPEndpoint))
+		case config.OTLPEndpoint != "":
+			opts = append(opts, otlptracegrpc.WithEndpoint(config.OTLPEndpoint))
+		}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(config.OTLPHeaders) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(config.OTLPHeaders))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+
+	case TraceExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		switch {
+		case isURL:
+			opts = append(opts, otlptracehttp.WithEndpointURL(config.OTLPEndpoint))
+		case config.OTLPEndpoint != "":
+			opts = append(opts, otlptracehttp.WithEndpoint(config.OTLPEndpoint))
+		}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(config.OTLPHeaders) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(config.OTLPHeaders))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	return nil, nil
 }
 
 // createMetricInstruments creates all metric instruments
@@ -227,6 +391,32 @@ func (p *Provider) createMetricInstruments() error {
 		return err
 	}
 
+	p.workflowNodes, err = p.meter.Int64Histogram(
+		metricWorkflowNodes,
+		metric.WithDescription("Number of nodes executed per workflow execution"),
+		metric.WithExplicitBucketBoundaries(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000),
+	)
+	if err != nil {
+		return err
+	}
+
+	p.workflowActive, err = p.meter.Int64UpDownCounter(
+		metricWorkflowActive,
+		metric.WithDescription("Number of workflow executions in flight"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Protection limit metrics
+	p.limitsExceeded, err = p.meter.Int64Counter(
+		metricLimitsExceeded,
+		metric.WithDescription("Total number of times a protection limit stopped a node or workflow"),
+	)
+	if err != nil {
+		return err
+	}
+
 	// HTTP metrics
 	p.httpCalls, err = p.meter.Int64Counter(
 		metricHTTPCalls,
@@ -255,6 +445,17 @@ func (p *Provider) Tracer() trace.Tracer {
 	return p.tracer
 }
 
+// TracerProvider returns the provider of spans for workflow executions. It
+// is a no-op provider when tracing is disabled.
+func (p *Provider) TracerProvider() trace.TracerProvider {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.tracerProvider == nil {
+		return noop.NewTracerProvider()
+	}
+	return p.tracerProvider
+}
+
 // Meter returns the meter for recording metrics
 func (p *Provider) Meter() metric.Meter {
 	p.mu.RLock()
@@ -262,73 +463,142 @@ func (p *Provider) Meter() metric.Meter {
 	return p.meter
 }
 
-// RecordWorkflowExecution records metrics for a workflow execution
-func (p *Provider) RecordWorkflowExecution(ctx context.Context, workflowID string, duration time.Duration, success bool, nodesExecuted int) {
-	if p.meter == nil {
-		return
+// tenantKey is the context key for the tenant ID metrics are labelled with
+type tenantKey struct{}
+
+// ContextWithTenant returns a context whose recorded metrics carry a
+// tenant.id label. An empty tenantID leaves ctx unchanged.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	if tenantID == "" {
+		return ctx
 	}
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
 
-	attrs := []attribute.KeyValue{
-		attribute.String("workflow.id", workflowID),
-		attribute.Int("nodes.executed", nodesExecuted),
+// withTenant appends the tenant.id label from ctx to attrs, if there is one
+func withTenant(ctx context.Context, attrs []attribute.KeyValue) []attribute.KeyValue {
+	if tenantID, ok := ctx.Value(tenantKey{}).(string); ok {
+		attrs = append(attrs, attribute.String("tenant.id", tenantID))
 	}
+	return attrs
+}
 
-	// Record execution count
-	p.workflowExecutions.Add(ctx, 1, metric.WithAttributes(attrs...))
+// RecordWorkflowExecution records metrics for a workflow execution,
+// labelled with its workflow ID, if allowed, and its status
+func (p *Provider) RecordWorkflowExecution(ctx context.Context, workflowID string, duration time.Duration, success bool, nodesExecuted int) {
+	if p.meter == nil {
+		return
+	}
 
-	// Record duration
-	p.workflowDuration.Record(ctx, float64(duration.Milliseconds()), metric.WithAttributes(attrs...))
+	attrs := withTenant(ctx, []attribute.KeyValue{
+		attribute.String("workflow.id", p.workflowLabels.label(workflowID)),
+		attribute.String("status", statusLabel(success)),
+	})
+	opt := metric.WithAttributes(attrs...)
 
-	// Record success/failure
+	p.workflowExecutions.Add(ctx, 1, opt)
+	p.workflowDuration.Record(ctx, milliseconds(duration), opt)
+	p.workflowNodes.Record(ctx, int64(nodesExecuted), opt)
 	if success {
-		p.workflowSuccess.Add(ctx, 1, metric.WithAttributes(attrs...))
+		p.workflowSuccess.Add(ctx, 1, opt)
 	} else {
-		p.workflowFailure.Add(ctx, 1, metric.WithAttributes(attrs...))
+		p.workflowFailure.Add(ctx, 1, opt)
 	}
 }
 
-// RecordNodeExecution records metrics for a node execution
-func (p *Provider) RecordNodeExecution(ctx context.Context, nodeID string, nodeType types.NodeType, duration time.Duration, success bool) {
+// RecordExecutionStart counts a workflow execution as in flight until
+// RecordExecutionEnd is called for it
+func (p *Provider) RecordExecutionStart(ctx context.Context) {
 	if p.meter == nil {
 		return
 	}
+	p.workflowActive.Add(ctx, 1, metric.WithAttributes(withTenant(ctx, nil)...))
+}
 
-	attrs := []attribute.KeyValue{
-		attribute.String("node.id", nodeID),
-		attribute.String("node.type", string(nodeType)),
+// RecordExecutionEnd stops counting a workflow execution as in flight
+func (p *Provider) RecordExecutionEnd(ctx context.Context) {
+	if p.meter == nil {
+		return
 	}
+	p.workflowActive.Add(ctx, -1, metric.WithAttributes(withTenant(ctx, nil)...))
+}
 
-	// Record execution count
-	p.nodeExecutions.Add(ctx, 1, metric.WithAttributes(attrs...))
+// RecordNodeExecution records metrics for a node execution, labelled with
+// its node type and status
+func (p *Provider) RecordNodeExecution(ctx context.Context, nodeType types.NodeType, duration time.Duration, success bool) {
+	if p.meter == nil {
+		return
+	}
 
-	// Record duration
-	p.nodeDuration.Record(ctx, float64(duration.Milliseconds()), metric.WithAttributes(attrs...))
+	attrs := withTenant(ctx, []attribute.KeyValue{
+		attribute.String("node.type", string(nodeType)),
+		attribute.String("status", statusLabel(success)),
+	})
+	opt := metric.WithAttributes(attrs...)
 
-	// Record success/failure
+	p.nodeExecutions.Add(ctx, 1, opt)
+	p.nodeDuration.Record(ctx, milliseconds(duration), opt)
 	if success {
-		p.nodeSuccess.Add(ctx, 1, metric.WithAttributes(attrs...))
+		p.nodeSuccess.Add(ctx, 1, opt)
 	} else {
-		p.nodeFailure.Add(ctx, 1, metric.WithAttributes(attrs...))
+		p.nodeFailure.Add(ctx, 1, opt)
 	}
 }
 
-// RecordHTTPCall records metrics for an HTTP call
-func (p *Provider) RecordHTTPCall(ctx context.Context, method, url string, statusCode int, duration time.Duration) {
+// RecordHTTPCall records metrics for an HTTP call made with the named
+// client clientUID ("" for the default client), labelled with the client,
+// if allowed, and the status class of statusCode: "2xx" to "5xx", or
+// "error" when no response arrived
+func (p *Provider) RecordHTTPCall(ctx context.Context, clientUID string, statusCode int, duration time.Duration) {
 	if p.meter == nil {
 		return
 	}
 
-	attrs := []attribute.KeyValue{
-		attribute.String("http.method", method),
-		attribute.String("http.url", url),
-		attribute.Int("http.status_code", statusCode),
+	client := LabelDefaultClient
+	if clientUID != "" {
+		client = p.clientLabels.label(clientUID)
+	}
+	attrs := withTenant(ctx, []attribute.KeyValue{
+		attribute.String("http.client", client),
+		attribute.String("http.status_class", statusClass(statusCode)),
+	})
+	opt := metric.WithAttributes(attrs...)
+
+	p.httpCalls.Add(ctx, 1, opt)
+	p.httpDuration.Record(ctx, milliseconds(duration), opt)
+}
+
+// RecordLimitExceeded counts a protection limit, such as
+// "max_node_executions", stopping a node or workflow
+func (p *Provider) RecordLimitExceeded(ctx context.Context, limit string) {
+	if p.meter == nil {
+		return
 	}
+	attrs := withTenant(ctx, []attribute.KeyValue{attribute.String("limit", limit)})
+	p.limitsExceeded.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
 
-	// Record HTTP call count
-	p.httpCalls.Add(ctx, 1, metric.WithAttributes(attrs...))
+// ForTenant returns a recorder of an engine's HTTP calls and exceeded
+// limits that labels them with tenantID. It implements engine.Metrics.
+func (p *Provider) ForTenant(tenantID string) *TenantMetrics {
+	return &TenantMetrics{provider: p, tenantID: tenantID}
+}
 
-	// Record duration
-	p.httpDuration.Record(ctx, float64(duration.Milliseconds()), metric.WithAttributes(attrs...))
+// TenantMetrics records metrics for one tenant's executions
+type TenantMetrics struct {
+	provider *Provider
+	tenantID string
+}
+
+// RecordHTTPCall records an HTTP call; see Provider.RecordHTTPCall
+func (m *TenantMetrics) RecordHTTPCall(ctx context.Context, clientUID string, statusCode int, duration time.Duration) {
+	m.provider.RecordHTTPCall(ContextWithTenant(ctx, m.tenantID), clientUID, statusCode, duration)
+}
+
+// RecordLimitExceeded records an exceeded limit; see
+// Provider.RecordLimitExceeded
+func (m *TenantMetrics) RecordLimitExceeded(ctx context.Context, limit string) {
+	m.provider.RecordLimitExceeded(ContextWithTenant(ctx, m.tenantID), limit)
 }
 
 // Shutdown gracefully shuts down the telemetry provider
@@ -342,5 +612,12 @@ func (p *Provider) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Flushes spans still waiting to be exported
+	if p.sdkTracerProvider != nil {
+		if err := p.sdkTracerProvider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("failed to shutdown tracer provider: %w", err)
+		}
+	}
+
 	return nil
 }
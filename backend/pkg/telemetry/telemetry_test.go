@@ -2,9 +2,21 @@ package telemetry
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/observer"
 	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
 )
 
@@ -54,6 +66,35 @@ func TestNewProvider(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "otlp grpc exporter",
+			config: Config{
+				ServiceName:   "test-service",
+				EnableTracing: true,
+				TraceExporter: TraceExporterOTLPGRPC,
+				OTLPEndpoint:  "localhost:4317",
+				OTLPInsecure:  true,
+				SampleRatio:   0.5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown exporter",
+			config: Config{
+				EnableTracing: true,
+				TraceExporter: "zipkin",
+			},
+			wantErr: true,
+		},
+		{
+			name: "sample ratio out of range",
+			config: Config{
+				EnableTracing: true,
+				TraceExporter: TraceExporterOTLPHTTP,
+				SampleRatio:   1.5,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -63,6 +104,9 @@ func TestNewProvider(t *testing.T) {
 				t.Errorf("NewProvider() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if err != nil && !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("NewProvider() error = %v, want ErrInvalidConfig", err)
+			}
 
 			if err == nil {
 				if provider == nil {
@@ -142,28 +186,24 @@ func TestRecordNodeExecution(t *testing.T) {
 
 	tests := []struct {
 		name     string
-		nodeID   string
 		nodeType types.NodeType
 		duration time.Duration
 		success  bool
 	}{
 		{
 			name:     "successful number node",
-			nodeID:   "node-1",
 			nodeType: types.NodeTypeNumber,
 			duration: 10 * time.Millisecond,
 			success:  true,
 		},
 		{
 			name:     "failed operation node",
-			nodeID:   "node-2",
 			nodeType: types.NodeTypeOperation,
 			duration: 5 * time.Millisecond,
 			success:  false,
 		},
 		{
 			name:     "successful http node",
-			nodeID:   "node-3",
 			nodeType: types.NodeTypeHTTP,
 			duration: 200 * time.Millisecond,
 			success:  true,
@@ -173,7 +213,7 @@ func TestRecordNodeExecution(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Should not panic
-			provider.RecordNodeExecution(ctx, tt.nodeID, tt.nodeType, tt.duration, tt.success)
+			provider.RecordNodeExecution(ctx, tt.nodeType, tt.duration, tt.success)
 		})
 	}
 }
@@ -190,31 +230,32 @@ func TestRecordHTTPCall(t *testing.T) {
 
 	tests := []struct {
 		name       string
-		method     string
-		url        string
+		clientUID  string
 		statusCode int
 		duration   time.Duration
 	}{
 		{
-			name:       "successful GET",
-			method:     "GET",
-			url:        "https://api.example.com/data",
+			name:       "successful call with the default client",
 			statusCode: 200,
 			duration:   150 * time.Millisecond,
 		},
 		{
-			name:       "failed POST",
-			method:     "POST",
-			url:        "https://api.example.com/submit",
+			name:       "failed call with a named client",
+			clientUID:  "crm",
 			statusCode: 500,
 			duration:   100 * time.Millisecond,
 		},
+		{
+			name:      "call without a response",
+			clientUID: "crm",
+			duration:  30 * time.Second,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Should not panic
-			provider.RecordHTTPCall(ctx, tt.method, tt.url, tt.statusCode, tt.duration)
+			provider.RecordHTTPCall(ctx, tt.clientUID, tt.statusCode, tt.duration)
 		})
 	}
 }
@@ -259,6 +300,199 @@ func TestProviderWithNilMetrics(t *testing.T) {
 
 	// These should not panic even with nil metrics
 	provider.RecordWorkflowExecution(ctx, "test", time.Second, true, 1)
-	provider.RecordNodeExecution(ctx, "node1", types.NodeTypeNumber, time.Millisecond, true)
-	provider.RecordHTTPCall(ctx, "GET", "http://example.com", 200, time.Second)
+	provider.RecordNodeExecution(ctx, types.NodeTypeNumber, time.Millisecond, true)
+	provider.RecordHTTPCall(ctx, "", 200, time.Second)
+	provider.RecordLimitExceeded(ctx, "max_http_calls")
+	provider.RecordExecutionStart(ctx)
+	provider.RecordExecutionEnd(ctx)
+}
+
+func TestContextWithTenant(t *testing.T) {
+	ctx := context.Background()
+	if attrs := withTenant(ContextWithTenant(ctx, ""), nil); len(attrs) != 0 {
+		t.Errorf("Expected no tenant label for empty tenant, got %v", attrs)
+	}
+
+	attrs := withTenant(ContextWithTenant(ctx, "acme"), nil)
+	if len(attrs) != 1 || attrs[0].Key != "tenant.id" || attrs[0].Value.AsString() != "acme" {
+		t.Errorf("Expected tenant.id=acme label, got %v", attrs)
+	}
+}
+
+func TestOTLPHTTPExport(t *testing.T) {
+	var exports atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("X-Api-Key") != "secret" {
+			t.Errorf("Unexpected export to %s with headers %v", r.URL.Path, r.Header)
+		}
+		io.Copy(io.Discard, r.Body)
+		exports.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	ctx := context.Background()
+	provider, err := NewProvider(ctx, Config{
+		ServiceName:   "test-service",
+		EnableTracing: true,
+		TraceExporter: TraceExporterOTLPHTTP,
+		OTLPEndpoint:  collector.URL,
+		OTLPInsecure:  true,
+		OTLPHeaders:   map[string]string{"X-Api-Key": "secret"},
+		SampleRatio:   1,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	_, span := provider.TracerProvider().Tracer("test").Start(ctx, "test.span")
+	span.End()
+
+	// Shutdown flushes the batch
+	if err := provider.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if exports.Load() == 0 {
+		t.Error("No spans were exported")
+	}
+}
+
+func TestTracerProviderDisabled(t *testing.T) {
+	provider, err := NewProvider(context.Background(), Config{EnableMetrics: true})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	_, span := provider.TracerProvider().Tracer("test").Start(context.Background(), "test.span")
+	defer span.End()
+	if span.IsRecording() {
+		t.Error("Span is recording with tracing disabled")
+	}
+}
+
+// newTestProvider creates a provider whose metrics are read with the
+// returned reader instead of being exported
+func newTestProvider(t *testing.T, config Config) (*Provider, *sdkmetric.ManualReader) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	provider := &Provider{
+		workflowLabels: newAllowList(config.MetricWorkflowIDs),
+		clientLabels:   newAllowList(config.MetricHTTPClients),
+	}
+	if err := provider.initMeter(resource.Empty(), reader); err != nil {
+		t.Fatalf("initMeter() error = %v", err)
+	}
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return provider, reader
+}
+
+// collectSeries returns the value of each series of the named metric by
+// its encoded labels: the sum of counters, or the count of histograms
+func collectSeries(t *testing.T, reader *sdkmetric.ManualReader, name string) map[string]int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	series := map[string]int64{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != name {
+				continue
+			}
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					series[dp.Attributes.Encoded(attribute.DefaultEncoder())] = dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					series[dp.Attributes.Encoded(attribute.DefaultEncoder())] = int64(dp.Count)
+				}
+			case metricdata.Histogram[int64]:
+				for _, dp := range data.DataPoints {
+					series[dp.Attributes.Encoded(attribute.DefaultEncoder())] = int64(dp.Count)
+				}
+			}
+		}
+	}
+	return series
+}
+
+func TestMetricLabels(t *testing.T) {
+	provider, reader := newTestProvider(t, Config{
+		MetricWorkflowIDs: []string{"billing"},
+		MetricHTTPClients: []string{"crm"},
+	})
+	ctx := context.Background()
+	obs := NewTelemetryObserver(provider)
+
+	for _, workflowID := range []string{"billing", "billing", "scratch", ""} {
+		obs.OnEvent(ctx, observer.Event{Type: observer.EventWorkflowStart, TenantID: "acme"})
+		obs.OnEvent(ctx, observer.Event{
+			Type:       observer.EventWorkflowEnd,
+			Status:     observer.StatusSuccess,
+			WorkflowID: workflowID,
+			TenantID:   "acme",
+			Metadata:   map[string]interface{}{"nodes_executed": 3},
+		})
+	}
+	obs.OnEvent(ctx, observer.Event{Type: observer.EventWorkflowStart, TenantID: "acme"})
+	obs.OnEvent(ctx, observer.Event{Type: observer.EventNodeSuccess, NodeID: "a", NodeType: types.NodeTypeHTTP})
+	obs.OnEvent(ctx, observer.Event{Type: observer.EventNodeFailure, NodeID: "b", NodeType: types.NodeTypeHTTP})
+
+	metrics := provider.ForTenant("acme")
+	metrics.RecordHTTPCall(ctx, "", 204, time.Millisecond)
+	metrics.RecordHTTPCall(ctx, "crm", 503, time.Millisecond)
+	metrics.RecordHTTPCall(ctx, "payments", 0, time.Millisecond)
+	metrics.RecordLimitExceeded(ctx, "max_http_calls")
+
+	tests := []struct {
+		metric string
+		want   map[string]int64
+	}{
+		{
+			metric: metricWorkflowDuration,
+			want: map[string]int64{
+				"status=success,tenant.id=acme,workflow.id=":        1,
+				"status=success,tenant.id=acme,workflow.id=billing": 2,
+				"status=success,tenant.id=acme,workflow.id=other":   1,
+			},
+		},
+		{
+			metric: metricWorkflowActive,
+			want:   map[string]int64{"tenant.id=acme": 1},
+		},
+		{
+			metric: metricNodeDuration,
+			want: map[string]int64{
+				"node.type=http,status=success": 1,
+				"node.type=http,status=failure": 1,
+			},
+		},
+		{
+			metric: metricHTTPDuration,
+			want: map[string]int64{
+				"http.client=default,http.status_class=2xx,tenant.id=acme": 1,
+				"http.client=crm,http.status_class=5xx,tenant.id=acme":     1,
+				"http.client=other,http.status_class=error,tenant.id=acme": 1,
+			},
+		},
+		{
+			metric: metricLimitsExceeded,
+			want:   map[string]int64{"limit=max_http_calls,tenant.id=acme": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			if got := collectSeries(t, reader, tt.metric); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("series = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("any workflow ID", func(t *testing.T) {
+		if got := newAllowList([]string{"*"}).label("scratch"); got != "scratch" {
+			t.Errorf("label() = %q, want scratch", got)
+		}
+	})
 }
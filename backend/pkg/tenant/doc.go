@@ -0,0 +1,46 @@
+// Package tenant namespaces server resources and enforces per-tenant quotas.
+//
+// # Overview
+//
+// A tenant ID comes from the authenticated caller (see package auth).
+// Callers without one, and every caller when authentication is disabled,
+// belong to the Default tenant. IDs are validated with Validate, so they
+// are always safe to use as directory names.
+//
+// Registry lazily creates one value per tenant, such as a workflow store
+// or an HTTP client registry, so each tenant sees only its own resources:
+//
+//	stores := tenant.NewRegistry(func(id string) (workflow.WorkflowStore, error) {
+//	    return workflow.NewWorkflowRegistry(), nil
+//	})
+//	store, err := stores.Get("acme")
+//
+// # Quotas
+//
+// A Quota caps how much of the server a tenant may use:
+//
+//   - MaxConcurrentExecutions: executions queued or running at once
+//   - MaxExecutionsPerMinute: executions started in any 60 second window
+//   - MaxNodes: nodes in one workflow
+//
+// Quotas come on top of the engine limits in types.Config. Quota.Apply
+// tightens a config's MaxNodes, and a Limiter counts the running and
+// recent executions of each tenant:
+//
+//	limiter := tenant.NewLimiter(quotas)
+//	release, err := limiter.Acquire("acme")
+//	if err != nil {
+//	    // errors.Is(err, tenant.ErrQuotaExceeded)
+//	}
+//	defer release()
+//
+// Quotas can be loaded from a JSON file with a default and per-tenant
+// overrides. A zero field means no limit:
+//
+//	{
+//	  "default": {"max_concurrent_executions": 4, "max_executions_per_minute": 60},
+//	  "tenants": {
+//	    "acme": {"max_concurrent_executions": 16, "max_executions_per_minute": 600, "max_nodes": 500}
+//	  }
+//	}
+package tenant
@@ -0,0 +1,34 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinel errors for tenants and quotas
+var (
+	ErrInvalidTenant    = errors.New("invalid tenant ID")
+	ErrQuotaExceeded    = errors.New("tenant quota exceeded")
+	ErrInvalidQuotaFile = errors.New("invalid quota file")
+)
+
+// QuotaError reports which quota a tenant ran into
+type QuotaError struct {
+	Tenant string
+	Quota  string // "max_concurrent_executions" or "max_executions_per_minute"
+	Limit  int
+
+	// RetryAfter is how long until the quota frees up, when known
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: tenant %q reached %s (%d)", ErrQuotaExceeded, e.Tenant, e.Quota, e.Limit)
+}
+
+// Unwrap allows errors.Is(err, ErrQuotaExceeded)
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
@@ -0,0 +1,184 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+// rateWindow is the window MaxExecutionsPerMinute is counted over
+const rateWindow = time.Minute
+
+// Quota caps a tenant's use of the server. Zero fields mean no limit.
+type Quota struct {
+	MaxConcurrentExecutions int `json:"max_concurrent_executions,omitempty"`
+	MaxExecutionsPerMinute  int `json:"max_executions_per_minute,omitempty"`
+	MaxNodes                int `json:"max_nodes,omitempty"`
+}
+
+// Apply returns config with its limits tightened to the quota. Limits in
+// config that are already stricter are kept.
+func (q Quota) Apply(config types.Config) types.Config {
+	if q.MaxNodes > 0 && (config.MaxNodes <= 0 || q.MaxNodes < config.MaxNodes) {
+		config.MaxNodes = q.MaxNodes
+	}
+	return config
+}
+
+func (q Quota) validate() error {
+	if q.MaxConcurrentExecutions < 0 || q.MaxExecutionsPerMinute < 0 || q.MaxNodes < 0 {
+		return fmt.Errorf("quota limits must not be negative")
+	}
+	return nil
+}
+
+// Quotas holds the default quota and per-tenant overrides. An override
+// replaces the default entirely for its tenant.
+type Quotas struct {
+	Default Quota            `json:"default"`
+	Tenants map[string]Quota `json:"tenants,omitempty"`
+}
+
+// For returns the quota that applies to tenant id
+func (q Quotas) For(id string) Quota {
+	if quota, ok := q.Tenants[id]; ok {
+		return quota
+	}
+	return q.Default
+}
+
+// LoadQuotas reads quotas from a JSON file
+func LoadQuotas(path string) (Quotas, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Quotas{}, fmt.Errorf("%w: %v", ErrInvalidQuotaFile, err)
+	}
+
+	var quotas Quotas
+	if err := json.Unmarshal(data, &quotas); err != nil {
+		return Quotas{}, fmt.Errorf("%w: %s: %v", ErrInvalidQuotaFile, path, err)
+	}
+	if err := quotas.Default.validate(); err != nil {
+		return Quotas{}, fmt.Errorf("%w: default: %v", ErrInvalidQuotaFile, err)
+	}
+	for id, quota := range quotas.Tenants {
+		if err := Validate(id); err != nil {
+			return Quotas{}, fmt.Errorf("%w: %v", ErrInvalidQuotaFile, err)
+		}
+		if err := quota.validate(); err != nil {
+			return Quotas{}, fmt.Errorf("%w: tenant %q: %v", ErrInvalidQuotaFile, id, err)
+		}
+	}
+	return quotas, nil
+}
+
+// Limiter enforces the execution quotas of every tenant
+type Limiter struct {
+	quotas Quotas
+
+	mu    sync.Mutex
+	usage map[string]*usage
+
+	// now is replaced in tests to move the rate window deterministically
+	now func() time.Time
+}
+
+// usage is one tenant's running count and recent start times, oldest
+// first. Guarded by Limiter.mu.
+type usage struct {
+	running int
+	starts  []time.Time
+}
+
+// NewLimiter creates a limiter for quotas
+func NewLimiter(quotas Quotas) *Limiter {
+	return &Limiter{
+		quotas: quotas,
+		usage:  make(map[string]*usage),
+		now:    time.Now,
+	}
+}
+
+// Quota returns the quota that applies to tenant id
+func (l *Limiter) Quota(id string) Quota {
+	return l.quotas.For(id)
+}
+
+// Acquire counts one execution against tenant id. It returns a
+// *QuotaError when the tenant is at its concurrency or rate limit. On
+// success the caller must call release once the execution has finished;
+// extra calls are ignored.
+func (l *Limiter) Acquire(id string) (release func(), err error) {
+	quota := l.quotas.For(id)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, ok := l.usage[id]
+	if !ok {
+		u = &usage{}
+		l.usage[id] = u
+	}
+	u.expire(now)
+
+	if quota.MaxConcurrentExecutions > 0 && u.running >= quota.MaxConcurrentExecutions {
+		return nil, &QuotaError{Tenant: id, Quota: "max_concurrent_executions", Limit: quota.MaxConcurrentExecutions}
+	}
+	if quota.MaxExecutionsPerMinute > 0 && len(u.starts) >= quota.MaxExecutionsPerMinute {
+		return nil, &QuotaError{
+			Tenant:     id,
+			Quota:      "max_executions_per_minute",
+			Limit:      quota.MaxExecutionsPerMinute,
+			RetryAfter: u.starts[0].Add(rateWindow).Sub(now),
+		}
+	}
+
+	u.running++
+	if quota.MaxExecutionsPerMinute > 0 {
+		u.starts = append(u.starts, now)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			u.running--
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// Usage is a tenant's current execution counts
+type Usage struct {
+	Running           int `json:"running"`
+	StartedLastMinute int `json:"started_last_minute"`
+}
+
+// Usage returns the current counts for tenant id. Starts are only tracked
+// while the tenant has a per-minute quota.
+func (l *Limiter) Usage(id string) Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, ok := l.usage[id]
+	if !ok {
+		return Usage{}
+	}
+	u.expire(l.now())
+	return Usage{Running: u.running, StartedLastMinute: len(u.starts)}
+}
+
+// expire drops start times that have left the rate window
+func (u *usage) expire(now time.Time) {
+	cutoff := now.Add(-rateWindow)
+	i := 0
+	for i < len(u.starts) && !u.starts[i].After(cutoff) {
+		i++
+	}
+	u.starts = u.starts[i:]
+}
@@ -0,0 +1,74 @@
+package tenant
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Default is the tenant of callers that carry no tenant ID
+const Default = "default"
+
+// validID allows IDs that are safe as file names and metric labels
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Validate checks that id is a usable tenant ID
+func Validate(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("%w: %q (use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit)", ErrInvalidTenant, id)
+	}
+	return nil
+}
+
+// Registry holds one lazily created value per tenant
+type Registry[T any] struct {
+	create func(id string) (T, error)
+
+	mu    sync.Mutex
+	items map[string]T
+}
+
+// NewRegistry creates a registry that builds each tenant's value with
+// create the first time the tenant is seen
+func NewRegistry[T any](create func(id string) (T, error)) *Registry[T] {
+	return &Registry[T]{
+		create: create,
+		items:  make(map[string]T),
+	}
+}
+
+// Get returns the value for tenant id, creating it if needed. A failed
+// create is not cached, so the next Get tries again.
+func (r *Registry[T]) Get(id string) (T, error) {
+	var zero T
+	if err := Validate(id); err != nil {
+		return zero, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item, ok := r.items[id]; ok {
+		return item, nil
+	}
+	item, err := r.create(id)
+	if err != nil {
+		return zero, fmt.Errorf("tenant %q: %w", id, err)
+	}
+	r.items[id] = item
+	return item, nil
+}
+
+// IDs returns the tenants seen so far, sorted
+func (r *Registry[T]) IDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.items))
+	for id := range r.items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,161 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/types"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"default", true},
+		{"acme-corp_1.eu", true},
+		{"", false},
+		{"..", false},
+		{"../etc", false},
+		{"-leading", false},
+		{"with space", false},
+		{"a/b", false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.id); (err == nil) != tt.valid {
+			t.Errorf("Validate(%q) error = %v, want valid %v", tt.id, err, tt.valid)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	created := 0
+	fail := true
+	registry := NewRegistry(func(id string) (*[]string, error) {
+		if id == "flaky" && fail {
+			return nil, fmt.Errorf("disk full")
+		}
+		created++
+		return &[]string{id}, nil
+	})
+
+	a1, err := registry.Get("a")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	a2, _ := registry.Get("a")
+	b, _ := registry.Get("b")
+	if a1 != a2 || a1 == b || created != 2 {
+		t.Errorf("Expected one value per tenant, created %d", created)
+	}
+
+	if _, err := registry.Get("../a"); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("Get(invalid) error = %v, want ErrInvalidTenant", err)
+	}
+	if _, err := registry.Get("flaky"); err == nil {
+		t.Error("Expected create error")
+	}
+	fail = false
+	if _, err := registry.Get("flaky"); err != nil {
+		t.Errorf("Expected failed create to be retried, got %v", err)
+	}
+	if ids := registry.IDs(); len(ids) != 3 || ids[0] != "a" {
+		t.Errorf("IDs() = %v", ids)
+	}
+}
+
+func TestQuotaApply(t *testing.T) {
+	config := types.DefaultConfig()
+	config.MaxNodes = 100
+
+	if got := (Quota{MaxNodes: 10}).Apply(config).MaxNodes; got != 10 {
+		t.Errorf("Stricter quota: MaxNodes = %d, want 10", got)
+	}
+	if got := (Quota{MaxNodes: 500}).Apply(config).MaxNodes; got != 100 {
+		t.Errorf("Looser quota: MaxNodes = %d, want config limit 100", got)
+	}
+	if got := (Quota{}).Apply(config).MaxNodes; got != 100 {
+		t.Errorf("No quota: MaxNodes = %d, want 100", got)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(Quotas{
+		Default: Quota{MaxConcurrentExecutions: 2},
+		Tenants: map[string]Quota{"acme": {MaxExecutionsPerMinute: 2}},
+	})
+	clock := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return clock }
+
+	// Concurrency: the default tenant may run two at once
+	r1, _ := limiter.Acquire(Default)
+	_, err := limiter.Acquire(Default)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	var quotaErr *QuotaError
+	if _, err := limiter.Acquire(Default); !errors.As(err, &quotaErr) || quotaErr.Quota != "max_concurrent_executions" {
+		t.Fatalf("Acquire(third) error = %v, want concurrency quota error", err)
+	}
+	r1()
+	r1() // extra releases are ignored
+	if _, err := limiter.Acquire(Default); err != nil {
+		t.Errorf("Acquire() after release error = %v", err)
+	}
+	if _, err := limiter.Acquire(Default); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected double release not to free a second slot, got %v", err)
+	}
+
+	// Rate: acme may start two per minute, however short they are
+	for i := 0; i < 2; i++ {
+		release, err := limiter.Acquire("acme")
+		if err != nil {
+			t.Fatalf("Acquire(acme) error = %v", err)
+		}
+		release()
+		clock = clock.Add(10 * time.Second)
+	}
+	_, err = limiter.Acquire("acme")
+	if !errors.As(err, &quotaErr) || quotaErr.Quota != "max_executions_per_minute" || quotaErr.RetryAfter != 40*time.Second {
+		t.Fatalf("Acquire(acme) error = %v, want rate quota error retrying after 40s", err)
+	}
+	if usage := limiter.Usage("acme"); usage.StartedLastMinute != 2 || usage.Running != 0 {
+		t.Errorf("Usage(acme) = %+v", usage)
+	}
+	clock = clock.Add(41 * time.Second)
+	if _, err := limiter.Acquire("acme"); err != nil {
+		t.Errorf("Acquire(acme) after the window error = %v", err)
+	}
+}
+
+func TestLoadQuotas(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "quotas.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	quotas, err := LoadQuotas(write(`{"default": {"max_nodes": 50}, "tenants": {"acme": {"max_concurrent_executions": 8}}}`))
+	if err != nil {
+		t.Fatalf("LoadQuotas() error = %v", err)
+	}
+	if quotas.For("other").MaxNodes != 50 || quotas.For("acme").MaxConcurrentExecutions != 8 || quotas.For("acme").MaxNodes != 0 {
+		t.Errorf("Quotas = %+v", quotas)
+	}
+
+	for _, content := range []string{
+		`{`,
+		`{"default": {"max_nodes": -1}}`,
+		`{"tenants": {"../x": {}}}`,
+	} {
+		if _, err := LoadQuotas(write(content)); !errors.Is(err, ErrInvalidQuotaFile) {
+			t.Errorf("LoadQuotas(%s) error = %v, want ErrInvalidQuotaFile", content, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Package trigger exposes saved workflows as inbound webhooks.
+//
+// # Overview
+//
+// A Trigger maps an unguessable ID, served by the server at
+// /hooks/{id}, to a saved workflow. Each request to the hook is captured
+// as a Request (method, path, headers, query and parsed body) and
+// delivered to the workflow before it runs:
+//
+//	reg, _ := trigger.NewRegistry(trigger.NewMemoryStore())
+//	t, _ := reg.Create("default", trigger.Spec{
+//	    WorkflowID: "wf-1",
+//	    Secret:     "s3cret",
+//	    Response:   &trigger.Response{Node: "reply"},
+//	})
+//
+//	req, _ := trigger.NewRequest(r, body, t.SignatureHeader)
+//	payload, _ = trigger.Deliver(payload, t.Spec, req)
+//
+// # Delivery
+//
+// With DeliverContext (the default) the whole request becomes the context
+// constant named ContextName, so expressions read context.request.body and
+// templates {{const.request.method}}. A context_constant or
+// context_variable of that name in the saved workflow is replaced, which
+// lets the designer run the workflow with a sample request. With
+// DeliverNode only the body is written into the input node named by
+// Spec.Node, and must match its type: text for a text_input, a number for
+// a number node.
+//
+// Static Spec.Inputs are bound to the workflow's declared inputs first,
+// as for execute-by-ID.
+//
+// # Signatures
+//
+// A trigger with a Secret only accepts requests whose SignatureHeader
+// holds the HMAC-SHA256, keyed with the secret, of the TimestampHeader
+// value, a "." and the raw body, as produced by Sign. The timestamp is in
+// Unix seconds and must be within SignatureTolerance of the server's
+// clock, so captured requests cannot be replayed. Secrets are never
+// returned by the API; see Trigger.Redacted.
+//
+// # Responses
+//
+// ModeSync triggers wait for the workflow and answer with the output of
+// Response.Node (or the final output), status and content type. ModeAsync
+// triggers answer 202 Accepted with the execution ID at once.
+package trigger
@@ -0,0 +1,11 @@
+package trigger
+
+import "errors"
+
+// Sentinel errors for triggers
+var (
+	ErrInvalidTrigger   = errors.New("invalid trigger")
+	ErrTriggerNotFound  = errors.New("trigger not found")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidBody      = errors.New("invalid request body")
+)
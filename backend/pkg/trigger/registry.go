@@ -0,0 +1,158 @@
+package trigger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Registry holds every trigger, writing changes through to a Store
+type Registry struct {
+	mu       sync.RWMutex
+	store    Store
+	triggers map[string]*Trigger
+}
+
+// NewRegistry loads the triggers in store. A nil store keeps triggers in
+// memory.
+func NewRegistry(store Store) (*Registry, error) {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	loaded, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{store: store, triggers: make(map[string]*Trigger, len(loaded))}
+	for _, t := range loaded {
+		r.triggers[t.ID] = t
+	}
+	return r, nil
+}
+
+// Create adds a trigger for a tenant's workflow. The caller checks that
+// the workflow exists and accepts the spec's inputs.
+func (r *Registry) Create(tenantID string, spec Spec) (Trigger, error) {
+	if err := spec.Normalize(); err != nil {
+		return Trigger{}, err
+	}
+
+	now := time.Now().UTC()
+	t := &Trigger{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Spec:      spec,
+		HasSecret: spec.Secret != "",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.store.Save(t); err != nil {
+		return Trigger{}, err
+	}
+	r.triggers[t.ID] = t
+	return t.copy(), nil
+}
+
+// Get returns the trigger with the given ID
+func (r *Registry) Get(id string) (Trigger, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.triggers[id]
+	if !ok {
+		return Trigger{}, fmt.Errorf("%w: %s", ErrTriggerNotFound, id)
+	}
+	return t.copy(), nil
+}
+
+// List returns the triggers matching filter, oldest first
+func (r *Registry) List(filter Filter) []Trigger {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	triggers := make([]Trigger, 0, len(r.triggers))
+	for _, t := range r.triggers {
+		if filter.matches(t) {
+			triggers = append(triggers, t.copy())
+		}
+	}
+	sort.Slice(triggers, func(i, k int) bool {
+		if triggers[i].CreatedAt.Equal(triggers[k].CreatedAt) {
+			return triggers[i].ID < triggers[k].ID
+		}
+		return triggers[i].CreatedAt.Before(triggers[k].CreatedAt)
+	})
+	return triggers
+}
+
+// Update replaces a trigger's spec, secret included. Its ID, and so its
+// URL, stays the same.
+func (r *Registry) Update(id string, spec Spec) (Trigger, error) {
+	if err := spec.Normalize(); err != nil {
+		return Trigger{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.triggers[id]
+	if !ok {
+		return Trigger{}, fmt.Errorf("%w: %s", ErrTriggerNotFound, id)
+	}
+	updated := *current
+	updated.Spec = spec
+	updated.HasSecret = spec.Secret != ""
+	updated.UpdatedAt = time.Now().UTC()
+	if err := r.store.Save(&updated); err != nil {
+		return Trigger{}, err
+	}
+	r.triggers[id] = &updated
+	return updated.copy(), nil
+}
+
+// Delete removes a trigger
+func (r *Registry) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.triggers[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrTriggerNotFound, id)
+	}
+	if err := r.store.Delete(id); err != nil {
+		return err
+	}
+	delete(r.triggers, id)
+	return nil
+}
+
+// copy returns a copy of the trigger that shares no mutable state
+func (t *Trigger) copy() Trigger {
+	c := *t
+	c.Methods = append([]string(nil), t.Methods...)
+	if t.Response != nil {
+		response := *t.Response
+		c.Response = &response
+	}
+	if t.Inputs != nil {
+		c.Inputs = make(map[string]interface{}, len(t.Inputs))
+		for k, v := range t.Inputs {
+			c.Inputs[k] = v
+		}
+	}
+	return c
+}
+
+// matches reports whether the trigger passes the filter
+func (f Filter) matches(t *Trigger) bool {
+	if f.TenantID != "" && t.TenantID != f.TenantID {
+		return false
+	}
+	return f.WorkflowID == "" || t.WorkflowID == f.WorkflowID
+}
@@ -0,0 +1,79 @@
+package trigger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err := NewRegistry(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := reg.Create("acme", Spec{WorkflowID: "wf-1", Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.ID == "" || !created.HasSecret || created.Mode != ModeSync || created.Response == nil {
+		t.Errorf("Create() = %+v, want a normalized trigger with a secret", created)
+	}
+	if redacted := created.Redacted(); redacted.Secret != "" || !redacted.HasSecret {
+		t.Errorf("Redacted() = %+v", redacted)
+	}
+	if _, err := reg.Create("acme", Spec{}); !errors.Is(err, ErrInvalidTrigger) {
+		t.Errorf("Create() of an invalid spec error = %v", err)
+	}
+	other, _ := reg.Create("globex", Spec{WorkflowID: "wf-1"})
+
+	// Copies handed out do not change the registry
+	created.Methods[0] = "DELETE"
+	if got, _ := reg.Get(created.ID); !got.Allows("POST") || got.Allows("DELETE") {
+		t.Errorf("Get() methods = %v after changing a copy", got.Methods)
+	}
+
+	if list := reg.List(Filter{TenantID: "acme", WorkflowID: "wf-1"}); len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("List() = %+v, want only %s", list, created.ID)
+	}
+
+	updated, err := reg.Update(created.ID, Spec{WorkflowID: "wf-1", Mode: ModeAsync})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.HasSecret || updated.Mode != ModeAsync || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Update() = %+v", updated)
+	}
+
+	// Triggers survive a restart, and their files are private
+	info, err := os.Stat(filepath.Join(dir, created.ID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		t.Errorf("Trigger file mode = %v, want owner only", perm)
+	}
+	reopened, err := NewRegistry(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.Get(created.ID); err != nil || got.Mode != ModeAsync {
+		t.Errorf("Reopened Get() = %+v, %v", got, err)
+	}
+
+	if err := reg.Delete(other.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := reg.Get(other.ID); !errors.Is(err, ErrTriggerNotFound) {
+		t.Errorf("Get() after Delete() error = %v", err)
+	}
+	if err := reg.Delete(other.ID); !errors.Is(err, ErrTriggerNotFound) {
+		t.Errorf("Second Delete() error = %v", err)
+	}
+}
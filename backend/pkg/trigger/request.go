@@ -0,0 +1,159 @@
+package trigger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hiddenHeaders carry credentials and are never delivered to workflows
+var hiddenHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Request is the part of an incoming HTTP request delivered to a workflow.
+// Repeated headers are joined with ", "; repeated query parameters keep
+// their first value.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Query   map[string]string `json:"query"`
+	Body    interface{}       `json:"body"`
+}
+
+// NewRequest captures r, whose body has already been read into body.
+// JSON bodies are decoded, form bodies become an object of their first
+// values, and anything else is kept as text. Credentials and the
+// signature header are left out.
+func NewRequest(r *http.Request, body []byte, signatureHeader string) (Request, error) {
+	req := Request{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Headers: make(map[string]string, len(r.Header)),
+		Query:   make(map[string]string),
+	}
+
+	hidden := append([]string{http.CanonicalHeaderKey(signatureHeader)}, hiddenHeaders...)
+	for name, values := range r.Header {
+		if !contains(hidden, name) {
+			req.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	for name, values := range r.URL.Query() {
+		req.Query[name] = values[0]
+	}
+
+	parsed, err := parseBody(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		return Request{}, err
+	}
+	req.Body = parsed
+	return req, nil
+}
+
+// Value returns the request as the object delivered to the workflow
+func (req Request) Value() map[string]interface{} {
+	headers := make(map[string]interface{}, len(req.Headers))
+	for name, value := range req.Headers {
+		headers[name] = value
+	}
+	query := make(map[string]interface{}, len(req.Query))
+	for name, value := range req.Query {
+		query[name] = value
+	}
+	return map[string]interface{}{
+		"method":  req.Method,
+		"path":    req.Path,
+		"headers": headers,
+		"query":   query,
+		"body":    req.Body,
+	}
+}
+
+// parseBody decodes a request body by its content type
+func parseBody(contentType string, body []byte) (interface{}, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+		}
+		return v, nil
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+		}
+		form := make(map[string]interface{}, len(values))
+		for name, v := range values {
+			form[name] = v[0]
+		}
+		return form, nil
+	default:
+		return string(body), nil
+	}
+}
+
+// Sign returns the signature of body sent at timestamp (Unix seconds) for
+// secret: "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a
+// "." and the body. The timestamp travels in TimestampHeader.
+func Sign(secret string, timestamp int64, body []byte) string {
+	return "sha256=" + hex.EncodeToString(mac(secret, strconv.FormatInt(timestamp, 10), body))
+}
+
+// Verify checks a signature header value against body and the timestamp
+// header value in constant time. The "sha256=" prefix is optional.
+// Timestamps further than SignatureTolerance from now are rejected, so a
+// captured request cannot be replayed later.
+func Verify(secret, signature, timestamp string, body []byte, now time.Time) error {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	if signature == "" {
+		return fmt.Errorf("%w: signature is missing", ErrInvalidSignature)
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not hex", ErrInvalidSignature)
+	}
+	timestamp = strings.TrimSpace(timestamp)
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: timestamp is missing or not Unix seconds", ErrInvalidSignature)
+	}
+	if !hmac.Equal(got, mac(secret, timestamp, body)) {
+		return fmt.Errorf("%w: signature does not match", ErrInvalidSignature)
+	}
+	if skew := now.Sub(time.Unix(sent, 0)); skew > SignatureTolerance || skew < -SignatureTolerance {
+		return fmt.Errorf("%w: timestamp is outside the %s tolerance", ErrInvalidSignature, SignatureTolerance)
+	}
+	return nil
+}
+
+// mac returns the HMAC-SHA256 of timestamp + "." + body keyed with secret
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package trigger
+
+import "github.com/yesoreyeram/thaiyyal/backend/pkg/filestore"
+
+// Store persists triggers. The registry keeps its own copy of every
+// trigger and writes through to the store on each change, so stores need
+// no querying.
+type Store interface {
+	// Load returns every stored trigger
+	Load() ([]*Trigger, error)
+
+	// Save creates or replaces a trigger
+	Save(t *Trigger) error
+
+	// Delete removes a trigger; deleting a missing one is not an error
+	Delete(id string) error
+}
+
+// MemoryStore keeps triggers until the process exits
+type MemoryStore = filestore.Memory[Trigger]
+
+// FileStore keeps one JSON file per trigger in a directory. Files hold
+// trigger secrets, so only their owner can read them.
+type FileStore = filestore.Store[Trigger]
+
+// storeOptions describes triggers to filestore
+var storeOptions = filestore.Options[Trigger]{
+	Kind:    "trigger",
+	ID:      func(t *Trigger) string { return t.ID },
+	DirMode: 0o700,
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return filestore.NewMemory(storeOptions)
+}
+
+// NewFileStore opens the store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	return filestore.New(dir, storeOptions)
+}
@@ -0,0 +1,229 @@
+package trigger
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+)
+
+// Mode decides how a trigger answers the request that fired it
+type Mode string
+
+const (
+	// ModeSync waits for the workflow and answers with its output
+	ModeSync Mode = "sync"
+
+	// ModeAsync queues the workflow and answers 202 Accepted with the
+	// execution ID
+	ModeAsync Mode = "async"
+)
+
+// Delivery decides how the request reaches the workflow
+type Delivery string
+
+const (
+	// DeliverContext delivers the whole request as the context constant
+	// ContextName
+	DeliverContext Delivery = "context"
+
+	// DeliverNode writes the request body into the input node Spec.Node
+	DeliverNode Delivery = "node"
+)
+
+// ContextName is the context value requests are delivered to
+const ContextName = "request"
+
+// DefaultSignatureHeader carries request signatures unless a trigger
+// names another header
+const DefaultSignatureHeader = "X-Signature-256"
+
+// TimestampHeader carries the Unix time in seconds a signed request was
+// sent at, which its signature covers
+const TimestampHeader = "X-Signature-Timestamp"
+
+// SignatureTolerance is how far a signed request's timestamp may be from
+// the server's clock
+const SignatureTolerance = 5 * time.Minute
+
+// methods are the HTTP methods a trigger may accept
+var methods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// Response maps a sync trigger's workflow result to its HTTP response
+type Response struct {
+	// Node is the node whose output is the response body; empty uses the
+	// workflow's final output
+	Node string `json:"node,omitempty"`
+
+	// Status is the response status (default 200)
+	Status int `json:"status,omitempty"`
+
+	// ContentType is the response content type (default
+	// application/json). For other types a string output is written as is.
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// Spec is the part of a trigger its owner sets
+type Spec struct {
+	// WorkflowID is the saved workflow to run
+	WorkflowID string `json:"workflow_id"`
+
+	// Version pins a workflow version; 0 runs the latest
+	Version int `json:"version,omitempty"`
+
+	// Methods are the accepted HTTP methods (default POST)
+	Methods []string `json:"methods,omitempty"`
+
+	// Mode is how the trigger answers (default sync)
+	Mode Mode `json:"mode,omitempty"`
+
+	// Delivery is how the request reaches the workflow (default context)
+	Delivery Delivery `json:"delivery,omitempty"`
+
+	// Node is the input node receiving the body with DeliverNode
+	Node string `json:"node,omitempty"`
+
+	// Inputs are bound to the workflow's declared inputs on every run
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+
+	// Secret, when set, requires requests signed with it
+	Secret string `json:"secret,omitempty"`
+
+	// SignatureHeader is the header carrying the signature (default
+	// DefaultSignatureHeader)
+	SignatureHeader string `json:"signature_header,omitempty"`
+
+	// Response maps the output of sync triggers
+	Response *Response `json:"response,omitempty"`
+}
+
+// Trigger exposes a saved workflow as a webhook
+type Trigger struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Spec
+
+	// HasSecret reports whether requests must be signed, since Secret is
+	// redacted in API responses
+	HasSecret bool `json:"has_secret"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Filter selects triggers in List. Empty fields match everything.
+type Filter struct {
+	TenantID   string
+	WorkflowID string
+}
+
+// Redacted returns a copy of the trigger without its secret
+func (t Trigger) Redacted() Trigger {
+	t.Secret = ""
+	return t
+}
+
+// Allows reports whether the trigger accepts the HTTP method
+func (t Trigger) Allows(method string) bool {
+	for _, m := range t.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize fills defaults and checks spec. The registry normalizes
+// every spec it stores; callers normalize first to check a spec against
+// its workflow with Deliver.
+func (spec *Spec) Normalize() error {
+	spec.WorkflowID = strings.TrimSpace(spec.WorkflowID)
+	if spec.WorkflowID == "" {
+		return fmt.Errorf("%w: workflow_id is required", ErrInvalidTrigger)
+	}
+	if spec.Version < 0 {
+		return fmt.Errorf("%w: version must be positive", ErrInvalidTrigger)
+	}
+
+	if len(spec.Methods) == 0 {
+		spec.Methods = []string{http.MethodPost}
+	}
+	seen := make(map[string]bool, len(spec.Methods))
+	accepted := spec.Methods[:0]
+	for _, m := range spec.Methods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if !methods[m] {
+			return fmt.Errorf("%w: unsupported method %q", ErrInvalidTrigger, m)
+		}
+		if !seen[m] {
+			seen[m] = true
+			accepted = append(accepted, m)
+		}
+	}
+	spec.Methods = accepted
+
+	switch spec.Mode {
+	case "":
+		spec.Mode = ModeSync
+	case ModeSync, ModeAsync:
+	default:
+		return fmt.Errorf("%w: mode must be %q or %q", ErrInvalidTrigger, ModeSync, ModeAsync)
+	}
+
+	switch spec.Delivery {
+	case "":
+		spec.Delivery = DeliverContext
+	case DeliverContext, DeliverNode:
+	default:
+		return fmt.Errorf("%w: delivery must be %q or %q", ErrInvalidTrigger, DeliverContext, DeliverNode)
+	}
+	spec.Node = strings.TrimSpace(spec.Node)
+	if (spec.Delivery == DeliverNode) != (spec.Node != "") {
+		return fmt.Errorf("%w: node is required with %q delivery and only allowed with it", ErrInvalidTrigger, DeliverNode)
+	}
+
+	spec.SignatureHeader = strings.TrimSpace(spec.SignatureHeader)
+	if spec.SignatureHeader == "" {
+		spec.SignatureHeader = DefaultSignatureHeader
+	}
+	spec.SignatureHeader = http.CanonicalHeaderKey(spec.SignatureHeader)
+
+	if spec.Response == nil {
+		spec.Response = &Response{}
+	}
+	if spec.Response.Status == 0 {
+		spec.Response.Status = http.StatusOK
+	}
+	if spec.Response.Status < 200 || spec.Response.Status > 599 {
+		return fmt.Errorf("%w: response status %d is out of range", ErrInvalidTrigger, spec.Response.Status)
+	}
+	if spec.Response.ContentType == "" {
+		spec.Response.ContentType = "application/json"
+	}
+	if _, _, err := mime.ParseMediaType(spec.Response.ContentType); err != nil {
+		return fmt.Errorf("%w: response content_type: %v", ErrInvalidTrigger, err)
+	}
+	return nil
+}
+
+// Deliver binds a trigger's static inputs and the request to a workflow
+// payload. Input problems are reported as a *params.ValidationError.
+func Deliver(payload []byte, spec Spec, req Request) ([]byte, error) {
+	bound, err := params.Bind(payload, spec.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Delivery == DeliverNode {
+		return params.Apply(bound, map[string]interface{}{"body": req.Body}, map[string]string{"body": spec.Node})
+	}
+	return params.Apply(bound, map[string]interface{}{ContextName: req.Value()}, nil)
+}
@@ -0,0 +1,200 @@
+package trigger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/engine"
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/params"
+)
+
+func TestSpecNormalize(t *testing.T) {
+	spec := Spec{WorkflowID: " wf ", Methods: []string{"post", "GET", "POST"}, Secret: "s", SignatureHeader: "x-hub-signature-256"}
+	if err := spec.Normalize(); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	want := Spec{
+		WorkflowID:      "wf",
+		Methods:         []string{"POST", "GET"},
+		Mode:            ModeSync,
+		Delivery:        DeliverContext,
+		Secret:          "s",
+		SignatureHeader: "X-Hub-Signature-256",
+		Response:        &Response{Status: http.StatusOK, ContentType: "application/json"},
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("Normalize() = %+v, want %+v", spec, want)
+	}
+
+	for _, tt := range []struct {
+		name string
+		spec Spec
+	}{
+		{"missing workflow", Spec{}},
+		{"negative version", Spec{WorkflowID: "wf", Version: -1}},
+		{"unsupported method", Spec{WorkflowID: "wf", Methods: []string{"TRACE"}}},
+		{"unknown mode", Spec{WorkflowID: "wf", Mode: "later"}},
+		{"unknown delivery", Spec{WorkflowID: "wf", Delivery: "email"}},
+		{"node delivery without node", Spec{WorkflowID: "wf", Delivery: DeliverNode}},
+		{"node without node delivery", Spec{WorkflowID: "wf", Node: "n1"}},
+		{"status out of range", Spec{WorkflowID: "wf", Response: &Response{Status: 99}}},
+		{"bad content type", Spec{WorkflowID: "wf", Response: &Response{ContentType: "text/"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Normalize(); !errors.Is(err, ErrInvalidTrigger) {
+				t.Errorf("Normalize() error = %v, want ErrInvalidTrigger", err)
+			}
+		})
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        interface{}
+	}{
+		{"json", "application/json; charset=utf-8", `{"amount": 5}`, map[string]interface{}{"amount": 5.0}},
+		{"json suffix", "application/vnd.api+json", `[1]`, []interface{}{1.0}},
+		{"form", "application/x-www-form-urlencoded", "a=1&a=2&b=x", map[string]interface{}{"a": "1", "b": "x"}},
+		{"text", "text/plain", "hello", "hello"},
+		{"empty", "application/json", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/hooks/abc?x=1&x=2", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			r.Header.Add("X-Tag", "a")
+			r.Header.Add("X-Tag", "b")
+			r.Header.Set("Authorization", "Bearer token")
+			r.Header.Set("X-Signature-256", "sha256=00")
+
+			req, err := NewRequest(r, []byte(tt.body), DefaultSignatureHeader)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(req.Body, tt.want) {
+				t.Errorf("Body = %#v, want %#v", req.Body, tt.want)
+			}
+			if req.Method != http.MethodPost || req.Path != "/hooks/abc" || req.Query["x"] != "1" || req.Headers["X-Tag"] != "a, b" {
+				t.Errorf("Request = %+v", req)
+			}
+			if _, ok := req.Headers["Authorization"]; ok {
+				t.Error("Authorization header was delivered")
+			}
+			if _, ok := req.Headers["X-Signature-256"]; ok {
+				t.Error("Signature header was delivered")
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/hooks/abc", nil)
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := NewRequest(r, []byte(`{"broken`), DefaultSignatureHeader); !errors.Is(err, ErrInvalidBody) {
+		t.Errorf("NewRequest() error = %v, want ErrInvalidBody", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event": "push"}`)
+	now := time.Unix(1700000000, 0)
+	sent := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("s3cret", now.Unix(), body)
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Fatalf("Sign() = %q, want a sha256= prefix", signature)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		signature string
+		timestamp string
+		at        time.Time
+		valid     bool
+	}{
+		{"valid", signature, sent, now, true},
+		{"without prefix", strings.TrimPrefix(signature, "sha256="), sent, now, true},
+		{"within tolerance", signature, sent, now.Add(SignatureTolerance), true},
+		{"clock behind", signature, sent, now.Add(-time.Minute), true},
+		{"missing", "", sent, now, false},
+		{"not hex", "sha256=zz", sent, now, false},
+		{"wrong secret", Sign("other", now.Unix(), body), sent, now, false},
+		{"missing timestamp", signature, "", now, false},
+		{"other timestamp", signature, strconv.FormatInt(now.Unix()+1, 10), now, false},
+		{"replayed later", signature, sent, now.Add(SignatureTolerance + time.Second), false},
+		{"from the future", signature, sent, now.Add(-SignatureTolerance - time.Second), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify("s3cret", tt.signature, tt.timestamp, body, tt.at)
+			if tt.valid != (err == nil) || (err != nil && !errors.Is(err, ErrInvalidSignature)) {
+				t.Errorf("Verify() error = %v, want valid = %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	payload := []byte(`{
+		"inputs": [{"name": "greeting", "type": "string", "required": true}],
+		"nodes": [
+			{"id": "body", "data": {"text": ""}},
+			{"id": "greeting", "type": "expression", "data": {"expression": "context.greeting"}},
+			{"id": "name", "type": "expression", "data": {"expression": "context.request.body.name"}}
+		],
+		"edges": [{"source": "body", "target": "greeting"}, {"source": "body", "target": "name"}]
+	}`)
+	req := Request{Method: http.MethodPost, Body: map[string]interface{}{"name": "Ada"}}
+
+	run := func(t *testing.T, payload []byte) map[string]interface{} {
+		t.Helper()
+		eng, err := engine.New(payload)
+		if err != nil {
+			t.Fatalf("engine.New() error = %v", err)
+		}
+		result, err := eng.Execute()
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		return result.NodeResults
+	}
+
+	spec := Spec{WorkflowID: "wf", Inputs: map[string]interface{}{"greeting": "Hello"}}
+	if err := spec.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	delivered, err := Deliver(payload, spec, req)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	results := run(t, delivered)
+	if results["greeting"] != "Hello" || results["name"] != "Ada" {
+		t.Errorf("greeting = %v, name = %v; want the static input and the request body", results["greeting"], results["name"])
+	}
+
+	// Node delivery writes the body into the input node
+	spec = Spec{WorkflowID: "wf", Delivery: DeliverNode, Node: "body", Inputs: map[string]interface{}{"greeting": "Hi"}}
+	if err := spec.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	delivered, err = Deliver(payload, spec, Request{Body: "raw text"})
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if got := run(t, delivered)["body"]; got != "raw text" {
+		t.Errorf("body = %v, want the request body", got)
+	}
+	if _, err := Deliver(payload, spec, req); !errors.Is(err, params.ErrInvalidInputs) {
+		t.Errorf("Deliver() of an object to a text node error = %v, want ErrInvalidInputs", err)
+	}
+
+	spec.Inputs = nil
+	if _, err := Deliver(payload, spec, Request{}); !errors.Is(err, params.ErrInvalidInputs) {
+		t.Errorf("Deliver() without required input error = %v, want ErrInvalidInputs", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"reflect"
 	"time"
+
+	"github.com/yesoreyeram/thaiyyal/backend/pkg/config"
 )
 
 // generateExecutionID creates a unique execution identifier.
@@ -67,19 +69,33 @@ func DefaultConfig() Config {
 		MaxCacheSize:    1000,
 
 		// Resource limits - Reasonable for production
-		MaxInputSize:      10 * 1024 * 1024, // 10MB
-		MaxPayloadSize:    1 * 1024 * 1024,  // 1MB
-		MaxNodes:          1000,
-		MaxEdges:          10000,
-		MaxNodeExecutions: 10000,       // Limit total node executions including loop iterations
-		MaxStringLength:   1024 * 1024, // 1MB max string length
-		MaxArrayLength:    10000,       // 10k elements max in arrays
-		MaxVariables:      1000,        // Max 1000 variables in workflow state
-		MaxContextDepth:   32,          // Max 32 levels of nesting
+		MaxInputSize:        10 * 1024 * 1024, // 10MB
+		MaxPayloadSize:      1 * 1024 * 1024,  // 1MB
+		MaxNodes:            1000,
+		MaxEdges:            10000,
+		MaxNodeExecutions:   10000,       // Limit total node executions including loop iterations
+		MaxStringLength:     1024 * 1024, // 1MB max string length
+		MaxArrayLength:      10000,       // 10k elements max in arrays
+		MaxVariables:        1000,        // Max 1000 variables in workflow state
+		MaxContextDepth:     32,          // Max 32 levels of nesting
+		MaxSubWorkflowDepth: 8,           // Max 8 levels of nested sub_workflow nodes
 
 		// Retry configuration
 		DefaultMaxAttempts: 3,
 		DefaultBackoff:     1 * time.Second,
+
+		// Expression evaluation budget - per node execution
+		MaxExpressionSteps:      1000000,
+		MaxExpressionDepth:      256,
+		MaxExpressionOutputSize: 100000,
+
+		// Expression semantics - missing references evaluate to null
+		StrictExpressions: false,
+
+		// Numeric configuration
+		NumericMode:     config.NumericModeFloat,
+		DecimalScale:    16,
+		DecimalRounding: "half_even",
 	}
 }
 
@@ -168,15 +184,16 @@ func ZeroTrustConfig() Config {
 		MaxCacheSize:    100,             // Small cache
 
 		// Resource limits - Minimal
-		MaxInputSize:      1 * 1024 * 1024, // 1MB input
-		MaxPayloadSize:    512 * 1024,      // 512KB payload
-		MaxNodes:          50,              // Few nodes
-		MaxEdges:          200,             // Few edges
-		MaxNodeExecutions: 500,             // Limited executions
-		MaxStringLength:   50 * 1024,       // 50KB strings
-		MaxArrayLength:    500,             // Small arrays
-		MaxVariables:      50,              // Few variables
-		MaxContextDepth:   10,              // Shallow nesting
+		MaxInputSize:        1 * 1024 * 1024, // 1MB input
+		MaxPayloadSize:      512 * 1024,      // 512KB payload
+		MaxNodes:            50,              // Few nodes
+		MaxEdges:            200,             // Few edges
+		MaxNodeExecutions:   500,             // Limited executions
+		MaxStringLength:     50 * 1024,       // 50KB strings
+		MaxArrayLength:      500,             // Small arrays
+		MaxVariables:        50,              // Few variables
+		MaxContextDepth:     10,              // Shallow nesting
+		MaxSubWorkflowDepth: 2,               // Shallow workflow nesting
 
 		// Retry configuration - Minimal
 		DefaultMaxAttempts: 1, // No retries
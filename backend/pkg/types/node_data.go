@@ -78,6 +78,7 @@ func (d BooleanInputData) Validate() error {
 type DateInputData struct {
 	CommonData
 	DateValue *string `json:"date_value,omitempty"` // YYYY-MM-DD format
+	Timezone  *string `json:"timezone,omitempty"`   // IANA zone to interpret the date in (defaults to Config.DefaultTimezone)
 }
 
 func (d DateInputData) Validate() error {
@@ -91,6 +92,7 @@ func (d DateInputData) Validate() error {
 type DateTimeInputData struct {
 	CommonData
 	DateTimeValue *string `json:"datetime_value,omitempty"` // ISO 8601 format
+	Timezone      *string `json:"timezone,omitempty"`       // IANA zone for zone-less values (defaults to Config.DefaultTimezone)
 }
 
 func (d DateTimeInputData) Validate() error {
@@ -439,13 +441,15 @@ func (d VariableData) Validate() error {
 // ExtractData contains data for extract nodes
 type ExtractData struct {
 	CommonData
-	Field  *string  `json:"field,omitempty"`  // Single field path
-	Fields []string `json:"fields,omitempty"` // Multiple field paths
+	Field   *string           `json:"field,omitempty"`   // Single field path
+	Fields  []string          `json:"fields,omitempty"`  // Multiple field paths
+	Query   *string           `json:"query,omitempty"`   // JSONPath / JMESPath query, e.g. "$.orders[?(@.total > 100)].id"
+	Queries map[string]string `json:"queries,omitempty"` // Named queries; the result maps each name to its query result
 }
 
 func (d ExtractData) Validate() error {
-	if d.Field == nil && len(d.Fields) == 0 {
-		return ErrMissingRequiredField("field or fields")
+	if d.Field == nil && len(d.Fields) == 0 && d.Query == nil && len(d.Queries) == 0 {
+		return ErrMissingRequiredField("field, fields, query or queries")
 	}
 	return nil
 }
@@ -807,6 +811,29 @@ func (d RendererData) Validate() error {
 	return nil // Mode is optional
 }
 
+// ============================================================================
+// Workflow Composition Node Data Types
+// ============================================================================
+
+// SubWorkflowData contains data for sub_workflow nodes
+type SubWorkflowData struct {
+	CommonData
+	WorkflowID *string                `json:"workflow_id,omitempty"`
+	Version    *int                   `json:"version,omitempty"`    // Pinned version (default: latest)
+	Inputs     map[string]interface{} `json:"inputs,omitempty"`     // Static values for the child's inputs
+	InputName  *string                `json:"input_name,omitempty"` // Child input receiving the node's first input
+}
+
+func (d SubWorkflowData) Validate() error {
+	if d.WorkflowID == nil || *d.WorkflowID == "" {
+		return ErrMissingRequiredField("workflow_id")
+	}
+	if d.Version != nil && *d.Version < 0 {
+		return fmt.Errorf("version must not be negative")
+	}
+	return nil
+}
+
 // ============================================================================
 // Custom Executor Data Type
 // ============================================================================
@@ -436,3 +436,11 @@ func AsCustomExecutorData(data NodeDataInterface) (*CustomExecutorData, error) {
 	}
 	return nil, fmt.Errorf("expected CustomExecutorData, got %T", data)
 }
+
+// AsSubWorkflowData converts NodeDataInterface to SubWorkflowData with type checking
+func AsSubWorkflowData(data NodeDataInterface) (*SubWorkflowData, error) {
+	if d, ok := data.(SubWorkflowData); ok {
+		return &d, nil
+	}
+	return nil, fmt.Errorf("expected SubWorkflowData, got %T", data)
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 )
 
 // UnmarshalJSON implements custom JSON unmarshaling for Node with type-safe decoding
@@ -120,6 +121,12 @@ func inferTypeFromRawData(rawData json.RawMessage) NodeType {
 	if _, hasFields := data["fields"]; hasFields {
 		return NodeTypeExtract
 	}
+	if _, hasQuery := data["query"]; hasQuery {
+		return NodeTypeExtract
+	}
+	if _, hasQueries := data["queries"]; hasQueries {
+		return NodeTypeExtract
+	}
 	if _, hasTransformType := data["transform_type"]; hasTransformType {
 		return NodeTypeTransform
 	}
@@ -180,403 +187,108 @@ func inferTypeFromRawData(rawData json.RawMessage) NodeType {
 	return ""
 }
 
-// unmarshalNodeData decodes the JSON data into the appropriate NodeData type
-func unmarshalNodeData(nodeType NodeType, data json.RawMessage) (NodeDataInterface, error) {
-	switch nodeType {
+// nodeDataTypes maps every built-in node type to the Go type its data
+// decodes into. Other types decode into CustomExecutorData.
+var nodeDataTypes = map[NodeType]NodeDataInterface{
 	// Basic Input Nodes
-	case NodeTypeNumber:
-		var d NumberData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeTextInput:
-		var d TextInputData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeBooleanInput:
-		var d BooleanInputData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeDateInput:
-		var d DateInputData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeDateTimeInput:
-		var d DateTimeInputData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeNumber:        NumberData{},
+	NodeTypeTextInput:     TextInputData{},
+	NodeTypeBooleanInput:  BooleanInputData{},
+	NodeTypeDateInput:     DateInputData{},
+	NodeTypeDateTimeInput: DateTimeInputData{},
 	// Operation Nodes
-	case NodeTypeOperation:
-		var d OperationData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeTextOperation:
-		var d TextOperationData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeHTTP:
-		var d HTTPData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeExpression:
-		var d ExpressionData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeOperation:     OperationData{},
+	NodeTypeTextOperation: TextOperationData{},
+	NodeTypeHTTP:          HTTPData{},
+	NodeTypeExpression:    ExpressionData{},
 	// Control Flow Nodes
-	case NodeTypeCondition:
-		var d ConditionData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeForEach:
-		var d ForEachData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeWhileLoop:
-		var d WhileLoopData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeFilter:
-		var d FilterData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeMap:
-		var d MapData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeReduce:
-		var d ReduceData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeCondition: ConditionData{},
+	NodeTypeForEach:   ForEachData{},
+	NodeTypeWhileLoop: WhileLoopData{},
+	NodeTypeFilter:    FilterData{},
+	NodeTypeMap:       MapData{},
+	NodeTypeReduce:    ReduceData{},
 	// Array Processing Nodes
-	case NodeTypeSlice:
-		var d SliceData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeSort:
-		var d SortData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeFind:
-		var d FindData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeFlatMap:
-		var d FlatMapData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeGroupBy:
-		var d GroupByData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeUnique:
-		var d UniqueData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeChunk:
-		var d ChunkData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeReverse:
-		var d ReverseData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypePartition:
-		var d PartitionData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeZip:
-		var d ZipData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeSample:
-		var d SampleData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeRange:
-		var d RangeData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeCompact:
-		var d CompactData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeTranspose:
-		var d TransposeData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeSlice:     SliceData{},
+	NodeTypeSort:      SortData{},
+	NodeTypeFind:      FindData{},
+	NodeTypeFlatMap:   FlatMapData{},
+	NodeTypeGroupBy:   GroupByData{},
+	NodeTypeUnique:    UniqueData{},
+	NodeTypeChunk:     ChunkData{},
+	NodeTypeReverse:   ReverseData{},
+	NodeTypePartition: PartitionData{},
+	NodeTypeZip:       ZipData{},
+	NodeTypeSample:    SampleData{},
+	NodeTypeRange:     RangeData{},
+	NodeTypeCompact:   CompactData{},
+	NodeTypeTranspose: TransposeData{},
 	// State & Memory Nodes
-	case NodeTypeVariable:
-		var d VariableData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeExtract:
-		var d ExtractData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeTransform:
-		var d TransformData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeAccumulator:
-		var d AccumulatorData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeCounter:
-		var d CounterData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeParse:
-		var d ParseData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeFormat:
-		var d FormatData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeVariable:    VariableData{},
+	NodeTypeExtract:     ExtractData{},
+	NodeTypeTransform:   TransformData{},
+	NodeTypeAccumulator: AccumulatorData{},
+	NodeTypeCounter:     CounterData{},
+	NodeTypeParse:       ParseData{},
+	NodeTypeFormat:      FormatData{},
 	// Advanced Control Flow Nodes
-	case NodeTypeSwitch:
-		var d SwitchData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeParallel:
-		var d ParallelData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeJoin:
-		var d JoinData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeSplit:
-		var d SplitData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeDelay:
-		var d DelayData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeCache:
-		var d CacheData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeSwitch:   SwitchData{},
+	NodeTypeParallel: ParallelData{},
+	NodeTypeJoin:     JoinData{},
+	NodeTypeSplit:    SplitData{},
+	NodeTypeDelay:    DelayData{},
+	NodeTypeCache:    CacheData{},
 	// Error Handling & Resilience Nodes
-	case NodeTypeRetry:
-		var d RetryData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeTryCatch:
-		var d TryCatchData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeTimeout:
-		var d TimeoutData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeRetry:    RetryData{},
+	NodeTypeTryCatch: TryCatchData{},
+	NodeTypeTimeout:  TimeoutData{},
 	// Advanced Nodes
-	case NodeTypeRateLimiter:
-		var d RateLimiterData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeThrottle:
-		var d ThrottleData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeSchemaValidator:
-		var d SchemaValidatorData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypePaginator:
-		var d PaginatorData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeRateLimiter:     RateLimiterData{},
+	NodeTypeThrottle:        ThrottleData{},
+	NodeTypeSchemaValidator: SchemaValidatorData{},
+	NodeTypePaginator:       PaginatorData{},
 	// Context Nodes
-	case NodeTypeContextVariable:
-		var d ContextVariableData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
-	case NodeTypeContextConstant:
-		var d ContextConstantData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
-
+	NodeTypeContextVariable: ContextVariableData{},
+	NodeTypeContextConstant: ContextConstantData{},
 	// Visualization Nodes
-	case NodeTypeVisualization:
-		var d VisualizationData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
+	NodeTypeVisualization: VisualizationData{},
+	NodeTypeRenderer:      RendererData{},
+	// Workflow Composition Nodes
+	NodeTypeSubWorkflow: SubWorkflowData{},
+}
 
-	case NodeTypeRenderer:
-		var d RendererData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		return d, d.Validate()
+// NodeDataTypes returns the zero data value of every built-in node type,
+// keyed by node type
+func NodeDataTypes() map[NodeType]NodeDataInterface {
+	all := make(map[NodeType]NodeDataInterface, len(nodeDataTypes))
+	for nodeType, data := range nodeDataTypes {
+		all[nodeType] = data
+	}
+	return all
+}
 
-	default:
-		// Unknown node type - could be a custom executor
-		// Unmarshal into generic CustomExecutorData
-		var d CustomExecutorData
-		if err := json.Unmarshal(data, &d); err != nil {
-			return nil, err
-		}
-		// Also store the raw fields for custom executors
-		var fields map[string]interface{}
-		if err := json.Unmarshal(data, &fields); err != nil {
+// unmarshalNodeData decodes the JSON data into the appropriate NodeData type
+func unmarshalNodeData(nodeType NodeType, data json.RawMessage) (NodeDataInterface, error) {
+	if zero, ok := nodeDataTypes[nodeType]; ok {
+		ptr := reflect.New(reflect.TypeOf(zero))
+		if err := json.Unmarshal(data, ptr.Interface()); err != nil {
 			return nil, err
 		}
-		d.Fields = fields
+		d := ptr.Elem().Interface().(NodeDataInterface)
 		return d, d.Validate()
 	}
+
+	// Unknown node type - could be a custom executor
+	// Unmarshal into generic CustomExecutorData
+	var d CustomExecutorData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	// Also store the raw fields for custom executors
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	d.Fields = fields
+	return d, d.Validate()
 }
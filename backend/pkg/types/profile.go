@@ -0,0 +1,87 @@
+package types
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Profile summarizes where an execution's time went. The engine builds it
+// when profiling is enabled. Times are in milliseconds; node start times
+// are offsets from the start of the execution.
+type Profile struct {
+	TotalMS        float64       `json:"total_ms"`
+	HTTPWaitMS     float64       `json:"http_wait_ms"`         // Time spent waiting on HTTP responses, across all nodes
+	CriticalPath   []string      `json:"critical_path"`        // Slowest chain of dependent top-level nodes
+	CriticalPathMS float64       `json:"critical_path_ms"`     // Sum of the critical path's node times
+	Nodes          []NodeProfile `json:"nodes"`                // In execution order
+	Incomplete     bool          `json:"incomplete,omitempty"` // A node was still running when the profile was taken
+}
+
+// NodeProfile is the timing of one node execution. Nodes of sub-workflows
+// have IDs prefixed with the sub_workflow node running them, as in
+// observer events, and name that node as their parent.
+type NodeProfile struct {
+	NodeID     string   `json:"node_id"`
+	NodeType   NodeType `json:"node_type"`
+	Parent     string   `json:"parent,omitempty"`
+	StartMS    float64  `json:"start_ms"`
+	DurationMS float64  `json:"duration_ms"`
+	HTTPCalls  int      `json:"http_calls,omitempty"`
+	HTTPWaitMS float64  `json:"http_wait_ms,omitempty"`
+	Iterations int      `json:"iterations,omitempty"` // Items or rounds processed by loop nodes
+	Failed     bool     `json:"failed,omitempty"`
+}
+
+// WriteFolded writes the profile in the folded stack format read by
+// flamegraph.pl, speedscope and similar tools: one line per stack with
+// its self time in microseconds. Stacks start with a frame named root,
+// usually the workflow ID, and nest sub-workflow nodes under their
+// sub_workflow node. HTTP waits are a child frame named "http" of the
+// node making the calls, and time outside of nodes is the root's own.
+func (p *Profile) WriteFolded(w io.Writer, root string) error {
+	if root == "" {
+		root = "workflow"
+	}
+
+	parents := make(map[string]string, len(p.Nodes))
+	childTime := make(map[string]float64, len(p.Nodes))
+	nodeTime := 0.0
+	for _, node := range p.Nodes {
+		parents[node.NodeID] = node.Parent
+		if node.Parent == "" {
+			nodeTime += node.DurationMS
+		} else {
+			childTime[node.Parent] += node.DurationMS
+		}
+	}
+
+	stack := func(nodeID string) string {
+		frames := []string{}
+		for cur := nodeID; cur != ""; cur = parents[cur] {
+			frames = append([]string{foldedFrame(cur)}, frames...)
+		}
+		return foldedFrame(root) + ";" + strings.Join(frames, ";")
+	}
+
+	out := bufio.NewWriter(w)
+	writeLine := func(frames string, ms float64) {
+		if us := int64(ms * 1000); us > 0 {
+			fmt.Fprintf(out, "%s %d\n", frames, us)
+		}
+	}
+	writeLine(foldedFrame(root), p.TotalMS-nodeTime)
+	for _, node := range p.Nodes {
+		frames := stack(node.NodeID)
+		writeLine(frames, node.DurationMS-node.HTTPWaitMS-childTime[node.NodeID])
+		writeLine(frames+";http", node.HTTPWaitMS)
+	}
+	return out.Flush()
+}
+
+// foldedFrame makes name safe to use as a frame of a folded stack, where
+// ";" separates frames and the last space precedes the count
+func foldedFrame(name string) string {
+	return strings.NewReplacer(";", "_", " ", "_", "\n", "_").Replace(name)
+}
@@ -110,6 +110,8 @@ const (
 	NodeTypeContextConstant NodeType = "context_constant" // Define an immutable constant
 	// Visualization nodes
 	NodeTypeRenderer NodeType = "renderer" // Render data in various formats
+
+	NodeTypeSubWorkflow NodeType = "sub_workflow" // Run another saved workflow
 )
 
 // ============================================================================
@@ -118,9 +120,22 @@ const (
 
 // Payload represents the JSON payload from the frontend
 type Payload struct {
-	WorkflowID string `json:"workflow_id,omitempty"` // Optional workflow identifier
-	Nodes      []Node `json:"nodes"`
-	Edges      []Edge `json:"edges"`
+	WorkflowID string            `json:"workflow_id,omitempty"` // Optional workflow identifier
+	Nodes      []Node            `json:"nodes"`
+	Edges      []Edge            `json:"edges"`
+	Inputs     []InputDefinition `json:"inputs,omitempty"` // Optional declared inputs for parameterized execution
+}
+
+// InputDefinition declares a named input of a saved workflow. Values supplied
+// at execution time replace the matching context_constant/context_variable
+// values, or the value of the input node named by Node.
+type InputDefinition struct {
+	Name        string      `json:"name"`                  // Input name, also the context value it binds to
+	Type        string      `json:"type"`                  // Type: "string", "number", "boolean", "object", "array" or "any"
+	Required    bool        `json:"required,omitempty"`    // Execution fails if no value and no default
+	Default     interface{} `json:"default,omitempty"`     // Used when the caller omits the input
+	Description string      `json:"description,omitempty"` // Human-readable description
+	Node        string      `json:"node,omitempty"`        // Optional input node ID (number, text_input, boolean_input, date_input, datetime_input)
 }
 
 // Node represents a workflow node with type-safe data
@@ -141,7 +156,7 @@ type SwitchCase struct {
 type ContextVariableValue struct {
 	Name  string      `json:"name"`  // Variable name
 	Value interface{} `json:"value"` // The actual value
-	Type  string      `json:"type"`  // Type: "string", "number", "boolean", "time_string", "epoch_second", "epoch_ms", "null"
+	Type  string      `json:"type"`  // Type: "string", "number", "boolean", "object", "array", "time_string", "epoch_second", "epoch_ms", "null"
 }
 
 // Edge represents a connection between nodes
@@ -162,6 +177,7 @@ type Result struct {
 	NodeResults map[string]interface{} `json:"node_results"`
 	FinalOutput interface{}            `json:"final_output"`
 	Errors      []string               `json:"errors,omitempty"`
+	Profile     *Profile               `json:"profile,omitempty"` // Set when the engine profiles the execution
 }
 
 // CacheEntry represents a cached value with expiration
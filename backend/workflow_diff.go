@@ -0,0 +1,169 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// WorkflowDiff is the structural difference between two workflow
+// definitions, keyed by node ID and by edge.
+type WorkflowDiff struct {
+	FromVersion int         `json:"from_version,omitempty"`
+	ToVersion   int         `json:"to_version,omitempty"`
+	Nodes       ElementDiff `json:"nodes"`
+	Edges       ElementDiff `json:"edges"`
+}
+
+// ElementDiff lists the nodes or edges that differ between two definitions
+type ElementDiff struct {
+	Added   []string        `json:"added"`
+	Removed []string        `json:"removed"`
+	Changed []ElementChange `json:"changed"`
+}
+
+// ElementChange describes a node or edge present in both definitions
+// whose content differs. Fields lists the top-level keys that changed.
+type ElementChange struct {
+	ID     string                 `json:"id"`
+	Fields []string               `json:"fields"`
+	Before map[string]interface{} `json:"before"`
+	After  map[string]interface{} `json:"after"`
+}
+
+// Empty reports whether the two definitions are structurally identical
+func (d *WorkflowDiff) Empty() bool {
+	return d.Nodes.empty() && d.Edges.empty()
+}
+
+func (d ElementDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffVersions compares two versions of a workflow
+func DiffVersions(from, to *WorkflowVersion) (*WorkflowDiff, error) {
+	diff, err := DiffWorkflows(from.Data, to.Data)
+	if err != nil {
+		return nil, err
+	}
+	diff.FromVersion = from.Version
+	diff.ToVersion = to.Version
+	return diff, nil
+}
+
+// DiffWorkflows compares two workflow definitions. Nodes are matched by
+// ID; edges by ID when they have one, otherwise by source and target.
+func DiffWorkflows(from, to json.RawMessage) (*WorkflowDiff, error) {
+	before, err := parseDiffDefinition(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source definition: %w", err)
+	}
+	after, err := parseDiffDefinition(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target definition: %w", err)
+	}
+
+	return &WorkflowDiff{
+		Nodes: diffElements(keyElements(before.Nodes, nodeKey), keyElements(after.Nodes, nodeKey)),
+		Edges: diffElements(keyElements(before.Edges, edgeKey), keyElements(after.Edges, edgeKey)),
+	}, nil
+}
+
+// diffDefinition is the part of a workflow payload that is compared
+type diffDefinition struct {
+	Nodes []map[string]interface{} `json:"nodes"`
+	Edges []map[string]interface{} `json:"edges"`
+}
+
+func parseDiffDefinition(data json.RawMessage) (*diffDefinition, error) {
+	var def diffDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// keyElements indexes elements by key, numbering repeated keys so that
+// duplicates are still compared one-to-one.
+func keyElements(elements []map[string]interface{}, key func(int, map[string]interface{}) string) map[string]map[string]interface{} {
+	keyed := make(map[string]map[string]interface{}, len(elements))
+	for i, element := range elements {
+		k := key(i, element)
+		for n := 2; keyed[k] != nil; n++ {
+			k = fmt.Sprintf("%s#%d", key(i, element), n)
+		}
+		keyed[k] = element
+	}
+	return keyed
+}
+
+func nodeKey(index int, node map[string]interface{}) string {
+	if id, ok := node["id"].(string); ok && id != "" {
+		return id
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+func edgeKey(_ int, edge map[string]interface{}) string {
+	if id, ok := edge["id"].(string); ok && id != "" {
+		return id
+	}
+	source := fmt.Sprint(edge["source"])
+	if handle, ok := edge["sourceHandle"].(string); ok && handle != "" {
+		source += ":" + handle
+	}
+	target := fmt.Sprint(edge["target"])
+	if handle, ok := edge["targetHandle"].(string); ok && handle != "" {
+		target += ":" + handle
+	}
+	return source + "->" + target
+}
+
+// diffElements compares two keyed element sets. Results are sorted by key.
+func diffElements(before, after map[string]map[string]interface{}) ElementDiff {
+	diff := ElementDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []ElementChange{},
+	}
+
+	for key, old := range before {
+		current, ok := after[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, key)
+			continue
+		}
+		if fields := changedFields(old, current); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, ElementChange{ID: key, Fields: fields, Before: old, After: current})
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ID < diff.Changed[j].ID })
+
+	return diff
+}
+
+// changedFields returns the sorted top-level keys whose values differ
+func changedFields(before, after map[string]interface{}) []string {
+	var fields []string
+	for key, value := range before {
+		if other, ok := after[key]; !ok || !reflect.DeepEqual(value, other) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffWorkflows(t *testing.T) {
+	from := json.RawMessage(`{
+		"nodes": [
+			{"id": "1", "data": {"value": 10}},
+			{"id": "2", "data": {"value": 5}},
+			{"id": "3", "data": {"op": "add"}}
+		],
+		"edges": [
+			{"source": "1", "target": "3"},
+			{"source": "2", "target": "3"}
+		]
+	}`)
+	to := json.RawMessage(`{
+		"nodes": [
+			{"id": "1", "data": {"value": 10}},
+			{"id": "3", "data": {"op": "multiply"}, "position": {"x": 1}},
+			{"id": "4", "data": {"value": 7}}
+		],
+		"edges": [
+			{"source": "1", "target": "3"},
+			{"source": "4", "target": "3"}
+		]
+	}`)
+
+	diff, err := DiffWorkflows(from, to)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(diff.Nodes.Added, []string{"4"}) {
+		t.Errorf("Nodes added = %v, want [4]", diff.Nodes.Added)
+	}
+	if !reflect.DeepEqual(diff.Nodes.Removed, []string{"2"}) {
+		t.Errorf("Nodes removed = %v, want [2]", diff.Nodes.Removed)
+	}
+	if len(diff.Nodes.Changed) != 1 || diff.Nodes.Changed[0].ID != "3" {
+		t.Fatalf("Nodes changed = %+v, want node 3", diff.Nodes.Changed)
+	}
+	if !reflect.DeepEqual(diff.Nodes.Changed[0].Fields, []string{"data", "position"}) {
+		t.Errorf("Changed fields = %v, want [data position]", diff.Nodes.Changed[0].Fields)
+	}
+	if !reflect.DeepEqual(diff.Edges.Added, []string{"4->3"}) || !reflect.DeepEqual(diff.Edges.Removed, []string{"2->3"}) {
+		t.Errorf("Edges diff = %+v, want 4->3 added and 2->3 removed", diff.Edges)
+	}
+	if diff.Empty() {
+		t.Error("Expected non-empty diff")
+	}
+
+	same, err := DiffWorkflows(from, from)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !same.Empty() {
+		t.Errorf("Expected empty diff for identical definitions, got %+v", same)
+	}
+
+	if _, err := DiffWorkflows(from, json.RawMessage(`{"nodes": "x"}`)); err == nil {
+		t.Error("Expected error for malformed definition")
+	}
+}
+
+func TestDiffWorkflows_EdgeHandles(t *testing.T) {
+	from := json.RawMessage(`{"nodes": [], "edges": [{"source": "c", "sourceHandle": "true", "target": "t"}]}`)
+	to := json.RawMessage(`{"nodes": [], "edges": [{"source": "c", "sourceHandle": "false", "target": "t"}]}`)
+
+	diff, err := DiffWorkflows(from, to)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(diff.Edges.Added, []string{"c:false->t"}) || !reflect.DeepEqual(diff.Edges.Removed, []string{"c:true->t"}) {
+		t.Errorf("Edges diff = %+v, want handle change reported as remove and add", diff.Edges)
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"sync"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // WorkflowMeta represents a stored workflow with metadata
@@ -15,6 +13,7 @@ type WorkflowMeta struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description,omitempty"`
 	Data        json.RawMessage `json:"data"`
+	Version     int             `json:"version"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
 }
@@ -24,124 +23,145 @@ type WorkflowSummary struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
+	Version     int       `json:"version"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// WorkflowRegistry manages stored workflows by their IDs
+// WorkflowRegistry manages stored workflows by their IDs. It keeps
+// everything in memory and implements WorkflowStore.
 type WorkflowRegistry struct {
-	workflows map[string]*WorkflowMeta
+	workflows map[string]*workflowRecord
 	mu        sync.RWMutex
 }
 
+var _ WorkflowStore = (*WorkflowRegistry)(nil)
+
 // NewWorkflowRegistry creates a new workflow registry
 func NewWorkflowRegistry() *WorkflowRegistry {
 	return &WorkflowRegistry{
-		workflows: make(map[string]*WorkflowMeta),
+		workflows: make(map[string]*workflowRecord),
 	}
 }
 
 // Register adds a workflow to the registry and returns its ID
 func (r *WorkflowRegistry) Register(name, description string, data json.RawMessage) (string, error) {
-	if name == "" {
-		return "", fmt.Errorf("workflow name is required")
+	workflow, err := r.Save("", name, description, data, VersionInfo{})
+	if err != nil {
+		return "", err
 	}
+	return workflow.ID, nil
+}
 
-	if len(data) == 0 {
-		return "", fmt.Errorf("workflow data is required")
+// Update updates an existing workflow by saving a new version
+func (r *WorkflowRegistry) Update(id, name, description string, data json.RawMessage) error {
+	if id == "" {
+		return fmt.Errorf("workflow ID is required")
 	}
 
-	// Validate that data is valid JSON
-	var temp interface{}
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return "", fmt.Errorf("invalid workflow data: %w", err)
+	_, err := r.Save(id, name, description, data, VersionInfo{})
+	return err
+}
+
+// Save creates a workflow when id is empty, otherwise appends a new
+// version to the existing workflow. It returns the saved head version.
+func (r *WorkflowRegistry) Save(id, name, description string, data json.RawMessage, info VersionInfo) (*WorkflowMeta, error) {
+	if err := validateWorkflow(name, data); err != nil {
+		return nil, err
 	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	id := uuid.New().String()
-	now := time.Now()
-
-	workflow := &WorkflowMeta{
-		ID:          id,
-		Name:        name,
-		Description: description,
-		Data:        data,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+	var rec *workflowRecord
+	if id == "" {
+		rec = newWorkflowRecord(name, description, data, info)
+	} else {
+		current, exists := r.workflows[id]
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
+		}
+		rec = current.commit(name, description, data, info)
 	}
 
-	r.workflows[id] = workflow
+	r.workflows[rec.meta.ID] = rec
 
-	return id, nil
+	return rec.meta.clone(), nil
 }
 
-// Update updates an existing workflow
-func (r *WorkflowRegistry) Update(id, name, description string, data json.RawMessage) error {
+// Get retrieves a workflow by ID
+func (r *WorkflowRegistry) Get(id string) (*WorkflowMeta, error) {
 	if id == "" {
-		return fmt.Errorf("workflow ID is required")
+		return nil, fmt.Errorf("workflow ID is required")
 	}
 
-	if name == "" {
-		return fmt.Errorf("workflow name is required")
-	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	if len(data) == 0 {
-		return fmt.Errorf("workflow data is required")
+	rec, exists := r.workflows[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
 	}
 
-	// Validate that data is valid JSON
-	var temp interface{}
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return fmt.Errorf("invalid workflow data: %w", err)
-	}
+	// Return a copy to prevent external modifications
+	return rec.meta.clone(), nil
+}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// Versions returns the version history of a workflow, newest first.
+// Version data is omitted; use GetVersion to fetch it.
+func (r *WorkflowRegistry) Versions(id string) ([]WorkflowVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	workflow, exists := r.workflows[id]
+	rec, exists := r.workflows[id]
 	if !exists {
-		return fmt.Errorf("workflow with ID %s not found", id)
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
 	}
 
-	workflow.Name = name
-	workflow.Description = description
-	workflow.Data = data
-	workflow.UpdatedAt = time.Now()
-
-	return nil
+	return rec.history(), nil
 }
 
-// Get retrieves a workflow by ID
-func (r *WorkflowRegistry) Get(id string) (*WorkflowMeta, error) {
-	if id == "" {
-		return nil, fmt.Errorf("workflow ID is required")
-	}
-
+// GetVersion retrieves a specific version of a workflow
+func (r *WorkflowRegistry) GetVersion(id string, version int) (*WorkflowVersion, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	workflow, exists := r.workflows[id]
+	rec, exists := r.workflows[id]
 	if !exists {
-		return nil, fmt.Errorf("workflow with ID %s not found", id)
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
 	}
 
-	// Return a copy to prevent external modifications
-	workflowCopy := &WorkflowMeta{
-		ID:          workflow.ID,
-		Name:        workflow.Name,
-		Description: workflow.Description,
-		Data:        make(json.RawMessage, len(workflow.Data)),
-		CreatedAt:   workflow.CreatedAt,
-		UpdatedAt:   workflow.UpdatedAt,
+	v, err := rec.version(version)
+	if err != nil {
+		return nil, err
 	}
-	copy(workflowCopy.Data, workflow.Data)
 
-	return workflowCopy, nil
+	return v.clone(), nil
 }
 
-// Unregister removes a workflow by ID
+// Rollback saves a new version whose content is copied from an earlier
+// one. History is never rewritten, so a rollback can itself be undone.
+func (r *WorkflowRegistry) Rollback(id string, version int, info VersionInfo) (*WorkflowMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, exists := r.workflows[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
+	}
+
+	target, err := rec.version(version)
+	if err != nil {
+		return nil, err
+	}
+
+	rec = rec.commit(target.Name, target.Description, target.Data, rollbackInfo(version, info))
+	r.workflows[id] = rec
+
+	return rec.meta.clone(), nil
+}
+
+// Unregister removes a workflow and all its versions by ID
 func (r *WorkflowRegistry) Unregister(id string) error {
 	if id == "" {
 		return fmt.Errorf("workflow ID is required")
@@ -151,7 +171,7 @@ func (r *WorkflowRegistry) Unregister(id string) error {
 	defer r.mu.Unlock()
 
 	if _, exists := r.workflows[id]; !exists {
-		return fmt.Errorf("workflow with ID %s not found", id)
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
 	}
 
 	delete(r.workflows, id)
@@ -166,19 +186,18 @@ func (r *WorkflowRegistry) List() []WorkflowSummary {
 
 	summaries := make([]WorkflowSummary, 0, len(r.workflows))
 
-	for _, workflow := range r.workflows {
-		summaries = append(summaries, WorkflowSummary{
-			ID:          workflow.ID,
-			Name:        workflow.Name,
-			Description: workflow.Description,
-			CreatedAt:   workflow.CreatedAt,
-			UpdatedAt:   workflow.UpdatedAt,
-		})
+	for _, rec := range r.workflows {
+		summaries = append(summaries, rec.meta.summary())
 	}
 
 	return summaries
 }
 
+// Query returns one page of workflow summaries filtered and sorted by opts
+func (r *WorkflowRegistry) Query(opts ListOptions) (WorkflowPage, error) {
+	return queryWorkflows(r.List(), opts)
+}
+
 // Has checks if a workflow exists
 func (r *WorkflowRegistry) Has(id string) bool {
 	r.mu.RLock()
@@ -201,5 +220,27 @@ func (r *WorkflowRegistry) Clear() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.workflows = make(map[string]*WorkflowMeta)
+	r.workflows = make(map[string]*workflowRecord)
+}
+
+// record returns the stored record for id, or nil. Records are never
+// modified in place, so the result is safe to keep.
+func (r *WorkflowRegistry) record(id string) *workflowRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.workflows[id]
+}
+
+// restore puts back a record returned by record, deleting the workflow
+// when rec is nil.
+func (r *WorkflowRegistry) restore(id string, rec *workflowRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rec == nil {
+		delete(r.workflows, id)
+		return
+	}
+	r.workflows[id] = rec
 }
@@ -0,0 +1,246 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sentinel errors returned by workflow stores
+var (
+	// ErrWorkflowNotFound is returned when a workflow ID is not in the store
+	ErrWorkflowNotFound = errors.New("workflow not found")
+
+	// ErrInvalidListOptions is returned when list options cannot be applied
+	ErrInvalidListOptions = errors.New("invalid list options")
+)
+
+// WorkflowStore persists saved workflows. WorkflowRegistry is the in-memory
+// implementation; FileWorkflowStore keeps workflows on disk across restarts.
+type WorkflowStore interface {
+	// Register adds a workflow and returns its generated ID
+	Register(name, description string, data json.RawMessage) (string, error)
+
+	// Update saves a new version of an existing workflow
+	Update(id, name, description string, data json.RawMessage) error
+
+	// Get returns a copy of the workflow with the given ID
+	Get(id string) (*WorkflowMeta, error)
+
+	// Save creates a workflow when id is empty, otherwise appends a new
+	// version to it, and returns the saved head version
+	Save(id, name, description string, data json.RawMessage, info VersionInfo) (*WorkflowMeta, error)
+
+	// Versions returns the version history of a workflow, newest first
+	Versions(id string) ([]WorkflowVersion, error)
+
+	// GetVersion returns a specific version of a workflow
+	GetVersion(id string, version int) (*WorkflowVersion, error)
+
+	// Rollback saves a new version copied from an earlier one
+	Rollback(id string, version int, info VersionInfo) (*WorkflowMeta, error)
+
+	// Unregister removes a workflow and its history by ID
+	Unregister(id string) error
+
+	// List returns all workflow summaries in no particular order
+	List() []WorkflowSummary
+
+	// Query returns one page of workflow summaries
+	Query(opts ListOptions) (WorkflowPage, error)
+
+	// Has checks if a workflow exists
+	Has(id string) bool
+
+	// Count returns the number of stored workflows
+	Count() int
+}
+
+// Sort fields accepted by ListOptions
+const (
+	SortByName      = "name"
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+)
+
+// Sort orders accepted by ListOptions
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// ListOptions controls filtering, sorting and pagination of workflow listings
+type ListOptions struct {
+	// Search keeps workflows whose name contains it (case-insensitive)
+	Search string
+
+	// SortBy is one of SortByName, SortByCreatedAt or SortByUpdatedAt.
+	// Defaults to SortByUpdatedAt.
+	SortBy string
+
+	// Order is SortAscending or SortDescending. Defaults to ascending for
+	// names and descending for timestamps, so the newest workflows come first.
+	Order string
+
+	// Offset is the number of matching workflows to skip
+	Offset int
+
+	// Limit is the maximum number of workflows to return (0 = no limit)
+	Limit int
+}
+
+// WorkflowPage is one page of a workflow listing
+type WorkflowPage struct {
+	Workflows []WorkflowSummary `json:"workflows"`
+	Total     int               `json:"total"`
+	Offset    int               `json:"offset"`
+	Limit     int               `json:"limit"`
+}
+
+// HasMore reports whether more workflows follow this page
+func (p WorkflowPage) HasMore() bool {
+	return p.Offset+len(p.Workflows) < p.Total
+}
+
+// normalize fills in defaults and rejects unknown values
+func (o ListOptions) normalize() (ListOptions, error) {
+	switch o.SortBy {
+	case "":
+		o.SortBy = SortByUpdatedAt
+	case SortByName, SortByCreatedAt, SortByUpdatedAt:
+	default:
+		return o, fmt.Errorf("%w: unknown sort field %q", ErrInvalidListOptions, o.SortBy)
+	}
+
+	switch o.Order {
+	case "":
+		o.Order = SortDescending
+		if o.SortBy == SortByName {
+			o.Order = SortAscending
+		}
+	case SortAscending, SortDescending:
+	default:
+		return o, fmt.Errorf("%w: unknown sort order %q", ErrInvalidListOptions, o.Order)
+	}
+
+	if o.Offset < 0 {
+		return o, fmt.Errorf("%w: offset must not be negative", ErrInvalidListOptions)
+	}
+	if o.Limit < 0 {
+		return o, fmt.Errorf("%w: limit must not be negative", ErrInvalidListOptions)
+	}
+
+	return o, nil
+}
+
+// queryWorkflows filters, sorts and pages summaries. The slice is sorted in
+// place; ties are broken by ID so pages are stable.
+func queryWorkflows(summaries []WorkflowSummary, opts ListOptions) (WorkflowPage, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return WorkflowPage{}, err
+	}
+
+	if search := strings.ToLower(strings.TrimSpace(opts.Search)); search != "" {
+		matched := summaries[:0]
+		for _, s := range summaries {
+			if strings.Contains(strings.ToLower(s.Name), search) {
+				matched = append(matched, s)
+			}
+		}
+		summaries = matched
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		var cmp int
+		switch opts.SortBy {
+		case SortByName:
+			cmp = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case SortByCreatedAt:
+			cmp = a.CreatedAt.Compare(b.CreatedAt)
+		default:
+			cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+		if cmp == 0 {
+			cmp = strings.Compare(a.ID, b.ID)
+		}
+		if opts.Order == SortDescending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	page := WorkflowPage{
+		Total:  len(summaries),
+		Offset: opts.Offset,
+		Limit:  opts.Limit,
+	}
+
+	start := min(opts.Offset, len(summaries))
+	end := len(summaries)
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, end)
+	}
+	page.Workflows = append([]WorkflowSummary{}, summaries[start:end]...)
+
+	return page, nil
+}
+
+// validateWorkflow checks the fields shared by Register and Update
+func validateWorkflow(name string, data json.RawMessage) error {
+	if name == "" {
+		return fmt.Errorf("workflow name is required")
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("workflow data is required")
+	}
+
+	// Validate that data is valid JSON
+	var temp interface{}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return fmt.Errorf("invalid workflow data: %w", err)
+	}
+
+	return nil
+}
+
+// newWorkflowMeta creates a workflow record with a fresh ID
+func newWorkflowMeta(name, description string, data json.RawMessage) *WorkflowMeta {
+	now := time.Now()
+	return &WorkflowMeta{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: description,
+		Data:        data,
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// summary returns the listing view of a workflow
+func (w *WorkflowMeta) summary() WorkflowSummary {
+	return WorkflowSummary{
+		ID:          w.ID,
+		Name:        w.Name,
+		Description: w.Description,
+		Version:     w.Version,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+}
+
+// clone returns a deep copy to prevent external modifications
+func (w *WorkflowMeta) clone() *WorkflowMeta {
+	c := *w
+	c.Data = make(json.RawMessage, len(w.Data))
+	copy(c.Data, w.Data)
+	return &c
+}
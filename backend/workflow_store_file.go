@@ -0,0 +1,289 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// workflowFileExt is the extension of workflow files in a FileWorkflowStore
+const workflowFileExt = ".json"
+
+// workflowFile is the on-disk form of a workflow: the head version plus
+// its full history.
+type workflowFile struct {
+	WorkflowMeta
+	Versions []*WorkflowVersion `json:"versions,omitempty"`
+}
+
+// record converts a loaded file into an index record. Files written
+// before versioning have no history and become version 1.
+func (f *workflowFile) record() *workflowRecord {
+	meta := f.WorkflowMeta
+	if len(f.Versions) == 0 {
+		if meta.Version == 0 {
+			meta.Version = 1
+		}
+		return &workflowRecord{
+			meta:     &meta,
+			versions: []*WorkflowVersion{newWorkflowVersion(&meta, VersionInfo{})},
+		}
+	}
+	return &workflowRecord{meta: &meta, versions: f.Versions}
+}
+
+// FileWorkflowStore keeps each workflow and its version history as a JSON
+// file in a directory so saved workflows survive restarts. Files are
+// written to a temporary file and renamed into place, so a crash never
+// leaves a half-written workflow.
+//
+// All workflows are loaded into an in-memory index when the store is
+// opened; reads are served from the index and writes go to disk first.
+// The directory must not be shared by more than one process.
+type FileWorkflowStore struct {
+	dir   string
+	index *WorkflowRegistry
+	mu    sync.Mutex // serializes writes
+}
+
+var _ WorkflowStore = (*FileWorkflowStore)(nil)
+
+// NewFileWorkflowStore opens (creating if needed) a workflow store in dir
+// and loads the workflows already saved there.
+func NewFileWorkflowStore(dir string) (*FileWorkflowStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("workflow store directory is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create workflow store directory: %w", err)
+	}
+
+	s := &FileWorkflowStore{
+		dir:   dir,
+		index: NewWorkflowRegistry(),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Dir returns the directory backing the store
+func (s *FileWorkflowStore) Dir() string {
+	return s.dir
+}
+
+// Register saves a new workflow and returns its ID
+func (s *FileWorkflowStore) Register(name, description string, data json.RawMessage) (string, error) {
+	workflow, err := s.Save("", name, description, data, VersionInfo{})
+	if err != nil {
+		return "", err
+	}
+	return workflow.ID, nil
+}
+
+// Update saves a new version of an existing workflow
+func (s *FileWorkflowStore) Update(id, name, description string, data json.RawMessage) error {
+	if id == "" {
+		return fmt.Errorf("workflow ID is required")
+	}
+
+	_, err := s.Save(id, name, description, data, VersionInfo{})
+	return err
+}
+
+// Save creates or versions a workflow and writes it to disk
+func (s *FileWorkflowStore) Save(id, name, description string, data json.RawMessage, info VersionInfo) (*WorkflowMeta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.index.record(id)
+	workflow, err := s.index.Save(id, name, description, data, info)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.persist(workflow.ID, previous); err != nil {
+		return nil, err
+	}
+
+	return workflow, nil
+}
+
+// Get retrieves a workflow by ID
+func (s *FileWorkflowStore) Get(id string) (*WorkflowMeta, error) {
+	return s.index.Get(id)
+}
+
+// Versions returns the version history of a workflow, newest first
+func (s *FileWorkflowStore) Versions(id string) ([]WorkflowVersion, error) {
+	return s.index.Versions(id)
+}
+
+// GetVersion retrieves a specific version of a workflow
+func (s *FileWorkflowStore) GetVersion(id string, version int) (*WorkflowVersion, error) {
+	return s.index.GetVersion(id, version)
+}
+
+// Rollback saves a new version copied from an earlier one
+func (s *FileWorkflowStore) Rollback(id string, version int, info VersionInfo) (*WorkflowMeta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.index.record(id)
+	workflow, err := s.index.Rollback(id, version, info)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.persist(id, previous); err != nil {
+		return nil, err
+	}
+
+	return workflow, nil
+}
+
+// Unregister deletes a workflow file by ID
+func (s *FileWorkflowStore) Unregister(id string) error {
+	if id == "" {
+		return fmt.Errorf("workflow ID is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.index.Has(id) {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
+	}
+
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete workflow %s: %w", id, err)
+	}
+
+	return s.index.Unregister(id)
+}
+
+// List returns all workflow summaries
+func (s *FileWorkflowStore) List() []WorkflowSummary {
+	return s.index.List()
+}
+
+// Query returns one page of workflow summaries filtered and sorted by opts
+func (s *FileWorkflowStore) Query(opts ListOptions) (WorkflowPage, error) {
+	return s.index.Query(opts)
+}
+
+// Has checks if a workflow exists
+func (s *FileWorkflowStore) Has(id string) bool {
+	return s.index.Has(id)
+}
+
+// Count returns the number of stored workflows
+func (s *FileWorkflowStore) Count() int {
+	return s.index.Count()
+}
+
+// load reads every workflow file in the directory into the index.
+// Leftover temporary files from an interrupted write are removed.
+func (s *FileWorkflowStore) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read workflow store directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, ".") {
+			_ = os.Remove(filepath.Join(s.dir, name))
+			continue
+		}
+		if filepath.Ext(name) != workflowFileExt {
+			continue
+		}
+
+		raw, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read workflow file %s: %w", name, err)
+		}
+
+		var file workflowFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return fmt.Errorf("failed to parse workflow file %s: %w", name, err)
+		}
+		if file.ID != strings.TrimSuffix(name, workflowFileExt) {
+			return fmt.Errorf("workflow file %s contains ID %q", name, file.ID)
+		}
+
+		s.index.restore(file.ID, file.record())
+	}
+
+	return nil
+}
+
+// persist writes the indexed record for id to disk. If that fails the
+// index is reset to previous so memory and disk stay in step.
+func (s *FileWorkflowStore) persist(id string, previous *workflowRecord) error {
+	if err := s.write(s.index.record(id)); err != nil {
+		s.index.restore(id, previous)
+		return err
+	}
+	return nil
+}
+
+// write atomically replaces the file for a workflow
+func (s *FileWorkflowStore) write(rec *workflowRecord) error {
+	workflow := rec.meta
+	raw, err := json.MarshalIndent(workflowFile{WorkflowMeta: *workflow, Versions: rec.versions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode workflow %s: %w", workflow.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "."+workflow.ID+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary workflow file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(raw)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, s.path(workflow.ID))
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write workflow %s: %w", workflow.ID, err)
+	}
+
+	s.syncDir()
+	return nil
+}
+
+// syncDir flushes the directory entry after a rename. Not every platform
+// supports syncing a directory, so failures are ignored.
+func (s *FileWorkflowStore) syncDir() {
+	dir, err := os.Open(s.dir)
+	if err != nil {
+		return
+	}
+	_ = dir.Sync()
+	_ = dir.Close()
+}
+
+// path returns the file path for a workflow ID
+func (s *FileWorkflowStore) path(id string) string {
+	return filepath.Join(s.dir, id+workflowFileExt)
+}
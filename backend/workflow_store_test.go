@@ -0,0 +1,196 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkflowStore_Query(t *testing.T) {
+	stores := map[string]func(t *testing.T) WorkflowStore{
+		"memory": func(t *testing.T) WorkflowStore { return NewWorkflowRegistry() },
+		"file": func(t *testing.T) WorkflowStore {
+			store, err := NewFileWorkflowStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to open file store: %v", err)
+			}
+			return store
+		},
+	}
+
+	data := json.RawMessage(`{"nodes": [], "edges": []}`)
+
+	for storeName, open := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			store := open(t)
+			for _, name := range []string{"Charlie report", "alpha import", "Bravo report", "delta sync"} {
+				if _, err := store.Register(name, "", data); err != nil {
+					t.Fatalf("Failed to register %q: %v", name, err)
+				}
+			}
+
+			tests := []struct {
+				name      string
+				opts      ListOptions
+				wantNames []string
+				wantTotal int
+				wantMore  bool
+			}{
+				{
+					name:      "sort by name",
+					opts:      ListOptions{SortBy: SortByName},
+					wantNames: []string{"alpha import", "Bravo report", "Charlie report", "delta sync"},
+					wantTotal: 4,
+				},
+				{
+					name:      "sort by name descending",
+					opts:      ListOptions{SortBy: SortByName, Order: SortDescending},
+					wantNames: []string{"delta sync", "Charlie report", "Bravo report", "alpha import"},
+					wantTotal: 4,
+				},
+				{
+					name:      "first page",
+					opts:      ListOptions{SortBy: SortByName, Limit: 3},
+					wantNames: []string{"alpha import", "Bravo report", "Charlie report"},
+					wantTotal: 4,
+					wantMore:  true,
+				},
+				{
+					name:      "second page",
+					opts:      ListOptions{SortBy: SortByName, Offset: 3, Limit: 3},
+					wantNames: []string{"delta sync"},
+					wantTotal: 4,
+				},
+				{
+					name:      "offset past end",
+					opts:      ListOptions{Offset: 10, Limit: 3},
+					wantNames: []string{},
+					wantTotal: 4,
+				},
+				{
+					name:      "case-insensitive search",
+					opts:      ListOptions{Search: "REPORT", SortBy: SortByName},
+					wantNames: []string{"Bravo report", "Charlie report"},
+					wantTotal: 2,
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					page, err := store.Query(tt.opts)
+					if err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+					if page.Total != tt.wantTotal {
+						t.Errorf("Total = %d, want %d", page.Total, tt.wantTotal)
+					}
+					if page.HasMore() != tt.wantMore {
+						t.Errorf("HasMore = %v, want %v", page.HasMore(), tt.wantMore)
+					}
+					if len(page.Workflows) != len(tt.wantNames) {
+						t.Fatalf("Got %d workflows, want %d", len(page.Workflows), len(tt.wantNames))
+					}
+					for i, want := range tt.wantNames {
+						if page.Workflows[i].Name != want {
+							t.Errorf("Workflows[%d] = %q, want %q", i, page.Workflows[i].Name, want)
+						}
+					}
+				})
+			}
+
+			for _, opts := range []ListOptions{{SortBy: "size"}, {Order: "up"}, {Offset: -1}} {
+				if _, err := store.Query(opts); !errors.Is(err, ErrInvalidListOptions) {
+					t.Errorf("Query(%+v) error = %v, want ErrInvalidListOptions", opts, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileWorkflowStore_Persistence(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileWorkflowStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+
+	keepID, err := store.Register("Keep", "kept across restarts", json.RawMessage(`{"nodes": [], "edges": []}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+	dropID, err := store.Register("Drop", "", json.RawMessage(`{"nodes": [], "edges": []}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+
+	updated := json.RawMessage(`{"nodes": [{"id": "1"}], "edges": []}`)
+	if err := store.Update(keepID, "Keep v2", "updated", updated); err != nil {
+		t.Fatalf("Failed to update workflow: %v", err)
+	}
+	if err := store.Unregister(dropID); err != nil {
+		t.Fatalf("Failed to unregister workflow: %v", err)
+	}
+
+	// Simulate a crash in the middle of a write
+	if err := os.WriteFile(filepath.Join(dir, ".interrupted.tmp"), []byte("{"), 0o600); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+
+	reopened, err := NewFileWorkflowStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen file store: %v", err)
+	}
+
+	if reopened.Count() != 1 {
+		t.Fatalf("Count after reopen = %d, want 1", reopened.Count())
+	}
+	if reopened.Has(dropID) {
+		t.Error("Deleted workflow reappeared after reopen")
+	}
+
+	wf, err := reopened.Get(keepID)
+	if err != nil {
+		t.Fatalf("Failed to get workflow after reopen: %v", err)
+	}
+	if wf.Name != "Keep v2" || wf.Description != "updated" {
+		t.Errorf("Got %q/%q, want updated name and description", wf.Name, wf.Description)
+	}
+	var got, want bytes.Buffer
+	if err := json.Compact(&got, wf.Data); err != nil {
+		t.Fatalf("Stored data is not valid JSON: %v", err)
+	}
+	_ = json.Compact(&want, updated)
+	if got.String() != want.String() {
+		t.Errorf("Data = %s, want %s", got.String(), want.String())
+	}
+	if wf.UpdatedAt.Before(wf.CreatedAt) {
+		t.Error("Expected UpdatedAt not to be before CreatedAt")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read store directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != keepID+".json" {
+		t.Errorf("Unexpected files in store directory: %v", entries)
+	}
+
+	if err := reopened.Unregister(dropID); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("Unregister of deleted workflow error = %v, want ErrWorkflowNotFound", err)
+	}
+}
+
+func TestFileWorkflowStore_CorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := NewFileWorkflowStore(dir); err == nil {
+		t.Error("Expected error opening a store with a corrupt workflow file")
+	}
+}
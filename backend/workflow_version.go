@@ -0,0 +1,114 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrVersionNotFound is returned when a workflow has no such version
+var ErrVersionNotFound = errors.New("workflow version not found")
+
+// VersionInfo describes who made a change and why
+type VersionInfo struct {
+	Author  string `json:"author,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// WorkflowVersion is an immutable snapshot of a workflow. Every save
+// appends a version numbered one higher than the last, starting at 1.
+type WorkflowVersion struct {
+	Version     int             `json:"version"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Author      string          `json:"author,omitempty"`
+	Message     string          `json:"message,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// clone returns a deep copy of the version
+func (v *WorkflowVersion) clone() *WorkflowVersion {
+	c := *v
+	c.Data = make(json.RawMessage, len(v.Data))
+	copy(c.Data, v.Data)
+	return &c
+}
+
+// workflowRecord is a workflow together with its version history. The
+// head version always matches meta; neither is modified once stored, so
+// a saved record can be restored if persisting a newer one fails.
+type workflowRecord struct {
+	meta     *WorkflowMeta
+	versions []*WorkflowVersion
+}
+
+// newWorkflowRecord creates a record holding version 1 of a workflow
+func newWorkflowRecord(name, description string, data json.RawMessage, info VersionInfo) *workflowRecord {
+	meta := newWorkflowMeta(name, description, data)
+	return &workflowRecord{
+		meta:     meta,
+		versions: []*WorkflowVersion{newWorkflowVersion(meta, info)},
+	}
+}
+
+// newWorkflowVersion snapshots the current state of meta
+func newWorkflowVersion(meta *WorkflowMeta, info VersionInfo) *WorkflowVersion {
+	return &WorkflowVersion{
+		Version:     meta.Version,
+		Name:        meta.Name,
+		Description: meta.Description,
+		Data:        meta.Data,
+		Author:      info.Author,
+		Message:     info.Message,
+		CreatedAt:   meta.UpdatedAt,
+	}
+}
+
+// commit returns a new record with one more version on top
+func (rec *workflowRecord) commit(name, description string, data json.RawMessage, info VersionInfo) *workflowRecord {
+	meta := *rec.meta
+	meta.Name = name
+	meta.Description = description
+	meta.Data = data
+	meta.Version++
+	meta.UpdatedAt = time.Now()
+
+	versions := make([]*WorkflowVersion, len(rec.versions), len(rec.versions)+1)
+	copy(versions, rec.versions)
+
+	return &workflowRecord{
+		meta:     &meta,
+		versions: append(versions, newWorkflowVersion(&meta, info)),
+	}
+}
+
+// version looks up a version by number
+func (rec *workflowRecord) version(version int) (*WorkflowVersion, error) {
+	for _, v := range rec.versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s version %d", ErrVersionNotFound, rec.meta.ID, version)
+}
+
+// history returns all versions newest first, without their data
+func (rec *workflowRecord) history() []WorkflowVersion {
+	history := make([]WorkflowVersion, 0, len(rec.versions))
+	for i := len(rec.versions) - 1; i >= 0; i-- {
+		v := *rec.versions[i]
+		v.Data = nil
+		history = append(history, v)
+	}
+	return history
+}
+
+// rollbackInfo fills in a default message for a rollback
+func rollbackInfo(version int, info VersionInfo) VersionInfo {
+	if info.Message == "" {
+		info.Message = fmt.Sprintf("Rollback to version %d", version)
+	}
+	return info
+}
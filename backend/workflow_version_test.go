@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkflowStore_Versions(t *testing.T) {
+	stores := map[string]func(t *testing.T) WorkflowStore{
+		"memory": func(t *testing.T) WorkflowStore { return NewWorkflowRegistry() },
+		"file": func(t *testing.T) WorkflowStore {
+			store, err := NewFileWorkflowStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to open file store: %v", err)
+			}
+			return store
+		},
+	}
+
+	v1 := json.RawMessage(`{"nodes": [{"id": "1"}], "edges": []}`)
+	v2 := json.RawMessage(`{"nodes": [{"id": "1"}, {"id": "2"}], "edges": []}`)
+
+	for storeName, open := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			store := open(t)
+
+			created, err := store.Save("", "Pipeline", "first", v1, VersionInfo{Author: "ana", Message: "initial"})
+			if err != nil {
+				t.Fatalf("Failed to save workflow: %v", err)
+			}
+			if created.Version != 1 {
+				t.Errorf("New workflow version = %d, want 1", created.Version)
+			}
+
+			updated, err := store.Save(created.ID, "Pipeline", "second", v2, VersionInfo{Author: "bo", Message: "add node"})
+			if err != nil {
+				t.Fatalf("Failed to save version: %v", err)
+			}
+			if updated.Version != 2 || updated.CreatedAt != created.CreatedAt {
+				t.Errorf("Got version %d created %v, want 2 and original creation time", updated.Version, updated.CreatedAt)
+			}
+
+			rolledBack, err := store.Rollback(created.ID, 1, VersionInfo{Author: "ana"})
+			if err != nil {
+				t.Fatalf("Failed to roll back: %v", err)
+			}
+			if rolledBack.Version != 3 || rolledBack.Description != "first" || string(rolledBack.Data) != string(v1) {
+				t.Errorf("Rollback produced %+v, want version 3 with version 1 content", rolledBack)
+			}
+
+			versions, err := store.Versions(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to list versions: %v", err)
+			}
+			if len(versions) != 3 {
+				t.Fatalf("Got %d versions, want 3", len(versions))
+			}
+			if versions[0].Version != 3 || versions[0].Message != "Rollback to version 1" || versions[0].Data != nil {
+				t.Errorf("Newest version = %+v, want version 3 with default message and no data", versions[0])
+			}
+			if versions[1].Author != "bo" || versions[1].Message != "add node" {
+				t.Errorf("Version 2 = %+v, want author and message preserved", versions[1])
+			}
+
+			old, err := store.GetVersion(created.ID, 2)
+			if err != nil {
+				t.Fatalf("Failed to get version: %v", err)
+			}
+			if string(old.Data) != string(v2) {
+				t.Errorf("Version 2 data = %s, want %s", old.Data, v2)
+			}
+
+			if _, err := store.GetVersion(created.ID, 9); !errors.Is(err, ErrVersionNotFound) {
+				t.Errorf("GetVersion(9) error = %v, want ErrVersionNotFound", err)
+			}
+			if _, err := store.Rollback(created.ID, 9, VersionInfo{}); !errors.Is(err, ErrVersionNotFound) {
+				t.Errorf("Rollback(9) error = %v, want ErrVersionNotFound", err)
+			}
+			if _, err := store.Save("missing", "Pipeline", "", v1, VersionInfo{}); !errors.Is(err, ErrWorkflowNotFound) {
+				t.Errorf("Save to missing ID error = %v, want ErrWorkflowNotFound", err)
+			}
+		})
+	}
+}
+
+func TestFileWorkflowStore_VersionHistoryPersists(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileWorkflowStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+
+	id, err := store.Register("Versioned", "", json.RawMessage(`{"nodes": [], "edges": []}`))
+	if err != nil {
+		t.Fatalf("Failed to register workflow: %v", err)
+	}
+	if _, err := store.Save(id, "Versioned", "", json.RawMessage(`{"nodes": [{"id": "a"}], "edges": []}`), VersionInfo{Message: "second"}); err != nil {
+		t.Fatalf("Failed to save version: %v", err)
+	}
+
+	// A workflow file written before versioning existed
+	legacy := `{"id": "legacy", "name": "Old", "data": {"nodes": [], "edges": []}, "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-02T00:00:00Z"}`
+	if err := os.WriteFile(filepath.Join(dir, "legacy.json"), []byte(legacy), 0o600); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	reopened, err := NewFileWorkflowStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen file store: %v", err)
+	}
+
+	versions, err := reopened.Versions(id)
+	if err != nil || len(versions) != 2 || versions[0].Message != "second" {
+		t.Errorf("Versions after reopen = %+v (err %v), want 2 versions", versions, err)
+	}
+
+	old, err := reopened.Get("legacy")
+	if err != nil {
+		t.Fatalf("Failed to load legacy workflow: %v", err)
+	}
+	if old.Version != 1 {
+		t.Errorf("Legacy workflow version = %d, want 1", old.Version)
+	}
+	if _, err := reopened.GetVersion("legacy", 1); err != nil {
+		t.Errorf("Legacy workflow has no version 1: %v", err)
+	}
+}